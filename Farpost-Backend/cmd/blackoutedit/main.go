@@ -0,0 +1,121 @@
+// blackoutedit changes the dates or the description of a blackout by hand,
+// for example when a utility reports an extension by phone. The change is
+// recorded in the blackout history with the given actor:
+//
+//	go run ./cmd/blackoutedit --config=config/local.yaml --id=b1f4 --end="2019-01-15 22:00:00" --actor=dispatcher
+//
+// Only the flags given are changed, --end="" marks the end as unknown.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+	"vlru-prsch/internal/config"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/lifecycle"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/backend"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+func main() {
+	var id, actor, start, end, description string
+	flag.StringVar(&id, "id", "", "blackout id")
+	flag.StringVar(&actor, "actor", "", "who makes the change, stored in the history")
+	flag.StringVar(&start, "start", "", "new start, YYYY-MM-DD HH:MM:SS")
+	flag.StringVar(&end, "end", "", "new end, YYYY-MM-DD HH:MM:SS, empty when unknown")
+	flag.StringVar(&description, "description", "", "new description")
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if id == "" || actor == "" {
+		log.Error("id and actor flags are required")
+		os.Exit(1)
+	}
+
+	if actor == models.ActorSource || actor == models.ActorSystem {
+		log.Error("actor is reserved", slog.String("actor", actor))
+		os.Exit(1)
+	}
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for name, value := range map[string]string{"start": start, "end": end} {
+		if !set[name] || (name == "end" && value == "") {
+			continue
+		}
+		if _, err := time.Parse(timeLayout, value); err != nil {
+			log.Error("invalid time format", slog.String(name, value))
+			os.Exit(1)
+		}
+	}
+
+	store, err := backend.New(cfg)
+	if err != nil {
+		log.Error("failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	blackout, err := store.GetBlackout(id, models.Filter{})
+	if errors.Is(err, storage.ErrBlackoutNotFound) {
+		log.Error("blackout not found", slog.String("id", id))
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Error("failed to get blackout", slog.String("id", id), sl.Err(err))
+		os.Exit(1)
+	}
+
+	revisions, err := store.GetRevisions(id, models.Filter{})
+	if err != nil {
+		log.Error("failed to get revisions", slog.String("id", id), sl.Err(err))
+		os.Exit(1)
+	}
+
+	var prev *models.Revision
+	if len(revisions) > 0 {
+		prev = &revisions[len(revisions)-1]
+	}
+
+	if set["start"] {
+		blackout.StartDate = start
+	}
+	if set["end"] {
+		blackout.EndDate = end
+	}
+	if set["description"] {
+		blackout.Description = description
+	}
+
+	if blackout.EndDate != "" && blackout.EndDate < blackout.StartDate {
+		log.Error("end is before start", slog.String("start", blackout.StartDate), slog.String("end", blackout.EndDate))
+		os.Exit(1)
+	}
+
+	revision, changed := lifecycle.Revise(prev, blackout, time.Now().Format(timeLayout), actor)
+	if !changed {
+		log.Info("nothing changed", slog.String("id", id))
+		return
+	}
+
+	if err := store.ReviseBlackout(revision); err != nil {
+		log.Error("failed to revise blackout", slog.String("id", id), sl.Err(err))
+		os.Exit(1)
+	}
+
+	for _, change := range lifecycle.Changes(prev, revision) {
+		log.Info("blackout changed",
+			slog.String("id", id),
+			slog.String("field", change.Field),
+			slog.String("from", change.From),
+			slog.String("to", change.To))
+	}
+}
@@ -0,0 +1,116 @@
+// blackoutkind shows and overrides the kind of blackouts: planned, emergency or unknown.
+//
+// Kinds are assigned by the keyword rules in kinds.planned and kinds.emergency.
+// A kind set by hand is kept when the rules change:
+//
+//	go run ./cmd/blackoutkind --config=config/local.yaml --id=b1f4 --kind=emergency
+//
+// --kind=auto hands the blackout back to the rules, --reclassify applies
+// edited rules to every blackout not set by hand:
+//
+//	go run ./cmd/blackoutkind --config=config/local.yaml --id=b1f4 --kind=auto
+//	go run ./cmd/blackoutkind --config=config/local.yaml --reclassify
+package main
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"slices"
+	"vlru-prsch/internal/classify"
+	"vlru-prsch/internal/config"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/backend"
+)
+
+const kindAuto = "auto"
+
+func main() {
+	var id, kind string
+	var reclassify bool
+	flag.StringVar(&id, "id", "", "blackout id")
+	flag.StringVar(&kind, "kind", "", "planned, emergency, unknown or auto to return the blackout to the rules")
+	flag.BoolVar(&reclassify, "reclassify", false, "classify every blackout not set by hand again")
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if id == "" && !reclassify {
+		log.Error("id or reclassify flag is required")
+		os.Exit(1)
+	}
+
+	if kind != "" && kind != kindAuto && !slices.Contains(models.BlackoutKinds, kind) {
+		log.Error("invalid kind, use: planned, emergency, unknown, auto", slog.String("kind", kind))
+		os.Exit(1)
+	}
+
+	store, err := backend.New(cfg)
+	if err != nil {
+		log.Error("failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	classifier := classify.New(log, store, classify.Rules{
+		Planned:   cfg.Kinds.Planned,
+		Emergency: cfg.Kinds.Emergency,
+	}, cfg.Kinds.Interval)
+
+	switch {
+	case reclassify:
+		reset, err := store.ResetBlackoutKinds("")
+		if err != nil {
+			log.Error("failed to reset kinds", sl.Err(err))
+			os.Exit(1)
+		}
+
+		classified, err := classifier.ClassifyNew()
+		if err != nil {
+			log.Error("failed to classify blackouts", sl.Err(err))
+			os.Exit(1)
+		}
+
+		log.Info("blackouts classified", slog.Int64("reset", reset), slog.Int("classified", classified))
+		return
+
+	case kind == kindAuto:
+		if _, err := store.ResetBlackoutKinds(id); err != nil {
+			exitOnBlackoutError(log, id, "failed to reset kind", err)
+		}
+
+		if _, err := classifier.ClassifyNew(); err != nil {
+			log.Error("failed to classify blackouts", sl.Err(err))
+			os.Exit(1)
+		}
+
+	case kind != "":
+		if err := store.OverrideBlackoutKind(id, kind); err != nil {
+			exitOnBlackoutError(log, id, "failed to override kind", err)
+		}
+	}
+
+	blackout, err := store.GetBlackout(id, models.Filter{})
+	if err != nil {
+		exitOnBlackoutError(log, id, "failed to get blackout", err)
+	}
+
+	log.Info("blackout kind",
+		slog.String("id", blackout.ID),
+		slog.String("kind", blackout.Kind),
+		slog.String("type", blackout.Type),
+		slog.String("source", blackout.Source),
+		slog.String("description", blackout.Description))
+}
+
+func exitOnBlackoutError(log *slog.Logger, id string, msg string, err error) {
+	if errors.Is(err, storage.ErrBlackoutNotFound) {
+		log.Error("blackout not found", slog.String("id", id))
+	} else {
+		log.Error(msg, slog.String("id", id), sl.Err(err))
+	}
+	os.Exit(1)
+}
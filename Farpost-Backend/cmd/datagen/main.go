@@ -0,0 +1,102 @@
+// datagen writes a synthetic city to a new SQLite database, for demos and load
+// testing without the real data.
+//
+// Streets, buildings (some of them fake) and blackouts follow the patterns of
+// the real data: summer hot water works lasting days, heating outages in the
+// cold season only, short emergencies at any hour. The same --seed and sizes
+// give the same database:
+//
+//	go run ./cmd/datagen --out=storage/storage.db --seed=1 --streets=300 --buildings=6000 --blackouts=20000
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/synthetic"
+)
+
+const dateLayout = "2006-01-02"
+
+func main() {
+	var out, from, to string
+	var force bool
+	var opts synthetic.Options
+	flag.StringVar(&out, "out", "./storage/storage.db", "path of the SQLite database to create")
+	flag.BoolVar(&force, "force", false, "replace the database if it exists")
+	flag.Int64Var(&opts.Seed, "seed", 1, "seed of the generator")
+	flag.IntVar(&opts.Streets, "streets", 300, "number of streets")
+	flag.IntVar(&opts.Buildings, "buildings", 6000, "number of real buildings")
+	flag.IntVar(&opts.Blackouts, "blackouts", 20000, "number of blackouts")
+	flag.Float64Var(&opts.FakeShare, "fake-share", 0.1, "share of streets with a fake building standing for the whole street")
+	flag.StringVar(&from, "from", "2023-01-01", "first day of the blackouts, YYYY-MM-DD")
+	flag.StringVar(&to, "to", "2025-01-01", "day after the last one of the blackouts, YYYY-MM-DD")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	var err error
+	opts.From, err = time.Parse(dateLayout, from)
+	if err != nil {
+		log.Error("invalid from date", slog.String("from", from), sl.Err(err))
+		os.Exit(1)
+	}
+	opts.To, err = time.Parse(dateLayout, to)
+	if err != nil {
+		log.Error("invalid to date", slog.String("to", to), sl.Err(err))
+		os.Exit(1)
+	}
+
+	city, err := synthetic.Generate(opts)
+	if err != nil {
+		log.Error("invalid options", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(out); err == nil {
+		if !force {
+			log.Error("database already exists, use --force to replace it", slog.String("out", out))
+			os.Exit(1)
+		}
+		if err := os.Remove(out); err != nil {
+			log.Error("failed to remove database", slog.String("out", out), sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		log.Error("failed to create directory", slog.String("out", out), sl.Err(err))
+		os.Exit(1)
+	}
+
+	if err := synthetic.Write(out, city); err != nil {
+		log.Error("failed to write database", slog.String("out", out), sl.Err(err))
+		os.Exit(1)
+	}
+
+	fake, links, open := 0, 0, 0
+	for _, building := range city.Buildings {
+		if building.IsFake {
+			fake++
+		}
+	}
+	for _, blackout := range city.Blackouts {
+		links += len(blackout.Buildings)
+		if blackout.EndDate == "" {
+			open++
+		}
+	}
+
+	log.Info("database generated",
+		slog.String("out", out),
+		slog.Int64("seed", opts.Seed),
+		slog.Int("streets", len(city.Streets)),
+		slog.Int("buildings", len(city.Buildings)-fake),
+		slog.Int("fake_buildings", fake),
+		slog.Int("blackouts", len(city.Blackouts)),
+		slog.Int("open_ended", open),
+		slog.Int("blackout_buildings", links))
+}
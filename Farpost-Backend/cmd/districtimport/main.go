@@ -0,0 +1,137 @@
+// districtimport maps streets and buildings to administrative districts from a local CSV file.
+//
+// The file must have a header with the columns district and street; an optional
+// number column maps a single building, which overrides the district of its street.
+// Comma and semicolon separators are both supported. Missing districts are created
+// in the city, only its streets are matched.
+//
+//	go run ./cmd/districtimport --config=config/local.yaml --city=vladivostok --file=districts.csv
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"vlru-prsch/internal/config"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/backend"
+)
+
+func main() {
+	var file, city string
+	flag.StringVar(&file, "file", "", "path to the CSV file with district mappings")
+	flag.StringVar(&city, "city", "", "code of the configured city the districts are in")
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if file == "" {
+		log.Error("file flag is required")
+		os.Exit(1)
+	}
+	if !slices.ContainsFunc(cfg.Cities, func(c config.City) bool { return c.Code == city }) {
+		log.Error("city flag must be one of the configured cities", slog.String("city", city))
+		os.Exit(1)
+	}
+
+	mappings, err := readMappings(file)
+	if err != nil {
+		log.Error("failed to read mappings", slog.String("file", file), sl.Err(err))
+		os.Exit(1)
+	}
+
+	storage, err := backend.New(cfg)
+	if err != nil {
+		log.Error("failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	updated, missing, err := storage.ImportDistricts(city, mappings)
+	if err != nil {
+		log.Error("failed to import districts", sl.Err(err))
+		os.Exit(1)
+	}
+
+	for _, mapping := range missing {
+		log.Warn("address not found",
+			slog.String("district", mapping.District),
+			slog.String("street", mapping.Street),
+			slog.String("number", mapping.Number))
+	}
+
+	log.Info("import finished",
+		slog.Int("rows", len(mappings)),
+		slog.Int64("updated", updated),
+		slog.Int("not_found", len(missing)))
+}
+
+func readMappings(path string) ([]models.DistrictMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	if firstLine, _, _ := strings.Cut(string(data), "\n"); strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "district", "street", "number":
+			columns[name] = i
+		}
+	}
+	for _, name := range []string{"district", "street"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %q is missing", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var mappings []models.DistrictMapping
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		mapping := models.DistrictMapping{
+			District: field(record, "district"),
+			Street:   field(record, "street"),
+			Number:   field(record, "number"),
+		}
+		if mapping.District == "" || mapping.Street == "" {
+			return nil, fmt.Errorf("line %d: district and street are required", line)
+		}
+
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings, nil
+}
@@ -0,0 +1,151 @@
+// forecast trains the outage forecast model from the local database and backtests it.
+//
+// Training writes the model to forecast.model_path, the server picks it up on start:
+//
+//	go run ./cmd/forecast --config=config/local.yaml --mode=train
+//
+// A backtest trains on the history before --split and scores the next --horizon days:
+//
+//	go run ./cmd/forecast --config=config/local.yaml --mode=backtest --split=2019-10-01 --horizon=30
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+	"vlru-prsch/internal/config"
+	"vlru-prsch/internal/forecast"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/backend"
+)
+
+const dateLayout = "2006-01-02"
+
+func main() {
+	var mode, out, until, split string
+	var horizon int
+	flag.StringVar(&mode, "mode", "train", "train or backtest")
+	flag.StringVar(&out, "out", "", "where to write the model, forecast.model_path by default")
+	flag.StringVar(&until, "until", "", "last day of the training history, YYYY-MM-DD, the whole history by default")
+	flag.StringVar(&split, "split", "", "first day of the backtest period, YYYY-MM-DD")
+	flag.IntVar(&horizon, "horizon", 30, "number of days scored by the backtest")
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	storage, err := backend.New(cfg)
+	if err != nil {
+		log.Error("failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	serviceTypes, err := storage.GetServiceTypes()
+	if err != nil {
+		log.Error("failed to load service types", sl.Err(err))
+		os.Exit(1)
+	}
+
+	records, err := storage.GetOutageHistory()
+	if err != nil {
+		log.Error("failed to read outage history", sl.Err(err))
+		os.Exit(1)
+	}
+
+	streets, err := storage.GetStreetsCount()
+	if err != nil {
+		log.Error("failed to count streets", sl.Err(err))
+		os.Exit(1)
+	}
+
+	from, to, ok := historyRange(records)
+	if !ok {
+		log.Error("outage history is empty")
+		os.Exit(1)
+	}
+
+	opts := forecast.Options{Alpha: cfg.Forecast.Alpha, Beta: cfg.Forecast.Beta, Types: serviceTypes.Codes()}
+
+	switch mode {
+	case "train":
+		if until != "" {
+			to, err = time.Parse(dateLayout, until)
+			if err != nil {
+				log.Error("invalid until date", slog.String("until", until), sl.Err(err))
+				os.Exit(1)
+			}
+		}
+
+		if out == "" {
+			out = cfg.Forecast.ModelPath
+		}
+
+		model := forecast.Train(records, streets, from, to, opts)
+		if err := model.Save(out); err != nil {
+			log.Error("failed to save model", sl.Err(err))
+			os.Exit(1)
+		}
+
+		log.Info("model trained",
+			slog.String("from", model.From),
+			slog.String("to", model.To),
+			slog.Int("streets_with_outages", len(model.Days)),
+			slog.String("out", out))
+
+	case "backtest":
+		splitDate, err := time.Parse(dateLayout, split)
+		if err != nil {
+			log.Error("split flag must be a YYYY-MM-DD date", slog.String("split", split))
+			os.Exit(1)
+		}
+		if !splitDate.After(from) || horizon < 1 {
+			log.Error("split must follow the first outage and horizon must be positive",
+				slog.String("first_outage", from.Format(dateLayout)))
+			os.Exit(1)
+		}
+
+		printReport(forecast.Backtest(records, streets, from, splitDate, horizon, opts))
+
+	default:
+		log.Error("unknown mode, use train or backtest", slog.String("mode", mode))
+		os.Exit(1)
+	}
+}
+
+func historyRange(records []models.OutageRecord) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	for _, record := range records {
+		start, err := time.Parse("2006-01-02 15:04:05", record.StartDate)
+		if err != nil {
+			continue
+		}
+		if from.IsZero() || start.Before(from) {
+			from = start
+		}
+		if start.After(to) {
+			to = start
+		}
+	}
+	return from, to, !from.IsZero()
+}
+
+func printReport(report forecast.Report) {
+	fmt.Printf("split %s, horizon %d days\n", report.Split, report.Horizon)
+	fmt.Printf("street-type-days scored: %d, with outage: %d\n", report.Pairs, report.Outages)
+	fmt.Printf("brier score:      %.5f\n", report.Brier)
+	fmt.Printf("base rate brier:  %.5f\n", report.BaseBrier)
+	fmt.Printf("skill:            %.3f\n", report.Skill)
+	fmt.Printf("log loss:         %.5f\n", report.LogLoss)
+	fmt.Println()
+	fmt.Println("calibration:  bin     n  predicted  observed")
+	for i, bin := range report.Calibrated {
+		if bin.Predictions == 0 {
+			continue
+		}
+		fmt.Printf("          %.1f-%.1f %6d  %9.3f  %8.3f\n",
+			float64(i)/10, float64(i+1)/10, bin.Predictions, bin.Predicted, bin.Observed)
+	}
+}
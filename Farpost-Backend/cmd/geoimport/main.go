@@ -0,0 +1,143 @@
+// geoimport loads building coordinates from a local CSV file.
+//
+// The file must have a header with the columns street, number, latitude and
+// longitude (lat, lon and lng are accepted too). Comma and semicolon separators
+// are both supported. Street names must match the streets of the city exactly.
+//
+//	go run ./cmd/geoimport --config=config/local.yaml --city=vladivostok --file=buildings.csv
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"vlru-prsch/internal/config"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/backend"
+)
+
+func main() {
+	var file, city string
+	flag.StringVar(&file, "file", "", "path to the CSV file with building coordinates")
+	flag.StringVar(&city, "city", "", "code of the configured city the buildings are in")
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if file == "" {
+		log.Error("file flag is required")
+		os.Exit(1)
+	}
+	if !slices.ContainsFunc(cfg.Cities, func(c config.City) bool { return c.Code == city }) {
+		log.Error("city flag must be one of the configured cities", slog.String("city", city))
+		os.Exit(1)
+	}
+
+	locations, err := readLocations(file)
+	if err != nil {
+		log.Error("failed to read locations", slog.String("file", file), sl.Err(err))
+		os.Exit(1)
+	}
+
+	storage, err := backend.New(cfg)
+	if err != nil {
+		log.Error("failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	updated, missing, err := storage.ImportBuildingLocations(city, locations)
+	if err != nil {
+		log.Error("failed to import locations", sl.Err(err))
+		os.Exit(1)
+	}
+
+	for _, location := range missing {
+		log.Warn("building not found", slog.String("street", location.Street), slog.String("number", location.Number))
+	}
+
+	log.Info("import finished",
+		slog.Int("rows", len(locations)),
+		slog.Int64("updated_buildings", updated),
+		slog.Int("not_found", len(missing)))
+}
+
+func readLocations(path string) ([]models.BuildingLocation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+	reader.TrimLeadingSpace = true
+	if firstLine, _, _ := strings.Cut(string(data), "\n"); strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "street":
+			columns["street"] = i
+		case "number":
+			columns["number"] = i
+		case "latitude", "lat":
+			columns["latitude"] = i
+		case "longitude", "lon", "lng":
+			columns["longitude"] = i
+		}
+	}
+	for _, name := range []string{"street", "number", "latitude", "longitude"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %q is missing", name)
+		}
+	}
+
+	var locations []models.BuildingLocation
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		lat, err := strconv.ParseFloat(strings.TrimSpace(record[columns["latitude"]]), 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("line %d: invalid latitude %q", line, record[columns["latitude"]])
+		}
+
+		lon, err := strconv.ParseFloat(strings.TrimSpace(record[columns["longitude"]]), 64)
+		if err != nil || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("line %d: invalid longitude %q", line, record[columns["longitude"]])
+		}
+
+		locations = append(locations, models.BuildingLocation{
+			Street:    record[columns["street"]],
+			Number:    record[columns["number"]],
+			Latitude:  lat,
+			Longitude: lon,
+		})
+	}
+
+	return locations, nil
+}
@@ -8,19 +8,70 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"sync/atomic"
+	"time"
+	"vlru-prsch/internal/anomaly"
+	"vlru-prsch/internal/cache"
+	"vlru-prsch/internal/classify"
 	"vlru-prsch/internal/config"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/forecast"
+	"vlru-prsch/internal/http-server/handlers/analytics/durations"
+	anomaliesget "vlru-prsch/internal/http-server/handlers/anomalies/get"
 	blackoutsget "vlru-prsch/internal/http-server/handlers/blackouts/get"
+	citiesget "vlru-prsch/internal/http-server/handlers/cities/get"
+	"vlru-prsch/internal/http-server/handlers/blackouts/history"
+	cachestats "vlru-prsch/internal/http-server/handlers/cache/stats"
+	ratelimitstats "vlru-prsch/internal/http-server/handlers/ratelimit/stats"
 	orgsget "vlru-prsch/internal/http-server/handlers/organizations/get"
 	dayget "vlru-prsch/internal/http-server/handlers/calendar/day/get"
+	districtsget "vlru-prsch/internal/http-server/handlers/districts/get"
+	hotspotsget "vlru-prsch/internal/http-server/handlers/hotspots/get"
+	mapget "vlru-prsch/internal/http-server/handlers/map/get"
 	monthget "vlru-prsch/internal/http-server/handlers/calendar/month/get"
+	nearbyget "vlru-prsch/internal/http-server/handlers/nearby/get"
+	withinpost "vlru-prsch/internal/http-server/handlers/within/post"
 	"vlru-prsch/internal/http-server/handlers/complaints"
 	"vlru-prsch/internal/http-server/handlers/search"
+	servicetypesget "vlru-prsch/internal/http-server/handlers/servicetypes/get"
+	"vlru-prsch/internal/http-server/handlers/stream"
+	"vlru-prsch/internal/http-server/handlers/subscriptions/confirm"
+	"vlru-prsch/internal/http-server/handlers/subscriptions/subscribe"
+	"vlru-prsch/internal/http-server/handlers/subscriptions/unsubscribe"
+	"vlru-prsch/internal/http-server/handlers/webhooks/deliveries"
+	webhookslist "vlru-prsch/internal/http-server/handlers/webhooks/list"
+	"vlru-prsch/internal/http-server/handlers/webhooks/redeliver"
+	webhooksremove "vlru-prsch/internal/http-server/handlers/webhooks/remove"
+	webhookssave "vlru-prsch/internal/http-server/handlers/webhooks/save"
+	"vlru-prsch/internal/http-server/middleware/auth"
+	"vlru-prsch/internal/http-server/middleware/cachecontrol"
+	"vlru-prsch/internal/http-server/middleware/cached"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/http-server/middleware/compress"
+	"vlru-prsch/internal/http-server/middleware/deadline"
+	"vlru-prsch/internal/http-server/middleware/etag"
+	"vlru-prsch/internal/http-server/middleware/limited"
+	"vlru-prsch/internal/http-server/middleware/logger"
 	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/lib/random"
+	"vlru-prsch/internal/lib/tlsreload"
 	"vlru-prsch/internal/lib/logger/slogpretty"
-	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/lifecycle"
+	"vlru-prsch/internal/mailer"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/ratelimit"
+	"vlru-prsch/internal/recurrence"
+	"vlru-prsch/internal/spatial"
+	"vlru-prsch/internal/storage/backend"
+	"vlru-prsch/internal/subscriptions"
+	"vlru-prsch/internal/telegram"
+	"vlru-prsch/internal/webhooks"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -42,97 +93,393 @@ const (
 func main() {
 	cfg := config.MustLoad()
 
-	log := setupLogger(cfg.Env)
+	level := new(slog.LevelVar)
+	level.Set(logLevel(cfg))
+
+	log := setupLogger(cfg.Env, level)
 
 	log.Info("init config and start app", slog.Any("cfg", cfg))
 
-	storage, err := sqlite.New(cfg.StoragePath)
+	storage, err := backend.New(cfg)
 	if err != nil {
 		log.Error("failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if err := storage.SyncServiceTypes(configServiceTypes(cfg)); err != nil {
+		log.Error("failed to sync service types", sl.Err(err))
+		os.Exit(1)
+	}
+
+	serviceTypes, err := storage.GetServiceTypes()
+	if err != nil {
+		log.Error("failed to load service types", sl.Err(err))
+		os.Exit(1)
+	}
+
+	cities, err := loadCities(cfg)
+	if err != nil {
+		log.Error("failed to load cities", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if err := storage.SyncCities(cities, cfg.DefaultCity); err != nil {
+		log.Error("failed to sync cities", sl.Err(err))
+		os.Exit(1)
+	}
+
+	codes := make([]string, 0, len(cities))
+	for _, c := range cities {
+		codes = append(codes, c.Code)
+	}
+
+	hub := events.NewHub(cfg.Events.HistorySize, cfg.Events.ClientBuffer, time.Now().UnixMilli())
+	watcher := events.NewWatcher(log, storage, hub, serviceTypes.Codes(), cfg.Events.PollInterval, cities)
+	go watcher.Run(context.Background())
+
+	dispatcher := webhooks.New(log, storage, hub, webhooks.Options{
+		PollInterval: cfg.Webhooks.PollInterval,
+		Timeout:      cfg.Webhooks.Timeout,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		BackoffBase:  cfg.Webhooks.BackoffBase,
+		BackoffMax:   cfg.Webhooks.BackoffMax,
+	})
+	go dispatcher.Run(context.Background())
+
+	locator := spatial.NewLocator(log, storage, cfg.Spatial.RefreshInterval, codes)
+	go locator.Run(context.Background())
+
+	detector := recurrence.New(log, storage, recurrence.Options{
+		Interval:        cfg.Recurrence.Interval,
+		Window:          cfg.Recurrence.Window,
+		MinOutages:      cfg.Recurrence.MinOutages,
+		PeriodTolerance: cfg.Recurrence.PeriodTolerance,
+	})
+	go detector.Run(context.Background())
+
+	forecasts := forecast.NewService(cfg.Forecast.MinProbability, cfg.Forecast.TopStreets)
+	if model, err := forecast.Load(cfg.Forecast.ModelPath); err != nil {
+		log.Warn("forecast model is not loaded, run cmd/forecast to train it", sl.Err(err))
+	} else {
+		forecasts.Set(model)
+	}
+
+	classifier := classify.New(log, storage, classify.Rules{
+		Planned:   cfg.Kinds.Planned,
+		Emergency: cfg.Kinds.Emergency,
+	}, cfg.Kinds.Interval)
+	go classifier.Run(context.Background())
+
+	tracker := lifecycle.NewTracker(log, storage, cfg.Lifecycle.Interval, cities)
+	go tracker.Run(context.Background())
+
+	anomalies := anomaly.New(log, storage, anomaly.Options{
+		Interval:  cfg.Anomalies.Interval,
+		Weeks:     cfg.Anomalies.Weeks,
+		Threshold: cfg.Anomalies.Threshold,
+		MinCount:  cfg.Anomalies.MinCount,
+		MinStdDev: cfg.Anomalies.MinStdDev,
+		Lookback:  cfg.Anomalies.Lookback,
+		Types:     serviceTypes.Codes(),
+		Cities:    cities,
+	})
+	go anomalies.Run(context.Background())
+
+	sender := mailer.NewSMTP(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, string(cfg.SMTP.Password), cfg.SMTP.From)
+	notifier := subscriptions.New(log, storage, sender, serviceTypes, cfg.Subscriptions.BaseURL, cfg.Subscriptions.DigestAt, cities)
+	go notifier.Run(context.Background(), hub)
+
+	if cfg.Telegram.Token != "" {
+		client := telegram.NewClient(cfg.Telegram.BaseURL, string(cfg.Telegram.Token), cfg.Telegram.PollTimeout)
+		bot := telegram.New(log, client, storage, serviceTypes, cfg.Telegram.PollTimeout, cities)
+		go bot.Run(context.Background(), hub)
+	}
+
+	responses, err := cache.New(log, storage, cache.Options{
+		MaxEntries:    cfg.Cache.MaxEntries,
+		Dir:           cfg.Cache.Dir,
+		CheckInterval: cfg.Cache.CheckInterval,
+	})
+	if err != nil {
+		log.Error("failed to init response cache", sl.Err(err))
+		os.Exit(1)
+	}
+	go responses.Run(context.Background())
+
+	// cacheFor serves the endpoint from the response cache with its configured TTL
+	cacheFor := func(endpoint string) func(next http.Handler) http.Handler {
+		return cached.New(log, responses, endpoint, cfg.Cache.TTLFor(endpoint))
+	}
+
+	// controlled sets Cache-Control of the endpoint, responses about past periods are kept longer
+	controlled := func(period cachecontrol.Period) func(next http.Handler) http.Handler {
+		return cachecontrol.New(cachecontrol.Policy{
+			MaxAge:     cfg.HTTPCache.MaxAge,
+			PastMaxAge: cfg.HTTPCache.PastMaxAge,
+		}, period)
+	}
+
+	// a forecast changes with every new blackout, even for a past month
+	calendarPeriod := func(r *http.Request) (time.Time, bool) {
+		if r.URL.Query().Get("forecast") == "true" {
+			return time.Time{}, false
+		}
+		return cachecontrol.Month("month")(r)
+	}
+
+	allowlist, err := ratelimit.ParseAllowlist(cfg.RateLimit.Allowlist)
+	if err != nil {
+		log.Error("failed to parse rate limit allowlist", sl.Err(err))
+		os.Exit(1)
+	}
+
+	limiter := ratelimit.NewMemory()
+	go limiter.Run(context.Background(), cfg.RateLimit.SweepInterval)
+	limits := ratelimit.NewMetrics(limiter.Len)
+
+	// limit applies the limits of the route group to every client
+	limit := func(group string) func(next http.Handler) http.Handler {
+		if !cfg.RateLimit.Enabled {
+			return func(next http.Handler) http.Handler { return next }
+		}
+
+		rules := cfg.RateLimit.Groups[group]
+
+		return limited.New(log, limiter, limits, limited.Options{
+			Group:     group,
+			IP:        ratelimit.Rule{Rate: rules.Rate, Burst: rules.Burst},
+			Key:       ratelimit.Rule{Rate: rules.KeyRate, Burst: rules.KeyBurst},
+			Keys:      cfg.APIKeys,
+			Allowlist: allowlist,
+		})
 	}
 
+	settings := config.NewManager(log, cfg)
+	go settings.Run(context.Background(), cfg.WatchInterval)
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
-	router.Use(middleware.Logger)
+	if cfg.RateLimit.TrustProxy {
+		router.Use(middleware.RealIP)
+	}
+	// compression goes around the access log, it reads the payload to tell error responses
+	router.Use(compress.New(cfg.HTTPCache.CompressionLevel))
+	router.Use(logger.New(log, logger.Options{
+		Sample: cfg.AccessLog.Sample,
+		Redact: cfg.AccessLog.Redact,
+	}))
 	router.Use(middleware.Recoverer)
 
-	router.Use(corsConfig(cfg.Env))
+	router.Use(deadline.New(func() time.Duration { return settings.Current().Timeout }))
+
+	// the CORS policy is swapped as a whole on reload
+	var corsPolicy atomic.Pointer[cors.Cors]
+	corsPolicy.Store(corsConfig(cfg.CORS))
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			corsPolicy.Load().Handler(next).ServeHTTP(w, r)
+		})
+	})
+
+	settings.OnReload(func(c *config.Config) {
+		level.Set(logLevel(c))
+		corsPolicy.Store(corsConfig(c.CORS))
+	})
+	router.Use(middleware.RequestSize(cfg.MaxBodyBytes))
 
 	router.Get("/swagger/*", httpSwagger.Handler(
         httpSwagger.URL("/swagger/doc.json"), 
     ))
 
-	router.Route("/off", func(r chi.Router) {
-		r.Post("/search", search.New(log, storage))
-		r.Get("/blackouts", blackoutsget.New(log, storage))
-		r.Get("/orgs", orgsget.New(log, storage))
-		r.Get("/complaints", complaints.New(log, storage))
-		r.Get("/calendar", monthget.New(log, storage))
-		r.Get("/calendar/day", dayget.New(log, storage))
-	})
+	offRoutes := func(r chi.Router) {
+		r.Use(city.New(log, cities))
+		r.Use(etag.New())
+
+		r.Group(func(r chi.Router) {
+			r.Use(limit("public"))
+
+			r.With(controlled(cachecontrol.Current), cacheFor("/cities")).Get("/cities", citiesget.New(log, cities))
+			r.With(controlled(cachecontrol.Moment("curr_time")), cacheFor("/blackouts")).Get("/blackouts", blackoutsget.New(log, storage, serviceTypes))
+			r.Get("/blackouts/{id}/history", history.New(log, storage))
+			r.With(controlled(cachecontrol.Moment("curr_time")), cacheFor("/orgs")).Get("/orgs", orgsget.New(log, storage))
+			r.With(controlled(cachecontrol.Moment("curr_time")), cacheFor("/districts")).Get("/districts", districtsget.New(log, storage, serviceTypes))
+			r.With(controlled(cachecontrol.Day("to")), cacheFor("/analytics/durations")).Get("/analytics/durations", durations.New(log, storage, serviceTypes))
+			r.With(controlled(cachecontrol.Current), cacheFor("/hotspots")).Get("/hotspots", hotspotsget.New(log, storage, serviceTypes))
+			r.With(controlled(cachecontrol.Current), cacheFor("/anomalies")).Get("/anomalies", anomaliesget.New(log, storage, serviceTypes))
+			r.With(controlled(cachecontrol.Moment("curr_time")), cacheFor("/complaints")).Get("/complaints", complaints.New(log, storage, cfg.ServiceTypes.LegacyFields))
+			r.With(controlled(cachecontrol.Current), cacheFor("/service-types")).Get("/service-types", servicetypesget.New(log, storage))
+			r.With(controlled(calendarPeriod), cacheFor("/calendar")).Get("/calendar", monthget.New(log, storage, serviceTypes, forecasts))
+			r.With(controlled(cachecontrol.Day("date")), cacheFor("/calendar/day")).Get("/calendar/day", dayget.New(log, storage))
+			r.Get("/stream", stream.New(log, hub, cfg.Events.Heartbeat))
+			r.With(controlled(cachecontrol.Moment("curr_time")), cacheFor("/map")).Get("/map", mapget.New(log, storage, serviceTypes))
+		})
+
+		// search and spatial queries hit the database on every request
+		r.Group(func(r chi.Router) {
+			r.Use(limit("search"))
+
+			r.Post("/search", search.New(log, storage))
+			r.Get("/nearby", nearbyget.New(log, locator, cfg.Spatial.MaxRadius))
+			r.Post("/within", withinpost.New(log, locator))
+		})
+
+		r.Route("/subscriptions", func(r chi.Router) {
+			r.Use(limit("subscriptions"))
+
+			r.Post("/", subscribe.New(log, storage, notifier, func() (string, error) { return random.Token(16) }))
+			r.Get("/confirm", confirm.New(log, storage))
+			r.Get("/unsubscribe", unsubscribe.New(log, storage))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(limit("admin"))
+			r.Use(auth.New(log, cfg.APIKeys))
+
+			r.Get("/cache/stats", cachestats.New(log, responses))
+			r.Get("/ratelimit/stats", ratelimitstats.New(log, limits))
 
-	log.Info("starting server", slog.Any("address", cfg.Address))
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Post("/", webhookssave.New(log, storage, serviceTypes, webhooks.NewSecret))
+				r.Get("/", webhookslist.New(log, storage))
+				r.Delete("/{id}", webhooksremove.New(log, storage))
+				r.Get("/{id}/deliveries", deliveries.New(log, storage))
+				r.Post("/{id}/deliveries/{delivery_id}/redeliver", redeliver.New(log, storage))
+			})
+		})
+	}
+
+	// /off serves the default city or the one of the X-City header,
+	// /{city}/off serves the city of the prefix
+	router.Route("/off", offRoutes)
+	router.Route("/{city}/off", offRoutes)
+
+	log.Info("starting server", slog.Any("address", cfg.Address), slog.Bool("tls", cfg.TLS.Enabled()))
 
 	srv := &http.Server{
-		Addr:         cfg.Address,
-		Handler:      router,
-		ReadTimeout:  cfg.Timeout,
-		WriteTimeout: cfg.Timeout,
-		IdleTimeout:  cfg.IddleTimeout,
+		Addr:              cfg.Address,
+		Handler:           router,
+		ReadTimeout:       cfg.Timeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Timeout,
+		IdleTimeout:       cfg.IddleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Error("failed to start server")
+	if cfg.TLS.Enabled() {
+		certs, err := tlsreload.New(log, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			log.Error("failed to load certificate", sl.Err(err))
+			os.Exit(1)
+		}
+		if cfg.TLS.ReloadInterval > 0 {
+			go certs.Run(context.Background(), cfg.TLS.ReloadInterval)
+		}
+
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+
+		if err := srv.ListenAndServeTLS("", ""); err != nil {
+			log.Error("failed to start server", sl.Err(err))
+		}
+	} else if err := srv.ListenAndServe(); err != nil {
+		log.Error("failed to start server", sl.Err(err))
 	}
 
 	log.Error("server stoped")
 }
 
-func corsConfig(env string) func(next http.Handler) http.Handler {
-	switch env {
-	case local:
-		return cors.Handler(cors.Options{
-			AllowedOrigins:   []string{"http://localhost", "http://127.0.0.1"},
-			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization"},
-			ExposedHeaders:   []string{"Link"},
-			AllowCredentials: true,
-			MaxAge:           300,
+func corsConfig(c config.CORS) *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins:   c.AllowedOrigins,
+		AllowedMethods:   c.AllowedMethods,
+		AllowedHeaders:   c.AllowedHeaders,
+		ExposedHeaders:   c.ExposedHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           int(c.MaxAge.Seconds()),
+	})
+}
+
+// configServiceTypes returns the service types added in the config
+func configServiceTypes(cfg *config.Config) []models.ServiceType {
+	types := make([]models.ServiceType, 0, len(cfg.ServiceTypes.Types))
+	for _, t := range cfg.ServiceTypes.Types {
+		types = append(types, models.ServiceType{
+			Code:     t.Code,
+			NameRu:   t.NameRu,
+			NameEn:   t.NameEn,
+			Icon:     t.Icon,
+			Position: t.Position,
 		})
-	// dev/prod можно настроить аналогично
 	}
 
-	return cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	})
+	return types
 }
 
+// loadCities resolves the time zones of the configured cities and marks the default one,
+// config.Load has checked both
+func loadCities(cfg *config.Config) ([]models.City, error) {
+	cities := make([]models.City, 0, len(cfg.Cities))
+
+	for _, c := range cfg.Cities {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("city %s: %w", c.Code, err)
+		}
+
+		cities = append(cities, models.City{
+			Code:           c.Code,
+			Name:           c.Name,
+			Timezone:       c.Timezone,
+			BuildingsTotal: c.BuildingsTotal,
+			IsDefault:      c.Code == cfg.DefaultCity,
+			Location:       loc,
+		})
+	}
 
+	return cities, nil
+}
 
-func setupLogger(env string) *slog.Logger {
+func setupLogger(env string, level *slog.LevelVar) *slog.Logger {
 	var log *slog.Logger
 
 	switch env {
 	case local:
-		log = setupPrettySlog()
+		log = setupPrettySlog(level)
 	case dev:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
 	case prod:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
 	}
 
 	return log
 }
 
-func setupPrettySlog() *slog.Logger {
+// logLevel is the configured level, debug for local and dev and info for prod without one
+func logLevel(cfg *config.Config) slog.Level {
+	switch cfg.LogLevel {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+
+	if cfg.Env == prod {
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+func setupPrettySlog(level *slog.LevelVar) *slog.Logger {
 	opts := slogpretty.PrettyHandlerOptions{
 		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
+			Level: level,
 		},
 	}
 
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"vlru-prsch/internal/config"
+)
+
+func TestLoadCitiesOfShippedConfig(t *testing.T) {
+	cfg, err := config.Load("../../config/local.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cities, err := loadCities(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cities) != len(cfg.Cities) {
+		t.Fatalf("got %d cities, want %d", len(cities), len(cfg.Cities))
+	}
+
+	for _, c := range cities {
+		if c.Location == nil || c.Location.String() != c.Timezone {
+			t.Errorf("city %s: got location %v, want %s", c.Code, c.Location, c.Timezone)
+		}
+	}
+}
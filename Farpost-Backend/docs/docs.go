@@ -15,9 +15,152 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/off/analytics/durations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Считает среднюю, медианную и 90-перцентильную длительность отключений, начавшихся в периоде, по типам услуг и организациям, потерянные здание-часы, долю отключений без даты окончания и помесячную динамику. Учитываются только реальные здания. Отключения без окончания не входят в длительности, а в здание-часах считаются до конца периода",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Длительность отключений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2019-10-01",
+                        "description": "Начало периода в формате YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-12-31",
+                        "description": "Конец периода в формате YYYY-MM-DD, включительно",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ленинский",
+                        "description": "Административный район",
+                        "name": "district",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Аналитика за период",
+                        "schema": {
+                            "$ref": "#/definitions/durations.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный период - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid period\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении данных - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get blackouts data\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/anomalies": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает часы, в которые новых отключений одного типа было заметно больше, чем в тот же час предыдущих недель (z-score не ниже порога). Проверка выполняется по расписанию, от новых к старым",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Всплески новых отключений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "electricity",
+                        "description": "Код типа услуги из /off/service-types",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключений: planned, emergency, unknown, без параметра все виды вместе",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-12-01_00:00:00",
+                        "description": "Начало периода в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-12-31_23:59:59",
+                        "description": "Конец периода в том же формате",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 100,
+                        "description": "Максимальное количество записей, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список всплесков",
+                        "schema": {
+                            "$ref": "#/definitions/anomalies.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid time format\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении данных - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get anomalies\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/off/blackouts": {
             "get": {
-                "description": "Возвращает статистику по отключениям горячей/холодной воды, электричества и отопления",
+                "description": "Возвращает статистику по отключениям горячей/холодной воды, электричества и отопления. С параметром district доли считаются от зданий района. С параметром compare к каждому типу добавляется сравнение с тем же моментом день, неделю или год назад и организации, больше всего повлиявшие на изменение",
                 "consumes": [
                     "application/json"
                 ],
@@ -36,6 +179,27 @@ const docTemplate = `{
                         "name": "curr_time",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ленинский",
+                        "description": "Административный район",
+                        "name": "district",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "week",
+                        "description": "Период сравнения: day, week, year",
+                        "name": "compare",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -60,6 +224,83 @@ const docTemplate = `{
                 }
             }
         },
+        "/off/blackouts/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все ревизии отключения: перенос начала или окончания, смену описания и состояния (announced, active, extended, resolved, cancelled), с временем изменения и автором. Ревизии только добавляются и никогда не меняются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blackouts"
+                ],
+                "summary": "История изменений отключения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор отключения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "История отключения",
+                        "schema": {
+                            "$ref": "#/definitions/history.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Отключение не найдено - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"blackout not found\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get history\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/cache/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает число ответов в кэше, попадания и промахи всего и по endpoints, число вытеснений и сбросов после изменения данных. Кэшированные ответы отмечаются заголовком X-Cache: HIT, остальные - X-Cache: MISS",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cache"
+                ],
+                "summary": "Статистика кэша ответов",
+                "responses": {
+                    "200": {
+                        "description": "Статистика кэша",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http-server_handlers_cache_stats.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет доступа - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unauthorized\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/off/calendar": {
             "get": {
                 "security": [
@@ -67,7 +308,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Возвращает информацию об отключениях по дням указанного месяца для отображения в календаре",
+                "description": "Возвращает информацию об отключениях по дням указанного месяца для отображения в календаре. С forecast=true для дней после curr_time добавляется слой вероятных отключений по сезонной модели, обученной на истории",
                 "consumes": [
                     "application/json"
                 ],
@@ -86,6 +327,41 @@ const docTemplate = `{
                         "name": "month",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "Добавить слой вероятных отключений",
+                        "name": "forecast",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Карбышева ул.",
+                        "description": "Улица для прогноза, по умолчанию наиболее вероятные улицы города",
+                        "name": "street",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-12-15_12:00:00",
+                        "description": "Текущее время, прогноз строится для следующих дней",
+                        "name": "curr_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ленинский",
+                        "description": "Административный район: учитываются отключения, затронувшие его здания",
+                        "name": "district",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -96,7 +372,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Неверный формат месяца - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to process month dates\\\"}",
+                        "description": "Неизвестный район - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unknown district\\\"}",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -106,6 +382,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
+                    },
+                    "503": {
+                        "description": "Модель прогноза не загружена - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"forecast is not available\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
                     }
                 }
             }
@@ -136,6 +418,20 @@ const docTemplate = `{
                         "name": "date",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ленинский",
+                        "description": "Административный район: считаются только его адреса",
+                        "name": "district",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -146,7 +442,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Отсутствует параметр date - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"date parameter is required\\\"}",
+                        "description": "Неизвестный район - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unknown district\\\"}",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -160,6 +456,31 @@ const docTemplate = `{
                 }
             }
         },
+        "/off/cities": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает обслуживаемые города. Данные города доступны по адресам /{city}/off/... или по /off/... с заголовком X-City, без них - по городу по умолчанию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cities"
+                ],
+                "summary": "Города",
+                "responses": {
+                    "200": {
+                        "description": "Список городов",
+                        "schema": {
+                            "$ref": "#/definitions/cities.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/off/complaints": {
             "get": {
                 "security": [
@@ -167,7 +488,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Возвращает статистику жалоб за указанный период для построения графиков и аналитики",
+                "description": "Возвращает статистику жалоб за указанный период для построения графиков и аналитики. Значения по типам услуг лежат в counts с ключами из реестра /off/service-types, поля hot, cold, electricity и heating возвращаются в режиме совместимости service_types.legacy_fields",
                 "consumes": [
                     "application/json"
                 ],
@@ -194,6 +515,20 @@ const docTemplate = `{
                         "name": "curr_time",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ленинский",
+                        "description": "Административный район: учитываются отключения, затронувшие его здания",
+                        "name": "district",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -204,7 +539,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Неверный период - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid period, use: hour, day, week, month\\\"}",
+                        "description": "Неизвестный район - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unknown district\\\"}",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -218,39 +553,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/off/orgs": {
+        "/off/districts": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Возвращает список организаций с информацией о количестве зданий и последних отключениях",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает районы, отсортированные по доле зданий, затронутых отключениями в указанное время, с разбивкой по типам услуг. С параметром kind учитываются только отключения этого вида",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "organizations"
+                    "blackouts"
                 ],
-                "summary": "Получить информацию об организациях",
+                "summary": "Рейтинг районов по отключениям",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "2024-01-15_14:30:00 или 2024-01-15T14:30:00Z",
+                        "example": "2019-01-15_14:30:00",
                         "description": "Текущее время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS",
                         "name": "curr_time",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ с данными об организациях",
+                        "description": "Рейтинг районов",
                         "schema": {
-                            "$ref": "#/definitions/organizations.Response"
+                            "$ref": "#/definitions/districts.Response"
                         }
                     },
                     "400": {
@@ -260,7 +599,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка сервера - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get organizations\\\"}",
+                        "description": "Ошибка при получении данных - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get districts data\\\"}",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -268,207 +607,2507 @@ const docTemplate = `{
                 }
             }
         },
-        "/off/search": {
-            "post": {
+        "/off/hotspots": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Поиск улиц по частичному совпадению названия. Возвращает список улиц, содержащих указанную подстроку",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает здания и улицы, где отключения одного типа повторяются: не реже заданного числа раз в окне анализа или с регулярным интервалом. Для каждого адреса приводится история отключений. Список обновляется фоновым анализом",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "search"
+                    "analytics"
                 ],
-                "summary": "Поиск улиц по подстроке",
+                "summary": "Хронические отключения",
                 "parameters": [
                     {
-                        "description": "Параметры поиска",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/search.Request"
-                        }
+                        "type": "string",
+                        "example": "building",
+                        "description": "Уровень: building или street",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "hot_water",
+                        "description": "Код типа услуги из /off/service-types",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "planned",
+                        "description": "Вид отключений: planned, emergency, unknown, без параметра все виды вместе",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Максимальное количество записей, по умолчанию 50",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ со списком найденных улиц",
+                        "description": "Список адресов",
                         "schema": {
-                            "$ref": "#/definitions/search.Response"
+                            "$ref": "#/definitions/hotspots.Response"
                         }
                     },
                     "400": {
-                        "description": "Слишком короткая строка поиска - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"suggest must be at least 2 characters\\\"}",
+                        "description": "Неверные параметры - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid scope, use: building, street\\\"}",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     },
                     "500": {
-                        "description": "Ошибка поиска - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"search failed\\\"}",
+                        "description": "Ошибка при получении данных - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get hotspots\\\"}",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "blackouts.BlackoutInfo": {
+        },
+        "/off/map": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает GeoJSON FeatureCollection: точка на каждое здание с координатами, затронутое отключением в указанное время. В свойствах - адрес, список отключений, основной тип услуги (service) и цвет маркера (marker-color)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "map"
+                ],
+                "summary": "Карта текущих отключений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2019-01-15_14:30:00",
+                        "description": "Время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS",
+                        "name": "curr_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "131.85,43.08,131.95,43.15",
+                        "description": "Ограничивающий прямоугольник: minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "hot_water,electricity",
+                        "description": "Типы отключений через запятую",
+                        "name": "services",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ленинский",
+                        "description": "Административный район",
+                        "name": "district",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Точки отключений",
+                        "schema": {
+                            "$ref": "#/definitions/geojson.FeatureCollection"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid bbox\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения данных - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get map data\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/nearby": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает действующие в указанное время отключения и затронутые ими здания в радиусе от точки. Учитываются только здания города запроса с импортированными координатами. Оба списка отсортированы по расстоянию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "map"
+                ],
+                "summary": "Отключения рядом с точкой",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "example": 43.1155,
+                        "description": "Широта",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "example": 131.8869,
+                        "description": "Долгота",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "example": 500,
+                        "description": "Радиус в метрах, по умолчанию 500",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-01-15_14:30:00",
+                        "description": "Время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS",
+                        "name": "curr_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отключения рядом",
+                        "schema": {
+                            "$ref": "#/definitions/nearby.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid radius\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения данных - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get nearby blackouts\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/orgs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает список организаций с информацией о количестве зданий и последних отключениях. С параметром kind учитываются только отключения этого вида",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Получить информацию об организациях",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2024-01-15_14:30:00 или 2024-01-15T14:30:00Z",
+                        "description": "Текущее время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS",
+                        "name": "curr_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "emergency",
+                        "description": "Вид отключения: planned, emergency, unknown",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ с данными об организациях",
+                        "schema": {
+                            "$ref": "#/definitions/organizations.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат времени - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid time format\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get organizations\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/ratelimit/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает число отслеживаемых клиентов и по группам маршрутов - пропущенные, отклоненные с кодом 429 и пришедшие из списка разрешенных адресов запросы",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratelimit"
+                ],
+                "summary": "Статистика ограничения частоты запросов",
+                "responses": {
+                    "200": {
+                        "description": "Статистика ограничения",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http-server_handlers_ratelimit_stats.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет доступа - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unauthorized\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/search": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Поиск улиц по частичному совпадению названия. Возвращает список улиц города запроса, содержащих указанную подстроку",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Поиск улиц по подстроке",
+                "parameters": [
+                    {
+                        "description": "Параметры поиска",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/search.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ со списком найденных улиц",
+                        "schema": {
+                            "$ref": "#/definitions/search.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Слишком короткая строка поиска - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"suggest must be at least 2 characters\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка поиска - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"search failed\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/service-types": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает реестр типов услуг в порядке отображения: код, названия на русском и английском и ключ иконки. Коды используются как ключи в ответах с агрегатами и в фильтрах по типу",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "service types"
+                ],
+                "summary": "Типы услуг",
+                "responses": {
+                    "200": {
+                        "description": "Реестр типов услуг",
+                        "schema": {
+                            "$ref": "#/definitions/servicetypes.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get service types\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events поток: появление новых запланированных отключений, их начало, окончание и изменение, а также изменение количества затронутых зданий по типам. Поддерживает возобновление по заголовку Last-Event-ID",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Поток событий об отключениях (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "hot_water,electricity",
+                        "description": "Коды типов услуг через запятую, см. /off/service-types",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Карбышева ул.",
+                        "description": "Улицы через запятую",
+                        "name": "streets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор последнего полученного события, если заголовок недоступен",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный Last-Event-ID - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid last event id\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Поток не поддерживается - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"streaming unsupported\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/subscriptions": {
+            "post": {
+                "description": "Создает подписку адреса на письма об отключениях и отправляет письмо со ссылкой для подтверждения. Подписка начинает работать только после подтверждения",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Подписаться на уведомления об отключениях",
+                "parameters": [
+                    {
+                        "description": "Параметры подписки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscribe.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Письмо для подтверждения отправлено",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid email\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Адрес не найден - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"building not found\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка уже существует - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"already subscribed\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to subscribe\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/subscriptions/confirm": {
+            "get": {
+                "description": "Подтверждает подписку по ссылке из письма",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Подтвердить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен из письма",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка подтверждена",
+                        "schema": {
+                            "$ref": "#/definitions/confirm.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Отсутствует токен - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"token parameter is required\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"subscription not found\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to confirm subscription\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/subscriptions/unsubscribe": {
+            "get": {
+                "description": "Удаляет подписку по ссылке из письма",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отписаться от уведомлений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен из письма",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка удалена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Отсутствует токен - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"token parameter is required\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"subscription not found\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to unsubscribe\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все зарегистрированные подписки города без секретов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Получить список подписок на события",
+                "responses": {
+                    "200": {
+                        "description": "Список подписок",
+                        "schema": {
+                            "$ref": "#/definitions/list.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет доступа - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unauthorized\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get webhooks\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Регистрирует адрес, на который будут отправляться события об отключениях в городе запроса. Запросы подписываются HMAC-SHA256 в заголовке X-Off-Signature",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Создать подписку на события",
+                "parameters": [
+                    {
+                        "description": "Параметры подписки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/save.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка создана",
+                        "schema": {
+                            "$ref": "#/definitions/save.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid url\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет доступа - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unauthorized\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сохранения - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to save webhook\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет подписку вместе с журналом её доставок",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Удалить подписку на события",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка удалена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный идентификатор - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid id\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет доступа - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unauthorized\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"webhook not found\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка удаления - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to delete webhook\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает последние доставки событий для подписки: статус, число попыток, код ответа и ошибку",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Получить журнал доставок подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Количество записей, по умолчанию 50, максимум 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Журнал доставок",
+                        "schema": {
+                            "$ref": "#/definitions/deliveries.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный идентификатор - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid id\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет доступа - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unauthorized\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"webhook not found\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get deliveries\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/webhooks/{id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ставит в очередь новую доставку с тем же телом, что и у указанной. Исходная запись журнала не меняется",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Повторно отправить событие",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор доставки",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Доставка поставлена в очередь",
+                        "schema": {
+                            "$ref": "#/definitions/redeliver.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный идентификатор - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid id\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет доступа - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"unauthorized\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Доставка не найдена - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"delivery not found\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка постановки в очередь - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to redeliver\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/off/within": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Принимает GeoJSON Polygon (геометрию или Feature) и возвращает действующие в указанное время отключения и затронутые ими здания города внутри него. Расстояния считаются от точки lat/lon, а если она не задана - от центра многоугольника",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "map"
+                ],
+                "summary": "Отключения внутри области",
+                "parameters": [
+                    {
+                        "description": "GeoJSON Polygon или Feature с ним",
+                        "name": "polygon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/geojson.Geometry"
+                        }
+                    },
+                    {
+                        "type": "number",
+                        "example": 43.1155,
+                        "description": "Широта точки отсчета",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "example": 131.8869,
+                        "description": "Долгота точки отсчета",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-01-15_14:30:00",
+                        "description": "Время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS",
+                        "name": "curr_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отключения в области",
+                        "schema": {
+                            "$ref": "#/definitions/within.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"invalid polygon\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения данных - пример: {\\\"status\\\":\\\"ERROR\\\",\\\"error\\\":\\\"failed to get blackouts within polygon\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "analytics.DurationStats": {
+            "description": "Статистика длительности отключений",
+            "type": "object",
+            "properties": {
+                "building_hours": {
+                    "description": "Потерянные здание-часы: длительность, умноженная на число зданий",
+                    "type": "number",
+                    "example": 1520.5
+                },
+                "key": {
+                    "description": "Тип услуги или организация, пусто для итога",
+                    "type": "string",
+                    "example": "hot_water"
+                },
+                "mean_hours": {
+                    "description": "Средняя длительность завершенных отключений в часах",
+                    "type": "number",
+                    "example": 9.5
+                },
+                "median_hours": {
+                    "description": "Медианная длительность в часах",
+                    "type": "number",
+                    "example": 8
+                },
+                "open": {
+                    "description": "Количество отключений без даты окончания",
+                    "type": "integer",
+                    "example": 3
+                },
+                "open_share": {
+                    "description": "Доля отключений без даты окончания в процентах",
+                    "type": "number",
+                    "example": 7.14
+                },
+                "outages": {
+                    "description": "Количество отключений",
+                    "type": "integer",
+                    "example": 42
+                },
+                "p90_hours": {
+                    "description": "90-й перцентиль длительности в часах",
+                    "type": "number",
+                    "example": 24
+                }
+            }
+        },
+        "analytics.MonthTrend": {
+            "description": "Показатели за месяц и изменение относительно предыдущего",
+            "type": "object",
+            "properties": {
+                "building_hours": {
+                    "description": "Потерянные здание-часы",
+                    "type": "number",
+                    "example": 1520.5
+                },
+                "building_hours_change": {
+                    "description": "Изменение здание-часов к предыдущему месяцу в процентах, null если сравнивать не с чем",
+                    "type": "number",
+                    "example": 4.2
+                },
+                "mean_hours": {
+                    "description": "Средняя длительность завершенных отключений в часах",
+                    "type": "number",
+                    "example": 9.5
+                },
+                "mean_hours_change": {
+                    "description": "Изменение средней длительности к предыдущему месяцу в процентах, null если сравнивать не с чем",
+                    "type": "number",
+                    "example": -12.5
+                },
+                "month": {
+                    "description": "Месяц в формате YYYY-MM",
+                    "type": "string",
+                    "example": "2019-12"
+                },
+                "outages": {
+                    "description": "Количество отключений",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "anomalies.Response": {
+            "description": "Обнаруженные всплески отключений",
+            "type": "object",
+            "properties": {
+                "anomalies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Anomaly"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "blackouts.BlackoutInfo": {
             "description": "Информация об отключении конкретного типа",
             "type": "object",
             "properties": {
-                "count_buildings": {
-                    "description": "Количество затронутых зданий",
+                "comparison": {
+                    "description": "Сравнение с базовым периодом, только с параметром compare",
+                    "$ref": "#/definitions/blackouts.Comparison"
+                },
+                "count_buildings": {
+                    "description": "Количество затронутых зданий",
+                    "type": "integer",
+                    "example": 15
+                },
+                "fraction_buildings": {
+                    "description": "Доля затронутых зданий в процентах",
+                    "type": "number",
+                    "example": 25.5
+                },
+                "time_last_blackout": {
+                    "description": "Время последнего отключения в формате \"2006-01-02 15:04:05\"",
+                    "type": "string",
+                    "example": "2019-01-15 14:30:00"
+                },
+                "type": {
+                    "description": "Тип отключения: hot_water (горячая вода), cold_water (холодная вода), electricity (электричество), heat (отопление)",
+                    "type": "string",
+                    "example": "hot_water"
+                }
+            }
+        },
+        "blackouts.Comparison": {
+            "description": "Изменение по сравнению с базовым периодом",
+            "type": "object",
+            "properties": {
+                "baseline_count": {
+                    "description": "Количество затронутых зданий в базовый момент",
+                    "type": "integer",
+                    "example": 10
+                },
+                "baseline_fraction": {
+                    "description": "Доля затронутых зданий в базовый момент в процентах",
+                    "type": "number",
+                    "example": 17
+                },
+                "baseline_time": {
+                    "description": "Момент, с которым сравнивается curr_time",
+                    "type": "string",
+                    "example": "2019-01-08 14:30:00"
+                },
+                "delta_count": {
+                    "description": "Изменение количества зданий",
+                    "type": "integer",
+                    "example": 5
+                },
+                "delta_fraction": {
+                    "description": "Изменение доли в процентных пунктах",
+                    "type": "number",
+                    "example": 8.5
+                },
+                "percent_delta": {
+                    "description": "Изменение количества зданий в процентах, null если в базовый момент отключений не было",
+                    "type": "number",
+                    "example": 50
+                },
+                "period": {
+                    "description": "Базовый период: day (день назад), week (неделя назад), year (год назад)",
+                    "type": "string",
+                    "example": "week"
+                },
+                "top_organizations": {
+                    "description": "Организации, больше всего повлиявшие на изменение",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/blackouts.OrganizationChange"
+                    }
+                }
+            }
+        },
+        "blackouts.OrganizationChange": {
+            "description": "Вклад организации в изменение",
+            "type": "object",
+            "properties": {
+                "baseline_count": {
+                    "description": "Затронутые здания в базовый момент",
+                    "type": "integer",
+                    "example": 3
+                },
+                "count": {
+                    "description": "Затронутые здания сейчас",
+                    "type": "integer",
+                    "example": 8
+                },
+                "delta": {
+                    "description": "Изменение количества зданий",
+                    "type": "integer",
+                    "example": 5
+                },
+                "name": {
+                    "description": "Организация-инициатор отключений",
+                    "type": "string",
+                    "example": "КГУП Приморский водоканал"
+                }
+            }
+        },
+        "blackouts.Response": {
+            "description": "Информация о текущих отключениях",
+            "type": "object",
+            "properties": {
+                "blackouts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/blackouts.BlackoutInfo"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "calendar.DateInfo": {
+            "description": "Информация об отключениях для конкретной даты",
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Дата в формате YYYY-MM-DD",
+                    "type": "string",
+                    "example": "2019-01-15"
+                },
+                "kinds": {
+                    "description": "Виды отключений в эту дату: planned, emergency, unknown",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "planned",
+                        "emergency"
+                    ]
+                },
+                "likely": {
+                    "description": "Вероятные отключения для будущих дней, только с параметром forecast=true",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forecast.Likely"
+                    }
+                },
+                "services": {
+                    "description": "Список типов отключений в эту дату: hot_water, cold_water, electricity, heat",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "hot_water",
+                        "cold_water"
+                    ]
+                }
+            }
+        },
+        "calendar.InfoOffs": {
+            "description": "Детальная информация об отключениях услуг за конкретный день",
+            "type": "object",
+            "properties": {
+                "amount_addresses": {
+                    "description": "Количество затронутых адресов/зданий",
+                    "type": "integer",
+                    "example": 25
+                },
+                "end_off": {
+                    "description": "Дата и время окончания отключения в формате YYYY-MM-DD HH:MM:SS",
+                    "type": "string",
+                    "example": "2019-01-15 18:00:00"
+                },
+                "kind": {
+                    "description": "Вид отключения: planned (плановое), emergency (аварийное), unknown (не определен)",
+                    "type": "string",
+                    "example": "planned"
+                },
+                "service": {
+                    "description": "Тип отключенной услуги: hot_water, cold_water, electricity, heat",
+                    "type": "string",
+                    "example": "hot_water"
+                },
+                "start_off": {
+                    "description": "Дата и время начала отключения в формате YYYY-MM-DD HH:MM:SS",
+                    "type": "string",
+                    "example": "2019-01-15 10:00:00"
+                }
+            }
+        },
+        "cities.Response": {
+            "description": "Обслуживаемые города",
+            "type": "object",
+            "properties": {
+                "cities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.City"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "complaints.Response": {
+            "description": "Ответ с данными жалоб для построения графиков",
+            "type": "object",
+            "properties": {
+                "complaints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ComplaintData"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "confirm.Response": {
+            "description": "Ответ с подтвержденной подпиской",
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Адрес подписки",
+                    "type": "string",
+                    "example": "Карбышева ул. 54"
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "mode": {
+                    "description": "Режим подписки: instant или digest",
+                    "type": "string",
+                    "example": "instant"
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "deliveries.Response": {
+            "description": "Журнал доставок подписки, новые записи первыми",
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebhookDelivery"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "districts.DistrictInfo": {
+            "description": "Статистика отключений по району",
+            "type": "object",
+            "properties": {
+                "count_buildings": {
+                    "description": "Количество зданий хотя бы с одним отключением",
+                    "type": "integer",
+                    "example": 150
+                },
+                "fraction_buildings": {
+                    "description": "Доля затронутых зданий в процентах",
+                    "type": "number",
+                    "example": 12.5
+                },
+                "name": {
+                    "description": "Название района",
+                    "type": "string",
+                    "example": "Ленинский"
+                },
+                "total_buildings": {
+                    "description": "Количество зданий в районе",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "types": {
+                    "description": "Статистика по типам отключений",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/districts.TypeInfo"
+                    }
+                }
+            }
+        },
+        "districts.Response": {
+            "description": "Рейтинг районов по доле затронутых отключениями зданий",
+            "type": "object",
+            "properties": {
+                "districts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/districts.DistrictInfo"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "districts.TypeInfo": {
+            "description": "Доля зданий района, затронутых отключением одного типа",
+            "type": "object",
+            "properties": {
+                "count_buildings": {
+                    "description": "Количество затронутых зданий",
+                    "type": "integer",
+                    "example": 90
+                },
+                "fraction_buildings": {
+                    "description": "Доля затронутых зданий в процентах",
+                    "type": "number",
+                    "example": 7.5
+                },
+                "type": {
+                    "description": "Тип отключения: hot_water, cold_water, electricity, heat",
+                    "type": "string",
+                    "example": "hot_water"
+                }
+            }
+        },
+        "durations.Response": {
+            "description": "Аналитика длительности отключений за период",
+            "type": "object",
+            "properties": {
+                "by_organization": {
+                    "description": "По организациям, от наибольших потерь здание-часов",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.DurationStats"
+                    }
+                },
+                "by_type": {
+                    "description": "По типам услуг",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.DurationStats"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "from": {
+                    "description": "Начало периода",
+                    "type": "string",
+                    "example": "2019-10-01"
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                },
+                "to": {
+                    "description": "Конец периода",
+                    "type": "string",
+                    "example": "2019-12-31"
+                },
+                "total": {
+                    "description": "Итог по всем отключениям",
+                    "$ref": "#/definitions/analytics.DurationStats"
+                },
+                "trend": {
+                    "description": "Помесячная динамика",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.MonthTrend"
+                    }
+                }
+            }
+        },
+        "events.Blackout": {
+            "description": "Отключение, к которому относится событие",
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Address"
+                    }
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Плановые работы"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2019-01-15 18:00:00"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "b1f4"
+                },
+                "initiator_name": {
+                    "type": "string",
+                    "example": "КГУП Приморский водоканал"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2019-01-15 10:00:00"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "hot_water"
+                }
+            }
+        },
+        "events.Event": {
+            "description": "Событие об изменении состояния отключений",
+            "type": "object",
+            "properties": {
+                "blackout": {
+                    "description": "Отключение, к которому относится событие",
+                    "$ref": "#/definitions/events.Blackout"
+                },
+                "city": {
+                    "description": "Код города события, у stream.reset отсутствует",
+                    "type": "string",
+                    "example": "vladivostok"
+                },
+                "counts": {
+                    "description": "Количество затронутых зданий по типам отключений",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "description": "Порядковый номер события, используется как id в SSE",
+                    "type": "integer",
+                    "example": 42
+                },
+                "time": {
+                    "description": "Время обнаружения события в формате \"2006-01-02 15:04:05\"",
+                    "type": "string",
+                    "example": "2019-01-15 14:30:00"
+                },
+                "type": {
+                    "description": "Тип события: blackout.created, blackout.started, blackout.ended, blackout.changed, counts.changed, stream.reset",
+                    "type": "string",
+                    "example": "blackout.started"
+                }
+            }
+        },
+        "forecast.Likely": {
+            "description": "Вероятное отключение",
+            "type": "object",
+            "properties": {
+                "probability": {
+                    "description": "Наибольшая вероятность среди улиц",
+                    "type": "number",
+                    "example": 0.42
+                },
+                "service": {
+                    "description": "Тип услуги",
+                    "type": "string",
+                    "example": "hot_water"
+                },
+                "streets": {
+                    "description": "Улицы с наибольшей вероятностью отключения",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forecast.StreetProbability"
+                    }
+                }
+            }
+        },
+        "forecast.StreetProbability": {
+            "description": "Вероятность отключения на улице",
+            "type": "object",
+            "properties": {
+                "probability": {
+                    "description": "Вероятность отключения в этот день",
+                    "type": "number",
+                    "example": 0.42
+                },
+                "street": {
+                    "description": "Улица",
+                    "type": "string",
+                    "example": "Карбышева ул."
+                }
+            }
+        },
+        "geojson.Feature": {
+            "description": "GeoJSON Feature",
+            "type": "object",
+            "properties": {
+                "geometry": {
+                    "$ref": "#/definitions/geojson.Geometry"
+                },
+                "properties": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "any"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Feature"
+                }
+            }
+        },
+        "geojson.FeatureCollection": {
+            "description": "GeoJSON FeatureCollection",
+            "type": "object",
+            "properties": {
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/geojson.Feature"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "FeatureCollection"
+                }
+            }
+        },
+        "geojson.Geometry": {
+            "description": "GeoJSON Geometry",
+            "type": "object",
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    },
+                    "example": [
+                        131.8869,
+                        43.1155
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Point"
+                }
+            }
+        },
+        "history.Response": {
+            "description": "История изменений отключения",
+            "type": "object",
+            "properties": {
+                "blackout_id": {
+                    "description": "Идентификатор отключения",
+                    "type": "string",
+                    "example": "b1f4"
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "history": {
+                    "description": "Ревизии от старых к новым",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/history.RevisionInfo"
+                    }
+                },
+                "state": {
+                    "description": "Текущее состояние: announced, active, extended, resolved, cancelled",
+                    "type": "string",
+                    "example": "extended"
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "history.RevisionInfo": {
+            "description": "Ревизия отключения и измененные в ней поля",
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "Кто внес изменение: source (загрузка данных), system (смена состояния по времени) или имя оператора",
+                    "type": "string",
+                    "example": "source"
+                },
+                "blackout_id": {
+                    "description": "Идентификатор отключения",
+                    "type": "string",
+                    "example": "b1f4"
+                },
+                "changed_at": {
+                    "description": "Время изменения",
+                    "type": "string",
+                    "example": "2019-01-15 16:05:00"
+                },
+                "changes": {
+                    "description": "Изменения по сравнению с предыдущей ревизией, пусто для первой",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RevisionChange"
+                    }
+                },
+                "description": {
+                    "description": "Описание",
+                    "type": "string",
+                    "example": "Плановые работы"
+                },
+                "end_date": {
+                    "description": "Окончание отключения, пусто если не известно",
+                    "type": "string",
+                    "example": "2019-01-15 22:00:00"
+                },
+                "id": {
+                    "description": "Идентификатор ревизии",
+                    "type": "integer",
+                    "example": 12
+                },
+                "start_date": {
+                    "description": "Начало отключения",
+                    "type": "string",
+                    "example": "2019-01-15 10:00:00"
+                },
+                "state": {
+                    "description": "Состояние: announced, active, extended, resolved, cancelled",
+                    "type": "string",
+                    "example": "extended"
+                }
+            }
+        },
+        "hotspots.Response": {
+            "description": "Адреса с хроническими отключениями",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "hotspots": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Hotspot"
+                    }
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "internal_http-server_handlers_cache_stats.Response": {
+            "description": "Статистика кэша ответов",
+            "type": "object",
+            "properties": {
+                "cache": {
+                    "$ref": "#/definitions/models.CacheStats"
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "internal_http-server_handlers_calendar_day_get.Response": {
+            "description": "Ответ с детальной информацией об отключениях за конкретный день",
+            "type": "object",
+            "properties": {
+                "blackouts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar.InfoOffs"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "internal_http-server_handlers_calendar_month_get.Response": {
+            "description": "Ответ с данными для календаря отключений по дням месяца",
+            "type": "object",
+            "properties": {
+                "dates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar.DateInfo"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "internal_http-server_handlers_ratelimit_stats.Response": {
+            "description": "Статистика ограничения частоты запросов",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "rate_limit": {
+                    "$ref": "#/definitions/models.RateLimitStats"
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "list.Response": {
+            "description": "Список подписок на события",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                },
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Webhook"
+                    }
+                }
+            }
+        },
+        "models.Address": {
+            "description": "Адрес здания, затронутого отключением",
+            "type": "object",
+            "properties": {
+                "building_id": {
+                    "description": "Идентификатор здания",
                     "type": "integer",
-                    "example": 15
+                    "example": 1024
                 },
-                "fraction_buildings": {
-                    "description": "Доля затронутых зданий в процентах",
+                "number": {
+                    "description": "Номер дома",
+                    "type": "string",
+                    "example": "54"
+                },
+                "street": {
+                    "description": "Название улицы",
+                    "type": "string",
+                    "example": "Карбышева ул."
+                }
+            }
+        },
+        "models.AffectedBuilding": {
+            "description": "Здание с действующими отключениями",
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Адрес здания",
+                    "type": "string",
+                    "example": "Карбышева ул. 54"
+                },
+                "blackouts": {
+                    "description": "Идентификаторы отключений",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "b1f4"
+                    ]
+                },
+                "building_id": {
+                    "description": "Идентификатор здания",
+                    "type": "integer",
+                    "example": 1024
+                },
+                "distance_m": {
+                    "description": "Расстояние от точки запроса в метрах",
                     "type": "number",
-                    "example": 25.5
+                    "example": 120.5
                 },
-                "time_last_blackout": {
-                    "description": "Время последнего отключения в формате \"2006-01-02 15:04:05\"",
+                "latitude": {
+                    "description": "Широта",
+                    "type": "number",
+                    "example": 43.1155
+                },
+                "longitude": {
+                    "description": "Долгота",
+                    "type": "number",
+                    "example": 131.8869
+                },
+                "services": {
+                    "description": "Типы отключенных услуг",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "hot_water"
+                    ]
+                }
+            }
+        },
+        "models.Anomaly": {
+            "description": "Всплеск числа новых отключений одного типа",
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "description": "Среднее за тот же час предыдущих недель",
+                    "type": "number",
+                    "example": 1.5
+                },
+                "count": {
+                    "description": "Количество новых отключений за час",
+                    "type": "integer",
+                    "example": 12
+                },
+                "detected_at": {
+                    "description": "Время обнаружения",
                     "type": "string",
-                    "example": "2019-01-15 14:30:00"
+                    "example": "2019-12-30 14:25:00"
+                },
+                "hour": {
+                    "description": "Начало часа",
+                    "type": "string",
+                    "example": "2019-12-30 14:00:00"
+                },
+                "id": {
+                    "description": "Идентификатор",
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "Вид отключений, только с параметром kind",
+                    "type": "string",
+                    "example": "emergency"
+                },
+                "stddev": {
+                    "description": "Стандартное отклонение за тот же час предыдущих недель",
+                    "type": "number",
+                    "example": 0.9
                 },
                 "type": {
-                    "description": "Тип отключения: hot_water (горячая вода), cold_water (холодная вода), electricity (электричество), heat (отопление)",
+                    "description": "Тип услуги",
+                    "type": "string",
+                    "example": "electricity"
+                },
+                "z_score": {
+                    "description": "Отклонение в стандартных отклонениях",
+                    "type": "number",
+                    "example": 11.7
+                }
+            }
+        },
+        "models.CacheStats": {
+            "description": "Статистика кэша ответов",
+            "type": "object",
+            "properties": {
+                "endpoints": {
+                    "description": "Счетчики по endpoints",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EndpointCacheStats"
+                    }
+                },
+                "entries": {
+                    "description": "Число ответов в памяти",
+                    "type": "integer",
+                    "example": 120
+                },
+                "evictions": {
+                    "description": "Ответы, вытесненные из памяти",
+                    "type": "integer",
+                    "example": 3
+                },
+                "hits": {
+                    "description": "Попадания в кэш",
+                    "type": "integer",
+                    "example": 950
+                },
+                "invalidations": {
+                    "description": "Сбросы кэша после изменения данных",
+                    "type": "integer",
+                    "example": 2
+                },
+                "max_entries": {
+                    "description": "Наибольшее число ответов в памяти",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "misses": {
+                    "description": "Промахи",
+                    "type": "integer",
+                    "example": 50
+                },
+                "version": {
+                    "description": "Версия данных, для которой хранятся ответы",
+                    "type": "string",
+                    "example": "1730000000-52428800-7"
+                }
+            }
+        },
+        "models.City": {
+            "description": "Город",
+            "type": "object",
+            "properties": {
+                "buildings_total": {
+                    "description": "Число зданий для расчета долей, 0 - по зданиям в базе",
+                    "type": "integer",
+                    "example": 0
+                },
+                "code": {
+                    "description": "Код города, используется в адресе /{city}/off и заголовке X-City",
+                    "type": "string",
+                    "example": "vladivostok"
+                },
+                "is_default": {
+                    "description": "Город по умолчанию для маршрутов /off",
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "description": "Название",
+                    "type": "string",
+                    "example": "Владивосток"
+                },
+                "timezone": {
+                    "description": "Часовой пояс, в нем читается время в формате RFC3339",
+                    "type": "string",
+                    "example": "Asia/Vladivostok"
+                }
+            }
+        },
+        "models.ComplaintData": {
+            "description": "Данные жалоб по типам отключений для построения графиков",
+            "type": "object",
+            "properties": {
+                "cold": {
+                    "description": "Количество жалоб на отключение холодной воды, только в режиме совместимости",
+                    "type": "integer",
+                    "example": 3
+                },
+                "counts": {
+                    "description": "Количество жалоб по коду типа услуги, для каждого типа из реестра",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "electricity": {
+                    "description": "Количество жалоб на отключение электричества, только в режиме совместимости",
+                    "type": "integer",
+                    "example": 8
+                },
+                "heating": {
+                    "description": "Количество жалоб на отключение отопления, только в режиме совместимости",
+                    "type": "integer",
+                    "example": 2
+                },
+                "hot": {
+                    "description": "Количество жалоб на отключение горячей воды, только в режиме совместимости",
+                    "type": "integer",
+                    "example": 5
+                },
+                "time": {
+                    "description": "Временная метка точки данных",
+                    "type": "string",
+                    "example": "2019-01-15 14:00:00"
+                }
+            }
+        },
+        "models.EndpointCacheStats": {
+            "description": "Статистика кэша одного endpoint",
+            "type": "object",
+            "properties": {
+                "endpoint": {
+                    "description": "Путь endpoint относительно /off",
+                    "type": "string",
+                    "example": "/calendar"
+                },
+                "hits": {
+                    "description": "Попадания в кэш",
+                    "type": "integer",
+                    "example": 900
+                },
+                "misses": {
+                    "description": "Промахи",
+                    "type": "integer",
+                    "example": 31
+                }
+            }
+        },
+        "models.Hotspot": {
+            "description": "Адрес с повторяющимися отключениями одного типа",
+            "type": "object",
+            "properties": {
+                "building_id": {
+                    "description": "Идентификатор здания, 0 для улицы",
+                    "type": "integer",
+                    "example": 1024
+                },
+                "detected_at": {
+                    "description": "Время анализа",
+                    "type": "string",
+                    "example": "2019-12-30 12:00:00"
+                },
+                "first_at": {
+                    "description": "Начало первого отключения",
+                    "type": "string",
+                    "example": "2019-09-02 09:00:00"
+                },
+                "id": {
+                    "description": "Идентификатор находки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "Вид отключений, только с параметром kind",
+                    "type": "string",
+                    "example": "planned"
+                },
+                "last_at": {
+                    "description": "Начало последнего отключения",
+                    "type": "string",
+                    "example": "2019-12-16 09:00:00"
+                },
+                "number": {
+                    "description": "Номер дома, пусто для улицы",
+                    "type": "string",
+                    "example": "54"
+                },
+                "outages": {
+                    "description": "Всего отключений этого типа",
+                    "type": "integer",
+                    "example": 7
+                },
+                "period_days": {
+                    "description": "Средний интервал между отключениями в днях",
+                    "type": "number",
+                    "example": 14.2
+                },
+                "periodic": {
+                    "description": "Отключения повторяются с примерно одинаковым интервалом",
+                    "type": "boolean",
+                    "example": true
+                },
+                "scope": {
+                    "description": "Уровень: building или street",
+                    "type": "string",
+                    "example": "building"
+                },
+                "street": {
+                    "description": "Улица",
+                    "type": "string",
+                    "example": "Карбышева ул."
+                },
+                "timeline": {
+                    "description": "Отключения по порядку",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HotspotOutage"
+                    }
+                },
+                "type": {
+                    "description": "Тип услуги",
                     "type": "string",
                     "example": "hot_water"
+                },
+                "window_outages": {
+                    "description": "Наибольшее число отключений в одном окне анализа",
+                    "type": "integer",
+                    "example": 4
                 }
             }
         },
-        "blackouts.Response": {
-            "description": "Информация о текущих отключениях",
+        "models.HotspotOutage": {
+            "description": "Отключение в истории адреса",
             "type": "object",
             "properties": {
-                "blackouts": {
+                "end_off": {
+                    "description": "Окончание, пусто если не указано",
+                    "type": "string",
+                    "example": "2019-12-16 18:00:00"
+                },
+                "id": {
+                    "description": "Идентификатор отключения",
+                    "type": "string",
+                    "example": "b1f4"
+                },
+                "start_off": {
+                    "description": "Начало",
+                    "type": "string",
+                    "example": "2019-12-16 09:00:00"
+                }
+            }
+        },
+        "models.NearbyBlackout": {
+            "description": "Отключение, затрагивающее здания в области запроса",
+            "type": "object",
+            "properties": {
+                "buildings_count": {
+                    "description": "Количество зданий в области запроса, затронутых отключением",
+                    "type": "integer",
+                    "example": 3
+                },
+                "description": {
+                    "description": "Описание",
+                    "type": "string",
+                    "example": "Плановые работы"
+                },
+                "distance_m": {
+                    "description": "Расстояние до ближайшего затронутого здания в метрах",
+                    "type": "number",
+                    "example": 120.5
+                },
+                "end_off": {
+                    "description": "Окончание отключения",
+                    "type": "string",
+                    "example": "2019-01-15 18:00:00"
+                },
+                "id": {
+                    "description": "Идентификатор отключения",
+                    "type": "string",
+                    "example": "b1f4"
+                },
+                "initiator_name": {
+                    "description": "Организация-инициатор",
+                    "type": "string",
+                    "example": "КГУП Приморский водоканал"
+                },
+                "service": {
+                    "description": "Тип услуги: hot_water, cold_water, electricity, heat",
+                    "type": "string",
+                    "example": "hot_water"
+                },
+                "start_off": {
+                    "description": "Начало отключения",
+                    "type": "string",
+                    "example": "2019-01-15 10:00:00"
+                }
+            }
+        },
+        "models.RateLimitGroupStats": {
+            "description": "Статистика ограничения одной группы маршрутов",
+            "type": "object",
+            "properties": {
+                "allowed": {
+                    "description": "Пропущенные запросы",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "allowlisted": {
+                    "description": "Запросы адресов из списка разрешенных, они не ограничиваются",
+                    "type": "integer",
+                    "example": 80
+                },
+                "group": {
+                    "description": "Группа маршрутов",
+                    "type": "string",
+                    "example": "search"
+                },
+                "limited": {
+                    "description": "Отклоненные запросы с кодом 429",
+                    "type": "integer",
+                    "example": 35
+                }
+            }
+        },
+        "models.RateLimitStats": {
+            "description": "Статистика ограничения частоты запросов",
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "description": "Клиенты, чьи бакеты еще не наполнились",
+                    "type": "integer",
+                    "example": 42
+                },
+                "groups": {
+                    "description": "Счетчики по группам маршрутов",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/blackouts.BlackoutInfo"
+                        "$ref": "#/definitions/models.RateLimitGroupStats"
                     }
+                }
+            }
+        },
+        "models.RevisionChange": {
+            "description": "Изменение одного поля отключения",
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Поле: start_date, end_date, description или state",
+                    "type": "string",
+                    "example": "end_date"
                 },
-                "error": {
-                    "description": "Сообщение об ошибке (если статус ERROR)",
+                "from": {
+                    "description": "Прежнее значение",
+                    "type": "string",
+                    "example": "2019-01-15 18:00:00"
+                },
+                "to": {
+                    "description": "Новое значение",
+                    "type": "string",
+                    "example": "2019-01-15 22:00:00"
+                }
+            }
+        },
+        "models.ServiceType": {
+            "description": "Тип услуги из реестра",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Код типа, используется во всех ответах и фильтрах",
+                    "type": "string",
+                    "example": "hot_water"
+                },
+                "icon": {
+                    "description": "Ключ иконки на фронтенде",
+                    "type": "string",
+                    "example": "hot-water"
+                },
+                "name_en": {
+                    "description": "Название на английском",
                     "type": "string",
-                    "example": ""
+                    "example": "hot water"
                 },
-                "status": {
-                    "description": "Статус операции: OK или ERROR",
+                "name_ru": {
+                    "description": "Название на русском",
                     "type": "string",
-                    "example": "OK"
+                    "example": "горячая вода"
+                },
+                "position": {
+                    "description": "Порядок отображения",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "calendar.DateInfo": {
-            "description": "Информация об отключениях для конкретной даты",
+        "models.Webhook": {
+            "description": "Подписка на события об отключениях",
             "type": "object",
             "properties": {
-                "date": {
-                    "description": "Дата в формате YYYY-MM-DD",
+                "buildings": {
+                    "description": "Фильтр по идентификаторам зданий",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1024,
+                        1025
+                    ]
+                },
+                "city": {
+                    "description": "Город, события которого отправляются",
                     "type": "string",
-                    "example": "2019-01-15"
+                    "example": "vladivostok"
                 },
-                "services": {
-                    "description": "Список типов отключений в эту дату: hot_water, cold_water, electricity, heat",
+                "created_at": {
+                    "description": "Время создания в формате \"2006-01-02 15:04:05\"",
+                    "type": "string",
+                    "example": "2019-01-15 14:30:00"
+                },
+                "id": {
+                    "description": "Идентификатор подписки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "organizations": {
+                    "description": "Фильтр по организациям-инициаторам",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "КГУП Приморский водоканал"
+                    ]
+                },
+                "streets": {
+                    "description": "Фильтр по улицам",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Карбышева ул."
+                    ]
+                },
+                "types": {
+                    "description": "Фильтр по типам отключений: hot_water, cold_water, electricity, heat",
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
                         "hot_water",
-                        "cold_water"
+                        "electricity"
                     ]
+                },
+                "url": {
+                    "description": "Адрес, на который отправляются события",
+                    "type": "string",
+                    "example": "https://example.com/hooks/off"
                 }
             }
         },
-        "calendar.InfoOffs": {
-            "description": "Детальная информация об отключениях услуг за конкретный день",
+        "models.WebhookDelivery": {
+            "description": "Доставка события подписчику",
             "type": "object",
             "properties": {
-                "amount_addresses": {
-                    "description": "Количество затронутых адресов/зданий",
+                "attempts": {
+                    "description": "Количество попыток доставки",
                     "type": "integer",
-                    "example": 25
+                    "example": 1
                 },
-                "end_off": {
-                    "description": "Дата и время окончания отключения в формате YYYY-MM-DD HH:MM:SS",
+                "created_at": {
+                    "description": "Время постановки в очередь",
                     "type": "string",
-                    "example": "2019-01-15 18:00:00"
+                    "example": "2019-01-15 14:30:00"
                 },
-                "service": {
-                    "description": "Тип отключенной услуги: hot_water, cold_water, electricity, heat",
+                "delivered_at": {
+                    "description": "Время успешной доставки",
                     "type": "string",
-                    "example": "hot_water"
+                    "example": "2019-01-15 14:30:01"
                 },
-                "start_off": {
-                    "description": "Дата и время начала отключения в формате YYYY-MM-DD HH:MM:SS",
+                "event_type": {
+                    "description": "Тип события",
                     "type": "string",
-                    "example": "2019-01-15 10:00:00"
-                }
-            }
-        },
-        "complaints.Response": {
-            "description": "Ответ с данными жалоб для построения графиков",
-            "type": "object",
-            "properties": {
-                "complaints": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ComplaintData"
-                    }
+                    "example": "blackout.started"
                 },
-                "error": {
-                    "description": "Сообщение об ошибке (если статус ERROR)",
+                "id": {
+                    "description": "Идентификатор доставки",
+                    "type": "integer",
+                    "example": 10
+                },
+                "last_error": {
+                    "description": "Ошибка последней попытки",
                     "type": "string",
                     "example": ""
                 },
+                "last_status_code": {
+                    "description": "HTTP статус последней попытки",
+                    "type": "integer",
+                    "example": 200
+                },
+                "next_attempt_at": {
+                    "description": "Время следующей попытки",
+                    "type": "string",
+                    "example": "2019-01-15 14:31:00"
+                },
+                "payload": {
+                    "description": "Тело запроса, отправляемое подписчику",
+                    "type": "string"
+                },
                 "status": {
-                    "description": "Статус операции: OK или ERROR",
+                    "description": "Статус: pending, succeeded, failed",
                     "type": "string",
-                    "example": "OK"
+                    "example": "pending"
+                },
+                "webhook_id": {
+                    "description": "Идентификатор подписки",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "internal_http-server_handlers_calendar_day_get.Response": {
-            "description": "Ответ с детальной информацией об отключениях за конкретный день",
+        "nearby.Response": {
+            "description": "Отключения и здания в радиусе от точки",
             "type": "object",
             "properties": {
                 "blackouts": {
+                    "description": "Отключения, от ближайшего к дальнему",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/calendar.InfoOffs"
+                        "$ref": "#/definitions/models.NearbyBlackout"
                     }
                 },
-                "error": {
-                    "description": "Сообщение об ошибке (если статус ERROR)",
-                    "type": "string",
-                    "example": ""
-                },
-                "status": {
-                    "description": "Статус операции: OK или ERROR",
-                    "type": "string",
-                    "example": "OK"
-                }
-            }
-        },
-        "internal_http-server_handlers_calendar_month_get.Response": {
-            "description": "Ответ с данными для календаря отключений по дням месяца",
-            "type": "object",
-            "properties": {
-                "dates": {
+                "buildings": {
+                    "description": "Здания с действующими отключениями, от ближайшего к дальнему",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/calendar.DateInfo"
+                        "$ref": "#/definitions/models.AffectedBuilding"
                     }
                 },
                 "error": {
@@ -483,37 +3122,6 @@ const docTemplate = `{
                 }
             }
         },
-        "models.ComplaintData": {
-            "description": "Данные жалоб по типам отключений для построения графиков",
-            "type": "object",
-            "properties": {
-                "cold": {
-                    "description": "Количество жалоб на отключение холодной воды",
-                    "type": "integer",
-                    "example": 3
-                },
-                "electricity": {
-                    "description": "Количество жалоб на отключение электричества",
-                    "type": "integer",
-                    "example": 8
-                },
-                "heating": {
-                    "description": "Количество жалоб на отключение отопления",
-                    "type": "integer",
-                    "example": 2
-                },
-                "hot": {
-                    "description": "Количество жалоб на отключение горячей воды",
-                    "type": "integer",
-                    "example": 5
-                },
-                "time": {
-                    "description": "Временная метка точки данных",
-                    "type": "string",
-                    "example": "2019-01-15 14:00:00"
-                }
-            }
-        },
         "organizations.OrganizationInfo": {
             "description": "Информация об организации и её отключениях",
             "type": "object",
@@ -562,6 +3170,27 @@ const docTemplate = `{
                 }
             }
         },
+        "redeliver.Response": {
+            "description": "Ответ с идентификатором новой доставки",
+            "type": "object",
+            "properties": {
+                "delivery_id": {
+                    "description": "Идентификатор новой доставки",
+                    "type": "integer",
+                    "example": 11
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
         "response.Response": {
             "description": "Стандартный ответ API",
             "type": "object",
@@ -578,6 +3207,90 @@ const docTemplate = `{
                 }
             }
         },
+        "save.Request": {
+            "description": "Запрос на создание подписки на события",
+            "type": "object",
+            "properties": {
+                "buildings": {
+                    "description": "Фильтр по идентификаторам зданий",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1024,
+                        1025
+                    ]
+                },
+                "organizations": {
+                    "description": "Фильтр по организациям-инициаторам",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "КГУП Приморский водоканал"
+                    ]
+                },
+                "secret": {
+                    "description": "Секрет для подписи, если не задан - будет сгенерирован",
+                    "type": "string",
+                    "example": "s3cr3t"
+                },
+                "streets": {
+                    "description": "Фильтр по улицам",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Карбышева ул."
+                    ]
+                },
+                "types": {
+                    "description": "Фильтр по типам отключений: hot_water, cold_water, electricity, heat",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "hot_water",
+                        "electricity"
+                    ]
+                },
+                "url": {
+                    "description": "Адрес получателя, http или https",
+                    "type": "string",
+                    "example": "https://example.com/hooks/off"
+                }
+            }
+        },
+        "save.Response": {
+            "description": "Ответ с созданной подпиской. Секрет возвращается только один раз",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "id": {
+                    "description": "Идентификатор подписки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "secret": {
+                    "description": "Секрет для проверки подписи X-Off-Signature",
+                    "type": "string",
+                    "example": "s3cr3t"
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
         "search.Request": {
             "description": "Запрос для поиска улиц по подстроке",
             "type": "object",
@@ -616,6 +3329,84 @@ const docTemplate = `{
                     ]
                 }
             }
+        },
+        "servicetypes.Response": {
+            "description": "Реестр типов услуг",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "service_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ServiceType"
+                    }
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "subscribe.Request": {
+            "description": "Запрос на подписку адреса на уведомления об отключениях",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Адрес электронной почты",
+                    "type": "string",
+                    "example": "resident@example.com"
+                },
+                "mode": {
+                    "description": "Режим: instant (письмо о каждом отключении) или digest (ежедневная сводка)",
+                    "type": "string",
+                    "example": "instant"
+                },
+                "number": {
+                    "description": "Номер дома",
+                    "type": "string",
+                    "example": "54"
+                },
+                "street": {
+                    "description": "Улица, как её возвращает /off/search",
+                    "type": "string",
+                    "example": "Карбышева ул."
+                }
+            }
+        },
+        "within.Response": {
+            "description": "Отключения и здания внутри многоугольника",
+            "type": "object",
+            "properties": {
+                "blackouts": {
+                    "description": "Отключения, от ближайшего к дальнему",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NearbyBlackout"
+                    }
+                },
+                "buildings": {
+                    "description": "Здания с действующими отключениями, от ближайшего к дальнему",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AffectedBuilding"
+                    }
+                },
+                "error": {
+                    "description": "Сообщение об ошибке (если статус ERROR)",
+                    "type": "string",
+                    "example": ""
+                },
+                "status": {
+                    "description": "Статус операции: OK или ERROR",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -637,8 +3428,6 @@ var SwaggerInfo = &swag.Spec{
 	Description:      "API для системы VLRU-PRSCH",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
-	// LeftDelim:        "{{",
-	// RightDelim:       "}}",
 }
 
 func init() {
@@ -0,0 +1,240 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/summary"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// DurationStats describes how long the outages of one group lasted
+// @Description Статистика длительности отключений
+type DurationStats struct {
+	// Тип услуги или организация, пусто для итога
+	Key string `json:"key,omitempty" example:"hot_water"`
+	// Количество отключений
+	Outages int `json:"outages" example:"42"`
+	// Количество отключений без даты окончания
+	Open int `json:"open" example:"3"`
+	// Доля отключений без даты окончания в процентах
+	OpenShare float64 `json:"open_share" example:"7.14"`
+	// Средняя длительность завершенных отключений в часах
+	MeanHours float64 `json:"mean_hours" example:"9.5"`
+	// Медианная длительность в часах
+	MedianHours float64 `json:"median_hours" example:"8"`
+	// 90-й перцентиль длительности в часах
+	P90Hours float64 `json:"p90_hours" example:"24"`
+	// Потерянные здание-часы: длительность, умноженная на число зданий
+	BuildingHours float64 `json:"building_hours" example:"1520.5"`
+}
+
+// MonthTrend holds the figures of one calendar month and the change against the previous one
+// @Description Показатели за месяц и изменение относительно предыдущего
+type MonthTrend struct {
+	// Месяц в формате YYYY-MM
+	Month string `json:"month" example:"2019-12"`
+	// Количество отключений
+	Outages int `json:"outages" example:"42"`
+	// Средняя длительность завершенных отключений в часах
+	MeanHours float64 `json:"mean_hours" example:"9.5"`
+	// Потерянные здание-часы
+	BuildingHours float64 `json:"building_hours" example:"1520.5"`
+	// Изменение средней длительности к предыдущему месяцу в процентах, null если сравнивать не с чем
+	MeanHoursChange *float64 `json:"mean_hours_change" example:"-12.5"`
+	// Изменение здание-часов к предыдущему месяцу в процентах, null если сравнивать не с чем
+	BuildingHoursChange *float64 `json:"building_hours_change" example:"4.2"`
+}
+
+// DurationReport groups duration statistics of a period
+type DurationReport struct {
+	Total          DurationStats
+	ByType         []DurationStats
+	ByOrganization []DurationStats
+	Trend          []MonthTrend
+}
+
+type group struct {
+	outages       int
+	open          int
+	hours         []float64
+	buildingHours float64
+}
+
+func (g *group) add(hours float64, open bool, buildings int64) {
+	g.outages++
+	if open {
+		g.open++
+	} else {
+		g.hours = append(g.hours, hours)
+	}
+	g.buildingHours += hours * float64(buildings)
+}
+
+func (g *group) stats(key string) DurationStats {
+	sort.Float64s(g.hours)
+
+	return DurationStats{
+		Key:           key,
+		Outages:       g.outages,
+		Open:          g.open,
+		OpenShare:     summary.Percentage(int64(g.open), int64(g.outages)),
+		MeanHours:     round(mean(g.hours)),
+		MedianHours:   round(percentile(g.hours, 50)),
+		P90Hours:      round(percentile(g.hours, 90)),
+		BuildingHours: round(g.buildingHours),
+	}
+}
+
+// Durations computes duration statistics of the blackouts. An outage without an end
+// is excluded from the duration figures and counted until rangeEnd in building-hours.
+// Months run from the first to the last month of the blackouts, empty ones included.
+// Types are reported in the order of types, the others are left out.
+func Durations(blackouts []models.BlackoutWithBuildings, types []string, rangeEnd time.Time) DurationReport {
+	var total group
+	byType := map[string]*group{}
+	byOrganization := map[string]*group{}
+	byMonth := map[string]*group{}
+
+	var firstMonth, lastMonth time.Time
+
+	for _, blackout := range blackouts {
+		start, err := time.Parse(timeLayout, blackout.StartDate)
+		if err != nil {
+			continue
+		}
+
+		open := blackout.EndDate == ""
+		end := rangeEnd
+		if !open {
+			end, err = time.Parse(timeLayout, blackout.EndDate)
+			if err != nil {
+				continue
+			}
+		}
+
+		// a broken record would pull every figure below zero
+		hours := end.Sub(start).Hours()
+		if hours < 0 {
+			continue
+		}
+
+		month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if firstMonth.IsZero() || month.Before(firstMonth) {
+			firstMonth = month
+		}
+		if month.After(lastMonth) {
+			lastMonth = month
+		}
+
+		for _, g := range []*group{
+			&total,
+			groupOf(byType, blackout.Type),
+			groupOf(byOrganization, blackout.InitiatorName),
+			groupOf(byMonth, month.Format("2006-01")),
+		} {
+			g.add(hours, open, blackout.BuildingsCount)
+		}
+	}
+
+	report := DurationReport{
+		Total:          total.stats(""),
+		ByType:         []DurationStats{},
+		ByOrganization: []DurationStats{},
+		Trend:          []MonthTrend{},
+	}
+
+	for _, blackoutType := range types {
+		if g, ok := byType[blackoutType]; ok {
+			report.ByType = append(report.ByType, g.stats(blackoutType))
+		}
+	}
+
+	for name, g := range byOrganization {
+		report.ByOrganization = append(report.ByOrganization, g.stats(name))
+	}
+	sort.Slice(report.ByOrganization, func(i, j int) bool {
+		a, b := report.ByOrganization[i], report.ByOrganization[j]
+		if a.BuildingHours != b.BuildingHours {
+			return a.BuildingHours > b.BuildingHours
+		}
+		return a.Key < b.Key
+	})
+
+	if firstMonth.IsZero() {
+		return report
+	}
+
+	var previous MonthTrend
+	for month := firstMonth; !month.After(lastMonth); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+
+		trend := MonthTrend{Month: key}
+		if g, ok := byMonth[key]; ok {
+			stats := g.stats(key)
+			trend.Outages = stats.Outages
+			trend.MeanHours = stats.MeanHours
+			trend.BuildingHours = stats.BuildingHours
+		}
+
+		if month.After(firstMonth) {
+			trend.MeanHoursChange = change(previous.MeanHours, trend.MeanHours)
+			trend.BuildingHoursChange = change(previous.BuildingHours, trend.BuildingHours)
+		}
+
+		report.Trend = append(report.Trend, trend)
+		previous = trend
+	}
+
+	return report
+}
+
+func groupOf(groups map[string]*group, key string) *group {
+	g, ok := groups[key]
+	if !ok {
+		g = &group{}
+		groups[key] = g
+	}
+	return g
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// percentile uses linear interpolation between the closest ranks of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// change returns the relative change in percent, nil when the base is zero
+func change(from, to float64) *float64 {
+	if from == 0 {
+		return nil
+	}
+
+	value := round((to - from) / from * 100)
+	return &value
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
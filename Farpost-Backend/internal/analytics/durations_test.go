@@ -0,0 +1,140 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{"empty", nil, 50, 0},
+		{"one value", []float64{7}, 90, 7},
+		{"median of odd", []float64{1, 2, 10}, 50, 2},
+		{"median of even interpolates", []float64{1, 2, 3, 10}, 50, 2.5},
+		{"p90 interpolates", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 20}, 90, 10},
+		{"p90 between ranks", []float64{0, 10}, 90, 9},
+		{"minimum", []float64{3, 4, 5}, 0, 3},
+		{"maximum", []float64{3, 4, 5}, 100, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to float64
+		want     *float64
+	}{
+		{"no base", 0, 5, nil},
+		{"no base and nothing now", 0, 0, nil},
+		{"growth", 4, 5, ptr(25)},
+		{"drop", 8, 7, ptr(-12.5)},
+		{"to zero", 3, 0, ptr(-100)},
+		{"rounded", 3, 4, ptr(33.33)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := change(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func blackout(blackoutType, organization, start, end string, buildings int64) models.BlackoutWithBuildings {
+	return models.BlackoutWithBuildings{
+		Blackout: models.Blackout{
+			Type:          blackoutType,
+			InitiatorName: organization,
+			StartDate:     start,
+			EndDate:       end,
+		},
+		BuildingsCount: buildings,
+	}
+}
+
+func TestDurations(t *testing.T) {
+	rangeEnd := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+
+	report := Durations([]models.BlackoutWithBuildings{
+		blackout("hot_water", "Водоканал", "2024-01-10 08:00:00", "2024-01-10 10:00:00", 10),
+		blackout("hot_water", "Водоканал", "2024-01-20 08:00:00", "2024-01-20 14:00:00", 1),
+		blackout("electricity", "Дальэнерго", "2024-03-01 00:00:00", "2024-03-01 04:00:00", 5),
+		// open ended, counted until the end of the range in building-hours only
+		blackout("electricity", "Дальэнерго", "2024-03-31 20:00:00", "", 2),
+		// broken records are skipped
+		blackout("heat", "Теплосеть", "2024-03-05 10:00:00", "2024-03-05 09:00:00", 1),
+		blackout("heat", "Теплосеть", "not a date", "", 1),
+	}, models.DefaultServiceTypes().Codes(), rangeEnd)
+
+	wantTotal := DurationStats{
+		Outages:       4,
+		Open:          1,
+		OpenShare:     25,
+		MeanHours:     4,
+		MedianHours:   4,
+		P90Hours:      5.6,
+		BuildingHours: 20 + 6 + 20 + 8,
+	}
+	if report.Total != wantTotal {
+		t.Errorf("got total %+v, want %+v", report.Total, wantTotal)
+	}
+
+	var types []string
+	for _, stats := range report.ByType {
+		types = append(types, stats.Key)
+	}
+	if !reflect.DeepEqual(types, []string{"hot_water", "electricity"}) {
+		t.Errorf("got types %v, want registry order", types)
+	}
+
+	if len(report.ByOrganization) != 2 || report.ByOrganization[0].Key != "Дальэнерго" {
+		t.Errorf("got organizations %+v, want Дальэнерго with most building-hours first", report.ByOrganization)
+	}
+
+	wantTrend := []MonthTrend{
+		{Month: "2024-01", Outages: 2, MeanHours: 4, BuildingHours: 26},
+		{Month: "2024-02", MeanHoursChange: ptr(-100), BuildingHoursChange: ptr(-100)},
+		{Month: "2024-03", Outages: 2, MeanHours: 4, BuildingHours: 28},
+	}
+	if !reflect.DeepEqual(report.Trend, wantTrend) {
+		t.Errorf("got trend %+v, want %+v", report.Trend, wantTrend)
+	}
+}
+
+func TestDurationsEmpty(t *testing.T) {
+	report := Durations(nil, nil, time.Now())
+
+	if report.Total != (DurationStats{}) || len(report.ByType) != 0 || len(report.ByOrganization) != 0 || len(report.Trend) != 0 {
+		t.Errorf("got %+v, want an empty report", report)
+	}
+	if report.ByType == nil || report.Trend == nil {
+		t.Error("empty lists must render as [], not null")
+	}
+}
+
+func ptr(value float64) *float64 {
+	return &value
+}
+
+func deref(value *float64) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
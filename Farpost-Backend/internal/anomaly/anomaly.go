@@ -0,0 +1,225 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+const hourLayout = "2006-01-02 15:00:00"
+
+type Storage interface {
+	GetHourlyCounts(from string, to string, filter models.Filter) ([]models.HourlyCount, error)
+	SaveAnomaly(anomaly models.Anomaly) (bool, error)
+}
+
+type Options struct {
+	Interval time.Duration
+	// Weeks of history compared against, the same hour of each week
+	Weeks int
+	// Threshold is the z-score from which an hour is anomalous
+	Threshold float64
+	// MinCount ignores spikes too small to matter, like 1 outage against 0
+	MinCount int
+	// MinStdDev keeps a flat history from turning every extra outage into a spike
+	MinStdDev float64
+	// Lookback is how many recent hours are checked on each run, so gaps between runs are covered
+	Lookback int
+	// Types are the service types checked, in display order
+	Types []string
+	// Cities are checked one by one, each in its own time zone
+	Cities []models.City
+	// Location is the time zone of a city without one and of the outages of
+	// every city together when no cities are set up, the server's when nil
+	Location *time.Location
+}
+
+// Detector compares the number of new outages per type in recent hours
+// with the same hour of previous weeks and stores and logs the spikes.
+// Outages of all kinds are checked together and each kind on its own,
+// every city apart from the others.
+type Detector struct {
+	log   *slog.Logger
+	store Storage
+	opts  Options
+	now   func() time.Time
+}
+
+func New(log *slog.Logger, store Storage, opts Options) *Detector {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	return &Detector{
+		log:   log,
+		store: store,
+		opts:  opts,
+		now:   time.Now,
+	}
+}
+
+func (d *Detector) Run(ctx context.Context) {
+	const op = "anomaly.Detector.Run"
+
+	log := d.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Detect(); err != nil {
+			log.Error("failed to detect anomalies", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Detect checks the current hour and the Lookback hours before it in every city.
+// The current hour is incomplete, a spike is reported as soon as it shows.
+func (d *Detector) Detect() error {
+	const op = "anomaly.Detector.Detect"
+
+	cities := d.opts.Cities
+	if len(cities) == 0 {
+		cities = []models.City{{}}
+	}
+
+	for _, city := range cities {
+		if err := d.detect(city); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func (d *Detector) detect(city models.City) error {
+	loc := city.Location
+	if loc == nil {
+		loc = d.opts.Location
+	}
+
+	now := d.now().In(loc)
+	// Truncate works on absolute time and would miss the hour in a half-hour zone
+	current := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	first := current.Add(-time.Duration(d.opts.Lookback) * time.Hour)
+	from := first.AddDate(0, 0, -7*d.opts.Weeks)
+
+	// the empty kind stands for outages of every kind
+	for _, kind := range append([]string{""}, models.BlackoutKinds...) {
+		counts, err := d.store.GetHourlyCounts(from.Format(hourLayout), current.Add(time.Hour).Format(hourLayout), models.Filter{City: city.Code, Kind: kind})
+		if err != nil {
+			return err
+		}
+
+		for _, anomaly := range Find(counts, first, current, d.opts) {
+			anomaly.City = city.Code
+			anomaly.Kind = kind
+			anomaly.DetectedAt = now.Format("2006-01-02 15:04:05")
+
+			isNew, err := d.store.SaveAnomaly(anomaly)
+			if err != nil {
+				return err
+			}
+
+			if isNew {
+				d.log.Warn("outage spike detected",
+					slog.String("city", anomaly.City),
+					slog.String("type", anomaly.Type),
+					slog.String("kind", anomaly.Kind),
+					slog.String("hour", anomaly.Hour),
+					slog.Int("count", anomaly.Count),
+					slog.Float64("baseline", anomaly.Baseline),
+					slog.Float64("z_score", anomaly.ZScore))
+			}
+		}
+	}
+
+	return nil
+}
+
+// Find returns the hours between first and last inclusive whose count
+// stands out from the same hour of the previous weeks
+func Find(counts []models.HourlyCount, first, last time.Time, opts Options) []models.Anomaly {
+	series := map[string]map[string]int{}
+	for _, count := range counts {
+		if series[count.Type] == nil {
+			series[count.Type] = map[string]int{}
+		}
+		series[count.Type][count.Hour] = count.Count
+	}
+
+	var anomalies []models.Anomaly
+
+	for _, blackoutType := range opts.Types {
+		hours := series[blackoutType]
+
+		for hour := first; !hour.After(last); hour = hour.Add(time.Hour) {
+			count := hours[hour.Format(hourLayout)]
+			if count < opts.MinCount {
+				continue
+			}
+
+			history := make([]float64, 0, opts.Weeks)
+			for week := 1; week <= opts.Weeks; week++ {
+				history = append(history, float64(hours[hour.AddDate(0, 0, -7*week).Format(hourLayout)]))
+			}
+
+			mean, deviation := meanStdDev(history)
+
+			// a flat history without MinStdDev would divide by zero
+			spread := math.Max(deviation, opts.MinStdDev)
+			if spread <= 0 {
+				continue
+			}
+
+			z := (float64(count) - mean) / spread
+			if z < opts.Threshold {
+				continue
+			}
+
+			anomalies = append(anomalies, models.Anomaly{
+				Type:     blackoutType,
+				Hour:     hour.Format(hourLayout),
+				Count:    count,
+				Baseline: round(mean),
+				StdDev:   round(deviation),
+				ZScore:   round(z),
+			})
+		}
+	}
+
+	return anomalies
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,201 @@
+package anomaly
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+var opts = Options{
+	Weeks:     4,
+	Threshold: 3,
+	MinCount:  3,
+	MinStdDev: 1,
+	Types:     models.DefaultServiceTypes().Codes(),
+}
+
+var hour = time.Date(2024, 3, 10, 11, 0, 0, 0, time.UTC)
+
+// counts returns the hot water counts of the hour, the history first, a week apart
+func counts(history []int, current int) []models.HourlyCount {
+	var list []models.HourlyCount
+	for week, count := range history {
+		list = append(list, models.HourlyCount{
+			Hour:  hour.AddDate(0, 0, -7*(len(history)-week)).Format(hourLayout),
+			Type:  "hot_water",
+			Count: count,
+		})
+	}
+	return append(list, models.HourlyCount{Hour: hour.Format(hourLayout), Type: "hot_water", Count: current})
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		counts  []models.HourlyCount
+		opts    func(o *Options)
+		want    []models.Anomaly
+		wantNil bool
+	}{
+		{
+			name:    "flat history, a small rise",
+			counts:  counts([]int{2, 2, 2, 2}, 4),
+			wantNil: true,
+		},
+		{
+			name:   "flat history, MinStdDev keeps the spike finite",
+			counts: counts([]int{2, 2, 2, 2}, 6),
+			want:   []models.Anomaly{{Type: "hot_water", Hour: "2024-03-10 11:00:00", Count: 6, Baseline: 2, StdDev: 0, ZScore: 4}},
+		},
+		{
+			name:   "zero history",
+			counts: counts([]int{0, 0, 0, 0}, 5),
+			want:   []models.Anomaly{{Type: "hot_water", Hour: "2024-03-10 11:00:00", Count: 5, Baseline: 0, StdDev: 0, ZScore: 5}},
+		},
+		{
+			name:    "zero history, below MinCount",
+			counts:  counts([]int{0, 0, 0, 0}, 2),
+			wantNil: true,
+		},
+		{
+			name:   "a real spike",
+			counts: counts([]int{1, 3, 1, 3}, 10),
+			want:   []models.Anomaly{{Type: "hot_water", Hour: "2024-03-10 11:00:00", Count: 10, Baseline: 2, StdDev: 1, ZScore: 8}},
+		},
+		{
+			name:    "a noisy history swallows the rise",
+			counts:  counts([]int{0, 8, 0, 8}, 10),
+			wantNil: true,
+		},
+		{
+			name:    "no spread at all is skipped, not an infinite z-score",
+			counts:  counts([]int{0, 0, 0, 0}, 5),
+			opts:    func(o *Options) { o.MinStdDev = 0 },
+			wantNil: true,
+		},
+		{
+			name:    "no spread with the count at the mean is skipped, not NaN",
+			counts:  counts([]int{3, 3, 3, 3}, 3),
+			opts:    func(o *Options) { o.MinStdDev = 0; o.Threshold = -1 },
+			wantNil: true,
+		},
+		{
+			name:    "types outside the registry are ignored",
+			counts:  []models.HourlyCount{{Hour: "2024-03-10 11:00:00", Type: "steam", Count: 50}},
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			if tt.opts != nil {
+				tt.opts(&o)
+			}
+
+			got := Find(tt.counts, hour, hour, o)
+			if tt.wantNil {
+				if len(got) != 0 {
+					t.Errorf("got %+v, want none", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// storage has a spike of emergencies, the only outages of the hour
+type storage struct {
+	from, to string
+	saved    []models.Anomaly
+}
+
+func (s *storage) GetHourlyCounts(from string, to string, filter models.Filter) ([]models.HourlyCount, error) {
+	s.from, s.to = from, to
+	if filter.Kind != "" && filter.Kind != models.KindEmergency {
+		return nil, nil
+	}
+	return counts([]int{1, 3, 1, 3}, 10), nil
+}
+
+func (s *storage) SaveAnomaly(anomaly models.Anomaly) (bool, error) {
+	s.saved = append(s.saved, anomaly)
+	return true, nil
+}
+
+func TestDetectUsesCityTime(t *testing.T) {
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := opts
+	o.Lookback = 2
+	o.Location = vladivostok
+
+	store := &storage{}
+	d := New(slog.New(slog.DiscardHandler), store, o)
+	// 11:20 in Vladivostok, the server runs in UTC
+	d.now = func() time.Time { return time.Date(2024, 3, 10, 1, 20, 0, 0, time.UTC) }
+
+	if err := d.Detect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.from != "2024-02-11 09:00:00" || store.to != "2024-03-10 12:00:00" {
+		t.Errorf("got counts from %s to %s, want the hours of Vladivostok", store.from, store.to)
+	}
+	if len(store.saved) == 0 || store.saved[0].Hour != "2024-03-10 11:00:00" || store.saved[0].DetectedAt != "2024-03-10 11:20:00" {
+		t.Errorf("got %+v, want the spike at 11:00 detected at 11:20 Vladivostok time", store.saved)
+	}
+}
+
+func TestDetectChecksEveryKind(t *testing.T) {
+	o := opts
+	o.Location = time.UTC
+
+	store := &storage{}
+	d := New(slog.New(slog.DiscardHandler), store, o)
+	d.now = func() time.Time { return hour.Add(20 * time.Minute) }
+
+	if err := d.Detect(); err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	for _, anomaly := range store.saved {
+		kinds = append(kinds, anomaly.Kind)
+	}
+	if !reflect.DeepEqual(kinds, []string{"", models.KindEmergency}) {
+		t.Errorf("got anomalies of kinds %q, want the spike among all outages and among emergencies", kinds)
+	}
+}
+
+func TestDetectChecksEveryCity(t *testing.T) {
+	o := opts
+	o.Location = time.UTC
+	o.Cities = []models.City{{Code: "vladivostok"}, {Code: "khabarovsk"}}
+
+	store := &storage{}
+	d := New(slog.New(slog.DiscardHandler), store, o)
+	d.now = func() time.Time { return hour.Add(20 * time.Minute) }
+
+	if err := d.Detect(); err != nil {
+		t.Fatal(err)
+	}
+
+	var cities []string
+	for _, anomaly := range store.saved {
+		if anomaly.Kind == "" {
+			cities = append(cities, anomaly.City)
+		}
+	}
+	if !reflect.DeepEqual(cities, []string{"vladivostok", "khabarovsk"}) {
+		t.Errorf("got spikes in cities %q, want one in each city", cities)
+	}
+}
@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+// Versioner reports the version of the stored data, it changes on every write
+type Versioner interface {
+	DataVersion() (string, error)
+}
+
+// Entry is a cached response
+type Entry struct {
+	Key         string    `json:"key"`
+	Version     string    `json:"version"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+}
+
+type Options struct {
+	// MaxEntries kept in memory, the least recently used entry is evicted first
+	MaxEntries int
+	// Dir keeps the entries on disk between restarts, empty keeps them in memory only
+	Dir string
+	// CheckInterval is how often the data version is checked
+	CheckInterval time.Duration
+}
+
+type counters struct {
+	hits   int64
+	misses int64
+}
+
+// Cache keeps responses until their TTL ends or the data version changes.
+// Entries of an older version are never served, a change found by Check
+// drops the memory and the disk store at once.
+type Cache struct {
+	log  *slog.Logger
+	src  Versioner
+	opts Options
+	disk *disk
+	now  func() time.Time
+
+	mu            sync.Mutex
+	version       string
+	entries       map[string]*list.Element
+	order         *list.List
+	endpoints     map[string]*counters
+	evictions     int64
+	invalidations int64
+}
+
+func New(log *slog.Logger, src Versioner, opts Options) (*Cache, error) {
+	const op = "cache.New"
+
+	c := &Cache{
+		log:       log,
+		src:       src,
+		opts:      opts,
+		now:       time.Now,
+		entries:   map[string]*list.Element{},
+		order:     list.New(),
+		endpoints: map[string]*counters{},
+	}
+
+	if opts.Dir != "" {
+		d, err := newDisk(opts.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.disk = d
+	}
+
+	if err := c.Check(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c, nil
+}
+
+func (c *Cache) Run(ctx context.Context) {
+	const op = "cache.Cache.Run"
+
+	log := c.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(c.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.Check(); err != nil {
+			log.Error("failed to check data version", sl.Err(err))
+		}
+	}
+}
+
+// Check reads the data version and drops every entry when it has changed
+func (c *Cache) Check() error {
+	const op = "cache.Cache.Check"
+
+	version, err := c.src.DataVersion()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version == c.version {
+		return nil
+	}
+
+	// the first check only learns the version, disk entries of an older one are dropped as well
+	if c.version != "" {
+		c.invalidations++
+		c.log.Info("data changed, cache dropped",
+			slog.String("op", op),
+			slog.String("version", version),
+			slog.Int("entries", c.order.Len()))
+	}
+
+	c.version = version
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+
+	if c.disk != nil {
+		if err := c.disk.purge(version); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Version returns the data version the cache currently serves
+func (c *Cache) Version() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.version
+}
+
+// Get returns a live entry of the current version and counts the hit or the miss for the endpoint
+func (c *Cache) Get(endpoint string, key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key)
+
+	stats, ok := c.endpoints[endpoint]
+	if !ok {
+		stats = &counters{}
+		c.endpoints[endpoint] = stats
+	}
+
+	if entry == nil {
+		stats.misses++
+		return nil, false
+	}
+
+	stats.hits++
+	return entry, true
+}
+
+func (c *Cache) lookup(key string) *Entry {
+	now := c.now()
+
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*Entry)
+		if now.Before(entry.ExpiresAt) {
+			c.order.MoveToFront(el)
+			return entry
+		}
+		c.remove(el)
+	}
+
+	if c.disk == nil {
+		return nil
+	}
+
+	entry, err := c.disk.get(key)
+	if err != nil {
+		c.log.Warn("failed to read cache entry", slog.String("key", key), sl.Err(err))
+		return nil
+	}
+	if entry == nil {
+		return nil
+	}
+
+	if entry.Version != c.version || !now.Before(entry.ExpiresAt) {
+		c.disk.remove(key)
+		return nil
+	}
+
+	c.insert(entry)
+	return entry
+}
+
+// Set stores an entry for ttl. Entries computed for an older version are
+// dropped, the data could change while the response was built.
+func (c *Cache) Set(entry *Entry, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry.Version != c.version || ttl <= 0 || c.opts.MaxEntries <= 0 {
+		return
+	}
+
+	entry.ExpiresAt = c.now().Add(ttl)
+
+	if el, ok := c.entries[entry.Key]; ok {
+		c.remove(el)
+	}
+	c.insert(entry)
+
+	if c.disk != nil {
+		if err := c.disk.set(entry); err != nil {
+			c.log.Warn("failed to write cache entry", slog.String("key", entry.Key), sl.Err(err))
+		}
+	}
+}
+
+func (c *Cache) insert(entry *Entry) {
+	c.entries[entry.Key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.opts.MaxEntries {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*Entry).Key)
+}
+
+// Stats returns the counters of the cache, endpoints are sorted by path
+func (c *Cache) Stats() models.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := models.CacheStats{
+		Version:       c.version,
+		Entries:       c.order.Len(),
+		MaxEntries:    c.opts.MaxEntries,
+		Evictions:     c.evictions,
+		Invalidations: c.invalidations,
+		Endpoints:     make([]models.EndpointCacheStats, 0, len(c.endpoints)),
+	}
+
+	for endpoint, counters := range c.endpoints {
+		stats.Hits += counters.hits
+		stats.Misses += counters.misses
+		stats.Endpoints = append(stats.Endpoints, models.EndpointCacheStats{
+			Endpoint: endpoint,
+			Hits:     counters.hits,
+			Misses:   counters.misses,
+		})
+	}
+
+	sort.Slice(stats.Endpoints, func(i, j int) bool {
+		return stats.Endpoints[i].Endpoint < stats.Endpoints[j].Endpoint
+	})
+
+	return stats
+}
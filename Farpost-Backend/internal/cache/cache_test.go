@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// versioner returns the version it holds, tests bump it to simulate writes
+type versioner struct {
+	version string
+}
+
+func (v *versioner) DataVersion() (string, error) {
+	return v.version, nil
+}
+
+func newCache(t *testing.T, src *versioner, opts Options) (*Cache, *time.Time) {
+	t.Helper()
+
+	c, err := New(slog.New(slog.DiscardHandler), src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	return c, &now
+}
+
+func set(c *Cache, key string, ttl time.Duration) {
+	c.Set(&Entry{Key: key, Version: c.Version(), Status: 200, Body: []byte(key)}, ttl)
+}
+
+func TestGet(t *testing.T) {
+	src := &versioner{version: "1"}
+	c, now := newCache(t, src, Options{MaxEntries: 10})
+
+	if _, ok := c.Get("/blackouts", "a"); ok {
+		t.Error("hit on an empty cache")
+	}
+
+	set(c, "a", time.Minute)
+
+	entry, ok := c.Get("/blackouts", "a")
+	if !ok || string(entry.Body) != "a" {
+		t.Errorf("Get = %v, %v, want the entry", entry, ok)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, ok := c.Get("/blackouts", "a"); ok {
+		t.Error("hit after the ttl")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Entries != 0 {
+		t.Errorf("Stats = %d hits, %d misses, %d entries, want 1, 2, 0", stats.Hits, stats.Misses, stats.Entries)
+	}
+}
+
+func TestSetSkips(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		version string
+		ttl     time.Duration
+	}{
+		{"older version", 10, "0", time.Minute},
+		{"no ttl", 10, "1", 0},
+		{"disabled", 0, "1", time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newCache(t, &versioner{version: "1"}, Options{MaxEntries: tt.max})
+
+			c.Set(&Entry{Key: "a", Version: tt.version}, tt.ttl)
+
+			if _, ok := c.Get("/blackouts", "a"); ok {
+				t.Error("the entry was stored")
+			}
+		})
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	src := &versioner{version: "1"}
+	c, _ := newCache(t, src, Options{MaxEntries: 10})
+
+	set(c, "a", time.Minute)
+
+	// a check without a change keeps the entries
+	if err := c.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("/blackouts", "a"); !ok {
+		t.Error("miss before the data changed")
+	}
+
+	src.version = "2"
+	if err := c.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("/blackouts", "a"); ok {
+		t.Error("hit after the data changed")
+	}
+
+	stats := c.Stats()
+	if stats.Version != "2" || stats.Invalidations != 1 {
+		t.Errorf("Stats = version %s, %d invalidations, want 2, 1", stats.Version, stats.Invalidations)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	c, _ := newCache(t, &versioner{version: "1"}, Options{MaxEntries: 2})
+
+	set(c, "a", time.Minute)
+	set(c, "b", time.Minute)
+
+	// a is used last, so b is the one to go
+	c.Get("/blackouts", "a")
+	set(c, "c", time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.Get("/blackouts", key); ok != want {
+			t.Errorf("Get(%s) = %v, want %v", key, ok, want)
+		}
+	}
+
+	if stats := c.Stats(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("Stats = %d evictions, %d entries, want 1, 2", stats.Evictions, stats.Entries)
+	}
+}
+
+func TestEndpointStats(t *testing.T) {
+	c, _ := newCache(t, &versioner{version: "1"}, Options{MaxEntries: 10})
+
+	set(c, "a", time.Minute)
+	c.Get("/map", "a")
+	c.Get("/blackouts", "a")
+	c.Get("/blackouts", "b")
+
+	stats := c.Stats()
+	if len(stats.Endpoints) != 2 {
+		t.Fatalf("Endpoints = %v, want 2", stats.Endpoints)
+	}
+
+	blackouts, m := stats.Endpoints[0], stats.Endpoints[1]
+	if blackouts.Endpoint != "/blackouts" || blackouts.Hits != 1 || blackouts.Misses != 1 {
+		t.Errorf("Endpoints[0] = %+v, want /blackouts with 1 hit and 1 miss", blackouts)
+	}
+	if m.Endpoint != "/map" || m.Hits != 1 || m.Misses != 0 {
+		t.Errorf("Endpoints[1] = %+v, want /map with 1 hit", m)
+	}
+}
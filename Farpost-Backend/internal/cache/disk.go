@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// disk keeps one JSON file per entry, named by the hash of the key
+type disk struct {
+	dir string
+}
+
+func newDisk(dir string) (*disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &disk{dir: dir}, nil
+}
+
+func (d *disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// get returns nil without an error when the key is not stored
+func (d *disk) get(key string) (*Entry, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	// a hash collision is not worth serving a wrong response
+	if entry.Key != key {
+		return nil, nil
+	}
+
+	return &entry, nil
+}
+
+// set writes next to the entry first, so a reader never sees a half-written file
+func (d *disk) set(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := d.path(entry.Key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (d *disk) remove(key string) {
+	os.Remove(d.path(key))
+}
+
+// purge removes the entries of every version but the given one
+func (d *disk) purge(version string) error {
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !(strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".tmp")) {
+			continue
+		}
+
+		path := filepath.Join(d.dir, name)
+		if strings.HasSuffix(name, ".json") && d.current(path, version) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *disk) current(path string, version string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var entry struct {
+		Version string `json:"version"`
+	}
+	return json.Unmarshal(data, &entry) == nil && entry.Version == version
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	src := &versioner{version: "1"}
+
+	c, _ := newCache(t, src, Options{MaxEntries: 10, Dir: dir})
+	set(c, "a", time.Minute)
+
+	// a new cache over the same directory and version serves the entry
+	restarted, _ := newCache(t, src, Options{MaxEntries: 10, Dir: dir})
+	entry, ok := restarted.Get("/blackouts", "a")
+	if !ok || string(entry.Body) != "a" {
+		t.Errorf("Get after a restart = %v, %v, want the entry", entry, ok)
+	}
+
+	// the data changed while the service was down
+	src.version = "2"
+	changed, _ := newCache(t, src, Options{MaxEntries: 10, Dir: dir})
+	if _, ok := changed.Get("/blackouts", "a"); ok {
+		t.Error("hit on an entry of an older version")
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("files left after the purge: %d", len(files))
+	}
+}
+
+func TestDiskExpired(t *testing.T) {
+	dir := t.TempDir()
+	src := &versioner{version: "1"}
+
+	c, now := newCache(t, src, Options{MaxEntries: 10, Dir: dir})
+	set(c, "a", time.Minute)
+
+	restarted, _ := newCache(t, src, Options{MaxEntries: 10, Dir: dir})
+	restarted.now = func() time.Time { return now.Add(time.Minute) }
+
+	if _, ok := restarted.Get("/blackouts", "a"); ok {
+		t.Error("hit after the ttl")
+	}
+	if _, err := os.Stat(restarted.disk.path("a")); !os.IsNotExist(err) {
+		t.Errorf("the expired entry is still on disk: %v", err)
+	}
+}
+
+func TestDiskPurge(t *testing.T) {
+	d, err := newDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, version := range map[string]string{"old": "1", "current": "2"} {
+		if err := d.set(&Entry{Key: key, Version: version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a write cut short and a file of someone else
+	if err := os.WriteFile(d.path("torn")+".tmp", []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(d.dir, "README")
+	if err := os.WriteFile(other, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.purge("2"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"current version", d.path("current"), true},
+		{"older version", d.path("old"), false},
+		{"half-written", d.path("torn") + ".tmp", false},
+		{"other file", other, true},
+	}
+
+	for _, tt := range tests {
+		_, err := os.Stat(tt.path)
+		if got := err == nil; got != tt.want {
+			t.Errorf("%s: kept = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiskGet(t *testing.T) {
+	d, err := newDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry, err := d.get("missing"); entry != nil || err != nil {
+		t.Errorf("get missing = %v, %v, want nil, nil", entry, err)
+	}
+
+	// the file of another key under this name, as after a hash collision
+	if err := d.set(&Entry{Key: "b", Version: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(d.path("b"), d.path("a")); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := d.get("a"); entry != nil || err != nil {
+		t.Errorf("get of a colliding key = %v, %v, want nil, nil", entry, err)
+	}
+
+	if err := os.WriteFile(d.path("broken"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.get("broken"); err == nil {
+		t.Error("get of a broken file: no error")
+	}
+}
@@ -0,0 +1,116 @@
+package classify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+// batchSize is the number of blackouts classified per query
+const batchSize = 500
+
+type Storage interface {
+	GetUnclassifiedBlackouts(limit int) ([]models.Blackout, error)
+	SetBlackoutKinds(kinds map[string]string) error
+}
+
+// Rules hold lowercase keyword fragments matched against the source
+// and the description of a blackout
+type Rules struct {
+	Planned   []string
+	Emergency []string
+}
+
+// Kind returns the kind of the blackout. Emergency keywords are checked first,
+// so "аварийный ремонт" is an emergency even though "ремонт" is planned.
+func (r Rules) Kind(blackout models.Blackout) string {
+	text := strings.ToLower(blackout.Source + " " + blackout.Description)
+
+	for _, keyword := range r.Emergency {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return models.KindEmergency
+		}
+	}
+
+	for _, keyword := range r.Planned {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return models.KindPlanned
+		}
+	}
+
+	return models.KindUnknown
+}
+
+// Classifier periodically assigns a kind to the blackouts that have none yet
+type Classifier struct {
+	log      *slog.Logger
+	store    Storage
+	rules    Rules
+	interval time.Duration
+}
+
+func New(log *slog.Logger, store Storage, rules Rules, interval time.Duration) *Classifier {
+	return &Classifier{
+		log:      log,
+		store:    store,
+		rules:    rules,
+		interval: interval,
+	}
+}
+
+func (c *Classifier) Run(ctx context.Context) {
+	const op = "classify.Classifier.Run"
+
+	log := c.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.ClassifyNew(); err != nil {
+			log.Error("failed to classify blackouts", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ClassifyNew classifies every blackout without a kind and returns how many there were
+func (c *Classifier) ClassifyNew() (int, error) {
+	const op = "classify.Classifier.ClassifyNew"
+
+	var total int
+	for {
+		blackouts, err := c.store.GetUnclassifiedBlackouts(batchSize)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if len(blackouts) == 0 {
+			return total, nil
+		}
+
+		kinds := make(map[string]string, len(blackouts))
+		for _, blackout := range blackouts {
+			kinds[blackout.ID] = c.rules.Kind(blackout)
+		}
+
+		if err := c.store.SetBlackoutKinds(kinds); err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		total += len(blackouts)
+
+		if len(blackouts) < batchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package classify
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+var rules = Rules{
+	Planned:   []string{"плановые", "ремонт", "Промывка"},
+	Emergency: []string{"авари", "порыв"},
+}
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		blackout models.Blackout
+		want     string
+	}{
+		{"planned", models.Blackout{Description: "Плановые работы"}, models.KindPlanned},
+		{"emergency", models.Blackout{Description: "Порыв на сети"}, models.KindEmergency},
+		{"emergency keywords come first", models.Blackout{Description: "Аварийный ремонт"}, models.KindEmergency},
+		{"keywords are lowercased", models.Blackout{Description: "промывка системы"}, models.KindPlanned},
+		{"source counts too", models.Blackout{Source: "Аварийная служба", Description: "Работы"}, models.KindEmergency},
+		{"no keyword", models.Blackout{Description: "Работы"}, models.KindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Kind(tt.blackout); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// storage hands out its unclassified blackouts in the order they were added
+type storage struct {
+	unclassified []models.Blackout
+	kinds        map[string]string
+	batches      []int
+	err          error
+}
+
+func (s *storage) GetUnclassifiedBlackouts(limit int) ([]models.Blackout, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var batch []models.Blackout
+	for _, blackout := range s.unclassified {
+		if _, ok := s.kinds[blackout.ID]; !ok && len(batch) < limit {
+			batch = append(batch, blackout)
+		}
+	}
+	s.batches = append(s.batches, len(batch))
+	return batch, nil
+}
+
+func (s *storage) SetBlackoutKinds(kinds map[string]string) error {
+	for id, kind := range kinds {
+		s.kinds[id] = kind
+	}
+	return nil
+}
+
+func TestClassifyNew(t *testing.T) {
+	store := &storage{kinds: map[string]string{}}
+	for i := range batchSize + 2 {
+		store.unclassified = append(store.unclassified, models.Blackout{ID: fmt.Sprint(i), Description: "Плановые работы"})
+	}
+	store.unclassified[0].Description = "Порыв"
+
+	c := New(slog.New(slog.DiscardHandler), store, rules, time.Hour)
+
+	total, err := c.ClassifyNew()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != batchSize+2 {
+		t.Errorf("got %d classified, want %d", total, batchSize+2)
+	}
+	if len(store.batches) != 2 || store.batches[0] != batchSize || store.batches[1] != 2 {
+		t.Errorf("got batches %v, want a full one and the rest", store.batches)
+	}
+	if store.kinds["0"] != models.KindEmergency || store.kinds["1"] != models.KindPlanned {
+		t.Errorf("got kinds %q and %q", store.kinds["0"], store.kinds["1"])
+	}
+
+	total, err = c.ClassifyNew()
+	if err != nil || total != 0 {
+		t.Errorf("second run classified %d, err %v, want nothing left", total, err)
+	}
+}
+
+func TestClassifyNewFails(t *testing.T) {
+	failure := errors.New("disk I/O error")
+
+	_, err := New(slog.New(slog.DiscardHandler), &storage{err: failure}, rules, time.Hour).ClassifyNew()
+	if !errors.Is(err, failure) {
+		t.Errorf("err = %v, want %v", err, failure)
+	}
+}
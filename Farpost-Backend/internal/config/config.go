@@ -1,7 +1,10 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"time"
 
@@ -10,16 +13,287 @@ import (
 
 type Config struct {
 	Env 			string 		`yaml:"env" env-default:"local"`
-	StoragePath		string 		`yaml:"storage_path" env-required:"true"`
+	// LogLevel is debug, info, warn or error, empty keeps the level of the env
+	LogLevel		string		`yaml:"log_level" env:"LOG_LEVEL"`
+	// WatchInterval is how often the file is checked for changes, 0 reloads it only on SIGHUP
+	WatchInterval	time.Duration	`yaml:"config_watch_interval" env:"CONFIG_WATCH_INTERVAL" env-default:"5s"`
+	StoragePath		string 		`yaml:"storage_path"`
+	Storage			Storage		`yaml:"storage"`
 	HTTPServer					`yaml:"http_server"`
+	CORS			CORS		`yaml:"cors"`
+	Events			Events		`yaml:"events"`
+	Webhooks		Webhooks	`yaml:"webhooks"`
+	APIKeys			Secrets		`yaml:"api_keys" env:"API_KEYS" env-separator:","`
+	SMTP			SMTP		`yaml:"smtp"`
+	Subscriptions	Subscriptions	`yaml:"subscriptions"`
+	Telegram		Telegram	`yaml:"telegram"`
+	Spatial			Spatial		`yaml:"spatial"`
+	Recurrence		Recurrence	`yaml:"recurrence"`
+	Forecast		Forecast	`yaml:"forecast"`
+	Anomalies		Anomalies	`yaml:"anomalies"`
+	Kinds			Kinds		`yaml:"kinds"`
+	Lifecycle		Lifecycle	`yaml:"lifecycle"`
+	ServiceTypes	ServiceTypes	`yaml:"service_types"`
+	Cache			Cache		`yaml:"cache"`
+	HTTPCache		HTTPCache	`yaml:"http_cache"`
+	RateLimit		RateLimit	`yaml:"rate_limit"`
+	AccessLog		AccessLog	`yaml:"access_log"`
+	Cities			[]City		`yaml:"cities"`
+	// DefaultCity is the code of the city served by the /off routes without a city
+	DefaultCity		string		`yaml:"default_city" env-default:"vladivostok"`
+
+	path			string
 }
 
 type HTTPServer struct {
-	Address			string 			`yaml:"address" env-default:"localhost:1234"`
-	Timeout			time.Duration	`yaml:"timeout" env-default:"4s"`
-	IddleTimeout	time.Duration	`yaml:"iddle_timeout" env-default:"60s"`
+	Address				string 			`yaml:"address" env:"HTTP_ADDRESS" env-default:"localhost:1234"`
+	Timeout				time.Duration	`yaml:"timeout" env:"HTTP_TIMEOUT" env-default:"4s"`
+	IddleTimeout		time.Duration	`yaml:"iddle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	// ReadHeaderTimeout bounds reading the headers, slow clients cannot hold a connection with them
+	ReadHeaderTimeout	time.Duration	`yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" env-default:"5s"`
+	MaxHeaderBytes		int				`yaml:"max_header_bytes" env:"HTTP_MAX_HEADER_BYTES" env-default:"1048576"`
+	// MaxBodyBytes of a request, reading a larger body fails at the limit
+	MaxBodyBytes		int64			`yaml:"max_body_bytes" env:"HTTP_MAX_BODY_BYTES" env-default:"1048576"`
+	TLS					TLS				`yaml:"tls"`
+}
+
+// TLS serves HTTPS when both files are set, plain HTTP otherwise
+type TLS struct {
+	CertFile		string			`yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile			string			`yaml:"key_file" env:"TLS_KEY_FILE"`
+	// ReloadInterval is how often the files are checked for a renewed certificate, 0 loads them once
+	ReloadInterval	time.Duration	`yaml:"reload_interval" env:"TLS_RELOAD_INTERVAL"`
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+type CORS struct {
+	// AllowedOrigins may hold "*" or one wildcard per origin, like https://*.vl.ru
+	AllowedOrigins		[]string		`yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" env-separator:"," env-default:"*"`
+	AllowedMethods		[]string		`yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS" env-separator:"," env-default:"GET,POST,OPTIONS"`
+	AllowedHeaders		[]string		`yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" env-separator:"," env-default:"Accept,Authorization,Content-Type,If-None-Match,X-City"`
+	ExposedHeaders		[]string		`yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" env-separator:"," env-default:"ETag,Retry-After,X-Cache"`
+	// AllowCredentials lets browsers send cookies and Authorization, it cannot go with the "*" origin
+	AllowCredentials	bool			`yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge				time.Duration	`yaml:"max_age" env:"CORS_MAX_AGE" env-default:"5m"`
+}
+
+const (
+	DriverSQLite	= "sqlite"
+	DriverPostgres	= "postgres"
+)
+
+// Storage selects the storage backend. SQLite reads the database from
+// storage_path, PostgreSQL connects with the DSN.
+type Storage struct {
+	Driver	string	`yaml:"driver" env:"STORAGE_DRIVER" env-default:"sqlite"`
+	DSN		Secret	`yaml:"dsn" env:"STORAGE_DSN"`
+}
+
+type Events struct {
+	PollInterval	time.Duration	`yaml:"poll_interval" env-default:"30s"`
+	Heartbeat		time.Duration	`yaml:"heartbeat" env-default:"15s"`
+	HistorySize		int				`yaml:"history_size" env-default:"1000"`
+	ClientBuffer	int				`yaml:"client_buffer" env-default:"64"`
+}
+
+// Secrets is a list of credentials that is masked when the config is logged
+type Secrets []string
+
+func (s Secrets) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("[%d hidden]", len(s)))
+}
+
+// Secret is a single credential that is masked when the config is logged
+type Secret string
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	if s == "" {
+		return json.Marshal("")
+	}
+	return json.Marshal("[hidden]")
+}
+
+type Webhooks struct {
+	PollInterval	time.Duration	`yaml:"poll_interval" env-default:"5s"`
+	Timeout			time.Duration	`yaml:"timeout" env-default:"10s"`
+	MaxAttempts		int				`yaml:"max_attempts" env-default:"8"`
+	BackoffBase		time.Duration	`yaml:"backoff_base" env-default:"30s"`
+	BackoffMax		time.Duration	`yaml:"backoff_max" env-default:"1h"`
+}
+
+type SMTP struct {
+	Host		string	`yaml:"host" env:"SMTP_HOST" env-default:"localhost"`
+	Port		int		`yaml:"port" env:"SMTP_PORT" env-default:"1025"`
+	Username	string	`yaml:"username" env:"SMTP_USERNAME"`
+	Password	Secret	`yaml:"password" env:"SMTP_PASSWORD"`
+	From		string	`yaml:"from" env:"SMTP_FROM" env-default:"VL.RU off <noreply@localhost>"`
+}
+
+type Subscriptions struct {
+	// BaseURL is used to build confirmation and unsubscribe links
+	BaseURL		string			`yaml:"base_url" env-default:"http://localhost:12345"`
+	// DigestAt is the time of day, counted from midnight, when digests are sent
+	DigestAt	time.Duration	`yaml:"digest_at" env-default:"8h"`
+}
+
+type Telegram struct {
+	// Token of the bot, the bot is disabled when it is empty
+	Token		Secret			`yaml:"token" env:"TELEGRAM_TOKEN"`
+	BaseURL		string			`yaml:"base_url" env:"TELEGRAM_BASE_URL" env-default:"https://api.telegram.org"`
+	PollTimeout	time.Duration	`yaml:"poll_timeout" env-default:"30s"`
+}
+
+type Spatial struct {
+	// RefreshInterval is how often building coordinates are reloaded into the index
+	RefreshInterval	time.Duration	`yaml:"refresh_interval" env-default:"10m"`
+	// MaxRadius of /off/nearby queries in meters
+	MaxRadius		float64			`yaml:"max_radius" env-default:"10000"`
+}
+
+type Recurrence struct {
+	Interval		time.Duration	`yaml:"interval" env-default:"1h"`
+	// Window in which MinOutages outages of one type make a hotspot
+	Window			time.Duration	`yaml:"window" env-default:"720h"`
+	MinOutages		int				`yaml:"min_outages" env-default:"3"`
+	// PeriodTolerance is the largest spread of the gaps, relative to their mean, of a periodic sequence
+	PeriodTolerance	float64			`yaml:"period_tolerance" env-default:"0.25"`
+}
+
+type Forecast struct {
+	// ModelPath is written by cmd/forecast and read on start, forecasts are off while it is missing
+	ModelPath		string	`yaml:"model_path" env-default:"./storage/forecast.json"`
+	// MinProbability hides less likely outages from the calendar layer
+	MinProbability	float64	`yaml:"min_probability" env-default:"0.2"`
+	// TopStreets limits the streets listed per type and day
+	TopStreets		int		`yaml:"top_streets" env-default:"5"`
+	// Alpha and Beta are the prior strengths of the model, in days
+	Alpha			float64	`yaml:"alpha" env-default:"2"`
+	Beta			float64	`yaml:"beta" env-default:"7"`
+}
+
+type Anomalies struct {
+	Interval	time.Duration	`yaml:"interval" env-default:"5m"`
+	// Weeks of history, the same hour of each week makes the baseline
+	Weeks		int				`yaml:"weeks" env-default:"8"`
+	Threshold	float64			`yaml:"threshold" env-default:"3"`
+	MinCount	int				`yaml:"min_count" env-default:"3"`
+	MinStdDev	float64			`yaml:"min_stddev" env-default:"1"`
+	// Lookback is the number of recent hours checked on each run
+	Lookback	int				`yaml:"lookback" env-default:"24"`
+}
+
+// Kinds holds the keyword rules telling planned blackouts from emergencies,
+// fragments are matched against the source and the description ignoring case
+type Kinds struct {
+	Interval	time.Duration	`yaml:"interval" env-default:"10m"`
+	Planned		[]string		`yaml:"planned" env-default:"планов,профилакт,ремонт,обслуживан,опрессовк,испытан,регламент"`
+	Emergency	[]string		`yaml:"emergency" env-default:"авари,порыв,повреждени,неисправн,внепланов,утечк"`
+}
+
+type Lifecycle struct {
+	// Interval between two comparisons of the blackouts with their last revisions
+	Interval	time.Duration	`yaml:"interval" env-default:"1m"`
+}
+
+type ServiceTypes struct {
+	// LegacyFields keeps the fixed hot, cold, electricity and heating fields
+	// of /off/complaints next to the counts keyed by type
+	LegacyFields	bool			`yaml:"legacy_fields" env-default:"true"`
+	// Types are added to the four seeded types of the registry on start,
+	// a type with the code of a stored one replaces it
+	Types			[]ServiceType	`yaml:"types"`
+}
+
+type ServiceType struct {
+	Code		string	`yaml:"code"`
+	NameRu		string	`yaml:"name_ru"`
+	NameEn		string	`yaml:"name_en"`
+	Icon		string	`yaml:"icon"`
+	Position	int		`yaml:"position"`
+}
+
+type Cache struct {
+	// MaxEntries kept in memory, 0 turns the cache off
+	MaxEntries		int						`yaml:"max_entries" env-default:"1000"`
+	// Dir keeps the entries on disk between restarts, empty keeps them in memory only
+	Dir				string					`yaml:"dir"`
+	// CheckInterval is how often the data version is checked, a change drops every entry
+	CheckInterval	time.Duration			`yaml:"check_interval" env-default:"5s"`
+	DefaultTTL		time.Duration			`yaml:"default_ttl" env-default:"1m"`
+	// TTL per endpoint, keyed by the path relative to /off
+	TTL				map[string]time.Duration	`yaml:"ttl"`
+}
+
+// TTLFor returns the TTL of the endpoint, the default one when not configured
+func (c Cache) TTLFor(endpoint string) time.Duration {
+	if ttl, ok := c.TTL[endpoint]; ok {
+		return ttl
+	}
+	return c.DefaultTTL
+}
+
+// HTTPCache configures the caching of responses by clients and proxies
+type HTTPCache struct {
+	// MaxAge of responses that may still change
+	MaxAge				time.Duration	`yaml:"max_age" env-default:"1m"`
+	// PastMaxAge of immutable responses about days before today
+	PastMaxAge			time.Duration	`yaml:"past_max_age" env-default:"1h"`
+	// CompressionLevel of gzip and brotli responses
+	CompressionLevel	int				`yaml:"compression_level" env-default:"5"`
+}
+
+type RateLimit struct {
+	Enabled			bool						`yaml:"enabled" env-default:"true"`
+	// TrustProxy takes the client address from X-Forwarded-For and X-Real-IP,
+	// only for a server behind a proxy that sets them
+	TrustProxy		bool						`yaml:"trust_proxy"`
+	// Allowlist addresses and CIDR networks are never limited
+	Allowlist		[]string					`yaml:"allowlist"`
+	// SweepInterval is how often the refilled buckets are dropped
+	SweepInterval	time.Duration				`yaml:"sweep_interval" env-default:"1m"`
+	// Groups of routes by name, the missing ones keep the defaults
+	Groups			map[string]RateLimitGroup	`yaml:"groups"`
+}
+
+// RateLimitGroup is a token bucket per client: Burst requests at once, refilled
+// at Rate requests per second. Clients with an API key get the Key ones, a zero rate is unlimited.
+type RateLimitGroup struct {
+	Rate		float64	`yaml:"rate"`
+	Burst		int		`yaml:"burst"`
+	KeyRate		float64	`yaml:"key_rate"`
+	KeyBurst	int		`yaml:"key_burst"`
+}
+
+// defaultRateLimitGroups keep the search, which scans the streets on every
+// keystroke, and the subscriptions, which send mail, the tightest
+var defaultRateLimitGroups = map[string]RateLimitGroup{
+	"public":        {Rate: 10, Burst: 40, KeyRate: 50, KeyBurst: 200},
+	"search":        {Rate: 2, Burst: 10, KeyRate: 20, KeyBurst: 50},
+	"subscriptions": {Rate: 0.05, Burst: 3, KeyRate: 1, KeyBurst: 10},
+	"admin":         {Rate: 1, Burst: 10, KeyRate: 20, KeyBurst: 100},
+}
+
+type AccessLog struct {
+	// Sample logs one of every n successful requests of a route, keyed by the pattern
+	// from /off on, e.g. /off/map. Failed requests are always logged.
+	Sample	map[string]int	`yaml:"sample"`
+	// Redact hides the values of these query parameters
+	Redact	[]string		`yaml:"redact" env:"ACCESS_LOG_REDACT" env-separator:"," env-default:"token,api_key,key,secret,email"`
 }
 
+type City struct {
+	Code			string	`yaml:"code"`
+	Name			string	`yaml:"name"`
+	Timezone		string	`yaml:"timezone"`
+	// BuildingsTotal replaces the number of buildings in the database as the
+	// base of the shares, 0 counts the buildings
+	BuildingsTotal	int64	`yaml:"buildings_total"`
+}
+
+// MustLoad reads the config of the --config flag or CONFIG_PATH and panics on any problem
 func MustLoad() *Config {
 	path := fetchConfigPath()
 	
@@ -31,14 +305,61 @@ func MustLoad() *Config {
 		panic("config file does not exist: " + path)
 	}
 
+	cfg, err := Load(path)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return cfg
+}
+
+// Load reads and validates the config file, environment variables override it
+func Load(path string) (*Config, error) {
 	var cfg Config
 
 	err := cleanenv.ReadConfig(path, &cfg)
 	if err != nil {
-		panic("failed to read config" + err.Error())
+		return nil, fmt.Errorf("failed to read config: %w", err)
 	} 
 
-	return &cfg
+	switch cfg.Storage.Driver {
+	case DriverSQLite:
+		if cfg.StoragePath == "" {
+			return nil, errors.New("storage_path is required for the sqlite storage")
+		}
+	case DriverPostgres:
+		if cfg.Storage.DSN == "" {
+			return nil, errors.New("storage.dsn is required for the postgres storage")
+		}
+	default:
+		return nil, errors.New("unknown storage driver: " + cfg.Storage.Driver)
+	}
+
+	if len(cfg.Cities) == 0 {
+		cfg.Cities = []City{{Code: "vladivostok", Name: "Владивосток", Timezone: "Asia/Vladivostok"}}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	if cfg.RateLimit.Groups == nil {
+		cfg.RateLimit.Groups = map[string]RateLimitGroup{}
+	}
+	for name, group := range defaultRateLimitGroups {
+		if _, ok := cfg.RateLimit.Groups[name]; !ok {
+			cfg.RateLimit.Groups[name] = group
+		}
+	}
+
+	cfg.path = path
+
+	return &cfg, nil
+}
+
+// Path is the file the config was read from
+func (c *Config) Path() string {
+	return c.path
 }
 
 func fetchConfigPath() string {
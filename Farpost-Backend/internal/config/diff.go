@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is a field that differs between two configs, named by its YAML path
+type Change struct {
+	Path string
+	From string
+	To   string
+}
+
+// Diff lists the fields changed from old to new. Secrets only show that they changed.
+func Diff(old *Config, new *Config) []Change {
+	var changes []Change
+	diff("", reflect.ValueOf(*old), reflect.ValueOf(*new), &changes)
+	return changes
+}
+
+func diff(path string, a reflect.Value, b reflect.Value, changes *[]Change) {
+	if a.Kind() == reflect.Struct {
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+
+			diff(name, a.Field(i), b.Field(i), changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return
+	}
+
+	*changes = append(*changes, Change{Path: path, From: show(a), To: show(b)})
+}
+
+// show prints a value, the masked form for secrets
+func show(v reflect.Value) string {
+	if m, ok := v.Interface().(json.Marshaler); ok {
+		if b, err := m.MarshalJSON(); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
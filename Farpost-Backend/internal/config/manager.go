@@ -0,0 +1,169 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+)
+
+// reloadable are the YAML paths that take effect without a restart
+var reloadable = []string{"log_level", "cors", "http_server.timeout"}
+
+// Reloadable reports whether a change of the field takes effect without a restart
+func Reloadable(path string) bool {
+	for _, p := range reloadable {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Manager keeps the current config and reloads the file on SIGHUP or when it
+// changes. A new config that fails to load or validate is rejected and the
+// current one stays. Of a valid one only the reloadable fields are swapped in,
+// the other changes are logged and wait for a restart.
+type Manager struct {
+	log  *slog.Logger
+	path string
+
+	current atomic.Pointer[Config]
+
+	mu       sync.Mutex
+	modified time.Time
+	size     int64
+	hooks    []func(*Config)
+}
+
+func NewManager(log *slog.Logger, cfg *Config) *Manager {
+	m := &Manager{
+		log:  log,
+		path: cfg.Path(),
+	}
+	m.current.Store(cfg)
+	m.modified, m.size, _ = m.stat()
+
+	return m
+}
+
+// Current returns the config in effect, it is never changed in place
+func (m *Manager) Current() *Config {
+	return m.current.Load()
+}
+
+// OnReload registers a hook called with every config swapped in
+func (m *Manager) OnReload(hook func(*Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook)
+}
+
+// Reload reads the file again and swaps the reloadable fields in
+func (m *Manager) Reload() error {
+	const op = "config.Manager.Reload"
+
+	log := m.log.With(slog.String("op", op), slog.String("path", m.path))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.modified, m.size, _ = m.stat()
+
+	next, err := Load(m.path)
+	if err != nil {
+		log.Error("config reload rejected, keeping the current config", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	old := m.current.Load()
+
+	changes := Diff(old, next)
+	if len(changes) == 0 {
+		log.Info("config reloaded without changes")
+		return nil
+	}
+
+	applied := *old
+	applied.LogLevel = next.LogLevel
+	applied.CORS = next.CORS
+	applied.HTTPServer.Timeout = next.HTTPServer.Timeout
+
+	for _, change := range changes {
+		attrs := []any{
+			slog.String("field", change.Path),
+			slog.String("from", change.From),
+			slog.String("to", change.To),
+		}
+
+		if Reloadable(change.Path) {
+			log.Info("config changed", attrs...)
+		} else {
+			log.Warn("config change needs a restart", attrs...)
+		}
+	}
+
+	m.current.Store(&applied)
+
+	for _, hook := range m.hooks {
+		hook(&applied)
+	}
+
+	return nil
+}
+
+// Run reloads the config on SIGHUP and, with a positive interval, when the
+// file has changed since the last reload
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			m.Reload()
+		case <-tick:
+			if m.changed() {
+				m.Reload()
+			}
+		}
+	}
+}
+
+func (m *Manager) changed() bool {
+	modified, size, err := m.stat()
+	if err != nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return !modified.Equal(m.modified) || size != m.size
+}
+
+func (m *Manager) stat() (time.Time, int64, error) {
+	info, err := os.Stat(m.path)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return info.ModTime(), info.Size(), nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// copyShipped writes the shipped config to a temporary file with the replacements applied
+func copyShipped(t *testing.T, path string, replacements ...string) {
+	t.Helper()
+
+	data, err := os.ReadFile(shipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := string(data)
+	for i := 0; i+1 < len(replacements); i += 2 {
+		if !strings.Contains(text, replacements[i]) {
+			t.Fatalf("the shipped config has no %q", replacements[i])
+		}
+		text = strings.Replace(text, replacements[i], replacements[i+1], 1)
+	}
+
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newManager(t *testing.T) (*Manager, string, *bytes.Buffer, *[]*Config) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	copyShipped(t, path)
+
+	var logs bytes.Buffer
+	m := NewManager(slog.New(slog.NewTextHandler(&logs, nil)), load(t, path))
+
+	var reloaded []*Config
+	m.OnReload(func(c *Config) { reloaded = append(reloaded, c) })
+
+	return m, path, &logs, &reloaded
+}
+
+func TestReloadRejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		replacements []string
+	}{
+		{"invalid value", []string{`log_level: ""`, `log_level: "trace"`}},
+		{"unknown time zone", []string{"timezone: Asia/Vladivostok", "timezone: Asia/Atlantis"}},
+		{"broken YAML", []string{"cors:", "cors: ["}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path, logs, reloaded := newManager(t)
+			before := m.Current()
+
+			copyShipped(t, path, tt.replacements...)
+
+			if err := m.Reload(); err == nil {
+				t.Fatal("Reload: no error")
+			}
+			if m.Current() != before {
+				t.Error("the config was swapped")
+			}
+			if len(*reloaded) != 0 {
+				t.Errorf("hooks called %d times", len(*reloaded))
+			}
+			if !strings.Contains(logs.String(), "config reload rejected") {
+				t.Errorf("log = %s, want the rejection", logs.String())
+			}
+		})
+	}
+}
+
+func TestReloadSwapsReloadable(t *testing.T) {
+	m, path, logs, reloaded := newManager(t)
+	before := m.Current()
+
+	copyShipped(t, path,
+		`log_level: ""`, `log_level: "debug"`,
+		"timeout: 50s", "timeout: 10s",
+		"max_age: 5m", "max_age: 10m",
+		`address: "0.0.0.0:12345"`, `address: "0.0.0.0:8080"`,
+		"max_entries: 1000", "max_entries: 10",
+		`"local-dev-key"`, `"rotated-key"`,
+	)
+
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	current := m.Current()
+	if current.LogLevel != "debug" || current.HTTPServer.Timeout != 10*time.Second || current.CORS.MaxAge != 10*time.Minute {
+		t.Errorf("reloadable fields = %q, %v, %v, want debug, 10s, 10m", current.LogLevel, current.HTTPServer.Timeout, current.CORS.MaxAge)
+	}
+	if current.HTTPServer.Address != "0.0.0.0:12345" || current.Cache.MaxEntries != 1000 {
+		t.Errorf("fields needing a restart = %q, %d, want them unchanged", current.HTTPServer.Address, current.Cache.MaxEntries)
+	}
+
+	// the old config is never changed in place
+	if before.LogLevel != "" || before.HTTPServer.Timeout != 50*time.Second {
+		t.Error("the previous config was changed")
+	}
+
+	if len(*reloaded) != 1 || (*reloaded)[0] != current {
+		t.Errorf("hooks called with %v, want the current config once", *reloaded)
+	}
+
+	for _, want := range []struct{ msg, field string }{
+		{"config changed", "log_level"},
+		{"config changed", "http_server.timeout"},
+		{"config changed", "cors.max_age"},
+		{"config change needs a restart", "http_server.address"},
+		{"config change needs a restart", "cache.max_entries"},
+		{"config change needs a restart", "api_keys"},
+	} {
+		if !logged(logs.String(), want.msg, want.field) {
+			t.Errorf("log has no %q of %s:\n%s", want.msg, want.field, logs.String())
+		}
+	}
+
+	for _, secret := range []string{"local-dev-key", "rotated-key"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("log shows the API key %s", secret)
+		}
+	}
+}
+
+// logged reports whether a line of the text log has the message and the field
+func logged(logs, msg, field string) bool {
+	for _, line := range strings.Split(logs, "\n") {
+		if strings.Contains(line, `msg="`+msg+`"`) && strings.Contains(line, " field="+field+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReloadWithoutChanges(t *testing.T) {
+	m, _, _, reloaded := newManager(t)
+	before := m.Current()
+
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Current() != before || len(*reloaded) != 0 {
+		t.Error("a reload without changes swapped the config")
+	}
+}
+
+func TestReloadable(t *testing.T) {
+	tests := map[string]bool{
+		"log_level":                   true,
+		"cors":                        true,
+		"cors.allowed_origins":        true,
+		"http_server.timeout":         true,
+		"http_server.timeout_extra":   false,
+		"http_server.address":         false,
+		"http_server.tls.cert_file":   false,
+		"log_level_of_something_else": false,
+	}
+
+	for path, want := range tests {
+		if got := Reloadable(path); got != want {
+			t.Errorf("Reloadable(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
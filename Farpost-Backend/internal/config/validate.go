@@ -0,0 +1,176 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	// the time zones of the cities, the runtime image has no tzdata
+	_ "time/tzdata"
+)
+
+var methods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// validate checks the server options, every problem is reported at once
+func (c *Config) validate() error {
+	var errs []error
+
+	switch c.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log_level %q: expected debug, info, warn or error", c.LogLevel))
+	}
+
+	if c.WatchInterval < 0 {
+		errs = append(errs, errors.New("config_watch_interval cannot be negative"))
+	}
+
+	errs = append(errs, c.validateCities()...)
+	errs = append(errs, c.HTTPServer.validate()...)
+	errs = append(errs, c.CORS.validate()...)
+	errs = append(errs, c.Anomalies.validate()...)
+
+	return errors.Join(errs...)
+}
+
+// validateCities checks that every city has a unique code and a known time
+// zone and that the default city is one of them
+func (c *Config) validateCities() []error {
+	var errs []error
+
+	codes := map[string]bool{}
+	for i, city := range c.Cities {
+		switch {
+		case city.Code == "":
+			errs = append(errs, fmt.Errorf("cities[%d]: code is required", i))
+		case city.Code != strings.ToLower(city.Code):
+			errs = append(errs, fmt.Errorf("city %s: code must be lower case, requests name cities in it", city.Code))
+		case codes[city.Code]:
+			errs = append(errs, fmt.Errorf("city %s is configured twice", city.Code))
+		}
+		codes[city.Code] = true
+
+		if city.Timezone == "" {
+			errs = append(errs, fmt.Errorf("city %s: timezone is required", city.Code))
+		} else if _, err := time.LoadLocation(city.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("city %s: timezone: %w", city.Code, err))
+		}
+	}
+
+	if !codes[c.DefaultCity] {
+		errs = append(errs, fmt.Errorf("default_city %q is not one of the cities", c.DefaultCity))
+	}
+
+	return errs
+}
+
+func (s HTTPServer) validate() []error {
+	var errs []error
+
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		errs = append(errs, fmt.Errorf("http_server.address %q: %w", s.Address, err))
+	}
+
+	if s.Timeout < 0 || s.IddleTimeout < 0 {
+		errs = append(errs, errors.New("http_server.timeout and http_server.iddle_timeout cannot be negative"))
+	}
+	if s.ReadHeaderTimeout <= 0 {
+		errs = append(errs, errors.New("http_server.read_header_timeout must be positive"))
+	}
+	if s.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("http_server.max_header_bytes must be positive"))
+	}
+	if s.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("http_server.max_body_bytes must be positive"))
+	}
+
+	errs = append(errs, s.TLS.validate()...)
+
+	return errs
+}
+
+func (t TLS) validate() []error {
+	if !t.Enabled() {
+		if t.ReloadInterval != 0 {
+			return []error{errors.New("http_server.tls.reload_interval needs http_server.tls.cert_file and key_file")}
+		}
+		return nil
+	}
+
+	var errs []error
+
+	if t.CertFile == "" || t.KeyFile == "" {
+		errs = append(errs, errors.New("http_server.tls needs both cert_file and key_file"))
+	} else if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+		errs = append(errs, fmt.Errorf("http_server.tls certificate: %w", err))
+	}
+
+	if t.ReloadInterval < 0 {
+		errs = append(errs, errors.New("http_server.tls.reload_interval cannot be negative"))
+	}
+
+	return errs
+}
+
+func (a Anomalies) validate() []error {
+	var errs []error
+
+	if a.Weeks <= 0 {
+		errs = append(errs, errors.New("anomalies.weeks must be positive"))
+	}
+	if a.Threshold <= 0 {
+		errs = append(errs, errors.New("anomalies.threshold must be positive"))
+	}
+	if a.MinStdDev <= 0 {
+		errs = append(errs, errors.New("anomalies.min_stddev must be positive, a flat history would give every extra outage an infinite z-score"))
+	}
+	if a.MinCount < 0 || a.Lookback < 0 {
+		errs = append(errs, errors.New("anomalies.min_count and anomalies.lookback cannot be negative"))
+	}
+
+	return errs
+}
+
+func (c CORS) validate() []error {
+	var errs []error
+
+	if len(c.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allowed_origins is empty, use \"*\" to allow any origin"))
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				errs = append(errs, errors.New("cors.allow_credentials cannot go with the \"*\" origin, list the origins"))
+			}
+			continue
+		}
+
+		if strings.Count(origin, "*") > 1 {
+			errs = append(errs, fmt.Errorf("cors origin %q: only one wildcard is supported", origin))
+			continue
+		}
+
+		u, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("cors origin %q: expected scheme://host[:port]", origin))
+		}
+	}
+
+	for _, method := range c.AllowedMethods {
+		if !slices.Contains(methods, method) {
+			errs = append(errs, fmt.Errorf("cors method %q: expected one of %s", method, strings.Join(methods, ", ")))
+		}
+	}
+
+	if c.MaxAge < 0 {
+		errs = append(errs, errors.New("cors.max_age cannot be negative"))
+	}
+
+	return errs
+}
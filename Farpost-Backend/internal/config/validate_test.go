@@ -0,0 +1,90 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const shipped = "../../config/local.yaml"
+
+func load(t *testing.T, path string) *Config {
+	t.Helper()
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"shipped config", func(c *Config) {}, nil},
+		{"log level", func(c *Config) { c.LogLevel = "trace" }, []string{`log_level "trace"`}},
+		{"watch interval", func(c *Config) { c.WatchInterval = -time.Second }, []string{"config_watch_interval"}},
+		{"address", func(c *Config) { c.HTTPServer.Address = "localhost" }, []string{"http_server.address"}},
+		{"negative timeout", func(c *Config) { c.HTTPServer.Timeout = -time.Second }, []string{"http_server.timeout"}},
+		{"read header timeout", func(c *Config) { c.HTTPServer.ReadHeaderTimeout = 0 }, []string{"read_header_timeout"}},
+		{"max body bytes", func(c *Config) { c.HTTPServer.MaxBodyBytes = 0 }, []string{"max_body_bytes"}},
+		{"key file alone", func(c *Config) { c.HTTPServer.TLS.KeyFile = "key.pem" }, []string{"both cert_file and key_file"}},
+		{"missing certificate", func(c *Config) {
+			c.HTTPServer.TLS.CertFile, c.HTTPServer.TLS.KeyFile = "missing.pem", "missing.key"
+		}, []string{"http_server.tls certificate"}},
+		{"reload without TLS", func(c *Config) { c.HTTPServer.TLS.ReloadInterval = time.Hour }, []string{"reload_interval needs"}},
+		{"no origins", func(c *Config) { c.CORS.AllowedOrigins = nil }, []string{"cors.allowed_origins is empty"}},
+		{"credentials with any origin", func(c *Config) {
+			c.CORS.AllowedOrigins, c.CORS.AllowCredentials = []string{"*"}, true
+		}, []string{"allow_credentials"}},
+		{"wildcard origin", func(c *Config) { c.CORS.AllowedOrigins = []string{"https://*.vl.ru"} }, nil},
+		{"two wildcards", func(c *Config) { c.CORS.AllowedOrigins = []string{"https://*.*.vl.ru"} }, []string{"only one wildcard"}},
+		{"origin with a path", func(c *Config) { c.CORS.AllowedOrigins = []string{"https://vl.ru/off"} }, []string{"expected scheme://host[:port]"}},
+		{"method", func(c *Config) { c.CORS.AllowedMethods = []string{"GET", "FETCH"} }, []string{`cors method "FETCH"`}},
+		{"anomaly weeks", func(c *Config) { c.Anomalies.Weeks = 0 }, []string{"anomalies.weeks"}},
+		{"anomaly spread", func(c *Config) { c.Anomalies.MinStdDev = 0 }, []string{"anomalies.min_stddev"}},
+		{"city without a code", func(c *Config) {
+			c.Cities = append(c.Cities, City{Name: "Находка", Timezone: "Asia/Vladivostok"})
+		}, []string{"code is required"}},
+		{"upper case code", func(c *Config) {
+			c.Cities = append(c.Cities, City{Code: "Nakhodka", Timezone: "Asia/Vladivostok"})
+		}, []string{"code must be lower case"}},
+		{"city twice", func(c *Config) { c.Cities = append(c.Cities, c.Cities[0]) }, []string{"configured twice"}},
+		{"no time zone", func(c *Config) { c.Cities[0].Timezone = "" }, []string{"timezone is required"}},
+		{"unknown time zone", func(c *Config) { c.Cities[0].Timezone = "Asia/Atlantis" }, []string{"timezone: unknown time zone"}},
+		{"unknown default city", func(c *Config) { c.DefaultCity = "atlantis" }, []string{`default_city "atlantis"`}},
+		{"all problems at once", func(c *Config) {
+			c.LogLevel = "trace"
+			c.Cities[0].Timezone = "Asia/Atlantis"
+			c.Anomalies.Weeks = 0
+		}, []string{"log_level", "timezone", "anomalies.weeks"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := load(t, shipped)
+			tt.change(cfg)
+
+			err := cfg.validate()
+
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("validate: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("validate: no error, want %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("validate: %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+)
+
+type Subscriber interface {
+	Subscribe(filter Filter, lastID int64) (*Subscription, []Event)
+	Unsubscribe(sub *Subscription)
+}
+
+// Consume calls handle for every event matching the filter until ctx is done.
+// When the hub drops the subscription because of a full buffer, Consume
+// resubscribes and picks up the missed events from the hub history.
+func Consume(ctx context.Context, log *slog.Logger, hub Subscriber, filter Filter, handle func(Event)) {
+	var lastID int64
+	for {
+		sub, backlog := hub.Subscribe(filter, lastID)
+
+		for _, e := range backlog {
+			if e.Type == StreamReset {
+				log.Warn("missed events are gone from the history", slog.Int64("last_event_id", lastID))
+				lastID = e.ID
+				continue
+			}
+			handle(e)
+			lastID = e.ID
+		}
+
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				hub.Unsubscribe(sub)
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					log.Warn("event subscription dropped, resubscribing", slog.Int64("last_event_id", lastID))
+					open = false
+					continue
+				}
+				handle(e)
+				lastID = e.ID
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+// subscriber hands out the queued subscriptions in order, the way the hub
+// answers a consumer that was dropped and resubscribes
+type subscriber struct {
+	subs     []*Subscription
+	backlogs [][]Event
+	lastIDs  []int64
+}
+
+func (s *subscriber) Subscribe(filter Filter, lastID int64) (*Subscription, []Event) {
+	s.lastIDs = append(s.lastIDs, lastID)
+
+	sub, backlog := s.subs[0], s.backlogs[0]
+	s.subs, s.backlogs = s.subs[1:], s.backlogs[1:]
+	return sub, backlog
+}
+
+func (s *subscriber) Unsubscribe(sub *Subscription) {}
+
+func subscription(events ...Event) (*Subscription, chan Event) {
+	c := make(chan Event, len(events))
+	for _, e := range events {
+		c <- e
+	}
+	return &Subscription{C: c, c: c}, c
+}
+
+func TestConsume(t *testing.T) {
+	dropped, c := subscription(Event{ID: 1, Type: CountsChanged})
+	close(c)
+
+	// the events after 1 are gone by the time the consumer is back
+	resumed, _ := subscription(Event{ID: 10, Type: CountsChanged})
+
+	hub := &subscriber{
+		subs:     []*Subscription{dropped, resumed},
+		backlogs: [][]Event{nil, {{ID: 9, Type: StreamReset}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var handled []Event
+	Consume(ctx, slog.New(slog.DiscardHandler), hub, Filter{}, func(e Event) {
+		handled = append(handled, e)
+		if e.ID == 10 {
+			cancel()
+		}
+	})
+
+	if got := ids(handled); len(got) != 2 || got[0] != 1 || got[1] != 10 {
+		t.Errorf("got handled %v, want [1 10] without the reset", got)
+	}
+	if len(hub.lastIDs) != 2 || hub.lastIDs[0] != 0 || hub.lastIDs[1] != 1 {
+		t.Errorf("got subscriptions from %v, want [0 1]", hub.lastIDs)
+	}
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"strings"
+	"vlru-prsch/internal/models"
+)
+
+const (
+	BlackoutCreated = "blackout.created"
+	BlackoutStarted = "blackout.started"
+	BlackoutEnded   = "blackout.ended"
+	BlackoutChanged = "blackout.changed"
+	CountsChanged   = "counts.changed"
+	// StreamReset tells a resuming client that the events it missed are gone
+	// and it has to reload the current state, the event has no time
+	StreamReset = "stream.reset"
+)
+
+// Event represents a change in the outage state of the city
+// @Description Событие об изменении состояния отключений
+type Event struct {
+	// Порядковый номер события, используется как id в SSE
+	ID int64 `json:"id" example:"42"`
+	// Тип события: blackout.created, blackout.started, blackout.ended, blackout.changed, counts.changed, stream.reset
+	Type string `json:"type" example:"blackout.started"`
+	// Время обнаружения события в формате "2006-01-02 15:04:05"
+	Time string `json:"time" example:"2019-01-15 14:30:00"`
+	// Код города события, у stream.reset отсутствует
+	City string `json:"city,omitempty" example:"vladivostok"`
+	// Отключение, к которому относится событие
+	Blackout *Blackout `json:"blackout,omitempty"`
+	// Количество затронутых зданий по типам отключений
+	Counts map[string]int64 `json:"counts,omitempty"`
+}
+
+// Blackout represents a blackout attached to an event
+// @Description Отключение, к которому относится событие
+type Blackout struct {
+	ID            string           `json:"id" example:"b1f4"`
+	Type          string           `json:"type" example:"hot_water"`
+	StartDate     string           `json:"start_date" example:"2019-01-15 10:00:00"`
+	EndDate       string           `json:"end_date" example:"2019-01-15 18:00:00"`
+	Description   string           `json:"description" example:"Плановые работы"`
+	InitiatorName string           `json:"initiator_name" example:"КГУП Приморский водоканал"`
+	Addresses     []models.Address `json:"addresses"`
+}
+
+func (b *Blackout) Streets() []string {
+	seen := make(map[string]bool)
+
+	var streets []string
+	for _, address := range b.Addresses {
+		if seen[address.Street] {
+			continue
+		}
+		seen[address.Street] = true
+		streets = append(streets, address.Street)
+	}
+
+	return streets
+}
+
+// Filter selects events by city, blackout type and street. Empty fields match everything,
+// events without a city, like stream.reset, go to every city.
+type Filter struct {
+	City    string
+	Types   []string
+	Streets []string
+}
+
+func (f Filter) Match(e Event) bool {
+	if f.City != "" && e.City != "" && e.City != f.City {
+		return false
+	}
+
+	if e.Blackout == nil {
+		if len(f.Types) == 0 {
+			return true
+		}
+		for _, t := range f.Types {
+			if _, ok := e.Counts[t]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(f.Types) > 0 && !contains(f.Types, e.Blackout.Type) {
+		return false
+	}
+
+	if len(f.Streets) == 0 {
+		return true
+	}
+
+	for _, street := range e.Blackout.Streets() {
+		if contains(f.Streets, street) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Hub fans events out to subscribers and keeps a bounded history
+// so that reconnecting clients can resume from Last-Event-ID.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      int64
+	history     []Event
+	historySize int
+	bufferSize  int
+	subs        map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	filter Filter
+	lagged bool
+}
+
+// Lagged reports whether the subscription was dropped because its buffer overflowed.
+func (s *Subscription) Lagged() bool {
+	return s.lagged
+}
+
+// NewHub returns a hub whose event ids continue after startID. Main passes the
+// start time in milliseconds, so ids keep growing across restarts and a client
+// resuming with an id of the previous run is told to reload.
+func NewHub(historySize, bufferSize int, startID int64) *Hub {
+	if historySize <= 0 {
+		historySize = 1
+	}
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+
+	return &Hub{
+		lastID:      startID,
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subs:        make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the next id to the event and delivers it to every matching subscriber.
+// Subscribers whose buffer is full are closed, they are expected to reconnect and resume.
+func (h *Hub) Publish(e Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	e.ID = h.lastID
+	if e.Time == "" {
+		e.Time = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	h.history = append(h.history, e)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+
+		select {
+		case sub.c <- e:
+		default:
+			sub.lagged = true
+			delete(h.subs, sub)
+			close(sub.c)
+		}
+	}
+
+	return e
+}
+
+// Subscribe registers a subscriber and returns the events after lastID
+// that are still in the history. When the events after lastID are gone,
+// because they fell out of the history or were published before a restart,
+// the backlog is a single StreamReset event and the client has to reload.
+func (h *Hub) Subscribe(filter Filter, lastID int64) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var backlog []Event
+	if lastID > 0 {
+		oldest := h.lastID + 1
+		if len(h.history) > 0 {
+			oldest = h.history[0].ID
+		}
+
+		if lastID < oldest-1 || lastID > h.lastID {
+			backlog = append(backlog, Event{ID: h.lastID, Type: StreamReset})
+		} else {
+			for _, e := range h.history {
+				if e.ID > lastID && filter.Match(e) {
+					backlog = append(backlog, e)
+				}
+			}
+		}
+	}
+
+	c := make(chan Event, h.bufferSize)
+	sub := &Subscription{C: c, c: c, filter: filter}
+	h.subs[sub] = struct{}{}
+
+	return sub, backlog
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.c)
+	}
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"testing"
+)
+
+func ids(events []Event) []int64 {
+	var ids []int64
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestHubSubscribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		startID   int64
+		published int
+		lastID    int64
+		wantIDs   []int64
+		wantReset bool
+	}{
+		{"new client", 100, 3, 0, nil, false},
+		{"resume", 100, 3, 101, []int64{102, 103}, false},
+		{"up to date", 100, 3, 103, nil, false},
+		{"resume from the oldest kept", 100, 5, 102, []int64{103, 104, 105}, false},
+		{"missed events fell out of the history", 100, 5, 101, []int64{105}, true},
+		{"id of the previous run", 100, 0, 42, []int64{100}, true},
+		{"id of the previous run with new events", 100, 2, 42, []int64{102}, true},
+		{"id from the future", 100, 2, 500, []int64{102}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(3, 10, tt.startID)
+			for range tt.published {
+				hub.Publish(Event{Type: CountsChanged, Time: "2024-03-10 12:00:00"})
+			}
+
+			sub, backlog := hub.Subscribe(Filter{}, tt.lastID)
+			defer hub.Unsubscribe(sub)
+
+			got := ids(backlog)
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("got backlog %v, want %v", got, tt.wantIDs)
+			}
+			for i := range got {
+				if got[i] != tt.wantIDs[i] {
+					t.Fatalf("got backlog %v, want %v", got, tt.wantIDs)
+				}
+			}
+
+			reset := len(backlog) == 1 && backlog[0].Type == StreamReset
+			if reset != tt.wantReset {
+				t.Errorf("got reset %v, want %v", reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestHubIDsGrowAcrossRestarts(t *testing.T) {
+	before := NewHub(10, 10, 1000)
+	last := before.Publish(Event{Type: CountsChanged})
+
+	// the next run starts later, so its ids start higher
+	after := NewHub(10, 10, 2000)
+	first := after.Publish(Event{Type: CountsChanged})
+
+	if first.ID <= last.ID {
+		t.Errorf("id %d after the restart is not above %d", first.ID, last.ID)
+	}
+}
@@ -0,0 +1,220 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+type Source interface {
+	GetFilteredBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error)
+	GetUpcomingBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error)
+	GetBlackoutAddresses(blackoutID string) ([]models.Address, error)
+	GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error)
+}
+
+// Watcher polls the storage and publishes the difference between
+// two consecutive snapshots of active blackouts to the hub.
+// Every city has its own snapshot, taken at the wall clock of the city the
+// dates of its blackouts are in, and its events carry the city code.
+type Watcher struct {
+	log      *slog.Logger
+	src      Source
+	hub      *Hub
+	types    []string
+	interval time.Duration
+	cities   []models.City
+	now      func() time.Time
+
+	primed    bool
+	snapshots map[string]snapshot
+}
+
+// snapshot is the state of one city at a poll
+type snapshot struct {
+	active   map[string]models.Blackout
+	upcoming map[string]models.Blackout
+	counts   map[string]int64
+}
+
+// NewWatcher returns a watcher over the blackouts of the cities, the counts
+// are taken for each of types
+func NewWatcher(log *slog.Logger, src Source, hub *Hub, types []string, interval time.Duration, cities []models.City) *Watcher {
+	return &Watcher{
+		log:       log,
+		src:       src,
+		hub:       hub,
+		types:     types,
+		interval:  interval,
+		cities:    cities,
+		now:       time.Now,
+		snapshots: map[string]snapshot{},
+	}
+}
+
+func (w *Watcher) Run(ctx context.Context) {
+	const op = "events.Watcher.Run"
+
+	log := w.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(); err != nil {
+			log.Error("failed to poll blackouts", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll takes a new snapshot of every city and publishes the changes since the previous one.
+// The first snapshot only primes the state, so restarts do not replay every active blackout.
+func (w *Watcher) Poll() error {
+	const op = "events.Watcher.Poll"
+
+	now := w.now()
+
+	snapshots := make(map[string]snapshot, len(w.cities))
+	times := make(map[string]string, len(w.cities))
+	for _, city := range w.cities {
+		local := now
+		if city.Location != nil {
+			local = now.In(city.Location)
+		}
+		times[city.Code] = local.Format("2006-01-02 15:04:05")
+
+		curr, err := w.take(city.Code, times[city.Code])
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		snapshots[city.Code] = curr
+	}
+
+	if w.primed {
+		for _, city := range w.cities {
+			w.diff(city.Code, times[city.Code], w.snapshots[city.Code], snapshots[city.Code])
+		}
+	}
+
+	w.primed = true
+	w.snapshots = snapshots
+
+	return nil
+}
+
+// take reads the state of the city at currentTime
+func (w *Watcher) take(city string, currentTime string) (snapshot, error) {
+	filter := models.Filter{City: city}
+
+	blackouts, err := w.src.GetFilteredBlackouts(currentTime, filter)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	active := make(map[string]models.Blackout, len(blackouts))
+	for _, blackout := range blackouts {
+		active[blackout.ID] = blackout
+	}
+
+	announced, err := w.src.GetUpcomingBlackouts(currentTime, filter)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	upcoming := make(map[string]models.Blackout, len(announced))
+	for _, blackout := range announced {
+		upcoming[blackout.ID] = blackout
+	}
+
+	counts := make(map[string]int64, len(w.types))
+	for _, blackoutType := range w.types {
+		count, err := w.src.GetBuildingsCountByBlackoutType(blackoutType, currentTime, filter)
+		if err != nil {
+			return snapshot{}, err
+		}
+		counts[blackoutType] = count
+	}
+
+	return snapshot{active: active, upcoming: upcoming, counts: counts}, nil
+}
+
+// diff publishes the changes in the city between two snapshots
+func (w *Watcher) diff(city string, currentTime string, prev, curr snapshot) {
+	for id, blackout := range curr.upcoming {
+		old, ok := prev.upcoming[id]
+		switch {
+		case !ok:
+			w.publish(BlackoutCreated, city, currentTime, blackout)
+		case changed(old, blackout):
+			w.publish(BlackoutChanged, city, currentTime, blackout)
+		}
+	}
+
+	for id, blackout := range curr.active {
+		old, ok := prev.active[id]
+		switch {
+		case !ok:
+			w.publish(BlackoutStarted, city, currentTime, blackout)
+		case changed(old, blackout):
+			w.publish(BlackoutChanged, city, currentTime, blackout)
+		}
+	}
+
+	for id, blackout := range prev.active {
+		if _, ok := curr.active[id]; !ok {
+			w.publish(BlackoutEnded, city, currentTime, blackout)
+		}
+	}
+
+	if countsChanged(prev.counts, curr.counts) {
+		w.hub.Publish(Event{Type: CountsChanged, Time: currentTime, City: city, Counts: curr.counts})
+	}
+}
+
+func (w *Watcher) publish(eventType, city, currentTime string, blackout models.Blackout) {
+	addresses, err := w.src.GetBlackoutAddresses(blackout.ID)
+	if err != nil {
+		w.log.Error("failed to get blackout addresses",
+			slog.String("blackout_id", blackout.ID), sl.Err(err))
+	}
+
+	w.hub.Publish(Event{
+		Type: eventType,
+		Time: currentTime,
+		City: city,
+		Blackout: &Blackout{
+			ID:            blackout.ID,
+			Type:          blackout.Type,
+			StartDate:     blackout.StartDate,
+			EndDate:       blackout.EndDate,
+			Description:   blackout.Description,
+			InitiatorName: blackout.InitiatorName,
+			Addresses:     addresses,
+		},
+	})
+}
+
+// changed compares the fields published with an event, so a blackout
+// classified in the meantime does not count as changed
+func changed(prev, curr models.Blackout) bool {
+	prev.Kind, curr.Kind = "", ""
+	return prev != curr
+}
+
+func countsChanged(prev, curr map[string]int64) bool {
+	for blackoutType, count := range curr {
+		if prev[blackoutType] != count {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+// source holds the blackouts of every city, active ones are those running at currentTime
+type source struct {
+	blackouts map[string][]models.Blackout
+}
+
+func (s *source) GetFilteredBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error) {
+	var active []models.Blackout
+	for _, blackout := range s.blackouts[filter.City] {
+		if blackout.StartDate <= currentTime && currentTime < blackout.EndDate {
+			active = append(active, blackout)
+		}
+	}
+	return active, nil
+}
+
+func (s *source) GetUpcomingBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error) {
+	var upcoming []models.Blackout
+	for _, blackout := range s.blackouts[filter.City] {
+		if blackout.StartDate > currentTime {
+			upcoming = append(upcoming, blackout)
+		}
+	}
+	return upcoming, nil
+}
+
+func (s *source) GetBlackoutAddresses(blackoutID string) ([]models.Address, error) {
+	return nil, nil
+}
+
+func (s *source) GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error) {
+	return 0, nil
+}
+
+func TestWatcherPollsAtTheClockOfEveryCity(t *testing.T) {
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := &source{blackouts: map[string][]models.Blackout{
+		"vladivostok": {{ID: "east", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00"}},
+		"moscow":      {{ID: "west", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00"}},
+	}}
+
+	hub := NewHub(10, 10, 0)
+	sub, _ := hub.Subscribe(Filter{}, 0)
+	defer hub.Unsubscribe(sub)
+
+	watcher := NewWatcher(slog.New(slog.DiscardHandler), src, hub, models.DefaultServiceTypes().Codes(), time.Minute, []models.City{
+		{Code: "vladivostok", Location: vladivostok},
+		{Code: "moscow", Location: moscow},
+	})
+
+	// 08:30 in Vladivostok and 01:30 in Moscow primes the state, an hour later
+	// the blackout of Vladivostok has started and the one of Moscow has not
+	now := time.Date(2024, 3, 9, 22, 30, 0, 0, time.UTC)
+	watcher.now = func() time.Time { return now }
+
+	for range 2 {
+		if err := watcher.Poll(); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Hour)
+	}
+
+	var got []Event
+	for len(sub.C) > 0 {
+		got = append(got, <-sub.C)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("got %+v, want only the start of the blackout of Vladivostok", got)
+	}
+	if e := got[0]; e.Type != BlackoutStarted || e.City != "vladivostok" || e.Blackout.ID != "east" || e.Time != "2024-03-10 09:30:00" {
+		t.Errorf("got %+v, want east started in vladivostok at 09:30", e)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	blackout := models.Blackout{ID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", Type: "hot_water"}
+
+	classified := blackout
+	classified.Kind = models.KindPlanned
+
+	extended := blackout
+	extended.EndDate = "2024-03-10 20:00:00"
+
+	tests := []struct {
+		name       string
+		prev, curr models.Blackout
+		want       bool
+	}{
+		{"same", blackout, blackout, false},
+		{"classified in the meantime", blackout, classified, false},
+		{"end moved", blackout, extended, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changed(tt.prev, tt.curr); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
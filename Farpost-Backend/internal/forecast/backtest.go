@@ -0,0 +1,106 @@
+package forecast
+
+import (
+	"math"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+// calibrationBins split predicted probabilities into equal-width bins
+const calibrationBins = 10
+
+// Report holds the scores of a backtest. Lower Brier score is better,
+// a positive skill means the model beats the constant per-type base rate.
+type Report struct {
+	Split      string
+	Horizon    int
+	Pairs      int
+	Outages    int
+	Brier      float64
+	BaseBrier  float64
+	Skill      float64
+	LogLoss    float64
+	Calibrated [calibrationBins]Bin
+}
+
+// Bin compares predicted and observed frequencies of one probability range
+type Bin struct {
+	Predictions int
+	Predicted   float64
+	Observed    float64
+}
+
+// Backtest trains on the history before split and scores every street seen
+// in training against the outage days of the following horizon days.
+func Backtest(records []models.OutageRecord, streets int, from, split time.Time, horizon int, opts Options) Report {
+	split = truncate(split)
+	trainEnd := split.AddDate(0, 0, -1)
+	testEnd := split.AddDate(0, 0, horizon-1)
+
+	model := Train(records, streets, from, trainEnd, opts)
+	outages := outageDays(records, split, testEnd)
+
+	// the base rate is the share of street-days with an outage in training, per type
+	baseRate := map[string]float64{}
+	trainDays := daysBetween(truncate(from), trainEnd)
+	for _, kinds := range model.Days {
+		for kind, counts := range kinds {
+			for _, count := range counts {
+				baseRate[kind] += float64(count)
+			}
+		}
+	}
+	for kind := range baseRate {
+		baseRate[kind] /= float64(trainDays * model.Streets)
+	}
+
+	report := Report{Split: split.Format(dateLayout), Horizon: horizon}
+
+	var brier, baseBrier, logLoss float64
+	for street := range model.Days {
+		for _, kind := range opts.Types {
+			for day := split; !day.After(testEnd); day = day.AddDate(0, 0, 1) {
+				p := model.Probability(street, kind, day)
+
+				var y float64
+				if outages[outageDay{street: street, kind: kind, day: day}] {
+					y = 1
+					report.Outages++
+				}
+
+				brier += (p - y) * (p - y)
+				baseBrier += (baseRate[kind] - y) * (baseRate[kind] - y)
+
+				clipped := math.Min(math.Max(p, 1e-6), 1-1e-6)
+				logLoss -= y*math.Log(clipped) + (1-y)*math.Log(1-clipped)
+
+				bin := min(int(p*calibrationBins), calibrationBins-1)
+				report.Calibrated[bin].Predictions++
+				report.Calibrated[bin].Predicted += p
+				report.Calibrated[bin].Observed += y
+
+				report.Pairs++
+			}
+		}
+	}
+
+	if report.Pairs == 0 {
+		return report
+	}
+
+	report.Brier = brier / float64(report.Pairs)
+	report.BaseBrier = baseBrier / float64(report.Pairs)
+	report.LogLoss = logLoss / float64(report.Pairs)
+	if report.BaseBrier > 0 {
+		report.Skill = 1 - report.Brier/report.BaseBrier
+	}
+
+	for i := range report.Calibrated {
+		if n := report.Calibrated[i].Predictions; n > 0 {
+			report.Calibrated[i].Predicted /= float64(n)
+			report.Calibrated[i].Observed /= float64(n)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,318 @@
+package forecast
+
+import (
+	"math"
+	"sort"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+const (
+	timeLayout = "2006-01-02 15:04:05"
+	dateLayout = "2006-01-02"
+
+	// weeks of the year, the last one is a day or two long
+	weeks = 53
+
+	// maxOutageDays caps the days counted for one long outage,
+	// so a forgotten end date does not paint a whole season
+	maxOutageDays = 14
+
+	// weekdayPrior is the prior strength of the weekday factor in outage days
+	weekdayPrior = 28
+)
+
+// Model is a seasonal baseline of outage probabilities by type and street.
+//
+// For a street, a type and a day the probability is the street's own rate in
+// that week of the year, shrunk towards the city seasonal rate scaled by how
+// often the street is hit compared to an average one, times a weekday factor.
+type Model struct {
+	// Training period, dates in YYYY-MM-DD
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Streets is the number of streets with real buildings
+	Streets int `json:"streets"`
+	// Alpha is the prior strength of the street factor in days
+	Alpha float64 `json:"alpha"`
+	// Beta is the prior strength of the street weekly rate in days
+	Beta float64 `json:"beta"`
+
+	// Exposure counts the training days falling in each week of the year
+	Exposure [weeks]int `json:"exposure"`
+	// Season is the smoothed share of streets with an outage on a day of the week, by type
+	Season map[string][weeks]float64 `json:"season"`
+	// Weekday is the relative frequency of outages by weekday, Sunday first, by type
+	Weekday map[string][7]float64 `json:"weekday"`
+	// Factor is how much more often than average a street is hit, by street and type
+	Factor map[string]map[string]float64 `json:"factor"`
+	// Days counts outage days by street, type and week of the year
+	Days map[string]map[string]map[int]int `json:"days"`
+	// Types are the service types forecast, in display order
+	Types []string `json:"types"`
+}
+
+type Options struct {
+	Alpha float64
+	Beta  float64
+	// Types are the service types forecast, in display order
+	Types []string
+}
+
+// Likely is a forecast for one type on one day
+// @Description Вероятное отключение
+type Likely struct {
+	// Тип услуги
+	Service string `json:"service" example:"hot_water"`
+	// Наибольшая вероятность среди улиц
+	Probability float64 `json:"probability" example:"0.42"`
+	// Улицы с наибольшей вероятностью отключения
+	Streets []StreetProbability `json:"streets"`
+}
+
+// StreetProbability is the forecast for one street
+// @Description Вероятность отключения на улице
+type StreetProbability struct {
+	// Улица
+	Street string `json:"street" example:"Карбышева ул."`
+	// Вероятность отключения в этот день
+	Probability float64 `json:"probability" example:"0.42"`
+}
+
+// outageDay marks that a street had an outage of a type on a day
+type outageDay struct {
+	street string
+	kind   string
+	day    time.Time
+}
+
+// Train builds a model from the history between from and to inclusive.
+// Records starting outside the period are ignored.
+func Train(records []models.OutageRecord, streets int, from, to time.Time, opts Options) *Model {
+	from, to = truncate(from), truncate(to)
+
+	m := &Model{
+		From:    from.Format(dateLayout),
+		To:      to.Format(dateLayout),
+		Streets: max(streets, 1),
+		Alpha:   opts.Alpha,
+		Beta:    opts.Beta,
+		Season:  map[string][weeks]float64{},
+		Weekday: map[string][7]float64{},
+		Factor:  map[string]map[string]float64{},
+		Days:    map[string]map[string]map[int]int{},
+		Types:   opts.Types,
+	}
+
+	var weekdayExposure [7]int
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		m.Exposure[week(day)]++
+		weekdayExposure[day.Weekday()]++
+	}
+
+	days := outageDays(records, from, to)
+
+	cityWeeks := map[string]*[weeks]int{}
+	cityWeekdays := map[string]*[7]int{}
+	for key := range days {
+		if _, ok := cityWeeks[key.kind]; !ok {
+			cityWeeks[key.kind] = &[weeks]int{}
+			cityWeekdays[key.kind] = &[7]int{}
+		}
+		cityWeeks[key.kind][week(key.day)]++
+		cityWeekdays[key.kind][key.day.Weekday()]++
+
+		if m.Days[key.street] == nil {
+			m.Days[key.street] = map[string]map[int]int{}
+		}
+		if m.Days[key.street][key.kind] == nil {
+			m.Days[key.street][key.kind] = map[int]int{}
+		}
+		m.Days[key.street][key.kind][week(key.day)]++
+	}
+
+	for kind, counts := range cityWeeks {
+		var season [weeks]float64
+		for w := range weeks {
+			var outages, exposure int
+			for _, nw := range neighbours(w) {
+				outages += counts[nw]
+				exposure += m.Exposure[nw]
+			}
+			if exposure > 0 {
+				season[w] = float64(outages) / float64(exposure*m.Streets)
+			}
+		}
+		m.Season[kind] = season
+
+		var total int
+		for _, count := range cityWeekdays[kind] {
+			total += count
+		}
+		var weekday [7]float64
+		for d := range 7 {
+			expected := float64(weekdayExposure[d]) / float64(daysBetween(from, to))
+			if expected == 0 {
+				weekday[d] = 1
+				continue
+			}
+			// four weeks of average outages pull a rare type towards a flat week
+			share := (float64(cityWeekdays[kind][d]) + weekdayPrior*expected) / (float64(total) + weekdayPrior)
+			weekday[d] = share / expected
+		}
+		m.Weekday[kind] = weekday
+	}
+
+	for street, kinds := range m.Days {
+		m.Factor[street] = map[string]float64{}
+		for kind, counts := range kinds {
+			var observed int
+			var expected float64
+			for w := range weeks {
+				observed += counts[w]
+				expected += float64(m.Exposure[w]) * m.Season[kind][w]
+			}
+			m.Factor[street][kind] = (float64(observed) + m.Alpha) / (expected + m.Alpha)
+		}
+	}
+
+	return m
+}
+
+// outageDays expands the records starting between from and to into days with outages
+func outageDays(records []models.OutageRecord, from, to time.Time) map[outageDay]bool {
+	days := map[outageDay]bool{}
+	for _, record := range records {
+		start, err := time.Parse(timeLayout, record.StartDate)
+		if err != nil || start.Before(from) || start.After(to.Add(24*time.Hour-time.Second)) {
+			continue
+		}
+
+		last := truncate(start)
+		if end, err := time.Parse(timeLayout, record.EndDate); err == nil && end.After(start) {
+			last = truncate(end)
+		}
+		if limit := truncate(start).AddDate(0, 0, maxOutageDays-1); last.After(limit) {
+			last = limit
+		}
+		if last.After(to) {
+			last = to
+		}
+
+		for day := truncate(start); !day.After(last); day = day.AddDate(0, 0, 1) {
+			days[outageDay{street: record.Street, kind: record.Type, day: day}] = true
+		}
+	}
+
+	return days
+}
+
+// Probability returns the chance of an outage of the type on the street on the day
+func (m *Model) Probability(street, kind string, day time.Time) float64 {
+	w := week(day)
+
+	season, ok := m.Season[kind]
+	if !ok {
+		return 0
+	}
+
+	factor, ok := m.Factor[street][kind]
+	if !ok {
+		// an average street's expected outage days are the whole prior
+		var expected float64
+		for i := range weeks {
+			expected += float64(m.Exposure[i]) * season[i]
+		}
+		factor = m.Alpha / (expected + m.Alpha)
+	}
+
+	prior := math.Min(season[w]*factor, 1)
+
+	var observed, exposure int
+	for _, nw := range neighbours(w) {
+		observed += m.Days[street][kind][nw]
+		exposure += m.Exposure[nw]
+	}
+
+	p := (float64(observed) + m.Beta*prior) / (float64(exposure) + m.Beta)
+	p *= m.Weekday[kind][day.Weekday()]
+
+	return math.Min(math.Max(p, 0), 1)
+}
+
+// Likely returns per type the streets most likely to lose the service on the day.
+// With a street given only that street is considered and every type is reported,
+// so a resident sees the low chances too.
+func (m *Model) Likely(day time.Time, street string, minProbability float64, limit int) []Likely {
+	var likely []Likely
+
+	// a model trained before the registry forecasts the types of the source
+	types := m.Types
+	if len(types) == 0 {
+		types = models.DefaultServiceTypes().Codes()
+	}
+
+	for _, kind := range types {
+		var candidates []StreetProbability
+
+		if street != "" {
+			if _, ok := m.Season[kind]; ok {
+				candidates = append(candidates, StreetProbability{Street: street, Probability: round(m.Probability(street, kind, day))})
+			}
+		} else {
+			for name := range m.Days {
+				if _, ok := m.Days[name][kind]; !ok {
+					continue
+				}
+				if p := m.Probability(name, kind, day); p >= minProbability {
+					candidates = append(candidates, StreetProbability{Street: name, Probability: round(p)})
+				}
+			}
+		}
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].Probability != candidates[j].Probability {
+				return candidates[i].Probability > candidates[j].Probability
+			}
+			return candidates[i].Street < candidates[j].Street
+		})
+		if len(candidates) > limit {
+			candidates = candidates[:limit]
+		}
+
+		likely = append(likely, Likely{
+			Service:     kind,
+			Probability: candidates[0].Probability,
+			Streets:     candidates,
+		})
+	}
+
+	return likely
+}
+
+// neighbours returns the week with the weeks around it,
+// they smooth out the noise of a few years of history
+func neighbours(w int) [3]int {
+	return [3]int{(w + weeks - 1) % weeks, w, (w + 1) % weeks}
+}
+
+// week returns the week of the year counted from January 1st, 0 to 52
+func week(day time.Time) int {
+	return (day.YearDay() - 1) / 7
+}
+
+func truncate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+func round(p float64) float64 {
+	return math.Round(p*1000) / 1000
+}
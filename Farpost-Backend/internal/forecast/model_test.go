@@ -0,0 +1,220 @@
+package forecast
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+func date(s string) time.Time {
+	day, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return day
+}
+
+func record(street, kind, start, end string) models.OutageRecord {
+	return models.OutageRecord{
+		Address:   models.Address{Street: street},
+		Type:      kind,
+		StartDate: start,
+		EndDate:   end,
+	}
+}
+
+// history has hot water off on Карбышева every Monday morning, on Светланская
+// on the first day of every third month and electricity off on Светланская
+// every ten days, from 2022-01-03 for two years
+func history() []models.OutageRecord {
+	var records []models.OutageRecord
+
+	from := date("2022-01-03")
+	for day := from; day.Before(from.AddDate(2, 0, 0)); day = day.AddDate(0, 0, 1) {
+		start := day.Format(dateLayout) + " 09:00:00"
+		end := day.Format(dateLayout) + " 12:00:00"
+
+		if day.Weekday() == time.Monday {
+			records = append(records, record("Карбышева ул.", "hot_water", start, end))
+		}
+		if day.Day() == 1 && day.Month()%3 == 1 {
+			records = append(records, record("Светланская ул.", "hot_water", start, end))
+		}
+		if int(day.Sub(from).Hours()/24)%10 == 0 {
+			records = append(records, record("Светланская ул.", "electricity", start, end))
+		}
+	}
+
+	return records
+}
+
+func TestOutageDays(t *testing.T) {
+	from, to := date("2024-03-01"), date("2024-03-31")
+
+	tests := []struct {
+		name   string
+		record models.OutageRecord
+		want   []string
+	}{
+		{"within a day", record("a", "heat", "2024-03-05 09:00:00", "2024-03-05 12:00:00"), []string{"2024-03-05"}},
+		{"over midnight", record("a", "heat", "2024-03-05 22:00:00", "2024-03-06 02:00:00"), []string{"2024-03-05", "2024-03-06"}},
+		{"no end", record("a", "heat", "2024-03-05 09:00:00", ""), []string{"2024-03-05"}},
+		{"end before start", record("a", "heat", "2024-03-05 09:00:00", "2024-03-01 09:00:00"), []string{"2024-03-05"}},
+		{"capped", record("a", "heat", "2024-03-01 09:00:00", "2024-03-30 09:00:00"), days("2024-03-01", maxOutageDays)},
+		{"clipped at the end of the period", record("a", "heat", "2024-03-30 09:00:00", "2024-04-02 09:00:00"), []string{"2024-03-30", "2024-03-31"}},
+		{"last second of the period", record("a", "heat", "2024-03-31 23:59:59", ""), []string{"2024-03-31"}},
+		{"starts before the period", record("a", "heat", "2024-02-29 09:00:00", "2024-03-02 09:00:00"), nil},
+		{"starts after the period", record("a", "heat", "2024-04-01 00:00:00", ""), nil},
+		{"unparsable", record("a", "heat", "yesterday", ""), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+				if outageDays([]models.OutageRecord{tt.record}, from, to)[outageDay{street: "a", kind: "heat", day: day}] {
+					got = append(got, day.Format(dateLayout))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func days(first string, n int) []string {
+	var list []string
+	for i := range n {
+		list = append(list, date(first).AddDate(0, 0, i).Format(dateLayout))
+	}
+	return list
+}
+
+func TestTrain(t *testing.T) {
+	m := Train(history(), 10, date("2022-01-03"), date("2024-01-01"), Options{Alpha: 30, Beta: 14, Types: models.DefaultServiceTypes().Codes()})
+
+	var exposure int
+	for _, days := range m.Exposure {
+		exposure += days
+	}
+	if exposure != daysBetween(date("2022-01-03"), date("2024-01-01")) {
+		t.Errorf("got %d exposure days, want the whole period", exposure)
+	}
+
+	monday, tuesday := date("2024-03-04"), date("2024-03-05")
+	p := func(street, kind string, day time.Time) float64 { return m.Probability(street, kind, day) }
+
+	tests := []struct {
+		name          string
+		higher, lower float64
+	}{
+		{"the hit street over the rarely hit one", p("Карбышева ул.", "hot_water", monday), p("Светланская ул.", "hot_water", monday)},
+		{"the usual weekday over another one", p("Карбышева ул.", "hot_water", monday), p("Карбышева ул.", "hot_water", tuesday)},
+		{"a known street over an unknown one", p("Светланская ул.", "electricity", tuesday), p("Ленина ул.", "electricity", tuesday)},
+		{"an unknown street keeps a chance", p("Ленина ул.", "hot_water", monday), 0},
+	}
+
+	for _, tt := range tests {
+		if tt.higher <= tt.lower {
+			t.Errorf("%s: %v is not above %v", tt.name, tt.higher, tt.lower)
+		}
+	}
+
+	for _, street := range []string{"Карбышева ул.", "Светланская ул.", "Ленина ул."} {
+		for _, kind := range []string{"hot_water", "electricity"} {
+			for day := monday; day.Before(monday.AddDate(0, 0, 7)); day = day.AddDate(0, 0, 1) {
+				if got := p(street, kind, day); got < 0 || got > 1 {
+					t.Errorf("%s %s %s: probability %v out of [0, 1]", street, kind, day.Format(dateLayout), got)
+				}
+			}
+		}
+	}
+
+	if got := p("Карбышева ул.", "heat", monday); got != 0 {
+		t.Errorf("got %v for a type never seen, want 0", got)
+	}
+}
+
+func TestLikely(t *testing.T) {
+	m := Train(history(), 10, date("2022-01-03"), date("2024-01-01"), Options{Alpha: 30, Beta: 14, Types: models.DefaultServiceTypes().Codes()})
+	monday := date("2024-03-04")
+
+	t.Run("streets of every type over the minimum", func(t *testing.T) {
+		likely := m.Likely(monday, "", 0.2, 5)
+		if len(likely) != 1 || likely[0].Service != "hot_water" || len(likely[0].Streets) != 1 || likely[0].Streets[0].Street != "Карбышева ул." {
+			t.Errorf("got %+v, want hot water on Карбышева only", likely)
+		}
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		for _, l := range m.Likely(monday, "", 0, 1) {
+			if len(l.Streets) != 1 || l.Probability != l.Streets[0].Probability {
+				t.Errorf("got %+v, want the single most likely street", l)
+			}
+		}
+	})
+
+	t.Run("one street gets every trained type", func(t *testing.T) {
+		var services []string
+		for _, l := range m.Likely(monday, "Ленина ул.", 0.99, 5) {
+			services = append(services, l.Service)
+		}
+		if !reflect.DeepEqual(services, []string{"hot_water", "electricity"}) {
+			t.Errorf("got %v, want every trained type in registry order", services)
+		}
+	})
+}
+
+func TestSaveLoad(t *testing.T) {
+	m := Train(history(), 10, date("2022-01-03"), date("2024-01-01"), Options{Alpha: 30, Beta: 14, Types: models.DefaultServiceTypes().Codes()})
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(loaded, m) {
+		t.Error("the loaded model differs from the saved one")
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	records := history()
+	opts := Options{Alpha: 30, Beta: 14, Types: models.DefaultServiceTypes().Codes()}
+
+	report := Backtest(records, 10, date("2022-01-03"), date("2023-07-03"), 28, opts)
+
+	streets := 2
+	if want := streets * len(opts.Types) * 28; report.Pairs != want {
+		t.Errorf("got %d pairs, want %d", report.Pairs, want)
+	}
+	// four Mondays and three ten-day electricity outages, the quarterly one is outside
+	if report.Outages != 7 {
+		t.Errorf("got %d outages, want 7", report.Outages)
+	}
+	if report.Skill <= 0 || report.Brier >= report.BaseBrier {
+		t.Errorf("got Brier %v against the base %v, want the model to beat the base rate", report.Brier, report.BaseBrier)
+	}
+
+	var predictions int
+	for _, bin := range report.Calibrated {
+		predictions += bin.Predictions
+		if bin.Predicted < 0 || bin.Predicted > 1 || bin.Observed < 0 || bin.Observed > 1 {
+			t.Errorf("got bin %+v out of [0, 1]", bin)
+		}
+	}
+	if predictions != report.Pairs {
+		t.Errorf("got %d predictions in the bins, want %d", predictions, report.Pairs)
+	}
+
+	if empty := Backtest(nil, 10, date("2022-01-03"), date("2023-07-03"), 28, opts); empty.Pairs != 0 || empty.Skill != 0 {
+		t.Errorf("got %+v for an empty history, want no pairs", empty)
+	}
+}
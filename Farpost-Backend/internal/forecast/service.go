@@ -0,0 +1,81 @@
+package forecast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoModel = errors.New("forecast model is not loaded")
+
+func Load(path string) (*Model, error) {
+	const op = "forecast.Load"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var model Model
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &model, nil
+}
+
+// Save writes the model next to path first, so a running server never reads a half-written file
+func (m *Model) Save(path string) error {
+	const op = "forecast.Model.Save"
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Service serves forecasts from the currently loaded model
+type Service struct {
+	mu    sync.RWMutex
+	model *Model
+
+	minProbability float64
+	limit          int
+}
+
+func NewService(minProbability float64, limit int) *Service {
+	return &Service{minProbability: minProbability, limit: limit}
+}
+
+// Set replaces the model, nil disables forecasts
+func (s *Service) Set(model *Model) {
+	s.mu.Lock()
+	s.model = model
+	s.mu.Unlock()
+}
+
+func (s *Service) Likely(day time.Time, street string) ([]Likely, error) {
+	s.mu.RLock()
+	model := s.model
+	s.mu.RUnlock()
+
+	if model == nil {
+		return nil, ErrNoModel
+	}
+
+	return model.Likely(day, street, s.minProbability, s.limit), nil
+}
@@ -0,0 +1,112 @@
+package durations
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+	"vlru-prsch/internal/analytics"
+	"vlru-prsch/internal/lib/api/filter"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// maxRange keeps a single request within about a year of data
+const maxRange = 366 * 24 * time.Hour
+
+// Response represents outage duration analytics for a period
+// @Description Аналитика длительности отключений за период
+type Response struct {
+	response.Response
+	// Начало периода
+	From string `json:"from" example:"2019-10-01"`
+	// Конец периода
+	To string `json:"to" example:"2019-12-31"`
+	// Итог по всем отключениям
+	Total analytics.DurationStats `json:"total"`
+	// По типам услуг
+	ByType []analytics.DurationStats `json:"by_type"`
+	// По организациям, от наибольших потерь здание-часов
+	ByOrganization []analytics.DurationStats `json:"by_organization"`
+	// Помесячная динамика
+	Trend []analytics.MonthTrend `json:"trend"`
+}
+
+type DurationsGiver interface {
+	GetDistricts(filter models.Filter) ([]models.District, error)
+	GetBlackoutsWithBuildings(from string, to string, filter models.Filter) ([]models.BlackoutWithBuildings, error)
+}
+
+// New godoc
+// @Summary Длительность отключений
+// @Description Считает среднюю, медианную и 90-перцентильную длительность отключений, начавшихся в периоде, по типам услуг и организациям, потерянные здание-часы, долю отключений без даты окончания и помесячную динамику. Учитываются только реальные здания. Отключения без окончания не входят в длительности, а в здание-часах считаются до конца периода
+// @Tags analytics
+// @Produce json
+// @Param from query string true "Начало периода в формате YYYY-MM-DD" example(2019-10-01)
+// @Param to query string true "Конец периода в формате YYYY-MM-DD, включительно" example(2019-12-31)
+// @Param district query string false "Административный район" example(Ленинский)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Аналитика за период"
+// @Failure 400 {object} response.Response "Неверный период - пример: {\"status\":\"ERROR\",\"error\":\"invalid period\"}"
+// @Failure 500 {object} response.Response "Ошибка при получении данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get blackouts data\"}"
+// @Router /off/analytics/durations [get]
+func New(log *slog.Logger, giver DurationsGiver, types models.ServiceTypes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.analytics.durations.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+		if from == "" || to == "" {
+			log.Warn("from or to parameter is empty")
+			render.JSON(w, r, response.Error("from and to parameters are required"))
+			return
+		}
+
+		fromDate, errFrom := time.Parse("2006-01-02", from)
+		toDate, errTo := time.Parse("2006-01-02", to)
+		if errFrom != nil || errTo != nil {
+			log.Warn("invalid date format", slog.String("from", from), slog.String("to", to))
+			render.JSON(w, r, response.Error("invalid date format, expected YYYY-MM-DD"))
+			return
+		}
+
+		if toDate.Before(fromDate) || toDate.Sub(fromDate) > maxRange {
+			log.Warn("invalid period", slog.String("from", from), slog.String("to", to))
+			render.JSON(w, r, response.Error("invalid period, to must follow from by at most 366 days"))
+			return
+		}
+
+		areaFilter, err := filter.FromRequest(r, giver)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
+		blackouts, err := giver.GetBlackoutsWithBuildings(from, to, areaFilter)
+		if err != nil {
+			log.Error("failed to get blackouts", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get blackouts data"))
+			return
+		}
+
+		report := analytics.Durations(blackouts, types.Codes(), toDate.Add(24*time.Hour-time.Second))
+
+		render.JSON(w, r, Response{
+			Response:       response.Ok(),
+			From:           from,
+			To:             to,
+			Total:          report.Total,
+			ByType:         report.ByType,
+			ByOrganization: report.ByOrganization,
+			Trend:          report.Trend,
+		})
+	}
+}
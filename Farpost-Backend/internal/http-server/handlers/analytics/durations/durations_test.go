@@ -0,0 +1,37 @@
+package durations_test
+
+import (
+	"net/http"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/analytics/durations"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/analytics/durations", durations.New(handlertest.Log, handlertest.Storage(t), handlertest.ServiceTypes))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"whole range with an open ended blackout", "/off/analytics/durations?from=2024-01-01&to=2024-12-31"},
+		{"one day", "/off/analytics/durations?from=2024-03-10&to=2024-03-10"},
+		{"other city", "/khabarovsk/off/analytics/durations?from=2024-01-01&to=2024-12-31"},
+		{"empty range", "/off/analytics/durations?from=2023-01-01&to=2023-12-31"},
+		{"missing bounds", "/off/analytics/durations"},
+		{"invalid date", "/off/analytics/durations?from=2024-03-10_00:00:00&to=2024-03-10"},
+		{"reversed range", "/off/analytics/durations?from=2024-03-10&to=2024-03-01"},
+		{"unknown kind", "/off/analytics/durations?from=2024-01-01&to=2024-12-31&kind=sudden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
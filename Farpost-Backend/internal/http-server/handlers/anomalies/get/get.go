@@ -0,0 +1,118 @@
+package anomalies
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"vlru-prsch/internal/lib/api/filter"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/date"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Response represents detected anomalies
+// @Description Обнаруженные всплески отключений
+type Response struct {
+	response.Response
+	Anomalies []models.Anomaly `json:"anomalies"`
+}
+
+type AnomaliesGiver interface {
+	GetAnomalies(blackoutType string, from string, to string, limit int, filter models.Filter) ([]models.Anomaly, error)
+}
+
+// New godoc
+// @Summary Всплески новых отключений
+// @Description Возвращает часы, в которые новых отключений одного типа было заметно больше, чем в тот же час предыдущих недель (z-score не ниже порога). Проверка выполняется по расписанию, от новых к старым
+// @Tags analytics
+// @Produce json
+// @Param type query string false "Код типа услуги из /off/service-types" example(electricity)
+// @Param kind query string false "Вид отключений: planned, emergency, unknown, без параметра все виды вместе" example(emergency)
+// @Param from query string false "Начало периода в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2019-12-01_00:00:00)
+// @Param to query string false "Конец периода в том же формате" example(2019-12-31_23:59:59)
+// @Param limit query int false "Максимальное количество записей, по умолчанию 100" example(100)
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Список всплесков"
+// @Failure 400 {object} response.Response "Неверные параметры - пример: {\"status\":\"ERROR\",\"error\":\"invalid time format\"}"
+// @Failure 500 {object} response.Response "Ошибка при получении данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get anomalies\"}"
+// @Router /off/anomalies [get]
+func New(log *slog.Logger, giver AnomaliesGiver, types models.ServiceTypes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.anomalies.get.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		blackoutType := query.Get("type")
+		if blackoutType != "" && !types.Has(blackoutType) {
+			log.Warn("invalid type", slog.String("type", blackoutType))
+			render.JSON(w, r, response.Error("invalid type, use: "+strings.Join(types.Codes(), ", ")))
+			return
+		}
+
+		from, to := "0000-01-01 00:00:00", "9999-12-31 23:59:59"
+		for _, bound := range []struct {
+			name  string
+			value *string
+		}{{"from", &from}, {"to", &to}} {
+			raw := query.Get(bound.name)
+			if raw == "" {
+				continue
+			}
+
+			parsed, err := date.ParseQueryDate(raw)
+			if err != nil {
+				log.Warn("invalid time format", slog.String(bound.name, raw), sl.Err(err))
+				render.JSON(w, r, response.Error("invalid time format"))
+				return
+			}
+			*bound.value = parsed
+		}
+
+		kindFilter, err := filter.KindFromRequest(r)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
+		limit := defaultLimit
+		if rawLimit := query.Get("limit"); rawLimit != "" {
+			parsed, err := strconv.Atoi(rawLimit)
+			if err != nil || parsed < 1 || parsed > maxLimit {
+				log.Warn("invalid limit", slog.String("limit", rawLimit))
+				render.JSON(w, r, response.Error("invalid limit, use 1 to 1000"))
+				return
+			}
+			limit = parsed
+		}
+
+		anomalies, err := giver.GetAnomalies(blackoutType, from, to, limit, kindFilter)
+		if err != nil {
+			log.Error("failed to get anomalies", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get anomalies"))
+			return
+		}
+
+		if anomalies == nil {
+			anomalies = []models.Anomaly{}
+		}
+
+		render.JSON(w, r, Response{
+			Response:  response.Ok(),
+			Anomalies: anomalies,
+		})
+	}
+}
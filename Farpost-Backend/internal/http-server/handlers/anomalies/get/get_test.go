@@ -0,0 +1,53 @@
+package anomalies_test
+
+import (
+	"net/http"
+	"testing"
+	anomaliesget "vlru-prsch/internal/http-server/handlers/anomalies/get"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	s := handlertest.Storage(t)
+
+	for _, a := range []models.Anomaly{
+		{Type: "electricity", Hour: "2024-03-10 11:00:00", Count: 5, Baseline: 1, StdDev: 0.5, ZScore: 8, DetectedAt: "2024-03-10 12:00:00"},
+		{Type: "hot_water", Hour: "2024-03-10 09:00:00", Count: 4, Baseline: 1.5, StdDev: 0.5, ZScore: 5, DetectedAt: "2024-03-10 10:00:00"},
+		{Type: "hot_water", Hour: "2024-03-03 09:00:00", Count: 3, Baseline: 1, StdDev: 0.4, ZScore: 5, DetectedAt: "2024-03-03 10:00:00"},
+		{Type: "electricity", Kind: models.KindEmergency, Hour: "2024-03-10 11:00:00", Count: 4, Baseline: 0.5, StdDev: 0.5, ZScore: 7, DetectedAt: "2024-03-10 12:00:00"},
+	} {
+		if _, err := s.SaveAnomaly(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/anomalies", anomaliesget.New(handlertest.Log, s, handlertest.ServiceTypes))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"all", "/off/anomalies"},
+		{"type", "/off/anomalies?type=hot_water"},
+		{"period", "/off/anomalies?from=2024-03-10_00:00:00&to=2024-03-10_23:59:59"},
+		{"limit", "/off/anomalies?limit=1"},
+		{"none", "/off/anomalies?type=heat"},
+		{"kind", "/off/anomalies?kind=emergency"},
+		{"unknown kind", "/off/anomalies?kind=sudden"},
+		{"unknown type", "/off/anomalies?type=steam"},
+		{"invalid time", "/off/anomalies?from=yesterday"},
+		{"invalid limit", "/off/anomalies?limit=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
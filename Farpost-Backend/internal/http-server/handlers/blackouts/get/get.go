@@ -2,12 +2,15 @@ package blackouts
 
 import (
 	"log/slog"
-	"math"
 	"net/http"
+	"slices"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/filter"
 	"vlru-prsch/internal/lib/api/response"
 	"vlru-prsch/internal/lib/date"
 	"vlru-prsch/internal/lib/logger/sl"
 	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/summary"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -31,30 +34,71 @@ type BlackoutInfo struct {
 	FractionBuildings float64 `json:"fraction_buildings" example:"25.5"`
 	// Время последнего отключения в формате "2006-01-02 15:04:05"
 	TimeLastBlackout string `json:"time_last_blackout" example:"2019-01-15 14:30:00"`
+	// Сравнение с базовым периодом, только с параметром compare
+	Comparison *Comparison `json:"comparison,omitempty"`
+}
+
+// Comparison represents the change against the baseline period
+// @Description Изменение по сравнению с базовым периодом
+type Comparison struct {
+	// Базовый период: day (день назад), week (неделя назад), year (год назад)
+	Period string `json:"period" example:"week"`
+	// Момент, с которым сравнивается curr_time
+	BaselineTime string `json:"baseline_time" example:"2019-01-08 14:30:00"`
+	// Количество затронутых зданий в базовый момент
+	BaselineCount int64 `json:"baseline_count" example:"10"`
+	// Доля затронутых зданий в базовый момент в процентах
+	BaselineFraction float64 `json:"baseline_fraction" example:"17"`
+	// Изменение количества зданий
+	DeltaCount int64 `json:"delta_count" example:"5"`
+	// Изменение доли в процентных пунктах
+	DeltaFraction float64 `json:"delta_fraction" example:"8.5"`
+	// Изменение количества зданий в процентах, null если в базовый момент отключений не было
+	PercentDelta *float64 `json:"percent_delta" example:"50"`
+	// Организации, больше всего повлиявшие на изменение
+	TopOrganizations []OrganizationChange `json:"top_organizations"`
+}
+
+// OrganizationChange represents the contribution of one organization
+// @Description Вклад организации в изменение
+type OrganizationChange struct {
+	// Организация-инициатор отключений
+	Name string `json:"name" example:"КГУП Приморский водоканал"`
+	// Затронутые здания сейчас
+	Count int64 `json:"count" example:"8"`
+	// Затронутые здания в базовый момент
+	BaselineCount int64 `json:"baseline_count" example:"3"`
+	// Изменение количества зданий
+	Delta int64 `json:"delta" example:"5"`
 }
 
 type BlackoutGiver interface {
 	GetBlackouts(currentTime string) ([]models.Blackout, error)
-	GetBuildingsCount() (int64, error)
-	GetBuildingsCountByBlackoutType(blackoutType string, currentTime string) (int64, error)
-	GetLastBlackoutTimeByType(blackoutType string, currentTime string) (string, error)
+	GetDistricts(filter models.Filter) ([]models.District, error)
+	GetBuildingsCount(filter models.Filter) (int64, error)
+	GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error)
+	GetLastBlackoutTimeByType(blackoutType string, currentTime string, filter models.Filter) (string, error)
+	GetBuildingsCountByOrganization(blackoutType string, currentTime string, filter models.Filter) (map[string]int64, error)
 }
 
 // New godoc
 // @Summary Получить информацию об отключениях
-// @Description Возвращает статистику по отключениям горячей/холодной воды, электричества и отопления
+// @Description Возвращает статистику по отключениям горячей/холодной воды, электричества и отопления. С параметром district доли считаются от зданий района. С параметром compare к каждому типу добавляется сравнение с тем же моментом день, неделю или год назад и организации, больше всего повлиявшие на изменение
 // @Tags blackouts
 // @Accept json
 // @Produce json
 // @Param curr_time query string true "Текущее время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2019-01-15T14:30:00Z или 2019-01-15_14:30:00)// @Security ApiKeyAuth
+// @Param district query string false "Административный район" example(Ленинский)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
+// @Param compare query string false "Период сравнения: day, week, year" example(week)
 // @Success 200 {object} Response "Успешный ответ"
-// @Failure 400 {object} response.Response "Неверный формат времени или отсутствует параметр curr_time"
+// @Failure 400 {object} response.Response "Неверный формат времени, отсутствует параметр curr_time или неизвестный район"
 // @Failure 500 {object} response.Response "Ошибка при получении данных"
 // @Router /off/blackouts [get]
 // @Response 400 {object} response.Response "Пример: {\"status\":\"ERROR\",\"error\":\"curr_time parameter is required\"}"
 // @Response 400 {object} response.Response "Пример: {\"status\":\"ERROR\",\"error\":\"invalid time format\"}"
 // @Response 500 {object} response.Response "Пример: {\"status\":\"ERROR\",\"error\":\"failed to get buildings data\"}"
-func New(log *slog.Logger, giver BlackoutGiver) http.HandlerFunc {
+func New(log *slog.Logger, giver BlackoutGiver, types models.ServiceTypes) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.blackout.get.New"
 
@@ -70,50 +114,82 @@ func New(log *slog.Logger, giver BlackoutGiver) http.HandlerFunc {
 			return
 		}
 
-		currTimeParse, err := date.ParseQueryDate(currTime)
+		currTimeParse, err := date.ParseQueryDateIn(currTime, city.Location(r.Context()))
 		if err != nil {
 			log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
 			render.JSON(w, r, response.Error("invalid time format"))
 			return
 		}
 
-		totalBuildings, err := giver.GetBuildingsCount()
+		compare := r.URL.Query().Get("compare")
+		if compare != "" && !slices.Contains(summary.Periods, compare) {
+			log.Warn("invalid compare period", slog.String("compare", compare))
+			render.JSON(w, r, response.Error("invalid compare, use: day, week, year"))
+			return
+		}
+
+		areaFilter, err := filter.FromRequest(r, giver)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
+		summaries, err := summary.Collect(log, giver, types, currTimeParse, areaFilter)
 		if err != nil {
 			log.Error("failed to get total buildings count", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to get buildings data"))
 			return
 		}
 
-		blackoutTypes := []string{"hot_water", "cold_water", "electricity", "heat"}
-		
-		var blackoutsInfo []BlackoutInfo
-
-		for _, blackoutType := range blackoutTypes {
-			affectedBuildings, err := giver.GetBuildingsCountByBlackoutType(blackoutType, currTimeParse)
+		var changes map[string]summary.Change
+		var baselineTime string
+		if compare != "" {
+			baselineTime, err = summary.BaselineTime(currTimeParse, compare)
 			if err != nil {
-				log.Error("failed to get buildings count for type", 
-				slog.String("type", blackoutType), slog.Any("error", err))
-				continue
+				log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
+				render.JSON(w, r, response.Error("invalid time format"))
+				return
 			}
 
-			lastBlackoutTime, err := giver.GetLastBlackoutTimeByType(blackoutType, currTimeParse)
+			baseline, err := summary.Collect(log, giver, types, baselineTime, areaFilter)
 			if err != nil {
-				log.Error("failed to get last blackout time", 
-				slog.String("type", blackoutType), slog.Any("error", err))
-				lastBlackoutTime = "unknown"
+				log.Error("failed to get baseline buildings count", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to get buildings data"))
+				return
 			}
 
-			var fraction float64
-			if totalBuildings > 0 {
-				fraction = float64(affectedBuildings) / float64(totalBuildings)
+			changes, err = summary.Compare(giver, summaries, baseline, currTimeParse, baselineTime, areaFilter)
+			if err != nil {
+				log.Error("failed to compare periods", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to get buildings data"))
+				return
 			}
-			percentage := math.Round(fraction * 100 * 100) / 100
+		}
 
+		var blackoutsInfo []BlackoutInfo
+
+		for _, typeSummary := range summaries {
 			info := BlackoutInfo{
-				Type:              blackoutType,
-				CountBuildings:    affectedBuildings,
-				FractionBuildings: percentage,
-				TimeLastBlackout:  lastBlackoutTime,
+				Type:              typeSummary.Type,
+				CountBuildings:    typeSummary.CountBuildings,
+				FractionBuildings: typeSummary.FractionBuildings,
+				TimeLastBlackout:  typeSummary.TimeLastBlackout,
+			}
+
+			if change, ok := changes[typeSummary.Type]; ok {
+				info.Comparison = &Comparison{
+					Period:           compare,
+					BaselineTime:     baselineTime,
+					BaselineCount:    change.BaselineCount,
+					BaselineFraction: change.BaselineFraction,
+					DeltaCount:       change.DeltaCount,
+					DeltaFraction:    change.DeltaFraction,
+					PercentDelta:     change.PercentDelta,
+					TopOrganizations: make([]OrganizationChange, 0, len(change.Organizations)),
+				}
+
+				for _, organization := range change.Organizations {
+					info.Comparison.TopOrganizations = append(info.Comparison.TopOrganizations, OrganizationChange(organization))
+				}
 			}
 
 			blackoutsInfo = append(blackoutsInfo, info)
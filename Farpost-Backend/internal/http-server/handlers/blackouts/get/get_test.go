@@ -0,0 +1,41 @@
+package blackouts_test
+
+import (
+	"net/http"
+	"testing"
+	blackoutsget "vlru-prsch/internal/http-server/handlers/blackouts/get"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/blackouts", blackoutsget.New(handlertest.Log, handlertest.Storage(t), handlertest.ServiceTypes))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"now", "/off/blackouts?curr_time=2024-03-10_12:00:00"},
+		{"rfc3339 in the city time zone", "/off/blackouts?curr_time=2024-03-10T02:00:00Z"},
+		{"open ended only", "/off/blackouts?curr_time=2025-01-01_00:00:00"},
+		{"other city", "/khabarovsk/off/blackouts?curr_time=2024-03-10_12:00:00"},
+		{"compare with the day before", "/off/blackouts?curr_time=2024-03-10_12:00:00&compare=day"},
+		{"kind", "/off/blackouts?curr_time=2024-03-10_12:00:00&kind=unknown"},
+		{"missing time", "/off/blackouts"},
+		{"invalid time", "/off/blackouts?curr_time=yesterday"},
+		{"unknown compare", "/off/blackouts?curr_time=2024-03-10_12:00:00&compare=month"},
+		{"unknown district", "/off/blackouts?curr_time=2024-03-10_12:00:00&district=Нет"},
+		{"unknown kind", "/off/blackouts?curr_time=2024-03-10_12:00:00&kind=sudden"},
+		{"unknown city", "/moscow/off/blackouts?curr_time=2024-03-10_12:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
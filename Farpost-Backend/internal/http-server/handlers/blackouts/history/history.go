@@ -0,0 +1,112 @@
+package history
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/lifecycle"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents the change history of a blackout
+// @Description История изменений отключения
+type Response struct {
+	response.Response
+	// Идентификатор отключения
+	BlackoutID string `json:"blackout_id" example:"b1f4"`
+	// Текущее состояние: announced, active, extended, resolved, cancelled
+	State string `json:"state" example:"extended"`
+	// Ревизии от старых к новым
+	History []RevisionInfo `json:"history"`
+}
+
+// RevisionInfo represents a revision with the fields it changed
+// @Description Ревизия отключения и измененные в ней поля
+type RevisionInfo struct {
+	models.Revision
+	// Изменения по сравнению с предыдущей ревизией, пусто для первой
+	Changes []models.RevisionChange `json:"changes"`
+}
+
+type HistoryGiver interface {
+	GetRevisions(blackoutID string, filter models.Filter) ([]models.Revision, error)
+	GetBlackout(id string, filter models.Filter) (models.Blackout, error)
+}
+
+// New godoc
+// @Summary История изменений отключения
+// @Description Возвращает все ревизии отключения: перенос начала или окончания, смену описания и состояния (announced, active, extended, resolved, cancelled), с временем изменения и автором. Ревизии только добавляются и никогда не меняются
+// @Tags blackouts
+// @Produce json
+// @Param id path string true "Идентификатор отключения"
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "История отключения"
+// @Failure 404 {object} response.Response "Отключение не найдено - пример: {\"status\":\"ERROR\",\"error\":\"blackout not found\"}"
+// @Failure 500 {object} response.Response "Ошибка получения - пример: {\"status\":\"ERROR\",\"error\":\"failed to get history\"}"
+// @Router /off/blackouts/{id}/history [get]
+func New(log *slog.Logger, giver HistoryGiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.blackouts.history.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id := chi.URLParam(r, "id")
+		// a blackout of another city is not found
+		cityFilter := models.Filter{City: city.FromContext(r.Context()).Code}
+
+		revisions, err := giver.GetRevisions(id, cityFilter)
+		if err != nil {
+			log.Error("failed to get revisions", slog.String("id", id), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get history"))
+			return
+		}
+
+		if len(revisions) == 0 {
+			// the blackout may be newer than the last run of the tracker
+			_, err := giver.GetBlackout(id, cityFilter)
+			if errors.Is(err, storage.ErrBlackoutNotFound) {
+				log.Info("blackout not found", slog.String("id", id))
+				render.JSON(w, r, response.Error("blackout not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get blackout", slog.String("id", id), sl.Err(err))
+				render.JSON(w, r, response.Error("failed to get history"))
+				return
+			}
+		}
+
+		history := make([]RevisionInfo, 0, len(revisions))
+		var state string
+		for i, revision := range revisions {
+			var prev *models.Revision
+			if i > 0 {
+				prev = &revisions[i-1]
+			}
+
+			history = append(history, RevisionInfo{
+				Revision: revision,
+				Changes:  lifecycle.Changes(prev, revision),
+			})
+			state = revision.State
+		}
+
+		render.JSON(w, r, Response{
+			Response:   response.Ok(),
+			BlackoutID: id,
+			State:      state,
+			History:    history,
+		})
+	}
+}
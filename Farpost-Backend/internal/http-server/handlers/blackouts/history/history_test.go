@@ -0,0 +1,45 @@
+package history_test
+
+import (
+	"net/http"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/blackouts/history"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	s := handlertest.Storage(t)
+
+	err := s.SaveRevisions([]models.Revision{
+		{BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 16:00:00", Description: "Плановые работы", State: "announced", Actor: "source", ChangedAt: "2024-03-09 12:00:00"},
+		{BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", Description: "Плановые работы", State: "extended", Actor: "source", ChangedAt: "2024-03-10 15:00:00"},
+		{BlackoutID: "b6", StartDate: "2024-03-09 22:00:00", Description: "Порыв на сети", State: "active", Actor: "source", ChangedAt: "2024-03-09 22:05:00"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/blackouts/{id}/history", history.New(handlertest.Log, s))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"revisions with changes", "/off/blackouts/b1/history"},
+		{"open ended", "/off/blackouts/b6/history"},
+		{"no revisions", "/off/blackouts/b2/history"},
+		{"unknown blackout", "/off/blackouts/b404/history"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
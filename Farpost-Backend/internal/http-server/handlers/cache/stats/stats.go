@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"log/slog"
+	"net/http"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/render"
+)
+
+// Response represents the counters of the response cache
+// @Description Статистика кэша ответов
+type Response struct {
+	response.Response
+	Cache models.CacheStats `json:"cache"`
+}
+
+type StatsGiver interface {
+	Stats() models.CacheStats
+}
+
+// New godoc
+// @Summary Статистика кэша ответов
+// @Description Возвращает число ответов в кэше, попадания и промахи всего и по endpoints, число вытеснений и сбросов после изменения данных. Кэшированные ответы отмечаются заголовком X-Cache: HIT, остальные - X-Cache: MISS
+// @Tags cache
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Статистика кэша"
+// @Failure 401 {object} response.Response "Нет доступа - пример: {\"status\":\"ERROR\",\"error\":\"unauthorized\"}"
+// @Router /off/cache/stats [get]
+func New(log *slog.Logger, giver StatsGiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{
+			Response: response.Ok(),
+			Cache:    giver.Stats(),
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package stats_test
+
+import (
+	"net/http"
+	"testing"
+	cachestats "vlru-prsch/internal/http-server/handlers/cache/stats"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type giver models.CacheStats
+
+func (g giver) Stats() models.CacheStats {
+	return models.CacheStats(g)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats models.CacheStats
+	}{
+		{"empty", models.CacheStats{Version: "1710000000-4096-1", MaxEntries: 1000}},
+		{"endpoints", models.CacheStats{
+			Version:       "1710000000-4096-1",
+			Entries:       2,
+			MaxEntries:    1000,
+			Hits:          3,
+			Misses:        2,
+			Invalidations: 1,
+			Endpoints: []models.EndpointCacheStats{
+				{Endpoint: "/blackouts", Hits: 3, Misses: 1},
+				{Endpoint: "/orgs", Misses: 1},
+			},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlertest.Router(func(r chi.Router) {
+				r.Get("/cache/stats", cachestats.New(handlertest.Log, giver(tt.stats)))
+			})
+
+			w := handlertest.Do(t, h, http.MethodGet, "/off/cache/stats", "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
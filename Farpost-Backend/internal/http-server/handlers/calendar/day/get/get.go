@@ -3,6 +3,7 @@ package calendar
 import (
 	"log/slog"
 	"net/http"
+	"vlru-prsch/internal/lib/api/filter"
 	"vlru-prsch/internal/lib/api/response"
 	"vlru-prsch/internal/lib/date"
 	"vlru-prsch/internal/lib/logger/sl"
@@ -30,10 +31,13 @@ type InfoOffs struct {
     EndOff string `json:"end_off" example:"2019-01-15 18:00:00"`
     // Количество затронутых адресов/зданий
     AmountAddresses int64 `json:"amount_addresses" example:"25"`
+    // Вид отключения: planned (плановое), emergency (аварийное), unknown (не определен)
+    Kind string `json:"kind" example:"planned"`
 }
 
 type DayInfoGiver interface {
-    GetBlackoutsWithBuildingsCount(targetDate string) ([]models.BlackoutInfo, error)
+    GetDistricts(filter models.Filter) ([]models.District, error)
+    GetBlackoutsWithBuildingsCount(targetDate string, filter models.Filter) ([]models.BlackoutInfo, error)
 }
 
 // New godoc
@@ -43,9 +47,12 @@ type DayInfoGiver interface {
 // @Accept json
 // @Produce json
 // @Param date query string true "Целевая дата в формате YYYY-MM-DD" example(2019-01-15)
+// @Param district query string false "Административный район: считаются только его адреса" example(Ленинский)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
 // @Security ApiKeyAuth
 // @Success 200 {object} Response "Успешный ответ с детальной информацией об отключениях"
 // @Failure 400 {object} response.Response "Отсутствует параметр date - пример: {\"status\":\"ERROR\",\"error\":\"date parameter is required\"}"
+// @Failure 400 {object} response.Response "Неизвестный район - пример: {\"status\":\"ERROR\",\"error\":\"unknown district\"}"
 // @Failure 500 {object} response.Response "Ошибка при получении данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get blackouts information\"}"
 // @Router /off/calendar/day [get]
 func New(log *slog.Logger, giver DayInfoGiver) http.HandlerFunc {
@@ -72,7 +79,12 @@ func New(log *slog.Logger, giver DayInfoGiver) http.HandlerFunc {
             return
         }   
 
-        blackoutsInfo, err := giver.GetBlackoutsWithBuildingsCount(targetDate)
+        areaFilter, err := filter.FromRequest(r, giver)
+        if filter.Render(w, r, log, err) {
+            return
+        }
+
+        blackoutsInfo, err := giver.GetBlackoutsWithBuildingsCount(targetDate, areaFilter)
         if err != nil {
             log.Error("failed to get blackouts info for date", 
                 slog.String("date", targetDate), 
@@ -88,6 +100,7 @@ func New(log *slog.Logger, giver DayInfoGiver) http.HandlerFunc {
                 StartOff:        blackout.StartDate,
                 EndOff:          blackout.EndDate,
                 AmountAddresses: blackout.BuildingCount,
+                Kind:            blackout.Kind,
             }
             infoOffs = append(infoOffs, info)
         }
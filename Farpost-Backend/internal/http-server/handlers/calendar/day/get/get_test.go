@@ -0,0 +1,35 @@
+package calendar_test
+
+import (
+	"net/http"
+	"testing"
+	dayget "vlru-prsch/internal/http-server/handlers/calendar/day/get"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/calendar/day", dayget.New(handlertest.Log, handlertest.Storage(t)))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"busy day", "/off/calendar/day?date=2024-03-10"},
+		{"open ended only", "/off/calendar/day?date=2024-06-01"},
+		{"other city", "/khabarovsk/off/calendar/day?date=2024-03-10"},
+		{"missing date", "/off/calendar/day"},
+		{"invalid date", "/off/calendar/day?date=10.03.2024"},
+		{"unknown district", "/off/calendar/day?date=2024-03-10&district=Нет"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
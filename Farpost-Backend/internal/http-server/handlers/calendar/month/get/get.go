@@ -1,8 +1,13 @@
 package calendar
 
 import (
+    "errors"
     "log/slog"
     "net/http"
+    "time"
+    "vlru-prsch/internal/forecast"
+    "vlru-prsch/internal/http-server/middleware/city"
+    "vlru-prsch/internal/lib/api/filter"
     "vlru-prsch/internal/lib/api/response"
     "vlru-prsch/internal/lib/date"
     "vlru-prsch/internal/lib/logger/sl"
@@ -26,26 +31,42 @@ type DateInfo struct {
   	Date string `json:"date" example:"2019-01-15"`
   	// Список типов отключений в эту дату: hot_water, cold_water, electricity, heat
   	Services []string `json:"services" example:"hot_water,cold_water"`
+  	// Виды отключений в эту дату: planned, emergency, unknown
+  	Kinds []string `json:"kinds" example:"planned,emergency"`
+  	// Вероятные отключения для будущих дней, только с параметром forecast=true
+  	Likely []forecast.Likely `json:"likely,omitempty"`
 }
 
 type DatesGiver interface {
-    GetBlackouts(currentTime string) ([]models.Blackout, error)
+    GetDistricts(filter models.Filter) ([]models.District, error)
+    GetFilteredBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error)
+}
+
+type Forecaster interface {
+    Likely(day time.Time, street string) ([]forecast.Likely, error)
 }
 
 // New godoc
 // @Summary Получить данные для календаря отключений за месяц
-// @Description Возвращает информацию об отключениях по дням указанного месяца для отображения в календаре
+// @Description Возвращает информацию об отключениях по дням указанного месяца для отображения в календаре. С forecast=true для дней после curr_time добавляется слой вероятных отключений по сезонной модели, обученной на истории
 // @Tags calendar
 // @Accept json
 // @Produce json
 // @Param month query string true "Первый месяц в формате YYYY-MM" example(2019-01)
+// @Param forecast query bool false "Добавить слой вероятных отключений" example(true)
+// @Param street query string false "Улица для прогноза, по умолчанию наиболее вероятные улицы города" example(Карбышева ул.)
+// @Param curr_time query string false "Текущее время, прогноз строится для следующих дней" example(2019-12-15_12:00:00)
+// @Param district query string false "Административный район: учитываются отключения, затронувшие его здания" example(Ленинский)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
 // @Security ApiKeyAuth
 // @Success 200 {object} Response "Успешный ответ с данными за месяц"
 // @Failure 400 {object} response.Response "Отсутствует параметр month - пример: {\"status\":\"ERROR\",\"error\":\"month parameter is required\"}"
 // @Failure 400 {object} response.Response "Неверный формат месяца - пример: {\"status\":\"ERROR\",\"error\":\"failed to process month dates\"}"
+// @Failure 400 {object} response.Response "Неизвестный район - пример: {\"status\":\"ERROR\",\"error\":\"unknown district\"}"
 // @Failure 500 {object} response.Response "Ошибка при получении данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get blackouts data\"}"
+// @Failure 503 {object} response.Response "Модель прогноза не загружена - пример: {\"status\":\"ERROR\",\"error\":\"forecast is not available\"}"
 // @Router /off/calendar [get]
-func New(log *slog.Logger, giver DatesGiver) http.HandlerFunc {
+func New(log *slog.Logger, giver DatesGiver, types models.ServiceTypes, forecaster Forecaster) http.HandlerFunc {
   	return func(w http.ResponseWriter, r *http.Request) {
     	const op = "handlers.calendar.month.get.New"
 
@@ -68,10 +89,27 @@ func New(log *slog.Logger, giver DatesGiver) http.HandlerFunc {
       		return
     	}
 
+    	areaFilter, err := filter.FromRequest(r, giver)
+    	if filter.Render(w, r, log, err) {
+      		return
+    	}
+
+    	withForecast := r.URL.Query().Get("forecast") == "true"
+    	street := r.URL.Query().Get("street")
+
+    	currTime := r.URL.Query().Get("curr_time")
+    	currTimeParse, err := date.ParseQueryDateIn(currTime, city.Location(r.Context()))
+    	if err != nil {
+      		log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
+      		render.JSON(w, r, response.Error("invalid time format"))
+      		return
+    	}
+    	today := currTimeParse[:10]
+
     	var dates []DateInfo
 
     	for _, dateStr := range monthDates {
-     		blackouts, err := giver.GetBlackouts(dateStr)
+     		blackouts, err := giver.GetFilteredBlackouts(dateStr, areaFilter)
       		if err != nil {
         		log.Error("failed to get blackouts for date", 
           		slog.String("date", dateStr), 
@@ -80,19 +118,50 @@ func New(log *slog.Logger, giver DatesGiver) http.HandlerFunc {
       		}
 
       		serviceTypes := make(map[string]bool)
+      		kinds := make(map[string]bool)
       		for _, blackout := range blackouts {
         		serviceTypes[blackout.Type] = true
+        		kinds[blackout.Kind] = true
       		}
 
       		var services []string
-      		for serviceType := range serviceTypes {
-        		services = append(services, serviceType)
+      		for _, serviceType := range types.Codes() {
+        		if serviceTypes[serviceType] {
+          			services = append(services, serviceType)
+        		}
+      		}
+
+      		var dayKinds []string
+      		for _, kind := range models.BlackoutKinds {
+        		if kinds[kind] {
+          			dayKinds = append(dayKinds, kind)
+        		}
       		}
 
       		dateInfo := DateInfo{
         		Date:     dateStr,
         		Services: services,
+        		Kinds:    dayKinds,
+      		}
+
+      		if withForecast && dateStr > today {
+        		day, _ := time.Parse("2006-01-02", dateStr)
+
+        		likely, err := forecaster.Likely(day, street)
+        		if errors.Is(err, forecast.ErrNoModel) {
+          			log.Warn("forecast requested without a model")
+          			render.JSON(w, r, response.Error("forecast is not available"))
+          			return
+        		}
+        		if err != nil {
+          			log.Error("failed to forecast", slog.String("date", dateStr), sl.Err(err))
+          			render.JSON(w, r, response.Error("failed to get forecast"))
+          			return
+        		}
+
+        		dateInfo.Likely = likely
       		}
+
       		dates = append(dates, dateInfo)
     	}
 
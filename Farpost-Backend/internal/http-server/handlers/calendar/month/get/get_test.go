@@ -0,0 +1,60 @@
+package calendar_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"vlru-prsch/internal/forecast"
+	monthget "vlru-prsch/internal/http-server/handlers/calendar/month/get"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// forecaster predicts hot water on every Monday, on the asked street or on Карбышева ул.
+type forecaster struct{}
+
+func (forecaster) Likely(day time.Time, street string) ([]forecast.Likely, error) {
+	if day.Weekday() != time.Monday {
+		return nil, nil
+	}
+	if street == "" {
+		street = "Карбышева ул."
+	}
+
+	return []forecast.Likely{{
+		Service:     "hot_water",
+		Probability: 0.5,
+		Streets:     []forecast.StreetProbability{{Street: street, Probability: 0.5}},
+	}}, nil
+}
+
+func TestNew(t *testing.T) {
+	s := handlertest.Storage(t)
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/calendar", monthget.New(handlertest.Log, s, handlertest.ServiceTypes, forecaster{}))
+		r.Get("/untrained/calendar", monthget.New(handlertest.Log, s, handlertest.ServiceTypes, forecast.NewService(0.1, 3)))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"month", "/off/calendar?month=2024-03&curr_time=2024-03-10_12:00:00"},
+		{"other city", "/khabarovsk/off/calendar?month=2024-03&curr_time=2024-03-10_12:00:00"},
+		{"forecast after the current day", "/off/calendar?month=2024-03&curr_time=2024-03-20_12:00:00&forecast=true"},
+		{"forecast for a street", "/off/calendar?month=2024-03&curr_time=2024-03-20_12:00:00&forecast=true&street=Светланская+ул."},
+		{"forecast without a model", "/off/untrained/calendar?month=2024-03&curr_time=2024-03-20_12:00:00&forecast=true"},
+		{"missing month", "/off/calendar?curr_time=2024-03-10_12:00:00"},
+		{"invalid month", "/off/calendar?month=03.2024&curr_time=2024-03-10_12:00:00"},
+		{"unknown kind", "/off/calendar?month=2024-03&curr_time=2024-03-10_12:00:00&kind=sudden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
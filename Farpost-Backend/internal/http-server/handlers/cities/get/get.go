@@ -0,0 +1,42 @@
+package cities
+
+import (
+	"log/slog"
+	"net/http"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents the served cities
+// @Description Обслуживаемые города
+type Response struct {
+	response.Response
+	Cities []models.City `json:"cities"`
+}
+
+// New godoc
+// @Summary Города
+// @Description Возвращает обслуживаемые города. Данные города доступны по адресам /{city}/off/... или по /off/... с заголовком X-City, без них - по городу по умолчанию
+// @Tags cities
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Список городов"
+// @Router /off/cities [get]
+func New(log *slog.Logger, cities []models.City) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.cities.get.New"
+
+		log.Debug("cities requested",
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.Ok(),
+			Cities:   cities,
+		})
+	}
+}
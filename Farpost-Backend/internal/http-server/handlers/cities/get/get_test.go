@@ -0,0 +1,19 @@
+package cities_test
+
+import (
+	"net/http"
+	"testing"
+	citiesget "vlru-prsch/internal/http-server/handlers/cities/get"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/cities", citiesget.New(handlertest.Log, handlertest.Cities))
+	})
+
+	w := handlertest.Do(t, h, http.MethodGet, "/off/cities", "")
+	handlertest.Golden(t, w.Body.Bytes())
+}
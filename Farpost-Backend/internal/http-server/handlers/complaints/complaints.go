@@ -3,6 +3,8 @@ package complaints
 import (
 	"log/slog"
 	"net/http"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/filter"
 	"vlru-prsch/internal/lib/api/response"
 	"vlru-prsch/internal/lib/date"
 	"vlru-prsch/internal/lib/logger/sl"
@@ -20,10 +22,11 @@ type Response struct {
 }
 
 type ComplaintsGiver interface {
-	GetComplaintsLastHour(currTimeParse string) ([]models.ComplaintData, error)
-	GetComplaintsLastDay(currTimeParse string) ([]models.ComplaintData, error)
-	GetComplaintsLastWeek(currTimeParse string) ([]models.ComplaintData, error)
-	GetComplaintsLastMonth(currTimeParse string) ([]models.ComplaintData, error)
+	GetDistricts(filter models.Filter) ([]models.District, error)
+	GetComplaintsLastHour(currTimeParse string, filter models.Filter) ([]models.ComplaintData, error)
+	GetComplaintsLastDay(currTimeParse string, filter models.Filter) ([]models.ComplaintData, error)
+	GetComplaintsLastWeek(currTimeParse string, filter models.Filter) ([]models.ComplaintData, error)
+	GetComplaintsLastMonth(currTimeParse string, filter models.Filter) ([]models.ComplaintData, error)
 }
 
 const (
@@ -35,25 +38,28 @@ const (
 
 // New godoc
 // @Summary Получить данные жалоб для графиков
-// @Description Возвращает статистику жалоб за указанный период для построения графиков и аналитики
+// @Description Возвращает статистику жалоб за указанный период для построения графиков и аналитики. Значения по типам услуг лежат в counts с ключами из реестра /off/service-types, поля hot, cold, electricity и heating возвращаются в режиме совместимости service_types.legacy_fields
 // @Tags complaints
 // @Accept json
 // @Produce json
 // @Param period query string true "Период для агрегации данных: hour (последний час), day (последние 24 часа), week (последние 7 дней), month (последние 30 дней)" example(day)
 // @Param curr_time query string true "Текущее время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2019-01-15_14:30:00 или 2019-01-15T14:30:00Z)
+// @Param district query string false "Административный район: учитываются отключения, затронувшие его здания" example(Ленинский)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
 // @Security ApiKeyAuth
 // @Success 200 {object} Response "Успешный ответ с данными жалоб по типам отключений"
 // @Failure 400 {object} response.Response "Отсутствует параметр period - пример: {\"status\":\"ERROR\",\"error\":\"period parameter is required\"}"
 // @Failure 400 {object} response.Response "Отсутствует параметр curr_time - пример: {\"status\":\"ERROR\",\"error\":\"curr_time parameter is required\"}"
 // @Failure 400 {object} response.Response "Неверный формат времени - пример: {\"status\":\"ERROR\",\"error\":\"invalid time format\"}"
 // @Failure 400 {object} response.Response "Неверный период - пример: {\"status\":\"ERROR\",\"error\":\"invalid period, use: hour, day, week, month\"}"
+// @Failure 400 {object} response.Response "Неизвестный район - пример: {\"status\":\"ERROR\",\"error\":\"unknown district\"}"
 // @Failure 500 {object} response.Response "Ошибка при получении данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get complaints data\"}"
 // @Router /off/complaints [get]
 // @Example period=hour "Получить данные за последний час"
 // @Example period=day "Получить данные за последние 24 часа" 
 // @Example period=week "Получить данные за последние 7 дней"
 // @Example period=month "Получить данные за последние 30 дней"
-func New(log *slog.Logger, giver ComplaintsGiver) http.HandlerFunc {
+func New(log *slog.Logger, giver ComplaintsGiver, legacyFields bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.complaints.New"
 
@@ -76,7 +82,7 @@ func New(log *slog.Logger, giver ComplaintsGiver) http.HandlerFunc {
 			return
 		}
 
-		currTimeParse, err := date.ParseQueryDate(currTime)
+		currTimeParse, err := date.ParseQueryDateIn(currTime, city.Location(r.Context()))
 		if err != nil {
 			log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
 			render.JSON(w, r, response.Error("invalid time format"))
@@ -96,16 +102,21 @@ func New(log *slog.Logger, giver ComplaintsGiver) http.HandlerFunc {
 			return
 		}
 
+		areaFilter, err := filter.FromRequest(r, giver)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
 		var complaints []models.ComplaintData
 		switch period {
 		case PeriodHour:
-			complaints, err = giver.GetComplaintsLastHour(currTimeParse)
+			complaints, err = giver.GetComplaintsLastHour(currTimeParse, areaFilter)
 		case PeriodDay:
-			complaints, err = giver.GetComplaintsLastDay(currTimeParse)
+			complaints, err = giver.GetComplaintsLastDay(currTimeParse, areaFilter)
 		case PeriodWeek:
-			complaints, err = giver.GetComplaintsLastWeek(currTimeParse)
+			complaints, err = giver.GetComplaintsLastWeek(currTimeParse, areaFilter)
 		case PeriodMonth:
-			complaints, err = giver.GetComplaintsLastMonth(currTimeParse)
+			complaints, err = giver.GetComplaintsLastMonth(currTimeParse, areaFilter)
 		}
 
 		if err != nil {
@@ -117,6 +128,12 @@ func New(log *slog.Logger, giver ComplaintsGiver) http.HandlerFunc {
 			return
 		}
 
+		if legacyFields {
+			for i := range complaints {
+				complaints[i] = complaints[i].WithLegacyFields()
+			}
+		}
+
 		render.JSON(w, r, Response{
 			Response:   response.Ok(),
 			Complaints: complaints,
@@ -0,0 +1,103 @@
+package complaints_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/complaints"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type giver struct{}
+
+func (giver) GetDistricts(models.Filter) ([]models.District, error) {
+	return nil, nil
+}
+
+func (giver) GetComplaintsLastHour(string, models.Filter) ([]models.ComplaintData, error) {
+	return nil, nil
+}
+
+func (giver) GetComplaintsLastDay(string, models.Filter) ([]models.ComplaintData, error) {
+	data := models.NewComplaintData("11:00", append(models.DefaultServiceTypes().Codes(), "gas"))
+	data.Counts["hot_water"] = 2
+	data.Counts["gas"] = 1
+	return []models.ComplaintData{*data}, nil
+}
+
+func (giver) GetComplaintsLastWeek(string, models.Filter) ([]models.ComplaintData, error) {
+	return nil, nil
+}
+
+func (giver) GetComplaintsLastMonth(string, models.Filter) ([]models.ComplaintData, error) {
+	return nil, nil
+}
+
+func TestNewLegacyFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		legacyFields bool
+		want         string
+	}{
+		{"counts only", false, `{"time":"11:00","counts":{"cold_water":0,"electricity":0,"gas":1,"heat":0,"hot_water":2}}`},
+		{"legacy fields", true, `{"time":"11:00","counts":{"cold_water":0,"electricity":0,"gas":1,"heat":0,"hot_water":2},"hot":2,"cold":0,"electricity":0,"heating":0}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			complaints.New(slog.New(slog.DiscardHandler), giver{}, tt.legacyFields).
+				ServeHTTP(w, httptest.NewRequest("GET", "/off/complaints?period=day&curr_time=2024-03-10_12:00:00", nil))
+
+			var got struct {
+				Error      string            `json:"error"`
+				Complaints []json.RawMessage `json:"complaints"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != "" || len(got.Complaints) != 1 {
+				t.Fatalf("got %s", w.Body.String())
+			}
+			if string(got.Complaints[0]) != tt.want {
+				t.Errorf("got %s, want %s", got.Complaints[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	s := handlertest.Storage(t)
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/complaints", complaints.New(handlertest.Log, s, false))
+		r.Get("/legacy/complaints", complaints.New(handlertest.Log, s, true))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"hour", "/off/complaints?period=hour&curr_time=2024-03-10_12:00:00"},
+		{"day", "/off/complaints?period=day&curr_time=2024-03-10_12:00:00"},
+		{"week", "/off/complaints?period=week&curr_time=2024-03-10_12:00:00"},
+		{"month", "/off/complaints?period=month&curr_time=2024-03-10_12:00:00"},
+		{"other city", "/khabarovsk/off/complaints?period=day&curr_time=2024-03-10_12:00:00"},
+		{"legacy fields", "/off/legacy/complaints?period=day&curr_time=2024-03-10_12:00:00"},
+		{"unknown period", "/off/complaints?period=year&curr_time=2024-03-10_12:00:00"},
+		{"missing time", "/off/complaints?period=day"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
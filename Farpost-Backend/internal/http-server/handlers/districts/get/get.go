@@ -0,0 +1,142 @@
+package districts
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"vlru-prsch/internal/lib/api/filter"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/date"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/summary"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents the district ranking
+// @Description Рейтинг районов по доле затронутых отключениями зданий
+type Response struct {
+	response.Response
+	Districts []DistrictInfo `json:"districts"`
+}
+
+// DistrictInfo represents outage statistics of a district
+// @Description Статистика отключений по району
+type DistrictInfo struct {
+	// Название района
+	Name string `json:"name" example:"Ленинский"`
+	// Количество зданий в районе
+	TotalBuildings int64 `json:"total_buildings" example:"1200"`
+	// Количество зданий хотя бы с одним отключением
+	CountBuildings int64 `json:"count_buildings" example:"150"`
+	// Доля затронутых зданий в процентах
+	FractionBuildings float64 `json:"fraction_buildings" example:"12.5"`
+	// Статистика по типам отключений
+	Types []TypeInfo `json:"types"`
+}
+
+// TypeInfo represents the share of a district affected by one blackout type
+// @Description Доля зданий района, затронутых отключением одного типа
+type TypeInfo struct {
+	// Тип отключения: hot_water, cold_water, electricity, heat
+	Type string `json:"type" example:"hot_water"`
+	// Количество затронутых зданий
+	CountBuildings int64 `json:"count_buildings" example:"90"`
+	// Доля затронутых зданий в процентах
+	FractionBuildings float64 `json:"fraction_buildings" example:"7.5"`
+}
+
+type DistrictsGiver interface {
+	GetDistrictStats(currentTime string, filter models.Filter) ([]models.DistrictStats, error)
+}
+
+// New godoc
+// @Summary Рейтинг районов по отключениям
+// @Description Возвращает районы, отсортированные по доле зданий, затронутых отключениями в указанное время, с разбивкой по типам услуг. С параметром kind учитываются только отключения этого вида
+// @Tags blackouts
+// @Produce json
+// @Param curr_time query string true "Текущее время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2019-01-15_14:30:00)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Рейтинг районов"
+// @Failure 400 {object} response.Response "Неверный формат времени - пример: {\"status\":\"ERROR\",\"error\":\"invalid time format\"}"
+// @Failure 500 {object} response.Response "Ошибка при получении данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get districts data\"}"
+// @Router /off/districts [get]
+func New(log *slog.Logger, giver DistrictsGiver, types models.ServiceTypes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.districts.get.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		currTime := r.URL.Query().Get("curr_time")
+		if currTime == "" {
+			log.Warn("curr_time parameter is empty")
+			render.JSON(w, r, response.Error("curr_time parameter is required"))
+			return
+		}
+
+		currTimeParse, err := date.ParseQueryDate(currTime)
+		if err != nil {
+			log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
+			render.JSON(w, r, response.Error("invalid time format"))
+			return
+		}
+
+		kindFilter, err := filter.KindFromRequest(r)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
+		stats, err := giver.GetDistrictStats(currTimeParse, kindFilter)
+		if err != nil {
+			log.Error("failed to get district stats", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get districts data"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:  response.Ok(),
+			Districts: Rank(stats, types),
+		})
+	}
+}
+
+// Rank orders districts by the affected share, the largest first
+func Rank(stats []models.DistrictStats, types models.ServiceTypes) []DistrictInfo {
+	districts := make([]DistrictInfo, 0, len(stats))
+
+	for _, district := range stats {
+		info := DistrictInfo{
+			Name:              district.Name,
+			TotalBuildings:    district.TotalBuildings,
+			CountBuildings:    district.AffectedBuildings,
+			FractionBuildings: summary.Percentage(district.AffectedBuildings, district.TotalBuildings),
+			Types:             make([]TypeInfo, 0, len(types)),
+		}
+
+		for _, blackoutType := range types.Codes() {
+			count := district.AffectedByType[blackoutType]
+			info.Types = append(info.Types, TypeInfo{
+				Type:              blackoutType,
+				CountBuildings:    count,
+				FractionBuildings: summary.Percentage(count, district.TotalBuildings),
+			})
+		}
+
+		districts = append(districts, info)
+	}
+
+	sort.SliceStable(districts, func(i, j int) bool {
+		if districts[i].FractionBuildings != districts[j].FractionBuildings {
+			return districts[i].FractionBuildings > districts[j].FractionBuildings
+		}
+		return districts[i].CountBuildings > districts[j].CountBuildings
+	})
+
+	return districts
+}
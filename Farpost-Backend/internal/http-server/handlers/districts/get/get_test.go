@@ -0,0 +1,154 @@
+package districts_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/districts/get"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type giver struct {
+	stats []models.DistrictStats
+	err   error
+}
+
+// GetDistrictStats leaves only Ленинский for planned blackouts
+func (g giver) GetDistrictStats(currentTime string, filter models.Filter) ([]models.DistrictStats, error) {
+	if filter.Kind == models.KindPlanned {
+		return g.stats[:1], g.err
+	}
+	return g.stats, g.err
+}
+
+var stats = []models.DistrictStats{
+	{District: models.District{ID: 1, Name: "Ленинский"}, TotalBuildings: 4, AffectedBuildings: 1,
+		AffectedByType: map[string]int64{"hot_water": 1}},
+	{District: models.District{ID: 2, Name: "Первомайский"}, TotalBuildings: 0},
+	{District: models.District{ID: 3, Name: "Фрунзенский"}, TotalBuildings: 3, AffectedBuildings: 2,
+		AffectedByType: map[string]int64{"hot_water": 1, "electricity": 1}},
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		giver     giver
+		wantError string
+		wantNames []string
+	}{
+		{"largest share first", "/off/districts?curr_time=2024-03-10_12:00:00", giver{stats: stats}, "", []string{"Фрунзенский", "Ленинский", "Первомайский"}},
+		{"kind", "/off/districts?curr_time=2024-03-10_12:00:00&kind=planned", giver{stats: stats}, "", []string{"Ленинский"}},
+		{"unknown kind", "/off/districts?curr_time=2024-03-10_12:00:00&kind=scheduled", giver{stats: stats}, "unknown kind, use: planned, emergency, unknown", nil},
+		{"no districts", "/off/districts?curr_time=2024-03-10_12:00:00", giver{}, "", []string{}},
+		{"no time", "/off/districts", giver{}, "curr_time parameter is required", nil},
+		{"invalid time", "/off/districts?curr_time=yesterday", giver{}, "invalid time format", nil},
+		{"storage failed", "/off/districts?curr_time=2024-03-10_12:00:00", giver{err: errors.New("disk I/O error")}, "failed to get districts data", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			districts.New(slog.New(slog.DiscardHandler), tt.giver, models.DefaultServiceTypes()).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got districts.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != tt.wantError {
+				t.Fatalf("got error %q, want %q", got.Error, tt.wantError)
+			}
+			if tt.wantError != "" {
+				return
+			}
+
+			names := []string{}
+			for _, district := range got.Districts {
+				names = append(names, district.Name)
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("got %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("got %v, want %v", names, tt.wantNames)
+				}
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	types := append(models.DefaultServiceTypes(), models.ServiceType{Code: "gas", NameRu: "газ", NameEn: "gas", Position: 5})
+
+	got := districts.Rank(stats, types)[0]
+
+	if got.FractionBuildings != 66.67 {
+		t.Errorf("got share %v, want 66.67", got.FractionBuildings)
+	}
+
+	// every registered type is listed in the registry order, a new one too
+	if len(got.Types) != len(types) {
+		t.Fatalf("got %d types, want %d", len(got.Types), len(types))
+	}
+	for i, info := range got.Types {
+		want := map[string]int64{"hot_water": 1, "electricity": 1}[info.Type]
+		if info.Type != types[i].Code || info.CountBuildings != want {
+			t.Errorf("got %+v at %d", info, i)
+		}
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	s := handlertest.Storage(t)
+
+	_, _, err := s.ImportDistricts("vladivostok", []models.DistrictMapping{
+		{District: "Ленинский", Street: "Карбышева ул."},
+		{District: "Фрунзенский", Street: "Светланская ул.", Number: "1"},
+		{District: "Первомайский", Street: "Нет такой"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = s.ImportDistricts("khabarovsk", []models.DistrictMapping{
+		{District: "Центральный", Street: "Ленина ул."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SetBlackoutKinds(map[string]string{"b1": models.KindPlanned, "b2": models.KindEmergency}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/districts", districts.New(handlertest.Log, s, handlertest.ServiceTypes))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"now", "/off/districts?curr_time=2024-03-10_12:00:00"},
+		{"open ended only", "/off/districts?curr_time=2025-01-01_00:00:00"},
+		{"missing time", "/off/districts"},
+		{"kind", "/off/districts?curr_time=2024-03-10_12:00:00&kind=planned"},
+		{"other city", "/khabarovsk/off/districts?curr_time=2024-03-10_12:00:00"},
+		{"unknown kind", "/off/districts?curr_time=2024-03-10_12:00:00&kind=sudden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package handlertest serves handlers over the fixture database of sqlitetest
+// and compares their responses with golden files in the testdata directory of
+// the calling package. Run the tests with -update to rewrite the golden files.
+package handlertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+	"vlru-prsch/internal/storage/storagetest"
+
+	_ "time/tzdata"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current responses")
+
+// Log drops the logs of the handlers under test
+var Log = slog.New(slog.DiscardHandler)
+
+// Cities are the cities of the fixture, Vladivostok is the default one
+var Cities = []models.City{
+	{Code: "vladivostok", Name: "Владивосток", Timezone: "Asia/Vladivostok", IsDefault: true, Location: location("Asia/Vladivostok")},
+	{Code: "khabarovsk", Name: "Хабаровск", Timezone: "Asia/Vladivostok", Location: location("Asia/Vladivostok")},
+}
+
+// Codes are the codes of Cities, as main passes them to the background jobs
+var Codes = []string{"vladivostok", "khabarovsk"}
+
+func location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// ServiceTypes are the types the registry of the fixture is seeded with,
+// as main passes them to the handlers
+var ServiceTypes = models.DefaultServiceTypes()
+
+// Storage returns the fixture storage of sqlitetest
+func Storage(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	return sqlitetest.Fixture(t)
+}
+
+// Locations place buildings 10, 11 and 12 of the fixture, the fake building 13
+// and building 20 in Khabarovsk stay without coordinates
+var Locations = []models.BuildingLocation{
+	{Street: "Карбышева ул.", Number: "54", Latitude: 43.1000, Longitude: 131.9000},
+	{Street: "Карбышева ул.", Number: "56", Latitude: 43.1010, Longitude: 131.9010},
+	{Street: "Светланская ул.", Number: "1", Latitude: 43.1150, Longitude: 131.8850},
+}
+
+// Located returns Storage with the Locations imported
+func Located(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := Storage(t)
+	if _, _, err := s.ImportBuildingLocations("vladivostok", Locations); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// Subscribed returns Storage with the subscriptions of tokens "confirmed" to
+// building 10 and "pending" to building 11
+func Subscribed(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := Storage(t)
+	for _, sub := range []models.Subscription{
+		{Email: "a@example.com", BuildingID: 10, Mode: models.SubscriptionInstant, Token: "confirmed", CreatedAt: storagetest.Now},
+		{Email: "b@example.com", BuildingID: 11, Mode: models.SubscriptionDigest, Token: "pending", CreatedAt: storagetest.Now},
+	} {
+		if _, err := s.SaveSubscription(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.ConfirmSubscription("confirmed", storagetest.Now); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// Webhooks returns Storage with webhook 1 and its deliveries 1 and 2 and
+// webhook 2 and its delivery 3
+func Webhooks(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := Storage(t)
+	for _, webhook := range []models.Webhook{
+		{URL: "https://example.com/a", Secret: "a", CreatedAt: storagetest.Now},
+		{URL: "https://example.com/b", Secret: "b", Types: []string{"electricity"}, Streets: []string{"Карбышева ул."}, CreatedAt: storagetest.Now},
+	} {
+		if _, err := s.SaveWebhook(webhook); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, d := range []models.WebhookDelivery{
+		{WebhookID: 1, EventType: "blackout.started", Payload: `{"id":"b1"}`, Status: models.DeliverySucceeded, NextAttemptAt: "2024-03-10 10:00:00"},
+		{WebhookID: 1, EventType: "blackout.ended", Payload: `{"id":"b4"}`, Status: models.DeliveryPending, NextAttemptAt: "2024-03-10 11:00:00"},
+		{WebhookID: 2, EventType: "blackout.started", Payload: `{"id":"b7"}`, Status: models.DeliveryPending, NextAttemptAt: "2024-03-10 12:00:00"},
+	} {
+		d.CreatedAt = storagetest.Now
+		if _, err := s.SaveWebhookDelivery(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return s
+}
+
+// Router mounts the routes under /off and /{city}/off behind the city
+// middleware, the way main does
+func Router(routes func(r chi.Router)) http.Handler {
+	router := chi.NewRouter()
+
+	off := func(r chi.Router) {
+		r.Use(city.New(Log, Cities))
+		routes(r)
+	}
+	router.Route("/off", off)
+	router.Route("/{city}/off", off)
+
+	return router
+}
+
+// Do sends a request to the handler and returns the recorded response.
+// A non-empty body is sent as JSON.
+func Do(t *testing.T, h http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	r := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+// Golden compares the response body with testdata/<test name>.golden.json.
+// JSON is indented before the comparison so the files read well in diffs,
+// other bodies are kept as they are in testdata/<test name>.golden.
+func Golden(t *testing.T, body []byte) {
+	t.Helper()
+
+	ext := ".golden"
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err == nil {
+		body = append(bytes.TrimSpace(indented.Bytes()), '\n')
+		ext = ".golden.json"
+	}
+
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name())+ext)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update to create it", err)
+	}
+
+	if !bytes.Equal(body, want) {
+		t.Errorf("response differs from %s, run the tests with -update to accept it\ngot:\n%s\nwant:\n%s", path, body, want)
+	}
+}
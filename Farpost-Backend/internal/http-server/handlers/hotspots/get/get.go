@@ -0,0 +1,102 @@
+package hotspots
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"vlru-prsch/internal/lib/api/filter"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// Response represents the list of chronic outage hotspots
+// @Description Адреса с хроническими отключениями
+type Response struct {
+	response.Response
+	Hotspots []models.Hotspot `json:"hotspots"`
+}
+
+type HotspotsGiver interface {
+	GetHotspots(scope string, blackoutType string, limit int, filter models.Filter) ([]models.Hotspot, error)
+}
+
+// New godoc
+// @Summary Хронические отключения
+// @Description Возвращает здания и улицы, где отключения одного типа повторяются: не реже заданного числа раз в окне анализа или с регулярным интервалом. Для каждого адреса приводится история отключений. Список обновляется фоновым анализом
+// @Tags analytics
+// @Produce json
+// @Param scope query string false "Уровень: building или street" example(building)
+// @Param type query string false "Код типа услуги из /off/service-types" example(hot_water)
+// @Param kind query string false "Вид отключений: planned, emergency, unknown, без параметра все виды вместе" example(planned)
+// @Param limit query int false "Максимальное количество записей, по умолчанию 50" example(50)
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Список адресов"
+// @Failure 400 {object} response.Response "Неверные параметры - пример: {\"status\":\"ERROR\",\"error\":\"invalid scope, use: building, street\"}"
+// @Failure 500 {object} response.Response "Ошибка при получении данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get hotspots\"}"
+// @Router /off/hotspots [get]
+func New(log *slog.Logger, giver HotspotsGiver, types models.ServiceTypes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.hotspots.get.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		scope := r.URL.Query().Get("scope")
+		if scope != "" && scope != models.HotspotBuilding && scope != models.HotspotStreet {
+			log.Warn("invalid scope", slog.String("scope", scope))
+			render.JSON(w, r, response.Error("invalid scope, use: building, street"))
+			return
+		}
+
+		blackoutType := r.URL.Query().Get("type")
+		if blackoutType != "" && !types.Has(blackoutType) {
+			log.Warn("invalid type", slog.String("type", blackoutType))
+			render.JSON(w, r, response.Error("invalid type, use: "+strings.Join(types.Codes(), ", ")))
+			return
+		}
+
+		kindFilter, err := filter.KindFromRequest(r)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
+		limit := defaultLimit
+		if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+			parsed, err := strconv.Atoi(rawLimit)
+			if err != nil || parsed < 1 || parsed > maxLimit {
+				log.Warn("invalid limit", slog.String("limit", rawLimit))
+				render.JSON(w, r, response.Error("invalid limit, use 1 to 500"))
+				return
+			}
+			limit = parsed
+		}
+
+		hotspots, err := giver.GetHotspots(scope, blackoutType, limit, kindFilter)
+		if err != nil {
+			log.Error("failed to get hotspots", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get hotspots"))
+			return
+		}
+
+		if hotspots == nil {
+			hotspots = []models.Hotspot{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.Ok(),
+			Hotspots: hotspots,
+		})
+	}
+}
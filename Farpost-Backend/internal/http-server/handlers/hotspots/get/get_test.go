@@ -0,0 +1,119 @@
+package hotspots_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/hotspots/get"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type giver struct {
+	err          error
+	scope        string
+	blackoutType string
+	limit        int
+	kind         string
+}
+
+func (g *giver) GetHotspots(scope string, blackoutType string, limit int, filter models.Filter) ([]models.Hotspot, error) {
+	g.scope, g.blackoutType, g.limit, g.kind = scope, blackoutType, limit, filter.Kind
+	return nil, g.err
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		err       error
+		wantError string
+		want      giver
+	}{
+		{"defaults", "/off/hotspots", nil, "", giver{limit: 50}},
+		{"filters", "/off/hotspots?scope=street&type=heat&limit=5", nil, "", giver{scope: "street", blackoutType: "heat", limit: 5}},
+		{"kind", "/off/hotspots?kind=Planned", nil, "", giver{limit: 50, kind: "planned"}},
+		{"unknown kind", "/off/hotspots?kind=scheduled", nil, "unknown kind, use: planned, emergency, unknown", giver{}},
+		{"invalid scope", "/off/hotspots?scope=district", nil, "invalid scope, use: building, street", giver{}},
+		{"invalid type", "/off/hotspots?type=steam", nil, "invalid type, use: hot_water, cold_water, electricity, heat", giver{}},
+		{"limit over the maximum", "/off/hotspots?limit=501", nil, "invalid limit, use 1 to 500", giver{}},
+		{"storage failed", "/off/hotspots", errors.New("disk I/O error"), "failed to get hotspots", giver{limit: 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{err: tt.err}
+
+			w := httptest.NewRecorder()
+			hotspots.New(slog.New(slog.DiscardHandler), g, models.DefaultServiceTypes()).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got struct {
+				Error    string           `json:"error"`
+				Hotspots []models.Hotspot `json:"hotspots"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != tt.wantError {
+				t.Fatalf("got error %q, want %q", got.Error, tt.wantError)
+			}
+			if g.scope != tt.want.scope || g.blackoutType != tt.want.blackoutType || g.limit != tt.want.limit || g.kind != tt.want.kind {
+				t.Errorf("storage got %q, %q, %d, %q", g.scope, g.blackoutType, g.limit, g.kind)
+			}
+			if tt.wantError == "" && got.Hotspots == nil {
+				t.Errorf("got %s, want an empty list", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	s := handlertest.Storage(t)
+
+	err := s.ReplaceHotspots([]models.Hotspot{
+		{Scope: "building", BuildingID: 11, Street: "Карбышева ул.", Number: "56", Type: "cold_water", Outages: 2, WindowOutages: 2,
+			FirstAt: "2024-02-01 08:00:00", LastAt: "2024-03-09 22:00:00",
+			Timeline: []models.HotspotOutage{{ID: "b4", StartOff: "2024-02-01 08:00:00", EndOff: "2024-02-01 10:00:00"}, {ID: "b6", StartOff: "2024-03-09 22:00:00"}}},
+		{Scope: "street", Street: "Карбышева ул.", Type: "hot_water", Outages: 4, WindowOutages: 3, Periodic: true, PeriodDays: 14.5,
+			FirstAt: "2024-01-15 09:00:00", LastAt: "2024-03-10 09:00:00"},
+		{Scope: "street", Street: "Карбышева ул.", Type: "hot_water", Kind: models.KindPlanned, Outages: 3, WindowOutages: 3,
+			FirstAt: "2024-02-12 09:00:00", LastAt: "2024-03-10 09:00:00"},
+	}, "2024-03-10 12:00:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/hotspots", hotspots.New(handlertest.Log, s, handlertest.ServiceTypes))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"all", "/off/hotspots"},
+		{"other city", "/khabarovsk/off/hotspots"},
+		{"scope", "/off/hotspots?scope=building"},
+		{"type", "/off/hotspots?type=hot_water"},
+		{"limit", "/off/hotspots?limit=1"},
+		{"none", "/off/hotspots?type=heat"},
+		{"kind", "/off/hotspots?kind=planned"},
+		{"unknown kind", "/off/hotspots?kind=sudden"},
+		{"unknown scope", "/off/hotspots?scope=district"},
+		{"unknown type", "/off/hotspots?type=steam"},
+		{"invalid limit", "/off/hotspots?limit=many"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
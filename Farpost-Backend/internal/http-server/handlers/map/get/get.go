@@ -0,0 +1,203 @@
+package geomap
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/filter"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/date"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/lib/geojson"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// colors follow the simplestyle-spec marker-color property, so the
+// collection can be dropped into most map libraries without extra styling
+var colors = map[string]string{
+	"hot_water":   "#e53935",
+	"cold_water":  "#1e88e5",
+	"electricity": "#fdd835",
+	"heat":        "#fb8c00",
+}
+
+// defaultColor styles the types added to the registry later
+const defaultColor = "#757575"
+
+// Blackout represents a blackout in the feature properties
+// @Description Отключение в свойствах точки на карте
+type Blackout struct {
+	ID            string `json:"id" example:"b1f4"`
+	Service       string `json:"service" example:"hot_water"`
+	StartOff      string `json:"start_off" example:"2019-01-15 10:00:00"`
+	EndOff        string `json:"end_off" example:"2019-01-15 18:00:00"`
+	InitiatorName string `json:"initiator_name" example:"КГУП Приморский водоканал"`
+	Description   string `json:"description" example:"Плановые работы"`
+	Kind          string `json:"kind" example:"planned"`
+}
+
+type MapGiver interface {
+	GetDistricts(filter models.Filter) ([]models.District, error)
+	GetMapBlackouts(currentTime string, bbox *models.BBox, filter models.Filter) ([]models.MapBlackout, error)
+}
+
+// New godoc
+// @Summary Карта текущих отключений
+// @Description Возвращает GeoJSON FeatureCollection: точка на каждое здание с координатами, затронутое отключением в указанное время. В свойствах - адрес, список отключений, основной тип услуги (service) и цвет маркера (marker-color)
+// @Tags map
+// @Produce json
+// @Param curr_time query string false "Время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2019-01-15_14:30:00)
+// @Param bbox query string false "Ограничивающий прямоугольник: minLon,minLat,maxLon,maxLat" example(131.85,43.08,131.95,43.15)
+// @Param services query string false "Типы отключений через запятую" example(hot_water,electricity)
+// @Param district query string false "Административный район" example(Ленинский)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
+// @Security ApiKeyAuth
+// @Success 200 {object} geojson.FeatureCollection "Точки отключений"
+// @Failure 400 {object} response.Response "Неверные параметры - пример: {\"status\":\"ERROR\",\"error\":\"invalid bbox\"}"
+// @Failure 500 {object} response.Response "Ошибка получения данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get map data\"}"
+// @Router /off/map [get]
+func New(log *slog.Logger, giver MapGiver, types models.ServiceTypes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.map.get.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		currTime := r.URL.Query().Get("curr_time")
+		currTimeParse, err := date.ParseQueryDateIn(currTime, city.Location(r.Context()))
+		if err != nil {
+			log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
+			render.JSON(w, r, response.Error("invalid time format"))
+			return
+		}
+
+		var bbox *models.BBox
+		if rawBBox := r.URL.Query().Get("bbox"); rawBBox != "" {
+			parsed, err := geo.ParseBBox(rawBBox)
+			if err != nil {
+				log.Warn("invalid bbox", slog.String("bbox", rawBBox), sl.Err(err))
+				render.JSON(w, r, response.Error("invalid bbox"))
+				return
+			}
+			bbox = &parsed
+		}
+
+		services := splitList(r.URL.Query().Get("services"))
+		for _, service := range services {
+			if !types.Has(service) {
+				log.Warn("invalid service", slog.String("service", service))
+				render.JSON(w, r, response.Error("invalid service, use: "+strings.Join(types.Codes(), ", ")))
+				return
+			}
+		}
+
+		areaFilter, err := filter.FromRequest(r, giver)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
+		blackouts, err := giver.GetMapBlackouts(currTimeParse, bbox, areaFilter)
+		if err != nil {
+			log.Error("failed to get map blackouts", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get map data"))
+			return
+		}
+
+		render.JSON(w, r, Collect(blackouts, services, types))
+	}
+}
+
+// Collect groups blackouts by building into point features. A building
+// affected by several services is styled by the first one of types.
+func Collect(blackouts []models.MapBlackout, services []string, types models.ServiceTypes) geojson.FeatureCollection {
+	type building struct {
+		blackout  models.MapBlackout
+		services  []string
+		blackouts []Blackout
+	}
+
+	var order []int64
+	buildings := make(map[int64]*building)
+
+	for _, blackout := range blackouts {
+		if len(services) > 0 && !slices.Contains(services, blackout.Type) {
+			continue
+		}
+
+		b, ok := buildings[blackout.BuildingID]
+		if !ok {
+			b = &building{blackout: blackout}
+			buildings[blackout.BuildingID] = b
+			order = append(order, blackout.BuildingID)
+		}
+
+		if !slices.Contains(b.services, blackout.Type) {
+			b.services = append(b.services, blackout.Type)
+		}
+
+		b.blackouts = append(b.blackouts, Blackout{
+			ID:            blackout.BlackoutID,
+			Service:       blackout.Type,
+			StartOff:      blackout.StartDate,
+			EndOff:        blackout.EndDate,
+			InitiatorName: blackout.InitiatorName,
+			Description:   blackout.Description,
+			Kind:          blackout.Kind,
+		})
+	}
+
+	features := make([]geojson.Feature, 0, len(order))
+	for _, id := range order {
+		b := buildings[id]
+
+		primary := b.services[0]
+		for _, blackoutType := range types.Codes() {
+			if slices.Contains(b.services, blackoutType) {
+				primary = blackoutType
+				break
+			}
+		}
+
+		features = append(features, geojson.NewPoint(b.blackout.Longitude, b.blackout.Latitude, map[string]any{
+			"building_id":  id,
+			"address":      b.blackout.Street + " " + b.blackout.Number,
+			"service":      primary,
+			"services":     b.services,
+			"marker-color": markerColor(primary),
+			"blackouts":    b.blackouts,
+		}))
+	}
+
+	return geojson.NewFeatureCollection(features)
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func markerColor(blackoutType string) string {
+	if color, ok := colors[blackoutType]; ok {
+		return color
+	}
+	return defaultColor
+}
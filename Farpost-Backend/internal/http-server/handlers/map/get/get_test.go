@@ -0,0 +1,223 @@
+package geomap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	mapget "vlru-prsch/internal/http-server/handlers/map/get"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var blackouts = []models.MapBlackout{
+	{LocatedBuilding: models.LocatedBuilding{Address: models.Address{BuildingID: 10, Street: "Карбышева ул.", Number: "54"}, Latitude: 43.10, Longitude: 131.90}, BlackoutID: "b1", Type: "hot_water"},
+	{LocatedBuilding: models.LocatedBuilding{Address: models.Address{BuildingID: 11, Street: "Карбышева ул.", Number: "56"}, Latitude: 43.11, Longitude: 131.91}, BlackoutID: "b1", Type: "hot_water"},
+	{LocatedBuilding: models.LocatedBuilding{Address: models.Address{BuildingID: 11, Street: "Карбышева ул.", Number: "56"}, Latitude: 43.11, Longitude: 131.91}, BlackoutID: "b5", Type: "heat"},
+	{LocatedBuilding: models.LocatedBuilding{Address: models.Address{BuildingID: 12, Street: "Светланская ул.", Number: "1"}, Latitude: 43.12, Longitude: 131.95}, BlackoutID: "b2", Type: "electricity"},
+}
+
+type giver struct {
+	err    error
+	time   string
+	bbox   *models.BBox
+	filter models.Filter
+}
+
+func (g *giver) GetDistricts(filter models.Filter) ([]models.District, error) {
+	return []models.District{{ID: 1, Name: "Ленинский"}}, nil
+}
+
+func (g *giver) GetMapBlackouts(currentTime string, bbox *models.BBox, filter models.Filter) ([]models.MapBlackout, error) {
+	g.time, g.bbox, g.filter = currentTime, bbox, filter
+	return blackouts, g.err
+}
+
+// collection is the part of the GeoJSON the tests look at
+type collection struct {
+	Status   string `json:"status"`
+	Error    string `json:"error"`
+	Type     string `json:"type"`
+	Features []struct {
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			BuildingID  int64    `json:"building_id"`
+			Address     string   `json:"address"`
+			Service     string   `json:"service"`
+			Services    []string `json:"services"`
+			MarkerColor string   `json:"marker-color"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		err       error
+		wantError string
+		wantBBox  *models.BBox
+		wantIDs   []int64
+	}{
+		{"every building", "/off/map?curr_time=2024-03-10_12:00:00", nil, "", nil, []int64{10, 11, 12}},
+		{"bounding box", "/off/map?curr_time=2024-03-10_12:00:00&bbox=131.89,43.09,131.92,43.115", nil, "",
+			&models.BBox{MinLon: 131.89, MinLat: 43.09, MaxLon: 131.92, MaxLat: 43.115}, []int64{10, 11, 12}},
+		{"services", "/off/map?curr_time=2024-03-10_12:00:00&services=heat,electricity", nil, "", nil, []int64{11, 12}},
+		{"invalid time", "/off/map?curr_time=yesterday", nil, "invalid time format", nil, nil},
+		{"invalid bounding box", "/off/map?curr_time=2024-03-10_12:00:00&bbox=131.89,43.09", nil, "invalid bbox", nil, nil},
+		{"unknown service", "/off/map?curr_time=2024-03-10_12:00:00&services=steam", nil, "invalid service, use: hot_water, cold_water, electricity, heat", nil, nil},
+		{"storage failed", "/off/map?curr_time=2024-03-10_12:00:00", errors.New("disk I/O error"), "failed to get map data", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{err: tt.err}
+
+			w := httptest.NewRecorder()
+			mapget.New(slog.New(slog.DiscardHandler), g, models.DefaultServiceTypes()).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got collection
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != tt.wantError {
+				t.Fatalf("got error %q, want %q", got.Error, tt.wantError)
+			}
+			if tt.wantError != "" {
+				return
+			}
+
+			if got.Type != "FeatureCollection" {
+				t.Errorf("got type %q, want FeatureCollection", got.Type)
+			}
+			if g.time != "2024-03-10 12:00:00" || !reflect.DeepEqual(g.bbox, tt.wantBBox) {
+				t.Errorf("storage got time %q and bbox %v", g.time, g.bbox)
+			}
+
+			var ids []int64
+			for _, feature := range got.Features {
+				ids = append(ids, feature.Properties.BuildingID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("got buildings %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestNewFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantError string
+		want      models.Filter
+	}{
+		{"none", "/off/map", "", models.Filter{}},
+		{"district and kind", "/off/map?district=ленинский&kind=emergency", "", models.Filter{District: "Ленинский", Kind: "emergency"}},
+		{"unknown district", "/off/map?district=Нет+такого", "unknown district", models.Filter{}},
+		{"unknown kind", "/off/map?kind=scheduled", "unknown kind, use: planned, emergency, unknown", models.Filter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{}
+
+			w := httptest.NewRecorder()
+			mapget.New(slog.New(slog.DiscardHandler), g, models.DefaultServiceTypes()).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got collection
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != tt.wantError {
+				t.Fatalf("got error %q, want %q", got.Error, tt.wantError)
+			}
+			if g.filter != tt.want {
+				t.Errorf("storage got %+v, want %+v", g.filter, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	got := mapget.Collect(blackouts, nil, models.DefaultServiceTypes())
+
+	if len(got.Features) != 3 {
+		t.Fatalf("got %d features, want 3", len(got.Features))
+	}
+
+	// building 11 has hot water and heat off, hot water comes first in the registry
+	properties := got.Features[1].Properties
+	want := map[string]any{
+		"building_id":  int64(11),
+		"address":      "Карбышева ул. 56",
+		"service":      "hot_water",
+		"services":     []string{"hot_water", "heat"},
+		"marker-color": "#e53935",
+	}
+	for key, value := range want {
+		if !reflect.DeepEqual(properties[key], value) {
+			t.Errorf("%s = %v, want %v", key, properties[key], value)
+		}
+	}
+	if n := len(properties["blackouts"].([]mapget.Blackout)); n != 2 {
+		t.Errorf("got %d blackouts, want 2", n)
+	}
+
+	if coordinates := string(got.Features[1].Geometry.Coordinates); coordinates != "[131.91,43.11]" {
+		t.Errorf("got coordinates %s, want longitude first", coordinates)
+	}
+}
+
+func TestCollectRegisteredType(t *testing.T) {
+	// gas is registered ahead of heat, so it styles building 11 with the default color
+	types := models.ServiceTypes{{Code: "hot_water"}, {Code: "gas"}, {Code: "heat"}}
+	gas := models.MapBlackout{LocatedBuilding: blackouts[2].LocatedBuilding, BlackoutID: "g1", Type: "gas"}
+
+	got := mapget.Collect([]models.MapBlackout{blackouts[2], gas}, nil, types)
+
+	if len(got.Features) != 1 {
+		t.Fatalf("got %d features, want 1", len(got.Features))
+	}
+
+	properties := got.Features[0].Properties
+	if properties["service"] != "gas" || properties["marker-color"] != "#757575" {
+		t.Errorf("got service %v colored %v, want gas colored #757575", properties["service"], properties["marker-color"])
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/map", mapget.New(handlertest.Log, handlertest.Located(t), handlertest.ServiceTypes))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"now", "/off/map?curr_time=2024-03-10_12:00:00"},
+		{"bounding box", "/off/map?curr_time=2024-03-10_12:00:00&bbox=131.89,43.09,131.91,43.11"},
+		{"services", "/off/map?curr_time=2024-03-10_12:00:00&services=cold_water"},
+		{"open ended only", "/off/map?curr_time=2025-01-01_00:00:00"},
+		{"unlocated buildings are left out", "/khabarovsk/off/map?curr_time=2024-03-10_12:00:00"},
+		{"invalid bounding box", "/off/map?curr_time=2024-03-10_12:00:00&bbox=131.89,43.09"},
+		{"unknown service", "/off/map?curr_time=2024-03-10_12:00:00&services=steam"},
+		{"invalid time", "/off/map?curr_time=yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
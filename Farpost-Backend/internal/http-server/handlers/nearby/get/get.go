@@ -0,0 +1,100 @@
+package nearby
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/date"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// DefaultRadius is used when the radius parameter is omitted
+const DefaultRadius = 500
+
+// Response represents blackouts found around a point
+// @Description Отключения и здания в радиусе от точки
+type Response struct {
+	response.Response
+	// Здания с действующими отключениями, от ближайшего к дальнему
+	Buildings []models.AffectedBuilding `json:"buildings"`
+	// Отключения, от ближайшего к дальнему
+	Blackouts []models.NearbyBlackout `json:"blackouts"`
+}
+
+type NearbyGiver interface {
+	Nearby(center geo.Point, radius float64, currentTime string, city string) (models.SpatialResult, error)
+}
+
+// New godoc
+// @Summary Отключения рядом с точкой
+// @Description Возвращает действующие в указанное время отключения и затронутые ими здания в радиусе от точки. Учитываются только здания города запроса с импортированными координатами. Оба списка отсортированы по расстоянию
+// @Tags map
+// @Produce json
+// @Param lat query number true "Широта" example(43.1155)
+// @Param lon query number true "Долгота" example(131.8869)
+// @Param radius query number false "Радиус в метрах, по умолчанию 500" example(500)
+// @Param curr_time query string false "Время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2019-01-15_14:30:00)
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Отключения рядом"
+// @Failure 400 {object} response.Response "Неверные параметры - пример: {\"status\":\"ERROR\",\"error\":\"invalid radius\"}"
+// @Failure 500 {object} response.Response "Ошибка получения данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get nearby blackouts\"}"
+// @Router /off/nearby [get]
+func New(log *slog.Logger, giver NearbyGiver, maxRadius float64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.nearby.get.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		lat, errLat := strconv.ParseFloat(query.Get("lat"), 64)
+		lon, errLon := strconv.ParseFloat(query.Get("lon"), 64)
+		if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			log.Warn("invalid coordinates", slog.String("lat", query.Get("lat")), slog.String("lon", query.Get("lon")))
+			render.JSON(w, r, response.Error("invalid coordinates"))
+			return
+		}
+
+		radius := float64(DefaultRadius)
+		if rawRadius := query.Get("radius"); rawRadius != "" {
+			parsed, err := strconv.ParseFloat(rawRadius, 64)
+			if err != nil || parsed <= 0 || parsed > maxRadius {
+				log.Warn("invalid radius", slog.String("radius", rawRadius))
+				render.JSON(w, r, response.Error("invalid radius, use 1 to "+strconv.FormatFloat(maxRadius, 'f', -1, 64)+" meters"))
+				return
+			}
+			radius = parsed
+		}
+
+		currTime := query.Get("curr_time")
+		currTimeParse, err := date.ParseQueryDate(currTime)
+		if err != nil {
+			log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
+			render.JSON(w, r, response.Error("invalid time format"))
+			return
+		}
+
+		result, err := giver.Nearby(geo.Point{Lat: lat, Lon: lon}, radius, currTimeParse, city.FromContext(r.Context()).Code)
+		if err != nil {
+			log.Error("failed to get nearby blackouts", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get nearby blackouts"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:  response.Ok(),
+			Buildings: result.Buildings,
+			Blackouts: result.Blackouts,
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package nearby_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/nearby/get"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/spatial"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type giver struct {
+	err    error
+	center geo.Point
+	radius float64
+	time   string
+	city   string
+}
+
+func (g *giver) Nearby(center geo.Point, radius float64, currentTime string, city string) (models.SpatialResult, error) {
+	g.center, g.radius, g.time, g.city = center, radius, currentTime, city
+	return models.SpatialResult{
+		Buildings: []models.AffectedBuilding{{BuildingID: 10, Address: "Карбышева ул. 54", Services: []string{"hot_water"}, Blackouts: []string{"b1"}}},
+		Blackouts: []models.NearbyBlackout{{ID: "b1", Service: "hot_water", BuildingsCount: 1}},
+	}, g.err
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		err        error
+		wantError  string
+		wantRadius float64
+	}{
+		{"default radius", "/off/nearby?lat=43.11&lon=131.89&curr_time=2024-03-10_12:00:00", nil, "", nearby.DefaultRadius},
+		{"radius", "/off/nearby?lat=43.11&lon=131.89&radius=1500&curr_time=2024-03-10_12:00:00", nil, "", 1500},
+		{"no coordinates", "/off/nearby?curr_time=2024-03-10_12:00:00", nil, "invalid coordinates", 0},
+		{"latitude out of range", "/off/nearby?lat=91&lon=131.89", nil, "invalid coordinates", 0},
+		{"radius over the limit", "/off/nearby?lat=43.11&lon=131.89&radius=5001", nil, "invalid radius, use 1 to 5000 meters", 0},
+		{"negative radius", "/off/nearby?lat=43.11&lon=131.89&radius=-1", nil, "invalid radius, use 1 to 5000 meters", 0},
+		{"invalid time", "/off/nearby?lat=43.11&lon=131.89&curr_time=yesterday", nil, "invalid time format", 0},
+		{"storage failed", "/off/nearby?lat=43.11&lon=131.89&curr_time=2024-03-10_12:00:00", errors.New("disk I/O error"), "failed to get nearby blackouts", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{err: tt.err}
+
+			w := httptest.NewRecorder()
+			nearby.New(slog.New(slog.DiscardHandler), g, 5000).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got nearby.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != tt.wantError {
+				t.Fatalf("got error %q, want %q", got.Error, tt.wantError)
+			}
+			if tt.wantError != "" {
+				return
+			}
+
+			if g.center != (geo.Point{Lat: 43.11, Lon: 131.89}) || g.radius != tt.wantRadius || g.time != "2024-03-10 12:00:00" {
+				t.Errorf("locator got %v, %v m, %q", g.center, g.radius, g.time)
+			}
+			if len(got.Buildings) != 1 || len(got.Blackouts) != 1 {
+				t.Errorf("got %+v, want the locator result", got)
+			}
+		})
+	}
+}
+
+func TestNewCity(t *testing.T) {
+	cities := []models.City{{Code: "vladivostok", IsDefault: true}, {Code: "khabarovsk"}}
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"default city", "", "vladivostok"},
+		{"city from the header", "Khabarovsk", "khabarovsk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{}
+
+			r := httptest.NewRequest("GET", "/off/nearby?lat=43.11&lon=131.89", nil)
+			r.Header.Set(city.Header, tt.header)
+
+			city.New(slog.New(slog.DiscardHandler), cities)(nearby.New(slog.New(slog.DiscardHandler), g, 5000)).ServeHTTP(httptest.NewRecorder(), r)
+
+			if g.city != tt.want {
+				t.Errorf("locator got city %q, want %q", g.city, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	locator := spatial.NewLocator(handlertest.Log, handlertest.Located(t), time.Hour, handlertest.Codes)
+	if err := locator.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/nearby", nearby.New(handlertest.Log, locator, 5000))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"default radius", "/off/nearby?lat=43.1&lon=131.9&curr_time=2024-03-10_12:00:00"},
+		{"wide radius", "/off/nearby?lat=43.1&lon=131.9&radius=3000&curr_time=2024-03-10_12:00:00"},
+		{"open ended only", "/off/nearby?lat=43.1&lon=131.9&radius=3000&curr_time=2025-01-01_00:00:00"},
+		{"nothing around", "/off/nearby?lat=48.48&lon=135.08&curr_time=2024-03-10_12:00:00"},
+		{"other city", "/khabarovsk/off/nearby?lat=43.1&lon=131.9&radius=3000&curr_time=2024-03-10_12:00:00"},
+		{"invalid coordinates", "/off/nearby?lat=91&lon=131.9&curr_time=2024-03-10_12:00:00"},
+		{"radius over the limit", "/off/nearby?lat=43.1&lon=131.9&radius=10000&curr_time=2024-03-10_12:00:00"},
+		{"invalid time", "/off/nearby?lat=43.1&lon=131.9&curr_time=yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
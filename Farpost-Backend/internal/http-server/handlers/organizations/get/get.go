@@ -3,9 +3,11 @@ package organizations
 import (
 	"log/slog"
 	"net/http"
+	"vlru-prsch/internal/lib/api/filter"
 	"vlru-prsch/internal/lib/api/response"
 	"vlru-prsch/internal/lib/date"
 	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -38,18 +40,19 @@ type OrganizationInfo struct {
 }
 
 type OrganizationGiver interface {
-	GetOrganizations(currentTime string) ([]string, error)
-	GetBuildingsCountByOrgName(name string, currentTime string) (int64, error)
-	GetLastAddressByOrgName(name string, currentTime string) (string, string, error)
+	GetOrganizations(currentTime string, filter models.Filter) ([]string, error)
+	GetBuildingsCountByOrgName(name string, currentTime string, filter models.Filter) (int64, error)
+	GetLastAddressByOrgName(name string, currentTime string, filter models.Filter) (string, string, error)
 }
 
 // New godoc
 // @Summary Получить информацию об организациях
-// @Description Возвращает список организаций с информацией о количестве зданий и последних отключениях
+// @Description Возвращает список организаций с информацией о количестве зданий и последних отключениях. С параметром kind учитываются только отключения этого вида
 // @Tags organizations
 // @Accept json
 // @Produce json
 // @Param curr_time query string true "Текущее время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2024-01-15_14:30:00 или 2024-01-15T14:30:00Z)
+// @Param kind query string false "Вид отключения: planned, emergency, unknown" example(emergency)
 // @Security ApiKeyAuth
 // @Success 200 {object} Response "Успешный ответ с данными об организациях"
 // @Failure 400 {object} response.Response "Неверный запрос - пример: {\"status\":\"ERROR\",\"error\":\"curr_time parameter is required\"}"
@@ -79,7 +82,12 @@ func New(log *slog.Logger, giver OrganizationGiver) http.HandlerFunc {
 			return
 		}
 
-		orgNames, err := giver.GetOrganizations(currTimeParse)
+		kindFilter, err := filter.KindFromRequest(r)
+		if filter.Render(w, r, log, err) {
+			return
+		}
+
+		orgNames, err := giver.GetOrganizations(currTimeParse, kindFilter)
 		if err != nil {
 			log.Error("failed to get organizations", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to get organizations"))
@@ -89,14 +97,14 @@ func New(log *slog.Logger, giver OrganizationGiver) http.HandlerFunc {
 		var organizationsInfo []OrganizationInfo
 
 		for _, orgName := range orgNames {
-			countBuildings, err := giver.GetBuildingsCountByOrgName(orgName, currTimeParse)
+			countBuildings, err := giver.GetBuildingsCountByOrgName(orgName, currTimeParse, kindFilter)
 			if err != nil {
 				log.Error("failed to get buildings count",
 					slog.String("org", orgName), sl.Err(err))
 				continue
 			}
 
-			lastTime, lastAddress, err := giver.GetLastAddressByOrgName(orgName, currTimeParse)
+			lastTime, lastAddress, err := giver.GetLastAddressByOrgName(orgName, currTimeParse, kindFilter)
 			if err != nil {
 				log.Error("failed to get last blackout",
 					slog.String("org", orgName), sl.Err(err))
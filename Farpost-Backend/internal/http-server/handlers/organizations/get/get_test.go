@@ -0,0 +1,42 @@
+package organizations_test
+
+import (
+	"net/http"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	orgsget "vlru-prsch/internal/http-server/handlers/organizations/get"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	s := handlertest.Storage(t)
+	if err := s.SetBlackoutKinds(map[string]string{"b1": models.KindPlanned, "b2": models.KindEmergency}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/orgs", orgsget.New(handlertest.Log, s))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"now", "/off/orgs?curr_time=2024-03-10_12:00:00"},
+		{"open ended", "/off/orgs?curr_time=2024-03-12_10:00:00"},
+		{"nothing active", "/off/orgs?curr_time=2024-01-01_00:00:00"},
+		{"kind", "/off/orgs?curr_time=2024-03-10_12:00:00&kind=emergency"},
+		{"unknown kind", "/off/orgs?curr_time=2024-03-10_12:00:00&kind=sudden"},
+		{"missing time", "/off/orgs"},
+		{"invalid time", "/off/orgs?curr_time=yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
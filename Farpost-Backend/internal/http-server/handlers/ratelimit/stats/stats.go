@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"log/slog"
+	"net/http"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/render"
+)
+
+// Response represents the counters of the rate limiter
+// @Description Статистика ограничения частоты запросов
+type Response struct {
+	response.Response
+	RateLimit models.RateLimitStats `json:"rate_limit"`
+}
+
+type StatsGiver interface {
+	Stats() models.RateLimitStats
+}
+
+// New godoc
+// @Summary Статистика ограничения частоты запросов
+// @Description Возвращает число отслеживаемых клиентов и по группам маршрутов - пропущенные, отклоненные с кодом 429 и пришедшие из списка разрешенных адресов запросы
+// @Tags ratelimit
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Статистика ограничения"
+// @Failure 401 {object} response.Response "Нет доступа - пример: {\"status\":\"ERROR\",\"error\":\"unauthorized\"}"
+// @Router /off/ratelimit/stats [get]
+func New(log *slog.Logger, giver StatsGiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{
+			Response:  response.Ok(),
+			RateLimit: giver.Stats(),
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package stats_test
+
+import (
+	"net/http"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	ratelimitstats "vlru-prsch/internal/http-server/handlers/ratelimit/stats"
+	"vlru-prsch/internal/ratelimit"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		clients int
+		record  func(m *ratelimit.Metrics)
+	}{
+		{"empty", 0, func(m *ratelimit.Metrics) {}},
+		{"groups", 3, func(m *ratelimit.Metrics) {
+			m.Allowed("search")
+			m.Allowed("search")
+			m.Limited("search")
+			m.Allowlisted("admin")
+			m.Allowed("public")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := ratelimit.NewMetrics(func() int { return tt.clients })
+			tt.record(metrics)
+
+			h := handlertest.Router(func(r chi.Router) {
+				r.Get("/ratelimit/stats", ratelimitstats.New(handlertest.Log, metrics))
+			})
+
+			w := handlertest.Do(t, h, http.MethodGet, "/off/ratelimit/stats", "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
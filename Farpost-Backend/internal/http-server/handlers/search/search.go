@@ -3,8 +3,10 @@ package search
 import (
 	"log/slog"
 	"net/http"
+	"vlru-prsch/internal/http-server/middleware/city"
 	"vlru-prsch/internal/lib/api/response"
 	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -26,12 +28,12 @@ type Response struct {
 }
 
 type StreetsFinder interface {
-	FindStreets(substr string) ([]string, error)
+	FindStreets(substr string, filter models.Filter) ([]string, error)
 }
 
 // New godoc
 // @Summary Поиск улиц по подстроке
-// @Description Поиск улиц по частичному совпадению названия. Возвращает список улиц, содержащих указанную подстроку
+// @Description Поиск улиц по частичному совпадению названия. Возвращает список улиц города запроса, содержащих указанную подстроку
 // @Tags search
 // @Accept json
 // @Produce json
@@ -68,7 +70,7 @@ func New(log *slog.Logger, finder StreetsFinder) http.HandlerFunc {
 
 		var streets []string
 
-		streets, err := finder.FindStreets(req.Suggest)
+		streets, err := finder.FindStreets(req.Suggest, models.Filter{City: city.FromContext(r.Context()).Code})
 		if err != nil {
 			log.Error("failed to find streets", slog.Any("error", err))
 			render.JSON(w, r, response.Error("search failed"))
@@ -0,0 +1,36 @@
+package search_test
+
+import (
+	"net/http"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/search"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNew(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Post("/search", search.New(handlertest.Log, handlertest.Storage(t)))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"default city", "/off/search", `{"suggest":"ул"}`},
+		{"other city", "/khabarovsk/off/search", `{"suggest":"ул"}`},
+		{"lower case", "/off/search", `{"suggest":"карб"}`},
+		{"no match", "/off/search", `{"suggest":"нет такой"}`},
+		{"too short", "/off/search", `{"suggest":"k"}`},
+		{"invalid body", "/off/search", `{"suggest":`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodPost, tt.target, tt.body)
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
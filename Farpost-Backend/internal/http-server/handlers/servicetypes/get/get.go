@@ -0,0 +1,59 @@
+package servicetypes
+
+import (
+	"log/slog"
+	"net/http"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents the service type registry
+// @Description Реестр типов услуг
+type Response struct {
+	response.Response
+	ServiceTypes models.ServiceTypes `json:"service_types"`
+}
+
+type ServiceTypesGiver interface {
+	GetServiceTypes() (models.ServiceTypes, error)
+}
+
+// New godoc
+// @Summary Типы услуг
+// @Description Возвращает реестр типов услуг в порядке отображения: код, названия на русском и английском и ключ иконки. Коды используются как ключи в ответах с агрегатами и в фильтрах по типу
+// @Tags service types
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Реестр типов услуг"
+// @Failure 500 {object} response.Response "Ошибка получения - пример: {\"status\":\"ERROR\",\"error\":\"failed to get service types\"}"
+// @Router /off/service-types [get]
+func New(log *slog.Logger, giver ServiceTypesGiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.servicetypes.get.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		types, err := giver.GetServiceTypes()
+		if err != nil {
+			log.Error("failed to get service types", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get service types"))
+			return
+		}
+
+		if types == nil {
+			types = []models.ServiceType{}
+		}
+
+		render.JSON(w, r, Response{
+			Response:     response.Ok(),
+			ServiceTypes: types,
+		})
+	}
+}
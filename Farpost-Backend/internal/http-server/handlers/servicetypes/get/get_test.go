@@ -0,0 +1,69 @@
+package servicetypes_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/servicetypes/get"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type giver struct {
+	types models.ServiceTypes
+	err   error
+}
+
+func (g giver) GetServiceTypes() (models.ServiceTypes, error) {
+	return g.types, g.err
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		giver     giver
+		wantError string
+		want      models.ServiceTypes
+	}{
+		{"registry", giver{types: models.DefaultServiceTypes()}, "", models.DefaultServiceTypes()},
+		{"empty registry", giver{}, "", models.ServiceTypes{}},
+		{"storage failed", giver{err: errors.New("disk I/O error")}, "failed to get service types", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			servicetypes.New(slog.New(slog.DiscardHandler), tt.giver).ServeHTTP(w, httptest.NewRequest("GET", "/off/service-types", nil))
+
+			var got struct {
+				Error        string              `json:"error"`
+				ServiceTypes models.ServiceTypes `json:"service_types"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != tt.wantError {
+				t.Fatalf("got error %q, want %q", got.Error, tt.wantError)
+			}
+			if !reflect.DeepEqual(got.ServiceTypes, tt.want) {
+				t.Errorf("got %+v, want %+v", got.ServiceTypes, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/service-types", servicetypes.New(handlertest.Log, handlertest.Storage(t)))
+	})
+
+	w := handlertest.Do(t, h, http.MethodGet, "/off/service-types", "")
+	handlertest.Golden(t, w.Body.Bytes())
+}
@@ -0,0 +1,163 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Subscriber interface {
+	Subscribe(filter events.Filter, lastID int64) (*events.Subscription, []events.Event)
+	Unsubscribe(sub *events.Subscription)
+}
+
+// New godoc
+// @Summary Поток событий об отключениях (SSE)
+// @Description Server-Sent Events поток: появление новых запланированных отключений, их начало, окончание и изменение, а также изменение количества затронутых зданий по типам. Поддерживает возобновление по заголовку Last-Event-ID
+// @Tags stream
+// @Produce text/event-stream
+// @Param types query string false "Коды типов услуг через запятую, см. /off/service-types" example(hot_water,electricity)
+// @Param streets query string false "Улицы через запятую" example(Карбышева ул.)
+// @Param Last-Event-ID header string false "Идентификатор последнего полученного события"
+// @Param last_event_id query string false "Идентификатор последнего полученного события, если заголовок недоступен"
+// @Security ApiKeyAuth
+// @Success 200 {object} events.Event "Поток событий"
+// @Failure 400 {object} response.Response "Неверный Last-Event-ID - пример: {\"status\":\"ERROR\",\"error\":\"invalid last event id\"}"
+// @Failure 500 {object} response.Response "Поток не поддерживается - пример: {\"status\":\"ERROR\",\"error\":\"streaming unsupported\"}"
+// @Router /off/stream [get]
+func New(log *slog.Logger, subscriber Subscriber, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.stream.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+
+		var lastID int64
+		if lastEventID != "" {
+			id, err := strconv.ParseInt(lastEventID, 10, 64)
+			if err != nil {
+				log.Warn("invalid last event id", slog.String("last_event_id", lastEventID), sl.Err(err))
+				render.JSON(w, r, response.Error("invalid last event id"))
+				return
+			}
+			lastID = id
+		}
+
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			log.Error("streaming unsupported", sl.Err(err))
+			render.JSON(w, r, response.Error("streaming unsupported"))
+			return
+		}
+
+		filter := events.Filter{
+			City:    city.FromContext(r.Context()).Code,
+			Types:   splitList(r.URL.Query().Get("types")),
+			Streets: splitList(r.URL.Query().Get("streets")),
+		}
+
+		sub, backlog := subscriber.Subscribe(filter, lastID)
+		defer subscriber.Unsubscribe(sub)
+
+		log.Info("client subscribed",
+			slog.Any("filter", filter),
+			slog.Int64("last_event_id", lastID),
+			slog.Int("backlog", len(backlog)))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", heartbeat.Milliseconds())
+
+		for _, e := range backlog {
+			if err := writeEvent(w, e); err != nil {
+				log.Warn("failed to write event", sl.Err(err))
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			log.Warn("failed to flush stream", sl.Err(err))
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Info("client disconnected")
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					log.Warn("failed to write heartbeat", sl.Err(err))
+					return
+				}
+			case e, ok := <-sub.C:
+				if !ok {
+					if sub.Lagged() {
+						log.Warn("client buffer overflow, closing stream")
+					}
+					return
+				}
+				if err := writeEvent(w, e); err != nil {
+					log.Warn("failed to write event", sl.Err(err))
+					return
+				}
+			}
+
+			if err := rc.Flush(); err != nil {
+				log.Warn("failed to flush stream", sl.Err(err))
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,99 @@
+package stream_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/stream"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// recorder lets http.ResponseController clear the write deadline,
+// httptest.ResponseRecorder alone does not support it
+type recorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r recorder) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func hub() *events.Hub {
+	hub := events.NewHub(10, 10, 0)
+
+	hub.Publish(events.Event{Type: "blackout.started", Time: "2024-03-10 09:00:00", Blackout: &events.Blackout{
+		ID:            "b1",
+		Type:          "hot_water",
+		StartDate:     "2024-03-10 09:00:00",
+		EndDate:       "2024-03-10 18:00:00",
+		Description:   "Плановые работы",
+		InitiatorName: "Водоканал",
+		Addresses:     []models.Address{{BuildingID: 10, Street: "Карбышева ул.", Number: "54"}},
+	}})
+	hub.Publish(events.Event{Type: "counts.changed", Time: "2024-03-10 09:00:00", Counts: map[string]int64{"hot_water": 1}})
+	hub.Publish(events.Event{Type: "blackout.started", Time: "2024-03-10 10:00:00", Blackout: &events.Blackout{
+		ID:            "b2",
+		Type:          "electricity",
+		StartDate:     "2024-03-10 10:00:00",
+		EndDate:       "2024-03-10 16:00:00",
+		Description:   "Ремонт линии",
+		InitiatorName: "Дальэнерго",
+		Addresses:     []models.Address{{BuildingID: 12, Street: "Светланская ул.", Number: "1"}},
+	}})
+
+	return hub
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		lastEventID string
+	}{
+		{"new client", "/off/stream", ""},
+		{"resume from the header", "/off/stream", "1"},
+		{"resume from the query", "/off/stream?last_event_id=2", ""},
+		{"types", "/off/stream?types=electricity&last_event_id=1", ""},
+		{"types and streets", "/off/stream?types=hot_water,electricity&streets=Светланская%20ул.", "1"},
+		{"resume from an unknown id", "/off/stream", "7"},
+		{"invalid last event id", "/off/stream", "first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlertest.Router(func(r chi.Router) {
+				r.Get("/stream", stream.New(handlertest.Log, hub(), 15*time.Second))
+			})
+
+			// the client is gone before the request is served, so the handler
+			// writes the backlog and returns instead of waiting for new events
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			r := httptest.NewRequestWithContext(ctx, http.MethodGet, tt.target, nil)
+			if tt.lastEventID != "" {
+				r.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+
+			w := recorder{httptest.NewRecorder()}
+			h.ServeHTTP(w, r)
+
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+
+	t.Run("streaming unsupported", func(t *testing.T) {
+		h := handlertest.Router(func(r chi.Router) {
+			r.Get("/stream", stream.New(handlertest.Log, hub(), 15*time.Second))
+		})
+
+		w := handlertest.Do(t, h, http.MethodGet, "/off/stream", "")
+		handlertest.Golden(t, w.Body.Bytes())
+	})
+}
@@ -0,0 +1,78 @@
+package confirm
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents the confirmed subscription
+// @Description Ответ с подтвержденной подпиской
+type Response struct {
+	response.Response
+	// Адрес подписки
+	Address string `json:"address" example:"Карбышева ул. 54"`
+	// Режим подписки: instant или digest
+	Mode string `json:"mode" example:"instant"`
+}
+
+type SubscriptionConfirmer interface {
+	ConfirmSubscription(token string, confirmedAt string) (models.Subscription, error)
+}
+
+// New godoc
+// @Summary Подтвердить подписку
+// @Description Подтверждает подписку по ссылке из письма
+// @Tags subscriptions
+// @Produce json
+// @Param token query string true "Токен из письма"
+// @Success 200 {object} Response "Подписка подтверждена"
+// @Failure 400 {object} response.Response "Отсутствует токен - пример: {\"status\":\"ERROR\",\"error\":\"token parameter is required\"}"
+// @Failure 404 {object} response.Response "Подписка не найдена - пример: {\"status\":\"ERROR\",\"error\":\"subscription not found\"}"
+// @Failure 500 {object} response.Response "Ошибка - пример: {\"status\":\"ERROR\",\"error\":\"failed to confirm subscription\"}"
+// @Router /off/subscriptions/confirm [get]
+func New(log *slog.Logger, confirmer SubscriptionConfirmer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.subscriptions.confirm.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			log.Warn("token parameter is empty")
+			render.JSON(w, r, response.Error("token parameter is required"))
+			return
+		}
+
+		sub, err := confirmer.ConfirmSubscription(token, time.Now().Format("2006-01-02 15:04:05"))
+		if errors.Is(err, storage.ErrSubscriptionNotFound) {
+			log.Info("subscription not found")
+			render.JSON(w, r, response.Error("subscription not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to confirm subscription", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to confirm subscription"))
+			return
+		}
+
+		log.Info("subscription confirmed", slog.Int64("subscription_id", sub.ID))
+
+		render.JSON(w, r, Response{
+			Response: response.Ok(),
+			Address:  sub.Street + " " + sub.Number,
+			Mode:     sub.Mode,
+		})
+	}
+}
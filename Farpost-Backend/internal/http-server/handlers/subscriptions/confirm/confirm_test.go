@@ -0,0 +1,75 @@
+package confirm_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/subscriptions/confirm"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type confirmer struct{}
+
+func (confirmer) ConfirmSubscription(token string, confirmedAt string) (models.Subscription, error) {
+	if token != "pending" {
+		return models.Subscription{}, storage.ErrSubscriptionNotFound
+	}
+	return models.Subscription{ID: 2, Street: "Карбышева ул.", Number: "56", Mode: models.SubscriptionDigest, ConfirmedAt: confirmedAt}, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   confirm.Response
+	}{
+		{"pending", "/off/subscriptions/confirm?token=pending", confirm.Response{Response: response.Ok(), Address: "Карбышева ул. 56", Mode: models.SubscriptionDigest}},
+		{"unknown token", "/off/subscriptions/confirm?token=unknown", confirm.Response{Response: response.Error("subscription not found")}},
+		{"no token", "/off/subscriptions/confirm", confirm.Response{Response: response.Error("token parameter is required")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			confirm.New(slog.New(slog.DiscardHandler), confirmer{}).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got confirm.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"pending", "/off/subscriptions/confirm?token=pending"},
+		{"confirmed again", "/off/subscriptions/confirm?token=confirmed"},
+		{"unknown token", "/off/subscriptions/confirm?token=unknown"},
+		{"missing token", "/off/subscriptions/confirm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlertest.Router(func(r chi.Router) {
+				r.Get("/subscriptions/confirm", confirm.New(handlertest.Log, handlertest.Subscribed(t)))
+			})
+
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
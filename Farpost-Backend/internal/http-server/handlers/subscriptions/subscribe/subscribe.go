@@ -0,0 +1,137 @@
+package subscribe
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"time"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Request represents a resident subscription request
+// @Description Запрос на подписку адреса на уведомления об отключениях
+type Request struct {
+	// Адрес электронной почты
+	Email string `json:"email" example:"resident@example.com"`
+	// Улица, как её возвращает /off/search
+	Street string `json:"street" example:"Карбышева ул."`
+	// Номер дома
+	Number string `json:"number" example:"54"`
+	// Режим: instant (письмо о каждом отключении) или digest (ежедневная сводка)
+	Mode string `json:"mode" example:"instant"`
+}
+
+type SubscriptionSaver interface {
+	FindBuilding(street string, number string, filter models.Filter) (models.Address, error)
+	SaveSubscription(sub models.Subscription) (int64, error)
+}
+
+type ConfirmationSender interface {
+	SendConfirmation(sub models.Subscription) error
+}
+
+// New godoc
+// @Summary Подписаться на уведомления об отключениях
+// @Description Создает подписку адреса на письма об отключениях и отправляет письмо со ссылкой для подтверждения. Подписка начинает работать только после подтверждения
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body Request true "Параметры подписки"
+// @Success 200 {object} response.Response "Письмо для подтверждения отправлено"
+// @Failure 400 {object} response.Response "Неверный запрос - пример: {\"status\":\"ERROR\",\"error\":\"invalid email\"}"
+// @Failure 404 {object} response.Response "Адрес не найден - пример: {\"status\":\"ERROR\",\"error\":\"building not found\"}"
+// @Failure 409 {object} response.Response "Подписка уже существует - пример: {\"status\":\"ERROR\",\"error\":\"already subscribed\"}"
+// @Failure 500 {object} response.Response "Ошибка - пример: {\"status\":\"ERROR\",\"error\":\"failed to subscribe\"}"
+// @Router /off/subscriptions [post]
+func New(log *slog.Logger, saver SubscriptionSaver, sender ConfirmationSender, newToken func() (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.subscriptions.subscribe.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode req body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode req"))
+			return
+		}
+
+		email, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			log.Warn("invalid email", sl.Err(err))
+			render.JSON(w, r, response.Error("invalid email"))
+			return
+		}
+
+		if req.Mode == "" {
+			req.Mode = models.SubscriptionInstant
+		}
+		if req.Mode != models.SubscriptionInstant && req.Mode != models.SubscriptionDigest {
+			log.Warn("invalid mode", slog.String("mode", req.Mode))
+			render.JSON(w, r, response.Error("invalid mode, use: instant, digest"))
+			return
+		}
+
+		address, err := saver.FindBuilding(req.Street, req.Number, models.Filter{City: city.FromContext(r.Context()).Code})
+		if errors.Is(err, storage.ErrBuildingNotFound) {
+			log.Info("building not found", slog.String("street", req.Street), slog.String("number", req.Number))
+			render.JSON(w, r, response.Error("building not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to find building", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to subscribe"))
+			return
+		}
+
+		token, err := newToken()
+		if err != nil {
+			log.Error("failed to generate token", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to subscribe"))
+			return
+		}
+
+		sub := models.Subscription{
+			Email:      email.Address,
+			BuildingID: address.BuildingID,
+			Street:     address.Street,
+			Number:     address.Number,
+			Mode:       req.Mode,
+			Token:      token,
+			CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+		}
+
+		sub.ID, err = saver.SaveSubscription(sub)
+		if errors.Is(err, storage.ErrSubscriptionExists) {
+			log.Info("subscription already exists", slog.Int64("building_id", address.BuildingID))
+			render.JSON(w, r, response.Error("already subscribed"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save subscription", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to subscribe"))
+			return
+		}
+
+		if err := sender.SendConfirmation(sub); err != nil {
+			log.Error("failed to send confirmation", slog.Int64("subscription_id", sub.ID), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to send confirmation email"))
+			return
+		}
+
+		log.Info("subscription created", slog.Int64("subscription_id", sub.ID))
+
+		render.JSON(w, r, response.Ok())
+	}
+}
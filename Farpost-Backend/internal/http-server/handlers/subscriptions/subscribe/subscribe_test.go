@@ -0,0 +1,187 @@
+package subscribe_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/subscriptions/subscribe"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/mailer"
+	"vlru-prsch/internal/mailer/smtptest"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/subscriptions"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var log = slog.New(slog.DiscardHandler)
+
+// saver knows building 10 at Карбышева ул. 54, where a@example.com is already subscribed
+type saver struct {
+	saved []models.Subscription
+}
+
+func (s *saver) FindBuilding(street string, number string, filter models.Filter) (models.Address, error) {
+	if street != "Карбышева ул." || number != "54" {
+		return models.Address{}, storage.ErrBuildingNotFound
+	}
+	return models.Address{BuildingID: 10, Street: street, Number: number}, nil
+}
+
+func (s *saver) SaveSubscription(sub models.Subscription) (int64, error) {
+	if sub.Email == "a@example.com" {
+		return 0, storage.ErrSubscriptionExists
+	}
+	s.saved = append(s.saved, sub)
+	return int64(len(s.saved)), nil
+}
+
+// notifier sends the confirmations through the stand-in SMTP server
+func notifier(t *testing.T, server *smtptest.Server) *subscriptions.Notifier {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(server.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := mailer.NewSMTP(host, portNumber, "", "", "off@example.com")
+	return subscriptions.New(log, nil, sender, models.DefaultServiceTypes(), "https://off.example.com", 8*time.Hour, nil)
+}
+
+func TestNew(t *testing.T) {
+	token := func() (string, error) { return "token", nil }
+
+	tests := []struct {
+		name     string
+		body     string
+		reject   []string
+		newToken func() (string, error)
+		want     response.Response
+		wantMode string
+	}{
+		{"instant by default", `{"email":"b@example.com","street":"Карбышева ул.","number":"54"}`, nil, token, response.Ok(), models.SubscriptionInstant},
+		{"digest", `{"email":"B <b@example.com>","street":"Карбышева ул.","number":"54","mode":"digest"}`, nil, token, response.Ok(), models.SubscriptionDigest},
+		{"already subscribed", `{"email":"a@example.com","street":"Карбышева ул.","number":"54"}`, nil, token, response.Error("already subscribed"), ""},
+		{"unknown building", `{"email":"b@example.com","street":"Карбышева ул.","number":"1000"}`, nil, token, response.Error("building not found"), ""},
+		{"invalid email", `{"email":"b","street":"Карбышева ул.","number":"54"}`, nil, token, response.Error("invalid email"), ""},
+		{"invalid mode", `{"email":"b@example.com","street":"Карбышева ул.","number":"54","mode":"weekly"}`, nil, token, response.Error("invalid mode, use: instant, digest"), ""},
+		{"invalid body", `{"email":`, nil, token, response.Error("failed to decode req"), ""},
+		{"token failed", `{"email":"b@example.com","street":"Карбышева ул.","number":"54"}`, nil,
+			func() (string, error) { return "", errors.New("no entropy") }, response.Error("failed to subscribe"), ""},
+		{"mailbox rejected", `{"email":"b@example.com","street":"Карбышева ул.","number":"54"}`, []string{"b@example.com"}, token,
+			response.Error("failed to send confirmation email"), models.SubscriptionInstant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := smtptest.NewServer(t, tt.reject...)
+			s := &saver{}
+			h := subscribe.New(log, s, notifier(t, server), tt.newToken)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/off/subscriptions", strings.NewReader(tt.body)))
+
+			var got response.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+
+			if tt.wantMode == "" {
+				if len(s.saved) > 0 {
+					t.Errorf("saved %+v", s.saved)
+				}
+				return
+			}
+
+			if len(s.saved) != 1 {
+				t.Fatalf("saved %d subscriptions, want 1", len(s.saved))
+			}
+			if sub := s.saved[0]; sub.Email != "b@example.com" || sub.BuildingID != 10 || sub.Mode != tt.wantMode || sub.Token != "token" || sub.ConfirmedAt != "" {
+				t.Errorf("saved %+v", sub)
+			}
+
+			messages := server.Messages()
+			if tt.want.Status != "OK" {
+				if len(messages) != 0 {
+					t.Errorf("got %d emails, want none", len(messages))
+				}
+				return
+			}
+			if len(messages) != 1 || len(messages[0].To) != 1 || messages[0].To[0] != "b@example.com" {
+				t.Fatalf("got emails %+v, want a confirmation to b@example.com", messages)
+			}
+		})
+	}
+}
+
+type sender struct {
+	err  error
+	sent []models.Subscription
+}
+
+func (s *sender) SendConfirmation(sub models.Subscription) error {
+	s.sent = append(s.sent, sub)
+	return s.err
+}
+
+func token() (string, error) {
+	return "token", nil
+}
+
+func TestNewGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		sendErr  error
+		wantSent int
+	}{
+		{"instant", `{"email":"Resident <c@example.com>","street":"Карбышева ул.","number":"54"}`, nil, 1},
+		{"digest", `{"email":"c@example.com","street":"Светланская ул.","number":"1","mode":"digest"}`, nil, 1},
+		{"pending again", `{"email":"b@example.com","street":"Карбышева ул.","number":"56","mode":"instant"}`, nil, 1},
+		{"already subscribed", `{"email":"a@example.com","street":"Карбышева ул.","number":"54"}`, nil, 0},
+		{"fake building", `{"email":"c@example.com","street":"Светланская ул.","number":""}`, nil, 0},
+		{"unknown building", `{"email":"c@example.com","street":"Карбышева ул.","number":"100"}`, nil, 0},
+		{"invalid email", `{"email":"resident","street":"Карбышева ул.","number":"54"}`, nil, 0},
+		{"invalid mode", `{"email":"c@example.com","street":"Карбышева ул.","number":"54","mode":"weekly"}`, nil, 0},
+		{"invalid body", `{"email":`, nil, 0},
+		{"send failed", `{"email":"c@example.com","street":"Карбышева ул.","number":"54"}`, errors.New("smtp is down"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &sender{err: tt.sendErr}
+			h := handlertest.Router(func(r chi.Router) {
+				r.Post("/subscriptions", subscribe.New(handlertest.Log, handlertest.Subscribed(t), sender, token))
+			})
+
+			w := handlertest.Do(t, h, http.MethodPost, "/off/subscriptions", tt.body)
+			handlertest.Golden(t, w.Body.Bytes())
+
+			if len(sender.sent) != tt.wantSent {
+				t.Fatalf("sent %d confirmations, want %d", len(sender.sent), tt.wantSent)
+			}
+			for _, sub := range sender.sent {
+				if sub.Token != "token" || sub.ID == 0 {
+					t.Errorf("sent %+v, want a saved subscription with the new token", sub)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package unsubscribe
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type SubscriptionDeleter interface {
+	DeleteSubscription(token string) error
+}
+
+// New godoc
+// @Summary Отписаться от уведомлений
+// @Description Удаляет подписку по ссылке из письма
+// @Tags subscriptions
+// @Produce json
+// @Param token query string true "Токен из письма"
+// @Success 200 {object} response.Response "Подписка удалена"
+// @Failure 400 {object} response.Response "Отсутствует токен - пример: {\"status\":\"ERROR\",\"error\":\"token parameter is required\"}"
+// @Failure 404 {object} response.Response "Подписка не найдена - пример: {\"status\":\"ERROR\",\"error\":\"subscription not found\"}"
+// @Failure 500 {object} response.Response "Ошибка - пример: {\"status\":\"ERROR\",\"error\":\"failed to unsubscribe\"}"
+// @Router /off/subscriptions/unsubscribe [get]
+func New(log *slog.Logger, deleter SubscriptionDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.subscriptions.unsubscribe.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			log.Warn("token parameter is empty")
+			render.JSON(w, r, response.Error("token parameter is required"))
+			return
+		}
+
+		err := deleter.DeleteSubscription(token)
+		if errors.Is(err, storage.ErrSubscriptionNotFound) {
+			log.Info("subscription not found")
+			render.JSON(w, r, response.Error("subscription not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete subscription", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to unsubscribe"))
+			return
+		}
+
+		log.Info("subscription deleted")
+
+		render.JSON(w, r, response.Ok())
+	}
+}
@@ -0,0 +1,83 @@
+package unsubscribe_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/subscriptions/unsubscribe"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type deleter struct {
+	deleted []string
+}
+
+func (d *deleter) DeleteSubscription(token string) error {
+	if token != "confirmed" {
+		return storage.ErrSubscriptionNotFound
+	}
+	d.deleted = append(d.deleted, token)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		want        response.Response
+		wantDeleted int
+	}{
+		{"confirmed", "/off/subscriptions/unsubscribe?token=confirmed", response.Ok(), 1},
+		{"unknown token", "/off/subscriptions/unsubscribe?token=unknown", response.Error("subscription not found"), 0},
+		{"no token", "/off/subscriptions/unsubscribe", response.Error("token parameter is required"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &deleter{}
+
+			w := httptest.NewRecorder()
+			unsubscribe.New(slog.New(slog.DiscardHandler), d).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got response.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if len(d.deleted) != tt.wantDeleted {
+				t.Errorf("deleted %v, want %d subscriptions", d.deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"confirmed", "/off/subscriptions/unsubscribe?token=confirmed"},
+		{"pending", "/off/subscriptions/unsubscribe?token=pending"},
+		{"unknown token", "/off/subscriptions/unsubscribe?token=unknown"},
+		{"missing token", "/off/subscriptions/unsubscribe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlertest.Router(func(r chi.Router) {
+				r.Get("/subscriptions/unsubscribe", unsubscribe.New(handlertest.Log, handlertest.Subscribed(t)))
+			})
+
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
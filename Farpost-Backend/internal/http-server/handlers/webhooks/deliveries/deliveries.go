@@ -0,0 +1,101 @@
+package deliveries
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// Response represents the delivery log of a webhook
+// @Description Журнал доставок подписки, новые записи первыми
+type Response struct {
+	response.Response
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+type DeliveriesGiver interface {
+	GetWebhook(id int64, filter models.Filter) (models.Webhook, error)
+	GetWebhookDeliveries(webhookID int64, limit int) ([]models.WebhookDelivery, error)
+}
+
+// New godoc
+// @Summary Получить журнал доставок подписки
+// @Description Возвращает последние доставки событий для подписки: статус, число попыток, код ответа и ошибку
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Идентификатор подписки"
+// @Param limit query int false "Количество записей, по умолчанию 50, максимум 500" example(50)
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Журнал доставок"
+// @Failure 400 {object} response.Response "Неверный идентификатор - пример: {\"status\":\"ERROR\",\"error\":\"invalid id\"}"
+// @Failure 401 {object} response.Response "Нет доступа - пример: {\"status\":\"ERROR\",\"error\":\"unauthorized\"}"
+// @Failure 404 {object} response.Response "Подписка не найдена - пример: {\"status\":\"ERROR\",\"error\":\"webhook not found\"}"
+// @Failure 500 {object} response.Response "Ошибка получения - пример: {\"status\":\"ERROR\",\"error\":\"failed to get deliveries\"}"
+// @Router /off/webhooks/{id}/deliveries [get]
+func New(log *slog.Logger, giver DeliveriesGiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhooks.deliveries.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Warn("invalid id", slog.String("id", chi.URLParam(r, "id")))
+			render.JSON(w, r, response.Error("invalid id"))
+			return
+		}
+
+		limit := defaultLimit
+		if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+			limit, err = strconv.Atoi(rawLimit)
+			if err != nil || limit <= 0 {
+				log.Warn("invalid limit", slog.String("limit", rawLimit))
+				render.JSON(w, r, response.Error("invalid limit"))
+				return
+			}
+			limit = min(limit, maxLimit)
+		}
+
+		_, err = giver.GetWebhook(id, models.Filter{City: city.FromContext(r.Context()).Code})
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("id", id))
+			render.JSON(w, r, response.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get webhook", slog.Int64("id", id), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get deliveries"))
+			return
+		}
+
+		deliveries, err := giver.GetWebhookDeliveries(id, limit)
+		if err != nil {
+			log.Error("failed to get deliveries", slog.Int64("id", id), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get deliveries"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:   response.Ok(),
+			Deliveries: deliveries,
+		})
+	}
+}
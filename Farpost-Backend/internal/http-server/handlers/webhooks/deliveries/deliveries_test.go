@@ -0,0 +1,109 @@
+package deliveries_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/webhooks/deliveries"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// cities of the requests, webhook 1 belongs to the default one
+var cities = []models.City{{Code: "vladivostok", IsDefault: true}, {Code: "khabarovsk"}}
+
+// giver knows webhook 1 with two deliveries and records the requested limit
+type giver struct {
+	limit int
+}
+
+func (g *giver) GetWebhook(id int64, filter models.Filter) (models.Webhook, error) {
+	if id != 1 || filter.City != "vladivostok" {
+		return models.Webhook{}, storage.ErrWebhookNotFound
+	}
+	return models.Webhook{ID: 1}, nil
+}
+
+func (g *giver) GetWebhookDeliveries(webhookID int64, limit int) ([]models.WebhookDelivery, error) {
+	g.limit = limit
+	return []models.WebhookDelivery{
+		{ID: 2, WebhookID: 1, EventType: "blackout.ended", Status: models.DeliveryPending},
+		{ID: 1, WebhookID: 1, EventType: "blackout.started", Status: models.DeliverySucceeded},
+	}, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantError string
+		wantLimit int
+		wantIDs   []int64
+	}{
+		{"default limit", "/off/webhooks/1/deliveries", "", 50, []int64{2, 1}},
+		{"limit", "/off/webhooks/1/deliveries?limit=1", "", 1, []int64{2, 1}},
+		{"limit over the maximum", "/off/webhooks/1/deliveries?limit=10000", "", 500, []int64{2, 1}},
+		{"invalid limit", "/off/webhooks/1/deliveries?limit=0", "invalid limit", 0, nil},
+		{"unknown webhook", "/off/webhooks/9/deliveries", "webhook not found", 0, nil},
+		{"webhook of another city", "/khabarovsk/off/webhooks/1/deliveries", "webhook not found", 0, nil},
+		{"invalid id", "/off/webhooks/one/deliveries", "invalid id", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{}
+			router := chi.NewRouter()
+			for _, prefix := range []string{"", "/{city}"} {
+				router.With(city.New(slog.New(slog.DiscardHandler), cities)).
+					Get(prefix+"/off/webhooks/{id}/deliveries", deliveries.New(slog.New(slog.DiscardHandler), g))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			var got deliveries.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Error != tt.wantError || g.limit != tt.wantLimit || len(got.Deliveries) != len(tt.wantIDs) {
+				t.Fatalf("got %+v with limit %d", got, g.limit)
+			}
+			for i, delivery := range got.Deliveries {
+				if delivery.ID != tt.wantIDs[i] {
+					t.Errorf("got deliveries %+v, want ids %v", got.Deliveries, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	h := handlertest.Router(func(r chi.Router) {
+		r.Get("/webhooks/{id}/deliveries", deliveries.New(handlertest.Log, handlertest.Webhooks(t)))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"webhook", "/off/webhooks/1/deliveries"},
+		{"limit", "/off/webhooks/1/deliveries?limit=1"},
+		{"other webhook", "/off/webhooks/2/deliveries"},
+		{"unknown webhook", "/off/webhooks/3/deliveries"},
+		{"invalid id", "/off/webhooks/one/deliveries"},
+		{"invalid limit", "/off/webhooks/1/deliveries?limit=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodGet, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
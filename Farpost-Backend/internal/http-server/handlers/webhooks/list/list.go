@@ -0,0 +1,57 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents the list of webhook subscriptions
+// @Description Список подписок на события
+type Response struct {
+	response.Response
+	Webhooks []models.Webhook `json:"webhooks"`
+}
+
+type WebhooksGiver interface {
+	GetWebhooks(filter models.Filter) ([]models.Webhook, error)
+}
+
+// New godoc
+// @Summary Получить список подписок на события
+// @Description Возвращает все зарегистрированные подписки города без секретов
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Список подписок"
+// @Failure 401 {object} response.Response "Нет доступа - пример: {\"status\":\"ERROR\",\"error\":\"unauthorized\"}"
+// @Failure 500 {object} response.Response "Ошибка получения - пример: {\"status\":\"ERROR\",\"error\":\"failed to get webhooks\"}"
+// @Router /off/webhooks [get]
+func New(log *slog.Logger, giver WebhooksGiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhooks.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		webhooks, err := giver.GetWebhooks(models.Filter{City: city.FromContext(r.Context()).Code})
+		if err != nil {
+			log.Error("failed to get webhooks", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get webhooks"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.Ok(),
+			Webhooks: webhooks,
+		})
+	}
+}
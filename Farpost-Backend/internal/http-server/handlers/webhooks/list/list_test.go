@@ -0,0 +1,108 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/webhooks/list"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type giver struct {
+	webhooks []models.Webhook
+	err      error
+}
+
+func (g giver) GetWebhooks(filter models.Filter) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	for _, webhook := range g.webhooks {
+		if webhook.City == filter.City {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, g.err
+}
+
+func TestNew(t *testing.T) {
+	webhooks := []models.Webhook{
+		{ID: 1, URL: "https://example.com/a", Secret: "a", City: "vladivostok", CreatedAt: "2024-03-10 12:00:00"},
+		{ID: 2, URL: "https://example.com/b", Secret: "b", City: "vladivostok", Types: []string{"electricity"}, CreatedAt: "2024-03-10 12:00:00"},
+		{ID: 3, URL: "https://example.com/c", Secret: "c", City: "khabarovsk", CreatedAt: "2024-03-10 12:00:00"},
+	}
+	cities := []models.City{{Code: "vladivostok", IsDefault: true}, {Code: "khabarovsk"}}
+
+	tests := []struct {
+		name    string
+		giver   giver
+		city    string
+		want    string
+		wantIn  []string
+		wantOut []string
+	}{
+		{"secrets are left out", giver{webhooks: webhooks}, "", "OK", []string{`"id":1`, `"id":2`, `"types":["electricity"]`}, []string{`"id":3`}},
+		{"webhooks of the city", giver{webhooks: webhooks}, "khabarovsk", "OK", []string{`"id":3`}, []string{`"id":1`, `"id":2`}},
+		{"storage failed", giver{err: errors.New("disk I/O error")}, "", "ERROR", []string{`"error":"failed to get webhooks"`}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/off/webhooks", nil)
+			r.Header.Set(city.Header, tt.city)
+
+			w := httptest.NewRecorder()
+			city.New(slog.New(slog.DiscardHandler), cities)(list.New(slog.New(slog.DiscardHandler), tt.giver)).ServeHTTP(w, r)
+
+			var got list.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("got status %q, want %q", got.Status, tt.want)
+			}
+
+			body := w.Body.String()
+			for _, want := range tt.wantIn {
+				if !strings.Contains(body, want) {
+					t.Errorf("%s is missing from %s", want, body)
+				}
+			}
+			for _, unwanted := range tt.wantOut {
+				if strings.Contains(body, unwanted) {
+					t.Errorf("%s is in %s", unwanted, body)
+				}
+			}
+			if strings.Contains(body, "secret") {
+				t.Errorf("secret leaked in %s", body)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		h    http.Handler
+	}{
+		{"webhooks", handlertest.Router(func(r chi.Router) {
+			r.Get("/webhooks", list.New(handlertest.Log, handlertest.Webhooks(t)))
+		})},
+		{"none", handlertest.Router(func(r chi.Router) {
+			r.Get("/webhooks", list.New(handlertest.Log, handlertest.Storage(t)))
+		})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, tt.h, http.MethodGet, "/off/webhooks", "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package redeliver
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents the queued redelivery
+// @Description Ответ с идентификатором новой доставки
+type Response struct {
+	response.Response
+	// Идентификатор новой доставки
+	DeliveryID int64 `json:"delivery_id" example:"11"`
+}
+
+type DeliveryRequeuer interface {
+	GetWebhook(id int64, filter models.Filter) (models.Webhook, error)
+	GetWebhookDelivery(id int64) (models.WebhookDelivery, error)
+	SaveWebhookDelivery(delivery models.WebhookDelivery) (int64, error)
+}
+
+// New godoc
+// @Summary Повторно отправить событие
+// @Description Ставит в очередь новую доставку с тем же телом, что и у указанной. Исходная запись журнала не меняется
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Идентификатор подписки"
+// @Param delivery_id path int true "Идентификатор доставки"
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Доставка поставлена в очередь"
+// @Failure 400 {object} response.Response "Неверный идентификатор - пример: {\"status\":\"ERROR\",\"error\":\"invalid id\"}"
+// @Failure 401 {object} response.Response "Нет доступа - пример: {\"status\":\"ERROR\",\"error\":\"unauthorized\"}"
+// @Failure 404 {object} response.Response "Доставка не найдена - пример: {\"status\":\"ERROR\",\"error\":\"delivery not found\"}"
+// @Failure 500 {object} response.Response "Ошибка постановки в очередь - пример: {\"status\":\"ERROR\",\"error\":\"failed to redeliver\"}"
+// @Router /off/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func New(log *slog.Logger, requeuer DeliveryRequeuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhooks.redeliver.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		webhookID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Warn("invalid id", slog.String("id", chi.URLParam(r, "id")))
+			render.JSON(w, r, response.Error("invalid id"))
+			return
+		}
+
+		deliveryID, err := strconv.ParseInt(chi.URLParam(r, "delivery_id"), 10, 64)
+		if err != nil {
+			log.Warn("invalid delivery id", slog.String("delivery_id", chi.URLParam(r, "delivery_id")))
+			render.JSON(w, r, response.Error("invalid id"))
+			return
+		}
+
+		// a webhook of another city has no deliveries here
+		_, err = requeuer.GetWebhook(webhookID, models.Filter{City: city.FromContext(r.Context()).Code})
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("webhook_id", webhookID))
+			render.JSON(w, r, response.Error("delivery not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get webhook", slog.Int64("webhook_id", webhookID), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to redeliver"))
+			return
+		}
+
+		delivery, err := requeuer.GetWebhookDelivery(deliveryID)
+		if errors.Is(err, storage.ErrDeliveryNotFound) || (err == nil && delivery.WebhookID != webhookID) {
+			log.Info("delivery not found", slog.Int64("webhook_id", webhookID), slog.Int64("delivery_id", deliveryID))
+			render.JSON(w, r, response.Error("delivery not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get delivery", slog.Int64("delivery_id", deliveryID), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to redeliver"))
+			return
+		}
+
+		now := time.Now().Format("2006-01-02 15:04:05")
+
+		id, err := requeuer.SaveWebhookDelivery(models.WebhookDelivery{
+			WebhookID:     delivery.WebhookID,
+			EventType:     delivery.EventType,
+			Payload:       delivery.Payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			log.Error("failed to save delivery", slog.Int64("delivery_id", deliveryID), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to redeliver"))
+			return
+		}
+
+		log.Info("delivery requeued", slog.Int64("delivery_id", deliveryID), slog.Int64("new_delivery_id", id))
+
+		render.JSON(w, r, Response{
+			Response:   response.Ok(),
+			DeliveryID: id,
+		})
+	}
+}
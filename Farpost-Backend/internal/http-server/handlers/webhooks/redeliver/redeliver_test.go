@@ -0,0 +1,143 @@
+package redeliver_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/webhooks/redeliver"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// cities of the requests, webhook 1 belongs to the default one
+var cities = []models.City{{Code: "vladivostok", IsDefault: true}, {Code: "khabarovsk"}}
+
+// requeuer knows delivery 1 of webhook 1 and keeps the saved ones
+type requeuer struct {
+	saved []models.WebhookDelivery
+}
+
+func (r *requeuer) GetWebhook(id int64, filter models.Filter) (models.Webhook, error) {
+	if id != 1 || filter.City != "vladivostok" {
+		return models.Webhook{}, storage.ErrWebhookNotFound
+	}
+	return models.Webhook{ID: 1, City: "vladivostok"}, nil
+}
+
+func (r *requeuer) GetWebhookDelivery(id int64) (models.WebhookDelivery, error) {
+	if id != 1 {
+		return models.WebhookDelivery{}, storage.ErrDeliveryNotFound
+	}
+	return models.WebhookDelivery{
+		ID:             1,
+		WebhookID:      1,
+		EventType:      "blackout.started",
+		Payload:        `{"id":"b1"}`,
+		Status:         models.DeliveryFailed,
+		Attempts:       5,
+		LastStatusCode: 500,
+		LastError:      "unexpected status code 500",
+	}, nil
+}
+
+func (r *requeuer) SaveWebhookDelivery(delivery models.WebhookDelivery) (int64, error) {
+	r.saved = append(r.saved, delivery)
+	return int64(len(r.saved) + 1), nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   redeliver.Response
+	}{
+		{"failed delivery", "/off/webhooks/1/deliveries/1/redeliver", redeliver.Response{Response: response.Ok(), DeliveryID: 2}},
+		{"other webhook", "/off/webhooks/2/deliveries/1/redeliver", redeliver.Response{Response: response.Error("delivery not found")}},
+		{"unknown delivery", "/off/webhooks/1/deliveries/9/redeliver", redeliver.Response{Response: response.Error("delivery not found")}},
+		{"webhook of another city", "/khabarovsk/off/webhooks/1/deliveries/1/redeliver", redeliver.Response{Response: response.Error("delivery not found")}},
+		{"invalid id", "/off/webhooks/one/deliveries/1/redeliver", redeliver.Response{Response: response.Error("invalid id")}},
+		{"invalid delivery id", "/off/webhooks/1/deliveries/one/redeliver", redeliver.Response{Response: response.Error("invalid id")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := &requeuer{}
+			router := chi.NewRouter()
+			for _, prefix := range []string{"", "/{city}"} {
+				router.With(city.New(slog.New(slog.DiscardHandler), cities)).
+					Post(prefix+"/off/webhooks/{id}/deliveries/{delivery_id}/redeliver", redeliver.New(slog.New(slog.DiscardHandler), rq))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", tt.target, nil))
+
+			var got redeliver.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+
+			if tt.want.DeliveryID == 0 {
+				if len(rq.saved) > 0 {
+					t.Errorf("requeued %+v", rq.saved)
+				}
+				return
+			}
+
+			// the copy starts over: pending, no attempts and no errors of the original
+			saved := rq.saved[0]
+			if saved.WebhookID != 1 || saved.EventType != "blackout.started" || saved.Payload != `{"id":"b1"}` ||
+				saved.Status != models.DeliveryPending || saved.Attempts != 0 || saved.LastError != "" || saved.NextAttemptAt == "" {
+				t.Errorf("requeued %+v", saved)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   *models.WebhookDelivery
+	}{
+		{"delivered", "/off/webhooks/1/deliveries/1/redeliver",
+			&models.WebhookDelivery{ID: 4, WebhookID: 1, EventType: "blackout.started", Payload: `{"id":"b1"}`, Status: models.DeliveryPending}},
+		{"other webhook", "/off/webhooks/2/deliveries/1/redeliver", nil},
+		{"unknown delivery", "/off/webhooks/1/deliveries/9/redeliver", nil},
+		{"invalid id", "/off/webhooks/one/deliveries/1/redeliver", nil},
+		{"invalid delivery id", "/off/webhooks/1/deliveries/one/redeliver", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := handlertest.Webhooks(t)
+			h := handlertest.Router(func(r chi.Router) {
+				r.Post("/webhooks/{id}/deliveries/{delivery_id}/redeliver", redeliver.New(handlertest.Log, s))
+			})
+
+			w := handlertest.Do(t, h, http.MethodPost, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+
+			if tt.want == nil {
+				return
+			}
+
+			got, err := s.GetWebhookDelivery(tt.want.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.WebhookID != tt.want.WebhookID || got.EventType != tt.want.EventType || got.Payload != tt.want.Payload || got.Status != tt.want.Status || got.Attempts != 0 {
+				t.Errorf("requeued %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
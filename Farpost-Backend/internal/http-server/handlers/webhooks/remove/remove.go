@@ -0,0 +1,68 @@
+package remove
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type WebhookDeleter interface {
+	DeleteWebhook(id int64, filter models.Filter) error
+}
+
+// New godoc
+// @Summary Удалить подписку на события
+// @Description Удаляет подписку вместе с журналом её доставок
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Идентификатор подписки"
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response "Подписка удалена"
+// @Failure 400 {object} response.Response "Неверный идентификатор - пример: {\"status\":\"ERROR\",\"error\":\"invalid id\"}"
+// @Failure 401 {object} response.Response "Нет доступа - пример: {\"status\":\"ERROR\",\"error\":\"unauthorized\"}"
+// @Failure 404 {object} response.Response "Подписка не найдена - пример: {\"status\":\"ERROR\",\"error\":\"webhook not found\"}"
+// @Failure 500 {object} response.Response "Ошибка удаления - пример: {\"status\":\"ERROR\",\"error\":\"failed to delete webhook\"}"
+// @Router /off/webhooks/{id} [delete]
+func New(log *slog.Logger, deleter WebhookDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhooks.remove.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Warn("invalid id", slog.String("id", chi.URLParam(r, "id")))
+			render.JSON(w, r, response.Error("invalid id"))
+			return
+		}
+
+		err = deleter.DeleteWebhook(id, models.Filter{City: city.FromContext(r.Context()).Code})
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("id", id))
+			render.JSON(w, r, response.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete webhook", slog.Int64("id", id), sl.Err(err))
+			render.JSON(w, r, response.Error("failed to delete webhook"))
+			return
+		}
+
+		log.Info("webhook deleted", slog.Int64("id", id))
+
+		render.JSON(w, r, response.Ok())
+	}
+}
@@ -0,0 +1,93 @@
+package remove_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/webhooks/remove"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// cities of the requests, webhook 1 belongs to the default one
+var cities = []models.City{{Code: "vladivostok", IsDefault: true}, {Code: "khabarovsk"}}
+
+type deleter struct {
+	deleted []int64
+}
+
+func (d *deleter) DeleteWebhook(id int64, filter models.Filter) error {
+	if id != 1 || filter.City != "vladivostok" {
+		return storage.ErrWebhookNotFound
+	}
+	d.deleted = append(d.deleted, id)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		want        response.Response
+		wantDeleted int
+	}{
+		{"deleted", "/off/webhooks/1", response.Ok(), 1},
+		{"unknown webhook", "/off/webhooks/9", response.Error("webhook not found"), 0},
+		{"webhook of another city", "/khabarovsk/off/webhooks/1", response.Error("webhook not found"), 0},
+		{"invalid id", "/off/webhooks/one", response.Error("invalid id"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &deleter{}
+			router := chi.NewRouter()
+			for _, prefix := range []string{"", "/{city}"} {
+				router.With(city.New(slog.New(slog.DiscardHandler), cities)).
+					Delete(prefix+"/off/webhooks/{id}", remove.New(slog.New(slog.DiscardHandler), d))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("DELETE", tt.target, nil))
+
+			var got response.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if len(d.deleted) != tt.wantDeleted {
+				t.Errorf("deleted %v, want %d webhooks", d.deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"existing", "/off/webhooks/2"},
+		{"unknown", "/off/webhooks/3"},
+		{"invalid id", "/off/webhooks/two"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlertest.Router(func(r chi.Router) {
+				r.Delete("/webhooks/{id}", remove.New(handlertest.Log, handlertest.Webhooks(t)))
+			})
+
+			w := handlertest.Do(t, h, http.MethodDelete, tt.target, "")
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
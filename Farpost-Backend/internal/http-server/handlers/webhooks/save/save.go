@@ -0,0 +1,127 @@
+package save
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Request represents a webhook subscription request
+// @Description Запрос на создание подписки на события
+type Request struct {
+	// Адрес получателя, http или https
+	URL string `json:"url" example:"https://example.com/hooks/off"`
+	// Секрет для подписи, если не задан - будет сгенерирован
+	Secret string `json:"secret,omitempty" example:"s3cr3t"`
+	// Фильтр по идентификаторам зданий
+	Buildings []int64 `json:"buildings,omitempty" example:"1024,1025"`
+	// Фильтр по улицам
+	Streets []string `json:"streets,omitempty" example:"Карбышева ул."`
+	// Фильтр по типам отключений: hot_water, cold_water, electricity, heat
+	Types []string `json:"types,omitempty" example:"hot_water,electricity"`
+	// Фильтр по организациям-инициаторам
+	Organizations []string `json:"organizations,omitempty" example:"КГУП Приморский водоканал"`
+}
+
+// Response represents the created webhook subscription
+// @Description Ответ с созданной подпиской. Секрет возвращается только один раз
+type Response struct {
+	response.Response
+	// Идентификатор подписки
+	ID int64 `json:"id" example:"1"`
+	// Секрет для проверки подписи X-Off-Signature
+	Secret string `json:"secret" example:"s3cr3t"`
+}
+
+type WebhookSaver interface {
+	SaveWebhook(webhook models.Webhook) (int64, error)
+}
+
+// New godoc
+// @Summary Создать подписку на события
+// @Description Регистрирует адрес, на который будут отправляться события об отключениях в городе запроса. Запросы подписываются HMAC-SHA256 в заголовке X-Off-Signature
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body Request true "Параметры подписки"
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Подписка создана"
+// @Failure 400 {object} response.Response "Неверный запрос - пример: {\"status\":\"ERROR\",\"error\":\"invalid url\"}"
+// @Failure 401 {object} response.Response "Нет доступа - пример: {\"status\":\"ERROR\",\"error\":\"unauthorized\"}"
+// @Failure 500 {object} response.Response "Ошибка сохранения - пример: {\"status\":\"ERROR\",\"error\":\"failed to save webhook\"}"
+// @Router /off/webhooks [post]
+func New(log *slog.Logger, saver WebhookSaver, types models.ServiceTypes, newSecret func() (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhooks.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode req body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode req"))
+			return
+		}
+
+		target, err := url.Parse(req.URL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			log.Warn("invalid url", slog.String("url", req.URL))
+			render.JSON(w, r, response.Error("invalid url"))
+			return
+		}
+
+		for _, blackoutType := range req.Types {
+			if !types.Has(blackoutType) {
+				log.Warn("invalid type", slog.String("type", blackoutType))
+				render.JSON(w, r, response.Error("invalid type, use: "+strings.Join(types.Codes(), ", ")))
+				return
+			}
+		}
+
+		secret := req.Secret
+		if secret == "" {
+			secret, err = newSecret()
+			if err != nil {
+				log.Error("failed to generate secret", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to save webhook"))
+				return
+			}
+		}
+
+		id, err := saver.SaveWebhook(models.Webhook{
+			URL:           target.String(),
+			Secret:        secret,
+			Buildings:     req.Buildings,
+			Streets:       req.Streets,
+			Types:         req.Types,
+			Organizations: req.Organizations,
+			CreatedAt:     time.Now().Format("2006-01-02 15:04:05"),
+			City:          city.FromContext(r.Context()).Code,
+		})
+		if err != nil {
+			log.Error("failed to save webhook", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to save webhook"))
+			return
+		}
+
+		log.Info("webhook saved", slog.Int64("id", id), slog.String("url", target.String()))
+
+		render.JSON(w, r, Response{
+			Response: response.Ok(),
+			ID:       id,
+			Secret:   secret,
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package save_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	webhookssave "vlru-prsch/internal/http-server/handlers/webhooks/save"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type saver struct {
+	saved []models.Webhook
+}
+
+func (s *saver) SaveWebhook(webhook models.Webhook) (int64, error) {
+	s.saved = append(s.saved, webhook)
+	return int64(len(s.saved)), nil
+}
+
+var ok = response.Ok()
+
+func failed(msg string) webhookssave.Response {
+	return webhookssave.Response{Response: response.Error(msg)}
+}
+
+func TestNew(t *testing.T) {
+	generated := func() (string, error) { return "generated", nil }
+
+	// the webhook belongs to the city of the request
+	cities := []models.City{{Code: "vladivostok", IsDefault: true}}
+
+	tests := []struct {
+		name      string
+		body      string
+		newSecret func() (string, error)
+		want      webhookssave.Response
+		wantSaved *models.Webhook
+	}{
+		{"generated secret", `{"url":"https://example.com/hooks/off"}`, generated,
+			webhookssave.Response{Response: ok, ID: 1, Secret: "generated"},
+			&models.Webhook{URL: "https://example.com/hooks/off", Secret: "generated", City: "vladivostok"}},
+		{"own secret and filters", `{"url":"http://example.com/hooks/off","secret":"s3cr3t","buildings":[10],"streets":["Карбышева ул."],"types":["hot_water","electricity"],"organizations":["Водоканал"]}`, generated,
+			webhookssave.Response{Response: ok, ID: 1, Secret: "s3cr3t"},
+			&models.Webhook{URL: "http://example.com/hooks/off", Secret: "s3cr3t", Buildings: []int64{10}, Streets: []string{"Карбышева ул."}, Types: []string{"hot_water", "electricity"}, Organizations: []string{"Водоканал"}, City: "vladivostok"}},
+		{"invalid url", `{"url":"example.com/hooks/off"}`, generated, failed("invalid url"), nil},
+		{"unsupported scheme", `{"url":"ftp://example.com/hooks/off"}`, generated, failed("invalid url"), nil},
+		{"invalid type", `{"url":"https://example.com/hooks/off","types":["steam"]}`, generated,
+			failed("invalid type, use: hot_water, cold_water, electricity, heat"), nil},
+		{"invalid body", `{"url":`, generated, failed("failed to decode req"), nil},
+		{"secret failed", `{"url":"https://example.com/hooks/off"}`, func() (string, error) { return "", errors.New("no entropy") },
+			failed("failed to save webhook"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &saver{}
+			h := city.New(slog.New(slog.DiscardHandler), cities)(webhookssave.New(slog.New(slog.DiscardHandler), s, models.DefaultServiceTypes(), tt.newSecret))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/off/webhooks", strings.NewReader(tt.body)))
+
+			var got webhookssave.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+			if tt.wantSaved == nil {
+				if len(s.saved) > 0 {
+					t.Errorf("saved %+v", s.saved)
+				}
+				return
+			}
+			if len(s.saved) != 1 {
+				t.Fatalf("saved %d webhooks, want 1", len(s.saved))
+			}
+			saved := s.saved[0]
+			saved.CreatedAt = ""
+			if !reflect.DeepEqual(saved, *tt.wantSaved) {
+				t.Errorf("saved %+v, want %+v", saved, *tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestNewGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		newSecret func() (string, error)
+	}{
+		{"generated secret", `{"url":"https://example.com/hooks/off"}`, nil},
+		{"own secret and filters", `{"url":"http://example.com/hooks/off","secret":"s3cr3t","buildings":[10],"streets":["Карбышева ул."],"types":["hot_water","electricity"],"organizations":["Водоканал"]}`, nil},
+		{"invalid url", `{"url":"example.com/hooks/off"}`, nil},
+		{"unsupported scheme", `{"url":"ftp://example.com/hooks/off"}`, nil},
+		{"invalid type", `{"url":"https://example.com/hooks/off","types":["steam"]}`, nil},
+		{"invalid body", `{"url":`, nil},
+		{"secret failed", `{"url":"https://example.com/hooks/off"}`, func() (string, error) { return "", errors.New("no entropy") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newSecret := tt.newSecret
+			if newSecret == nil {
+				newSecret = func() (string, error) { return "generated", nil }
+			}
+
+			h := handlertest.Router(func(r chi.Router) {
+				r.Post("/webhooks", webhookssave.New(handlertest.Log, handlertest.Webhooks(t), handlertest.ServiceTypes, newSecret))
+			})
+
+			w := handlertest.Do(t, h, http.MethodPost, "/off/webhooks", tt.body)
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package within
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/date"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/lib/geojson"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// maxBodySize limits the polygon body, a district outline fits in a fraction of it
+const maxBodySize = 1 << 20
+
+// Response represents blackouts found inside a polygon
+// @Description Отключения и здания внутри многоугольника
+type Response struct {
+	response.Response
+	// Здания с действующими отключениями, от ближайшего к дальнему
+	Buildings []models.AffectedBuilding `json:"buildings"`
+	// Отключения, от ближайшего к дальнему
+	Blackouts []models.NearbyBlackout `json:"blackouts"`
+}
+
+type WithinGiver interface {
+	Within(polygon geo.Polygon, ref geo.Point, currentTime string, city string) (models.SpatialResult, error)
+}
+
+// New godoc
+// @Summary Отключения внутри области
+// @Description Принимает GeoJSON Polygon (геометрию или Feature) и возвращает действующие в указанное время отключения и затронутые ими здания города внутри него. Расстояния считаются от точки lat/lon, а если она не задана - от центра многоугольника
+// @Tags map
+// @Accept json
+// @Produce json
+// @Param polygon body geojson.Geometry true "GeoJSON Polygon или Feature с ним"
+// @Param lat query number false "Широта точки отсчета" example(43.1155)
+// @Param lon query number false "Долгота точки отсчета" example(131.8869)
+// @Param curr_time query string false "Время в формате YYYY-MM-DDTHH:MM:SSZ или YYYY-MM-DD_HH:MM:SS" example(2019-01-15_14:30:00)
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "Отключения в области"
+// @Failure 400 {object} response.Response "Неверные параметры - пример: {\"status\":\"ERROR\",\"error\":\"invalid polygon\"}"
+// @Failure 500 {object} response.Response "Ошибка получения данных - пример: {\"status\":\"ERROR\",\"error\":\"failed to get blackouts within polygon\"}"
+// @Router /off/within [post]
+func New(log *slog.Logger, giver WithinGiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.within.post.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			log.Warn("failed to read request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to read request"))
+			return
+		}
+
+		polygon, err := geojson.ParsePolygon(body)
+		if err != nil {
+			log.Warn("invalid polygon", sl.Err(err))
+			render.JSON(w, r, response.Error("invalid polygon: "+err.Error()))
+			return
+		}
+
+		query := r.URL.Query()
+
+		ref := polygon.Centroid()
+		if query.Get("lat") != "" || query.Get("lon") != "" {
+			lat, errLat := strconv.ParseFloat(query.Get("lat"), 64)
+			lon, errLon := strconv.ParseFloat(query.Get("lon"), 64)
+			if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+				log.Warn("invalid coordinates", slog.String("lat", query.Get("lat")), slog.String("lon", query.Get("lon")))
+				render.JSON(w, r, response.Error("invalid coordinates"))
+				return
+			}
+			ref = geo.Point{Lat: lat, Lon: lon}
+		}
+
+		currTime := query.Get("curr_time")
+		currTimeParse, err := date.ParseQueryDate(currTime)
+		if err != nil {
+			log.Warn("invalid time format", slog.String("curr_time", currTime), sl.Err(err))
+			render.JSON(w, r, response.Error("invalid time format"))
+			return
+		}
+
+		result, err := giver.Within(polygon, ref, currTimeParse, city.FromContext(r.Context()).Code)
+		if err != nil {
+			log.Error("failed to get blackouts within polygon", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get blackouts within polygon"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:  response.Ok(),
+			Buildings: result.Buildings,
+			Blackouts: result.Blackouts,
+		})
+	}
+}
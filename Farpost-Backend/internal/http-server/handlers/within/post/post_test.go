@@ -0,0 +1,151 @@
+package within_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vlru-prsch/internal/http-server/handlers/handlertest"
+	"vlru-prsch/internal/http-server/handlers/within/post"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/spatial"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const polygon = `{"type":"Polygon","coordinates":[[[131.88,43.10],[131.90,43.10],[131.90,43.12],[131.88,43.12],[131.88,43.10]]]}`
+
+type giver struct {
+	err     error
+	polygon geo.Polygon
+	ref     geo.Point
+	city    string
+}
+
+func (g *giver) Within(polygon geo.Polygon, ref geo.Point, currentTime string, city string) (models.SpatialResult, error) {
+	g.polygon, g.ref, g.city = polygon, ref, city
+	return models.SpatialResult{
+		Buildings: []models.AffectedBuilding{{BuildingID: 10}},
+		Blackouts: []models.NearbyBlackout{{ID: "b1"}},
+	}, g.err
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		body      string
+		err       error
+		wantError string
+		wantRef   geo.Point
+	}{
+		{"from the centroid", "/off/within?curr_time=2024-03-10_12:00:00", polygon, nil, "", geo.Point{Lat: 43.11, Lon: 131.89}},
+		{"from a point", "/off/within?lat=43.1&lon=131.88", polygon, nil, "", geo.Point{Lat: 43.1, Lon: 131.88}},
+		{"feature", "/off/within", `{"type":"Feature","geometry":` + polygon + `}`, nil, "", geo.Point{Lat: 43.11, Lon: 131.89}},
+		{"not a polygon", "/off/within", `{"type":"Point","coordinates":[131.88,43.10]}`, nil, `invalid polygon: expected Polygon geometry, got "Point"`, geo.Point{}},
+		{"half a point", "/off/within?lat=43.1", polygon, nil, "invalid coordinates", geo.Point{}},
+		{"invalid time", "/off/within?curr_time=yesterday", polygon, nil, "invalid time format", geo.Point{}},
+		{"storage failed", "/off/within", polygon, errors.New("disk I/O error"), "failed to get blackouts within polygon", geo.Point{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{err: tt.err}
+
+			w := httptest.NewRecorder()
+			within.New(slog.New(slog.DiscardHandler), g).ServeHTTP(w, httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body)))
+
+			var got within.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Error != tt.wantError {
+				t.Fatalf("got error %q, want %q", got.Error, tt.wantError)
+			}
+			if tt.wantError != "" {
+				return
+			}
+
+			if len(g.polygon) != 1 || len(g.polygon[0]) != 5 {
+				t.Errorf("locator got polygon %v", g.polygon)
+			}
+			if g.ref != tt.wantRef {
+				t.Errorf("locator got reference %v, want %v", g.ref, tt.wantRef)
+			}
+			if len(got.Buildings) != 1 || len(got.Blackouts) != 1 {
+				t.Errorf("got %+v, want the locator result", got)
+			}
+		})
+	}
+}
+
+func TestNewCity(t *testing.T) {
+	cities := []models.City{{Code: "vladivostok", IsDefault: true}, {Code: "khabarovsk"}}
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"default city", "", "vladivostok"},
+		{"city from the header", "khabarovsk", "khabarovsk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &giver{}
+
+			r := httptest.NewRequest("POST", "/off/within", strings.NewReader(polygon))
+			r.Header.Set(city.Header, tt.header)
+
+			city.New(slog.New(slog.DiscardHandler), cities)(within.New(slog.New(slog.DiscardHandler), g)).ServeHTTP(httptest.NewRecorder(), r)
+
+			if g.city != tt.want {
+				t.Errorf("locator got city %q, want %q", g.city, tt.want)
+			}
+		})
+	}
+}
+
+// square covers buildings 10 and 11 on Карбышева ул.
+const square = `{"type":"Polygon","coordinates":[[[131.895,43.095],[131.905,43.095],[131.905,43.105],[131.895,43.105],[131.895,43.095]]]}`
+
+func TestNewGolden(t *testing.T) {
+	locator := spatial.NewLocator(handlertest.Log, handlertest.Located(t), time.Hour, handlertest.Codes)
+	if err := locator.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlertest.Router(func(r chi.Router) {
+		r.Post("/within", within.New(handlertest.Log, locator))
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"polygon", "/off/within?curr_time=2024-03-10_12:00:00", square},
+		{"feature", "/off/within?curr_time=2024-03-10_12:00:00", `{"type":"Feature","properties":{},"geometry":` + square + `}`},
+		{"reference point", "/off/within?curr_time=2024-03-10_12:00:00&lat=43.1150&lon=131.8850", square},
+		{"open ended only", "/off/within?curr_time=2025-01-01_00:00:00", square},
+		{"other city", "/khabarovsk/off/within?curr_time=2024-03-10_12:00:00", square},
+		{"not a polygon", "/off/within?curr_time=2024-03-10_12:00:00", `{"type":"Point","coordinates":[131.9,43.1]}`},
+		{"invalid coordinates", "/off/within?curr_time=2024-03-10_12:00:00&lat=43.1", square},
+		{"invalid time", "/off/within?curr_time=yesterday", square},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := handlertest.Do(t, h, http.MethodPost, tt.target, tt.body)
+			handlertest.Golden(t, w.Body.Bytes())
+		})
+	}
+}
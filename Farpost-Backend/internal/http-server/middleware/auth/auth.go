@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"vlru-prsch/internal/lib/api/response"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// New returns a middleware that lets through only requests carrying one of the
+// configured API keys in the Authorization header, either raw or as a Bearer token.
+// With no keys configured every request is rejected.
+func New(log *slog.Logger, keys []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/auth"))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := Key(r)
+
+			if key == "" || !Valid(keys, key) {
+				log.Warn("unauthorized request",
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", middleware.GetReqID(r.Context())))
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("unauthorized"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Key returns the API key of the Authorization header, empty without one
+func Key(r *http.Request) string {
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+// Valid reports whether the key is one of the configured keys
+func Valid(keys []string, key string) bool {
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package cachecontrol
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/date"
+)
+
+// Period returns the end of the period a request asks about, false when the
+// request is not about a fixed period, e.g. it asks about the current moment
+type Period func(r *http.Request) (time.Time, bool)
+
+type Policy struct {
+	// MaxAge of responses that may still change
+	MaxAge time.Duration
+	// PastMaxAge of immutable responses about periods over before today
+	PastMaxAge time.Duration
+}
+
+// New returns a middleware setting Cache-Control of a route. A response about
+// a period that ended before today, in the time zone of the city of the request,
+// is immutable and may be kept for PastMaxAge without revalidation, the rest
+// for MaxAge. A rare correction of a past blackout by the loader reaches
+// clients once PastMaxAge runs out.
+func New(policy Policy, period Period) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			value := fmt.Sprintf("public, max-age=%d", int(policy.MaxAge.Seconds()))
+
+			if end, ok := period(r); ok && !end.After(today(r)) {
+				value = fmt.Sprintf("public, max-age=%d, immutable", int(policy.PastMaxAge.Seconds()))
+			}
+
+			w.Header().Set("Cache-Control", value)
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// today is the start of the current day in the time zone of the city of the request
+func today(r *http.Request) time.Time {
+	now := time.Now().In(city.Location(r.Context()))
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// Current is the period of requests about the current moment, they always get MaxAge
+func Current(r *http.Request) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Month reads a YYYY-MM query parameter, the period ends with the month
+func Month(param string) Period {
+	return func(r *http.Request) (time.Time, bool) {
+		start, err := time.ParseInLocation("2006-01", r.URL.Query().Get(param), city.Location(r.Context()))
+		if err != nil {
+			return time.Time{}, false
+		}
+		return start.AddDate(0, 1, 0), true
+	}
+}
+
+// Day reads a YYYY-MM-DD query parameter, the period ends with the day
+func Day(param string) Period {
+	return func(r *http.Request) (time.Time, bool) {
+		start, err := time.ParseInLocation("2006-01-02", r.URL.Query().Get(param), city.Location(r.Context()))
+		if err != nil {
+			return time.Time{}, false
+		}
+		return start.AddDate(0, 0, 1), true
+	}
+}
+
+// Moment reads a time in the formats of curr_time, the period ends with its day.
+// Without the parameter the request is about the current moment.
+func Moment(param string) Period {
+	return func(r *http.Request) (time.Time, bool) {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			return time.Time{}, false
+		}
+
+		loc := city.Location(r.Context())
+
+		moment, err := date.ParseQueryDateIn(value, loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		start, err := time.ParseInLocation("2006-01-02", moment[:min(len(moment), 10)], loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return start.AddDate(0, 0, 1), true
+	}
+}
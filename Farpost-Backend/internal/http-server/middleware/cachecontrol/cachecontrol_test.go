@@ -0,0 +1,65 @@
+package cachecontrol_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vlru-prsch/internal/http-server/middleware/cachecontrol"
+)
+
+func TestNew(t *testing.T) {
+	policy := cachecontrol.Policy{MaxAge: time.Minute, PastMaxAge: time.Hour}
+
+	tests := []struct {
+		name   string
+		period cachecontrol.Period
+		target string
+		want   string
+	}{
+		{"current", cachecontrol.Current, "/", "public, max-age=60"},
+		{"past month", cachecontrol.Month("month"), "/?month=2024-03", "public, max-age=3600, immutable"},
+		{"future month", cachecontrol.Month("month"), "/?month=2999-03", "public, max-age=60"},
+		{"bad month", cachecontrol.Month("month"), "/?month=march", "public, max-age=60"},
+		{"past day", cachecontrol.Day("date"), "/?date=2024-03-10", "public, max-age=3600, immutable"},
+		{"future day", cachecontrol.Day("date"), "/?date=2999-03-10", "public, max-age=60"},
+		{"past moment", cachecontrol.Moment("curr_time"), "/?curr_time=2024-03-10_12:00:00", "public, max-age=3600, immutable"},
+		{"past RFC3339 moment", cachecontrol.Moment("curr_time"), "/?curr_time=2024-03-10T12:00:00Z", "public, max-age=3600, immutable"},
+		{"future moment", cachecontrol.Moment("curr_time"), "/?curr_time=2999-03-10_12:00:00", "public, max-age=60"},
+		{"no moment", cachecontrol.Moment("curr_time"), "/", "public, max-age=60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := cachecontrol.New(policy, tt.period)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToday(t *testing.T) {
+	policy := cachecontrol.Policy{MaxAge: time.Minute, PastMaxAge: time.Hour}
+	handler := cachecontrol.New(policy, cachecontrol.Day("date"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// today has not ended yet in UTC, the zone without a city, the day before yesterday has
+	now := time.Now().UTC()
+	tests := map[string]string{
+		now.Format("2006-01-02"):                   "public, max-age=60",
+		now.AddDate(0, 0, -2).Format("2006-01-02"): "public, max-age=3600, immutable",
+	}
+
+	for date, want := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?date="+date, nil))
+
+		if got := w.Header().Get("Cache-Control"); got != want {
+			t.Errorf("%s: Cache-Control = %q, want %q", date, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cached
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+	"vlru-prsch/internal/cache"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header tells whether the response came from the cache, HIT or MISS
+const Header = "X-Cache"
+
+// New returns a middleware serving GET responses of the endpoint from the
+// cache. Responses are keyed by the endpoint, the city of the request and the
+// query parameters, so only the order of the parameters does not matter.
+func New(log *slog.Logger, c *cache.Cache, endpoint string, ttl time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/cached"), slog.String("endpoint", endpoint))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			k := key(endpoint, r)
+
+			if entry, ok := c.Get(endpoint, k); ok {
+				w.Header().Set("Content-Type", entry.ContentType)
+				w.Header().Set(Header, "HIT")
+				w.WriteHeader(entry.Status)
+				w.Write(entry.Body)
+				return
+			}
+
+			// the version is taken before the response is built, data changed meanwhile is not cached
+			version := c.Version()
+
+			w.Header().Set(Header, "MISS")
+
+			var body bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status != http.StatusOK || response.Failed(body.Bytes()) {
+				return
+			}
+
+			c.Set(&cache.Entry{
+				Key:         k,
+				Version:     version,
+				Status:      status,
+				ContentType: ww.Header().Get("Content-Type"),
+				Body:        body.Bytes(),
+			}, ttl)
+
+			log.Debug("response cached", slog.String("key", k), slog.String("request_id", middleware.GetReqID(r.Context())))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// key normalizes the request: parameters are sorted by name and value and
+// otherwise kept as sent, a handler may tell an empty or padded value apart
+func key(endpoint string, r *http.Request) string {
+	query := r.URL.Query()
+	for _, values := range query {
+		sort.Strings(values)
+	}
+
+	return city.FromContext(r.Context()).Code + ":" + endpoint + "?" + query.Encode()
+}
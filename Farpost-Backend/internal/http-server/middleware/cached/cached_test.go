@@ -0,0 +1,154 @@
+package cached_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vlru-prsch/internal/cache"
+	"vlru-prsch/internal/http-server/middleware/cached"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type versioner struct {
+	version string
+}
+
+func (v *versioner) DataVersion() (string, error) {
+	return v.version, nil
+}
+
+// server counts the calls of the handler behind the cache, it answers with
+// the body the test gives it
+type server struct {
+	http.Handler
+	cache *cache.Cache
+	calls int
+	body  string
+}
+
+func newServer(t *testing.T, src *versioner) *server {
+	t.Helper()
+
+	log := slog.New(slog.DiscardHandler)
+
+	c, err := cache.New(log, src, cache.Options{MaxEntries: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &server{cache: c, body: `{"status":"OK"}`}
+
+	router := chi.NewRouter()
+	router.Use(city.New(log, []models.City{
+		{Code: "vladivostok", IsDefault: true},
+		{Code: "khabarovsk"},
+	}))
+	router.Use(cached.New(log, c, "/blackouts", time.Minute))
+	router.HandleFunc("/blackouts", func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(s.body))
+	})
+	s.Handler = router
+
+	return s
+}
+
+func (s *server) do(t *testing.T, method, target string, header http.Header) string {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, nil)
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s %s: status %d", method, target, w.Code)
+	}
+	return w.Header().Get(cached.Header)
+}
+
+func TestCached(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  string
+		second string
+		header http.Header
+		want   string
+	}{
+		{"same request", "/blackouts?curr_time=2024-03-10", "/blackouts?curr_time=2024-03-10", nil, "HIT"},
+		{"parameters in another order", "/blackouts?a=1&b=2", "/blackouts?b=2&a=1", nil, "HIT"},
+		{"values in another order", "/blackouts?a=1&a=2", "/blackouts?a=2&a=1", nil, "HIT"},
+		{"empty parameter", "/blackouts?a=1", "/blackouts?a=1&b=", nil, "MISS"},
+		{"padded value", "/blackouts?a=1", "/blackouts?a=%201", nil, "MISS"},
+		{"other parameters", "/blackouts?a=1", "/blackouts?a=2", nil, "MISS"},
+		{"other city", "/blackouts", "/blackouts", http.Header{city.Header: {"khabarovsk"}}, "MISS"},
+		{"default city named", "/blackouts", "/blackouts", http.Header{city.Header: {"vladivostok"}}, "HIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t, &versioner{version: "1"})
+
+			if got := s.do(t, http.MethodGet, tt.first, nil); got != "MISS" {
+				t.Errorf("first request: %s, want MISS", got)
+			}
+			if got := s.do(t, http.MethodGet, tt.second, tt.header); got != tt.want {
+				t.Errorf("second request: %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachedSkips(t *testing.T) {
+	t.Run("error response", func(t *testing.T) {
+		s := newServer(t, &versioner{version: "1"})
+		s.body = `{"status":"ERROR","error":"failed to get blackouts"}`
+
+		s.do(t, http.MethodGet, "/blackouts", nil)
+		if got := s.do(t, http.MethodGet, "/blackouts", nil); got != "MISS" {
+			t.Errorf("second request: %s, want MISS", got)
+		}
+		if s.calls != 2 {
+			t.Errorf("handler called %d times, want 2", s.calls)
+		}
+	})
+
+	t.Run("not a GET", func(t *testing.T) {
+		s := newServer(t, &versioner{version: "1"})
+
+		for range 2 {
+			if got := s.do(t, http.MethodPost, "/blackouts", nil); got != "" {
+				t.Errorf("POST: %s, want no header", got)
+			}
+		}
+		if s.calls != 2 {
+			t.Errorf("handler called %d times, want 2", s.calls)
+		}
+	})
+}
+
+func TestCachedInvalidation(t *testing.T) {
+	src := &versioner{version: "1"}
+	s := newServer(t, src)
+
+	s.do(t, http.MethodGet, "/blackouts", nil)
+
+	src.version = "2"
+	if err := s.cache.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"MISS", "HIT"} {
+		if got := s.do(t, http.MethodGet, "/blackouts", nil); got != want {
+			t.Errorf("after the data changed: %s, want %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package city
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Header lets clients of the unprefixed routes pick a city
+const Header = "X-City"
+
+type ctxKey struct{}
+
+// New returns a middleware resolving the city of the request from the {city}
+// route parameter, then the X-City header, falling back to the default city.
+// Requests naming an unknown city are rejected. Responses vary by the header,
+// so shared caches keep the answers of the cities apart.
+func New(log *slog.Logger, cities []models.City) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/city"))
+
+		var fallback models.City
+		for _, c := range cities {
+			if c.IsDefault {
+				fallback = c
+				break
+			}
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", Header)
+
+			code := chi.URLParam(r, "city")
+			if code == "" {
+				code = r.Header.Get(Header)
+			}
+			code = strings.ToLower(strings.TrimSpace(code))
+
+			current := fallback
+			if code != "" {
+				found := false
+				for _, c := range cities {
+					if c.Code == code {
+						current, found = c, true
+						break
+					}
+				}
+
+				if !found {
+					log.Warn("unknown city",
+						slog.String("city", code),
+						slog.String("request_id", middleware.GetReqID(r.Context())))
+					render.JSON(w, r, response.Error("unknown city"))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, current)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// FromContext returns the city resolved by the middleware, the zero city
+// without a filter when the middleware is not mounted
+func FromContext(ctx context.Context) models.City {
+	current, _ := ctx.Value(ctxKey{}).(models.City)
+	return current
+}
+
+// Location returns the time zone of the city of the request, UTC when unknown
+func Location(ctx context.Context) *time.Location {
+	if loc := FromContext(ctx).Location; loc != nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -0,0 +1,81 @@
+package city_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var cities = []models.City{
+	{Code: "vladivostok", IsDefault: true},
+	{Code: "khabarovsk"},
+}
+
+func router() http.Handler {
+	r := chi.NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(city.FromContext(r.Context()).Code))
+	}
+
+	r.With(city.New(slog.New(slog.DiscardHandler), cities)).Get("/blackouts", handler)
+	r.With(city.New(slog.New(slog.DiscardHandler), cities)).Get("/{city}/blackouts", handler)
+	return r
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   string
+	}{
+		{"default", "/blackouts", "", "vladivostok"},
+		{"route", "/khabarovsk/blackouts", "", "khabarovsk"},
+		{"header", "/blackouts", "Khabarovsk ", "khabarovsk"},
+		{"route over header", "/vladivostok/blackouts", "khabarovsk", "vladivostok"},
+		{"unknown route", "/moscow/blackouts", "", `{"status":"ERROR","error":"unknown city"}` + "\n"},
+		{"unknown header", "/blackouts", "moscow", `{"status":"ERROR","error":"unknown city"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(city.Header, tt.header)
+			}
+
+			rr := httptest.NewRecorder()
+			router().ServeHTTP(rr, req)
+
+			if got := rr.Body.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVary(t *testing.T) {
+	var bodies []string
+	for _, header := range []string{"vladivostok", "khabarovsk"} {
+		req := httptest.NewRequest(http.MethodGet, "/blackouts", nil)
+		req.Header.Set(city.Header, header)
+
+		rr := httptest.NewRecorder()
+		router().ServeHTTP(rr, req)
+
+		if got := rr.Header().Values("Vary"); len(got) != 1 || got[0] != city.Header {
+			t.Errorf("%s: Vary = %q, want %q", header, got, city.Header)
+		}
+		bodies = append(bodies, rr.Body.String())
+	}
+
+	// the same URL answers differently, a cache ignoring the header would mix the cities up
+	if bodies[0] == bodies[1] {
+		t.Errorf("got %q for both cities", bodies[0])
+	}
+}
@@ -0,0 +1,69 @@
+package compress
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// New returns a middleware compressing responses with brotli or gzip, brotli
+// wins when the client takes both. Every response varies by Accept-Encoding,
+// also one sent as is, so a shared cache does not hand it to every client.
+func New(level int) func(next http.Handler) http.Handler {
+	compressor := middleware.NewCompressor(level)
+	compressor.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+
+	return func(next http.Handler) http.Handler {
+		compressed := compressor.Handler(next)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			compressed.ServeHTTP(&writer{ResponseWriter: w}, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// writer adds Vary: Accept-Encoding when the compressor has not
+type writer struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Accept-Encoding") {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *writer) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
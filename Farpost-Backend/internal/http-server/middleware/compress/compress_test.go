@@ -0,0 +1,94 @@
+package compress_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"vlru-prsch/internal/http-server/middleware/compress"
+	"vlru-prsch/internal/http-server/middleware/etag"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestCompression(t *testing.T) {
+	body := strings.Repeat(`{"status":"OK"}`, 100)
+
+	handler := compress.New(5)(etag.New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})))
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+		decode         func(r io.Reader) (io.Reader, error)
+	}{
+		{"brotli", "br", "br", func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+		{"gzip", "gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"brotli first", "gzip, deflate, br", "br", func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+		{"identity", "", "", func(r io.Reader) (io.Reader, error) { return r, nil }},
+	}
+
+	var tag string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/off/blackouts", nil)
+			if tt.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.want {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Values("Vary"); !slices.Contains(got, "Accept-Encoding") {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+
+			// the weak tag names the payload, not the bytes on the wire
+			if tag == "" {
+				tag = w.Header().Get("ETag")
+			} else if got := w.Header().Get("ETag"); got != tag {
+				t.Errorf("ETag = %q, want %q as with the other encodings", got, tag)
+			}
+
+			decoded, err := tt.decode(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != body {
+				t.Errorf("decoded body of %d bytes, want %d", len(got), len(body))
+			}
+		})
+	}
+}
+
+func TestVaryOfNotModified(t *testing.T) {
+	handler := compress.New(5)(etag.New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"OK"}`))
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/off/blackouts", nil)
+	r.Header.Set("Accept-Encoding", "br")
+	r.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", w.Code)
+	}
+	if got := w.Header().Values("Vary"); !slices.Equal(got, []string{"Accept-Encoding"}) {
+		t.Errorf("Vary = %q, want Accept-Encoding once", got)
+	}
+}
@@ -0,0 +1,29 @@
+package deadline
+
+import (
+	"net/http"
+	"time"
+)
+
+// New returns a middleware that gives every request the timeout in effect when
+// it starts to read its body and write the response. The timeout is read on
+// each request, so a reloaded config applies to the next one. Handlers that
+// stream, like /stream, lift the write deadline themselves.
+func New(timeout func() time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if d := timeout(); d > 0 {
+				rc := http.NewResponseController(w)
+				deadline := time.Now().Add(d)
+
+				// the writer may not support deadlines, the server ones stay then
+				_ = rc.SetReadDeadline(deadline)
+				_ = rc.SetWriteDeadline(deadline)
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,127 @@
+package etag
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"vlru-prsch/internal/lib/api/response"
+)
+
+// New returns a middleware adding an ETag to JSON responses of GET and HEAD
+// requests and answering 304 Not Modified when If-None-Match carries it.
+// The tag is a hash of the payload, so a response served from the cache and
+// a freshly built one share it. It is weak, compression changes the bytes but
+// not the meaning. Error responses get Cache-Control: no-store instead of a tag.
+// Other content types, like the event stream, pass through untouched.
+func New() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := &writer{ResponseWriter: w}
+			next.ServeHTTP(ww, r)
+
+			if ww.passthrough || ww.status == 0 {
+				return
+			}
+
+			body := ww.body.Bytes()
+
+			if ww.status != http.StatusOK || response.Failed(body) {
+				w.Header().Set("Cache-Control", "no-store")
+				w.WriteHeader(ww.status)
+				w.Write(body)
+				return
+			}
+
+			sum := sha256.Sum256(body)
+			tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", tag)
+
+			if match(r.Header.Get("If-None-Match"), tag) {
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.WriteHeader(ww.status)
+			w.Write(body)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// match compares the tags of If-None-Match weakly, as RFC 9110 asks for GET
+func match(header string, tag string) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writer keeps JSON bodies until the handler is done, anything else is written through
+type writer struct {
+	http.ResponseWriter
+	status      int
+	passthrough bool
+	body        bytes.Buffer
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+
+	w.status = code
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+
+	return w.body.Write(p)
+}
+
+// Flush only reaches the client for streamed responses, a kept body goes out at once anyway
+func (w *writer) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.passthrough {
+		return
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,128 @@
+package etag_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/middleware/etag"
+)
+
+// respond returns a handler answering with the content type, status and body
+func respond(contentType string, status int, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	})
+}
+
+func serve(handler http.Handler, method string, ifNoneMatch string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", nil)
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	w := httptest.NewRecorder()
+	etag.New()(handler).ServeHTTP(w, r)
+	return w
+}
+
+func TestNotModified(t *testing.T) {
+	handler := respond("application/json", http.StatusOK, `{"status":"OK"}`)
+
+	tag := serve(handler, http.MethodGet, "").Header().Get("ETag")
+	if len(tag) < 4 || tag[:3] != `W/"` {
+		t.Fatalf("ETag = %q, want a weak tag", tag)
+	}
+	strong := tag[2:]
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"no tag", "", http.StatusOK},
+		{"same tag", tag, http.StatusNotModified},
+		{"strong form of the tag", strong, http.StatusNotModified},
+		{"one of the tags", `"other", ` + tag, http.StatusNotModified},
+		{"any tag", "*", http.StatusNotModified},
+		{"other tag", `W/"other"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, http.MethodGet, tt.ifNoneMatch)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if w.Header().Get("ETag") != tag {
+				t.Errorf("ETag = %q, want %q", w.Header().Get("ETag"), tag)
+			}
+			if tt.want == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("Content-Type") != "") {
+				t.Errorf("304 with body %q and Content-Type %q", w.Body.String(), w.Header().Get("Content-Type"))
+			}
+			if tt.want == http.StatusOK && w.Body.String() != `{"status":"OK"}` {
+				t.Errorf("body = %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTagFollowsBody(t *testing.T) {
+	first := serve(respond("application/json", http.StatusOK, `{"days":[1]}`), http.MethodGet, "").Header().Get("ETag")
+	second := serve(respond("application/json", http.StatusOK, `{"days":[2]}`), http.MethodGet, "").Header().Get("ETag")
+
+	if first == second {
+		t.Errorf("different bodies share the tag %s", first)
+	}
+
+	w := serve(respond("application/json", http.StatusOK, `{"days":[2]}`), http.MethodGet, first)
+	if w.Code != http.StatusOK {
+		t.Errorf("status with a stale tag = %d, want 200", w.Code)
+	}
+}
+
+func TestUntagged(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      http.Handler
+		method       string
+		wantCode     int
+		wantBody     string
+		cacheControl string
+	}{
+		{
+			"error body", respond("application/json", http.StatusOK, `{"status":"ERROR","error":"internal error"}`),
+			http.MethodGet, http.StatusOK, `{"status":"ERROR","error":"internal error"}`, "no-store",
+		},
+		{
+			"error status", respond("application/json", http.StatusTooManyRequests, `{"status":"ERROR"}`),
+			http.MethodGet, http.StatusTooManyRequests, `{"status":"ERROR"}`, "no-store",
+		},
+		{
+			"event stream", respond("text/event-stream", http.StatusOK, "data: {}\n\n"),
+			http.MethodGet, http.StatusOK, "data: {}\n\n", "",
+		},
+		{
+			"POST", respond("application/json", http.StatusOK, `{"status":"OK"}`),
+			http.MethodPost, http.StatusOK, `{"status":"OK"}`, "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.handler, tt.method, "*")
+
+			if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+			if tag := w.Header().Get("ETag"); tag != "" {
+				t.Errorf("ETag = %q, want none", tag)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package limited
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"vlru-prsch/internal/http-server/middleware/auth"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/ratelimit"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Options struct {
+	// Group names the routes sharing the buckets and the counters
+	Group string
+	// IP is the rule of clients without an API key
+	IP ratelimit.Rule
+	// Key is the rule of clients with one of Keys, a bucket per key
+	Key  ratelimit.Rule
+	Keys []string
+	// Allowlist addresses are never limited
+	Allowlist ratelimit.Allowlist
+}
+
+// New returns a middleware that limits the requests of every client to the
+// routes of the group. A client is its API key when it sends a valid one and its
+// address otherwise, an unknown key does not get a bucket of its own. An IPv6
+// client is its /64 network, a single host usually gets the whole of it.
+// A client out of tokens gets 429 Too Many Requests with Retry-After.
+func New(log *slog.Logger, limiter ratelimit.Limiter, metrics *ratelimit.Metrics, opts Options) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/limited"),
+			slog.String("group", opts.Group),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+
+			if ip.IsValid() && opts.Allowlist.Contains(ip) {
+				metrics.Allowlisted(opts.Group)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client, rule := "ip:"+clientNetwork(r, ip), opts.IP
+			if key := auth.Key(r); key != "" && auth.Valid(opts.Keys, key) {
+				client, rule = "key:"+key, opts.Key
+			}
+
+			ok, wait := limiter.Take(opts.Group+"|"+client, rule)
+			if !ok {
+				metrics.Limited(opts.Group)
+
+				log.Warn("rate limit exceeded",
+					slog.String("ip", ip.String()),
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", middleware.GetReqID(r.Context())))
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds())))))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, response.Error("too many requests"))
+				return
+			}
+
+			metrics.Allowed(opts.Group)
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// clientIP reads RemoteAddr, it holds a bare address after middleware.RealIP
+func clientIP(r *http.Request) netip.Addr {
+	if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+
+	if addr, err := netip.ParseAddr(r.RemoteAddr); err == nil {
+		return addr.Unmap()
+	}
+
+	return netip.Addr{}
+}
+
+// clientNetwork is the address an IPv4 client is limited by and the /64 network
+// of an IPv6 one. RemoteAddr that is not an address is taken without its port,
+// so the connections of one client share the bucket.
+func clientNetwork(r *http.Request, ip netip.Addr) string {
+	if !ip.IsValid() {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			return host
+		}
+		return r.RemoteAddr
+	}
+
+	if ip.Is6() {
+		return netip.PrefixFrom(ip.WithZone(""), 64).Masked().String()
+	}
+
+	return ip.String()
+}
@@ -0,0 +1,163 @@
+package limited_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vlru-prsch/internal/http-server/middleware/limited"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/ratelimit"
+)
+
+// limiter records the buckets taken from and limits the keys in limit
+type limiter struct {
+	taken []string
+	limit map[string]time.Duration
+}
+
+func (l *limiter) Take(key string, rule ratelimit.Rule) (bool, time.Duration) {
+	l.taken = append(l.taken, key)
+	if wait, ok := l.limit[key]; ok {
+		return false, wait
+	}
+	return true, 0
+}
+
+func serve(t *testing.T, l ratelimit.Limiter, metrics *ratelimit.Metrics, remoteAddr string, key string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	allowlist, err := ratelimit.ParseAllowlist([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := limited.New(slog.New(slog.DiscardHandler), l, metrics, limited.Options{
+		Group:     "public",
+		IP:        ratelimit.Rule{Rate: 1, Burst: 2},
+		Key:       ratelimit.Rule{Rate: 10, Burst: 20},
+		Keys:      []string{"secret"},
+		Allowlist: allowlist,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodGet, "/off/blackouts", nil)
+	r.RemoteAddr = remoteAddr
+	if key != "" {
+		r.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		key        string
+		want       string
+	}{
+		{"IPv4 with a port", "203.0.113.7:51234", "", "public|ip:203.0.113.7"},
+		{"IPv4 after RealIP", "203.0.113.7", "", "public|ip:203.0.113.7"},
+		{"IPv4 mapped to IPv6", "[::ffff:203.0.113.7]:51234", "", "public|ip:203.0.113.7"},
+		{"IPv6", "[2001:db8:1:2:aaaa::1]:51234", "", "public|ip:2001:db8:1:2::/64"},
+		{"IPv6 of the same /64", "[2001:db8:1:2:bbbb::2]:443", "", "public|ip:2001:db8:1:2::/64"},
+		{"IPv6 after RealIP", "2001:db8:1:3::1", "", "public|ip:2001:db8:1:3::/64"},
+		{"not an address", "client.example:51234", "", "public|ip:client.example"},
+		{"not an address without a port", "@", "", "public|ip:@"},
+		{"API key", "203.0.113.7:51234", "secret", "public|key:secret"},
+		{"unknown API key", "203.0.113.7:51234", "guess", "public|ip:203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &limiter{}
+			serve(t, l, ratelimit.NewMetrics(nil), tt.remoteAddr, tt.key)
+
+			if len(l.taken) != 1 || l.taken[0] != tt.want {
+				t.Errorf("buckets taken from = %q, want %q", l.taken, tt.want)
+			}
+		})
+	}
+}
+
+func TestTooManyRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		wait           time.Duration
+		wantRetryAfter string
+	}{
+		{"whole seconds", 3 * time.Second, "3"},
+		{"rounded up", 1500 * time.Millisecond, "2"},
+		{"at least a second", 10 * time.Millisecond, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &limiter{limit: map[string]time.Duration{"public|ip:203.0.113.7": tt.wait}}
+			metrics := ratelimit.NewMetrics(nil)
+
+			w := serve(t, l, metrics, "203.0.113.7:51234", "")
+
+			if w.Code != http.StatusTooManyRequests {
+				t.Errorf("status = %d, want 429", w.Code)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			if !response.Failed(w.Body.Bytes()) {
+				t.Errorf("body = %s, want an error", w.Body.String())
+			}
+
+			stats := metrics.Stats()
+			if len(stats.Groups) != 1 || stats.Groups[0].Limited != 1 || stats.Groups[0].Allowed != 0 {
+				t.Errorf("Stats = %+v, want one limited request", stats.Groups)
+			}
+		})
+	}
+}
+
+func TestAllowlisted(t *testing.T) {
+	l := &limiter{}
+	metrics := ratelimit.NewMetrics(nil)
+
+	w := serve(t, l, metrics, "10.1.2.3:51234", "")
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+	if len(l.taken) != 0 {
+		t.Errorf("buckets taken from = %q, want none", l.taken)
+	}
+	if stats := metrics.Stats(); len(stats.Groups) != 1 || stats.Groups[0].Allowlisted != 1 {
+		t.Errorf("Stats = %+v, want one allowlisted request", stats.Groups)
+	}
+}
+
+// TestBuckets runs the middleware over the real limiter: the burst of the IP
+// rule is two requests, a valid key has a bucket of its own
+func TestBuckets(t *testing.T) {
+	l := ratelimit.NewMemory()
+	metrics := ratelimit.NewMetrics(l.Len)
+
+	codes := []int{}
+	for _, client := range []struct{ addr, key string }{
+		{"[2001:db8::1]:1000", ""},
+		{"[2001:db8::2]:1001", ""},
+		{"[2001:db8::3]:1002", ""},
+		{"[2001:db8::3]:1002", "secret"},
+		{"[2001:db8:0:1::1]:1003", ""},
+	} {
+		codes = append(codes, serve(t, l, metrics, client.addr, client.key).Code)
+	}
+
+	want := []int{200, 200, 429, 200, 200}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("statuses = %v, want %v", codes, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"vlru-prsch/internal/lib/api/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type Options struct {
+	// Sample logs one of every n successful requests of the route, keyed by the
+	// pattern from /off on so both city forms share it. Failed requests are always
+	// logged, also those answered with 200 and an error body.
+	Sample map[string]int
+	// Redact hides the values of these query parameters
+	Redact []string
+}
+
+// New returns a middleware writing an access log record per request through
+// the slog handler of the service: method, route pattern, path, status, bytes,
+// duration, request ID and client IP. Handlers answer most errors with 200 and
+// {"status":"ERROR"}, so a request fails by its status or by its body, the
+// middleware has to see the body before it is compressed.
+func New(log *slog.Logger, opts Options) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/logger"))
+
+		log.Info("logger middleware enabled")
+
+		redact := make(map[string]bool, len(opts.Redact))
+		for _, param := range opts.Redact {
+			redact[strings.ToLower(param)] = true
+		}
+
+		var mu sync.Mutex
+		seen := map[string]int{}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			var body head
+			ww.Tee(&body)
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				route := ""
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					route = rctx.RoutePattern()
+				}
+
+				failed := status >= http.StatusBadRequest || response.Failed(body.data)
+
+				if key := sampleKey(route); opts.Sample[key] > 1 && !failed {
+					mu.Lock()
+					seen[key]++
+					skip := seen[key]%opts.Sample[key] != 1
+					mu.Unlock()
+
+					if skip {
+						return
+					}
+				}
+
+				level := slog.LevelInfo
+				switch {
+				case status >= http.StatusInternalServerError:
+					level = slog.LevelError
+				case failed:
+					level = slog.LevelWarn
+				}
+
+				log.LogAttrs(r.Context(), level, "request completed",
+					slog.String("method", r.Method),
+					slog.String("route", route),
+					slog.String("path", redactedPath(r.URL, redact)),
+					slog.Int("status", status),
+					slog.Bool("failed", failed),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.Duration("duration", time.Since(start)),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("ip", clientIP(r)),
+				)
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// headSize covers the start response.Failed looks at
+const headSize = 64
+
+// head keeps the first bytes of the body
+type head struct {
+	data []byte
+}
+
+func (h *head) Write(p []byte) (int, error) {
+	if n := headSize - len(h.data); n > 0 {
+		h.data = append(h.data, p[:min(n, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// sampleKey drops the city prefix of a route pattern, /{city}/off/map becomes /off/map
+func sampleKey(route string) string {
+	if i := strings.Index(route, "/off"); i > 0 {
+		return route[i:]
+	}
+	return route
+}
+
+func redactedPath(u *url.URL, redact map[string]bool) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+
+	query := u.Query()
+	for param, values := range query {
+		if redact[strings.ToLower(param)] {
+			for i := range values {
+				values[i] = "REDACTED"
+			}
+		}
+	}
+
+	return u.Path + "?" + query.Encode()
+}
+
+// clientIP is the address of RemoteAddr, the proxied one after middleware.RealIP
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
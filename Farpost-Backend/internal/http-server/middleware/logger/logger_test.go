@@ -0,0 +1,145 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vlru-prsch/internal/http-server/middleware/compress"
+	"vlru-prsch/internal/http-server/middleware/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type record struct {
+	Level  string `json:"level"`
+	Msg    string `json:"msg"`
+	Route  string `json:"route"`
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	Failed bool   `json:"failed"`
+}
+
+// server answers /off/map and /{city}/off/map with data, /off/fail with an
+// error body sent as 200 and /off/panic with 500. Compression goes around the
+// log as in the service.
+func server(t *testing.T, opts logger.Options) (http.Handler, func() []record) {
+	t.Helper()
+
+	var logs bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"OK"}`))
+	}
+
+	router := chi.NewRouter()
+	router.Use(compress.New(5))
+	router.Use(logger.New(log, opts))
+	router.Get("/off/map", ok)
+	router.Get("/{city}/off/map", ok)
+	router.Get("/off/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ERROR","error":"failed to get map"}`))
+	})
+	router.Get("/off/panic", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	records := func() []record {
+		var result []record
+		for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+			var rec record
+			if err := json.Unmarshal(line, &rec); err != nil {
+				t.Fatalf("%s: %v", line, err)
+			}
+			if rec.Msg == "request completed" {
+				result = append(result, rec)
+			}
+		}
+		return result
+	}
+
+	return router, records
+}
+
+func get(handler http.Handler, target string) {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Header.Set("Accept-Encoding", "gzip, br")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestFailed(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantLevel  string
+		wantStatus int
+		wantFailed bool
+	}{
+		{"data", "/off/map", "INFO", 200, false},
+		{"error body", "/off/fail", "WARN", 200, true},
+		{"not found", "/off/missing", "WARN", 404, true},
+		{"server error", "/off/panic", "ERROR", 500, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, records := server(t, logger.Options{})
+
+			get(handler, tt.target)
+
+			got := records()
+			if len(got) != 1 {
+				t.Fatalf("records = %+v, want one", got)
+			}
+			if got[0].Level != tt.wantLevel || got[0].Status != tt.wantStatus || got[0].Failed != tt.wantFailed {
+				t.Errorf("record = %+v, want %s, %d, failed %v", got[0], tt.wantLevel, tt.wantStatus, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	handler, records := server(t, logger.Options{Sample: map[string]int{
+		"/off/map":  3,
+		"/off/fail": 3,
+	}})
+
+	// the city form shares the counter of /off/map
+	for _, target := range []string{"/off/map", "/vladivostok/off/map", "/off/map", "/khabarovsk/off/map", "/off/map"} {
+		get(handler, target)
+	}
+	for range 3 {
+		get(handler, "/off/fail")
+	}
+
+	var data, failed int
+	for _, rec := range records() {
+		if rec.Failed {
+			failed++
+		} else {
+			data++
+		}
+	}
+
+	// the first and the fourth of five, every failed one
+	if data != 2 || failed != 3 {
+		t.Errorf("logged %d successful and %d failed requests, want 2 and 3", data, failed)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	handler, records := server(t, logger.Options{Redact: []string{"token", "email"}})
+
+	get(handler, "/off/map?Email=resident%40vl.ru&token=abc&token=def&month=2024-03")
+
+	got := records()
+	want := "/off/map?Email=REDACTED&month=2024-03&token=REDACTED&token=REDACTED"
+	if len(got) != 1 || got[0].Path != want {
+		t.Errorf("records = %+v, want path %s", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"vlru-prsch/internal/http-server/middleware/city"
+	"vlru-prsch/internal/lib/api/response"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+
+	"github.com/go-chi/render"
+)
+
+var (
+	ErrUnknownDistrict = errors.New("unknown district")
+	ErrUnknownKind     = errors.New("unknown kind")
+)
+
+type DistrictGiver interface {
+	GetDistricts(filter models.Filter) ([]models.District, error)
+}
+
+// FromQuery reads the filter of aggregate endpoints of the city coded by city from
+// the query parameters. The district is matched against the districts of the city
+// ignoring case.
+func FromQuery(query url.Values, city string, giver DistrictGiver) (models.Filter, error) {
+	const op = "lib.api.filter.FromQuery"
+
+	kind, err := kindFromQuery(query)
+	if err != nil {
+		return models.Filter{}, err
+	}
+	filter := models.Filter{City: city, Kind: kind}
+
+	district := strings.TrimSpace(query.Get("district"))
+	if district == "" {
+		return filter, nil
+	}
+
+	districts, err := giver.GetDistricts(filter)
+	if err != nil {
+		return models.Filter{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, known := range districts {
+		if strings.EqualFold(known.Name, district) {
+			filter.District = known.Name
+			return filter, nil
+		}
+	}
+
+	return models.Filter{}, ErrUnknownDistrict
+}
+
+// FromRequest reads the filter from the query parameters and scopes it to
+// the city resolved by the city middleware.
+func FromRequest(r *http.Request, giver DistrictGiver) (models.Filter, error) {
+	return FromQuery(r.URL.Query(), city.FromContext(r.Context()).Code, giver)
+}
+
+// KindFromRequest is FromRequest for the endpoints without a district filter,
+// like the findings of the background jobs: only the kind and the city are read.
+func KindFromRequest(r *http.Request) (models.Filter, error) {
+	kind, err := kindFromQuery(r.URL.Query())
+	if err != nil {
+		return models.Filter{}, err
+	}
+
+	return models.Filter{
+		City: city.FromContext(r.Context()).Code,
+		Kind: kind,
+	}, nil
+}
+
+func kindFromQuery(query url.Values) (string, error) {
+	kind := strings.ToLower(strings.TrimSpace(query.Get("kind")))
+	if kind != "" && !slices.Contains(models.BlackoutKinds, kind) {
+		return "", ErrUnknownKind
+	}
+
+	return kind, nil
+}
+
+// Render writes the error of FromRequest as the response and reports whether
+// there was one, so the handler only has to return
+func Render(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrUnknownDistrict):
+		log.Warn("unknown district", slog.String("district", r.URL.Query().Get("district")))
+		render.JSON(w, r, response.Error("unknown district"))
+	case errors.Is(err, ErrUnknownKind):
+		log.Warn("unknown kind", slog.String("kind", r.URL.Query().Get("kind")))
+		render.JSON(w, r, response.Error("unknown kind, use: "+strings.Join(models.BlackoutKinds, ", ")))
+	default:
+		log.Error("failed to get districts", sl.Err(err))
+		render.JSON(w, r, response.Error("failed to get districts"))
+	}
+
+	return true
+}
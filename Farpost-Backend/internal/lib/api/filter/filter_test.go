@@ -0,0 +1,87 @@
+package filter
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"vlru-prsch/internal/models"
+)
+
+type giver struct {
+	err   error
+	calls int
+}
+
+// GetDistricts knows two districts of Vladivostok and one of Khabarovsk
+func (g *giver) GetDistricts(filter models.Filter) ([]models.District, error) {
+	g.calls++
+	if filter.City == "khabarovsk" {
+		return []models.District{{ID: 3, Name: "Центральный"}}, g.err
+	}
+	return []models.District{{ID: 1, Name: "Ленинский"}, {ID: 2, Name: "Фрунзенский"}}, g.err
+}
+
+func TestFromQuery(t *testing.T) {
+	failure := errors.New("disk I/O error")
+
+	tests := []struct {
+		name      string
+		query     string
+		city      string
+		err       error
+		want      models.Filter
+		wantErr   error
+		wantCalls int
+	}{
+		{"no district", "", "vladivostok", nil, models.Filter{City: "vladivostok"}, nil, 0},
+		{"blank district", "district=%20", "vladivostok", nil, models.Filter{City: "vladivostok"}, nil, 0},
+		{"stored name", "district=%D0%BB%D0%B5%D0%BD%D0%B8%D0%BD%D1%81%D0%BA%D0%B8%D0%B9", "vladivostok", nil, models.Filter{City: "vladivostok", District: "Ленинский"}, nil, 1},
+		{"district of another city", "district=%D0%BB%D0%B5%D0%BD%D0%B8%D0%BD%D1%81%D0%BA%D0%B8%D0%B9", "khabarovsk", nil, models.Filter{}, ErrUnknownDistrict, 1},
+		{"unknown", "district=Nowhere", "vladivostok", nil, models.Filter{}, ErrUnknownDistrict, 1},
+		{"storage failed", "district=Nowhere", "vladivostok", failure, models.Filter{}, failure, 1},
+		{"kind", "kind=%20Emergency", "vladivostok", nil, models.Filter{City: "vladivostok", Kind: "emergency"}, nil, 0},
+		{"kind and district", "kind=planned&district=%D0%A4%D1%80%D1%83%D0%BD%D0%B7%D0%B5%D0%BD%D1%81%D0%BA%D0%B8%D0%B9", "vladivostok", nil, models.Filter{City: "vladivostok", District: "Фрунзенский", Kind: "planned"}, nil, 1},
+		{"unknown kind is checked first", "kind=scheduled&district=Nowhere", "vladivostok", nil, models.Filter{}, ErrUnknownKind, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g := &giver{err: tt.err}
+			got, err := FromQuery(query, tt.city, g)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if g.calls != tt.wantCalls {
+				t.Errorf("districts were read %d times, want %d", g.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestKindFromRequest(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    models.Filter
+		wantErr error
+	}{
+		{"/off/hotspots", models.Filter{}, nil},
+		{"/off/hotspots?kind=unknown&district=Nowhere", models.Filter{Kind: "unknown"}, nil},
+		{"/off/hotspots?kind=scheduled", models.Filter{}, ErrUnknownKind},
+	}
+
+	for _, tt := range tests {
+		got, err := KindFromRequest(httptest.NewRequest("GET", tt.target, nil))
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("%s: got %+v, %v, want %+v, %v", tt.target, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -2,6 +2,8 @@
 
 package response
 
+import "bytes"
+
 // Response represents a standard API response
 // @Description Стандартный ответ API
 type Response struct {
@@ -24,4 +26,12 @@ func Error(msg string) Response {
 		Status: "ERROR",
 		Error:  msg,
 	}
-}
\ No newline at end of file
+}
+// failed is how every encoded error response starts
+var failed = []byte(`{"status":"ERROR"`)
+
+// Failed reports whether an encoded response body is an error response.
+// Errors are sent with status 200, middlewares keeping responses check the body.
+func Failed(body []byte) bool {
+	return bytes.HasPrefix(body, failed)
+}
@@ -25,4 +25,18 @@ func ParseQueryDate(date string) (string, error) {
 	}
 
 	return result, nil
-}
\ No newline at end of file
+}
+// ParseQueryDateIn works as ParseQueryDate, but converts RFC3339 times to the
+// wall clock of loc, the time zone the stored times are kept in
+func ParseQueryDateIn(date string, loc *time.Location) (string, error) {
+	if !strings.Contains(date, "T") {
+		return ParseQueryDate(date)
+	}
+
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return "", err
+	}
+
+	return t.In(loc).Format("2006-01-02 15:04:05"), nil
+}
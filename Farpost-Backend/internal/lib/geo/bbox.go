@@ -0,0 +1,36 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"vlru-prsch/internal/models"
+)
+
+// ParseBBox parses "minLon,minLat,maxLon,maxLat", the order used by GeoJSON
+func ParseBBox(value string) (models.BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return models.BBox{}, fmt.Errorf("bbox must have 4 comma separated numbers")
+	}
+
+	var nums [4]float64
+	for i, part := range parts {
+		num, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return models.BBox{}, fmt.Errorf("invalid bbox number %q: %w", part, err)
+		}
+		nums[i] = num
+	}
+
+	bbox := models.BBox{MinLon: nums[0], MinLat: nums[1], MaxLon: nums[2], MaxLat: nums[3]}
+
+	if bbox.MinLon > bbox.MaxLon || bbox.MinLat > bbox.MaxLat {
+		return models.BBox{}, fmt.Errorf("bbox minimum is greater than maximum")
+	}
+	if bbox.MinLat < -90 || bbox.MaxLat > 90 || bbox.MinLon < -180 || bbox.MaxLon > 180 {
+		return models.BBox{}, fmt.Errorf("bbox is out of range")
+	}
+
+	return bbox, nil
+}
@@ -0,0 +1,36 @@
+package geo
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+)
+
+func TestParseBBox(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    models.BBox
+		wantErr bool
+	}{
+		{"valid", "131.85,43.08,131.95,43.15", models.BBox{MinLon: 131.85, MinLat: 43.08, MaxLon: 131.95, MaxLat: 43.15}, false},
+		{"spaces", " 131.85, 43.08 ,131.95,43.15", models.BBox{MinLon: 131.85, MinLat: 43.08, MaxLon: 131.95, MaxLat: 43.15}, false},
+		{"a point", "131.9,43.1,131.9,43.1", models.BBox{MinLon: 131.9, MinLat: 43.1, MaxLon: 131.9, MaxLat: 43.1}, false},
+		{"three numbers", "131.85,43.08,131.95", models.BBox{}, true},
+		{"not a number", "131.85,north,131.95,43.15", models.BBox{}, true},
+		{"minimum over maximum", "131.95,43.08,131.85,43.15", models.BBox{}, true},
+		{"latitude out of range", "131.85,-91,131.95,43.15", models.BBox{}, true},
+		{"longitude out of range", "131.85,43.08,181,43.15", models.BBox{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBBox(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"math"
+)
+
+const earthRadius = 6371000.0
+
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Distance returns the great-circle distance between two points in meters
+func Distance(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Polygon is an outer ring followed by optional holes, as in GeoJSON
+type Polygon [][]Point
+
+// Contains reports whether the point is inside the outer ring and outside every hole
+func (p Polygon) Contains(pt Point) bool {
+	if len(p) == 0 || !ringContains(p[0], pt) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bounds returns the bounding box of the outer ring
+func (p Polygon) Bounds() (min, max Point) {
+	min = Point{Lat: math.Inf(1), Lon: math.Inf(1)}
+	max = Point{Lat: math.Inf(-1), Lon: math.Inf(-1)}
+
+	if len(p) == 0 {
+		return min, max
+	}
+
+	for _, pt := range p[0] {
+		min.Lat = math.Min(min.Lat, pt.Lat)
+		min.Lon = math.Min(min.Lon, pt.Lon)
+		max.Lat = math.Max(max.Lat, pt.Lat)
+		max.Lon = math.Max(max.Lon, pt.Lon)
+	}
+
+	return min, max
+}
+
+// Centroid returns the average of the outer ring vertices, good enough
+// as a reference point for sorting results of small city polygons
+func (p Polygon) Centroid() Point {
+	if len(p) == 0 || len(p[0]) == 0 {
+		return Point{}
+	}
+
+	ring := p[0]
+	// a closed ring repeats its first vertex at the end
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+
+	var c Point
+	for _, pt := range ring {
+		c.Lat += pt.Lat
+		c.Lon += pt.Lon
+	}
+	c.Lat /= float64(len(ring))
+	c.Lon /= float64(len(ring))
+
+	return c
+}
+
+func ringContains(ring []Point, pt Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lon < (b.Lon-a.Lon)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
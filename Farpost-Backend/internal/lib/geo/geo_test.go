@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// square is a 0.02 degree square around the center of Vladivostok with a
+// 0.01 degree hole in the middle
+var square = Polygon{
+	{{43.10, 131.88}, {43.10, 131.90}, {43.12, 131.90}, {43.12, 131.88}, {43.10, 131.88}},
+	{{43.1075, 131.8875}, {43.1075, 131.8925}, {43.1125, 131.8925}, {43.1125, 131.8875}, {43.1075, 131.8875}},
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", Point{43.1, 131.9}, Point{43.1, 131.9}, 0},
+		{"a degree of latitude", Point{43, 131.9}, Point{44, 131.9}, 111195},
+		{"a degree of longitude shrinks with latitude", Point{43, 131}, Point{43, 132}, 81322},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); math.Abs(got-tt.want) > 1 {
+				t.Errorf("got %.1f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	tests := []struct {
+		name string
+		pt   Point
+		want bool
+	}{
+		{"inside", Point{43.105, 131.885}, true},
+		{"in the hole", Point{43.11, 131.89}, false},
+		{"outside", Point{43.13, 131.89}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := square.Contains(tt.pt); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (Polygon{}).Contains(Point{43.11, 131.89}) {
+		t.Error("empty polygon contains a point")
+	}
+}
+
+func TestPolygonBoundsAndCentroid(t *testing.T) {
+	min, max := square.Bounds()
+	if min != (Point{43.10, 131.88}) || max != (Point{43.12, 131.90}) {
+		t.Errorf("got bounds %v %v", min, max)
+	}
+
+	// the closing vertex is not counted twice
+	c := square.Centroid()
+	if math.Abs(c.Lat-43.11) > 1e-9 || math.Abs(c.Lon-131.89) > 1e-9 {
+		t.Errorf("got centroid %v, want {43.11 131.89}", c)
+	}
+}
@@ -0,0 +1,117 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// cellSize is the grid step in degrees, about 1.1 km by latitude
+const cellSize = 0.01
+
+// Index is an in-memory grid index of points. It is built once and is read-only afterwards.
+type Index struct {
+	cells  map[cell][]entry
+	points int
+}
+
+type cell struct {
+	x, y int
+}
+
+type entry struct {
+	id    int64
+	point Point
+}
+
+// Hit is a point found by a query with its distance to the reference point in meters
+type Hit struct {
+	ID       int64
+	Point    Point
+	Distance float64
+}
+
+func NewIndex() *Index {
+	return &Index{cells: make(map[cell][]entry)}
+}
+
+func (idx *Index) Insert(id int64, pt Point) {
+	c := cellOf(pt)
+	idx.cells[c] = append(idx.cells[c], entry{id: id, point: pt})
+	idx.points++
+}
+
+func (idx *Index) Len() int {
+	return idx.points
+}
+
+// Nearby returns the points within radius meters from center, nearest first
+func (idx *Index) Nearby(center Point, radius float64) []Hit {
+	dLat := radius / earthRadius * 180 / math.Pi
+	dLon := dLat / math.Max(math.Cos(center.Lat*math.Pi/180), 0.01)
+
+	var hits []Hit
+	idx.scan(Point{Lat: center.Lat - dLat, Lon: center.Lon - dLon}, Point{Lat: center.Lat + dLat, Lon: center.Lon + dLon}, func(e entry) {
+		if d := Distance(center, e.point); d <= radius {
+			hits = append(hits, Hit{ID: e.id, Point: e.point, Distance: d})
+		}
+	})
+
+	sortHits(hits)
+	return hits
+}
+
+// Within returns the points inside the polygon sorted by distance from ref
+func (idx *Index) Within(polygon Polygon, ref Point) []Hit {
+	min, max := polygon.Bounds()
+
+	var hits []Hit
+	idx.scan(min, max, func(e entry) {
+		if polygon.Contains(e.point) {
+			hits = append(hits, Hit{ID: e.id, Point: e.point, Distance: Distance(ref, e.point)})
+		}
+	})
+
+	sortHits(hits)
+	return hits
+}
+
+func (idx *Index) scan(min, max Point, fn func(e entry)) {
+	lo, hi := cellOf(min), cellOf(max)
+
+	// a huge box would walk more cells than there are points, look at every cell instead
+	if (hi.x-lo.x+1)*(hi.y-lo.y+1) > len(idx.cells) {
+		for c, entries := range idx.cells {
+			if c.x < lo.x || c.x > hi.x || c.y < lo.y || c.y > hi.y {
+				continue
+			}
+			for _, e := range entries {
+				fn(e)
+			}
+		}
+		return
+	}
+
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			for _, e := range idx.cells[cell{x, y}] {
+				fn(e)
+			}
+		}
+	}
+}
+
+func cellOf(pt Point) cell {
+	return cell{
+		x: int(math.Floor(pt.Lon / cellSize)),
+		y: int(math.Floor(pt.Lat / cellSize)),
+	}
+}
+
+func sortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Distance != hits[j].Distance {
+			return hits[i].Distance < hits[j].Distance
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
@@ -0,0 +1,61 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func hitIDs(hits []Hit) []int64 {
+	var ids []int64
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestIndex(t *testing.T) {
+	idx := NewIndex()
+	idx.Insert(1, Point{43.1100, 131.8900})
+	idx.Insert(2, Point{43.1110, 131.8900}) // about 111 m north of 1
+	idx.Insert(3, Point{43.1050, 131.8850}) // in the next grid cell
+	idx.Insert(4, Point{43.1600, 131.9500}) // several kilometers away
+
+	if idx.Len() != 4 {
+		t.Fatalf("got %d points, want 4", idx.Len())
+	}
+
+	center := Point{43.1101, 131.8900}
+
+	tests := []struct {
+		name   string
+		radius float64
+		want   []int64
+	}{
+		{"nearest first", 200, []int64{1, 2}},
+		{"across cells", 1000, []int64{1, 2, 3}},
+		{"whole city", 20000, []int64{1, 2, 3, 4}},
+		{"nothing around", 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := idx.Nearby(center, tt.radius)
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for _, hit := range hits {
+				if hit.Distance > tt.radius {
+					t.Errorf("hit %d is %.1f m away", hit.ID, hit.Distance)
+				}
+			}
+		})
+	}
+
+	t.Run("within", func(t *testing.T) {
+		// 1 and 2 are in the hole of the square
+		hits := idx.Within(square, Point{43.105, 131.885})
+		if got := hitIDs(hits); !reflect.DeepEqual(got, []int64{3}) {
+			t.Errorf("got %v, want [3]", got)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package geojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"vlru-prsch/internal/lib/geo"
+)
+
+// FeatureCollection is a GeoJSON (RFC 7946) feature collection
+// @Description GeoJSON FeatureCollection
+type FeatureCollection struct {
+	Type     string    `json:"type" example:"FeatureCollection"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature
+// @Description GeoJSON Feature
+type Feature struct {
+	Type       string         `json:"type" example:"Feature"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry. Coordinates are kept raw, their shape depends on the type
+// @Description GeoJSON Geometry
+type Geometry struct {
+	Type        string          `json:"type" example:"Point"`
+	Coordinates json.RawMessage `json:"coordinates" swaggertype:"array,number" example:"131.8869,43.1155"`
+}
+
+func NewFeatureCollection(features []Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// NewPoint returns a point feature. GeoJSON orders coordinates as longitude, latitude.
+func NewPoint(lon, lat float64, properties map[string]any) Feature {
+	coordinates, _ := json.Marshal([2]float64{lon, lat})
+
+	return Feature{
+		Type:       "Feature",
+		Geometry:   Geometry{Type: "Point", Coordinates: coordinates},
+		Properties: properties,
+	}
+}
+
+// ParsePolygon reads a Polygon geometry or a Feature holding one
+func ParsePolygon(data []byte) (geo.Polygon, error) {
+	var object struct {
+		Type        string          `json:"type"`
+		Geometry    *Geometry       `json:"geometry"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	geometry := Geometry{Type: object.Type, Coordinates: object.Coordinates}
+	if object.Type == "Feature" {
+		if object.Geometry == nil {
+			return nil, errors.New("feature has no geometry")
+		}
+		geometry = *object.Geometry
+	}
+
+	if geometry.Type != "Polygon" {
+		return nil, fmt.Errorf("expected Polygon geometry, got %q", geometry.Type)
+	}
+
+	var rings [][][]float64
+	if err := json.Unmarshal(geometry.Coordinates, &rings); err != nil {
+		return nil, fmt.Errorf("invalid polygon coordinates: %w", err)
+	}
+	if len(rings) == 0 {
+		return nil, errors.New("polygon has no rings")
+	}
+
+	polygon := make(geo.Polygon, 0, len(rings))
+	for _, positions := range rings {
+		if len(positions) < 4 {
+			return nil, errors.New("polygon ring must have at least 4 positions")
+		}
+
+		ring := make([]geo.Point, 0, len(positions))
+		for _, position := range positions {
+			if len(position) < 2 {
+				return nil, errors.New("position must have longitude and latitude")
+			}
+
+			point := geo.Point{Lon: position[0], Lat: position[1]}
+			if point.Lon < -180 || point.Lon > 180 || point.Lat < -90 || point.Lat > 90 {
+				return nil, fmt.Errorf("position out of range: %v", position)
+			}
+			ring = append(ring, point)
+		}
+		polygon = append(polygon, ring)
+	}
+
+	return polygon, nil
+}
@@ -0,0 +1,40 @@
+package geojson
+
+import (
+	"reflect"
+	"testing"
+	"vlru-prsch/internal/lib/geo"
+)
+
+func TestParsePolygon(t *testing.T) {
+	ring := `[[131.88,43.10],[131.90,43.10],[131.90,43.12],[131.88,43.10]]`
+	want := geo.Polygon{{{Lat: 43.10, Lon: 131.88}, {Lat: 43.10, Lon: 131.90}, {Lat: 43.12, Lon: 131.90}, {Lat: 43.10, Lon: 131.88}}}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    geo.Polygon
+		wantErr bool
+	}{
+		{"geometry", `{"type":"Polygon","coordinates":[` + ring + `]}`, want, false},
+		{"feature", `{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[` + ring + `]}}`, want, false},
+		{"not JSON", `polygon`, nil, true},
+		{"feature without geometry", `{"type":"Feature"}`, nil, true},
+		{"point", `{"type":"Point","coordinates":[131.88,43.10]}`, nil, true},
+		{"no rings", `{"type":"Polygon","coordinates":[]}`, nil, true},
+		{"open ring", `{"type":"Polygon","coordinates":[[[131.88,43.10],[131.90,43.10],[131.88,43.10]]]}`, nil, true},
+		{"out of range", `{"type":"Polygon","coordinates":[[[131.88,43.10],[131.90,43.10],[131.90,93],[131.88,43.10]]]}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolygon([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,10 @@ package slogpretty
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	stdLog "log"
+	"time"
 
 	"github.com/fatih/color"
 	"log/slog"
@@ -58,6 +60,13 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 		fields[a.Key] = a.Value.Any()
 	}
 
+	msg := color.CyanString(r.Message)
+
+	// access log records lead with a request line, the other fields follow
+	if line, ok := requestLine(fields); ok {
+		msg = line
+	}
+
 	var b []byte
 	var err error
 
@@ -69,7 +78,6 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 	}
 
 	timeStr := r.Time.Format("[15:05:05.000]")
-	msg := color.CyanString(r.Message)
 
 	h.l.Println(
 		timeStr,
@@ -81,6 +89,36 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 	return nil
 }
 
+// requestLine renders the method, path, status and duration of an access log
+// record like "GET /off/cities 200 1.2ms" and takes them out of the fields
+func requestLine(fields map[string]interface{}) (string, bool) {
+	method, okMethod := fields["method"].(string)
+	path, okPath := fields["path"].(string)
+	status, okStatus := fields["status"].(int64)
+	duration, okDuration := fields["duration"].(time.Duration)
+
+	if !okMethod || !okPath || !okStatus || !okDuration {
+		return "", false
+	}
+
+	// an error body sent with 200 is failed as well
+	failed, _ := fields["failed"].(bool)
+
+	statusStr := color.GreenString("%d", status)
+	switch {
+	case status >= 500:
+		statusStr = color.RedString("%d", status)
+	case status >= 400 || failed:
+		statusStr = color.YellowString("%d", status)
+	}
+
+	for _, key := range []string{"method", "path", "status", "duration", "failed"} {
+		delete(fields, key)
+	}
+
+	return fmt.Sprintf("%s %s %s %s", color.CyanString(method), color.CyanString(path), statusStr, duration.Round(time.Microsecond)), true
+}
+
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	return &PrettyHandler{
 		Handler: h.Handler,
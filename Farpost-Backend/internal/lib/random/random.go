@@ -0,0 +1,15 @@
+package random
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Token returns a hex encoded string built from size random bytes
+func Token(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
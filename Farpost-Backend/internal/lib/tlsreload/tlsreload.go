@@ -0,0 +1,126 @@
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+)
+
+// Reloader serves the certificate of the files and picks up a renewed one
+// without a restart. A pair that fails to load keeps the previous one in use.
+type Reloader struct {
+	log      *slog.Logger
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+func New(log *slog.Logger, certFile string, keyFile string) (*Reloader, error) {
+	const op = "tlsreload.New"
+
+	r := &Reloader{
+		log:      log,
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// GetCertificate is the tls.Config hook
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload loads the pair again
+func (r *Reloader) Reload() error {
+	const op = "tlsreload.Reloader.Reload"
+
+	modified, err := r.lastModified()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modified = modified
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Run reloads the pair every interval when one of the files has changed
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	const op = "tlsreload.Reloader.Run"
+
+	log := r.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modified, err := r.lastModified()
+		if err != nil {
+			log.Error("failed to check certificate", sl.Err(err))
+			continue
+		}
+
+		r.mu.RLock()
+		changed := !modified.Equal(r.modified)
+		r.mu.RUnlock()
+
+		if !changed {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			log.Error("failed to reload certificate, keeping the previous one", sl.Err(err))
+			continue
+		}
+
+		log.Info("certificate reloaded", slog.String("cert_file", r.certFile))
+	}
+}
+
+// lastModified is the later modification time of the two files
+func (r *Reloader) lastModified() (time.Time, error) {
+	var last time.Time
+
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	return last, nil
+}
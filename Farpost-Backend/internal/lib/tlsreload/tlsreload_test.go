@@ -0,0 +1,174 @@
+package tlsreload
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePair writes a self-signed certificate for the host and its key
+func writePair(t *testing.T, certFile, keyFile, host string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	write(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func write(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// host is the name the served certificate was issued for
+func host(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func files(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	return filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+}
+
+func TestNew(t *testing.T) {
+	certFile, keyFile := files(t)
+
+	if _, err := New(slog.New(slog.DiscardHandler), certFile, keyFile); err == nil {
+		t.Error("New without the files: no error")
+	}
+
+	writePair(t, certFile, keyFile, "old.example")
+
+	r, err := New(slog.New(slog.DiscardHandler), certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := host(t, r); got != "old.example" {
+		t.Errorf("certificate of %s, want old.example", got)
+	}
+}
+
+func TestReload(t *testing.T) {
+	certFile, keyFile := files(t)
+	writePair(t, certFile, keyFile, "old.example")
+
+	r, err := New(slog.New(slog.DiscardHandler), certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePair(t, certFile, keyFile, "new.example")
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := host(t, r); got != "new.example" {
+		t.Errorf("certificate of %s after a reload, want new.example", got)
+	}
+
+	// a key that does not match the certificate keeps the pair in use
+	other, _ := files(t)
+	writePair(t, other, keyFile, "other.example")
+	if err := r.Reload(); err == nil {
+		t.Error("Reload of a mismatched pair: no error")
+	}
+	if got := host(t, r); got != "new.example" {
+		t.Errorf("certificate of %s after a failed reload, want new.example", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	certFile, keyFile := files(t)
+	writePair(t, certFile, keyFile, "old.example")
+
+	r, err := New(slog.New(slog.DiscardHandler), certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx, 10*time.Millisecond)
+
+	// the renewed pair gets a later modification time, as after a copy
+	writePair(t, certFile, keyFile, "new.example")
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{certFile, keyFile} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for host(t, r) != "new.example" {
+		if time.Now().After(deadline) {
+			t.Fatal("the renewed certificate was not picked up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the server hands it out in the handshake
+	config := &tls.Config{GetCertificate: r.GetCertificate}
+	cert, err := config.GetCertificate(&tls.ClientHelloInfo{ServerName: "new.example"})
+	if err != nil || !bytes.Equal(cert.Certificate[0], mustCert(t, certFile)) {
+		t.Errorf("GetCertificate = %v, want the renewed certificate", err)
+	}
+}
+
+func mustCert(t *testing.T, certFile string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	return block.Bytes
+}
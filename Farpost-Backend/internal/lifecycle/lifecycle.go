@@ -0,0 +1,214 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+type Storage interface {
+	GetAllBlackouts(filter models.Filter) ([]models.Blackout, error)
+	GetLatestRevisions(filter models.Filter) (map[string]models.Revision, error)
+	SaveRevisions(revisions []models.Revision) error
+}
+
+// State returns the lifecycle state of the blackout at now. An outage whose end
+// moved later, or became unknown, stays extended until it is resolved.
+func State(prev *models.Revision, blackout models.Blackout, now string) string {
+	switch {
+	case blackout.EndDate != "" && blackout.EndDate <= now:
+		return models.StateResolved
+	case prev != nil && prev.State == models.StateExtended:
+		return models.StateExtended
+	case prev != nil && prev.EndDate != "" && (blackout.EndDate == "" || blackout.EndDate > prev.EndDate):
+		return models.StateExtended
+	case blackout.StartDate > now:
+		return models.StateAnnounced
+	default:
+		return models.StateActive
+	}
+}
+
+// Revise returns the revision recording the blackout as it is at now, or false
+// when nothing changed since prev. A change of the state alone is made by the system.
+func Revise(prev *models.Revision, blackout models.Blackout, now string, actor string) (models.Revision, bool) {
+	revision := models.Revision{
+		BlackoutID:  blackout.ID,
+		StartDate:   blackout.StartDate,
+		EndDate:     blackout.EndDate,
+		Description: blackout.Description,
+		State:       State(prev, blackout, now),
+		Actor:       actor,
+		ChangedAt:   now,
+	}
+
+	if prev == nil {
+		return revision, true
+	}
+
+	sameData := prev.StartDate == revision.StartDate &&
+		prev.EndDate == revision.EndDate &&
+		prev.Description == revision.Description
+
+	if sameData && prev.State == revision.State {
+		return models.Revision{}, false
+	}
+
+	if sameData {
+		revision.Actor = models.ActorSystem
+	}
+
+	return revision, true
+}
+
+// Changes lists the fields that differ between a revision and the one before it.
+// The first revision of a blackout has no changes.
+func Changes(prev *models.Revision, revision models.Revision) []models.RevisionChange {
+	changes := []models.RevisionChange{}
+	if prev == nil {
+		return changes
+	}
+
+	for _, field := range []struct {
+		name     string
+		from, to string
+	}{
+		{"start_date", prev.StartDate, revision.StartDate},
+		{"end_date", prev.EndDate, revision.EndDate},
+		{"description", prev.Description, revision.Description},
+		{"state", prev.State, revision.State},
+	} {
+		if field.from != field.to {
+			changes = append(changes, models.RevisionChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+
+	return changes
+}
+
+// Tracker periodically compares the blackouts with their last revisions and
+// appends a revision for every change made by the source or by the clock.
+// A blackout removed from the source before it was resolved is recorded as cancelled.
+// The clock of every city is its wall clock, the dates of its blackouts are.
+type Tracker struct {
+	log      *slog.Logger
+	store    Storage
+	interval time.Duration
+	cities   []models.City
+	now      func() time.Time
+}
+
+func NewTracker(log *slog.Logger, store Storage, interval time.Duration, cities []models.City) *Tracker {
+	return &Tracker{
+		log:      log,
+		store:    store,
+		interval: interval,
+		cities:   cities,
+		now:      time.Now,
+	}
+}
+
+func (t *Tracker) Run(ctx context.Context) {
+	const op = "lifecycle.Tracker.Run"
+
+	log := t.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.Track(); err != nil {
+			log.Error("failed to track blackout changes", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Track records the changes since the previous run in every city
+func (t *Tracker) Track() error {
+	const op = "lifecycle.Tracker.Track"
+
+	cities := t.cities
+	if len(cities) == 0 {
+		cities = []models.City{{}}
+	}
+
+	for _, city := range cities {
+		if err := t.track(city); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *Tracker) track(city models.City) error {
+	current := t.now()
+	if city.Location != nil {
+		current = current.In(city.Location)
+	}
+	now := current.Format(timeLayout)
+
+	filter := models.Filter{City: city.Code}
+
+	blackouts, err := t.store.GetAllBlackouts(filter)
+	if err != nil {
+		return err
+	}
+
+	latest, err := t.store.GetLatestRevisions(filter)
+	if err != nil {
+		return err
+	}
+
+	var revisions []models.Revision
+
+	seen := make(map[string]bool, len(blackouts))
+	for _, blackout := range blackouts {
+		seen[blackout.ID] = true
+
+		var prev *models.Revision
+		if revision, ok := latest[blackout.ID]; ok {
+			prev = &revision
+		}
+
+		if revision, changed := Revise(prev, blackout, now, models.ActorSource); changed {
+			revisions = append(revisions, revision)
+		}
+	}
+
+	for id, prev := range latest {
+		if seen[id] || prev.State == models.StateCancelled || prev.State == models.StateResolved {
+			continue
+		}
+
+		cancelled := prev
+		cancelled.ID = 0
+		cancelled.State = models.StateCancelled
+		cancelled.Actor = models.ActorSource
+		cancelled.ChangedAt = now
+		revisions = append(revisions, cancelled)
+	}
+
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	if err := t.store.SaveRevisions(revisions); err != nil {
+		return err
+	}
+
+	t.log.Debug("blackout revisions recorded", slog.String("city", city.Code), slog.Int("count", len(revisions)))
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package lifecycle
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+const now = "2024-03-10 12:00:00"
+
+func blackout(start, end, description string) models.Blackout {
+	return models.Blackout{ID: "b1", StartDate: start, EndDate: end, Description: description}
+}
+
+func revision(start, end, description, state string) *models.Revision {
+	return &models.Revision{BlackoutID: "b1", StartDate: start, EndDate: end, Description: description, State: state}
+}
+
+func TestState(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     *models.Revision
+		blackout models.Blackout
+		want     string
+	}{
+		{"announced", nil, blackout("2024-03-11 09:00:00", "2024-03-11 18:00:00", ""), models.StateAnnounced},
+		{"active", nil, blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", ""), models.StateActive},
+		{"active without an end", nil, blackout("2024-03-10 09:00:00", "", ""), models.StateActive},
+		{"starts right now", nil, blackout(now, "2024-03-10 18:00:00", ""), models.StateActive},
+		{"resolved", nil, blackout("2024-03-10 09:00:00", "2024-03-10 11:00:00", ""), models.StateResolved},
+		{"resolved right now", nil, blackout("2024-03-10 09:00:00", now, ""), models.StateResolved},
+		{
+			"end moved later",
+			revision("2024-03-10 09:00:00", "2024-03-10 14:00:00", "", models.StateActive),
+			blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", ""),
+			models.StateExtended,
+		},
+		{
+			"end became unknown",
+			revision("2024-03-10 09:00:00", "2024-03-10 14:00:00", "", models.StateActive),
+			blackout("2024-03-10 09:00:00", "", ""),
+			models.StateExtended,
+		},
+		{
+			"end moved earlier",
+			revision("2024-03-10 09:00:00", "2024-03-10 18:00:00", "", models.StateActive),
+			blackout("2024-03-10 09:00:00", "2024-03-10 14:00:00", ""),
+			models.StateActive,
+		},
+		{
+			"an unknown end becoming known is no extension",
+			revision("2024-03-10 09:00:00", "", "", models.StateActive),
+			blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", ""),
+			models.StateActive,
+		},
+		{
+			"extended stays extended",
+			revision("2024-03-10 09:00:00", "2024-03-10 18:00:00", "", models.StateExtended),
+			blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", ""),
+			models.StateExtended,
+		},
+		{
+			"extended until resolved",
+			revision("2024-03-10 09:00:00", "2024-03-10 11:30:00", "", models.StateExtended),
+			blackout("2024-03-10 09:00:00", "2024-03-10 11:30:00", ""),
+			models.StateResolved,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := State(tt.prev, tt.blackout, now); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevise(t *testing.T) {
+	active := revision("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Плановые работы", models.StateActive)
+	announced := revision("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Плановые работы", models.StateAnnounced)
+
+	tests := []struct {
+		name        string
+		prev        *models.Revision
+		blackout    models.Blackout
+		wantChanged bool
+		wantState   string
+		wantActor   string
+	}{
+		{"first revision", nil, blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Плановые работы"), true, models.StateActive, "operator"},
+		{"nothing changed", active, blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Плановые работы"), false, "", ""},
+		{"the clock moved the state", announced, blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Плановые работы"), true, models.StateActive, models.ActorSystem},
+		{"description changed", active, blackout("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Авария"), true, models.StateActive, "operator"},
+		{"start changed", active, blackout("2024-03-10 10:00:00", "2024-03-10 18:00:00", "Плановые работы"), true, models.StateActive, "operator"},
+		{"end moved later", active, blackout("2024-03-10 09:00:00", "2024-03-10 20:00:00", "Плановые работы"), true, models.StateExtended, "operator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := Revise(tt.prev, tt.blackout, now, "operator")
+			if changed != tt.wantChanged {
+				t.Fatalf("got changed %v, want %v", changed, tt.wantChanged)
+			}
+			if !changed {
+				if got != (models.Revision{}) {
+					t.Errorf("got %+v, want an empty revision", got)
+				}
+				return
+			}
+
+			if got.State != tt.wantState || got.Actor != tt.wantActor {
+				t.Errorf("got state %s by %s, want %s by %s", got.State, got.Actor, tt.wantState, tt.wantActor)
+			}
+			if got.BlackoutID != "b1" || got.ChangedAt != now || got.StartDate != tt.blackout.StartDate || got.EndDate != tt.blackout.EndDate || got.Description != tt.blackout.Description {
+				t.Errorf("got %+v, want the blackout as it is at %s", got, now)
+			}
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	prev := revision("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Плановые работы", models.StateActive)
+
+	tests := []struct {
+		name     string
+		prev     *models.Revision
+		revision models.Revision
+		want     []models.RevisionChange
+	}{
+		{"first revision", nil, *prev, []models.RevisionChange{}},
+		{"same", prev, *prev, []models.RevisionChange{}},
+		{"end and state", prev, *revision("2024-03-10 09:00:00", "2024-03-10 20:00:00", "Плановые работы", models.StateExtended), []models.RevisionChange{
+			{Field: "end_date", From: "2024-03-10 18:00:00", To: "2024-03-10 20:00:00"},
+			{Field: "state", From: models.StateActive, To: models.StateExtended},
+		}},
+		{"description", prev, *revision("2024-03-10 09:00:00", "2024-03-10 18:00:00", "Авария", models.StateActive), []models.RevisionChange{
+			{Field: "description", From: "Плановые работы", To: "Авария"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Changes(tt.prev, tt.revision); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// storage keeps the blackouts of the default city, those of other cities are in cities
+type storage struct {
+	blackouts []models.Blackout
+	latest    map[string]models.Revision
+	saved     []models.Revision
+	cities    map[string][]models.Blackout
+}
+
+func (s *storage) GetAllBlackouts(filter models.Filter) ([]models.Blackout, error) {
+	if filter.City != "" {
+		return s.cities[filter.City], nil
+	}
+	return s.blackouts, nil
+}
+
+func (s *storage) GetLatestRevisions(filter models.Filter) (map[string]models.Revision, error) {
+	if filter.City != "" {
+		return nil, nil
+	}
+	return s.latest, nil
+}
+
+func (s *storage) SaveRevisions(revisions []models.Revision) error {
+	s.saved = append(s.saved, revisions...)
+	return nil
+}
+
+func TestTrack(t *testing.T) {
+	store := &storage{
+		blackouts: []models.Blackout{
+			{ID: "new", StartDate: "2024-03-11 09:00:00", EndDate: "2024-03-11 18:00:00"},
+			{ID: "same", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00"},
+		},
+		latest: map[string]models.Revision{
+			"same":     {BlackoutID: "same", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", State: models.StateActive},
+			"gone":     {ID: 7, BlackoutID: "gone", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", State: models.StateActive},
+			"resolved": {BlackoutID: "resolved", StartDate: "2024-03-09 09:00:00", EndDate: "2024-03-09 18:00:00", State: models.StateResolved},
+			"dropped":  {BlackoutID: "dropped", State: models.StateCancelled},
+		},
+	}
+
+	tracker := NewTracker(slog.New(slog.DiscardHandler), store, time.Minute, nil)
+	tracker.now = func() time.Time { return time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC) }
+
+	if err := tracker.Track(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]models.Revision{}
+	for _, r := range store.saved {
+		got[r.BlackoutID] = r
+	}
+	if len(store.saved) != 2 {
+		t.Fatalf("got %+v, want a revision of the new blackout and one of the removed one", store.saved)
+	}
+	if r := got["new"]; r.State != models.StateAnnounced || r.Actor != models.ActorSource || r.ChangedAt != now {
+		t.Errorf("got %+v, want the new blackout announced by the source", r)
+	}
+	if r := got["gone"]; r.ID != 0 || r.State != models.StateCancelled || r.Actor != models.ActorSource || r.ChangedAt != now {
+		t.Errorf("got %+v, want the removed blackout cancelled as a new revision", r)
+	}
+
+	// nothing left to record
+	store.saved = nil
+	store.latest = map[string]models.Revision{"new": got["new"], "same": store.latest["same"], "gone": got["gone"]}
+	if err := tracker.Track(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.saved) != 0 {
+		t.Errorf("got %+v on a second run, want nothing", store.saved)
+	}
+}
+
+func TestTrackAtTheClockOfEveryCity(t *testing.T) {
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &storage{cities: map[string][]models.Blackout{
+		"vladivostok": {{ID: "east", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00"}},
+		"moscow":      {{ID: "west", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00"}},
+	}}
+
+	tracker := NewTracker(slog.New(slog.DiscardHandler), store, time.Minute, []models.City{
+		{Code: "vladivostok", Location: vladivostok},
+		{Code: "moscow", Location: moscow},
+	})
+	// 15:00 in Vladivostok, 08:00 in Moscow
+	tracker.now = func() time.Time { return time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC) }
+
+	if err := tracker.Track(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]models.Revision{}
+	for _, r := range store.saved {
+		got[r.BlackoutID] = r
+	}
+	if r := got["east"]; r.State != models.StateActive || r.ChangedAt != "2024-03-10 15:00:00" {
+		t.Errorf("got %+v, want the blackout of Vladivostok active at 15:00", r)
+	}
+	if r := got["west"]; r.State != models.StateAnnounced || r.ChangedAt != "2024-03-10 08:00:00" {
+		t.Errorf("got %+v, want the blackout of Moscow announced at 08:00", r)
+	}
+}
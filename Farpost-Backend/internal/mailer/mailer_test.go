@@ -0,0 +1,113 @@
+package mailer_test
+
+import (
+	"encoding/base64"
+	"mime"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"vlru-prsch/internal/mailer"
+	"vlru-prsch/internal/mailer/smtptest"
+)
+
+func newSMTP(t *testing.T, server *smtptest.Server, username string) *mailer.SMTP {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(server.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mailer.NewSMTP(host, portNumber, username, "secret", "off@example.com")
+}
+
+func TestSMTPSend(t *testing.T) {
+	server := smtptest.NewServer(t)
+	sender := newSMTP(t, server, "")
+
+	body := strings.Repeat("Отключение горячей воды по адресу Карбышева ул., 54. ", 5)
+	err := sender.Send(mailer.Message{To: "resident@example.com", Subject: "Отключение горячей воды", Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+
+	if got.From != "off@example.com" || len(got.To) != 1 || got.To[0] != "resident@example.com" || got.Auth != "" {
+		t.Errorf("got envelope from %q to %v with auth %q", got.From, got.To, got.Auth)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Отключение горячей воды" {
+		t.Errorf("got subject %q", subject)
+	}
+	if got := msg.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("got content type %q", got)
+	}
+
+	var encoded strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(got.Data[strings.Index(got.Data, "\n\n")+2:]), "\n") {
+		if len(line) > 76 {
+			t.Errorf("line of %d characters, want at most 76", len(line))
+		}
+		encoded.WriteString(line)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != body {
+		t.Errorf("got body %q, want %q", decoded, body)
+	}
+}
+
+func TestSMTPSendAuthenticates(t *testing.T) {
+	server := smtptest.NewServer(t)
+
+	if err := newSMTP(t, server, "user").Send(mailer.Message{To: "resident@example.com", Subject: "s", Body: "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+
+	credentials, err := base64.StdEncoding.DecodeString(messages[0].Auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(credentials) != "\x00user\x00secret" {
+		t.Errorf("got credentials %q", credentials)
+	}
+}
+
+func TestSMTPSendRejected(t *testing.T) {
+	server := smtptest.NewServer(t, "gone@example.com")
+
+	err := newSMTP(t, server, "").Send(mailer.Message{To: "gone@example.com", Subject: "s", Body: "b"})
+	if err == nil {
+		t.Fatal("got no error for a rejected recipient")
+	}
+	if len(server.Messages()) != 0 {
+		t.Errorf("got %d messages, want none", len(server.Messages()))
+	}
+}
@@ -0,0 +1,145 @@
+// Package smtptest runs a stand-in SMTP server for tests. It speaks just
+// enough of the protocol for net/smtp and keeps the messages it accepts.
+package smtptest
+
+import (
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Message is a message the server accepted
+type Message struct {
+	From string
+	To   []string
+	// Data is the message as the client sent it, headers and body
+	Data string
+	// Auth is the argument of AUTH PLAIN, empty without authentication
+	Auth string
+}
+
+type Server struct {
+	// Addr is the host:port the server listens on
+	Addr string
+
+	listener net.Listener
+	// reject answers RCPT TO for these addresses with a permanent failure
+	reject map[string]bool
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewServer starts a server on a loopback port and stops it when the test ends.
+// Recipients in reject are refused the way a mail server refuses unknown mailboxes.
+func NewServer(t testing.TB, reject ...string) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		Addr:     listener.Addr().String(),
+		listener: listener,
+		reject:   map[string]bool{},
+	}
+	for _, to := range reject {
+		s.reject[to] = true
+	}
+
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return s
+}
+
+// Messages returns the messages accepted so far
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	reply := func(line string) { _ = tp.PrintfLine("%s", line) }
+
+	reply("220 smtptest ready")
+
+	var msg Message
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-smtptest")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			msg.Auth = strings.TrimPrefix(arg, "PLAIN ")
+			reply("235 authenticated")
+		case "MAIL":
+			msg.From = address(arg)
+			reply("250 ok")
+		case "RCPT":
+			to := address(arg)
+			if s.reject[to] {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			msg.To = append(msg.To, to)
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+
+			msg = Message{}
+			reply("250 queued")
+		case "RSET":
+			msg = Message{}
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// address takes the mailbox out of "FROM:<a@example.com>"
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	return strings.Trim(strings.TrimSpace(addr), "<>")
+}
@@ -0,0 +1,34 @@
+package models
+
+// HourlyCount is the number of outages of one type started within an hour
+type HourlyCount struct {
+	// Hour in "2006-01-02 15:00:00"
+	Hour  string
+	Type  string
+	Count int
+}
+
+// Anomaly is an hour with unusually many new outages of one type
+// @Description Всплеск числа новых отключений одного типа
+type Anomaly struct {
+	// Идентификатор
+	ID int64 `json:"id" example:"1"`
+	// Тип услуги
+	Type string `json:"type" example:"electricity"`
+	// Вид отключений, только с параметром kind
+	Kind string `json:"kind,omitempty" example:"emergency"`
+	// City is the code of the city the spike was found in
+	City string `json:"-"`
+	// Начало часа
+	Hour string `json:"hour" example:"2019-12-30 14:00:00"`
+	// Количество новых отключений за час
+	Count int `json:"count" example:"12"`
+	// Среднее за тот же час предыдущих недель
+	Baseline float64 `json:"baseline" example:"1.5"`
+	// Стандартное отклонение за тот же час предыдущих недель
+	StdDev float64 `json:"stddev" example:"0.9"`
+	// Отклонение в стандартных отклонениях
+	ZScore float64 `json:"z_score" example:"11.7"`
+	// Время обнаружения
+	DetectedAt string `json:"detected_at" example:"2019-12-30 14:25:00"`
+}
@@ -1,5 +1,73 @@
 package models
 
+// ServiceType is an entry of the service type registry
+// @Description Тип услуги из реестра
+type ServiceType struct {
+	// Код типа, используется во всех ответах и фильтрах
+	Code string `json:"code" example:"hot_water"`
+	// Название на русском
+	NameRu string `json:"name_ru" example:"горячая вода"`
+	// Название на английском
+	NameEn string `json:"name_en" example:"hot water"`
+	// Ключ иконки на фронтенде
+	Icon string `json:"icon" example:"hot-water"`
+	// Порядок отображения
+	Position int `json:"position" example:"1"`
+}
+
+// ServiceTypes is the service type registry in display order.
+// It is read from the storage on start and passed to whatever lists or checks types.
+type ServiceTypes []ServiceType
+
+// DefaultServiceTypes returns the four types the registry is seeded with
+func DefaultServiceTypes() ServiceTypes {
+	return ServiceTypes{
+		{Code: "hot_water", NameRu: "горячая вода", NameEn: "hot water", Icon: "hot-water", Position: 1},
+		{Code: "cold_water", NameRu: "холодная вода", NameEn: "cold water", Icon: "cold-water", Position: 2},
+		{Code: "electricity", NameRu: "электричество", NameEn: "electricity", Icon: "lighting", Position: 3},
+		{Code: "heat", NameRu: "отопление", NameEn: "heating", Icon: "heating", Position: 4},
+	}
+}
+
+// Codes returns the codes of the types in display order
+func (t ServiceTypes) Codes() []string {
+	codes := make([]string, 0, len(t))
+	for _, serviceType := range t {
+		codes = append(codes, serviceType.Code)
+	}
+	return codes
+}
+
+// Has reports whether the type coded by code is registered
+func (t ServiceTypes) Has(code string) bool {
+	for _, serviceType := range t {
+		if serviceType.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Name returns the Russian name of the type coded by code, the code itself
+// when the type is not registered
+func (t ServiceTypes) Name(code string) string {
+	for _, serviceType := range t {
+		if serviceType.Code == code {
+			return serviceType.NameRu
+		}
+	}
+	return code
+}
+
+const (
+	KindPlanned   = "planned"
+	KindEmergency = "emergency"
+	KindUnknown   = "unknown"
+)
+
+// BlackoutKinds lists the kinds a blackout is classified into
+var BlackoutKinds = []string{KindPlanned, KindEmergency, KindUnknown}
+
 type Blackout struct {
 	ID 				string
 	StartDate 		string
@@ -8,6 +76,7 @@ type Blackout struct {
 	Type 			string
 	InitiatorName 	string
 	Source 			string
+	Kind			string
 }
 
 // BlackoutInfo represents detailed information about a service outage
@@ -21,4 +90,11 @@ type BlackoutInfo struct {
     EndDate string `json:"end_off" example:"2019-01-16"`
     // Количество затронутых адресов/зданий
     BuildingCount int64 `json:"amount_addresses" example:"25"`
-}
\ No newline at end of file
+    // Вид отключения: planned (плановое), emergency (аварийное), unknown (не определен)
+    Kind string `json:"kind" example:"planned"`
+}
+// BlackoutWithBuildings is a blackout with the number of real buildings it affects
+type BlackoutWithBuildings struct {
+	Blackout
+	BuildingsCount int64
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceTypes(t *testing.T) {
+	types := append(DefaultServiceTypes(), ServiceType{Code: "gas", NameRu: "газ", NameEn: "gas", Position: 5})
+
+	if got, want := types.Codes(), []string{"hot_water", "cold_water", "electricity", "heat", "gas"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Codes() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		code     string
+		wantHas  bool
+		wantName string
+	}{
+		{"hot_water", true, "горячая вода"},
+		{"gas", true, "газ"},
+		{"steam", false, "steam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := types.Has(tt.code); got != tt.wantHas {
+				t.Errorf("Has() = %v, want %v", got, tt.wantHas)
+			}
+			if got := types.Name(tt.code); got != tt.wantName {
+				t.Errorf("Name() = %q, want %q", got, tt.wantName)
+			}
+		})
+	}
+}
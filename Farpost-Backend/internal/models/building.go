@@ -0,0 +1,47 @@
+package models
+
+// Address represents a building affected by a blackout
+// @Description Адрес здания, затронутого отключением
+type Address struct {
+	// Идентификатор здания
+	BuildingID int64 `json:"building_id" example:"1024"`
+	// Название улицы
+	Street string `json:"street" example:"Карбышева ул."`
+	// Номер дома
+	Number string `json:"number" example:"54"`
+}
+
+// BuildingLocation is a geocoded address read from an import file
+type BuildingLocation struct {
+	Street    string
+	Number    string
+	Latitude  float64
+	Longitude float64
+}
+
+// BBox is a bounding box in degrees
+type BBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// LocatedBuilding is a building with known coordinates
+type LocatedBuilding struct {
+	Address
+	Latitude  float64
+	Longitude float64
+}
+
+// MapBlackout is an active blackout at a building with known coordinates
+type MapBlackout struct {
+	LocatedBuilding
+	BlackoutID    string
+	Type          string
+	StartDate     string
+	EndDate       string
+	Description   string
+	InitiatorName string
+	Kind          string
+}
@@ -0,0 +1,33 @@
+package models
+
+// CacheStats are the counters of the response cache
+// @Description Статистика кэша ответов
+type CacheStats struct {
+	// Версия данных, для которой хранятся ответы
+	Version string `json:"version" example:"1730000000-52428800-7"`
+	// Число ответов в памяти
+	Entries int `json:"entries" example:"120"`
+	// Наибольшее число ответов в памяти
+	MaxEntries int `json:"max_entries" example:"1000"`
+	// Попадания в кэш
+	Hits int64 `json:"hits" example:"950"`
+	// Промахи
+	Misses int64 `json:"misses" example:"50"`
+	// Ответы, вытесненные из памяти
+	Evictions int64 `json:"evictions" example:"3"`
+	// Сбросы кэша после изменения данных
+	Invalidations int64 `json:"invalidations" example:"2"`
+	// Счетчики по endpoints
+	Endpoints []EndpointCacheStats `json:"endpoints"`
+}
+
+// EndpointCacheStats are the cache counters of one endpoint
+// @Description Статистика кэша одного endpoint
+type EndpointCacheStats struct {
+	// Путь endpoint относительно /off
+	Endpoint string `json:"endpoint" example:"/calendar"`
+	// Попадания в кэш
+	Hits int64 `json:"hits" example:"900"`
+	// Промахи
+	Misses int64 `json:"misses" example:"31"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// City is a tenant of the service, streets and blackouts belong to one city.
+// Rows without a city belong to the default one.
+// @Description Город
+type City struct {
+	// Код города, используется в адресе /{city}/off и заголовке X-City
+	Code string `json:"code" example:"vladivostok"`
+	// Название
+	Name string `json:"name" example:"Владивосток"`
+	// Часовой пояс, в нем читается время в формате RFC3339
+	Timezone string `json:"timezone" example:"Asia/Vladivostok"`
+	// Число зданий для расчета долей, 0 - по зданиям в базе
+	BuildingsTotal int64 `json:"buildings_total" example:"0"`
+	// Город по умолчанию для маршрутов /off
+	IsDefault bool `json:"is_default" example:"true"`
+
+	Location *time.Location `json:"-"`
+}
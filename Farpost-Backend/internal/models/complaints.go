@@ -5,12 +5,39 @@ package models
 type ComplaintData struct {
 	// Временная метка точки данных
 	Time string `json:"time" example:"2019-01-15 14:00:00"`
-	// Количество жалоб на отключение горячей воды
-	HotWater int `json:"hot" example:"5"`
-	// Количество жалоб на отключение холодной воды  
-	ColdWater int `json:"cold" example:"3"`
-	// Количество жалоб на отключение электричества
-	Electricity int `json:"electricity" example:"8"`
-	// Количество жалоб на отключение отопления
-	Heating int `json:"heating" example:"2"`
-}
\ No newline at end of file
+	// Количество жалоб по коду типа услуги, для каждого типа из реестра
+	Counts map[string]int `json:"counts"`
+	// Количество жалоб на отключение горячей воды, только в режиме совместимости
+	HotWater *int `json:"hot,omitempty" example:"5"`
+	// Количество жалоб на отключение холодной воды, только в режиме совместимости
+	ColdWater *int `json:"cold,omitempty" example:"3"`
+	// Количество жалоб на отключение электричества, только в режиме совместимости
+	Electricity *int `json:"electricity,omitempty" example:"8"`
+	// Количество жалоб на отключение отопления, только в режиме совместимости
+	Heating *int `json:"heating,omitempty" example:"2"`
+}
+
+// NewComplaintData returns a data point with a zero count for every type of types
+func NewComplaintData(time string, types []string) *ComplaintData {
+	counts := make(map[string]int, len(types))
+	for _, blackoutType := range types {
+		counts[blackoutType] = 0
+	}
+
+	return &ComplaintData{Time: time, Counts: counts}
+}
+
+// WithLegacyFields fills the fixed fields of the four original types from Counts
+func (d ComplaintData) WithLegacyFields() ComplaintData {
+	count := func(blackoutType string) *int {
+		value := d.Counts[blackoutType]
+		return &value
+	}
+
+	d.HotWater = count("hot_water")
+	d.ColdWater = count("cold_water")
+	d.Electricity = count("electricity")
+	d.Heating = count("heat")
+
+	return d
+}
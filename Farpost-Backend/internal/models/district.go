@@ -0,0 +1,37 @@
+package models
+
+// Filter narrows aggregate queries down to a city, a part of it and a kind of blackouts.
+// The zero value matches every building and blackout.
+type Filter struct {
+	// City code, as stored in the cities table
+	City string
+	// District name, as stored in the districts table
+	District string
+	// Kind of blackouts, one of BlackoutKinds
+	Kind string
+}
+
+// District is an administrative district of the city
+// @Description Административный район
+type District struct {
+	// Идентификатор района
+	ID int64 `json:"id" example:"1"`
+	// Название района
+	Name string `json:"name" example:"Ленинский"`
+}
+
+// DistrictMapping assigns a street, or a single building when Number is set, to a district
+type DistrictMapping struct {
+	District string
+	Street   string
+	Number   string
+}
+
+// DistrictStats holds building counts of one district at a moment in time
+type DistrictStats struct {
+	District
+	TotalBuildings    int64
+	AffectedBuildings int64
+	// AffectedByType counts affected buildings per blackout type
+	AffectedByType map[string]int64
+}
@@ -0,0 +1,68 @@
+package models
+
+const (
+	HotspotBuilding = "building"
+	HotspotStreet   = "street"
+)
+
+// OutageRecord is one blackout at one building, a row of the outage history
+type OutageRecord struct {
+	Address
+	// CityID is the city of the street, 0 when no city is set up.
+	// Streets of two cities may share a name.
+	CityID     int64
+	BlackoutID string
+	Type       string
+	// Kind of the blackout, one of BlackoutKinds
+	Kind      string
+	StartDate string
+	EndDate   string
+}
+
+// Hotspot is a building or a street with recurring outages of one type
+// @Description Адрес с повторяющимися отключениями одного типа
+type Hotspot struct {
+	// Идентификатор находки
+	ID int64 `json:"id" example:"1"`
+	// Уровень: building или street
+	Scope string `json:"scope" example:"building"`
+	// Идентификатор здания, 0 для улицы
+	BuildingID int64 `json:"building_id" example:"1024"`
+	// Улица
+	Street string `json:"street" example:"Карбышева ул."`
+	// Номер дома, пусто для улицы
+	Number string `json:"number" example:"54"`
+	// Тип услуги
+	Type string `json:"type" example:"hot_water"`
+	// Вид отключений, только с параметром kind
+	Kind string `json:"kind,omitempty" example:"planned"`
+	// CityID is the city of the street, like in OutageRecord
+	CityID int64 `json:"-"`
+	// Всего отключений этого типа
+	Outages int `json:"outages" example:"7"`
+	// Наибольшее число отключений в одном окне анализа
+	WindowOutages int `json:"window_outages" example:"4"`
+	// Отключения повторяются с примерно одинаковым интервалом
+	Periodic bool `json:"periodic" example:"true"`
+	// Средний интервал между отключениями в днях
+	PeriodDays float64 `json:"period_days" example:"14.2"`
+	// Начало первого отключения
+	FirstAt string `json:"first_at" example:"2019-09-02 09:00:00"`
+	// Начало последнего отключения
+	LastAt string `json:"last_at" example:"2019-12-16 09:00:00"`
+	// Отключения по порядку
+	Timeline []HotspotOutage `json:"timeline"`
+	// Время анализа
+	DetectedAt string `json:"detected_at" example:"2019-12-30 12:00:00"`
+}
+
+// HotspotOutage is an entry of a hotspot timeline
+// @Description Отключение в истории адреса
+type HotspotOutage struct {
+	// Идентификатор отключения
+	ID string `json:"id" example:"b1f4"`
+	// Начало
+	StartOff string `json:"start_off" example:"2019-12-16 09:00:00"`
+	// Окончание, пусто если не указано
+	EndOff string `json:"end_off" example:"2019-12-16 18:00:00"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// RateLimitStats are the counters of the rate limiter
+// @Description Статистика ограничения частоты запросов
+type RateLimitStats struct {
+	// Клиенты, чьи бакеты еще не наполнились
+	Clients int `json:"clients" example:"42"`
+	// Счетчики по группам маршрутов
+	Groups []RateLimitGroupStats `json:"groups"`
+}
+
+// RateLimitGroupStats are the rate limiter counters of one route group
+// @Description Статистика ограничения одной группы маршрутов
+type RateLimitGroupStats struct {
+	// Группа маршрутов
+	Group string `json:"group" example:"search"`
+	// Пропущенные запросы
+	Allowed int64 `json:"allowed" example:"1200"`
+	// Отклоненные запросы с кодом 429
+	Limited int64 `json:"limited" example:"35"`
+	// Запросы адресов из списка разрешенных, они не ограничиваются
+	Allowlisted int64 `json:"allowlisted" example:"80"`
+}
@@ -0,0 +1,51 @@
+package models
+
+const (
+	StateAnnounced = "announced"
+	StateActive    = "active"
+	StateExtended  = "extended"
+	StateResolved  = "resolved"
+	StateCancelled = "cancelled"
+)
+
+// BlackoutStates lists the lifecycle states of a blackout
+var BlackoutStates = []string{StateAnnounced, StateActive, StateExtended, StateResolved, StateCancelled}
+
+const (
+	// ActorSource marks changes made by the importer of the source data
+	ActorSource = "source"
+	// ActorSystem marks state changes that only follow the clock
+	ActorSystem = "system"
+)
+
+// Revision is the state of a blackout after one change, revisions are never updated
+// @Description Состояние отключения после изменения
+type Revision struct {
+	// Идентификатор ревизии
+	ID int64 `json:"id" example:"12"`
+	// Идентификатор отключения
+	BlackoutID string `json:"blackout_id" example:"b1f4"`
+	// Начало отключения
+	StartDate string `json:"start_date" example:"2019-01-15 10:00:00"`
+	// Окончание отключения, пусто если не известно
+	EndDate string `json:"end_date" example:"2019-01-15 22:00:00"`
+	// Описание
+	Description string `json:"description" example:"Плановые работы"`
+	// Состояние: announced, active, extended, resolved, cancelled
+	State string `json:"state" example:"extended"`
+	// Кто внес изменение: source (загрузка данных), system (смена состояния по времени) или имя оператора
+	Actor string `json:"actor" example:"source"`
+	// Время изменения
+	ChangedAt string `json:"changed_at" example:"2019-01-15 16:05:00"`
+}
+
+// RevisionChange is one field changed by a revision
+// @Description Изменение одного поля отключения
+type RevisionChange struct {
+	// Поле: start_date, end_date, description или state
+	Field string `json:"field" example:"end_date"`
+	// Прежнее значение
+	From string `json:"from" example:"2019-01-15 18:00:00"`
+	// Новое значение
+	To string `json:"to" example:"2019-01-15 22:00:00"`
+}
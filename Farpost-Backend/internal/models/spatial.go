@@ -0,0 +1,47 @@
+package models
+
+// SpatialResult holds the outcome of a radius or polygon query
+type SpatialResult struct {
+	Buildings []AffectedBuilding
+	Blackouts []NearbyBlackout
+}
+
+// AffectedBuilding represents a building with active blackouts found by a spatial query
+// @Description Здание с действующими отключениями
+type AffectedBuilding struct {
+	// Идентификатор здания
+	BuildingID int64 `json:"building_id" example:"1024"`
+	// Адрес здания
+	Address string `json:"address" example:"Карбышева ул. 54"`
+	// Широта
+	Latitude float64 `json:"latitude" example:"43.1155"`
+	// Долгота
+	Longitude float64 `json:"longitude" example:"131.8869"`
+	// Расстояние от точки запроса в метрах
+	DistanceM float64 `json:"distance_m" example:"120.5"`
+	// Типы отключенных услуг
+	Services []string `json:"services" example:"hot_water"`
+	// Идентификаторы отключений
+	Blackouts []string `json:"blackouts" example:"b1f4"`
+}
+
+// NearbyBlackout represents a blackout found by a spatial query
+// @Description Отключение, затрагивающее здания в области запроса
+type NearbyBlackout struct {
+	// Идентификатор отключения
+	ID string `json:"id" example:"b1f4"`
+	// Тип услуги: hot_water, cold_water, electricity, heat
+	Service string `json:"service" example:"hot_water"`
+	// Начало отключения
+	StartOff string `json:"start_off" example:"2019-01-15 10:00:00"`
+	// Окончание отключения
+	EndOff string `json:"end_off" example:"2019-01-15 18:00:00"`
+	// Организация-инициатор
+	InitiatorName string `json:"initiator_name" example:"КГУП Приморский водоканал"`
+	// Описание
+	Description string `json:"description" example:"Плановые работы"`
+	// Количество зданий в области запроса, затронутых отключением
+	BuildingsCount int `json:"buildings_count" example:"3"`
+	// Расстояние до ближайшего затронутого здания в метрах
+	DistanceM float64 `json:"distance_m" example:"120.5"`
+}
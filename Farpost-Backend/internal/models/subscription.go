@@ -0,0 +1,26 @@
+package models
+
+const (
+	SubscriptionInstant = "instant"
+	SubscriptionDigest  = "digest"
+)
+
+// Subscription represents a resident subscribed to outage alerts for a building
+type Subscription struct {
+	ID           int64
+	Email        string
+	BuildingID   int64
+	Street       string
+	Number       string
+	Mode         string
+	Token        string
+	ConfirmedAt  string
+	CreatedAt    string
+	LastDigestAt string
+}
+
+// TelegramSubscription links a Telegram chat to a building
+type TelegramSubscription struct {
+	ChatID  int64
+	Address Address
+}
@@ -0,0 +1,57 @@
+package models
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook represents a partner subscription to outage events
+// @Description Подписка на события об отключениях
+type Webhook struct {
+	// Идентификатор подписки
+	ID int64 `json:"id" example:"1"`
+	// Адрес, на который отправляются события
+	URL string `json:"url" example:"https://example.com/hooks/off"`
+	// Секрет для подписи HMAC-SHA256, не возвращается в списках
+	Secret string `json:"-"`
+	// Фильтр по идентификаторам зданий
+	Buildings []int64 `json:"buildings" example:"1024,1025"`
+	// Фильтр по улицам
+	Streets []string `json:"streets" example:"Карбышева ул."`
+	// Фильтр по типам отключений: hot_water, cold_water, electricity, heat
+	Types []string `json:"types" example:"hot_water,electricity"`
+	// Фильтр по организациям-инициаторам
+	Organizations []string `json:"organizations" example:"КГУП Приморский водоканал"`
+	// Время создания в формате "2006-01-02 15:04:05"
+	CreatedAt string `json:"created_at" example:"2019-01-15 14:30:00"`
+	// Город, события которого отправляются
+	City string `json:"city" example:"vladivostok"`
+}
+
+// WebhookDelivery represents a single event queued for a webhook
+// @Description Доставка события подписчику
+type WebhookDelivery struct {
+	// Идентификатор доставки
+	ID int64 `json:"id" example:"10"`
+	// Идентификатор подписки
+	WebhookID int64 `json:"webhook_id" example:"1"`
+	// Тип события
+	EventType string `json:"event_type" example:"blackout.started"`
+	// Тело запроса, отправляемое подписчику
+	Payload string `json:"payload"`
+	// Статус: pending, succeeded, failed
+	Status string `json:"status" example:"pending"`
+	// Количество попыток доставки
+	Attempts int `json:"attempts" example:"1"`
+	// Время следующей попытки
+	NextAttemptAt string `json:"next_attempt_at" example:"2019-01-15 14:31:00"`
+	// HTTP статус последней попытки
+	LastStatusCode int `json:"last_status_code" example:"200"`
+	// Ошибка последней попытки
+	LastError string `json:"last_error" example:""`
+	// Время постановки в очередь
+	CreatedAt string `json:"created_at" example:"2019-01-15 14:30:00"`
+	// Время успешной доставки
+	DeliveredAt string `json:"delivered_at" example:"2019-01-15 14:30:01"`
+}
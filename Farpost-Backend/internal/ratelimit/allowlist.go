@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Allowlist holds the networks that are never limited
+type Allowlist []netip.Prefix
+
+// ParseAllowlist reads addresses and CIDR networks, a bare address allows only itself
+func ParseAllowlist(entries []string) (Allowlist, error) {
+	const op = "ratelimit.ParseAllowlist"
+
+	list := make(Allowlist, 0, len(entries))
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			list = append(list, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, prefix.Masked())
+	}
+
+	return list, nil
+}
+
+func (l Allowlist) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, prefix := range l {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
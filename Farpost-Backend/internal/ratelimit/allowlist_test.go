@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestAllowlist(t *testing.T) {
+	list, err := ParseAllowlist([]string{"10.0.0.0/8", " 192.168.1.5 ", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"10.1.2.3":        true,
+		"::ffff:10.1.2.3": true,
+		"192.168.1.5":     true,
+		"192.168.1.6":     false,
+		"2001:db8:1::1":   true,
+		"2001:db9::1":     false,
+		"11.0.0.1":        false,
+	}
+
+	for addr, want := range tests {
+		if got := list.Contains(mustAddr(t, addr)); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", addr, got, want)
+		}
+	}
+
+	if _, err := ParseAllowlist([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("ParseAllowlist of a bad network: no error")
+	}
+	if _, err := ParseAllowlist([]string{"localhost"}); err == nil {
+		t.Error("ParseAllowlist of a host name: no error")
+	}
+}
+
+func mustAddr(t *testing.T, s string) netip.Addr {
+	t.Helper()
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
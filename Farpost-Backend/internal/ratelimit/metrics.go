@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"vlru-prsch/internal/models"
+)
+
+type counters struct {
+	allowed     int64
+	limited     int64
+	allowlisted int64
+}
+
+// Metrics counts the decisions per route group
+type Metrics struct {
+	clients func() int
+
+	mu     sync.Mutex
+	groups map[string]*counters
+}
+
+// NewMetrics takes the number of clients tracked by the limiter, nil when unknown
+func NewMetrics(clients func() int) *Metrics {
+	return &Metrics{
+		clients: clients,
+		groups:  map[string]*counters{},
+	}
+}
+
+func (m *Metrics) group(name string) *counters {
+	c, ok := m.groups[name]
+	if !ok {
+		c = &counters{}
+		m.groups[name] = c
+	}
+	return c
+}
+
+func (m *Metrics) Allowed(group string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.group(group).allowed++
+}
+
+func (m *Metrics) Limited(group string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.group(group).limited++
+}
+
+func (m *Metrics) Allowlisted(group string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.group(group).allowlisted++
+}
+
+// Stats returns the counters of every group that has seen a request, sorted by name
+func (m *Metrics) Stats() models.RateLimitStats {
+	clients := 0
+	if m.clients != nil {
+		clients = m.clients()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	groups := make([]models.RateLimitGroupStats, 0, len(m.groups))
+	for name, c := range m.groups {
+		groups = append(groups, models.RateLimitGroupStats{
+			Group:       name,
+			Allowed:     c.allowed,
+			Limited:     c.limited,
+			Allowlisted: c.allowlisted,
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Group < groups[j].Group })
+
+	return models.RateLimitStats{
+		Clients: clients,
+		Groups:  groups,
+	}
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Rule is a token bucket: Burst requests at once, refilled at Rate requests per second.
+// A zero Rate lets every request through.
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+// Limiter keeps the buckets of the clients. The in-process Memory is the only
+// implementation for now, a shared store can take its place for several replicas.
+type Limiter interface {
+	// Take takes a token from the bucket of the key. Without a token left it
+	// returns false and the time until the next one.
+	Take(key string, rule Rule) (bool, time.Duration)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	rule    Rule
+}
+
+// Memory keeps the buckets in the process, they are lost on restart
+type Memory struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (m *Memory) Take(key string, rule Rule) (bool, time.Duration) {
+	if rule.Rate <= 0 {
+		return true, 0
+	}
+
+	burst := float64(max(rule.Burst, 1))
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*rule.Rate)
+	b.updated = now
+	b.rule = rule
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+	return false, wait
+}
+
+// Sweep drops the buckets that have refilled, a new bucket would be the same
+func (m *Memory) Sweep() int {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dropped := 0
+	for key, b := range m.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.rule.Rate >= float64(max(b.rule.Burst, 1)) {
+			delete(m.buckets, key)
+			dropped++
+		}
+	}
+
+	return dropped
+}
+
+// Run sweeps the buckets every interval until the context is done
+func (m *Memory) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Sweep()
+		}
+	}
+}
+
+// Len is the number of clients with a bucket that is not full
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.buckets)
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	m := NewMemory()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	rule := Rule{Rate: 2, Burst: 3}
+
+	// the burst goes at once
+	for i := range 3 {
+		if ok, _ := m.Take("a", rule); !ok {
+			t.Fatalf("request %d of the burst was limited", i+1)
+		}
+	}
+
+	ok, wait := m.Take("a", rule)
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("Take after the burst = %v, %v, want false, 500ms", ok, wait)
+	}
+
+	// other clients have buckets of their own
+	if ok, _ := m.Take("b", rule); !ok {
+		t.Error("another client was limited")
+	}
+
+	// two tokens a second, a quarter second refills half of one
+	now = now.Add(250 * time.Millisecond)
+	if ok, wait := m.Take("a", rule); ok || wait != 250*time.Millisecond {
+		t.Errorf("Take after 250ms = %v, %v, want false, 250ms", ok, wait)
+	}
+
+	now = now.Add(250 * time.Millisecond)
+	if ok, _ := m.Take("a", rule); !ok {
+		t.Error("limited after a token was refilled")
+	}
+
+	// the bucket never holds more than the burst
+	now = now.Add(time.Hour)
+	for i := range 3 {
+		if ok, _ := m.Take("a", rule); !ok {
+			t.Fatalf("request %d after an hour was limited", i+1)
+		}
+	}
+	if ok, _ := m.Take("a", rule); ok {
+		t.Error("the bucket refilled above the burst")
+	}
+}
+
+func TestTakeWithoutRate(t *testing.T) {
+	m := NewMemory()
+
+	for range 100 {
+		if ok, _ := m.Take("a", Rule{Burst: 1}); !ok {
+			t.Fatal("limited without a rate")
+		}
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len = %d, want no buckets", m.Len())
+	}
+}
+
+func TestSweep(t *testing.T) {
+	m := NewMemory()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	m.Take("slow", Rule{Rate: 0.1, Burst: 1})
+	m.Take("fast", Rule{Rate: 10, Burst: 1})
+
+	now = now.Add(time.Second)
+	if dropped := m.Sweep(); dropped != 1 || m.Len() != 1 {
+		t.Errorf("Sweep = %d dropped, %d left, want 1, 1", dropped, m.Len())
+	}
+}
@@ -0,0 +1,248 @@
+package recurrence
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// minPeriodic is the fewest outages whose gaps can show a period
+const minPeriodic = 3
+
+type Storage interface {
+	GetOutageHistory() ([]models.OutageRecord, error)
+	ReplaceHotspots(hotspots []models.Hotspot, detectedAt string) error
+}
+
+type Options struct {
+	// Interval between two analysis runs
+	Interval time.Duration
+	// Window is the sliding window in which MinOutages outages make a hotspot
+	Window time.Duration
+	// MinOutages of the same type needed for a hotspot
+	MinOutages int
+	// PeriodTolerance is the largest coefficient of variation of the gaps
+	// between outages for the sequence to count as periodic
+	PeriodTolerance float64
+}
+
+// Detector periodically scans the outage history for buildings and streets
+// that keep losing the same service and stores them as hotspots.
+type Detector struct {
+	log   *slog.Logger
+	store Storage
+	opts  Options
+	now   func() time.Time
+}
+
+func New(log *slog.Logger, store Storage, opts Options) *Detector {
+	return &Detector{
+		log:   log,
+		store: store,
+		opts:  opts,
+		now:   time.Now,
+	}
+}
+
+func (d *Detector) Run(ctx context.Context) {
+	const op = "recurrence.Detector.Run"
+
+	log := d.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Detect(); err != nil {
+			log.Error("failed to detect recurring outages", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Detect runs one analysis over the whole history and replaces the stored findings.
+// The history is analysed as a whole and once per blackout kind.
+func (d *Detector) Detect() error {
+	const op = "recurrence.Detector.Detect"
+
+	records, err := d.store.GetOutageHistory()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	hotspots := Find(records, d.opts)
+	for _, kind := range models.BlackoutKinds {
+		for _, hotspot := range Find(ofKind(records, kind), d.opts) {
+			hotspot.Kind = kind
+			hotspots = append(hotspots, hotspot)
+		}
+	}
+
+	if err := d.store.ReplaceHotspots(hotspots, d.now().Format(timeLayout)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	d.log.Debug("recurring outages detected", slog.String("op", op), slog.Int("hotspots", len(hotspots)))
+
+	return nil
+}
+
+func ofKind(records []models.OutageRecord, kind string) []models.OutageRecord {
+	var matching []models.OutageRecord
+	for _, record := range records {
+		if record.Kind == kind {
+			matching = append(matching, record)
+		}
+	}
+	return matching
+}
+
+type sequence struct {
+	hotspot models.Hotspot
+	seen    map[string]bool
+	starts  []time.Time
+}
+
+// Find groups the history by building and type and by street and type,
+// and returns the groups that recur often enough or periodically.
+func Find(records []models.OutageRecord, opts Options) []models.Hotspot {
+	sequences := map[string]*sequence{}
+	var order []string
+
+	add := func(key string, hotspot models.Hotspot, record models.OutageRecord, start time.Time) {
+		seq, ok := sequences[key]
+		if !ok {
+			seq = &sequence{hotspot: hotspot, seen: map[string]bool{}}
+			sequences[key] = seq
+			order = append(order, key)
+		}
+
+		// a street sees the same blackout once per building
+		if seq.seen[record.BlackoutID] {
+			return
+		}
+		seq.seen[record.BlackoutID] = true
+
+		seq.starts = append(seq.starts, start)
+		seq.hotspot.Timeline = append(seq.hotspot.Timeline, models.HotspotOutage{
+			ID:       record.BlackoutID,
+			StartOff: record.StartDate,
+			EndOff:   record.EndDate,
+		})
+	}
+
+	for _, record := range records {
+		start, err := time.Parse(timeLayout, record.StartDate)
+		if err != nil {
+			continue
+		}
+
+		add(fmt.Sprintf("b:%d:%s", record.BuildingID, record.Type), models.Hotspot{
+			Scope:      models.HotspotBuilding,
+			CityID:     record.CityID,
+			BuildingID: record.BuildingID,
+			Street:     record.Street,
+			Number:     record.Number,
+			Type:       record.Type,
+		}, record, start)
+
+		add(fmt.Sprintf("s:%d:%s:%s", record.CityID, record.Street, record.Type), models.Hotspot{
+			Scope:  models.HotspotStreet,
+			CityID: record.CityID,
+			Street: record.Street,
+			Type:   record.Type,
+		}, record, start)
+	}
+
+	var hotspots []models.Hotspot
+	for _, key := range order {
+		seq := sequences[key]
+
+		// too few for the window and for a period alike
+		if len(seq.starts) < min(opts.MinOutages, minPeriodic) {
+			continue
+		}
+
+		sort.Sort(byStart{seq})
+
+		hotspot := seq.hotspot
+		hotspot.Outages = len(seq.starts)
+		hotspot.WindowOutages = maxInWindow(seq.starts, opts.Window)
+		hotspot.PeriodDays, hotspot.Periodic = period(seq.starts, opts.PeriodTolerance)
+		hotspot.FirstAt = seq.hotspot.Timeline[0].StartOff
+		hotspot.LastAt = seq.hotspot.Timeline[len(seq.hotspot.Timeline)-1].StartOff
+
+		if hotspot.WindowOutages < opts.MinOutages && !hotspot.Periodic {
+			continue
+		}
+
+		hotspots = append(hotspots, hotspot)
+	}
+
+	return hotspots
+}
+
+// byStart sorts the starts of a sequence together with its timeline
+type byStart struct{ *sequence }
+
+func (s byStart) Len() int           { return len(s.starts) }
+func (s byStart) Less(i, j int) bool { return s.starts[i].Before(s.starts[j]) }
+func (s byStart) Swap(i, j int) {
+	s.starts[i], s.starts[j] = s.starts[j], s.starts[i]
+	s.hotspot.Timeline[i], s.hotspot.Timeline[j] = s.hotspot.Timeline[j], s.hotspot.Timeline[i]
+}
+
+// maxInWindow returns the largest number of sorted starts that fit in one window
+func maxInWindow(starts []time.Time, window time.Duration) int {
+	best := 0
+	first := 0
+	for last := range starts {
+		for starts[last].Sub(starts[first]) > window {
+			first++
+		}
+		best = max(best, last-first+1)
+	}
+	return best
+}
+
+// period returns the mean gap between sorted starts in days and whether
+// the gaps are regular enough to call the sequence periodic
+func period(starts []time.Time, tolerance float64) (float64, bool) {
+	if len(starts) < 2 {
+		return 0, false
+	}
+
+	gaps := make([]float64, 0, len(starts)-1)
+	for i := 1; i < len(starts); i++ {
+		gaps = append(gaps, starts[i].Sub(starts[i-1]).Hours()/24)
+	}
+
+	var sum float64
+	for _, gap := range gaps {
+		sum += gap
+	}
+	mean := sum / float64(len(gaps))
+
+	var variance float64
+	for _, gap := range gaps {
+		variance += (gap - mean) * (gap - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(gaps)))
+
+	// a single gap is trivially regular, and gaps under a day are repeats of one outage
+	periodic := len(starts) >= minPeriodic && mean >= 1 && deviation/mean <= tolerance
+
+	return math.Round(mean*10) / 10, periodic
+}
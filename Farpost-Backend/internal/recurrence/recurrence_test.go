@@ -0,0 +1,284 @@
+package recurrence
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+var opts = Options{
+	Window:          30 * 24 * time.Hour,
+	MinOutages:      4,
+	PeriodTolerance: 0.2,
+}
+
+// outages returns one record per start for the building on the street, the
+// blackout ids are the prefix and the index
+func outages(prefix string, cityID, buildingID int64, street, blackoutType string, starts ...string) []models.OutageRecord {
+	records := make([]models.OutageRecord, 0, len(starts))
+	for i, start := range starts {
+		records = append(records, models.OutageRecord{
+			Address:    models.Address{BuildingID: buildingID, Street: street, Number: fmt.Sprint(buildingID)},
+			CityID:     cityID,
+			BlackoutID: fmt.Sprintf("%s%d", prefix, i),
+			Type:       blackoutType,
+			StartDate:  start,
+		})
+	}
+	return records
+}
+
+func join(groups ...[]models.OutageRecord) []models.OutageRecord {
+	var records []models.OutageRecord
+	for _, group := range groups {
+		records = append(records, group...)
+	}
+	return records
+}
+
+type found struct {
+	scope    string
+	street   string
+	building int64
+	outages  int
+	window   int
+	periodic bool
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []models.OutageRecord
+		want    []found
+	}{
+		{
+			"frequent in a window",
+			outages("a", 1, 10, "Карбышева ул.", "hot_water",
+				"2024-03-01 09:00:00", "2024-03-05 09:00:00", "2024-03-11 09:00:00", "2024-03-20 09:00:00"),
+			[]found{
+				{"building", "Карбышева ул.", 10, 4, 4, false},
+				{"street", "Карбышева ул.", 0, 4, 4, false},
+			},
+		},
+		{
+			"too rare",
+			outages("a", 1, 10, "Карбышева ул.", "hot_water",
+				"2024-01-01 09:00:00", "2024-02-17 09:00:00", "2024-03-02 09:00:00", "2024-06-20 09:00:00"),
+			nil,
+		},
+		{
+			// three outages five weeks apart are fewer than MinOutages but periodic
+			"periodic with few outages",
+			outages("a", 1, 10, "Карбышева ул.", "cold_water",
+				"2024-01-01 09:00:00", "2024-02-05 09:00:00", "2024-03-11 09:00:00"),
+			[]found{
+				{"building", "Карбышева ул.", 10, 3, 1, true},
+				{"street", "Карбышева ул.", 0, 3, 1, true},
+			},
+		},
+		{
+			"two outages show no period",
+			outages("a", 1, 10, "Карбышева ул.", "cold_water",
+				"2024-01-01 09:00:00", "2024-02-05 09:00:00"),
+			nil,
+		},
+		{
+			"other types are other sequences",
+			join(
+				outages("a", 1, 10, "Карбышева ул.", "hot_water", "2024-03-01 09:00:00", "2024-03-05 09:00:00"),
+				outages("b", 1, 10, "Карбышева ул.", "heat", "2024-03-02 09:00:00", "2024-03-06 09:00:00"),
+			),
+			nil,
+		},
+		{
+			"a street counts a blackout once",
+			join(
+				outages("a", 1, 10, "Карбышева ул.", "hot_water", "2024-03-01 09:00:00", "2024-03-05 09:00:00"),
+				outages("a", 1, 11, "Карбышева ул.", "hot_water", "2024-03-01 09:00:00", "2024-03-05 09:00:00"),
+				outages("b", 1, 11, "Карбышева ул.", "hot_water", "2024-03-08 09:00:00", "2024-03-12 09:00:00"),
+			),
+			[]found{
+				{"street", "Карбышева ул.", 0, 4, 4, true},
+				{"building", "Карбышева ул.", 11, 4, 4, true},
+			},
+		},
+		{
+			"same street name in two cities",
+			join(
+				outages("a", 1, 10, "Ленина ул.", "hot_water", "2024-03-01 09:00:00", "2024-03-05 09:00:00"),
+				outages("b", 2, 20, "Ленина ул.", "hot_water", "2024-03-02 09:00:00", "2024-03-06 09:00:00"),
+			),
+			nil,
+		},
+		{
+			"history out of order",
+			outages("a", 1, 10, "Карбышева ул.", "hot_water",
+				"2024-03-20 09:00:00", "2024-03-01 09:00:00", "2024-03-11 09:00:00", "2024-03-05 09:00:00"),
+			[]found{
+				{"building", "Карбышева ул.", 10, 4, 4, false},
+				{"street", "Карбышева ул.", 0, 4, 4, false},
+			},
+		},
+		{
+			"unparsable starts are skipped",
+			outages("a", 1, 10, "Карбышева ул.", "hot_water",
+				"2024-03-01 09:00:00", "2024-03-02 09:00:00", "2024-03-11 09:00:00", "yesterday"),
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []found
+			for _, hotspot := range Find(tt.records, opts) {
+				got = append(got, found{hotspot.Scope, hotspot.Street, hotspot.BuildingID, hotspot.Outages, hotspot.WindowOutages, hotspot.Periodic})
+
+				for i := 1; i < len(hotspot.Timeline); i++ {
+					if hotspot.Timeline[i].StartOff < hotspot.Timeline[i-1].StartOff {
+						t.Errorf("%s %s timeline out of order: %+v", hotspot.Scope, hotspot.Street, hotspot.Timeline)
+					}
+				}
+				if hotspot.FirstAt != hotspot.Timeline[0].StartOff || hotspot.LastAt != hotspot.Timeline[len(hotspot.Timeline)-1].StartOff {
+					t.Errorf("%s %s spans %s - %s, timeline %+v", hotspot.Scope, hotspot.Street, hotspot.FirstAt, hotspot.LastAt, hotspot.Timeline)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSplitsStreetsByCity(t *testing.T) {
+	records := join(
+		outages("a", 1, 10, "Ленина ул.", "hot_water", "2024-03-01 09:00:00", "2024-03-05 09:00:00", "2024-03-09 09:00:00", "2024-03-13 09:00:00"),
+		outages("b", 2, 20, "Ленина ул.", "hot_water", "2024-03-02 09:00:00", "2024-03-06 09:00:00", "2024-03-10 09:00:00", "2024-03-14 09:00:00"),
+	)
+
+	var streets []int
+	for _, hotspot := range Find(records, opts) {
+		if hotspot.Scope == models.HotspotStreet {
+			streets = append(streets, hotspot.Outages)
+		}
+	}
+	if !reflect.DeepEqual(streets, []int{4, 4}) {
+		t.Errorf("got street hotspots with %v outages, want one of 4 per city", streets)
+	}
+}
+
+type storage struct {
+	records    []models.OutageRecord
+	replaced   []models.Hotspot
+	detectedAt string
+}
+
+func (s *storage) GetOutageHistory() ([]models.OutageRecord, error) {
+	return s.records, nil
+}
+
+func (s *storage) ReplaceHotspots(hotspots []models.Hotspot, detectedAt string) error {
+	s.replaced, s.detectedAt = hotspots, detectedAt
+	return nil
+}
+
+func TestDetect(t *testing.T) {
+	store := &storage{records: outages("a", 1, 10, "Карбышева ул.", "hot_water",
+		"2024-03-01 09:00:00", "2024-03-05 09:00:00", "2024-03-11 09:00:00", "2024-03-20 09:00:00")}
+
+	d := New(slog.New(slog.DiscardHandler), store, opts)
+	d.now = func() time.Time { return time.Date(2024, 3, 21, 12, 0, 0, 0, time.UTC) }
+
+	if err := d.Detect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.detectedAt != "2024-03-21 12:00:00" {
+		t.Errorf("got detected at %q", store.detectedAt)
+	}
+
+	var got []string
+	for _, hotspot := range store.replaced {
+		got = append(got, fmt.Sprintf("%s %d %d", hotspot.Scope, hotspot.BuildingID, hotspot.Outages))
+	}
+	if want := []string{"building 10 4", "street 0 4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDetectFindsEveryKind(t *testing.T) {
+	records := outages("a", 1, 10, "Карбышева ул.", "hot_water",
+		"2024-03-01 09:00:00", "2024-03-05 09:00:00", "2024-03-11 09:00:00", "2024-03-20 09:00:00")
+	for i := range records {
+		records[i].Kind = models.KindPlanned
+	}
+	// one emergency is too few on its own, but counts among all kinds
+	records = append(records, outages("e", 1, 10, "Карбышева ул.", "hot_water", "2024-03-21 09:00:00")...)
+	records[len(records)-1].Kind = models.KindEmergency
+
+	store := &storage{records: records}
+	if err := New(slog.New(slog.DiscardHandler), store, opts).Detect(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, hotspot := range store.replaced {
+		got = append(got, fmt.Sprintf("%s %q %d", hotspot.Scope, hotspot.Kind, hotspot.Outages))
+	}
+	want := []string{`building "" 5`, `street "" 5`, `building "planned" 4`, `street "planned" 4`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, d) }
+
+	tests := []struct {
+		name         string
+		starts       []time.Time
+		wantDays     float64
+		wantPeriodic bool
+	}{
+		{"none", nil, 0, false},
+		{"one gap", []time.Time{day(0), day(7)}, 7, false},
+		{"weekly", []time.Time{day(0), day(7), day(14), day(21)}, 7, true},
+		{"almost weekly", []time.Time{day(0), day(7), day(13), day(21)}, 7, true},
+		{"irregular", []time.Time{day(0), day(2), day(30), day(31)}, 10.3, false},
+		{"repeats within a day", []time.Time{day(0), day(0).Add(time.Hour), day(0).Add(2 * time.Hour)}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, periodic := period(tt.starts, opts.PeriodTolerance)
+			if days != tt.wantDays || periodic != tt.wantPeriodic {
+				t.Errorf("got %v days, periodic %v, want %v, %v", days, periodic, tt.wantDays, tt.wantPeriodic)
+			}
+		})
+	}
+}
+
+func TestMaxInWindow(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, d) }
+
+	tests := []struct {
+		name   string
+		starts []time.Time
+		want   int
+	}{
+		{"none", nil, 0},
+		{"one", []time.Time{day(0)}, 1},
+		{"all in the window", []time.Time{day(0), day(10), day(30)}, 3},
+		{"best window later", []time.Time{day(0), day(40), day(45), day(50)}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxInWindow(tt.starts, opts.Window); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package spatial
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+type Source interface {
+	GetBuildingLocations(filter models.Filter) ([]models.LocatedBuilding, error)
+	GetBuildingsBlackouts(buildingIDs []int64, currentTime string) ([]models.MapBlackout, error)
+}
+
+// Searcher is a source finding located buildings itself, like PostgreSQL with
+// PostGIS. Spatial reports whether the search is available.
+type Searcher interface {
+	Spatial() bool
+	NearbyBuildings(center geo.Point, radius float64, filter models.Filter) ([]geo.Hit, error)
+	BuildingsWithin(polygon geo.Polygon, ref geo.Point, filter models.Filter) ([]geo.Hit, error)
+}
+
+// Locator answers radius and polygon queries over located buildings.
+// Building coordinates change only on import, so they are kept in an in-memory
+// index per city that is rebuilt periodically; blackouts are always read from the storage.
+// A source with spatial search of its own is queried directly instead.
+type Locator struct {
+	log      *slog.Logger
+	src      Source
+	searcher Searcher
+	interval time.Duration
+	cities   []string
+
+	mu      sync.RWMutex
+	indexes map[string]*geo.Index
+}
+
+// NewLocator returns a locator over the buildings of the cities coded by cities
+func NewLocator(log *slog.Logger, src Source, interval time.Duration, cities []string) *Locator {
+	l := &Locator{
+		log:      log,
+		src:      src,
+		interval: interval,
+		cities:   cities,
+		indexes:  map[string]*geo.Index{},
+	}
+
+	if searcher, ok := src.(Searcher); ok && searcher.Spatial() {
+		l.searcher = searcher
+	}
+
+	return l
+}
+
+func (l *Locator) Run(ctx context.Context) {
+	const op = "spatial.Locator.Run"
+
+	log := l.log.With(slog.String("op", op))
+
+	if l.searcher != nil {
+		log.Info("using the spatial search of the storage")
+		return
+	}
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.Reload(); err != nil {
+			log.Error("failed to reload building locations", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload rebuilds the indexes from the storage, there are none to rebuild
+// over a searcher
+func (l *Locator) Reload() error {
+	const op = "spatial.Locator.Reload"
+
+	if l.searcher != nil {
+		return nil
+	}
+
+	indexes := make(map[string]*geo.Index, len(l.cities))
+	for _, city := range l.cities {
+		locations, err := l.src.GetBuildingLocations(models.Filter{City: city})
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		index := geo.NewIndex()
+		for _, location := range locations {
+			index.Insert(location.BuildingID, geo.Point{Lat: location.Latitude, Lon: location.Longitude})
+		}
+		indexes[city] = index
+	}
+
+	l.mu.Lock()
+	l.indexes = indexes
+	l.mu.Unlock()
+
+	return nil
+}
+
+// index returns the index of the city, an empty one for a city not loaded
+func (l *Locator) index(city string) *geo.Index {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if index, ok := l.indexes[city]; ok {
+		return index
+	}
+	return geo.NewIndex()
+}
+
+// Nearby returns blackouts active at currentTime within radius meters from center
+// at the buildings of the city
+func (l *Locator) Nearby(center geo.Point, radius float64, currentTime string, city string) (models.SpatialResult, error) {
+	const op = "spatial.Locator.Nearby"
+
+	var hits []geo.Hit
+	if l.searcher != nil {
+		var err error
+		hits, err = l.searcher.NearbyBuildings(center, radius, models.Filter{City: city})
+		if err != nil {
+			return models.SpatialResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		hits = l.index(city).Nearby(center, radius)
+	}
+
+	result, err := l.collect(hits, currentTime)
+	if err != nil {
+		return models.SpatialResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// Within returns blackouts active at currentTime inside the polygon at the
+// buildings of the city, distances are measured from ref
+func (l *Locator) Within(polygon geo.Polygon, ref geo.Point, currentTime string, city string) (models.SpatialResult, error) {
+	const op = "spatial.Locator.Within"
+
+	var hits []geo.Hit
+	if l.searcher != nil {
+		var err error
+		hits, err = l.searcher.BuildingsWithin(polygon, ref, models.Filter{City: city})
+		if err != nil {
+			return models.SpatialResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		hits = l.index(city).Within(polygon, ref)
+	}
+
+	result, err := l.collect(hits, currentTime)
+	if err != nil {
+		return models.SpatialResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// collect joins the found buildings with their active blackouts.
+// Buildings without blackouts are left out; both lists are sorted by distance.
+func (l *Locator) collect(hits []geo.Hit, currentTime string) (models.SpatialResult, error) {
+	result := models.SpatialResult{
+		Buildings: []models.AffectedBuilding{},
+		Blackouts: []models.NearbyBlackout{},
+	}
+
+	if len(hits) == 0 {
+		return result, nil
+	}
+
+	distances := make(map[int64]float64, len(hits))
+	ids := make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		distances[hit.ID] = hit.Distance
+		ids = append(ids, hit.ID)
+	}
+
+	rows, err := l.src.GetBuildingsBlackouts(ids, currentTime)
+	if err != nil {
+		return models.SpatialResult{}, err
+	}
+
+	buildings := make(map[int64]*models.AffectedBuilding)
+	blackouts := make(map[string]*models.NearbyBlackout)
+	services := make(map[int64]map[string]bool)
+
+	for _, row := range rows {
+		distance := distances[row.BuildingID]
+
+		building, ok := buildings[row.BuildingID]
+		if !ok {
+			building = &models.AffectedBuilding{
+				BuildingID: row.BuildingID,
+				Address:    row.Street + " " + row.Number,
+				Latitude:   row.Latitude,
+				Longitude:  row.Longitude,
+				DistanceM:  round(distance),
+				Services:   []string{},
+			}
+			buildings[row.BuildingID] = building
+			services[row.BuildingID] = map[string]bool{}
+		}
+
+		building.Blackouts = append(building.Blackouts, row.BlackoutID)
+		if !services[row.BuildingID][row.Type] {
+			services[row.BuildingID][row.Type] = true
+			building.Services = append(building.Services, row.Type)
+		}
+
+		blackout, ok := blackouts[row.BlackoutID]
+		if !ok {
+			blackout = &models.NearbyBlackout{
+				ID:            row.BlackoutID,
+				Service:       row.Type,
+				StartOff:      row.StartDate,
+				EndOff:        row.EndDate,
+				InitiatorName: row.InitiatorName,
+				Description:   row.Description,
+				DistanceM:     round(distance),
+			}
+			blackouts[row.BlackoutID] = blackout
+		}
+
+		blackout.BuildingsCount++
+		blackout.DistanceM = min(blackout.DistanceM, round(distance))
+	}
+
+	for _, hit := range hits {
+		if building, ok := buildings[hit.ID]; ok {
+			result.Buildings = append(result.Buildings, *building)
+		}
+	}
+
+	for _, blackout := range blackouts {
+		result.Blackouts = append(result.Blackouts, *blackout)
+	}
+	sort.Slice(result.Blackouts, func(i, j int) bool {
+		if result.Blackouts[i].DistanceM != result.Blackouts[j].DistanceM {
+			return result.Blackouts[i].DistanceM < result.Blackouts[j].DistanceM
+		}
+		return result.Blackouts[i].ID < result.Blackouts[j].ID
+	})
+
+	return result, nil
+}
+
+// round keeps distances to a tenth of a meter, finer precision is noise for geocoded addresses
+func round(meters float64) float64 {
+	return float64(int64(meters*10+0.5)) / 10
+}
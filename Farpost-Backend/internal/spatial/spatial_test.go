@@ -0,0 +1,268 @@
+package spatial
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/models"
+)
+
+// source has three buildings of Vladivostok along a street, about 111 m apart,
+// b1 touches the first two and b2 the third. Building 20 of Khabarovsk is
+// stored at the same place, so only the city keeps it apart, b3 touches it.
+type source struct {
+	err       error
+	buildings []int64
+}
+
+var located = []models.LocatedBuilding{
+	{Address: models.Address{BuildingID: 10, Street: "Карбышева ул.", Number: "54"}, Latitude: 43.110, Longitude: 131.89},
+	{Address: models.Address{BuildingID: 11, Street: "Карбышева ул.", Number: "56"}, Latitude: 43.111, Longitude: 131.89},
+	{Address: models.Address{BuildingID: 12, Street: "Карбышева ул.", Number: "58"}, Latitude: 43.112, Longitude: 131.89},
+}
+
+var khabarovsk = []models.LocatedBuilding{
+	{Address: models.Address{BuildingID: 20, Street: "Ленина ул.", Number: "5"}, Latitude: 43.110, Longitude: 131.89},
+}
+
+func (s *source) GetBuildingLocations(filter models.Filter) ([]models.LocatedBuilding, error) {
+	if filter.City == "khabarovsk" {
+		return khabarovsk, s.err
+	}
+	return located, s.err
+}
+
+func (s *source) GetBuildingsBlackouts(buildingIDs []int64, currentTime string) ([]models.MapBlackout, error) {
+	s.buildings = buildingIDs
+
+	var blackouts []models.MapBlackout
+	for _, building := range append(located, khabarovsk...) {
+		for _, id := range buildingIDs {
+			if id != building.BuildingID {
+				continue
+			}
+			blackout := models.MapBlackout{LocatedBuilding: building, BlackoutID: "b1", Type: "hot_water"}
+			switch id {
+			case 12:
+				blackout.BlackoutID, blackout.Type = "b2", "electricity"
+			case 20:
+				blackout.BlackoutID, blackout.Type = "b3", "heat"
+			}
+			blackouts = append(blackouts, blackout)
+		}
+	}
+
+	return blackouts, nil
+}
+
+func newLocator(t *testing.T, src *source) *Locator {
+	t.Helper()
+
+	l := NewLocator(slog.New(slog.DiscardHandler), src, time.Hour, []string{"vladivostok", "khabarovsk"})
+	if err := l.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestNearby(t *testing.T) {
+	src := &source{}
+	l := newLocator(t, src)
+
+	got, err := l.Nearby(geo.Point{Lat: 43.1121, Lon: 131.89}, 150, "2024-03-10 12:00:00", "vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(src.buildings, []int64{12, 11}) {
+		t.Errorf("blackouts were read for %v, want [12 11]", src.buildings)
+	}
+
+	want := models.SpatialResult{
+		Buildings: []models.AffectedBuilding{
+			{BuildingID: 12, Address: "Карбышева ул. 58", Latitude: 43.112, Longitude: 131.89, DistanceM: 11.1, Services: []string{"electricity"}, Blackouts: []string{"b2"}},
+			{BuildingID: 11, Address: "Карбышева ул. 56", Latitude: 43.111, Longitude: 131.89, DistanceM: 122.3, Services: []string{"hot_water"}, Blackouts: []string{"b1"}},
+		},
+		Blackouts: []models.NearbyBlackout{
+			{ID: "b2", Service: "electricity", BuildingsCount: 1, DistanceM: 11.1},
+			{ID: "b1", Service: "hot_water", BuildingsCount: 1, DistanceM: 122.3},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	l := newLocator(t, &source{})
+
+	polygon := geo.Polygon{{{Lat: 43.1095, Lon: 131.88}, {Lat: 43.1095, Lon: 131.90}, {Lat: 43.1115, Lon: 131.90}, {Lat: 43.1115, Lon: 131.88}, {Lat: 43.1095, Lon: 131.88}}}
+
+	got, err := l.Within(polygon, geo.Point{Lat: 43.110, Lon: 131.89}, "2024-03-10 12:00:00", "vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Buildings) != 2 || got.Buildings[0].BuildingID != 10 || got.Buildings[1].BuildingID != 11 {
+		t.Fatalf("got buildings %+v, want 10 and 11", got.Buildings)
+	}
+	if len(got.Blackouts) != 1 || got.Blackouts[0].BuildingsCount != 2 || got.Blackouts[0].DistanceM != 0 {
+		t.Errorf("got blackouts %+v, want b1 at both buildings", got.Blackouts)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	src := &source{}
+	l := newLocator(t, src)
+
+	got, err := l.Nearby(geo.Point{Lat: 48.48, Lon: 135.07}, 500, "2024-03-10 12:00:00", "vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if src.buildings != nil {
+		t.Errorf("blackouts were read for %v with nothing around", src.buildings)
+	}
+	if got.Buildings == nil || got.Blackouts == nil || len(got.Buildings)+len(got.Blackouts) != 0 {
+		t.Errorf("got %+v, want empty lists", got)
+	}
+}
+
+func TestReloadFailureKeepsIndex(t *testing.T) {
+	src := &source{}
+	l := newLocator(t, src)
+
+	src.err = errors.New("disk I/O error")
+	if err := l.Reload(); err == nil {
+		t.Fatal("want the storage error")
+	}
+
+	got, err := l.Nearby(geo.Point{Lat: 43.110, Lon: 131.89}, 10, "2024-03-10 12:00:00", "vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Buildings) != 1 {
+		t.Errorf("got %d buildings, want the index built before the failure", len(got.Buildings))
+	}
+}
+
+func TestNearbyKeepsCitiesApart(t *testing.T) {
+	l := newLocator(t, &source{})
+
+	tests := []struct {
+		city string
+		want []int64
+	}{
+		{"vladivostok", []int64{10}},
+		{"khabarovsk", []int64{20}},
+		{"moscow", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.city, func(t *testing.T) {
+			got, err := l.Nearby(geo.Point{Lat: 43.110, Lon: 131.89}, 10, "2024-03-10 12:00:00", tt.city)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []int64
+			for _, building := range got.Buildings {
+				ids = append(ids, building.BuildingID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("got buildings %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// searcher is a source with spatial search of its own, it answers from an
+// index over the located buildings of the filter and records the queries
+type searcher struct {
+	source
+	spatial bool
+	queries []string
+}
+
+func (s *searcher) Spatial() bool {
+	return s.spatial
+}
+
+func (s *searcher) NearbyBuildings(center geo.Point, radius float64, filter models.Filter) ([]geo.Hit, error) {
+	s.queries = append(s.queries, "nearby "+filter.City)
+	return s.index(filter).Nearby(center, radius), nil
+}
+
+func (s *searcher) BuildingsWithin(polygon geo.Polygon, ref geo.Point, filter models.Filter) ([]geo.Hit, error) {
+	s.queries = append(s.queries, "within "+filter.City)
+	return s.index(filter).Within(polygon, ref), nil
+}
+
+func (s *searcher) index(filter models.Filter) *geo.Index {
+	locations, _ := s.source.GetBuildingLocations(filter)
+
+	index := geo.NewIndex()
+	for _, location := range locations {
+		index.Insert(location.BuildingID, geo.Point{Lat: location.Latitude, Lon: location.Longitude})
+	}
+	return index
+}
+
+func TestSearcher(t *testing.T) {
+	// a failing load shows the locator never reads the locations itself
+	src := &searcher{source: source{err: errors.New("database is down")}, spatial: true}
+	l := NewLocator(slog.New(slog.DiscardHandler), src, time.Hour, []string{"vladivostok"})
+	if err := l.Reload(); err != nil {
+		t.Fatalf("Reload over a searcher: %v", err)
+	}
+	src.err = nil
+
+	nearby, err := l.Nearby(geo.Point{Lat: 43.110, Lon: 131.89}, 150, "2024-03-10 12:00:00", "khabarovsk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	square := geo.Polygon{{{Lat: 43.1095, Lon: 131.889}, {Lat: 43.1115, Lon: 131.889}, {Lat: 43.1115, Lon: 131.891}, {Lat: 43.1095, Lon: 131.891}}}
+	within, err := l.Within(square, geo.Point{Lat: 43.112, Lon: 131.89}, "2024-03-10 12:00:00", "vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ids(nearby), []int64{20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got nearby buildings %v, want %v", got, want)
+	}
+	if got, want := ids(within), []int64{11, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got buildings within %v, want %v", got, want)
+	}
+	if want := []string{"nearby khabarovsk", "within vladivostok"}; !reflect.DeepEqual(src.queries, want) {
+		t.Errorf("got queries %v, want %v", src.queries, want)
+	}
+}
+
+func TestSearcherWithoutSpatial(t *testing.T) {
+	src := &searcher{}
+	l := NewLocator(slog.New(slog.DiscardHandler), src, time.Hour, []string{"vladivostok"})
+	if err := l.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := l.Nearby(geo.Point{Lat: 43.110, Lon: 131.89}, 10, "2024-03-10 12:00:00", "vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ids(got), []int64{10}) || len(src.queries) != 0 {
+		t.Errorf("got buildings %v and queries %v, want the index to answer", ids(got), src.queries)
+	}
+}
+
+func ids(result models.SpatialResult) []int64 {
+	var ids []int64
+	for _, building := range result.Buildings {
+		ids = append(ids, building.BuildingID)
+	}
+	return ids
+}
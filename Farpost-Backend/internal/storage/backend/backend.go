@@ -0,0 +1,25 @@
+package backend
+
+import (
+	"vlru-prsch/internal/config"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/postgres"
+	"vlru-prsch/internal/storage/sqlite"
+)
+
+// New opens the storage backend selected by the config
+func New(cfg *config.Config) (storage.Storage, error) {
+	if cfg.Storage.Driver == config.DriverPostgres {
+		s, err := postgres.New(string(cfg.Storage.DSN))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	s, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
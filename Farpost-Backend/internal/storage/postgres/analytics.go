@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"vlru-prsch/internal/models"
+)
+
+// GetBlackoutsWithBuildings returns the blackouts started between from and to
+// inclusive, dates are YYYY-MM-DD. Blackouts touching only fake buildings are left out.
+func (s *Storage) GetBlackoutsWithBuildings(from string, to string, filter models.Filter) ([]models.BlackoutWithBuildings, error) {
+	const op = "storage.postgres.GetBlackoutsWithBuildings"
+
+	cond, args := buildingsFilter("bg.id", filter, []any{from + " 00:00:00", to + " 23:59:59"})
+	kindCond, args := kindFilter("bl.kind", filter, args)
+
+	rows, err := s.db.Query(`
+        SELECT bl.id, bl.start_date, bl.end_date, bl.description, bl.type, bl.initiator_name, bl.source, COALESCE(bl.kind, 'unknown'),
+               COUNT(DISTINCT bg.id)
+        FROM blackouts bl
+        JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+        JOIN buildings bg ON bb.building_id = bg.id
+        WHERE NOT bg.is_fake
+        AND bl.start_date >= $1
+        AND bl.start_date <= $2`+cond+kindCond+`
+        GROUP BY bl.id
+        ORDER BY bl.start_date`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var blackouts []models.BlackoutWithBuildings
+	for rows.Next() {
+		var blackout models.BlackoutWithBuildings
+		var endDate sql.NullString
+		var source sql.NullString
+
+		err := rows.Scan(
+			&blackout.ID,
+			&blackout.StartDate,
+			&endDate,
+			&blackout.Description,
+			&blackout.Type,
+			&blackout.InitiatorName,
+			&source,
+			&blackout.Kind,
+			&blackout.BuildingsCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		blackout.EndDate = endDate.String
+		blackout.Source = source.String
+
+		blackouts = append(blackouts, blackout)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"fmt"
+	"vlru-prsch/internal/models"
+)
+
+// GetHourlyCounts returns the number of outages of the filter city and kind started
+// in each hour between from and to, per type. Hours without outages are left out, so are blackouts
+// touching only fake buildings.
+func (s *Storage) GetHourlyCounts(from string, to string, filter models.Filter) ([]models.HourlyCount, error) {
+	const op = "storage.postgres.GetHourlyCounts"
+
+	cond, args := blackoutsFilter("id", filter, []any{from, to})
+	kindCond, args := kindFilter("kind", filter, args)
+	cond += kindCond
+
+	rows, err := s.db.Query(`
+        SELECT to_char(start_date::timestamp, 'YYYY-MM-DD HH24:00:00') AS hour, type, COUNT(*)
+        FROM blackouts
+        WHERE start_date >= $1 AND start_date < $2
+        AND id IN (
+            SELECT bb.blackout_id FROM blackouts_buildings bb
+            JOIN buildings bg ON bb.building_id = bg.id
+            WHERE NOT bg.is_fake)`+cond+`
+        GROUP BY hour, type
+        ORDER BY hour`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var counts []models.HourlyCount
+	for rows.Next() {
+		var count models.HourlyCount
+
+		if err := rows.Scan(&count.Hour, &count.Type, &count.Count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+// anomalyCity selects the id of the city coded by $1, the default city for an
+// unknown code and 0 without cities, so the unique key never holds NULL
+const anomalyCity = `COALESCE((SELECT id FROM cities WHERE code = $1), ` + defaultCity + `, 0)`
+
+// SaveAnomaly stores an anomaly and reports whether it is new. A repeated
+// anomaly of the same city, type, kind and hour only raises the stored count.
+func (s *Storage) SaveAnomaly(anomaly models.Anomaly) (bool, error) {
+	const op = "storage.postgres.SaveAnomaly"
+
+	res, err := s.db.Exec(`
+        INSERT INTO anomalies (city_id, type, kind, hour, count, baseline, stddev, z_score, detected_at)
+        VALUES (`+anomalyCity+`, $2, $3, $4, $5, $6, $7, $8, $9)
+        ON CONFLICT (city_id, type, kind, hour) DO NOTHING`,
+		anomaly.City, anomaly.Type, anomaly.Kind, anomaly.Hour, anomaly.Count, anomaly.Baseline, anomaly.StdDev, anomaly.ZScore, anomaly.DetectedAt)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if inserted > 0 {
+		return true, nil
+	}
+
+	_, err = s.db.Exec(`
+        UPDATE anomalies SET count = $2, z_score = $3
+        WHERE city_id = `+anomalyCity+` AND type = $4 AND kind = $5 AND hour = $6 AND count < $2`,
+		anomaly.City, anomaly.Count, anomaly.ZScore, anomaly.Type, anomaly.Kind, anomaly.Hour)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return false, nil
+}
+
+// GetAnomalies returns anomalies of the filter city between from and to, the newest
+// first. An empty blackoutType matches every type, an empty filter kind returns
+// the spikes of outages of every kind.
+func (s *Storage) GetAnomalies(blackoutType string, from string, to string, limit int, filter models.Filter) ([]models.Anomaly, error) {
+	const op = "storage.postgres.GetAnomalies"
+
+	cond, args := cityFilter("city_id", filter, []any{blackoutType, filter.Kind, from, to, limit})
+
+	rows, err := s.db.Query(`
+        SELECT id, type, kind, hour, count, baseline, stddev, z_score, detected_at
+        FROM anomalies
+        WHERE ($1::text = '' OR type = $1)
+        AND kind = $2
+        AND hour >= $3 AND hour <= $4`+cond+`
+        ORDER BY hour DESC, type
+        LIMIT $5`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var anomalies []models.Anomaly
+	for rows.Next() {
+		var anomaly models.Anomaly
+
+		err := rows.Scan(
+			&anomaly.ID,
+			&anomaly.Type,
+			&anomaly.Kind,
+			&anomaly.Hour,
+			&anomaly.Count,
+			&anomaly.Baseline,
+			&anomaly.StdDev,
+			&anomaly.ZScore,
+			&anomaly.DetectedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		anomalies = append(anomalies, anomaly)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return anomalies, nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"fmt"
+	"vlru-prsch/internal/models"
+)
+
+// defaultCity selects the id of the city owning the rows without a city
+const defaultCity = `(SELECT id FROM cities WHERE is_default)`
+
+// cityBuildings selects the buildings on the streets of the city coded by the placeholder
+func cityBuildings(placeholder string) string {
+	return `
+            SELECT bc.id FROM buildings bc
+            JOIN streets sc ON bc.street_id = sc.id
+            JOIN cities c ON c.id = COALESCE(sc.city_id, ` + defaultCity + `)
+            WHERE c.code = ` + placeholder
+}
+
+// cityBlackouts selects the blackouts of the city coded by the placeholder
+func cityBlackouts(placeholder string) string {
+	return `
+            SELECT bk.id FROM blackouts bk
+            JOIN cities c ON c.id = COALESCE(bk.city_id, ` + defaultCity + `)
+            WHERE c.code = ` + placeholder
+}
+
+// citiesFilter returns a condition limiting a street id column to the city of the filter
+func citiesFilter(column string, filter models.Filter, args []any) (string, []any) {
+	if filter.City == "" {
+		return "", args
+	}
+
+	p, args := arg(args, filter.City)
+
+	return `
+        AND ` + column + ` IN (
+            SELECT sc.id FROM streets sc
+            JOIN cities c ON c.id = COALESCE(sc.city_id, ` + defaultCity + `)
+            WHERE c.code = ` + p + `)`, args
+}
+
+// cityFilter returns a condition limiting a city id column to the city of the filter,
+// rows without a city, NULL or 0 in the tables of background job findings, belong to the default one
+func cityFilter(column string, filter models.Filter, args []any) (string, []any) {
+	if filter.City == "" {
+		return "", args
+	}
+
+	p, args := arg(args, filter.City)
+
+	return `
+        AND COALESCE(NULLIF(` + column + `, 0), ` + defaultCity + `) IN (SELECT id FROM cities WHERE code = ` + p + `)`, args
+}
+
+// cityCode selects the code of the city of a city id column, the default one for NULL
+func cityCode(column string) string {
+	return `COALESCE((SELECT code FROM cities WHERE id = COALESCE(` + column + `, ` + defaultCity + `)), '')`
+}
+
+// SyncCities stores the configured cities and marks the default one.
+// Cities missing from the configuration are kept, their data stays in place.
+func (s *Storage) SyncCities(cities []models.City, defaultCode string) error {
+	const op = "storage.postgres.SyncCities"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, city := range cities {
+		_, err := tx.Exec(`
+            INSERT INTO cities (code, name, timezone, buildings_total, is_default)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT (code) DO UPDATE SET
+                name = excluded.name,
+                timezone = excluded.timezone,
+                buildings_total = excluded.buildings_total,
+                is_default = excluded.is_default`,
+			city.Code, city.Name, city.Timezone, city.BuildingsTotal, city.Code == defaultCode)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE cities SET is_default = (code = $1)`, defaultCode); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// getBuildingsTotal returns the configured number of buildings of the city, 0 when not set
+func (s *Storage) getBuildingsTotal(city string) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(`SELECT COALESCE(MAX(buildings_total), 0) FROM cities WHERE code = $1`, city).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+// ImportBuildingLocations sets coordinates of the buildings of the city coded by city
+// matched by street name and number. It returns the number of updated buildings
+// and the locations that matched nothing.
+func (s *Storage) ImportBuildingLocations(city string, locations []models.BuildingLocation) (int64, []models.BuildingLocation, error) {
+	const op = "storage.postgres.ImportBuildingLocations"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var cityID int64
+	err = tx.QueryRow(`SELECT id FROM cities WHERE code = $1`, city).Scan(&cityID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, storage.ErrCityNotFound
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.Prepare(`
+        UPDATE buildings SET latitude = $1, longitude = $2
+        WHERE number = $3
+        AND street_id IN (
+            SELECT id FROM streets
+            WHERE name = $4 AND COALESCE(city_id, ` + defaultCity + `) = $5)`)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var updated int64
+	var missing []models.BuildingLocation
+
+	for _, location := range locations {
+		res, err := stmt.Exec(location.Latitude, location.Longitude,
+			strings.TrimSpace(location.Number), strings.TrimSpace(location.Street), cityID)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if affected == 0 {
+			missing = append(missing, location)
+		}
+		updated += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, missing, nil
+}
+
+// GetMapBlackouts returns blackouts active at currentTime for every located building,
+// optionally limited to a bounding box. A date without time covers the whole day.
+func (s *Storage) GetMapBlackouts(currentTime string, bbox *models.BBox, filter models.Filter) ([]models.MapBlackout, error) {
+	const op = "storage.postgres.GetMapBlackouts"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	query := `
+        SELECT bg.id, s.name, bg.number, bg.latitude, bg.longitude,
+               bl.id, bl.type, bl.start_date, bl.end_date, bl.description, bl.initiator_name, COALESCE(bl.kind, 'unknown')
+        FROM blackouts bl
+        JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+        JOIN buildings bg ON bb.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        WHERE NOT bg.is_fake
+        AND bg.latitude IS NOT NULL AND bg.longitude IS NOT NULL
+        AND bl.start_date <= $1
+        AND (bl.end_date >= $2 OR bl.end_date IS NULL)`
+	args := []any{queryTime, currentTime}
+
+	if bbox != nil {
+		query += `
+        AND bg.longitude BETWEEN $3 AND $4
+        AND bg.latitude BETWEEN $5 AND $6`
+		args = append(args, bbox.MinLon, bbox.MaxLon, bbox.MinLat, bbox.MaxLat)
+	}
+
+	cond, args := buildingsFilter("bg.id", filter, args)
+	kindCond, args := kindFilter("bl.kind", filter, args)
+	query += cond + kindCond + `
+        ORDER BY bg.id, bl.start_date`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanMapBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+// GetBuildingLocations returns every real building of the filter with known coordinates
+func (s *Storage) GetBuildingLocations(filter models.Filter) ([]models.LocatedBuilding, error) {
+	const op = "storage.postgres.GetBuildingLocations"
+
+	cond, args := buildingsFilter("bg.id", filter, nil)
+
+	rows, err := s.db.Query(`
+        SELECT bg.id, s.name, bg.number, bg.latitude, bg.longitude
+        FROM buildings bg
+        JOIN streets s ON bg.street_id = s.id
+        WHERE NOT bg.is_fake
+        AND bg.latitude IS NOT NULL AND bg.longitude IS NOT NULL`+cond, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var buildings []models.LocatedBuilding
+	for rows.Next() {
+		var building models.LocatedBuilding
+
+		err := rows.Scan(
+			&building.BuildingID,
+			&building.Street,
+			&building.Number,
+			&building.Latitude,
+			&building.Longitude,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		buildings = append(buildings, building)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buildings, nil
+}
+
+// GetBuildingsBlackouts returns blackouts active at currentTime for the given buildings.
+// The ids go as one array parameter, there is no limit to chunk around.
+// A date without time covers the whole day.
+func (s *Storage) GetBuildingsBlackouts(buildingIDs []int64, currentTime string) ([]models.MapBlackout, error) {
+	const op = "storage.postgres.GetBuildingsBlackouts"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	if len(buildingIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(`
+        SELECT bg.id, s.name, bg.number, bg.latitude, bg.longitude,
+               bl.id, bl.type, bl.start_date, bl.end_date, bl.description, bl.initiator_name, COALESCE(bl.kind, 'unknown')
+        FROM blackouts bl
+        JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+        JOIN buildings bg ON bb.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        WHERE bl.start_date <= $1
+        AND (bl.end_date >= $2 OR bl.end_date IS NULL)
+        AND bg.id = ANY($3)
+        ORDER BY bg.id, bl.start_date`,
+		queryTime, currentTime, buildingIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanMapBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+func scanMapBlackouts(rows *sql.Rows) ([]models.MapBlackout, error) {
+	var blackouts []models.MapBlackout
+	for rows.Next() {
+		var blackout models.MapBlackout
+		var endDate sql.NullString
+
+		err := rows.Scan(
+			&blackout.BuildingID,
+			&blackout.Street,
+			&blackout.Number,
+			&blackout.Latitude,
+			&blackout.Longitude,
+			&blackout.BlackoutID,
+			&blackout.Type,
+			&blackout.StartDate,
+			&endDate,
+			&blackout.Description,
+			&blackout.InitiatorName,
+			&blackout.Kind,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		blackout.EndDate = endDate.String
+
+		blackouts = append(blackouts, blackout)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return blackouts, nil
+}
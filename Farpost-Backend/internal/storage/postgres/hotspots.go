@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"vlru-prsch/internal/models"
+)
+
+// GetOutageHistory returns every blackout of every real building, oldest first
+func (s *Storage) GetOutageHistory() ([]models.OutageRecord, error) {
+	const op = "storage.postgres.GetOutageHistory"
+
+	rows, err := s.db.Query(`
+        SELECT bg.id, s.name, bg.number, COALESCE(s.city_id, ` + defaultCity + `, 0), bl.id, bl.type, COALESCE(bl.kind, 'unknown'), bl.start_date, bl.end_date
+        FROM blackouts bl
+        JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+        JOIN buildings bg ON bb.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        WHERE NOT bg.is_fake
+        ORDER BY bl.start_date, bl.id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var records []models.OutageRecord
+	for rows.Next() {
+		var record models.OutageRecord
+		var endDate sql.NullString
+
+		err := rows.Scan(
+			&record.BuildingID,
+			&record.Street,
+			&record.Number,
+			&record.CityID,
+			&record.BlackoutID,
+			&record.Type,
+			&record.Kind,
+			&record.StartDate,
+			&endDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		record.EndDate = endDate.String
+
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// ReplaceHotspots swaps the stored findings for a new analysis result
+func (s *Storage) ReplaceHotspots(hotspots []models.Hotspot, detectedAt string) error {
+	const op = "storage.postgres.ReplaceHotspots"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM hotspots`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.Prepare(`
+        INSERT INTO hotspots (scope, building_id, street, number, type, kind, city_id, outages, window_outages,
+                              periodic, period_days, first_at, last_at, timeline, detected_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, hotspot := range hotspots {
+		timeline, err := json.Marshal(nonNil(hotspot.Timeline))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = stmt.Exec(
+			hotspot.Scope,
+			hotspot.BuildingID,
+			hotspot.Street,
+			hotspot.Number,
+			hotspot.Type,
+			hotspot.Kind,
+			hotspot.CityID,
+			hotspot.Outages,
+			hotspot.WindowOutages,
+			hotspot.Periodic,
+			hotspot.PeriodDays,
+			hotspot.FirstAt,
+			hotspot.LastAt,
+			string(timeline),
+			detectedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetHotspots returns stored findings of the filter city, the most frequent first.
+// Empty scope or blackoutType match everything, an empty filter kind
+// returns the findings over blackouts of every kind.
+func (s *Storage) GetHotspots(scope string, blackoutType string, limit int, filter models.Filter) ([]models.Hotspot, error) {
+	const op = "storage.postgres.GetHotspots"
+
+	cond, args := cityFilter("city_id", filter, []any{scope, blackoutType, filter.Kind, limit})
+
+	rows, err := s.db.Query(`
+        SELECT id, scope, building_id, street, number, type, kind, outages, window_outages,
+               periodic, period_days, first_at, last_at, timeline, detected_at
+        FROM hotspots
+        WHERE ($1::text = '' OR scope = $1)
+        AND ($2::text = '' OR type = $2)
+        AND kind = $3`+cond+`
+        ORDER BY window_outages DESC, outages DESC, last_at DESC
+        LIMIT $4`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var hotspots []models.Hotspot
+	for rows.Next() {
+		var hotspot models.Hotspot
+		var timeline []byte
+
+		err := rows.Scan(
+			&hotspot.ID,
+			&hotspot.Scope,
+			&hotspot.BuildingID,
+			&hotspot.Street,
+			&hotspot.Number,
+			&hotspot.Type,
+			&hotspot.Kind,
+			&hotspot.Outages,
+			&hotspot.WindowOutages,
+			&hotspot.Periodic,
+			&hotspot.PeriodDays,
+			&hotspot.FirstAt,
+			&hotspot.LastAt,
+			&timeline,
+			&hotspot.DetectedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := json.Unmarshal(timeline, &hotspot.Timeline); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		hotspots = append(hotspots, hotspot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hotspots, nil
+}
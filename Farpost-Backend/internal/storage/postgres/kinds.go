@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"fmt"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+// kindFilter returns a condition limiting a blackout kind column to the filter.
+// Blackouts not classified yet count as unknown.
+func kindFilter(column string, filter models.Filter, args []any) (string, []any) {
+	if filter.Kind == "" {
+		return "", args
+	}
+
+	p, args := arg(args, filter.Kind)
+
+	return `
+        AND COALESCE(` + column + `, 'unknown') = ` + p, args
+}
+
+// GetUnclassifiedBlackouts returns up to limit blackouts without a kind
+func (s *Storage) GetUnclassifiedBlackouts(limit int) ([]models.Blackout, error) {
+	const op = "storage.postgres.GetUnclassifiedBlackouts"
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, 'unknown'
+        FROM blackouts
+        WHERE kind IS NULL
+        LIMIT $1`,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+// GetBlackout returns a single blackout of the filter city by id
+func (s *Storage) GetBlackout(id string, filter models.Filter) (models.Blackout, error) {
+	const op = "storage.postgres.GetBlackout"
+
+	cond, args := blackoutsFilter("id", filter, []any{id})
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE id = $1`+cond,
+		args...)
+	if err != nil {
+		return models.Blackout{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return models.Blackout{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(blackouts) == 0 {
+		return models.Blackout{}, storage.ErrBlackoutNotFound
+	}
+
+	return blackouts[0], nil
+}
+
+// SetBlackoutKinds stores kinds found by the rules, keyed by blackout id.
+// Kinds set by hand are left untouched.
+func (s *Storage) SetBlackoutKinds(kinds map[string]string) error {
+	const op = "storage.postgres.SetBlackoutKinds"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`UPDATE blackouts SET kind = $1 WHERE id = $2 AND NOT kind_manual`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for id, kind := range kinds {
+		if _, err := stmt.Exec(kind, id); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// OverrideBlackoutKind sets the kind of a blackout by hand, the rules no longer change it
+func (s *Storage) OverrideBlackoutKind(id string, kind string) error {
+	const op = "storage.postgres.OverrideBlackoutKind"
+
+	res, err := s.db.Exec(`UPDATE blackouts SET kind = $1, kind_manual = TRUE WHERE id = $2`, kind, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrBlackoutNotFound
+	}
+
+	return nil
+}
+
+// ResetBlackoutKinds clears the kinds found by the rules so they are classified again.
+// With an id only that blackout is reset, including a kind set by hand.
+func (s *Storage) ResetBlackoutKinds(id string) (int64, error) {
+	const op = "storage.postgres.ResetBlackoutKinds"
+
+	query, args := `UPDATE blackouts SET kind = NULL WHERE NOT kind_manual`, []any(nil)
+	if id != "" {
+		query, args = `UPDATE blackouts SET kind = NULL, kind_manual = FALSE WHERE id = $1`, []any{id}
+	}
+
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if id != "" && affected == 0 {
+		return 0, storage.ErrBlackoutNotFound
+	}
+
+	return affected, nil
+}
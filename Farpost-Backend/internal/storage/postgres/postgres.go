@@ -0,0 +1,741 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+type Storage struct {
+	db      *sql.DB
+	postgis bool
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+func New(dsn string) (*Storage, error) {
+	const op = "storage.postgres.New"
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed to open db: %w", op, err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s failed to connect: %w", op, err)
+	}
+
+	postgis, err := migrate(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Storage{db: db, postgis: postgis}, nil
+}
+
+// arg appends a query argument and returns its numbered placeholder
+func arg(args []any, value any) (string, []any) {
+	args = append(args, value)
+	return "$" + strconv.Itoa(len(args)), args
+}
+
+// dayBounds widens a date without time to the whole day, like the SQLite backend
+func dayBounds(currentTime string) (string, string) {
+	if len(currentTime) == 10 {
+		return currentTime + " 23:59:59", currentTime + " 00:00:00"
+	}
+	return currentTime, currentTime
+}
+
+func (s *Storage) FindStreets(substr string, filter models.Filter) ([]string, error) {
+	const op = "storage.postgres.FindStreets"
+
+	if len(substr) == 0 {
+		return []string{}, nil
+	}
+
+	lowSubstr := strings.ToLower(substr)
+	normSubstr := func(str string) string {
+		str = strings.ToLower(str)
+		runes := []rune(str)
+		runes[0] = unicode.ToUpper(runes[0])
+		return string(runes)
+	}(substr)
+
+	args := []any{"%" + lowSubstr + "%", normSubstr + "%"}
+	cond, args := citiesFilter("id", filter, args)
+
+	rows, err := s.db.Query(`
+        SELECT name FROM streets
+        WHERE (name LIKE $1 OR name LIKE $2)`+cond,
+		args...)
+	if err != nil {
+		return []string{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var streets []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return []string{}, fmt.Errorf("%s: %w", op, err)
+		}
+		streets = append(streets, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []string{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return streets, nil
+}
+
+func (s *Storage) GetBlackouts(currentTime string) ([]models.Blackout, error) {
+	const op = "storage.postgres.GetBlackouts"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE start_date <= $1 AND (end_date >= $2 OR end_date IS NULL)
+        ORDER BY start_date DESC`,
+		queryTime, currentTime)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+// GetFilteredBlackouts is GetBlackouts limited to the blackouts touching buildings that match the filter
+func (s *Storage) GetFilteredBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error) {
+	const op = "storage.postgres.GetFilteredBlackouts"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	cond, args := blackoutsFilter("id", filter, []any{queryTime, currentTime})
+	kindCond, args := kindFilter("kind", filter, args)
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE start_date <= $1 AND (end_date >= $2 OR end_date IS NULL)`+cond+kindCond+`
+        ORDER BY start_date DESC`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+func (s *Storage) GetBuildingsCount(filter models.Filter) (int64, error) {
+	const op = "storage.postgres.GetBuildingsCount"
+
+	if filter.City != "" && filter.District == "" {
+		total, err := s.getBuildingsTotal(filter.City)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if total > 0 {
+			return total, nil
+		}
+	}
+
+	cond, args := buildingsFilter("id", filter, nil)
+
+	var count int64
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM buildings WHERE NOT is_fake`+cond, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// GetStreetsCount returns the number of streets with at least one real building
+func (s *Storage) GetStreetsCount() (int, error) {
+	const op = "storage.postgres.GetStreetsCount"
+
+	var count int
+	err := s.db.QueryRow(`
+        SELECT COUNT(DISTINCT street_id) FROM buildings WHERE NOT is_fake`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (s *Storage) GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error) {
+	const op = "storage.postgres.GetBuildingsCountByBlackoutType"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	cond, args := buildingsFilter("b.id", filter, []any{blackoutType, queryTime, currentTime})
+	kindCond, args := kindFilter("bl.kind", filter, args)
+
+	var count int64
+	err := s.db.QueryRow(`
+        SELECT COUNT(DISTINCT b.id)
+        FROM buildings b
+        JOIN blackouts_buildings bb ON b.id = bb.building_id
+        JOIN blackouts bl ON bb.blackout_id = bl.id
+        WHERE bl.type = $1
+        AND NOT b.is_fake
+        AND bl.start_date <= $2
+        AND (bl.end_date >= $3 OR bl.end_date IS NULL)`+cond+kindCond,
+		args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// GetBuildingsCountByOrganization returns the number of buildings affected by
+// blackouts of the type at currentTime per initiating organization. A building
+// switched off by two organizations is counted for each of them.
+func (s *Storage) GetBuildingsCountByOrganization(blackoutType string, currentTime string, filter models.Filter) (map[string]int64, error) {
+	const op = "storage.postgres.GetBuildingsCountByOrganization"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	cond, args := buildingsFilter("b.id", filter, []any{blackoutType, queryTime, currentTime})
+	kindCond, args := kindFilter("bl.kind", filter, args)
+
+	rows, err := s.db.Query(`
+        SELECT COALESCE(bl.initiator_name, ''), COUNT(DISTINCT b.id)
+        FROM buildings b
+        JOIN blackouts_buildings bb ON b.id = bb.building_id
+        JOIN blackouts bl ON bb.blackout_id = bl.id
+        WHERE bl.type = $1
+        AND NOT b.is_fake
+        AND bl.start_date <= $2
+        AND (bl.end_date >= $3 OR bl.end_date IS NULL)`+cond+kindCond+`
+        GROUP BY bl.initiator_name`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int64{}
+	for rows.Next() {
+		var name string
+		var count int64
+
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		counts[name] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+func (s *Storage) GetLastBlackoutTimeByType(blackoutType string, currentTime string, filter models.Filter) (string, error) {
+	const op = "storage.postgres.GetLastBlackoutTimeByType"
+
+	cond, args := blackoutsFilter("id", filter, []any{blackoutType, currentTime})
+	kindCond, args := kindFilter("kind", filter, args)
+
+	var lastTime string
+	err := s.db.QueryRow(`
+        SELECT MAX(start_date)
+        FROM blackouts
+        WHERE type = $1
+        AND start_date <= $2`+cond+kindCond,
+		args...).Scan(&lastTime)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lastTime, nil
+}
+
+// complaintBuckets describes how GetComplaints* split their period
+type complaintBuckets struct {
+	count   int
+	step    time.Duration
+	key     string
+	display string
+	// format is the to_char pattern producing key from start_date
+	format string
+}
+
+var (
+	complaintsHour  = complaintBuckets{60, time.Minute, "2006-01-02 15:04:00", "15:04", "YYYY-MM-DD HH24:MI:00"}
+	complaintsDay   = complaintBuckets{24, time.Hour, "2006-01-02 15:00:00", "15:04", "YYYY-MM-DD HH24:00:00"}
+	complaintsWeek  = complaintBuckets{7, 24 * time.Hour, "2006-01-02", "02.01", "YYYY-MM-DD"}
+	complaintsMonth = complaintBuckets{30, 24 * time.Hour, "2006-01-02", "02.01", "YYYY-MM-DD"}
+)
+
+func (s *Storage) GetComplaintsLastHour(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
+	const op = "storage.postgres.GetComplaintsLastHour"
+
+	data, err := s.getComplaints(endTime, filter, complaintsHour)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+func (s *Storage) GetComplaintsLastDay(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
+	const op = "storage.postgres.GetComplaintsLastDay"
+
+	data, err := s.getComplaints(endTime, filter, complaintsDay)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+func (s *Storage) GetComplaintsLastWeek(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
+	const op = "storage.postgres.GetComplaintsLastWeek"
+
+	data, err := s.getComplaints(endTime, filter, complaintsWeek)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+func (s *Storage) GetComplaintsLastMonth(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
+	const op = "storage.postgres.GetComplaintsLastMonth"
+
+	data, err := s.getComplaints(endTime, filter, complaintsMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+// getComplaints counts blackouts started in each bucket of the period ending at endTime, per type
+func (s *Storage) getComplaints(endTime string, filter models.Filter, buckets complaintBuckets) ([]models.ComplaintData, error) {
+	endTimeParsed, err := time.Parse("2006-01-02 15:04:05", endTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time format: %w", err)
+	}
+
+	startTime := endTimeParsed.Add(-time.Duration(buckets.count) * buckets.step)
+	startTimeStr := startTime.Format("2006-01-02 15:04:05")
+	endTimeStr := endTimeParsed.Format("2006-01-02 15:04:05")
+
+	serviceTypes, err := s.GetServiceTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	cond, args := blackoutsFilter("id", filter, []any{buckets.format, startTimeStr, endTimeStr})
+	kindCond, args := kindFilter("kind", filter, args)
+
+	rows, err := s.db.Query(`
+        SELECT to_char(start_date::timestamp, $1) AS bucket, type, COUNT(*)
+        FROM blackouts
+        WHERE start_date >= $2 AND start_date < $3`+cond+kindCond+`
+        GROUP BY bucket, type
+        ORDER BY bucket`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dataMap := make(map[string]*models.ComplaintData)
+
+	for i := 0; i < buckets.count; i++ {
+		bucketTime := startTime.Add(time.Duration(i) * buckets.step)
+		dataMap[bucketTime.Format(buckets.key)] = models.NewComplaintData(bucketTime.Format(buckets.display), serviceTypes.Codes())
+	}
+
+	for rows.Next() {
+		var bucket, blackoutType string
+		var count int
+		if err := rows.Scan(&bucket, &blackoutType, &count); err != nil {
+			return nil, err
+		}
+
+		if data, exists := dataMap[bucket]; exists {
+			if _, registered := data.Counts[blackoutType]; registered {
+				data.Counts[blackoutType] = count
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []models.ComplaintData
+	for i := 0; i < buckets.count; i++ {
+		bucketTime := startTime.Add(time.Duration(i) * buckets.step)
+		if data, exists := dataMap[bucketTime.Format(buckets.key)]; exists {
+			result = append(result, *data)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Storage) GetOrganizations(currentTime string, filter models.Filter) ([]string, error) {
+	const op = "storage.postgres.GetOrganizations"
+
+	cond, args := blackoutsFilter("b.id", filter, []any{currentTime})
+	kindCond, args := kindFilter("b.kind", filter, args)
+	cond += kindCond
+
+	rows, err := s.db.Query(`
+        SELECT b.initiator_name
+        FROM blackouts b
+        JOIN blackouts_buildings bb ON b.id = bb.blackout_id
+        WHERE b.start_date <= $1 AND (b.end_date >= $1 OR b.end_date IS NULL)`+cond+`
+        GROUP BY b.initiator_name
+        ORDER BY COUNT(DISTINCT bb.building_id) DESC
+        LIMIT 4`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var organizations []string
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		organizations = append(organizations, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return organizations, nil
+}
+
+func (s *Storage) GetBuildingsCountByOrgName(name string, currentTime string, filter models.Filter) (int64, error) {
+	const op = "storage.postgres.GetBuildingsCountByOrgName"
+
+	cond, args := blackoutsFilter("b.id", filter, []any{name, currentTime})
+	kindCond, args := kindFilter("b.kind", filter, args)
+	cond += kindCond
+
+	var count int64
+	err := s.db.QueryRow(`
+        SELECT COUNT(DISTINCT bb.building_id)
+        FROM blackouts b
+        JOIN blackouts_buildings bb ON b.id = bb.blackout_id
+        JOIN buildings bu ON bb.building_id = bu.id
+        WHERE b.initiator_name = $1
+        AND b.start_date <= $2
+        AND (b.end_date >= $2 OR b.end_date IS NULL)
+        AND NOT bu.is_fake`+cond,
+		args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (s *Storage) GetLastAddressByOrgName(name string, currentTime string, filter models.Filter) (string, string, error) {
+	const op = "storage.postgres.GetLastAddressByOrgName"
+
+	cond, args := blackoutsFilter("b.id", filter, []any{name, currentTime})
+	kindCond, args := kindFilter("b.kind", filter, args)
+	cond += kindCond
+
+	var lastTime, address string
+	err := s.db.QueryRow(`
+        SELECT b.start_date, s.name || ' ' || bg.number
+        FROM blackouts b
+        JOIN blackouts_buildings bb ON b.id = bb.blackout_id
+        JOIN buildings bg ON bb.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        WHERE b.initiator_name = $1
+        AND b.start_date <= $2
+        AND NOT bg.is_fake`+cond+`
+        ORDER BY b.start_date DESC
+        LIMIT 1`,
+		args...).Scan(&lastTime, &address)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lastTime, address, nil
+}
+
+func (s *Storage) GetBlackoutsWithBuildingsCount(targetDate string, filter models.Filter) ([]models.BlackoutInfo, error) {
+	const op = "storage.postgres.GetBlackoutsWithBuildingsCount"
+
+	queryTime, targetDate := dayBounds(targetDate)
+
+	cond, args := buildingsFilter("b.id", filter, []any{queryTime, targetDate})
+	kindCond, args := kindFilter("bl.kind", filter, args)
+
+	// minutes are enough for the calendar, "2006-01-02 15:04:05" -> "2006-01-02 15:04"
+	rows, err := s.db.Query(`
+        SELECT
+            bl.type,
+            left(bl.start_date, 16),
+            left(bl.end_date, 16),
+            COUNT(DISTINCT b.id) AS building_count,
+            COALESCE(bl.kind, 'unknown')
+        FROM blackouts bl
+        JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+        JOIN buildings b ON bb.building_id = b.id
+        WHERE NOT b.is_fake
+        AND bl.start_date <= $1
+        AND (bl.end_date >= $2 OR bl.end_date IS NULL)`+cond+kindCond+`
+        GROUP BY bl.id
+        ORDER BY bl.start_date DESC`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var blackouts []models.BlackoutInfo
+	for rows.Next() {
+		var blackout models.BlackoutInfo
+		var endDate sql.NullString
+
+		err := rows.Scan(
+			&blackout.Type,
+			&blackout.StartDate,
+			&endDate,
+			&blackout.BuildingCount,
+			&blackout.Kind,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		blackout.EndDate = endDate.String
+
+		blackouts = append(blackouts, blackout)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+func (s *Storage) GetBlackoutAddresses(blackoutID string) ([]models.Address, error) {
+	const op = "storage.postgres.GetBlackoutAddresses"
+
+	rows, err := s.db.Query(`
+        SELECT bg.id, s.name, bg.number
+        FROM blackouts_buildings bb
+        JOIN buildings bg ON bb.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        WHERE bb.blackout_id = $1
+        AND NOT bg.is_fake
+        ORDER BY s.name, bg.number`,
+		blackoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	addresses, err := scanAddresses(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return addresses, nil
+}
+
+// GetUpcomingBlackouts returns the blackouts of the filter starting after currentTime
+func (s *Storage) GetUpcomingBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error) {
+	const op = "storage.postgres.GetUpcomingBlackouts"
+
+	cond, args := blackoutsFilter("id", filter, []any{currentTime})
+	kindCond, args := kindFilter("kind", filter, args)
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE start_date > $1`+cond+kindCond+`
+        ORDER BY start_date`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+func (s *Storage) GetBuildingBlackouts(buildingID int64, from string, to string) ([]models.Blackout, error) {
+	const op = "storage.postgres.GetBuildingBlackouts"
+
+	rows, err := s.db.Query(`
+        SELECT bl.id, bl.start_date, bl.end_date, bl.description, bl.type, bl.initiator_name, bl.source, COALESCE(bl.kind, 'unknown')
+        FROM blackouts bl
+        JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+        WHERE bb.building_id = $1
+        AND bl.start_date <= $2
+        AND (bl.end_date >= $3 OR bl.end_date IS NULL)
+        ORDER BY bl.start_date`,
+		buildingID, to, from)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+// FindBuilding returns the real building of the filter city at the address
+func (s *Storage) FindBuilding(street string, number string, filter models.Filter) (models.Address, error) {
+	const op = "storage.postgres.FindBuilding"
+
+	cond, args := buildingsFilter("bg.id", filter, []any{strings.TrimSpace(street), strings.TrimSpace(number)})
+
+	var address models.Address
+	err := s.db.QueryRow(`
+        SELECT bg.id, s.name, bg.number
+        FROM buildings bg
+        JOIN streets s ON bg.street_id = s.id
+        WHERE s.name = $1 AND bg.number = $2
+        AND NOT bg.is_fake`+cond+`
+        LIMIT 1`,
+		args...).Scan(&address.BuildingID, &address.Street, &address.Number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Address{}, storage.ErrBuildingNotFound
+	}
+	if err != nil {
+		return models.Address{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return address, nil
+}
+
+// GetStreetBuildings returns the real buildings of a street in the filter city
+// ordered by the leading number of the house, "12а" goes after "12"
+func (s *Storage) GetStreetBuildings(street string, filter models.Filter) ([]models.Address, error) {
+	const op = "storage.postgres.GetStreetBuildings"
+
+	cond, args := buildingsFilter("bg.id", filter, []any{street})
+
+	rows, err := s.db.Query(`
+        SELECT bg.id, s.name, bg.number
+        FROM buildings bg
+        JOIN streets s ON bg.street_id = s.id
+        WHERE s.name = $1
+        AND NOT bg.is_fake`+cond+`
+        ORDER BY COALESCE(substring(bg.number FROM '^[0-9]+')::bigint, 0), bg.number`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	addresses, err := scanAddresses(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return addresses, nil
+}
+
+func scanBlackouts(rows *sql.Rows) ([]models.Blackout, error) {
+	var blackouts []models.Blackout
+	for rows.Next() {
+		var blackout models.Blackout
+		var endDate sql.NullString
+		var source sql.NullString
+
+		err := rows.Scan(
+			&blackout.ID,
+			&blackout.StartDate,
+			&endDate,
+			&blackout.Description,
+			&blackout.Type,
+			&blackout.InitiatorName,
+			&source,
+			&blackout.Kind,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		blackout.EndDate = endDate.String
+		blackout.Source = source.String
+
+		blackouts = append(blackouts, blackout)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return blackouts, nil
+}
+
+func scanAddresses(rows *sql.Rows) ([]models.Address, error) {
+	var addresses []models.Address
+	for rows.Next() {
+		var address models.Address
+
+		if err := rows.Scan(&address.BuildingID, &address.Street, &address.Number); err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,292 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrationLock serializes migrations of processes started at the same time
+const migrationLock = 7_242_011
+
+// schema holds every table of the service. Unlike the SQLite database the
+// source tables (streets, buildings, blackouts, blackouts_buildings) are created
+// here too, the loader fills them. Times stay text in the "2006-01-02 15:04:05"
+// format of the source, so they sort and compare as in the SQLite backend.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS cities (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		code TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		timezone TEXT NOT NULL,
+		buildings_total BIGINT NOT NULL DEFAULT 0,
+		is_default BOOLEAN NOT NULL DEFAULT FALSE
+	)`,
+	`CREATE TABLE IF NOT EXISTS districts (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		city_id BIGINT NOT NULL REFERENCES cities(id),
+		name TEXT NOT NULL,
+		UNIQUE (city_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS streets (
+		id BIGINT PRIMARY KEY,
+		name TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS buildings (
+		id BIGINT PRIMARY KEY,
+		street_id BIGINT NOT NULL,
+		number TEXT NOT NULL DEFAULT '',
+		is_fake BOOLEAN NOT NULL DEFAULT FALSE
+	)`,
+	`CREATE TABLE IF NOT EXISTS blackouts (
+		id TEXT PRIMARY KEY,
+		start_date TEXT NOT NULL,
+		end_date TEXT,
+		description TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL,
+		initiator_name TEXT NOT NULL DEFAULT '',
+		source TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS blackouts_buildings (
+		blackout_id TEXT NOT NULL,
+		building_id BIGINT NOT NULL,
+		PRIMARY KEY (blackout_id, building_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhooks (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		buildings JSONB NOT NULL DEFAULT '[]',
+		streets JSONB NOT NULL DEFAULT '[]',
+		types JSONB NOT NULL DEFAULT '[]',
+		organizations JSONB NOT NULL DEFAULT '[]',
+		created_at TEXT NOT NULL,
+		city_id BIGINT REFERENCES cities(id)
+	)`,
+	// the payload is kept byte for byte, receivers check its signature
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		webhook_id BIGINT NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TEXT NOT NULL,
+		last_status_code INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		delivered_at TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+		ON webhook_deliveries(status, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook
+		ON webhook_deliveries(webhook_id)`,
+	`CREATE TABLE IF NOT EXISTS subscriptions (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		email TEXT NOT NULL,
+		building_id BIGINT NOT NULL,
+		mode TEXT NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		confirmed_at TEXT,
+		created_at TEXT NOT NULL,
+		last_digest_at TEXT,
+		UNIQUE (email, building_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_subscriptions_building
+		ON subscriptions(building_id)`,
+	`CREATE TABLE IF NOT EXISTS telegram_subscriptions (
+		chat_id BIGINT NOT NULL,
+		building_id BIGINT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (chat_id, building_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_telegram_subscriptions_building
+		ON telegram_subscriptions(building_id)`,
+	`CREATE TABLE IF NOT EXISTS telegram_chats (
+		chat_id BIGINT PRIMARY KEY,
+		city_id BIGINT NOT NULL REFERENCES cities(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS hotspots (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		scope TEXT NOT NULL,
+		building_id BIGINT NOT NULL DEFAULT 0,
+		street TEXT NOT NULL,
+		number TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT '',
+		city_id BIGINT NOT NULL DEFAULT 0,
+		outages INTEGER NOT NULL,
+		window_outages INTEGER NOT NULL,
+		periodic BOOLEAN NOT NULL DEFAULT FALSE,
+		period_days DOUBLE PRECISION NOT NULL DEFAULT 0,
+		first_at TEXT NOT NULL,
+		last_at TEXT NOT NULL,
+		timeline JSONB NOT NULL DEFAULT '[]',
+		detected_at TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS anomalies (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		city_id BIGINT NOT NULL DEFAULT 0,
+		type TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT '',
+		hour TEXT NOT NULL,
+		count INTEGER NOT NULL,
+		baseline DOUBLE PRECISION NOT NULL,
+		stddev DOUBLE PRECISION NOT NULL,
+		z_score DOUBLE PRECISION NOT NULL,
+		detected_at TEXT NOT NULL,
+		UNIQUE (city_id, type, kind, hour)
+	)`,
+	`CREATE TABLE IF NOT EXISTS blackout_revisions (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		blackout_id TEXT NOT NULL,
+		start_date TEXT NOT NULL,
+		end_date TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		changed_at TEXT NOT NULL,
+		city_id BIGINT REFERENCES cities(id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_blackout_revisions_blackout
+		ON blackout_revisions(blackout_id, id)`,
+	`CREATE OR REPLACE FUNCTION blackout_revisions_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'blackout revisions are append-only';
+		END
+		$$ LANGUAGE plpgsql`,
+	`CREATE OR REPLACE TRIGGER blackout_revisions_no_change
+		BEFORE UPDATE OR DELETE ON blackout_revisions
+		FOR EACH ROW EXECUTE FUNCTION blackout_revisions_append_only()`,
+	`CREATE TABLE IF NOT EXISTS service_types (
+		code TEXT PRIMARY KEY,
+		name_ru TEXT NOT NULL,
+		name_en TEXT NOT NULL,
+		icon TEXT NOT NULL DEFAULT '',
+		position INTEGER NOT NULL DEFAULT 0
+	)`,
+	// the registry starts with the types of the source, new ones come from the config
+	// and rows edited by hand are kept
+	`INSERT INTO service_types (code, name_ru, name_en, icon, position) VALUES
+		('hot_water', 'горячая вода', 'hot water', 'hot-water', 1),
+		('cold_water', 'холодная вода', 'cold water', 'cold-water', 2),
+		('electricity', 'электричество', 'electricity', 'lighting', 3),
+		('heat', 'отопление', 'heating', 'heating', 4)
+		ON CONFLICT (code) DO NOTHING`,
+	`CREATE TABLE IF NOT EXISTS data_version (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		version BIGINT NOT NULL
+	)`,
+	`INSERT INTO data_version (id, version) VALUES (1, 0) ON CONFLICT (id) DO NOTHING`,
+	`CREATE OR REPLACE FUNCTION data_version_bump() RETURNS trigger AS $$
+		BEGIN
+			UPDATE data_version SET version = version + 1 WHERE id = 1;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+}
+
+// columns extend the source tables, also when the loader created them first
+var columns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"buildings", "latitude", "DOUBLE PRECISION"},
+	{"buildings", "longitude", "DOUBLE PRECISION"},
+	{"streets", "district_id", "BIGINT REFERENCES districts(id)"},
+	{"buildings", "district_id", "BIGINT REFERENCES districts(id)"},
+	{"blackouts", "kind", "TEXT"},
+	{"blackouts", "kind_manual", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	{"blackouts", "state", "TEXT"},
+	{"streets", "city_id", "BIGINT REFERENCES cities(id)"},
+	{"blackouts", "city_id", "BIGINT REFERENCES cities(id)"},
+}
+
+// versioned are the source tables, a write to any of them raises data_version
+// and drops the response cache. The service tables change on their own schedule
+// and their responses live until the TTL ends.
+var versioned = []string{"streets", "buildings", "blackouts", "blackouts_buildings"}
+
+// indexes cover the lookups of the aggregate endpoints
+var indexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_blackouts_start ON blackouts(start_date)`,
+	`CREATE INDEX IF NOT EXISTS idx_blackouts_type_start ON blackouts(type, start_date)`,
+	`CREATE INDEX IF NOT EXISTS idx_blackouts_buildings_building ON blackouts_buildings(building_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_buildings_street ON buildings(street_id)`,
+}
+
+// spatial is the PostGIS part of the schema, applied when the server has the
+// extension. The point is generated from the coordinates, so whatever writes
+// latitude and longitude keeps it current. Radius queries measure on the
+// sphere through the geography index, polygon queries test the plain geometry.
+var spatial = []string{
+	`CREATE EXTENSION IF NOT EXISTS postgis`,
+	`ALTER TABLE buildings ADD COLUMN IF NOT EXISTS geom geometry(Point, 4326)
+		GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_buildings_geom ON buildings USING GIST (geom)`,
+	`CREATE INDEX IF NOT EXISTS idx_buildings_geog ON buildings USING GIST ((geom::geography))`,
+}
+
+// migrate brings the schema up to date in one transaction. It reports whether
+// PostGIS is available and the spatial part is in place.
+func migrate(db *sql.DB) (bool, error) {
+	const op = "storage.postgres.migrate"
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, stmt := range schema {
+		if _, err := tx.Exec(stmt); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for _, column := range columns {
+		stmt := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`, column.table, column.name, column.definition)
+		if _, err := tx.Exec(stmt); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for _, table := range versioned {
+		stmt := fmt.Sprintf(`CREATE OR REPLACE TRIGGER %[1]s_data_version
+			AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON %[1]s
+			FOR EACH STATEMENT EXECUTE FUNCTION data_version_bump()`, table)
+		if _, err := tx.Exec(stmt); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for _, stmt := range indexes {
+		if _, err := tx.Exec(stmt); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var postgis bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'postgis')`).Scan(&postgis)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if postgis {
+		for _, stmt := range spatial {
+			if _, err := tx.Exec(stmt); err != nil {
+				return false, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return postgis, nil
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/models"
+)
+
+var errNoPostGIS = errors.New("PostGIS is not available")
+
+// Spatial reports whether the database has PostGIS, the storage answers
+// radius and polygon queries itself then
+func (s *Storage) Spatial() bool {
+	return s.postgis
+}
+
+// NearbyBuildings returns the located buildings of the filter within radius
+// meters from center, nearest first
+func (s *Storage) NearbyBuildings(center geo.Point, radius float64, filter models.Filter) ([]geo.Hit, error) {
+	const op = "storage.postgres.NearbyBuildings"
+
+	if !s.postgis {
+		return nil, fmt.Errorf("%s: %w", op, errNoPostGIS)
+	}
+
+	cond, args := buildingsFilter("bg.id", filter, []any{center.Lon, center.Lat, radius})
+
+	hits, err := s.hits(`
+        SELECT bg.id, bg.latitude, bg.longitude,
+               ST_Distance(bg.geom::geography, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography, false) AS distance
+        FROM buildings bg
+        WHERE NOT bg.is_fake
+        AND ST_DWithin(bg.geom::geography, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography, $3, false)`+cond+`
+        ORDER BY distance, bg.id`, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hits, nil
+}
+
+// BuildingsWithin returns the located buildings of the filter inside the
+// polygon sorted by distance from ref
+func (s *Storage) BuildingsWithin(polygon geo.Polygon, ref geo.Point, filter models.Filter) ([]geo.Hit, error) {
+	const op = "storage.postgres.BuildingsWithin"
+
+	if !s.postgis {
+		return nil, fmt.Errorf("%s: %w", op, errNoPostGIS)
+	}
+
+	cond, args := buildingsFilter("bg.id", filter, []any{ref.Lon, ref.Lat, wkt(polygon)})
+
+	hits, err := s.hits(`
+        SELECT bg.id, bg.latitude, bg.longitude,
+               ST_Distance(bg.geom::geography, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography, false) AS distance
+        FROM buildings bg
+        WHERE NOT bg.is_fake
+        AND ST_Covers(ST_GeomFromText($3, 4326), bg.geom)`+cond+`
+        ORDER BY distance, bg.id`, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hits, nil
+}
+
+func (s *Storage) hits(query string, args []any) ([]geo.Hit, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []geo.Hit
+	for rows.Next() {
+		var hit geo.Hit
+		if err := rows.Scan(&hit.ID, &hit.Point.Lat, &hit.Point.Lon, &hit.Distance); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// wkt writes the polygon as well-known text, closing rings left open
+func wkt(polygon geo.Polygon) string {
+	var b strings.Builder
+
+	b.WriteString("POLYGON(")
+	for i, ring := range polygon {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(ring[:len(ring):len(ring)], ring[0])
+		}
+
+		b.WriteString("(")
+		for j, pt := range ring {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(strconv.FormatFloat(pt.Lon, 'f', -1, 64))
+			b.WriteString(" ")
+			b.WriteString(strconv.FormatFloat(pt.Lat, 'f', -1, 64))
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"testing"
+	"vlru-prsch/internal/lib/geo"
+)
+
+func TestWKT(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon geo.Polygon
+		want    string
+	}{
+		{
+			"open ring",
+			geo.Polygon{{{Lat: 43.1, Lon: 131.9}, {Lat: 43.2, Lon: 131.9}, {Lat: 43.2, Lon: 132}}},
+			"POLYGON((131.9 43.1,131.9 43.2,132 43.2,131.9 43.1))",
+		},
+		{
+			"closed ring with a hole",
+			geo.Polygon{
+				{{Lat: 0, Lon: 0}, {Lat: 4, Lon: 0}, {Lat: 4, Lon: 4}, {Lat: 0, Lon: 0}},
+				{{Lat: 1, Lon: 1}, {Lat: 2, Lon: 1}, {Lat: 2, Lon: 2}},
+			},
+			"POLYGON((0 0,0 4,4 4,0 0),(1 1,1 2,2 2,1 1))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wkt(tt.polygon); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DataVersion returns the counter raised by the triggers of the source tables
+func (s *Storage) DataVersion() (string, error) {
+	const op = "storage.postgres.DataVersion"
+
+	var version int64
+	if err := s.db.QueryRow(`SELECT version FROM data_version WHERE id = 1`).Scan(&version); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return strconv.FormatInt(version, 10), nil
+}
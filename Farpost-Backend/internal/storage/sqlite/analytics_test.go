@@ -0,0 +1,50 @@
+package sqlite_test
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+func TestGetBlackoutsWithBuildings(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		filter models.Filter
+		want   map[string]int64
+	}{
+		{"whole range", "2024-01-01", "2024-12-31", models.Filter{}, map[string]int64{
+			"b4": 1, "b6": 2, "b1": 2, "b3": 1, "b2": 1, "b5": 1,
+		}},
+		{"to covers the whole day", "2024-03-10", "2024-03-10", models.Filter{}, map[string]int64{
+			"b1": 2, "b3": 1, "b2": 1,
+		}},
+		{"default city", "2024-03-10", "2024-03-10", vladivostok, map[string]int64{"b1": 2, "b2": 1}},
+		{"other city", "2024-03-10", "2024-03-10", khabarovsk, map[string]int64{"b3": 1}},
+		{"empty range", "2023-01-01", "2023-12-31", models.Filter{}, map[string]int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBlackoutsWithBuildings(tt.from, tt.to, tt.filter)
+			check(t, err)
+
+			counts := map[string]int64{}
+			for _, b := range got {
+				counts[b.ID] = b.BuildingsCount
+			}
+			equal(t, counts, tt.want)
+		})
+	}
+
+	t.Run("NULL end_date and source read as empty", func(t *testing.T) {
+		got, err := s.GetBlackoutsWithBuildings("2024-03-09", "2024-03-09", models.Filter{})
+		check(t, err)
+		equal(t, len(got), 1)
+		equal(t, got[0].EndDate, "")
+		equal(t, got[0].Source, "")
+	})
+}
@@ -0,0 +1,167 @@
+package sqlite_test
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+func TestGetHourlyCounts(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		filter models.Filter
+		want   []models.HourlyCount
+	}{
+		{"to is exclusive", "2024-03-10 09:00:00", "2024-03-10 11:30:00", models.Filter{}, []models.HourlyCount{
+			{Hour: "2024-03-10 09:00:00", Type: "hot_water", Count: 1},
+			{Hour: "2024-03-10 10:00:00", Type: "hot_water", Count: 1},
+		}},
+		// b7 touches only the fake building 13, b2 touches a real one as well
+		{"blackouts of fake buildings only are left out", "2024-03-10 10:00:00", "2024-03-10 12:00:00", models.Filter{}, []models.HourlyCount{
+			{Hour: "2024-03-10 10:00:00", Type: "hot_water", Count: 1},
+			{Hour: "2024-03-10 11:00:00", Type: "electricity", Count: 1},
+		}},
+		{"city", "2024-03-10 09:00:00", "2024-03-10 11:30:00", vladivostok, []models.HourlyCount{
+			{Hour: "2024-03-10 09:00:00", Type: "hot_water", Count: 1},
+		}},
+		{"other city", "2024-03-10 09:00:00", "2024-03-10 11:30:00", khabarovsk, []models.HourlyCount{
+			{Hour: "2024-03-10 10:00:00", Type: "hot_water", Count: 1},
+		}},
+		{"empty range", "2023-01-01 00:00:00", "2023-01-02 00:00:00", models.Filter{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetHourlyCounts(tt.from, tt.to, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestSaveAnomaly(t *testing.T) {
+	anomaly := models.Anomaly{
+		Type:       "electricity",
+		Hour:       "2024-03-10 11:00:00",
+		Count:      5,
+		Baseline:   1,
+		StdDev:     0.5,
+		ZScore:     8,
+		DetectedAt: "2024-03-10 12:00:00",
+	}
+	lower, higher := anomaly, anomaly
+	lower.Count, lower.ZScore = 3, 4
+	higher.Count, higher.ZScore = 7, 12
+
+	tests := []struct {
+		name      string
+		saved     []models.Anomaly
+		anomaly   models.Anomaly
+		wantNew   bool
+		wantCount int
+	}{
+		{"new", nil, anomaly, true, 5},
+		{"repeated with a higher count", []models.Anomaly{anomaly}, higher, false, 7},
+		{"repeated with a lower count", []models.Anomaly{anomaly}, lower, false, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+			for _, a := range tt.saved {
+				_, err := s.SaveAnomaly(a)
+				check(t, err)
+			}
+
+			isNew, err := s.SaveAnomaly(tt.anomaly)
+			check(t, err)
+			equal(t, isNew, tt.wantNew)
+
+			got, err := s.GetAnomalies("", "2024-03-10 00:00:00", "2024-03-10 23:59:59", 10, models.Filter{})
+			check(t, err)
+			equal(t, len(got), 1)
+			equal(t, got[0].Count, tt.wantCount)
+		})
+	}
+}
+
+func TestGetAnomalies(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	for _, a := range []models.Anomaly{
+		{Type: "electricity", Hour: "2024-03-10 11:00:00", Count: 5, DetectedAt: "2024-03-10 12:00:00"},
+		{Type: "hot_water", Hour: "2024-03-10 11:00:00", Count: 4, DetectedAt: "2024-03-10 12:00:00"},
+		{Type: "hot_water", Hour: "2024-03-09 08:00:00", Count: 3, DetectedAt: "2024-03-09 09:00:00"},
+		{Type: "electricity", Kind: models.KindEmergency, Hour: "2024-03-10 11:00:00", Count: 4, DetectedAt: "2024-03-10 12:00:00"},
+	} {
+		_, err := s.SaveAnomaly(a)
+		check(t, err)
+	}
+
+	tests := []struct {
+		name         string
+		blackoutType string
+		from         string
+		to           string
+		limit        int
+		want         []string
+	}{
+		{"newest first, then by type", "", "2024-03-01 00:00:00", "2024-03-31 23:59:59", 10, []string{
+			"2024-03-10 11:00:00 electricity", "2024-03-10 11:00:00 hot_water", "2024-03-09 08:00:00 hot_water",
+		}},
+		{"type", "hot_water", "2024-03-01 00:00:00", "2024-03-31 23:59:59", 10, []string{
+			"2024-03-10 11:00:00 hot_water", "2024-03-09 08:00:00 hot_water",
+		}},
+		{"bounds are inclusive", "", "2024-03-09 08:00:00", "2024-03-09 08:00:00", 10, []string{"2024-03-09 08:00:00 hot_water"}},
+		{"limit", "", "2024-03-01 00:00:00", "2024-03-31 23:59:59", 1, []string{"2024-03-10 11:00:00 electricity"}},
+		{"empty range", "", "2023-01-01 00:00:00", "2023-12-31 23:59:59", 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetAnomalies(tt.blackoutType, tt.from, tt.to, tt.limit, models.Filter{})
+			check(t, err)
+
+			var keys []string
+			for _, a := range got {
+				keys = append(keys, a.Hour+" "+a.Type)
+			}
+			equal(t, keys, tt.want)
+		})
+	}
+
+	t.Run("kind", func(t *testing.T) {
+		got, err := s.GetAnomalies("", "2024-03-01 00:00:00", "2024-03-31 23:59:59", 10, models.Filter{Kind: models.KindEmergency})
+		check(t, err)
+		equal(t, len(got), 1)
+		equal(t, got[0].Count, 4)
+	})
+
+	t.Run("city", func(t *testing.T) {
+		s := sqlitetest.Fixture(t)
+
+		// the same type and hour in two cities are two anomalies
+		for _, a := range []models.Anomaly{
+			{Type: "electricity", Hour: "2024-03-10 11:00:00", Count: 5, DetectedAt: "2024-03-10 12:00:00"},
+			{City: "khabarovsk", Type: "electricity", Hour: "2024-03-10 11:00:00", Count: 2, DetectedAt: "2024-03-10 12:00:00"},
+		} {
+			created, err := s.SaveAnomaly(a)
+			check(t, err)
+			equal(t, created, true)
+		}
+
+		got, err := s.GetAnomalies("", "2024-03-01 00:00:00", "2024-03-31 23:59:59", 10, khabarovsk)
+		check(t, err)
+		equal(t, len(got), 1)
+		equal(t, got[0].Count, 2)
+
+		got, err = s.GetAnomalies("", "2024-03-01 00:00:00", "2024-03-31 23:59:59", 10, vladivostok)
+		check(t, err)
+		equal(t, len(got), 1)
+		equal(t, got[0].Count, 5)
+	})
+}
@@ -0,0 +1,180 @@
+package sqlite_test
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+var (
+	vladivostok = models.Filter{City: "vladivostok"}
+	khabarovsk  = models.Filter{City: "khabarovsk"}
+)
+
+func TestSyncCities(t *testing.T) {
+	s, db := open(t)
+
+	check(t, s.SyncCities([]models.City{
+		{Code: "vladivostok", Name: "Владивосток", Timezone: "Asia/Vladivostok"},
+		{Code: "khabarovsk", Name: "Хабаровск", Timezone: "Asia/Vladivostok"},
+	}, "khabarovsk"))
+
+	// a city missing from the configuration is kept, the default moves
+	check(t, s.SyncCities([]models.City{
+		{Code: "vladivostok", Name: "Владивосток", Timezone: "Asia/Vladivostok", BuildingsTotal: 100},
+	}, "vladivostok"))
+
+	rows, err := db.Query(`SELECT code, buildings_total, is_default FROM cities ORDER BY id`)
+	check(t, err)
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var code string
+		var total, isDefault int
+		check(t, rows.Scan(&code, &total, &isDefault))
+		got = append(got, code+" "+string(rune('0'+isDefault)))
+		if code == "vladivostok" && total != 100 {
+			t.Errorf("got %d buildings in vladivostok, want 100", total)
+		}
+	}
+	equal(t, got, []string{"vladivostok 1", "khabarovsk 0"})
+}
+
+func TestCityFilter(t *testing.T) {
+	s, db := open(t, seed...)
+
+	t.Run("buildings count", func(t *testing.T) {
+		count, err := s.GetBuildingsCount(vladivostok)
+		check(t, err)
+		equal(t, count, int64(3))
+
+		count, err = s.GetBuildingsCount(khabarovsk)
+		check(t, err)
+		equal(t, count, int64(1))
+	})
+
+	t.Run("configured buildings total", func(t *testing.T) {
+		exec(t, db, `UPDATE cities SET buildings_total = 250 WHERE code = 'khabarovsk'`)
+		defer exec(t, db, `UPDATE cities SET buildings_total = 0`)
+
+		count, err := s.GetBuildingsCount(khabarovsk)
+		check(t, err)
+		equal(t, count, int64(250))
+	})
+
+	t.Run("streets", func(t *testing.T) {
+		streets, err := s.FindStreets("ул.", khabarovsk)
+		check(t, err)
+		equal(t, streets, []string{"Ленина ул."})
+
+		streets, err = s.FindStreets("Ленина", vladivostok)
+		check(t, err)
+		equal(t, len(streets), 0)
+	})
+
+	t.Run("blackout of another city", func(t *testing.T) {
+		_, err := s.GetBlackout("b1", vladivostok)
+		check(t, err)
+
+		_, err = s.GetBlackout("b1", khabarovsk)
+		is(t, err, storage.ErrBlackoutNotFound)
+	})
+}
+
+func TestImportToCity(t *testing.T) {
+	s, _ := open(t, seed...)
+
+	location := []models.BuildingLocation{{Street: "Ленина ул.", Number: "5", Latitude: 48.48, Longitude: 135.07}}
+
+	// the street is looked up in the city of the import only
+	updated, _, err := s.ImportBuildingLocations("vladivostok", location)
+	check(t, err)
+	equal(t, updated, int64(0))
+
+	updated, _, err = s.ImportBuildingLocations("khabarovsk", location)
+	check(t, err)
+	equal(t, updated, int64(1))
+
+	located, err := s.GetBuildingLocations(khabarovsk)
+	check(t, err)
+	equal(t, len(located), 1)
+
+	located, err = s.GetBuildingLocations(vladivostok)
+	check(t, err)
+	equal(t, len(located), 0)
+
+	_, _, err = s.ImportBuildingLocations("moscow", location)
+	is(t, err, storage.ErrCityNotFound)
+
+	// districts of the same name in two cities are two districts
+	for _, city := range []string{"vladivostok", "khabarovsk"} {
+		_, _, err := s.ImportDistricts(city, []models.DistrictMapping{{District: "Центральный", Street: "Ленина ул."}})
+		check(t, err)
+	}
+
+	districts, err := s.GetDistricts(khabarovsk)
+	check(t, err)
+	equal(t, len(districts), 1)
+
+	count, err := s.GetBuildingsCount(models.Filter{City: "khabarovsk", District: "Центральный"})
+	check(t, err)
+	equal(t, count, int64(1))
+
+	count, err = s.GetBuildingsCount(models.Filter{City: "vladivostok", District: "Центральный"})
+	check(t, err)
+	equal(t, count, int64(0))
+
+	_, _, err = s.ImportDistricts("moscow", nil)
+	is(t, err, storage.ErrCityNotFound)
+}
+
+func TestTelegramChatCity(t *testing.T) {
+	s, _ := open(t, seed...)
+
+	city, err := s.GetTelegramChatCity(42)
+	check(t, err)
+	equal(t, city, "")
+
+	check(t, s.SetTelegramChatCity(42, "khabarovsk"))
+	check(t, s.SetTelegramChatCity(42, "vladivostok"))
+
+	city, err = s.GetTelegramChatCity(42)
+	check(t, err)
+	equal(t, city, "vladivostok")
+
+	is(t, s.SetTelegramChatCity(42, "moscow"), storage.ErrCityNotFound)
+}
+
+func TestSyncCitiesEdgeCases(t *testing.T) {
+	cities := []models.City{
+		{Code: "vladivostok", Name: "Владивосток", Timezone: "Asia/Vladivostok"},
+		{Code: "khabarovsk", Name: "Хабаровск", Timezone: "Asia/Vladivostok"},
+	}
+
+	tests := []struct {
+		name        string
+		cities      []models.City
+		defaultCode string
+		filter      models.Filter
+		want        []string
+	}{
+		{"default keeps the rows without a city", cities, "vladivostok", khabarovsk, []string{"Ленина ул."}},
+		{"new default takes them over", cities, "khabarovsk", khabarovsk, []string{"Карбышева ул.", "Светланская ул.", "Ленина ул."}},
+		{"old default keeps nothing", cities, "khabarovsk", vladivostok, nil},
+		{"missing cities keep their data", cities[1:], "khabarovsk", vladivostok, nil},
+		{"added city", append(cities, models.City{Code: "nakhodka", Name: "Находка"}), "nakhodka", models.Filter{City: "nakhodka"}, []string{"Карбышева ул.", "Светланская ул."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+			check(t, s.SyncCities(tt.cities, tt.defaultCode))
+
+			got, err := s.FindStreets("ул", tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+// districtBuildings selects the buildings of the district named by its only parameter.
+// A building mapped to a district directly takes precedence over its street.
+const districtBuildings = `
+            SELECT bd.id FROM buildings bd
+            JOIN streets sd ON bd.street_id = sd.id
+            JOIN districts d ON d.id = COALESCE(bd.district_id, sd.district_id)
+            WHERE d.name = ?`
+
+// buildingsFilter returns a condition limiting a building id column to the filter
+func buildingsFilter(column string, filter models.Filter) (string, []any) {
+	var cond string
+	var args []any
+
+	if filter.City != "" {
+		cond += `
+        AND ` + column + ` IN (` + cityBuildings + `)`
+		args = append(args, filter.City)
+	}
+
+	if filter.District != "" {
+		cond += `
+        AND ` + column + ` IN (` + districtBuildings + `)`
+		args = append(args, filter.District)
+	}
+
+	return cond, args
+}
+
+// blackoutsFilter returns a condition limiting a blackout id column to the
+// blackouts of the city touching at least one building of the district
+func blackoutsFilter(column string, filter models.Filter) (string, []any) {
+	var cond string
+	var args []any
+
+	if filter.City != "" {
+		cond += `
+        AND ` + column + ` IN (` + cityBlackouts + `)`
+		args = append(args, filter.City)
+	}
+
+	if filter.District != "" {
+		cond += `
+        AND ` + column + ` IN (
+            SELECT bb.blackout_id FROM blackouts_buildings bb
+            WHERE bb.building_id IN (` + districtBuildings + `))`
+		args = append(args, filter.District)
+	}
+
+	return cond, args
+}
+
+// GetDistricts returns the districts of the filter city sorted by name
+func (s *Storage) GetDistricts(filter models.Filter) ([]models.District, error) {
+	const op = "storage.sqlite.GetDistricts"
+
+	cityCond, args := cityFilter("city_id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT id, name FROM districts
+        WHERE TRUE`+cityCond+`
+        ORDER BY name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var districts []models.District
+	for rows.Next() {
+		var district models.District
+
+		if err := rows.Scan(&district.ID, &district.Name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		districts = append(districts, district)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return districts, nil
+}
+
+// ImportDistricts creates the missing districts of the city coded by city and maps
+// its streets and buildings to them. It returns the number of updated rows and the
+// mappings that matched nothing.
+func (s *Storage) ImportDistricts(city string, mappings []models.DistrictMapping) (int64, []models.DistrictMapping, error) {
+	const op = "storage.sqlite.ImportDistricts"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var cityID int64
+	err = tx.QueryRow(`SELECT id FROM cities WHERE code = ?`, city).Scan(&cityID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, storage.ErrCityNotFound
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	insertDistrict, err := tx.Prepare(`
+        INSERT INTO districts (city_id, name) VALUES (?, ?)
+        ON CONFLICT (city_id, name) DO NOTHING`)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer insertDistrict.Close()
+
+	updateStreet, err := tx.Prepare(`
+        UPDATE streets SET district_id = (SELECT id FROM districts WHERE city_id = ?1 AND name = ?2)
+        WHERE name = ?3 AND COALESCE(city_id, ` + defaultCity + `) = ?1`)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer updateStreet.Close()
+
+	updateBuilding, err := tx.Prepare(`
+        UPDATE buildings SET district_id = (SELECT id FROM districts WHERE city_id = ?1 AND name = ?2)
+        WHERE number = ?3
+        AND street_id IN (
+            SELECT id FROM streets
+            WHERE name = ?4 AND COALESCE(city_id, ` + defaultCity + `) = ?1)`)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer updateBuilding.Close()
+
+	var updated int64
+	var missing []models.DistrictMapping
+
+	for _, mapping := range mappings {
+		district := strings.TrimSpace(mapping.District)
+		street := strings.TrimSpace(mapping.Street)
+		number := strings.TrimSpace(mapping.Number)
+
+		if _, err := insertDistrict.Exec(cityID, district); err != nil {
+			return 0, nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		var affected int64
+		if number == "" {
+			res, err := updateStreet.Exec(cityID, district, street)
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s: %w", op, err)
+			}
+			affected, err = res.RowsAffected()
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s: %w", op, err)
+			}
+		} else {
+			res, err := updateBuilding.Exec(cityID, district, number, street)
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s: %w", op, err)
+			}
+			affected, err = res.RowsAffected()
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if affected == 0 {
+			missing = append(missing, mapping)
+		}
+		updated += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, missing, nil
+}
+
+// buildingDistricts pairs every real building with its effective district
+const buildingDistricts = `
+            SELECT bd.id, COALESCE(bd.district_id, sd.district_id) AS district_id
+            FROM buildings bd
+            JOIN streets sd ON bd.street_id = sd.id
+            WHERE bd.is_fake = 0`
+
+// GetDistrictStats returns building counts of the districts of the filter city at
+// currentTime, the affected ones counted over the blackouts of the filter kind.
+// A date without time covers the whole day, like in GetBlackouts.
+func (s *Storage) GetDistrictStats(currentTime string, filter models.Filter) ([]models.DistrictStats, error) {
+	const op = "storage.sqlite.GetDistrictStats"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	cityCond, cityArgs := buildingsFilter("bd.id", models.Filter{City: filter.City})
+	districtCond, districtArgs := cityFilter("d.city_id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT d.id, d.name, COUNT(bd.id)
+        FROM districts d
+        LEFT JOIN (`+buildingDistricts+cityCond+`) bd ON bd.district_id = d.id
+        WHERE TRUE`+districtCond+`
+        GROUP BY d.id, d.name
+        ORDER BY d.name`, append(cityArgs, districtArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var stats []models.DistrictStats
+	index := make(map[int64]int)
+	for rows.Next() {
+		district := models.DistrictStats{AffectedByType: map[string]int64{}}
+
+		if err := rows.Scan(&district.ID, &district.Name, &district.TotalBuildings); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		index[district.ID] = len(stats)
+		stats = append(stats, district)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	kindCond, kindArgs := kindFilter("bl.kind", filter)
+
+	// rows with an empty type count buildings affected by any type
+	rows, err = s.db.Query(`
+        WITH affected AS (
+            SELECT DISTINCT bd.district_id, bd.id, bl.type
+            FROM blackouts bl
+            JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+            JOIN (`+buildingDistricts+cityCond+`) bd ON bb.building_id = bd.id
+            WHERE bd.district_id IS NOT NULL
+            AND bl.start_date <= ?
+            AND (bl.end_date >= ? OR bl.end_date IS NULL)`+kindCond+`
+        )
+        SELECT district_id, type, COUNT(*) FROM affected GROUP BY district_id, type
+        UNION ALL
+        SELECT district_id, '', COUNT(DISTINCT id) FROM affected GROUP BY district_id`,
+		append(append(append([]any{}, cityArgs...), queryTime, currentTime), kindArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var districtID, count int64
+		var blackoutType string
+
+		if err := rows.Scan(&districtID, &blackoutType, &count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		i, ok := index[districtID]
+		if !ok {
+			continue
+		}
+
+		if blackoutType == "" {
+			stats[i].AffectedBuildings = count
+		} else {
+			stats[i].AffectedByType[blackoutType] = count
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
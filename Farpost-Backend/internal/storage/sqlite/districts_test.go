@@ -0,0 +1,314 @@
+package sqlite_test
+
+import (
+	"errors"
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+var (
+	leninsky   = models.Filter{District: "Ленинский"}
+	frunzensky = models.Filter{District: "Фрунзенский"}
+)
+
+// withDistricts maps Карбышева ул. to Ленинский and Светланская ул. to Фрунзенский,
+// building 11 is moved to Фрунзенский on its own and Ленина ул. stays unmapped
+func withDistricts(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s, _ := open(t, seed...)
+	_, _, err := s.ImportDistricts("vladivostok", []models.DistrictMapping{
+		{District: "Ленинский", Street: "Карбышева ул."},
+		{District: "Фрунзенский", Street: "Светланская ул."},
+		{District: "Фрунзенский", Street: "Карбышева ул.", Number: "56"},
+	})
+	check(t, err)
+
+	return s
+}
+
+func TestImportDistricts(t *testing.T) {
+	s, _ := open(t, seed...)
+
+	updated, missing, err := s.ImportDistricts("vladivostok", []models.DistrictMapping{
+		{District: " Ленинский ", Street: "Карбышева ул."},
+		{District: "Ленинский", Street: "Карбышева ул.", Number: "54"},
+		{District: "Первореченский", Street: "Нет такой"},
+		{District: "Первореченский", Street: "Карбышева ул.", Number: "1"},
+	})
+	check(t, err)
+	equal(t, updated, int64(2))
+	equal(t, missing, []models.DistrictMapping{
+		{District: "Первореченский", Street: "Нет такой"},
+		{District: "Первореченский", Street: "Карбышева ул.", Number: "1"},
+	})
+
+	// a district is created even when its mappings match nothing
+	districts, err := s.GetDistricts(models.Filter{})
+	check(t, err)
+	equal(t, districts, []models.District{{ID: 1, Name: "Ленинский"}, {ID: 3, Name: "Первореченский"}})
+}
+
+func TestGetDistrictStats(t *testing.T) {
+	s := withDistricts(t)
+
+	got, err := s.GetDistrictStats(now, models.Filter{})
+	check(t, err)
+	equal(t, got, []models.DistrictStats{
+		{District: models.District{ID: 1, Name: "Ленинский"}, TotalBuildings: 1, AffectedBuildings: 1,
+			AffectedByType: map[string]int64{"hot_water": 1}},
+		{District: models.District{ID: 2, Name: "Фрунзенский"}, TotalBuildings: 2, AffectedBuildings: 2,
+			AffectedByType: map[string]int64{"hot_water": 1, "electricity": 1}},
+	})
+
+	t.Run("a date covers the whole day", func(t *testing.T) {
+		got, err := s.GetDistrictStats("2024-03-12", models.Filter{})
+		check(t, err)
+		equal(t, got[1].AffectedByType, map[string]int64{"heat": 1})
+	})
+}
+
+func TestDistrictFilter(t *testing.T) {
+	s := withDistricts(t)
+
+	t.Run("buildings count", func(t *testing.T) {
+		for filter, want := range map[models.Filter]int64{{}: 4, leninsky: 1, frunzensky: 2} {
+			got, err := s.GetBuildingsCount(filter)
+			check(t, err)
+			equal(t, got, want)
+		}
+	})
+
+	t.Run("buildings count by type", func(t *testing.T) {
+		got, err := s.GetBuildingsCountByBlackoutType("hot_water", now, frunzensky)
+		check(t, err)
+		equal(t, got, int64(1))
+	})
+
+	t.Run("blackouts", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			at     string
+			filter models.Filter
+			want   []string
+		}{
+			{"no filter", now, models.Filter{}, []string{"b2", "b1"}},
+			{"district", now, leninsky, []string{"b1"}},
+			{"building moved out of its street district", now, frunzensky, []string{"b2", "b1"}},
+			{"a date covers the whole day", "2024-03-12", frunzensky, []string{"b5"}},
+			{"unknown district", now, models.Filter{District: "Нет такого"}, nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := s.GetFilteredBlackouts(tt.at, tt.filter)
+				check(t, err)
+
+				var ids []string
+				for _, blackout := range got {
+					ids = append(ids, blackout.ID)
+				}
+				equal(t, ids, tt.want)
+			})
+		}
+	})
+}
+
+func TestGetLastBlackoutTimeByType(t *testing.T) {
+	s := withDistricts(t)
+
+	tests := []struct {
+		name         string
+		blackoutType string
+		filter       models.Filter
+		want         string
+	}{
+		{"latest started", "hot_water", models.Filter{}, "2024-03-10 09:00:00"},
+		{"district", "cold_water", leninsky, "2024-02-01 09:00:00"},
+		{"none in the district", "cold_water", frunzensky, ""},
+		{"none started yet", "heat", models.Filter{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetLastBlackoutTimeByType(tt.blackoutType, now, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+// withFixtureDistricts maps Карбышева ул. to Ленинский and Светланская ул. 1 to Фрунзенский
+
+func withFixtureDistricts(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := sqlitetest.Fixture(t)
+	_, _, err := s.ImportDistricts("vladivostok", []models.DistrictMapping{
+		{District: "Ленинский", Street: "Карбышева ул."},
+		{District: "Фрунзенский", Street: "Светланская ул.", Number: "1"},
+	})
+	check(t, err)
+
+	return s
+}
+
+func TestImportDistrictsEdgeCases(t *testing.T) {
+	tests := []struct {
+		name        string
+		mappings    []models.DistrictMapping
+		wantUpdated int64
+		wantMissing []models.DistrictMapping
+		wantNames   []string
+	}{
+		{"street maps every building", []models.DistrictMapping{
+			{District: "Ленинский", Street: "Карбышева ул."},
+		}, 1, nil, []string{"Ленинский"}},
+		{"building", []models.DistrictMapping{
+			{District: " Фрунзенский ", Street: " Светланская ул. ", Number: " 1 "},
+		}, 1, nil, []string{"Фрунзенский"}},
+		{"repeated district is created once", []models.DistrictMapping{
+			{District: "Ленинский", Street: "Карбышева ул."},
+			{District: "Ленинский", Street: "Светланская ул."},
+		}, 2, nil, []string{"Ленинский"}},
+		{"unmatched mapping still creates its district", []models.DistrictMapping{
+			{District: "Первомайский", Street: "Нет такой"},
+		}, 0, []models.DistrictMapping{{District: "Первомайский", Street: "Нет такой"}}, []string{"Первомайский"}},
+		{"street of another city", []models.DistrictMapping{
+			{District: "Центральный", Street: "Ленина ул."},
+		}, 0, []models.DistrictMapping{{District: "Центральный", Street: "Ленина ул."}}, []string{"Центральный"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+
+			updated, missing, err := s.ImportDistricts("vladivostok", tt.mappings)
+			check(t, err)
+			equal(t, updated, tt.wantUpdated)
+			equal(t, missing, tt.wantMissing)
+
+			districts, err := s.GetDistricts(vladivostok)
+			check(t, err)
+
+			var names []string
+			for _, d := range districts {
+				names = append(names, d.Name)
+			}
+			equal(t, names, tt.wantNames)
+		})
+	}
+}
+
+func TestImportDistrictsOfTwoCities(t *testing.T) {
+	s := withFixtureDistricts(t)
+
+	updated, missing, err := s.ImportDistricts("khabarovsk", []models.DistrictMapping{
+		{District: "Ленинский", Street: "Ленина ул."},
+		{District: "Ленинский", Street: "Карбышева ул."},
+	})
+	check(t, err)
+	equal(t, updated, int64(1))
+	equal(t, missing, []models.DistrictMapping{{District: "Ленинский", Street: "Карбышева ул."}})
+
+	got, err := s.GetDistricts(khabarovsk)
+	check(t, err)
+	equal(t, got, []models.District{{ID: 3, Name: "Ленинский"}})
+
+	blackouts, err := s.GetFilteredBlackouts(now, models.Filter{City: "khabarovsk", District: "Ленинский"})
+	check(t, err)
+	equal(t, len(blackouts), 1)
+	equal(t, blackouts[0].ID, "b3")
+
+	_, _, err = s.ImportDistricts("moscow", []models.DistrictMapping{{District: "Ленинский", Street: "Ленина ул."}})
+	if !errors.Is(err, storage.ErrCityNotFound) {
+		t.Fatalf("err = %v, want %v", err, storage.ErrCityNotFound)
+	}
+}
+
+func TestGetDistricts(t *testing.T) {
+	t.Run("none imported", func(t *testing.T) {
+		got, err := sqlitetest.Fixture(t).GetDistricts(vladivostok)
+		check(t, err)
+		equal(t, got, nil)
+	})
+
+	t.Run("sorted by name", func(t *testing.T) {
+		got, err := withFixtureDistricts(t).GetDistricts(vladivostok)
+		check(t, err)
+		equal(t, got, []models.District{{ID: 1, Name: "Ленинский"}, {ID: 2, Name: "Фрунзенский"}})
+	})
+
+	t.Run("other city", func(t *testing.T) {
+		got, err := withFixtureDistricts(t).GetDistricts(khabarovsk)
+		check(t, err)
+		equal(t, got, nil)
+	})
+}
+
+func TestGetDistrictStatsEdgeCases(t *testing.T) {
+	s := withFixtureDistricts(t)
+
+	tests := []struct {
+		name string
+		at   string
+		want []models.DistrictStats
+	}{
+		{"active now", now, []models.DistrictStats{
+			{District: models.District{ID: 1, Name: "Ленинский"}, TotalBuildings: 2, AffectedBuildings: 2,
+				AffectedByType: map[string]int64{"hot_water": 2, "cold_water": 1}},
+			{District: models.District{ID: 2, Name: "Фрунзенский"}, TotalBuildings: 1, AffectedBuildings: 1,
+				AffectedByType: map[string]int64{"electricity": 1, "cold_water": 1}},
+		}},
+		{"open ended only", "2025-01-01 00:00:00", []models.DistrictStats{
+			{District: models.District{ID: 1, Name: "Ленинский"}, TotalBuildings: 2, AffectedBuildings: 1,
+				AffectedByType: map[string]int64{"cold_water": 1}},
+			{District: models.District{ID: 2, Name: "Фрунзенский"}, TotalBuildings: 1, AffectedBuildings: 1,
+				AffectedByType: map[string]int64{"cold_water": 1}},
+		}},
+		{"date covers the whole day", "2024-02-01", []models.DistrictStats{
+			{District: models.District{ID: 1, Name: "Ленинский"}, TotalBuildings: 2, AffectedBuildings: 1,
+				AffectedByType: map[string]int64{"cold_water": 1}},
+			{District: models.District{ID: 2, Name: "Фрунзенский"}, TotalBuildings: 1,
+				AffectedByType: map[string]int64{}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetDistrictStats(tt.at, models.Filter{})
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+
+	t.Run("none imported", func(t *testing.T) {
+		got, err := sqlitetest.Fixture(t).GetDistrictStats(now, models.Filter{})
+		check(t, err)
+		equal(t, got, nil)
+	})
+}
+
+func TestGetFilteredBlackoutsByDistrict(t *testing.T) {
+	s := withFixtureDistricts(t)
+
+	tests := []struct {
+		name     string
+		district string
+		want     []string
+	}{
+		{"street district", "Ленинский", []string{"b1", "b6"}},
+		{"building district", "Фрунзенский", []string{"b2", "b6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetFilteredBlackouts(now, models.Filter{District: tt.district})
+			check(t, err)
+			equal(t, ids(got), tt.want)
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package sqlite_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+// withLocations places buildings 10, 11, 12 and the fake 13, building 20 stays unlocated
+func withLocations(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s, _ := open(t, seed...)
+	_, _, err := s.ImportBuildingLocations("vladivostok", []models.BuildingLocation{
+		{Street: "Карбышева ул.", Number: "54", Latitude: 43.10, Longitude: 131.90},
+		{Street: "Карбышева ул.", Number: "56", Latitude: 43.11, Longitude: 131.91},
+		{Street: "Светланская ул.", Number: "1", Latitude: 43.12, Longitude: 131.95},
+		{Street: "Светланская ул.", Number: "", Latitude: 43.13, Longitude: 131.96},
+	})
+	check(t, err)
+
+	return s
+}
+
+func TestImportBuildingLocations(t *testing.T) {
+	tests := []struct {
+		name        string
+		locations   []models.BuildingLocation
+		wantUpdated int64
+		wantMissing []models.BuildingLocation
+	}{
+		{"matched", []models.BuildingLocation{
+			{Street: "Карбышева ул.", Number: "54", Latitude: 43.10, Longitude: 131.90},
+		}, 1, nil},
+		{"spaces are trimmed", []models.BuildingLocation{
+			{Street: " Карбышева ул. ", Number: " 56 ", Latitude: 43.11, Longitude: 131.91},
+		}, 1, nil},
+		{"unmatched", []models.BuildingLocation{
+			{Street: "Нет такой", Number: "1"},
+			{Street: "Карбышева ул.", Number: "1"},
+		}, 0, []models.BuildingLocation{{Street: "Нет такой", Number: "1"}, {Street: "Карбышева ул.", Number: "1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := open(t, seed...)
+
+			updated, missing, err := s.ImportBuildingLocations("vladivostok", tt.locations)
+			check(t, err)
+			equal(t, updated, tt.wantUpdated)
+			equal(t, missing, tt.wantMissing)
+		})
+	}
+}
+
+func TestGetMapBlackouts(t *testing.T) {
+	s := withLocations(t)
+
+	tests := []struct {
+		name string
+		at   string
+		bbox *models.BBox
+		want []string
+	}{
+		{"by building, then start", now, nil, []string{"10 b1", "11 b1", "12 b2"}},
+		{"bounding box", now, &models.BBox{MinLon: 131.89, MinLat: 43.09, MaxLon: 131.92, MaxLat: 43.115}, []string{"10 b1", "11 b1"}},
+		{"empty bounding box", now, &models.BBox{MinLon: 135, MinLat: 48, MaxLon: 136, MaxLat: 49}, nil},
+		{"a date covers the whole day", "2024-03-12", nil, []string{"11 b5"}},
+		{"nothing active", "2025-01-01 00:00:00", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetMapBlackouts(tt.at, tt.bbox, models.Filter{})
+			check(t, err)
+			equal(t, mapKeys(got), tt.want)
+		})
+	}
+
+	t.Run("unlocated buildings are left out", func(t *testing.T) {
+		s, db := open(t, seed...)
+		exec(t, db, `UPDATE buildings SET latitude = 43.10 WHERE id = 10`)
+
+		got, err := s.GetMapBlackouts(now, nil, models.Filter{})
+		check(t, err)
+		equal(t, mapKeys(got), nil)
+	})
+}
+
+func TestGetBuildingLocations(t *testing.T) {
+	t.Run("none imported", func(t *testing.T) {
+		s, _ := open(t, seed...)
+
+		got, err := s.GetBuildingLocations(models.Filter{})
+		check(t, err)
+		equal(t, got, nil)
+	})
+
+	t.Run("fake and unlocated buildings are left out", func(t *testing.T) {
+		got, err := withLocations(t).GetBuildingLocations(models.Filter{})
+		check(t, err)
+
+		slices.SortFunc(got, func(a, b models.LocatedBuilding) int { return int(a.BuildingID - b.BuildingID) })
+		equal(t, got, []models.LocatedBuilding{
+			{Address: models.Address{BuildingID: 10, Street: "Карбышева ул.", Number: "54"}, Latitude: 43.10, Longitude: 131.90},
+			{Address: models.Address{BuildingID: 11, Street: "Карбышева ул.", Number: "56"}, Latitude: 43.11, Longitude: 131.91},
+			{Address: models.Address{BuildingID: 12, Street: "Светланская ул.", Number: "1"}, Latitude: 43.12, Longitude: 131.95},
+		})
+	})
+}
+
+func TestGetBuildingsBlackouts(t *testing.T) {
+	s := withLocations(t)
+
+	many := make([]int64, 0, 1000)
+	for id := int64(1000); id > 0; id-- {
+		many = append(many, id)
+	}
+
+	tests := []struct {
+		name      string
+		buildings []int64
+		at        string
+		want      []string
+	}{
+		{"fake buildings are kept", []int64{12, 13}, now, []string{"12 b2", "13 b2"}},
+		{"only the given buildings", []int64{11}, now, []string{"11 b1"}},
+		{"more buildings than a chunk", many, now, []string{"10 b1", "11 b1", "12 b2", "13 b2"}},
+		{"no buildings", nil, now, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBuildingsBlackouts(tt.buildings, tt.at)
+			check(t, err)
+			equal(t, mapKeys(got), tt.want)
+		})
+	}
+}
+
+func mapKeys(blackouts []models.MapBlackout) []string {
+	var keys []string
+	for _, b := range blackouts {
+		keys = append(keys, fmt.Sprintf("%d %s", b.BuildingID, b.BlackoutID))
+	}
+	return keys
+}
+
+// withFixtureLocations places buildings 10, 11, 12 and the fake 13, building 20 stays unlocated
+
+func withFixtureLocations(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := sqlitetest.Fixture(t)
+	_, _, err := s.ImportBuildingLocations("vladivostok", []models.BuildingLocation{
+		{Street: "Карбышева ул.", Number: "54", Latitude: 43.10, Longitude: 131.90},
+		{Street: "Карбышева ул.", Number: "56", Latitude: 43.11, Longitude: 131.91},
+		{Street: "Светланская ул.", Number: "1", Latitude: 43.12, Longitude: 131.95},
+		{Street: "Светланская ул.", Number: "", Latitude: 43.13, Longitude: 131.96},
+	})
+	check(t, err)
+
+	return s
+}
+
+func TestImportBuildingLocationsEdgeCases(t *testing.T) {
+	tests := []struct {
+		name        string
+		city        string
+		locations   []models.BuildingLocation
+		wantUpdated int64
+		wantMissing []models.BuildingLocation
+	}{
+		{"matched", "vladivostok", []models.BuildingLocation{
+			{Street: "Карбышева ул.", Number: "54", Latitude: 43.10, Longitude: 131.90},
+		}, 1, nil},
+		{"spaces are trimmed", "vladivostok", []models.BuildingLocation{
+			{Street: " Карбышева ул. ", Number: " 56 ", Latitude: 43.11, Longitude: 131.91},
+		}, 1, nil},
+		{"unmatched", "vladivostok", []models.BuildingLocation{
+			{Street: "Нет такой", Number: "1"},
+			{Street: "Карбышева ул.", Number: "1"},
+		}, 0, []models.BuildingLocation{{Street: "Нет такой", Number: "1"}, {Street: "Карбышева ул.", Number: "1"}}},
+		{"street of another city", "vladivostok", []models.BuildingLocation{
+			{Street: "Ленина ул.", Number: "5"},
+		}, 0, []models.BuildingLocation{{Street: "Ленина ул.", Number: "5"}}},
+		{"matched in another city", "khabarovsk", []models.BuildingLocation{
+			{Street: "Ленина ул.", Number: "5", Latitude: 48.48, Longitude: 135.07},
+			{Street: "Карбышева ул.", Number: "54"},
+		}, 1, []models.BuildingLocation{{Street: "Карбышева ул.", Number: "54"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, missing, err := sqlitetest.Fixture(t).ImportBuildingLocations(tt.city, tt.locations)
+			check(t, err)
+			equal(t, updated, tt.wantUpdated)
+			equal(t, missing, tt.wantMissing)
+		})
+	}
+
+	t.Run("unknown city", func(t *testing.T) {
+		_, _, err := sqlitetest.Fixture(t).ImportBuildingLocations("moscow", []models.BuildingLocation{
+			{Street: "Карбышева ул.", Number: "54"},
+		})
+		if !errors.Is(err, storage.ErrCityNotFound) {
+			t.Fatalf("err = %v, want %v", err, storage.ErrCityNotFound)
+		}
+	})
+}
+
+func TestGetBuildingLocationsEdgeCases(t *testing.T) {
+	t.Run("none imported", func(t *testing.T) {
+		got, err := sqlitetest.Fixture(t).GetBuildingLocations(models.Filter{})
+		check(t, err)
+		equal(t, got, nil)
+	})
+
+	t.Run("fake and unlocated buildings are left out", func(t *testing.T) {
+		got, err := withFixtureLocations(t).GetBuildingLocations(vladivostok)
+		check(t, err)
+
+		slices.SortFunc(got, func(a, b models.LocatedBuilding) int { return int(a.BuildingID - b.BuildingID) })
+		equal(t, got, []models.LocatedBuilding{
+			{Address: models.Address{BuildingID: 10, Street: "Карбышева ул.", Number: "54"}, Latitude: 43.10, Longitude: 131.90},
+			{Address: models.Address{BuildingID: 11, Street: "Карбышева ул.", Number: "56"}, Latitude: 43.11, Longitude: 131.91},
+			{Address: models.Address{BuildingID: 12, Street: "Светланская ул.", Number: "1"}, Latitude: 43.12, Longitude: 131.95},
+		})
+	})
+
+	t.Run("other city", func(t *testing.T) {
+		got, err := withFixtureLocations(t).GetBuildingLocations(khabarovsk)
+		check(t, err)
+		equal(t, got, nil)
+	})
+}
+
+func TestGetMapBlackoutsEdgeCases(t *testing.T) {
+	s := withFixtureLocations(t)
+
+	tests := []struct {
+		name   string
+		at     string
+		bbox   *models.BBox
+		filter models.Filter
+		want   []string
+	}{
+		{"by building, then start", now, nil, models.Filter{}, []string{"10 b1", "11 b6", "11 b1", "12 b6", "12 b2"}},
+		{"bounding box", now, &models.BBox{MinLon: 131.89, MinLat: 43.09, MaxLon: 131.92, MaxLat: 43.115}, models.Filter{}, []string{"10 b1", "11 b6", "11 b1"}},
+		{"unlocated buildings are left out", now, nil, khabarovsk, nil},
+		{"open ended", "2025-01-01 00:00:00", nil, models.Filter{}, []string{"11 b6", "12 b6"}},
+		{"a date covers the whole day", "2024-03-12", nil, models.Filter{}, []string{"11 b6", "11 b5", "12 b6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetMapBlackouts(tt.at, tt.bbox, tt.filter)
+			check(t, err)
+			equal(t, mapKeys(got), tt.want)
+		})
+	}
+}
+
+func TestGetBuildingsBlackoutsEdgeCases(t *testing.T) {
+	s := withFixtureLocations(t)
+
+	many := make([]int64, 0, 1000)
+	for id := int64(1000); id > 0; id-- {
+		many = append(many, id)
+	}
+
+	tests := []struct {
+		name      string
+		buildings []int64
+		at        string
+		want      []string
+	}{
+		{"fake buildings are kept", []int64{12, 13}, now, []string{"12 b6", "12 b2", "13 b7", "13 b2"}},
+		{"open ended", []int64{11}, "2025-01-01 00:00:00", []string{"11 b6"}},
+		{"a date covers the whole day", []int64{10, 11}, "2024-03-12", []string{"11 b6", "11 b5"}},
+		{"more buildings than a chunk", many, "2025-01-01 00:00:00", []string{"11 b6", "12 b6"}},
+		{"no buildings", nil, now, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBuildingsBlackouts(tt.buildings, tt.at)
+			check(t, err)
+			equal(t, mapKeys(got), tt.want)
+		})
+	}
+}
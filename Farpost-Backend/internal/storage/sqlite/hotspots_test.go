@@ -0,0 +1,173 @@
+package sqlite_test
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+func TestGetOutageHistory(t *testing.T) {
+	s, _ := open(t, seed...)
+
+	got, err := s.GetOutageHistory()
+	check(t, err)
+
+	// oldest first, the fake building 13 is left out
+	var keys []string
+	for _, record := range got {
+		keys = append(keys, record.BlackoutID+" "+record.Number)
+	}
+	equal(t, keys, []string{"b4 54", "b1 54", "b1 56", "b2 1", "b5 56"})
+}
+
+func TestHotspots(t *testing.T) {
+	s, _ := open(t)
+
+	building := models.Hotspot{
+		Scope: models.HotspotBuilding, BuildingID: 10, Street: "Карбышева ул.", Number: "54", Type: "hot_water",
+		Outages: 4, WindowOutages: 4, FirstAt: "2024-03-01 09:00:00", LastAt: "2024-03-20 09:00:00",
+		Timeline: []models.HotspotOutage{{ID: "a0", StartOff: "2024-03-01 09:00:00"}},
+	}
+	street := models.Hotspot{
+		Scope: models.HotspotStreet, Street: "Карбышева ул.", Type: "cold_water",
+		Outages: 3, WindowOutages: 1, Periodic: true, PeriodDays: 35, FirstAt: "2024-01-01 09:00:00", LastAt: "2024-03-11 09:00:00",
+	}
+
+	check(t, s.ReplaceHotspots([]models.Hotspot{{Scope: models.HotspotStreet, Street: "Старая ул.", Type: "heat"}}, "2024-03-20 12:00:00"))
+	check(t, s.ReplaceHotspots([]models.Hotspot{street, building}, "2024-03-21 12:00:00"))
+
+	tests := []struct {
+		name         string
+		scope        string
+		blackoutType string
+		limit        int
+		want         []string
+	}{
+		{"most frequent first, the previous run is gone", "", "", 10, []string{"building hot_water", "street cold_water"}},
+		{"scope", models.HotspotStreet, "", 10, []string{"street cold_water"}},
+		{"type", "", "hot_water", 10, []string{"building hot_water"}},
+		{"limit", "", "", 1, []string{"building hot_water"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetHotspots(tt.scope, tt.blackoutType, tt.limit, models.Filter{})
+			check(t, err)
+
+			var keys []string
+			for _, hotspot := range got {
+				keys = append(keys, hotspot.Scope+" "+hotspot.Type)
+			}
+			equal(t, keys, tt.want)
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := s.GetHotspots(models.HotspotBuilding, "", 10, models.Filter{})
+		check(t, err)
+
+		building.ID, building.DetectedAt = got[0].ID, "2024-03-21 12:00:00"
+		equal(t, got, []models.Hotspot{building})
+
+		got, err = s.GetHotspots(models.HotspotStreet, "", 10, models.Filter{})
+		check(t, err)
+		equal(t, got[0].Timeline, []models.HotspotOutage{})
+	})
+
+	t.Run("kind", func(t *testing.T) {
+		planned := building
+		planned.Kind = models.KindPlanned
+		check(t, s.ReplaceHotspots([]models.Hotspot{building, planned}, "2024-03-21 12:00:00"))
+
+		for _, kind := range []string{"", models.KindPlanned} {
+			got, err := s.GetHotspots("", "", 10, models.Filter{Kind: kind})
+			check(t, err)
+			if len(got) != 1 || got[0].Kind != kind {
+				t.Errorf("got %+v, want the hotspot of kind %q", got, kind)
+			}
+		}
+
+		got, err := s.GetHotspots("", "", 10, models.Filter{Kind: models.KindEmergency})
+		check(t, err)
+		equal(t, got, nil)
+	})
+}
+
+func TestGetOutageHistoryEdgeCases(t *testing.T) {
+	got, err := sqlitetest.Fixture(t).GetOutageHistory()
+	check(t, err)
+
+	// b7 and the row of b2 on the fake building 13 are left out
+	var blackouts []string
+	for _, r := range got {
+		blackouts = append(blackouts, r.BlackoutID)
+	}
+	equal(t, blackouts, []string{"b4", "b6", "b6", "b1", "b1", "b3", "b2", "b5"})
+
+	equal(t, got[1].EndDate, "")
+	equal(t, got[0], models.OutageRecord{
+		Address:    models.Address{BuildingID: 10, Street: "Карбышева ул.", Number: "54"},
+		CityID:     1,
+		BlackoutID: "b4",
+		Type:       "cold_water",
+		Kind:       models.KindUnknown,
+		StartDate:  "2024-02-01 08:00:00",
+		EndDate:    "2024-02-01 10:00:00",
+	})
+}
+
+func TestGetHotspots(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	// stale findings are replaced as a whole
+	check(t, s.ReplaceHotspots([]models.Hotspot{{Scope: "street", Street: "Ленина ул.", Type: "heat", Outages: 9, WindowOutages: 9}}, "2024-03-01 00:00:00"))
+	check(t, s.ReplaceHotspots([]models.Hotspot{
+		{Scope: "building", BuildingID: 10, Street: "Карбышева ул.", Number: "54", Type: "hot_water", Outages: 5, WindowOutages: 3, LastAt: "2024-03-10 09:00:00",
+			Timeline: []models.HotspotOutage{{ID: "b1", StartOff: "2024-03-10 09:00:00", EndOff: "2024-03-10 18:00:00"}}},
+		{Scope: "building", BuildingID: 11, Street: "Карбышева ул.", Number: "56", Type: "cold_water", Outages: 6, WindowOutages: 3, LastAt: "2024-03-09 22:00:00"},
+		{Scope: "street", Street: "Карбышева ул.", Type: "hot_water", Outages: 4, WindowOutages: 4, Periodic: true, PeriodDays: 14.5},
+		{Scope: "building", BuildingID: 11, Street: "Карбышева ул.", Number: "56", Type: "cold_water", Kind: models.KindEmergency, Outages: 6, WindowOutages: 3},
+	}, "2024-03-10 12:00:00"))
+
+	tests := []struct {
+		name         string
+		scope        string
+		blackoutType string
+		limit        int
+		want         []string
+	}{
+		{"by window, then outages", "", "", 10, []string{"street hot_water", "building cold_water", "building hot_water"}},
+		{"scope", "building", "", 10, []string{"building cold_water", "building hot_water"}},
+		{"type", "", "hot_water", 10, []string{"street hot_water", "building hot_water"}},
+		{"limit", "", "", 1, []string{"street hot_water"}},
+		{"stale findings are gone", "", "heat", 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetHotspots(tt.scope, tt.blackoutType, tt.limit, models.Filter{})
+			check(t, err)
+
+			var keys []string
+			for _, h := range got {
+				keys = append(keys, h.Scope+" "+h.Type)
+			}
+			equal(t, keys, tt.want)
+		})
+	}
+
+	t.Run("kind", func(t *testing.T) {
+		got, err := s.GetHotspots("", "", 10, models.Filter{Kind: models.KindEmergency})
+		check(t, err)
+		equal(t, len(got), 1)
+		equal(t, got[0].Kind, models.KindEmergency)
+	})
+
+	t.Run("timeline round trip", func(t *testing.T) {
+		got, err := s.GetHotspots("building", "", 10, models.Filter{})
+		check(t, err)
+		equal(t, got[0].Timeline, []models.HotspotOutage{})
+		equal(t, got[1].Timeline, []models.HotspotOutage{{ID: "b1", StartOff: "2024-03-10 09:00:00", EndOff: "2024-03-10 18:00:00"}})
+		equal(t, got[1].DetectedAt, "2024-03-10 12:00:00")
+	})
+}
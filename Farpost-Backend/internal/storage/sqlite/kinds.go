@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"fmt"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+// kindFilter returns a condition limiting a blackout kind column to the filter.
+// Blackouts not classified yet count as unknown.
+func kindFilter(column string, filter models.Filter) (string, []any) {
+	if filter.Kind == "" {
+		return "", nil
+	}
+
+	return `
+        AND COALESCE(` + column + `, 'unknown') = ?`, []any{filter.Kind}
+}
+
+// GetUnclassifiedBlackouts returns up to limit blackouts without a kind
+func (s *Storage) GetUnclassifiedBlackouts(limit int) ([]models.Blackout, error) {
+	const op = "storage.sqlite.GetUnclassifiedBlackouts"
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, 'unknown'
+        FROM blackouts
+        WHERE kind IS NULL
+        LIMIT ?`,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+// GetBlackout returns a single blackout of the filter city by id
+func (s *Storage) GetBlackout(id string, filter models.Filter) (models.Blackout, error) {
+	const op = "storage.sqlite.GetBlackout"
+
+	cond, args := blackoutsFilter("id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE id = ?`+cond,
+		append([]any{id}, args...)...)
+	if err != nil {
+		return models.Blackout{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return models.Blackout{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(blackouts) == 0 {
+		return models.Blackout{}, storage.ErrBlackoutNotFound
+	}
+
+	return blackouts[0], nil
+}
+
+// SetBlackoutKinds stores kinds found by the rules, keyed by blackout id.
+// Kinds set by hand are left untouched.
+func (s *Storage) SetBlackoutKinds(kinds map[string]string) error {
+	const op = "storage.sqlite.SetBlackoutKinds"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`UPDATE blackouts SET kind = ? WHERE id = ? AND kind_manual = 0`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for id, kind := range kinds {
+		if _, err := stmt.Exec(kind, id); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// OverrideBlackoutKind sets the kind of a blackout by hand, the rules no longer change it
+func (s *Storage) OverrideBlackoutKind(id string, kind string) error {
+	const op = "storage.sqlite.OverrideBlackoutKind"
+
+	res, err := s.db.Exec(`UPDATE blackouts SET kind = ?, kind_manual = 1 WHERE id = ?`, kind, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrBlackoutNotFound
+	}
+
+	return nil
+}
+
+// ResetBlackoutKinds clears the kinds found by the rules so they are classified again.
+// With an id only that blackout is reset, including a kind set by hand.
+func (s *Storage) ResetBlackoutKinds(id string) (int64, error) {
+	const op = "storage.sqlite.ResetBlackoutKinds"
+
+	query, args := `UPDATE blackouts SET kind = NULL WHERE kind_manual = 0`, []any(nil)
+	if id != "" {
+		query, args = `UPDATE blackouts SET kind = NULL, kind_manual = 0 WHERE id = ?`, []any{id}
+	}
+
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if id != "" && affected == 0 {
+		return 0, storage.ErrBlackoutNotFound
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,264 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+func TestGetBlackout(t *testing.T) {
+	s, _ := open(t, seed...)
+
+	got, err := s.GetBlackout("b1", models.Filter{})
+	check(t, err)
+	equal(t, got, models.Blackout{
+		ID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", Description: "Ремонт",
+		Type: "hot_water", InitiatorName: "Водоканал", Source: "vl.ru", Kind: "unknown",
+	})
+
+	_, err = s.GetBlackout("b404", models.Filter{})
+	is(t, err, storage.ErrBlackoutNotFound)
+}
+
+func TestGetUnclassifiedBlackouts(t *testing.T) {
+	tests := []struct {
+		name  string
+		kinds map[string]string
+		limit int
+		want  int
+	}{
+		{"all", nil, 10, 4},
+		{"limit", nil, 2, 2},
+		{"classified are left out", map[string]string{"b1": "planned", "b2": "emergency"}, 10, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := open(t, seed...)
+			check(t, s.SetBlackoutKinds(tt.kinds))
+
+			got, err := s.GetUnclassifiedBlackouts(tt.limit)
+			check(t, err)
+			equal(t, len(got), tt.want)
+		})
+	}
+}
+
+func TestBlackoutKinds(t *testing.T) {
+	set := func(kind string) func(*sqlite.Storage) error {
+		return func(s *sqlite.Storage) error { return s.SetBlackoutKinds(map[string]string{"b1": kind}) }
+	}
+	override := func(id string, kind string) func(*sqlite.Storage) error {
+		return func(s *sqlite.Storage) error { return s.OverrideBlackoutKind(id, kind) }
+	}
+	reset := func(id string) func(*sqlite.Storage) error {
+		return func(s *sqlite.Storage) error { _, err := s.ResetBlackoutKinds(id); return err }
+	}
+
+	tests := []struct {
+		name    string
+		steps   []func(*sqlite.Storage) error
+		want    string
+		wantErr error
+	}{
+		{"set by the rules", []func(*sqlite.Storage) error{set("planned")}, "planned", nil},
+		{"set by hand wins over the rules", []func(*sqlite.Storage) error{override("b1", "emergency"), set("planned")}, "emergency", nil},
+		{"reset of all", []func(*sqlite.Storage) error{set("planned"), reset("")}, "unknown", nil},
+		{"reset of all keeps the ones set by hand", []func(*sqlite.Storage) error{override("b1", "emergency"), reset("")}, "emergency", nil},
+		{"reset of one clears a kind set by hand", []func(*sqlite.Storage) error{override("b1", "emergency"), reset("b1"), set("planned")}, "planned", nil},
+		{"override of an unknown blackout", []func(*sqlite.Storage) error{override("b404", "planned")}, "", storage.ErrBlackoutNotFound},
+		{"reset of an unknown blackout", []func(*sqlite.Storage) error{reset("b404")}, "", storage.ErrBlackoutNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := open(t, seed...)
+
+			var err error
+			for _, step := range tt.steps {
+				if err = step(s); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+
+			got, err := s.GetBlackout("b1", models.Filter{})
+			check(t, err)
+			equal(t, got.Kind, tt.want)
+		})
+	}
+}
+
+func TestKindFilter(t *testing.T) {
+	s, _ := open(t, seed...)
+	check(t, s.SetBlackoutKinds(map[string]string{"b1": models.KindPlanned, "b2": models.KindEmergency}))
+
+	tests := []struct {
+		name string
+		kind string
+		want []string
+	}{
+		{"every kind", "", []string{"b2", "b1"}},
+		{"planned", models.KindPlanned, []string{"b1"}},
+		{"emergency", models.KindEmergency, []string{"b2"}},
+		{"unknown", models.KindUnknown, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetFilteredBlackouts(now, models.Filter{Kind: tt.kind})
+			check(t, err)
+
+			var ids []string
+			for _, blackout := range got {
+				ids = append(ids, blackout.ID)
+			}
+			equal(t, ids, tt.want)
+		})
+	}
+
+	t.Run("not classified yet count as unknown", func(t *testing.T) {
+		got, err := s.GetBuildingsCountByBlackoutType("heat", "2024-03-12", models.Filter{Kind: models.KindUnknown})
+		check(t, err)
+		equal(t, got, int64(1))
+	})
+}
+
+func TestNewRebuildsAnomalies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := sql.Open("sqlite3", path)
+	check(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// the table as created before anomalies had a kind and a city
+	exec(t, db, append(source,
+		`CREATE TABLE anomalies (id INTEGER PRIMARY KEY AUTOINCREMENT, type TEXT NOT NULL, hour TEXT NOT NULL,
+			count INTEGER NOT NULL, baseline REAL NOT NULL, stddev REAL NOT NULL, z_score REAL NOT NULL,
+			detected_at TEXT NOT NULL, UNIQUE (type, hour))`,
+		`INSERT INTO anomalies (type, hour, count, baseline, stddev, z_score, detected_at)
+			VALUES ('electricity', '2024-03-10 11:00:00', 10, 2, 1, 8, '2024-03-10 11:20:00')`,
+	)...)
+
+	s, err := sqlite.New(path)
+	check(t, err)
+
+	var rows int
+	check(t, db.QueryRow(`SELECT COUNT(*) FROM anomalies`).Scan(&rows))
+	equal(t, rows, 0)
+
+	saved, err := s.SaveAnomaly(models.Anomaly{Type: "electricity", Kind: models.KindEmergency, Hour: "2024-03-10 11:00:00", DetectedAt: "2024-03-10 11:20:00"})
+	check(t, err)
+	equal(t, saved, true)
+
+	// the same hour of another kind is another row
+	saved, err = s.SaveAnomaly(models.Anomaly{Type: "electricity", Hour: "2024-03-10 11:00:00", DetectedAt: "2024-03-10 11:20:00"})
+	check(t, err)
+	equal(t, saved, true)
+
+	// a second run keeps the rebuilt table
+	_, err = sqlite.New(path)
+	check(t, err)
+	check(t, db.QueryRow(`SELECT COUNT(*) FROM anomalies`).Scan(&rows))
+	equal(t, rows, 2)
+}
+
+func TestGetBlackoutEdgeCases(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name    string
+		id      string
+		filter  models.Filter
+		want    models.Blackout
+		wantErr error
+	}{
+		{"found", "b1", models.Filter{}, models.Blackout{
+			ID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", Description: "Плановые работы",
+			Type: "hot_water", InitiatorName: "Водоканал", Source: "vl.ru", Kind: "unknown",
+		}, nil},
+		{"NULL end_date and source", "b6", models.Filter{}, models.Blackout{
+			ID: "b6", StartDate: "2024-03-09 22:00:00", Description: "Порыв на сети",
+			Type: "cold_water", InitiatorName: "Водоканал", Kind: "unknown",
+		}, nil},
+		{"found in its city", "b3", khabarovsk, models.Blackout{
+			ID: "b3", StartDate: "2024-03-10 10:00:00", EndDate: "2024-03-10 20:00:00", Description: "Ремонт",
+			Type: "hot_water", InitiatorName: "Водоканал", Source: "vl.ru", Kind: "unknown",
+		}, nil},
+		{"other city", "b1", khabarovsk, models.Blackout{}, storage.ErrBlackoutNotFound},
+		{"unknown", "b404", models.Filter{}, models.Blackout{}, storage.ErrBlackoutNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBlackout(tt.id, tt.filter)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetUnclassifiedBlackoutsEdgeCases(t *testing.T) {
+	tests := []struct {
+		name  string
+		kinds map[string]string
+		limit int
+		want  int
+	}{
+		{"all", nil, 10, 7},
+		{"limit", nil, 2, 2},
+		{"classified are left out", map[string]string{"b1": "planned", "b2": "emergency"}, 10, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+			check(t, s.SetBlackoutKinds(tt.kinds))
+
+			got, err := s.GetUnclassifiedBlackouts(tt.limit)
+			check(t, err)
+			equal(t, len(got), tt.want)
+			for _, b := range got {
+				equal(t, b.Kind, "unknown")
+			}
+		})
+	}
+}
+
+func TestResetBlackoutKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		id       string
+		want     int64
+	}{
+		{"all", "", "", 7},
+		{"all but the ones set by hand", "b1", "", 6},
+		{"one", "", "b6", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+			if tt.override != "" {
+				check(t, s.OverrideBlackoutKind(tt.override, "planned"))
+			}
+
+			got, err := s.ResetBlackoutKinds(tt.id)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+// GetAllBlackouts returns every blackout of the source table
+func (s *Storage) GetAllBlackouts(filter models.Filter) ([]models.Blackout, error) {
+	const op = "storage.sqlite.GetAllBlackouts"
+
+	cond, args := blackoutsFilter("id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE TRUE`+cond, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+// GetLatestRevisions returns the last revision of every blackout of the filter city ever seen, keyed by blackout id
+func (s *Storage) GetLatestRevisions(filter models.Filter) (map[string]models.Revision, error) {
+	const op = "storage.sqlite.GetLatestRevisions"
+
+	cond, args := cityFilter("city_id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT id, blackout_id, start_date, end_date, description, state, actor, changed_at
+        FROM blackout_revisions
+        WHERE id IN (SELECT MAX(id) FROM blackout_revisions GROUP BY blackout_id)`+cond, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions, err := scanRevisions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	latest := make(map[string]models.Revision, len(revisions))
+	for _, revision := range revisions {
+		latest[revision.BlackoutID] = revision
+	}
+
+	return latest, nil
+}
+
+// GetRevisions returns the history of a blackout of the filter city, oldest first
+func (s *Storage) GetRevisions(blackoutID string, filter models.Filter) ([]models.Revision, error) {
+	const op = "storage.sqlite.GetRevisions"
+
+	cond, args := cityFilter("city_id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT id, blackout_id, start_date, end_date, description, state, actor, changed_at
+        FROM blackout_revisions
+        WHERE blackout_id = ?`+cond+`
+        ORDER BY id`,
+		append([]any{blackoutID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions, err := scanRevisions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// SaveRevisions appends the revisions and keeps the state column of the blackouts in step
+func (s *Storage) SaveRevisions(revisions []models.Revision) error {
+	const op = "storage.sqlite.SaveRevisions"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, revision := range revisions {
+		if err := insertRevision(tx, revision); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.Exec(`UPDATE blackouts SET state = ? WHERE id = ?`, revision.State, revision.BlackoutID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReviseBlackout changes the dates and the description of a blackout by hand and
+// records the revision in the same transaction. An empty end date is stored as NULL.
+func (s *Storage) ReviseBlackout(revision models.Revision) error {
+	const op = "storage.sqlite.ReviseBlackout"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+        UPDATE blackouts SET start_date = ?, end_date = ?, description = ?, state = ?
+        WHERE id = ?`,
+		revision.StartDate, sql.NullString{String: revision.EndDate, Valid: revision.EndDate != ""},
+		revision.Description, revision.State, revision.BlackoutID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrBlackoutNotFound
+	}
+
+	if err := insertRevision(tx, revision); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// insertRevision appends a revision in the city of its blackout. A removed
+// blackout keeps the city of its previous revision.
+func insertRevision(tx *sql.Tx, revision models.Revision) error {
+	_, err := tx.Exec(`
+        INSERT INTO blackout_revisions (blackout_id, start_date, end_date, description, state, actor, changed_at, city_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(
+            (SELECT city_id FROM blackouts WHERE id = ?),
+            (SELECT city_id FROM blackout_revisions WHERE blackout_id = ? ORDER BY id DESC LIMIT 1)))`,
+		revision.BlackoutID, revision.StartDate, revision.EndDate, revision.Description,
+		revision.State, revision.Actor, revision.ChangedAt, revision.BlackoutID, revision.BlackoutID)
+	return err
+}
+
+func scanRevisions(rows *sql.Rows) ([]models.Revision, error) {
+	var revisions []models.Revision
+	for rows.Next() {
+		var revision models.Revision
+
+		err := rows.Scan(
+			&revision.ID,
+			&revision.BlackoutID,
+			&revision.StartDate,
+			&revision.EndDate,
+			&revision.Description,
+			&revision.State,
+			&revision.Actor,
+			&revision.ChangedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
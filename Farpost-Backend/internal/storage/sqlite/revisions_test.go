@@ -0,0 +1,178 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+func TestGetAllBlackouts(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		filter models.Filter
+		want   []string
+	}{
+		{"every city", models.Filter{}, []string{"b1", "b2", "b3", "b4", "b5", "b6", "b7"}},
+		{"default city", vladivostok, []string{"b1", "b2", "b4", "b5", "b6", "b7"}},
+		{"other city", khabarovsk, []string{"b3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetAllBlackouts(tt.filter)
+			check(t, err)
+
+			all := ids(got)
+			slices.Sort(all)
+			equal(t, all, tt.want)
+		})
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	announced := models.Revision{BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", Description: "Плановые работы", State: "announced", Actor: "source", ChangedAt: "2024-03-09 12:00:00"}
+	active := announced
+	active.State, active.Actor, active.ChangedAt = "active", "system", "2024-03-10 09:00:00"
+	open := models.Revision{BlackoutID: "b6", StartDate: "2024-03-09 22:00:00", Description: "Порыв на сети", State: "active", Actor: "source", ChangedAt: "2024-03-09 22:05:00"}
+
+	check(t, s.SaveRevisions([]models.Revision{announced, active, open}))
+
+	tests := []struct {
+		name     string
+		blackout string
+		filter   models.Filter
+		want     []string
+	}{
+		{"oldest first", "b1", models.Filter{}, []string{"announced", "active"}},
+		{"NULL end_date", "b6", models.Filter{}, []string{"active"}},
+		{"in its city", "b1", vladivostok, []string{"announced", "active"}},
+		{"other city", "b1", khabarovsk, nil},
+		{"none", "b2", models.Filter{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetRevisions(tt.blackout, tt.filter)
+			check(t, err)
+
+			var states []string
+			for _, r := range got {
+				states = append(states, r.State)
+			}
+			equal(t, states, tt.want)
+		})
+	}
+
+	t.Run("latest", func(t *testing.T) {
+		got, err := s.GetLatestRevisions(models.Filter{})
+		check(t, err)
+		equal(t, len(got), 2)
+		equal(t, got["b1"].Actor, "system")
+		equal(t, got["b6"].EndDate, "")
+	})
+
+	t.Run("latest of another city", func(t *testing.T) {
+		got, err := s.GetLatestRevisions(khabarovsk)
+		check(t, err)
+		equal(t, len(got), 0)
+	})
+}
+
+func TestReviseBlackout(t *testing.T) {
+	tests := []struct {
+		name       string
+		revision   models.Revision
+		wantEnd    string
+		wantActive []string
+		wantErr    error
+	}{
+		{"end set", models.Revision{BlackoutID: "b6", StartDate: "2024-03-09 22:00:00", EndDate: "2024-03-10 08:00:00", Description: "Порыв устранен", State: "resolved", Actor: "operator"},
+			"2024-03-10 08:00:00", nil, nil},
+		{"empty end is stored as NULL", models.Revision{BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", Description: "Продлено", State: "extended", Actor: "operator"},
+			"", []string{"b1", "b6"}, nil},
+		{"unknown blackout", models.Revision{BlackoutID: "b404", StartDate: "2024-03-10 09:00:00", State: "resolved", Actor: "operator"},
+			"", nil, storage.ErrBlackoutNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+
+			err := s.ReviseBlackout(tt.revision)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+
+				revisions, err := s.GetRevisions(tt.revision.BlackoutID, models.Filter{})
+				check(t, err)
+				equal(t, len(revisions), 0)
+				return
+			}
+			check(t, err)
+
+			got, err := s.GetBlackout(tt.revision.BlackoutID, models.Filter{})
+			check(t, err)
+			equal(t, got.EndDate, tt.wantEnd)
+			equal(t, got.Description, tt.revision.Description)
+
+			active, err := s.GetBlackouts("2025-01-01 00:00:00")
+			check(t, err)
+			equal(t, ids(active), tt.wantActive)
+
+			revisions, err := s.GetRevisions(tt.revision.BlackoutID, models.Filter{})
+			check(t, err)
+			equal(t, len(revisions), 1)
+		})
+	}
+}
+
+func TestRevisionsAreAppendOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	db, err := sql.Open("sqlite3", path)
+	check(t, err)
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range sqlitetest.Schema {
+		_, err := db.Exec(stmt)
+		check(t, err)
+	}
+
+	s, err := sqlite.New(path)
+	check(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	check(t, s.SaveRevisions([]models.Revision{
+		{BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", State: "announced", Actor: "source", ChangedAt: "2024-03-09 12:00:00"},
+	}))
+
+	tests := []struct {
+		name string
+		stmt string
+	}{
+		{"update", `UPDATE blackout_revisions SET state = 'resolved'`},
+		{"delete", `DELETE FROM blackout_revisions`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Exec(tt.stmt)
+			if err == nil || !strings.Contains(err.Error(), "append-only") {
+				t.Fatalf("got error %v, want the append-only trigger to abort", err)
+			}
+
+			revisions, err := s.GetRevisions("b1", models.Filter{})
+			check(t, err)
+			equal(t, len(revisions), 1)
+			equal(t, revisions[0].State, "announced")
+		})
+	}
+}
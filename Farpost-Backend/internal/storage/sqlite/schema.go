@@ -0,0 +1,294 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SourceSchema is the schema of the source tables as the loader creates them,
+// the service adds its own tables and columns on top
+var SourceSchema = []string{
+	`CREATE TABLE streets(id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE buildings(id INTEGER PRIMARY KEY, street_id INTEGER, number TEXT, is_fake INTEGER DEFAULT 0)`,
+	`CREATE TABLE blackouts(id TEXT PRIMARY KEY, start_date TEXT, end_date TEXT, description TEXT, type TEXT, initiator_name TEXT, source TEXT)`,
+	`CREATE TABLE blackouts_buildings(blackout_id TEXT, building_id INTEGER)`,
+}
+
+// schema holds the tables the service owns. The source tables
+// (streets, buildings, blackouts, blackouts_buildings) come with the database.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		buildings TEXT NOT NULL DEFAULT '[]',
+		streets TEXT NOT NULL DEFAULT '[]',
+		types TEXT NOT NULL DEFAULT '[]',
+		organizations TEXT NOT NULL DEFAULT '[]',
+		created_at TEXT NOT NULL,
+		city_id INTEGER REFERENCES cities(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TEXT NOT NULL,
+		last_status_code INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		delivered_at TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+		ON webhook_deliveries(status, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook
+		ON webhook_deliveries(webhook_id)`,
+	`CREATE TABLE IF NOT EXISTS subscriptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL,
+		building_id INTEGER NOT NULL,
+		mode TEXT NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		confirmed_at TEXT,
+		created_at TEXT NOT NULL,
+		last_digest_at TEXT,
+		UNIQUE (email, building_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_subscriptions_building
+		ON subscriptions(building_id)`,
+	`CREATE TABLE IF NOT EXISTS telegram_subscriptions (
+		chat_id INTEGER NOT NULL,
+		building_id INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (chat_id, building_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_telegram_subscriptions_building
+		ON telegram_subscriptions(building_id)`,
+	`CREATE TABLE IF NOT EXISTS telegram_chats (
+		chat_id INTEGER PRIMARY KEY,
+		city_id INTEGER NOT NULL REFERENCES cities(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS districts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		city_id INTEGER NOT NULL REFERENCES cities(id),
+		name TEXT NOT NULL,
+		UNIQUE (city_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS hotspots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		scope TEXT NOT NULL,
+		building_id INTEGER NOT NULL DEFAULT 0,
+		street TEXT NOT NULL,
+		number TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT '',
+		city_id INTEGER NOT NULL DEFAULT 0,
+		outages INTEGER NOT NULL,
+		window_outages INTEGER NOT NULL,
+		periodic INTEGER NOT NULL DEFAULT 0,
+		period_days REAL NOT NULL DEFAULT 0,
+		first_at TEXT NOT NULL,
+		last_at TEXT NOT NULL,
+		timeline TEXT NOT NULL DEFAULT '[]',
+		detected_at TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS anomalies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		city_id INTEGER NOT NULL DEFAULT 0,
+		type TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT '',
+		hour TEXT NOT NULL,
+		count INTEGER NOT NULL,
+		baseline REAL NOT NULL,
+		stddev REAL NOT NULL,
+		z_score REAL NOT NULL,
+		detected_at TEXT NOT NULL,
+		UNIQUE (city_id, type, kind, hour)
+	)`,
+	`CREATE TABLE IF NOT EXISTS blackout_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		blackout_id TEXT NOT NULL,
+		start_date TEXT NOT NULL,
+		end_date TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		changed_at TEXT NOT NULL,
+		city_id INTEGER REFERENCES cities(id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_blackout_revisions_blackout
+		ON blackout_revisions(blackout_id, id)`,
+	`CREATE TRIGGER IF NOT EXISTS blackout_revisions_no_update
+		BEFORE UPDATE ON blackout_revisions
+		BEGIN SELECT RAISE(ABORT, 'blackout revisions are append-only'); END`,
+	`CREATE TRIGGER IF NOT EXISTS blackout_revisions_no_delete
+		BEFORE DELETE ON blackout_revisions
+		BEGIN SELECT RAISE(ABORT, 'blackout revisions are append-only'); END`,
+	`CREATE TABLE IF NOT EXISTS cities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		code TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		timezone TEXT NOT NULL,
+		buildings_total INTEGER NOT NULL DEFAULT 0,
+		is_default INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS service_types (
+		code TEXT PRIMARY KEY,
+		name_ru TEXT NOT NULL,
+		name_en TEXT NOT NULL,
+		icon TEXT NOT NULL DEFAULT '',
+		position INTEGER NOT NULL DEFAULT 0
+	)`,
+	// the registry starts with the types of the source, new ones come from the config
+	// and rows edited by hand are kept
+	`INSERT OR IGNORE INTO service_types (code, name_ru, name_en, icon, position) VALUES
+		('hot_water', 'горячая вода', 'hot water', 'hot-water', 1),
+		('cold_water', 'холодная вода', 'cold water', 'cold-water', 2),
+		('electricity', 'электричество', 'electricity', 'lighting', 3),
+		('heat', 'отопление', 'heating', 'heating', 4)`,
+	`CREATE TABLE IF NOT EXISTS data_version (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		version INTEGER NOT NULL
+	)`,
+	`INSERT OR IGNORE INTO data_version (id, version) VALUES (1, 0)`,
+}
+
+// columns are added to the source tables and to tables of older versions when missing
+var columns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"buildings", "latitude", "REAL"},
+	{"buildings", "longitude", "REAL"},
+	{"streets", "district_id", "INTEGER REFERENCES districts(id)"},
+	{"buildings", "district_id", "INTEGER REFERENCES districts(id)"},
+	{"blackouts", "kind", "TEXT"},
+	{"blackouts", "kind_manual", "INTEGER NOT NULL DEFAULT 0"},
+	{"blackouts", "state", "TEXT"},
+	{"hotspots", "kind", "TEXT NOT NULL DEFAULT ''"},
+	{"streets", "city_id", "INTEGER REFERENCES cities(id)"},
+	{"blackouts", "city_id", "INTEGER REFERENCES cities(id)"},
+	{"hotspots", "city_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"webhooks", "city_id", "INTEGER REFERENCES cities(id)"},
+	{"blackout_revisions", "city_id", "INTEGER REFERENCES cities(id)"},
+}
+
+// rebuilt are tables of background job findings whose unique key changed.
+// SQLite cannot alter a constraint, a table without the column is dropped
+// and created anew, the job finds the recent rows again on its next run.
+var rebuilt = []struct {
+	table  string
+	column string
+}{
+	{"anomalies", "city_id"},
+}
+
+// versioned are the source tables, a write to any of them raises data_version
+// and drops the response cache. The service tables change on their own schedule
+// and their responses live until the TTL ends.
+var versioned = []string{"streets", "buildings", "blackouts", "blackouts_buildings"}
+
+func migrate(db *sql.DB) error {
+	const op = "storage.sqlite.migrate"
+
+	for _, table := range rebuilt {
+		if err := dropWithout(db, table.table, table.column); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for _, column := range columns {
+		if err := addColumn(db, column.table, column.name, column.definition); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := addVersionTriggers(db); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// addVersionTriggers makes every write to the source tables raise data_version.
+// Tables the loader has not created yet are skipped, DataVersion calls it again
+// once the schema changes.
+func addVersionTriggers(db *sql.DB) error {
+	for _, table := range versioned {
+		var exists bool
+		err := db.QueryRow(`SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		for _, event := range []string{"insert", "update", "delete"} {
+			stmt := fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_data_version_%[2]s
+				AFTER %[2]s ON %[1]s
+				BEGIN UPDATE data_version SET version = version + 1 WHERE id = 1; END`, table, event)
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// dropWithout drops the table when it exists without the column
+func dropWithout(db *sql.DB, table, column string) error {
+	var columns, matching int
+	err := db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE name = ?) FROM pragma_table_info(?)`, column, table).Scan(&columns, &matching)
+	if err != nil {
+		return err
+	}
+
+	if columns == 0 || matching > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`DROP TABLE %s`, table))
+	return err
+}
+
+func addColumn(db *sql.DB, table, name, definition string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := false
+	for rows.Next() {
+		var existing string
+		if err := rows.Scan(&existing); err != nil {
+			return err
+		}
+		if existing == name {
+			return nil
+		}
+		found = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// the table is not there yet, nothing to extend
+	if !found {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, name, definition))
+	return err
+}
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"fmt"
+	"vlru-prsch/internal/models"
+)
+
+// GetServiceTypes returns the service type registry in display order
+func (s *Storage) GetServiceTypes() (models.ServiceTypes, error) {
+	const op = "storage.sqlite.GetServiceTypes"
+
+	rows, err := s.db.Query(`
+        SELECT code, name_ru, name_en, icon, position
+        FROM service_types
+        ORDER BY position, code`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var types models.ServiceTypes
+	for rows.Next() {
+		var serviceType models.ServiceType
+
+		err := rows.Scan(&serviceType.Code, &serviceType.NameRu, &serviceType.NameEn, &serviceType.Icon, &serviceType.Position)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		types = append(types, serviceType)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return types, nil
+}
+
+// SyncServiceTypes stores the configured service types next to the seeded ones,
+// a configured type replaces the stored row with the same code.
+func (s *Storage) SyncServiceTypes(types []models.ServiceType) error {
+	const op = "storage.sqlite.SyncServiceTypes"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, serviceType := range types {
+		_, err := tx.Exec(`
+            INSERT INTO service_types (code, name_ru, name_en, icon, position)
+            VALUES (?, ?, ?, ?, ?)
+            ON CONFLICT (code) DO UPDATE SET
+                name_ru = excluded.name_ru,
+                name_en = excluded.name_en,
+                icon = excluded.icon,
+                position = excluded.position`,
+			serviceType.Code, serviceType.NameRu, serviceType.NameEn, serviceType.Icon, serviceType.Position)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
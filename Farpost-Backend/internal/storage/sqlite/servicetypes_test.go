@@ -0,0 +1,121 @@
+package sqlite_test
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+	"vlru-prsch/internal/storage/storagetest"
+)
+
+var gas = models.ServiceType{Code: "gas", NameRu: "газ", NameEn: "gas", Icon: "gas", Position: 5}
+
+func TestGetServiceTypes(t *testing.T) {
+	s, _ := open(t)
+
+	got, err := s.GetServiceTypes()
+	check(t, err)
+	equal(t, got, models.DefaultServiceTypes())
+}
+
+func TestSyncServiceTypes(t *testing.T) {
+	s, _ := open(t)
+
+	hotWater := models.ServiceType{Code: "hot_water", NameRu: "ГВС", NameEn: "hot water", Icon: "hot-water", Position: 6}
+	check(t, s.SyncServiceTypes([]models.ServiceType{gas, hotWater}))
+
+	got, err := s.GetServiceTypes()
+	check(t, err)
+
+	// the configured hot water replaces the seeded row and moves after gas
+	defaults := models.DefaultServiceTypes()
+	equal(t, got, models.ServiceTypes{defaults[1], defaults[2], defaults[3], gas, hotWater})
+
+	// syncing again changes nothing
+	check(t, s.SyncServiceTypes([]models.ServiceType{gas, hotWater}))
+
+	again, err := s.GetServiceTypes()
+	check(t, err)
+	equal(t, again, got)
+}
+
+func TestComplaintsByServiceType(t *testing.T) {
+	s, _ := open(t, append(seed,
+		`INSERT INTO blackouts (id, start_date, end_date, description, type, initiator_name, source) VALUES
+			('g1', '2024-03-10 10:15:00', '2024-03-10 16:00:00', 'Утечка', 'gas', 'Приморскгазификация', 'vl.ru'),
+			('s1', '2024-03-10 10:20:00', '2024-03-10 16:00:00', 'Ремонт', 'steam', 'Теплосеть', 'vl.ru')`,
+	)...)
+
+	check(t, s.SyncServiceTypes([]models.ServiceType{gas}))
+
+	got, err := s.GetComplaintsLastDay(now, models.Filter{})
+	check(t, err)
+
+	if len(got) != 24 {
+		t.Fatalf("got %d points, want 24", len(got))
+	}
+
+	// every point carries every registered type, the unregistered steam is left out
+	want := map[string]map[string]int{
+		"09:00": {"hot_water": 1, "cold_water": 0, "electricity": 0, "heat": 0, "gas": 0},
+		"10:00": {"hot_water": 0, "cold_water": 0, "electricity": 0, "heat": 0, "gas": 1},
+		"11:00": {"hot_water": 0, "cold_water": 0, "electricity": 1, "heat": 0, "gas": 0},
+	}
+	for _, point := range got[21:] {
+		equal(t, point.Counts, want[point.Time])
+	}
+
+	if got[0].HotWater != nil {
+		t.Errorf("got legacy fields %+v, want them left to the handler", got[0])
+	}
+}
+
+func TestGetServiceTypesEdgeCases(t *testing.T) {
+	tests := []struct {
+		name string
+		seed []string
+		want []string
+	}{
+		{"seeded with the types of the source", nil, []string{"hot_water", "cold_water", "electricity", "heat"}},
+		{"rows edited by hand", []string{
+			`UPDATE service_types SET position = 0 WHERE code = 'heat'`,
+			`INSERT INTO service_types (code, name_ru, name_en, position) VALUES ('steam', 'пар', 'steam', 0)`,
+		}, []string{"heat", "steam", "hot_water", "cold_water", "electricity"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sqlitetest.Open(t, storagetest.Seed, tt.seed).GetServiceTypes()
+			check(t, err)
+
+			var codes []string
+			for _, st := range got {
+				codes = append(codes, st.Code)
+			}
+			equal(t, codes, tt.want)
+		})
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		got, err := sqlitetest.Fixture(t).GetServiceTypes()
+		check(t, err)
+		equal(t, got, models.DefaultServiceTypes())
+	})
+}
+
+func TestSyncServiceTypesEdgeCases(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	check(t, s.SyncServiceTypes([]models.ServiceType{
+		{Code: "gas", NameRu: "газ", NameEn: "gas", Icon: "gas", Position: 5},
+		{Code: "heat", NameRu: "теплоснабжение", NameEn: "heating", Icon: "heating", Position: 4},
+	}))
+	// syncing twice changes nothing
+	check(t, s.SyncServiceTypes([]models.ServiceType{{Code: "gas", NameRu: "газ", NameEn: "gas", Icon: "gas", Position: 5}}))
+
+	got, err := s.GetServiceTypes()
+	check(t, err)
+	equal(t, got.Codes(), []string{"hot_water", "cold_water", "electricity", "heat", "gas"})
+	equal(t, got.Name("heat"), "теплоснабжение")
+	equal(t, got.Name("steam"), "steam")
+	equal(t, got.Has("gas"), true)
+}
@@ -2,19 +2,27 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Storage struct {
 	db *sql.DB
+
+	versionMu     sync.Mutex
+	schemaVersion int64
 }
 
+var _ storage.Storage = (*Storage)(nil)
+
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
@@ -23,10 +31,14 @@ func New(storagePath string) (*Storage, error) {
 		return nil, fmt.Errorf("%s failed to open db: %w", op, err)
 	}
 
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return &Storage{db: db}, nil
 }
 
-func (s *Storage) FindStreets(substr string) ([]string, error) {
+func (s *Storage) FindStreets(substr string, filter models.Filter) ([]string, error) {
 	const op = "storage.sqlite.FindStreets"
 
 	if len(substr) == 0 {
@@ -41,10 +53,12 @@ func (s *Storage) FindStreets(substr string) ([]string, error) {
 		return string(runes)
 	}(substr)
 
+	cond, args := citiesFilter("id", filter)
+
 	rows, err := s.db.Query(`
         SELECT name FROM streets 
-        WHERE name LIKE ? OR name LIKE ?`,
-		"%"+lowSubstr+"%", normSubstr+"%")
+        WHERE (name LIKE ? OR name LIKE ?)`+cond,
+		append([]any{"%"+lowSubstr+"%", normSubstr+"%"}, args...)...)
 	if err != nil {
 		return []string{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -69,14 +83,10 @@ func (s *Storage) FindStreets(substr string) ([]string, error) {
 func (s *Storage) GetBlackouts(currentTime string) ([]models.Blackout, error) {
 	const op = "storage.sqlite.GetBlackouts"
 
-	queryTime := currentTime
-    if len(currentTime) == 10 {
-        queryTime = currentTime + " 23:59:59"
-		currentTime = currentTime + " 00:00:00"
-    }
+	queryTime, currentTime := dayBounds(currentTime)
 
 	rows, err := s.db.Query(`
-        SELECT id, start_date, end_date, description, type, initiator_name, source 
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
         FROM blackouts 
         WHERE start_date <= ? AND (end_date >= ? OR end_date IS NULL)
         ORDER BY start_date DESC`,
@@ -100,6 +110,7 @@ func (s *Storage) GetBlackouts(currentTime string) ([]models.Blackout, error) {
 			&blackout.Type,
 			&blackout.InitiatorName,
 			&source,
+			&blackout.Kind,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
@@ -119,25 +130,80 @@ func (s *Storage) GetBlackouts(currentTime string) ([]models.Blackout, error) {
 	return blackouts, nil
 }
 
-func (s *Storage) GetBuildingsCount() (int64, error) {
+// GetFilteredBlackouts is GetBlackouts limited to the blackouts touching buildings that match the filter
+func (s *Storage) GetFilteredBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error) {
+	const op = "storage.sqlite.GetFilteredBlackouts"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	cond, args := blackoutsFilter("id", filter)
+	kindCond, kindArgs := kindFilter("kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE start_date <= ? AND (end_date >= ? OR end_date IS NULL)`+cond+`
+        ORDER BY start_date DESC`,
+		append([]any{queryTime, currentTime}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+func (s *Storage) GetBuildingsCount(filter models.Filter) (int64, error) {
 	const op = "storage.sqlite.GetBuildingsCount"
 
+	if filter.City != "" && filter.District == "" {
+		total, err := s.getBuildingsTotal(filter.City)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if total > 0 {
+			return total, nil
+		}
+	}
+
+	cond, args := buildingsFilter("id", filter)
+
 	var count int64
-	if err := s.db.QueryRow("SELECT COUNT(*) FROM buildings WHERE is_fake = 0").Scan(&count); err != nil {
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM buildings WHERE is_fake = 0"+cond, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// GetStreetsCount returns the number of streets with at least one real building
+func (s *Storage) GetStreetsCount() (int, error) {
+	const op = "storage.sqlite.GetStreetsCount"
+
+	var count int
+	err := s.db.QueryRow(`
+        SELECT COUNT(DISTINCT street_id) FROM buildings WHERE is_fake = 0`).Scan(&count)
+	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return count, nil
 }
 
-func (s *Storage) GetBuildingsCountByBlackoutType(blackoutType string, currentTime string) (int64, error) {
+func (s *Storage) GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error) {
 	const op = "storage.sqlite.GetBuildingsCountByBlackoutType"
 
-	queryTime := currentTime
-    if len(currentTime) == 10 {
-        queryTime = currentTime + " 23:59:59"
-		currentTime = currentTime + " 00:00:00"
-    }
+	queryTime, currentTime := dayBounds(currentTime)
+
+	cond, args := buildingsFilter("b.id", filter)
+	kindCond, kindArgs := kindFilter("bl.kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
 
 	var count int64
 	err := s.db.QueryRow(`
@@ -148,8 +214,8 @@ func (s *Storage) GetBuildingsCountByBlackoutType(blackoutType string, currentTi
         WHERE bl.type = ? 
 		AND b.is_fake = 0
         AND bl.start_date <= ? 
-        AND (bl.end_date >= ? OR bl.end_date IS NULL)`,
-		blackoutType, queryTime, currentTime).Scan(&count)
+        AND (bl.end_date >= ? OR bl.end_date IS NULL)`+cond,
+		append([]any{blackoutType, queryTime, currentTime}, args...)...).Scan(&count)
 
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -157,24 +223,76 @@ func (s *Storage) GetBuildingsCountByBlackoutType(blackoutType string, currentTi
 	return count, nil
 }
 
-func (s *Storage) GetLastBlackoutTimeByType(blackoutType string, currentTime string) (string, error) {
+// GetBuildingsCountByOrganization returns the number of buildings affected by
+// blackouts of the type at currentTime per initiating organization. A building
+// switched off by two organizations is counted for each of them.
+func (s *Storage) GetBuildingsCountByOrganization(blackoutType string, currentTime string, filter models.Filter) (map[string]int64, error) {
+	const op = "storage.sqlite.GetBuildingsCountByOrganization"
+
+	queryTime, currentTime := dayBounds(currentTime)
+
+	cond, args := buildingsFilter("b.id", filter)
+	kindCond, kindArgs := kindFilter("bl.kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
+
+	rows, err := s.db.Query(`
+        SELECT COALESCE(bl.initiator_name, ''), COUNT(DISTINCT b.id)
+        FROM buildings b
+        JOIN blackouts_buildings bb ON b.id = bb.building_id
+        JOIN blackouts bl ON bb.blackout_id = bl.id
+        WHERE bl.type = ?
+        AND b.is_fake = 0
+        AND bl.start_date <= ?
+        AND (bl.end_date >= ? OR bl.end_date IS NULL)`+cond+`
+        GROUP BY bl.initiator_name`,
+		append([]any{blackoutType, queryTime, currentTime}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int64{}
+	for rows.Next() {
+		var name string
+		var count int64
+
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		counts[name] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+func (s *Storage) GetLastBlackoutTimeByType(blackoutType string, currentTime string, filter models.Filter) (string, error) {
 	const op = "storage.sqlite.GetLastBlackoutTimeByType"
 
-	var lastTime string
+	cond, args := blackoutsFilter("id", filter)
+	kindCond, kindArgs := kindFilter("kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
+
+	// MAX is NULL when no blackout of the type matches
+	var lastTime sql.NullString
 	err := s.db.QueryRow(`
         SELECT MAX(start_date) 
         FROM blackouts 
         WHERE type = ? 
-        AND start_date <= ?`,
-		blackoutType, currentTime).Scan(&lastTime)
+        AND start_date <= ?`+cond,
+		append([]any{blackoutType, currentTime}, args...)...).Scan(&lastTime)
 
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
-	return lastTime, nil
+	return lastTime.String, nil
 }
 
-func (s *Storage) GetComplaintsLastDay(endTime string) ([]models.ComplaintData, error) {
+func (s *Storage) GetComplaintsLastDay(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
     const op = "storage.sqlite.GetComplaintsLastDay"
 
     endTimeParsed, err := time.Parse("2006-01-02 15:04:05", endTime)
@@ -186,16 +304,25 @@ func (s *Storage) GetComplaintsLastDay(endTime string) ([]models.ComplaintData,
     startTimeStr := startTime.Format("2006-01-02 15:04:05")
     endTimeStr := endTimeParsed.Format("2006-01-02 15:04:05")
 
+    cond, args := blackoutsFilter("id", filter)
+    kindCond, kindArgs := kindFilter("kind", filter)
+    cond, args = cond+kindCond, append(args, kindArgs...)
+
+    serviceTypes, err := s.GetServiceTypes()
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", op, err)
+    }
+
     rows, err := s.db.Query(`
         SELECT 
             strftime('%Y-%m-%d %H:00:00', start_date) as hour,
             type,
             COUNT(*) as count
         FROM blackouts 
-        WHERE start_date >= ? AND start_date < ?
+        WHERE start_date >= ? AND start_date < ?`+cond+`
         GROUP BY strftime('%Y-%m-%d %H', start_date), type
         ORDER BY hour`,
-        startTimeStr, endTimeStr)
+        append([]any{startTimeStr, endTimeStr}, args...)...)
     
     if err != nil {
         return nil, fmt.Errorf("%s: %w", op, err)
@@ -208,13 +335,7 @@ func (s *Storage) GetComplaintsLastDay(endTime string) ([]models.ComplaintData,
         hourTime := startTime.Add(time.Duration(i) * time.Hour)
         hourKey := hourTime.Format("2006-01-02 15:00:00")
         displayTime := hourTime.Format("15:04")
-        dataMap[hourKey] = &models.ComplaintData{
-            Time:        displayTime,
-            HotWater:    0,
-            ColdWater:   0,
-            Electricity: 0,
-            Heating:     0,
-        }
+        dataMap[hourKey] = models.NewComplaintData(displayTime, serviceTypes.Codes())
     }
 
     for rows.Next() {
@@ -225,15 +346,8 @@ func (s *Storage) GetComplaintsLastDay(endTime string) ([]models.ComplaintData,
         }
 
         if data, exists := dataMap[hour]; exists {
-            switch blackoutType {
-            case "hot_water":
-                data.HotWater = count
-            case "cold_water":
-                data.ColdWater = count
-            case "electricity":
-                data.Electricity = count
-            case "heat":
-                data.Heating = count
+            if _, registered := data.Counts[blackoutType]; registered {
+                data.Counts[blackoutType] = count
             }
         }
     }
@@ -254,7 +368,7 @@ func (s *Storage) GetComplaintsLastDay(endTime string) ([]models.ComplaintData,
     return result, nil
 }
 
-func (s *Storage) GetComplaintsLastHour(endTime string) ([]models.ComplaintData, error) {
+func (s *Storage) GetComplaintsLastHour(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
     const op = "storage.sqlite.GetComplaintsLastHour"
 
     endTimeParsed, err := time.Parse("2006-01-02 15:04:05", endTime)
@@ -266,16 +380,25 @@ func (s *Storage) GetComplaintsLastHour(endTime string) ([]models.ComplaintData,
     startTimeStr := startTime.Format("2006-01-02 15:04:05")
     endTimeStr := endTimeParsed.Format("2006-01-02 15:04:05")
 
+    cond, args := blackoutsFilter("id", filter)
+    kindCond, kindArgs := kindFilter("kind", filter)
+    cond, args = cond+kindCond, append(args, kindArgs...)
+
+    serviceTypes, err := s.GetServiceTypes()
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", op, err)
+    }
+
     rows, err := s.db.Query(`
         SELECT 
             strftime('%Y-%m-%d %H:%M:00', start_date) as minute,
             type,
             COUNT(*) as count
         FROM blackouts 
-        WHERE start_date >= ? AND start_date < ?
+        WHERE start_date >= ? AND start_date < ?`+cond+`
         GROUP BY strftime('%Y-%m-%d %H:%M', start_date), type
         ORDER BY minute`,
-        startTimeStr, endTimeStr)
+        append([]any{startTimeStr, endTimeStr}, args...)...)
     
     if err != nil {
         return nil, fmt.Errorf("%s: %w", op, err)
@@ -288,13 +411,7 @@ func (s *Storage) GetComplaintsLastHour(endTime string) ([]models.ComplaintData,
         minuteTime := startTime.Add(time.Duration(i) * time.Minute)
         minuteKey := minuteTime.Format("2006-01-02 15:04:00")
         displayTime := minuteTime.Format("15:04")
-        dataMap[minuteKey] = &models.ComplaintData{
-            Time:        displayTime,
-            HotWater:    0,
-            ColdWater:   0,
-            Electricity: 0,
-            Heating:     0,
-        }
+        dataMap[minuteKey] = models.NewComplaintData(displayTime, serviceTypes.Codes())
     }
 
     for rows.Next() {
@@ -305,15 +422,8 @@ func (s *Storage) GetComplaintsLastHour(endTime string) ([]models.ComplaintData,
         }
 
         if data, exists := dataMap[minute]; exists {
-            switch blackoutType {
-            case "hot_water":
-                data.HotWater = count
-            case "cold_water":
-                data.ColdWater = count
-            case "electricity":
-                data.Electricity = count
-            case "heat":
-                data.Heating = count
+            if _, registered := data.Counts[blackoutType]; registered {
+                data.Counts[blackoutType] = count
             }
         }
     }
@@ -334,7 +444,7 @@ func (s *Storage) GetComplaintsLastHour(endTime string) ([]models.ComplaintData,
     return result, nil
 }
 
-func (s *Storage) GetComplaintsLastWeek(endTime string) ([]models.ComplaintData, error) {
+func (s *Storage) GetComplaintsLastWeek(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
     const op = "storage.sqlite.GetComplaintsLastWeek"
 
     endTimeParsed, err := time.Parse("2006-01-02 15:04:05", endTime)
@@ -346,16 +456,25 @@ func (s *Storage) GetComplaintsLastWeek(endTime string) ([]models.ComplaintData,
     startTimeStr := startTime.Format("2006-01-02 15:04:05")
     endTimeStr := endTimeParsed.Format("2006-01-02 15:04:05")
 
+    cond, args := blackoutsFilter("id", filter)
+    kindCond, kindArgs := kindFilter("kind", filter)
+    cond, args = cond+kindCond, append(args, kindArgs...)
+
+    serviceTypes, err := s.GetServiceTypes()
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", op, err)
+    }
+
     rows, err := s.db.Query(`
         SELECT 
             strftime('%Y-%m-%d', start_date) as day,
             type,
             COUNT(*) as count
         FROM blackouts 
-        WHERE start_date >= ? AND start_date < ?
+        WHERE start_date >= ? AND start_date < ?`+cond+`
         GROUP BY strftime('%Y-%m-%d', start_date), type
         ORDER BY day`,
-        startTimeStr, endTimeStr)
+        append([]any{startTimeStr, endTimeStr}, args...)...)
     
     if err != nil {
         return nil, fmt.Errorf("%s: %w", op, err)
@@ -368,13 +487,7 @@ func (s *Storage) GetComplaintsLastWeek(endTime string) ([]models.ComplaintData,
         dayTime := startTime.Add(time.Duration(i) * 24 * time.Hour)
         dayKey := dayTime.Format("2006-01-02")
         displayTime := dayTime.Format("02.01")
-        dataMap[dayKey] = &models.ComplaintData{
-            Time:        displayTime,
-            HotWater:    0,
-            ColdWater:   0,
-            Electricity: 0,
-            Heating:     0,
-        }
+        dataMap[dayKey] = models.NewComplaintData(displayTime, serviceTypes.Codes())
     }
 
     for rows.Next() {
@@ -385,15 +498,8 @@ func (s *Storage) GetComplaintsLastWeek(endTime string) ([]models.ComplaintData,
         }
 
         if data, exists := dataMap[day]; exists {
-            switch blackoutType {
-            case "hot_water":
-                data.HotWater = count
-            case "cold_water":
-                data.ColdWater = count
-            case "electricity":
-                data.Electricity = count
-            case "heat":
-                data.Heating = count
+            if _, registered := data.Counts[blackoutType]; registered {
+                data.Counts[blackoutType] = count
             }
         }
     }
@@ -414,7 +520,7 @@ func (s *Storage) GetComplaintsLastWeek(endTime string) ([]models.ComplaintData,
     return result, nil
 }
 
-func (s *Storage) GetComplaintsLastMonth(endTime string) ([]models.ComplaintData, error) {
+func (s *Storage) GetComplaintsLastMonth(endTime string, filter models.Filter) ([]models.ComplaintData, error) {
     const op = "storage.sqlite.GetComplaintsLastMonth"
 
     endTimeParsed, err := time.Parse("2006-01-02 15:04:05", endTime)
@@ -426,16 +532,25 @@ func (s *Storage) GetComplaintsLastMonth(endTime string) ([]models.ComplaintData
     startTimeStr := startTime.Format("2006-01-02 15:04:05")
     endTimeStr := endTimeParsed.Format("2006-01-02 15:04:05")
 
+    cond, args := blackoutsFilter("id", filter)
+    kindCond, kindArgs := kindFilter("kind", filter)
+    cond, args = cond+kindCond, append(args, kindArgs...)
+
+    serviceTypes, err := s.GetServiceTypes()
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", op, err)
+    }
+
     rows, err := s.db.Query(`
         SELECT 
             strftime('%Y-%m-%d', start_date) as day,
             type,
             COUNT(*) as count
         FROM blackouts 
-        WHERE start_date >= ? AND start_date < ?
+        WHERE start_date >= ? AND start_date < ?`+cond+`
         GROUP BY strftime('%Y-%m-%d', start_date), type
         ORDER BY day`,
-        startTimeStr, endTimeStr)
+        append([]any{startTimeStr, endTimeStr}, args...)...)
     
     if err != nil {
         return nil, fmt.Errorf("%s: %w", op, err)
@@ -448,13 +563,7 @@ func (s *Storage) GetComplaintsLastMonth(endTime string) ([]models.ComplaintData
         dayTime := startTime.Add(time.Duration(i) * 24 * time.Hour)
         dayKey := dayTime.Format("2006-01-02")
         displayTime := dayTime.Format("02.01") 
-        dataMap[dayKey] = &models.ComplaintData{
-            Time:        displayTime,
-            HotWater:    0,
-            ColdWater:   0,
-            Electricity: 0,
-            Heating:     0,
-        }
+        dataMap[dayKey] = models.NewComplaintData(displayTime, serviceTypes.Codes())
     }
 
     for rows.Next() {
@@ -465,15 +574,8 @@ func (s *Storage) GetComplaintsLastMonth(endTime string) ([]models.ComplaintData
         }
 
         if data, exists := dataMap[day]; exists {
-            switch blackoutType {
-            case "hot_water":
-                data.HotWater = count
-            case "cold_water":
-                data.ColdWater = count
-            case "electricity":
-                data.Electricity = count
-            case "heat":
-                data.Heating = count
+            if _, registered := data.Counts[blackoutType]; registered {
+                data.Counts[blackoutType] = count
             }
         }
     }
@@ -494,18 +596,22 @@ func (s *Storage) GetComplaintsLastMonth(endTime string) ([]models.ComplaintData
     return result, nil
 }
 
-func (s *Storage) GetOrganizations(currentTime string) ([]string, error) {
+func (s *Storage) GetOrganizations(currentTime string, filter models.Filter) ([]string, error) {
 	const op = "storage.sqlite.GetOrganizations"
 
+	cond, args := blackoutsFilter("b.id", filter)
+	kindCond, kindArgs := kindFilter("b.kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
+
 	rows, err := s.db.Query(`
         SELECT b.initiator_name
         FROM blackouts b
         JOIN blackouts_buildings bb ON b.id = bb.blackout_id
-        WHERE b.start_date <= ? AND (b.end_date >= ? OR b.end_date IS NULL)
+        WHERE b.start_date <= ? AND (b.end_date >= ? OR b.end_date IS NULL)`+cond+`
         GROUP BY b.initiator_name
         ORDER BY COUNT(DISTINCT bb.building_id) DESC
 		LIMIT 4`,
-		currentTime, currentTime)
+		append([]any{currentTime, currentTime}, args...)...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -529,9 +635,13 @@ func (s *Storage) GetOrganizations(currentTime string) ([]string, error) {
 	return organizations, nil
 }
 
-func (s *Storage) GetBuildingsCountByOrgName(name string, currentTime string) (int64, error) {
+func (s *Storage) GetBuildingsCountByOrgName(name string, currentTime string, filter models.Filter) (int64, error) {
 	const op = "storage.sqlite.GetBuildingsCountByOrgName"
 
+	cond, args := blackoutsFilter("b.id", filter)
+	kindCond, kindArgs := kindFilter("b.kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
+
 	var count int64
 	err := s.db.QueryRow(`
         SELECT COUNT(DISTINCT bb.building_id) 
@@ -541,17 +651,21 @@ func (s *Storage) GetBuildingsCountByOrgName(name string, currentTime string) (i
         WHERE b.initiator_name = ? 
         AND b.start_date <= ? 
         AND (b.end_date >= ? OR b.end_date IS NULL)
-        AND bu.is_fake = 0`,
-		name, currentTime, currentTime).Scan(&count)
+        AND bu.is_fake = 0`+cond,
+		append([]any{name, currentTime, currentTime}, args...)...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	return count, nil
 }
 
-func (s *Storage) GetLastAddressByOrgName(name string, currentTime string) (string, string, error) {
+func (s *Storage) GetLastAddressByOrgName(name string, currentTime string, filter models.Filter) (string, string, error) {
 	const op = "storage.sqlite.GetLastAddressByOrgName"
 
+	cond, args := blackoutsFilter("b.id", filter)
+	kindCond, kindArgs := kindFilter("b.kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
+
 	var lastTime, address string
 	err := s.db.QueryRow(`
         SELECT b.start_date, s.name || ' ' || bg.number
@@ -561,10 +675,10 @@ func (s *Storage) GetLastAddressByOrgName(name string, currentTime string) (stri
         JOIN streets s ON bg.street_id = s.id
         WHERE b.initiator_name = ? 
         AND b.start_date <= ?
-        AND bg.is_fake = 0
+        AND bg.is_fake = 0`+cond+`
         ORDER BY b.start_date DESC 
         LIMIT 1`,
-		name, currentTime).Scan(&lastTime, &address)
+		append([]any{name, currentTime}, args...)...).Scan(&lastTime, &address)
 
 	if err != nil {
 		return "", "", fmt.Errorf("%s: %w", op, err)
@@ -572,30 +686,31 @@ func (s *Storage) GetLastAddressByOrgName(name string, currentTime string) (stri
 	return lastTime, address, nil
 }
 
-func (s *Storage) GetBlackoutsWithBuildingsCount(targetDate string) ([]models.BlackoutInfo, error) {
+func (s *Storage) GetBlackoutsWithBuildingsCount(targetDate string, filter models.Filter) ([]models.BlackoutInfo, error) {
     const op = "storage.sqlite.GetBlackoutsWithBuildingsCount"
 
-    queryTime := targetDate
-    if len(targetDate) == 10 {
-        queryTime = targetDate + " 23:59:59"
-        targetDate = targetDate + " 00:00:00"
-    }
+    queryTime, targetDate := dayBounds(targetDate)
+
+    cond, args := buildingsFilter("b.id", filter)
+    kindCond, kindArgs := kindFilter("bl.kind", filter)
+    cond, args = cond+kindCond, append(args, kindArgs...)
 
     rows, err := s.db.Query(`
         SELECT 
             bl.type,
             bl.start_date,
             bl.end_date,
-            COUNT(DISTINCT b.id) as building_count
+            COUNT(DISTINCT b.id) as building_count,
+            COALESCE(bl.kind, 'unknown')
         FROM blackouts bl
         JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
         JOIN buildings b ON bb.building_id = b.id
         WHERE b.is_fake = 0
         AND bl.start_date <= ? 
-        AND (bl.end_date >= ? OR bl.end_date IS NULL)
+        AND (bl.end_date >= ? OR bl.end_date IS NULL)`+cond+`
         GROUP BY bl.id, bl.type, bl.start_date, bl.end_date
         ORDER BY bl.start_date DESC`,
-        queryTime, targetDate)
+        append([]any{queryTime, targetDate}, args...)...)
     // "2006-01-02 15:04:05" -> "2006-01-02 15:04"
     if err != nil {
         return nil, fmt.Errorf("%s: %w", op, err)
@@ -613,6 +728,7 @@ func (s *Storage) GetBlackoutsWithBuildingsCount(targetDate string) ([]models.Bl
             &startDate,
             &endDate,
             &blackout.BuildingCount,
+            &blackout.Kind,
         )
         if err != nil {
             return nil, fmt.Errorf("%s: %w", op, err)
@@ -643,4 +759,205 @@ func (s *Storage) GetBlackoutsWithBuildingsCount(targetDate string) ([]models.Bl
     }
 
     return blackouts, nil
-}
\ No newline at end of file
+}
+func (s *Storage) GetBlackoutAddresses(blackoutID string) ([]models.Address, error) {
+	const op = "storage.sqlite.GetBlackoutAddresses"
+
+	rows, err := s.db.Query(`
+        SELECT bg.id, s.name, bg.number
+        FROM blackouts_buildings bb
+        JOIN buildings bg ON bb.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        WHERE bb.blackout_id = ?
+        AND bg.is_fake = 0
+        ORDER BY s.name, bg.number`,
+		blackoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var addresses []models.Address
+	for rows.Next() {
+		var address models.Address
+
+		if err := rows.Scan(&address.BuildingID, &address.Street, &address.Number); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return addresses, nil
+}
+
+// GetUpcomingBlackouts returns the blackouts of the filter starting after currentTime
+func (s *Storage) GetUpcomingBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error) {
+	const op = "storage.sqlite.GetUpcomingBlackouts"
+
+	cond, args := blackoutsFilter("id", filter)
+	kindCond, kindArgs := kindFilter("kind", filter)
+	cond, args = cond+kindCond, append(args, kindArgs...)
+
+	rows, err := s.db.Query(`
+        SELECT id, start_date, end_date, description, type, initiator_name, source, COALESCE(kind, 'unknown')
+        FROM blackouts
+        WHERE start_date > ?`+cond+`
+        ORDER BY start_date`,
+		append([]any{currentTime}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+func (s *Storage) GetBuildingBlackouts(buildingID int64, from string, to string) ([]models.Blackout, error) {
+	const op = "storage.sqlite.GetBuildingBlackouts"
+
+	rows, err := s.db.Query(`
+        SELECT bl.id, bl.start_date, bl.end_date, bl.description, bl.type, bl.initiator_name, bl.source, COALESCE(bl.kind, 'unknown')
+        FROM blackouts bl
+        JOIN blackouts_buildings bb ON bl.id = bb.blackout_id
+        WHERE bb.building_id = ?
+        AND bl.start_date <= ?
+        AND (bl.end_date >= ? OR bl.end_date IS NULL)
+        ORDER BY bl.start_date`,
+		buildingID, to, from)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blackouts, nil
+}
+
+// FindBuilding returns the real building of the filter city at the address
+func (s *Storage) FindBuilding(street string, number string, filter models.Filter) (models.Address, error) {
+	const op = "storage.sqlite.FindBuilding"
+
+	cond, args := buildingsFilter("bg.id", filter)
+
+	var address models.Address
+	err := s.db.QueryRow(`
+        SELECT bg.id, s.name, bg.number
+        FROM buildings bg
+        JOIN streets s ON bg.street_id = s.id
+        WHERE s.name = ? AND bg.number = ?
+        AND bg.is_fake = 0`+cond+`
+        LIMIT 1`,
+		append([]any{strings.TrimSpace(street), strings.TrimSpace(number)}, args...)...).Scan(&address.BuildingID, &address.Street, &address.Number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Address{}, storage.ErrBuildingNotFound
+	}
+	if err != nil {
+		return models.Address{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return address, nil
+}
+
+// dayBounds widens a date without time to the whole day, it returns the
+// upper bound for start_date and the lower one for end_date
+func dayBounds(currentTime string) (string, string) {
+	if len(currentTime) == 10 {
+		return currentTime + " 23:59:59", currentTime + " 00:00:00"
+	}
+	return currentTime, currentTime
+}
+
+func scanBlackouts(rows *sql.Rows) ([]models.Blackout, error) {
+	var blackouts []models.Blackout
+	for rows.Next() {
+		var blackout models.Blackout
+		var endDate sql.NullString
+		var source sql.NullString
+
+		err := rows.Scan(
+			&blackout.ID,
+			&blackout.StartDate,
+			&endDate,
+			&blackout.Description,
+			&blackout.Type,
+			&blackout.InitiatorName,
+			&source,
+			&blackout.Kind,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if endDate.Valid {
+			blackout.EndDate = endDate.String
+		}
+
+		if source.Valid {
+			blackout.Source = source.String
+		}
+
+		blackouts = append(blackouts, blackout)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return blackouts, nil
+}
+
+// GetStreetBuildings returns the real buildings of the street in the filter city
+func (s *Storage) GetStreetBuildings(street string, filter models.Filter) ([]models.Address, error) {
+	const op = "storage.sqlite.GetStreetBuildings"
+
+	cond, args := buildingsFilter("bg.id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT bg.id, s.name, bg.number
+        FROM buildings bg
+        JOIN streets s ON bg.street_id = s.id
+        WHERE s.name = ?
+        AND bg.is_fake = 0`+cond+`
+        ORDER BY CAST(bg.number AS INTEGER), bg.number`,
+		append([]any{street}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var addresses []models.Address
+	for rows.Next() {
+		var address models.Address
+
+		if err := rows.Scan(&address.BuildingID, &address.Street, &address.Number); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return addresses, nil
+}
+
+// Close closes the database, the storage must not be used afterwards
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,732 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+	"vlru-prsch/internal/storage/storagetest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// source is the part of the source database the storage reads
+var source = []string{
+	`CREATE TABLE streets (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE buildings (id INTEGER PRIMARY KEY, street_id INTEGER, number TEXT, is_fake INTEGER DEFAULT 0)`,
+	`CREATE TABLE blackouts (id TEXT PRIMARY KEY, start_date TEXT, end_date TEXT, description TEXT, type TEXT, initiator_name TEXT, source TEXT)`,
+	`CREATE TABLE blackouts_buildings (blackout_id TEXT, building_id INTEGER)`,
+}
+
+// seed: b1 and b2 are active at now, b4 is over and b5 has not started.
+// Building 13 is fake and building 20 is on a street of Khabarovsk with no
+// other data, the rest belongs to the default Vladivostok.
+var seed = []string{
+	`INSERT INTO cities (id, code, name, timezone, is_default) VALUES
+		(1, 'vladivostok', 'Владивосток', 'Asia/Vladivostok', 1), (2, 'khabarovsk', 'Хабаровск', 'Asia/Vladivostok', 0)`,
+	`INSERT INTO streets (id, name, city_id) VALUES (1, 'Карбышева ул.', NULL), (2, 'Светланская ул.', NULL), (3, 'Ленина ул.', 2)`,
+	`INSERT INTO buildings (id, street_id, number, is_fake) VALUES
+		(10, 1, '54', 0), (11, 1, '56', 0), (12, 2, '1', 0), (13, 2, '', 1), (20, 3, '5', 0)`,
+	`INSERT INTO blackouts (id, start_date, end_date, description, type, initiator_name, source) VALUES
+		('b1', '2024-03-10 09:00:00', '2024-03-10 18:00:00', 'Ремонт', 'hot_water', 'Водоканал', 'vl.ru'),
+		('b2', '2024-03-10 11:30:00', '2024-03-11 12:00:00', 'Авария', 'electricity', 'Дальэнерго', 'vl.ru'),
+		('b4', '2024-02-01 09:00:00', '2024-02-01 18:00:00', 'Промывка', 'cold_water', 'Водоканал', 'vl.ru'),
+		('b5', '2024-03-12 09:00:00', '2024-03-12 18:00:00', 'Опрессовка', 'heat', 'Теплосеть', 'vl.ru')`,
+	`INSERT INTO blackouts_buildings (blackout_id, building_id) VALUES
+		('b1', 10), ('b1', 11), ('b2', 12), ('b2', 13), ('b4', 10), ('b5', 11)`,
+}
+
+const now = "2024-03-10 12:00:00"
+
+// open creates the source tables in a fresh database, runs sqlite.New over
+// it and applies the statements
+func open(t *testing.T, stmts ...string) (*sqlite.Storage, *sql.DB) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := sql.Open("sqlite3", path)
+	check(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	exec(t, db, source...)
+
+	s, err := sqlite.New(path)
+	check(t, err)
+
+	exec(t, db, stmts...)
+
+	return s, db
+}
+
+func TestNewAddsColumns(t *testing.T) {
+	_, db := open(t)
+
+	tests := []struct {
+		table string
+		want  []string
+	}{
+		{"streets", []string{"id", "name", "district_id", "city_id"}},
+		{"buildings", []string{"id", "street_id", "number", "is_fake", "latitude", "longitude", "district_id"}},
+		{"blackouts", []string{"id", "start_date", "end_date", "description", "type", "initiator_name", "source", "kind", "kind_manual", "state", "city_id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, tt.table)
+			check(t, err)
+			defer rows.Close()
+
+			var columns []string
+			for rows.Next() {
+				var name string
+				check(t, rows.Scan(&name))
+				columns = append(columns, name)
+			}
+			check(t, rows.Err())
+
+			equal(t, columns, tt.want)
+		})
+	}
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, _ := open(t, storagetest.Seed...)
+		return s
+	})
+}
+
+func exec(t *testing.T, db *sql.DB, stmts ...string) {
+	t.Helper()
+
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func is(t *testing.T, err, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+}
+
+func equal[T any](t *testing.T, got, want T) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// The tests of this package run against sqlitetest.Fixture, storagetest.Seed
+// with the rows of sqlitetest.Edge: b6 has a NULL end_date and source, b7
+// touches only the fake building 13.
+
+func TestFindStreets(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		substr string
+		filter models.Filter
+		want   []string
+	}{
+		{"empty query", "", models.Filter{}, []string{}},
+		{"lower case prefix", "карб", models.Filter{}, []string{"Карбышева ул."}},
+		{"every city", "ул", models.Filter{}, []string{"Карбышева ул.", "Светланская ул.", "Ленина ул."}},
+		{"default city", "ул", vladivostok, []string{"Карбышева ул.", "Светланская ул."}},
+		{"other city", "ул", khabarovsk, []string{"Ленина ул."}},
+		{"no match", "Нет такой", models.Filter{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FindStreets(tt.substr, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetBlackouts(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name string
+		at   string
+		want []string
+	}{
+		{"active now, newest first", now, []string{"b2", "b7", "b3", "b1", "b6"}},
+		{"date covers the whole day", "2024-03-12", []string{"b5", "b6"}},
+		{"open ended stays active", "2025-01-01 00:00:00", []string{"b6"}},
+		{"before any data", "2024-01-01 00:00:00", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBlackouts(tt.at)
+			check(t, err)
+			equal(t, ids(got), tt.want)
+		})
+	}
+
+	t.Run("NULL end_date and source read as empty", func(t *testing.T) {
+		got, err := s.GetBlackouts("2025-01-01 00:00:00")
+		check(t, err)
+		equal(t, got, []models.Blackout{{
+			ID:            "b6",
+			StartDate:     "2024-03-09 22:00:00",
+			Description:   "Порыв на сети",
+			Type:          "cold_water",
+			InitiatorName: "Водоканал",
+			Kind:          "unknown",
+		}})
+	})
+}
+
+func TestGetFilteredBlackouts(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		filter models.Filter
+		want   []string
+	}{
+		{"no filter", models.Filter{}, []string{"b2", "b7", "b3", "b1", "b6"}},
+		{"default city owns the rows without a city", vladivostok, []string{"b2", "b7", "b1", "b6"}},
+		{"other city", khabarovsk, []string{"b3"}},
+		{"unclassified count as unknown", models.Filter{Kind: "unknown"}, []string{"b2", "b7", "b3", "b1", "b6"}},
+		{"kind", models.Filter{Kind: "planned"}, nil},
+		{"unknown district", models.Filter{District: "Нет такого"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetFilteredBlackouts(now, tt.filter)
+			check(t, err)
+			equal(t, ids(got), tt.want)
+		})
+	}
+}
+
+func TestGetBuildingsCount(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		filter models.Filter
+		want   int64
+	}{
+		{"fake buildings are left out", models.Filter{}, 4},
+		{"default city", vladivostok, 3},
+		{"other city", khabarovsk, 1},
+		{"unknown district", models.Filter{District: "Нет такого"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBuildingsCount(tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetStreetsCount(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	got, err := s.GetStreetsCount()
+	check(t, err)
+	equal(t, got, 3)
+}
+
+func TestGetBuildingsCountByBlackoutType(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name         string
+		blackoutType string
+		at           string
+		filter       models.Filter
+		want         int64
+	}{
+		{"every city", "hot_water", now, models.Filter{}, 3},
+		{"default city", "hot_water", now, vladivostok, 2},
+		{"fake buildings are left out", "electricity", now, models.Filter{}, 1},
+		{"open ended", "cold_water", now, models.Filter{}, 2},
+		{"date covers the whole day", "cold_water", "2024-02-01", models.Filter{}, 1},
+		{"not started", "heat", now, models.Filter{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBuildingsCountByBlackoutType(tt.blackoutType, tt.at, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetBuildingsCountByOrganization(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name         string
+		blackoutType string
+		filter       models.Filter
+		want         map[string]int64
+	}{
+		{"every city", "hot_water", models.Filter{}, map[string]int64{"Водоканал": 3}},
+		{"fake buildings are left out", "electricity", models.Filter{}, map[string]int64{"Дальэнерго": 1}},
+		{"open ended in the default city", "cold_water", vladivostok, map[string]int64{"Водоканал": 2}},
+		{"none", "heat", models.Filter{}, map[string]int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBuildingsCountByOrganization(tt.blackoutType, now, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetLastBlackoutTimeByTypeEdgeCases(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name         string
+		blackoutType string
+		filter       models.Filter
+		want         string
+	}{
+		{"every city", "hot_water", models.Filter{}, "2024-03-10 10:00:00"},
+		{"default city", "hot_water", vladivostok, "2024-03-10 09:00:00"},
+		{"open ended", "cold_water", models.Filter{}, "2024-03-09 22:00:00"},
+		{"not started yet", "heat", models.Filter{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetLastBlackoutTimeByType(tt.blackoutType, now, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetComplaints(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	periods := map[string]func(string, models.Filter) ([]models.ComplaintData, error){
+		"hour":  s.GetComplaintsLastHour,
+		"day":   s.GetComplaintsLastDay,
+		"week":  s.GetComplaintsLastWeek,
+		"month": s.GetComplaintsLastMonth,
+	}
+
+	tests := []struct {
+		name    string
+		period  string
+		end     string
+		filter  models.Filter
+		buckets int
+		total   int
+	}{
+		{"hour by minute", "hour", now, models.Filter{}, 60, 1},
+		{"day by hour", "day", now, models.Filter{}, 24, 5},
+		{"day of the default city", "day", now, vladivostok, 24, 4},
+		{"day of the other city", "day", now, khabarovsk, 24, 1},
+		// the day buckets start at end minus the period, so the current day is not one of them
+		{"week by day", "week", now, models.Filter{}, 7, 1},
+		{"month by day", "month", now, models.Filter{}, 30, 1},
+		{"month before", "month", "2024-03-01 00:00:00", models.Filter{}, 30, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := periods[tt.period](tt.end, tt.filter)
+			check(t, err)
+			equal(t, len(got), tt.buckets)
+			equal(t, complaints(got), tt.total)
+		})
+	}
+
+	for period, get := range periods {
+		t.Run(period+" rejects a date without time", func(t *testing.T) {
+			if _, err := get("2024-03-10", models.Filter{}); err == nil {
+				t.Fatal("want an error")
+			}
+		})
+	}
+}
+
+// TestGetComplaintsBuckets checks where every blackout lands. The window
+
+// starts at end minus the period and counts blackouts by start, so b6 without
+
+// an end counts as any other. Only buckets with complaints are listed.
+
+func TestGetComplaintsBuckets(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	type get func(string, models.Filter) ([]models.ComplaintData, error)
+
+	tests := []struct {
+		name        string
+		get         get
+		end         string
+		filter      models.Filter
+		buckets     int
+		first, last string
+		want        map[string]map[string]int
+	}{
+		{"hour", s.GetComplaintsLastHour, now, models.Filter{}, 60, "11:00", "11:59", map[string]map[string]int{
+			"11:30": {"electricity": 1},
+		}},
+		{"hour of two cities", s.GetComplaintsLastHour, "2024-03-10 10:30:00", models.Filter{}, 60, "09:30", "10:29", map[string]map[string]int{
+			"10:00": {"hot_water": 1},
+			"10:15": {"electricity": 1},
+		}},
+		{"hour of the other city", s.GetComplaintsLastHour, "2024-03-10 10:30:00", khabarovsk, 60, "09:30", "10:29", map[string]map[string]int{
+			"10:00": {"hot_water": 1},
+		}},
+		{"hour ending at the start", s.GetComplaintsLastHour, "2024-03-10 11:30:00", models.Filter{}, 60, "10:30", "11:29", map[string]map[string]int{}},
+		{"hour without an end", s.GetComplaintsLastHour, "2024-03-09 22:30:00", models.Filter{}, 60, "21:30", "22:29", map[string]map[string]int{
+			"22:00": {"cold_water": 1},
+		}},
+		{"week without the current day", s.GetComplaintsLastWeek, now, models.Filter{}, 7, "03.03", "09.03", map[string]map[string]int{
+			"09.03": {"cold_water": 1},
+		}},
+		{"week to midnight", s.GetComplaintsLastWeek, "2024-03-11 00:00:00", models.Filter{}, 7, "04.03", "10.03", map[string]map[string]int{
+			"09.03": {"cold_water": 1},
+			"10.03": {"hot_water": 2, "electricity": 2},
+		}},
+		{"week of the default city", s.GetComplaintsLastWeek, "2024-03-11 00:00:00", vladivostok, 7, "04.03", "10.03", map[string]map[string]int{
+			"09.03": {"cold_water": 1},
+			"10.03": {"hot_water": 1, "electricity": 2},
+		}},
+		{"month to midnight", s.GetComplaintsLastMonth, "2024-03-11 00:00:00", models.Filter{}, 30, "10.02", "10.03", map[string]map[string]int{
+			"09.03": {"cold_water": 1},
+			"10.03": {"hot_water": 2, "electricity": 2},
+		}},
+		{"month over a leap day", s.GetComplaintsLastMonth, "2024-03-02 00:00:00", models.Filter{}, 30, "01.02", "01.03", map[string]map[string]int{
+			"01.02": {"cold_water": 1},
+		}},
+		{"month of the other city", s.GetComplaintsLastMonth, "2024-03-02 00:00:00", khabarovsk, 30, "01.02", "01.03", map[string]map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets, err := tt.get(tt.end, tt.filter)
+			check(t, err)
+
+			equal(t, len(buckets), tt.buckets)
+			equal(t, buckets[0].Time, tt.first)
+			equal(t, buckets[len(buckets)-1].Time, tt.last)
+
+			got := map[string]map[string]int{}
+			for _, bucket := range buckets {
+				for code, count := range bucket.Counts {
+					if count == 0 {
+						continue
+					}
+					if got[bucket.Time] == nil {
+						got[bucket.Time] = map[string]int{}
+					}
+					got[bucket.Time][code] = count
+				}
+			}
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetOrganizations(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name string
+		at   string
+		want []string
+	}{
+		{"most buildings first", now, []string{"Водоканал", "Дальэнерго"}},
+		{"open ended counts", "2024-03-12 10:00:00", []string{"Водоканал", "Теплосеть"}},
+		{"before any data", "2024-01-01 00:00:00", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetOrganizations(tt.at, models.Filter{})
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetBuildingsCountByOrgName(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name string
+		org  string
+		want int64
+	}{
+		{"with an open ended blackout", "Водоканал", 4},
+		{"fake buildings are left out", "Дальэнерго", 1},
+		{"not started", "Теплосеть", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBuildingsCountByOrgName(tt.org, now, models.Filter{})
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetLastAddressByOrgName(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name        string
+		org         string
+		wantTime    string
+		wantAddress string
+		wantErr     bool
+	}{
+		{"latest start", "Водоканал", "2024-03-10 10:00:00", "Ленина ул. 5", false},
+		{"fake buildings are skipped", "Дальэнерго", "2024-03-10 11:30:00", "Светланская ул. 1", false},
+		{"not started", "Теплосеть", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTime, gotAddress, err := s.GetLastAddressByOrgName(tt.org, now, models.Filter{})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			check(t, err)
+			equal(t, gotTime, tt.wantTime)
+			equal(t, gotAddress, tt.wantAddress)
+		})
+	}
+}
+
+func TestGetBlackoutsWithBuildingsCount(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	b2 := models.BlackoutInfo{Type: "electricity", StartDate: "2024-03-10 11:30", EndDate: "2024-03-11 12:00", BuildingCount: 1, Kind: "unknown"}
+	b3 := models.BlackoutInfo{Type: "hot_water", StartDate: "2024-03-10 10:00", EndDate: "2024-03-10 20:00", BuildingCount: 1, Kind: "unknown"}
+	b1 := models.BlackoutInfo{Type: "hot_water", StartDate: "2024-03-10 09:00", EndDate: "2024-03-10 18:00", BuildingCount: 2, Kind: "unknown"}
+	b6 := models.BlackoutInfo{Type: "cold_water", StartDate: "2024-03-09 22:00", EndDate: "", BuildingCount: 2, Kind: "unknown"}
+
+	tests := []struct {
+		name   string
+		at     string
+		filter models.Filter
+		want   []models.BlackoutInfo
+	}{
+		{"fake only blackouts are left out", now, models.Filter{}, []models.BlackoutInfo{b2, b3, b1, b6}},
+		{"default city", now, vladivostok, []models.BlackoutInfo{b2, b1, b6}},
+		{"date covers the whole day", "2024-03-11", models.Filter{}, []models.BlackoutInfo{b2, b6}},
+		{"before any data", "2024-01-01", models.Filter{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBlackoutsWithBuildingsCount(tt.at, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetBlackoutAddresses(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name string
+		id   string
+		want []models.Address
+	}{
+		{"sorted by street and number", "b1", []models.Address{
+			{BuildingID: 10, Street: "Карбышева ул.", Number: "54"},
+			{BuildingID: 11, Street: "Карбышева ул.", Number: "56"},
+		}},
+		{"fake buildings are left out", "b2", []models.Address{{BuildingID: 12, Street: "Светланская ул.", Number: "1"}}},
+		{"fake only", "b7", nil},
+		{"unknown blackout", "b404", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBlackoutAddresses(tt.id)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetUpcomingBlackouts(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		at     string
+		filter models.Filter
+		want   []string
+	}{
+		{"not started yet", now, models.Filter{}, []string{"b5"}},
+		{"oldest first", "2024-01-01 00:00:00", models.Filter{}, []string{"b4", "b6", "b1", "b3", "b7", "b2", "b5"}},
+		{"other city", "2024-01-01 00:00:00", khabarovsk, []string{"b3"}},
+		{"after every start", "2025-01-01 00:00:00", models.Filter{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetUpcomingBlackouts(tt.at, tt.filter)
+			check(t, err)
+			equal(t, ids(got), tt.want)
+		})
+	}
+
+	t.Run("of a kind", func(t *testing.T) {
+		s := sqlitetest.Fixture(t)
+		check(t, s.SetBlackoutKinds(map[string]string{"b1": models.KindEmergency, "b5": models.KindPlanned}))
+
+		for kind, want := range map[string][]string{
+			models.KindPlanned:   {"b5"},
+			models.KindEmergency: {"b1"},
+			models.KindUnknown:   {"b4", "b6", "b3", "b7", "b2"},
+		} {
+			got, err := s.GetUpcomingBlackouts("2024-01-01 00:00:00", models.Filter{Kind: kind})
+			check(t, err)
+			equal(t, ids(got), want)
+		}
+	})
+}
+
+func TestGetBuildingBlackouts(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name     string
+		building int64
+		from     string
+		to       string
+		want     []string
+	}{
+		{"open ended overlaps", 11, "2024-03-01 00:00:00", "2024-03-31 23:59:59", []string{"b6", "b1", "b5"}},
+		{"over before", 10, "2024-02-01 00:00:00", "2024-02-29 23:59:59", []string{"b4"}},
+		{"fake building keeps its blackouts", 13, "2024-03-01 00:00:00", "2024-03-31 23:59:59", []string{"b7", "b2"}},
+		{"unknown building", 99, "2024-03-01 00:00:00", "2024-03-31 23:59:59", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetBuildingBlackouts(tt.building, tt.from, tt.to)
+			check(t, err)
+			equal(t, ids(got), tt.want)
+		})
+	}
+}
+
+func TestFindBuilding(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name    string
+		street  string
+		number  string
+		filter  models.Filter
+		want    int64
+		wantErr error
+	}{
+		{"exact", "Карбышева ул.", "54", models.Filter{}, 10, nil},
+		{"spaces are trimmed", " Карбышева ул. ", " 56 ", models.Filter{}, 11, nil},
+		{"in its city", "Ленина ул.", "5", khabarovsk, 20, nil},
+		{"other city", "Карбышева ул.", "54", khabarovsk, 0, storage.ErrBuildingNotFound},
+		{"fake building", "Светланская ул.", "", models.Filter{}, 0, storage.ErrBuildingNotFound},
+		{"unknown street", "Нет такой", "1", models.Filter{}, 0, storage.ErrBuildingNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FindBuilding(tt.street, tt.number, tt.filter)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+			equal(t, got.BuildingID, tt.want)
+		})
+	}
+}
+
+func TestGetStreetBuildings(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+
+	tests := []struct {
+		name   string
+		street string
+		filter models.Filter
+		want   []models.Address
+	}{
+		{"sorted by number", "Карбышева ул.", models.Filter{}, []models.Address{
+			{BuildingID: 10, Street: "Карбышева ул.", Number: "54"},
+			{BuildingID: 11, Street: "Карбышева ул.", Number: "56"},
+		}},
+		{"fake buildings are left out", "Светланская ул.", models.Filter{}, []models.Address{{BuildingID: 12, Street: "Светланская ул.", Number: "1"}}},
+		{"other city", "Карбышева ул.", khabarovsk, nil},
+		{"unknown street", "Нет такой", models.Filter{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetStreetBuildings(tt.street, tt.filter)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func ids(blackouts []models.Blackout) []string {
+	var ids []string
+	for _, b := range blackouts {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func complaints(buckets []models.ComplaintData) int {
+	total := 0
+	for _, bucket := range buckets {
+		for _, count := range bucket.Counts {
+			total += count
+		}
+	}
+	return total
+}
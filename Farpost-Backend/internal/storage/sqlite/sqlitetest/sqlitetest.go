@@ -0,0 +1,96 @@
+// Package sqlitetest builds SQLite databases for tests: the schema of the
+// production database, migrated by sqlite.New and filled with seed rows.
+package sqlitetest
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/storagetest"
+)
+
+// Schema is the schema of the source tables, see sqlite.SourceSchema
+var Schema = sqlite.SourceSchema
+
+// Edge adds the rows storagetest.Seed leaves out. b6 has no end yet and no
+// source, both NULL, and touches buildings 11 and 12. b7 touches only the fake
+// building 13, so it is left out wherever fake buildings are.
+var Edge = []string{
+	`INSERT INTO blackouts (id, start_date, end_date, description, type, initiator_name, source) VALUES
+		('b6', '2024-03-09 22:00:00', NULL, 'Порыв на сети', 'cold_water', 'Водоканал', NULL),
+		('b7', '2024-03-10 10:15:00', '2024-03-10 14:00:00', 'Замена счетчика', 'electricity', 'Дальэнерго', 'vl.ru')`,
+	`INSERT INTO blackouts_buildings (blackout_id, building_id) VALUES
+		('b6', 11),
+		('b6', 12),
+		('b7', 13)`,
+}
+
+var databases atomic.Int64
+
+// Fixture returns an in-memory storage with storagetest.Seed and Edge, the
+// data the handler golden files are recorded against
+func Fixture(t testing.TB) *sqlite.Storage {
+	return Open(t, storagetest.Seed, Edge)
+}
+
+// Open returns a storage over a new in-memory database with the seeds applied in order
+func Open(t testing.TB, seeds ...[]string) *sqlite.Storage {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:sqlitetest%d?mode=memory&cache=shared", databases.Add(1))
+
+	// the database lives while a connection is open, this one holds it for the test
+	keep, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { keep.Close() })
+
+	return open(t, keep, dsn, seeds)
+}
+
+// OpenFile is Open on a database file in a temporary directory, for the cases
+// that need a file, like the conformance suite over several connections
+func OpenFile(t testing.TB, seeds ...[]string) *sqlite.Storage {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return open(t, db, path, seeds)
+}
+
+func open(t testing.TB, db *sql.DB, dsn string, seeds [][]string) *sqlite.Storage {
+	t.Helper()
+
+	exec(t, db, Schema)
+
+	s, err := sqlite.New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, seed := range seeds {
+		exec(t, db, seed)
+	}
+
+	return s
+}
+
+func exec(t testing.TB, db *sql.DB, stmts []string) {
+	t.Helper()
+
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+// SaveSubscription creates a pending subscription. A pending subscription for the
+// same email and building gets the new token and mode, a confirmed one is left intact.
+func (s *Storage) SaveSubscription(sub models.Subscription) (int64, error) {
+	const op = "storage.sqlite.SaveSubscription"
+
+	res, err := s.db.Exec(`
+        INSERT INTO subscriptions (email, building_id, mode, token, created_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT (email, building_id) DO UPDATE
+        SET token = excluded.token, mode = excluded.mode, created_at = excluded.created_at
+        WHERE subscriptions.confirmed_at IS NULL`,
+		sub.Email, sub.BuildingID, sub.Mode, sub.Token, sub.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return 0, storage.ErrSubscriptionExists
+	}
+
+	var id int64
+	err = s.db.QueryRow(`SELECT id FROM subscriptions WHERE token = ?`, sub.Token).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) ConfirmSubscription(token string, confirmedAt string) (models.Subscription, error) {
+	const op = "storage.sqlite.ConfirmSubscription"
+
+	_, err := s.db.Exec(`
+        UPDATE subscriptions SET confirmed_at = ?
+        WHERE token = ? AND confirmed_at IS NULL`,
+		confirmedAt, token)
+	if err != nil {
+		return models.Subscription{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	subs, err := s.querySubscriptions(`WHERE sub.token = ?`, token)
+	if err != nil {
+		return models.Subscription{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(subs) == 0 {
+		return models.Subscription{}, storage.ErrSubscriptionNotFound
+	}
+
+	return subs[0], nil
+}
+
+func (s *Storage) DeleteSubscription(token string) error {
+	const op = "storage.sqlite.DeleteSubscription"
+
+	res, err := s.db.Exec(`DELETE FROM subscriptions WHERE token = ?`, token)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// GetSubscriptionsByBuildings returns confirmed subscriptions in the given mode for any of the buildings
+func (s *Storage) GetSubscriptionsByBuildings(buildingIDs []int64, mode string) ([]models.Subscription, error) {
+	const op = "storage.sqlite.GetSubscriptionsByBuildings"
+
+	if len(buildingIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, 0, len(buildingIDs)+1)
+	args = append(args, mode)
+	for _, id := range buildingIDs {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(buildingIDs)), ",")
+
+	subs, err := s.querySubscriptions(`
+        WHERE sub.confirmed_at IS NOT NULL
+        AND sub.mode = ?
+        AND sub.building_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return subs, nil
+}
+
+// GetDigestSubscriptions returns confirmed digest subscriptions to buildings of the filter city
+// that have not got a digest since the given time
+func (s *Storage) GetDigestSubscriptions(since string, filter models.Filter) ([]models.Subscription, error) {
+	const op = "storage.sqlite.GetDigestSubscriptions"
+
+	cond, args := buildingsFilter("sub.building_id", filter)
+
+	subs, err := s.querySubscriptions(`
+        WHERE sub.confirmed_at IS NOT NULL
+        AND sub.mode = ?
+        AND (sub.last_digest_at IS NULL OR sub.last_digest_at < ?)`+cond,
+		append([]any{models.SubscriptionDigest, since}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return subs, nil
+}
+
+func (s *Storage) SetSubscriptionDigestSent(id int64, sentAt string) error {
+	const op = "storage.sqlite.SetSubscriptionDigestSent"
+
+	if _, err := s.db.Exec(`UPDATE subscriptions SET last_digest_at = ? WHERE id = ?`, sentAt, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) querySubscriptions(where string, args ...any) ([]models.Subscription, error) {
+	rows, err := s.db.Query(`
+        SELECT sub.id, sub.email, sub.building_id, s.name, bg.number, sub.mode, sub.token,
+               sub.confirmed_at, sub.created_at, sub.last_digest_at
+        FROM subscriptions sub
+        JOIN buildings bg ON sub.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        `+where+`
+        ORDER BY sub.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.Subscription
+	for rows.Next() {
+		var sub models.Subscription
+		var confirmedAt, lastDigestAt sql.NullString
+
+		err := rows.Scan(
+			&sub.ID,
+			&sub.Email,
+			&sub.BuildingID,
+			&sub.Street,
+			&sub.Number,
+			&sub.Mode,
+			&sub.Token,
+			&confirmedAt,
+			&sub.CreatedAt,
+			&lastDigestAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sub.ConfirmedAt = confirmedAt.String
+		sub.LastDigestAt = lastDigestAt.String
+
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
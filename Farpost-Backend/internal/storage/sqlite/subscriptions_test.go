@@ -0,0 +1,217 @@
+package sqlite_test
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+func subscription(email string, building int64, mode string, token string) models.Subscription {
+	return models.Subscription{Email: email, BuildingID: building, Mode: mode, Token: token, CreatedAt: now}
+}
+
+func TestSaveSubscription(t *testing.T) {
+	tests := []struct {
+		name      string
+		saved     []models.Subscription
+		confirm   string
+		sub       models.Subscription
+		wantErr   error
+		wantToken string
+	}{
+		{"new", nil, "", subscription("a@example.com", 10, models.SubscriptionInstant, "t1"), nil, "t1"},
+		{"pending takes the new token and mode", []models.Subscription{subscription("a@example.com", 10, models.SubscriptionInstant, "t1")}, "",
+			subscription("a@example.com", 10, models.SubscriptionDigest, "t2"), nil, "t2"},
+		{"confirmed is left intact", []models.Subscription{subscription("a@example.com", 10, models.SubscriptionInstant, "t1")}, "t1",
+			subscription("a@example.com", 10, models.SubscriptionDigest, "t2"), storage.ErrSubscriptionExists, "t1"},
+		{"other building", []models.Subscription{subscription("a@example.com", 10, models.SubscriptionInstant, "t1")}, "t1",
+			subscription("a@example.com", 11, models.SubscriptionInstant, "t2"), nil, "t2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+			for _, sub := range tt.saved {
+				_, err := s.SaveSubscription(sub)
+				check(t, err)
+			}
+			if tt.confirm != "" {
+				_, err := s.ConfirmSubscription(tt.confirm, now)
+				check(t, err)
+			}
+
+			id, err := s.SaveSubscription(tt.sub)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+			} else {
+				check(t, err)
+				if id == 0 {
+					t.Fatal("got id 0")
+				}
+			}
+
+			got, err := s.ConfirmSubscription(tt.wantToken, now)
+			check(t, err)
+			equal(t, got.BuildingID, tt.sub.BuildingID)
+		})
+	}
+}
+
+func TestConfirmSubscription(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+	_, err := s.SaveSubscription(subscription("a@example.com", 10, models.SubscriptionInstant, "t1"))
+	check(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		at      string
+		want    models.Subscription
+		wantErr error
+	}{
+		{"pending", "t1", "2024-03-10 12:05:00", models.Subscription{
+			ID: 1, Email: "a@example.com", BuildingID: 10, Street: "Карбышева ул.", Number: "54",
+			Mode: models.SubscriptionInstant, Token: "t1", ConfirmedAt: "2024-03-10 12:05:00", CreatedAt: now,
+		}, nil},
+		{"repeated keeps the first time", "t1", "2024-03-10 13:00:00", models.Subscription{
+			ID: 1, Email: "a@example.com", BuildingID: 10, Street: "Карбышева ул.", Number: "54",
+			Mode: models.SubscriptionInstant, Token: "t1", ConfirmedAt: "2024-03-10 12:05:00", CreatedAt: now,
+		}, nil},
+		{"unknown token", "t404", now, models.Subscription{}, storage.ErrSubscriptionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ConfirmSubscription(tt.token, tt.at)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	s := sqlitetest.Fixture(t)
+	_, err := s.SaveSubscription(subscription("a@example.com", 10, models.SubscriptionInstant, "t1"))
+	check(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"existing", "t1", nil},
+		{"already deleted", "t1", storage.ErrSubscriptionNotFound},
+		{"unknown token", "t404", storage.ErrSubscriptionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.DeleteSubscription(tt.token)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+		})
+	}
+}
+
+// withSubscriptions saves confirmed instant t1 for building 10, pending instant
+// t2 for 11, confirmed digests d1 for 12 and d2 for 10, d2 got a digest at 08:30
+func withSubscriptions(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := sqlitetest.Fixture(t)
+	for _, sub := range []models.Subscription{
+		subscription("a@example.com", 10, models.SubscriptionInstant, "t1"),
+		subscription("b@example.com", 11, models.SubscriptionInstant, "t2"),
+		subscription("c@example.com", 12, models.SubscriptionDigest, "d1"),
+		subscription("d@example.com", 10, models.SubscriptionDigest, "d2"),
+	} {
+		_, err := s.SaveSubscription(sub)
+		check(t, err)
+	}
+	for _, token := range []string{"t1", "d1", "d2"} {
+		_, err := s.ConfirmSubscription(token, now)
+		check(t, err)
+	}
+	check(t, s.SetSubscriptionDigestSent(4, "2024-03-10 08:30:00"))
+
+	return s
+}
+
+func TestGetSubscriptionsByBuildings(t *testing.T) {
+	s := withSubscriptions(t)
+
+	tests := []struct {
+		name      string
+		buildings []int64
+		mode      string
+		want      []string
+	}{
+		{"pending are left out", []int64{10, 11}, models.SubscriptionInstant, []string{"t1"}},
+		{"mode", []int64{10, 12}, models.SubscriptionDigest, []string{"d1", "d2"}},
+		{"no buildings", nil, models.SubscriptionInstant, nil},
+		{"unknown building", []int64{99}, models.SubscriptionInstant, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetSubscriptionsByBuildings(tt.buildings, tt.mode)
+			check(t, err)
+			equal(t, tokens(got), tt.want)
+		})
+	}
+}
+
+func TestGetDigestSubscriptions(t *testing.T) {
+	s := withSubscriptions(t)
+
+	tests := []struct {
+		name   string
+		since  string
+		filter models.Filter
+		want   []string
+	}{
+		{"never sent and sent before", "2024-03-10 09:00:00", models.Filter{}, []string{"d1", "d2"}},
+		{"sent since", "2024-03-10 08:00:00", models.Filter{}, []string{"d1"}},
+		{"in the city of the buildings", "2024-03-10 09:00:00", vladivostok, []string{"d1", "d2"}},
+		{"other city", "2024-03-10 09:00:00", khabarovsk, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetDigestSubscriptions(tt.since, tt.filter)
+			check(t, err)
+			equal(t, tokens(got), tt.want)
+		})
+	}
+}
+
+func TestSetSubscriptionDigestSent(t *testing.T) {
+	s := withSubscriptions(t)
+
+	check(t, s.SetSubscriptionDigestSent(3, "2024-03-10 09:00:00"))
+
+	got, err := s.GetDigestSubscriptions("2024-03-10 09:00:00", models.Filter{})
+	check(t, err)
+	equal(t, tokens(got), []string{"d2"})
+	equal(t, got[0].LastDigestAt, "2024-03-10 08:30:00")
+
+	// an unknown id is not an error, the digest job only passes stored ones
+	check(t, s.SetSubscriptionDigestSent(99, now))
+}
+
+func tokens(subs []models.Subscription) []string {
+	var tokens []string
+	for _, sub := range subs {
+		tokens = append(tokens, sub.Token)
+	}
+	return tokens
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+func (s *Storage) SaveTelegramSubscription(chatID int64, buildingID int64, createdAt string) error {
+	const op = "storage.sqlite.SaveTelegramSubscription"
+
+	res, err := s.db.Exec(`
+        INSERT INTO telegram_subscriptions (chat_id, building_id, created_at)
+        VALUES (?, ?, ?)
+        ON CONFLICT (chat_id, building_id) DO NOTHING`,
+		chatID, buildingID, createdAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrSubscriptionExists
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteTelegramSubscriptions(chatID int64) (int64, error) {
+	const op = "storage.sqlite.DeleteTelegramSubscriptions"
+
+	res, err := s.db.Exec(`DELETE FROM telegram_subscriptions WHERE chat_id = ?`, chatID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected, nil
+}
+
+func (s *Storage) GetTelegramSubscriptions(chatID int64) ([]models.TelegramSubscription, error) {
+	const op = "storage.sqlite.GetTelegramSubscriptions"
+
+	subs, err := s.queryTelegramSubscriptions(`WHERE ts.chat_id = ?`, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return subs, nil
+}
+
+func (s *Storage) GetTelegramSubscriptionsByBuildings(buildingIDs []int64) ([]models.TelegramSubscription, error) {
+	const op = "storage.sqlite.GetTelegramSubscriptionsByBuildings"
+
+	if len(buildingIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, 0, len(buildingIDs))
+	for _, id := range buildingIDs {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(buildingIDs)), ",")
+
+	subs, err := s.queryTelegramSubscriptions(`WHERE ts.building_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return subs, nil
+}
+
+// SetTelegramChatCity keeps the city the chat searches in
+func (s *Storage) SetTelegramChatCity(chatID int64, city string) error {
+	const op = "storage.sqlite.SetTelegramChatCity"
+
+	res, err := s.db.Exec(`
+        INSERT INTO telegram_chats (chat_id, city_id)
+        SELECT ?, id FROM cities WHERE code = ?
+        ON CONFLICT (chat_id) DO UPDATE SET city_id = excluded.city_id`,
+		chatID, city)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrCityNotFound
+	}
+
+	return nil
+}
+
+// GetTelegramChatCity returns the code of the city of the chat, empty when it has not picked one
+func (s *Storage) GetTelegramChatCity(chatID int64) (string, error) {
+	const op = "storage.sqlite.GetTelegramChatCity"
+
+	var city string
+	err := s.db.QueryRow(`
+        SELECT c.code FROM telegram_chats tc
+        JOIN cities c ON c.id = tc.city_id
+        WHERE tc.chat_id = ?`, chatID).Scan(&city)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return city, nil
+}
+
+func (s *Storage) queryTelegramSubscriptions(where string, args ...any) ([]models.TelegramSubscription, error) {
+	rows, err := s.db.Query(`
+        SELECT ts.chat_id, bg.id, s.name, bg.number
+        FROM telegram_subscriptions ts
+        JOIN buildings bg ON ts.building_id = bg.id
+        JOIN streets s ON bg.street_id = s.id
+        `+where+`
+        ORDER BY ts.chat_id, s.name, bg.number`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.TelegramSubscription
+	for rows.Next() {
+		var sub models.TelegramSubscription
+
+		err := rows.Scan(&sub.ChatID, &sub.Address.BuildingID, &sub.Address.Street, &sub.Address.Number)
+		if err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
@@ -0,0 +1,122 @@
+package sqlite_test
+
+import (
+	"fmt"
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+// withChats subscribes chat 100 to buildings 12 and 10, chat 200 to 12
+func withChats(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := sqlitetest.Fixture(t)
+	check(t, s.SaveTelegramSubscription(100, 12, now))
+	check(t, s.SaveTelegramSubscription(100, 10, now))
+	check(t, s.SaveTelegramSubscription(200, 12, now))
+
+	return s
+}
+
+func TestSaveTelegramSubscription(t *testing.T) {
+	s := withChats(t)
+
+	tests := []struct {
+		name     string
+		chat     int64
+		building int64
+		wantErr  error
+	}{
+		{"new", 100, 11, nil},
+		{"repeated", 100, 12, storage.ErrSubscriptionExists},
+		{"other chat", 300, 12, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.SaveTelegramSubscription(tt.chat, tt.building, now)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+		})
+	}
+}
+
+func TestGetTelegramSubscriptions(t *testing.T) {
+	s := withChats(t)
+
+	tests := []struct {
+		name string
+		chat int64
+		want []string
+	}{
+		{"sorted by address", 100, []string{"100 Карбышева ул. 54", "100 Светланская ул. 1"}},
+		{"one", 200, []string{"200 Светланская ул. 1"}},
+		{"unknown chat", 300, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetTelegramSubscriptions(tt.chat)
+			check(t, err)
+			equal(t, chats(got), tt.want)
+		})
+	}
+}
+
+func TestGetTelegramSubscriptionsByBuildings(t *testing.T) {
+	s := withChats(t)
+
+	tests := []struct {
+		name      string
+		buildings []int64
+		want      []string
+	}{
+		{"by chat", []int64{12}, []string{"100 Светланская ул. 1", "200 Светланская ул. 1"}},
+		{"several", []int64{10, 12}, []string{"100 Карбышева ул. 54", "100 Светланская ул. 1", "200 Светланская ул. 1"}},
+		{"no buildings", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetTelegramSubscriptionsByBuildings(tt.buildings)
+			check(t, err)
+			equal(t, chats(got), tt.want)
+		})
+	}
+}
+
+func TestDeleteTelegramSubscriptions(t *testing.T) {
+	s := withChats(t)
+
+	tests := []struct {
+		name string
+		chat int64
+		want int64
+	}{
+		{"every building of the chat", 100, 2},
+		{"already deleted", 100, 0},
+		{"unknown chat", 300, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.DeleteTelegramSubscriptions(tt.chat)
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+func chats(subs []models.TelegramSubscription) []string {
+	var keys []string
+	for _, sub := range subs {
+		keys = append(keys, fmt.Sprintf("%d %s %s", sub.ChatID, sub.Address.Street, sub.Address.Number))
+	}
+	return keys
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"fmt"
+)
+
+// DataVersion returns the counter raised by the triggers of the source tables
+// together with PRAGMA schema_version. A loader that drops and creates the
+// tables again takes the triggers with them, a new schema version shows that
+// and puts them back.
+func (s *Storage) DataVersion() (string, error) {
+	const op = "storage.sqlite.DataVersion"
+
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	var schemaVersion int64
+	if err := s.db.QueryRow(`PRAGMA schema_version`).Scan(&schemaVersion); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if schemaVersion != s.schemaVersion {
+		if err := addVersionTriggers(s.db); err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		if err := s.db.QueryRow(`PRAGMA schema_version`).Scan(&schemaVersion); err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		s.schemaVersion = schemaVersion
+	}
+
+	var version int64
+	if err := s.db.QueryRow(`SELECT version FROM data_version WHERE id = 1`).Scan(&version); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Sprintf("%d-%d", schemaVersion, version), nil
+}
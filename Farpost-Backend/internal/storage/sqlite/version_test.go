@@ -0,0 +1,100 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+	"vlru-prsch/internal/storage/storagetest"
+)
+
+func TestDataVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		open        func(t testing.TB, seeds ...[]string) *sqlite.Storage
+		change      func(s *sqlite.Storage) error
+		wantChanged bool
+	}{
+		{"read", sqlitetest.OpenFile, func(s *sqlite.Storage) error {
+			_, err := s.GetBlackouts(now)
+			return err
+		}, false},
+		{"write", sqlitetest.OpenFile, func(s *sqlite.Storage) error {
+			return s.OverrideBlackoutKind("b1", models.KindEmergency)
+		}, true},
+		{"write in memory", sqlitetest.Open, func(s *sqlite.Storage) error {
+			return s.OverrideBlackoutKind("b1", models.KindEmergency)
+		}, true},
+		{"write to a service table", sqlitetest.Open, func(s *sqlite.Storage) error {
+			return s.SyncServiceTypes([]models.ServiceType{{Code: "gas", NameRu: "газ", NameEn: "gas"}})
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.open(t, storagetest.Seed)
+
+			before, err := s.DataVersion()
+			check(t, err)
+
+			check(t, tt.change(s))
+
+			after, err := s.DataVersion()
+			check(t, err)
+			equal(t, after != before, tt.wantChanged)
+		})
+	}
+}
+
+// TestDataVersionOfLoader writes the way the loader does, over a connection
+// of its own, also after it has dropped and created a source table again
+func TestDataVersionOfLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	loader, err := sql.Open("sqlite3", path)
+	check(t, err)
+	defer loader.Close()
+
+	load := func(stmts ...string) {
+		t.Helper()
+		for _, stmt := range stmts {
+			if _, err := loader.Exec(stmt); err != nil {
+				t.Fatalf("%s: %v", stmt, err)
+			}
+		}
+	}
+
+	load(sqlitetest.Schema...)
+
+	s, err := sqlite.New(path)
+	check(t, err)
+	defer s.Close()
+
+	insert := `INSERT INTO blackouts (id, start_date, end_date, description, type, initiator_name, source)
+		VALUES ('b1', '2024-03-10 09:00:00', NULL, '', 'heat', '', NULL)`
+
+	steps := []struct {
+		name  string
+		stmts []string
+	}{
+		{"insert", []string{insert}},
+		{"drop and create", []string{`DROP TABLE blackouts`, sqlitetest.Schema[2]}},
+		{"insert into the new table", []string{insert}},
+	}
+
+	version, err := s.DataVersion()
+	check(t, err)
+
+	for _, step := range steps {
+		load(step.stmts...)
+
+		next, err := s.DataVersion()
+		check(t, err)
+		if next == version {
+			t.Errorf("%s: version is still %s", step.name, next)
+		}
+		version = next
+	}
+}
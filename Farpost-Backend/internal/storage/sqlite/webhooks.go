@@ -0,0 +1,347 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+// SaveWebhook stores a webhook of the city coded by webhook.City,
+// an unknown city falls back to the default one
+func (s *Storage) SaveWebhook(webhook models.Webhook) (int64, error) {
+	const op = "storage.sqlite.SaveWebhook"
+
+	filters, err := marshalFilters(webhook)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.Exec(`
+        INSERT INTO webhooks (url, secret, buildings, streets, types, organizations, created_at, city_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, (SELECT id FROM cities WHERE code = ?))`,
+		webhook.URL, webhook.Secret, filters[0], filters[1], filters[2], filters[3], webhook.CreatedAt, webhook.City)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// webhookColumns are the columns scanWebhook reads
+var webhookColumns = `id, url, secret, buildings, streets, types, organizations, created_at, ` + cityCode("city_id")
+
+// GetWebhooks returns the webhooks of the filter city, of every city for an empty one
+func (s *Storage) GetWebhooks(filter models.Filter) ([]models.Webhook, error) {
+	const op = "storage.sqlite.GetWebhooks"
+
+	cond, args := cityFilter("city_id", filter)
+
+	rows, err := s.db.Query(`
+        SELECT `+webhookColumns+`
+        FROM webhooks
+        WHERE TRUE`+cond+`
+        ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns a webhook of the filter city by id
+func (s *Storage) GetWebhook(id int64, filter models.Filter) (models.Webhook, error) {
+	const op = "storage.sqlite.GetWebhook"
+
+	cond, args := cityFilter("city_id", filter)
+
+	row := s.db.QueryRow(`
+        SELECT `+webhookColumns+`
+        FROM webhooks
+        WHERE id = ?`+cond,
+		append([]any{id}, args...)...)
+
+	webhook, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Webhook{}, storage.ErrWebhookNotFound
+	}
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook of the filter city with its deliveries
+func (s *Storage) DeleteWebhook(id int64, filter models.Filter) error {
+	const op = "storage.sqlite.DeleteWebhook"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	cond, args := cityFilter("city_id", filter)
+
+	res, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`+cond, append([]any{id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrWebhookNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveWebhookDelivery(delivery models.WebhookDelivery) (int64, error) {
+	const op = "storage.sqlite.SaveWebhookDelivery"
+
+	res, err := s.db.Exec(`
+        INSERT INTO webhook_deliveries (webhook_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		delivery.WebhookID, delivery.EventType, delivery.Payload, delivery.Status,
+		delivery.Attempts, delivery.NextAttemptAt, delivery.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) UpdateWebhookDelivery(delivery models.WebhookDelivery) error {
+	const op = "storage.sqlite.UpdateWebhookDelivery"
+
+	var deliveredAt sql.NullString
+	if delivery.DeliveredAt != "" {
+		deliveredAt = sql.NullString{String: delivery.DeliveredAt, Valid: true}
+	}
+
+	_, err := s.db.Exec(`
+        UPDATE webhook_deliveries
+        SET status = ?, attempts = ?, next_attempt_at = ?,
+            last_status_code = ?, last_error = ?, delivered_at = ?
+        WHERE id = ?`,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, deliveredAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetDueWebhookDeliveries(currentTime string, limit int) ([]models.WebhookDelivery, error) {
+	const op = "storage.sqlite.GetDueWebhookDeliveries"
+
+	rows, err := s.db.Query(`
+        SELECT id, webhook_id, event_type, payload, status, attempts, next_attempt_at,
+               last_status_code, last_error, created_at, delivered_at
+        FROM webhook_deliveries
+        WHERE status = ? AND next_attempt_at <= ?
+        ORDER BY next_attempt_at, id
+        LIMIT ?`,
+		models.DeliveryPending, currentTime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Storage) GetWebhookDeliveries(webhookID int64, limit int) ([]models.WebhookDelivery, error) {
+	const op = "storage.sqlite.GetWebhookDeliveries"
+
+	rows, err := s.db.Query(`
+        SELECT id, webhook_id, event_type, payload, status, attempts, next_attempt_at,
+               last_status_code, last_error, created_at, delivered_at
+        FROM webhook_deliveries
+        WHERE webhook_id = ?
+        ORDER BY id DESC
+        LIMIT ?`,
+		webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Storage) GetWebhookDelivery(id int64) (models.WebhookDelivery, error) {
+	const op = "storage.sqlite.GetWebhookDelivery"
+
+	rows, err := s.db.Query(`
+        SELECT id, webhook_id, event_type, payload, status, attempts, next_attempt_at,
+               last_status_code, last_error, created_at, delivered_at
+        FROM webhook_deliveries
+        WHERE id = ?`,
+		id)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(deliveries) == 0 {
+		return models.WebhookDelivery{}, storage.ErrDeliveryNotFound
+	}
+
+	return deliveries[0], nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhook(row scanner) (models.Webhook, error) {
+	var webhook models.Webhook
+	var buildings, streets, types, organizations string
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		&buildings,
+		&streets,
+		&types,
+		&organizations,
+		&webhook.CreatedAt,
+		&webhook.City,
+	)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	if err := json.Unmarshal([]byte(buildings), &webhook.Buildings); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := json.Unmarshal([]byte(streets), &webhook.Streets); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := json.Unmarshal([]byte(types), &webhook.Types); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := json.Unmarshal([]byte(organizations), &webhook.Organizations); err != nil {
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var deliveredAt sql.NullString
+
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&deliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if deliveredAt.Valid {
+			delivery.DeliveredAt = deliveredAt.String
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func marshalFilters(webhook models.Webhook) ([4]string, error) {
+	var filters [4]string
+
+	for i, filter := range []any{
+		nonNil(webhook.Buildings),
+		nonNil(webhook.Streets),
+		nonNil(webhook.Types),
+		nonNil(webhook.Organizations),
+	} {
+		b, err := json.Marshal(filter)
+		if err != nil {
+			return filters, err
+		}
+		filters[i] = string(b)
+	}
+
+	return filters, nil
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
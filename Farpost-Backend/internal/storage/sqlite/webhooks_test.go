@@ -0,0 +1,275 @@
+package sqlite_test
+
+import (
+	"testing"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/storage/sqlite"
+	"vlru-prsch/internal/storage/sqlite/sqlitetest"
+)
+
+func TestSaveWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook models.Webhook
+		want    models.Webhook
+	}{
+		{"filters", models.Webhook{
+			URL: "https://example.com/hook", Secret: "s", Buildings: []int64{10, 11}, Streets: []string{"Карбышева ул."},
+			Types: []string{"hot_water"}, Organizations: []string{"Водоканал"}, CreatedAt: now,
+		}, models.Webhook{
+			ID: 1, URL: "https://example.com/hook", Secret: "s", Buildings: []int64{10, 11}, Streets: []string{"Карбышева ул."},
+			Types: []string{"hot_water"}, Organizations: []string{"Водоканал"}, CreatedAt: now, City: "vladivostok",
+		}},
+		{"city", models.Webhook{URL: "https://example.com/city", Secret: "s", CreatedAt: now, City: "khabarovsk"}, models.Webhook{
+			ID: 1, URL: "https://example.com/city", Secret: "s", Buildings: []int64{}, Streets: []string{},
+			Types: []string{}, Organizations: []string{}, CreatedAt: now, City: "khabarovsk",
+		}},
+		{"missing filters read as empty", models.Webhook{URL: "https://example.com/all", Secret: "s", CreatedAt: now}, models.Webhook{
+			ID: 1, URL: "https://example.com/all", Secret: "s", Buildings: []int64{}, Streets: []string{},
+			Types: []string{}, Organizations: []string{}, CreatedAt: now, City: "vladivostok",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sqlitetest.Fixture(t)
+
+			id, err := s.SaveWebhook(tt.webhook)
+			check(t, err)
+
+			got, err := s.GetWebhook(id, models.Filter{})
+			check(t, err)
+			equal(t, got, tt.want)
+		})
+	}
+}
+
+// withWebhooks saves webhook 1 of the default city and webhook 2 of Khabarovsk,
+// webhook 1 has deliveries 1 (due at 12:00), 2 (due at 11:00) and 3 (succeeded),
+// webhook 2 has delivery 4 (due at 13:00)
+func withWebhooks(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s := sqlitetest.Fixture(t)
+	for _, webhook := range []models.Webhook{
+		{URL: "https://example.com/a", Secret: "s", CreatedAt: now},
+		{URL: "https://example.com/b", Secret: "s", CreatedAt: now, City: "khabarovsk"},
+	} {
+		_, err := s.SaveWebhook(webhook)
+		check(t, err)
+	}
+
+	for _, d := range []models.WebhookDelivery{
+		{WebhookID: 1, EventType: "blackout.started", Payload: `{"id":"b1"}`, Status: models.DeliveryPending, NextAttemptAt: "2024-03-10 12:00:00"},
+		{WebhookID: 1, EventType: "blackout.started", Payload: `{"id":"b2"}`, Status: models.DeliveryPending, NextAttemptAt: "2024-03-10 11:00:00"},
+		{WebhookID: 1, EventType: "blackout.ended", Payload: `{"id":"b4"}`, Status: models.DeliverySucceeded, NextAttemptAt: "2024-03-10 10:00:00"},
+		{WebhookID: 2, EventType: "blackout.started", Payload: `{"id":"b5"}`, Status: models.DeliveryPending, NextAttemptAt: "2024-03-10 13:00:00"},
+	} {
+		d.CreatedAt = now
+		_, err := s.SaveWebhookDelivery(d)
+		check(t, err)
+	}
+
+	return s
+}
+
+func TestGetWebhooks(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		got, err := sqlitetest.Fixture(t).GetWebhooks(models.Filter{})
+		check(t, err)
+		equal(t, got, nil)
+	})
+
+	t.Run("by id", func(t *testing.T) {
+		got, err := withWebhooks(t).GetWebhooks(models.Filter{})
+		check(t, err)
+		equal(t, len(got), 2)
+		equal(t, got[0].URL, "https://example.com/a")
+		equal(t, got[1].URL, "https://example.com/b")
+	})
+
+	t.Run("default city keeps the webhooks without a city", func(t *testing.T) {
+		got, err := withWebhooks(t).GetWebhooks(vladivostok)
+		check(t, err)
+		equal(t, len(got), 1)
+		equal(t, got[0].URL, "https://example.com/a")
+	})
+}
+
+func TestGetWebhook(t *testing.T) {
+	s := withWebhooks(t)
+
+	tests := []struct {
+		name    string
+		id      int64
+		filter  models.Filter
+		want    string
+		wantErr error
+	}{
+		{"found", 2, models.Filter{}, "https://example.com/b", nil},
+		{"found in its city", 2, khabarovsk, "https://example.com/b", nil},
+		{"other city", 2, vladivostok, "", storage.ErrWebhookNotFound},
+		{"unknown", 99, models.Filter{}, "", storage.ErrWebhookNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetWebhook(tt.id, tt.filter)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+			equal(t, got.URL, tt.want)
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name          string
+		id            int64
+		filter        models.Filter
+		wantErr       error
+		wantWebhooks  int
+		wantDelivered []int64
+	}{
+		{"takes its deliveries along", 1, models.Filter{}, nil, 1, []int64{4}},
+		{"other city", 1, khabarovsk, storage.ErrWebhookNotFound, 2, []int64{2, 1, 4}},
+		{"unknown", 99, models.Filter{}, storage.ErrWebhookNotFound, 2, []int64{2, 1, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := withWebhooks(t)
+
+			err := s.DeleteWebhook(tt.id, tt.filter)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+			} else {
+				check(t, err)
+			}
+
+			webhooks, err := s.GetWebhooks(models.Filter{})
+			check(t, err)
+			equal(t, len(webhooks), tt.wantWebhooks)
+
+			due, err := s.GetDueWebhookDeliveries("2024-03-10 23:59:59", 10)
+			check(t, err)
+			equal(t, deliveryIDs(due), tt.wantDelivered)
+		})
+	}
+}
+
+func TestGetDueWebhookDeliveries(t *testing.T) {
+	s := withWebhooks(t)
+
+	tests := []struct {
+		name  string
+		at    string
+		limit int
+		want  []int64
+	}{
+		{"pending by next attempt", "2024-03-10 12:00:00", 10, []int64{2, 1}},
+		{"limit", "2024-03-10 23:59:59", 2, []int64{2, 1}},
+		{"every webhook", "2024-03-10 23:59:59", 10, []int64{2, 1, 4}},
+		{"none due", "2024-03-10 10:00:00", 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetDueWebhookDeliveries(tt.at, tt.limit)
+			check(t, err)
+			equal(t, deliveryIDs(got), tt.want)
+		})
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	s := withWebhooks(t)
+
+	tests := []struct {
+		name    string
+		webhook int64
+		limit   int
+		want    []int64
+	}{
+		{"newest first, any status", 1, 10, []int64{3, 2, 1}},
+		{"limit", 1, 1, []int64{3}},
+		{"other webhook", 2, 10, []int64{4}},
+		{"unknown webhook", 99, 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetWebhookDeliveries(tt.webhook, tt.limit)
+			check(t, err)
+			equal(t, deliveryIDs(got), tt.want)
+		})
+	}
+}
+
+func TestUpdateWebhookDelivery(t *testing.T) {
+	succeeded := models.WebhookDelivery{
+		ID: 1, WebhookID: 1, EventType: "blackout.started", Payload: `{"id":"b1"}`, Status: models.DeliverySucceeded,
+		Attempts: 1, NextAttemptAt: "2024-03-10 12:00:00", LastStatusCode: 200, CreatedAt: now, DeliveredAt: "2024-03-10 12:00:01",
+	}
+	retried := models.WebhookDelivery{
+		ID: 1, WebhookID: 1, EventType: "blackout.started", Payload: `{"id":"b1"}`, Status: models.DeliveryPending,
+		Attempts: 2, NextAttemptAt: "2024-03-10 12:05:00", LastStatusCode: 502, LastError: "bad gateway", CreatedAt: now,
+	}
+
+	tests := []struct {
+		name     string
+		delivery models.WebhookDelivery
+	}{
+		{"delivered", succeeded},
+		{"retry keeps delivered_at NULL", retried},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := withWebhooks(t)
+			check(t, s.UpdateWebhookDelivery(tt.delivery))
+
+			got, err := s.GetWebhookDelivery(tt.delivery.ID)
+			check(t, err)
+			equal(t, got, tt.delivery)
+		})
+	}
+}
+
+func TestGetWebhookDelivery(t *testing.T) {
+	s := withWebhooks(t)
+
+	tests := []struct {
+		name    string
+		id      int64
+		want    string
+		wantErr error
+	}{
+		{"payload byte for byte", 3, `{"id":"b4"}`, nil},
+		{"unknown", 99, "", storage.ErrDeliveryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetWebhookDelivery(tt.id)
+			if tt.wantErr != nil {
+				is(t, err, tt.wantErr)
+				return
+			}
+			check(t, err)
+			equal(t, got.Payload, tt.want)
+		})
+	}
+}
+
+func deliveryIDs(deliveries []models.WebhookDelivery) []int64 {
+	var ids []int64
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"errors"
+	"vlru-prsch/internal/models"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrBlackoutNotFound = errors.New("blackout not found")
+
+	ErrBuildingNotFound     = errors.New("building not found")
+	ErrSubscriptionExists   = errors.New("subscription already exists")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+
+	ErrCityNotFound = errors.New("city not found")
+)
+
+// Storage is the contract every storage backend implements. Handlers and
+// background jobs keep depending on their own small interfaces, this one is
+// what the backends are checked against and what the commands are wired with.
+//
+// Times are passed and returned as "2006-01-02 15:04:05" strings.
+type Storage interface {
+	// streets and buildings
+	FindStreets(substr string, filter models.Filter) ([]string, error)
+	FindBuilding(street string, number string, filter models.Filter) (models.Address, error)
+	GetStreetBuildings(street string, filter models.Filter) ([]models.Address, error)
+	GetBuildingsCount(filter models.Filter) (int64, error)
+	GetStreetsCount() (int, error)
+
+	// blackouts
+	GetBlackouts(currentTime string) ([]models.Blackout, error)
+	GetFilteredBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error)
+	GetAllBlackouts(filter models.Filter) ([]models.Blackout, error)
+	GetBlackout(id string, filter models.Filter) (models.Blackout, error)
+	GetUpcomingBlackouts(currentTime string, filter models.Filter) ([]models.Blackout, error)
+	GetBuildingBlackouts(buildingID int64, from string, to string) ([]models.Blackout, error)
+	GetBlackoutAddresses(blackoutID string) ([]models.Address, error)
+	GetBlackoutsWithBuildingsCount(targetDate string, filter models.Filter) ([]models.BlackoutInfo, error)
+	GetBlackoutsWithBuildings(from string, to string, filter models.Filter) ([]models.BlackoutWithBuildings, error)
+	GetOutageHistory() ([]models.OutageRecord, error)
+
+	// aggregates
+	GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error)
+	GetBuildingsCountByOrganization(blackoutType string, currentTime string, filter models.Filter) (map[string]int64, error)
+	GetLastBlackoutTimeByType(blackoutType string, currentTime string, filter models.Filter) (string, error)
+	GetComplaintsLastHour(endTime string, filter models.Filter) ([]models.ComplaintData, error)
+	GetComplaintsLastDay(endTime string, filter models.Filter) ([]models.ComplaintData, error)
+	GetComplaintsLastWeek(endTime string, filter models.Filter) ([]models.ComplaintData, error)
+	GetComplaintsLastMonth(endTime string, filter models.Filter) ([]models.ComplaintData, error)
+	GetHourlyCounts(from string, to string, filter models.Filter) ([]models.HourlyCount, error)
+
+	// organizations
+	GetOrganizations(currentTime string, filter models.Filter) ([]string, error)
+	GetBuildingsCountByOrgName(name string, currentTime string, filter models.Filter) (int64, error)
+	GetLastAddressByOrgName(name string, currentTime string, filter models.Filter) (string, string, error)
+
+	// cities, districts and service types
+	SyncCities(cities []models.City, defaultCode string) error
+	GetDistricts(filter models.Filter) ([]models.District, error)
+	ImportDistricts(city string, mappings []models.DistrictMapping) (int64, []models.DistrictMapping, error)
+	GetDistrictStats(currentTime string, filter models.Filter) ([]models.DistrictStats, error)
+	GetServiceTypes() (models.ServiceTypes, error)
+	SyncServiceTypes(types []models.ServiceType) error
+
+	// coordinates
+	ImportBuildingLocations(city string, locations []models.BuildingLocation) (int64, []models.BuildingLocation, error)
+	GetBuildingLocations(filter models.Filter) ([]models.LocatedBuilding, error)
+	GetMapBlackouts(currentTime string, bbox *models.BBox, filter models.Filter) ([]models.MapBlackout, error)
+	GetBuildingsBlackouts(buildingIDs []int64, currentTime string) ([]models.MapBlackout, error)
+
+	// findings of the background jobs
+	ReplaceHotspots(hotspots []models.Hotspot, detectedAt string) error
+	GetHotspots(scope string, blackoutType string, limit int, filter models.Filter) ([]models.Hotspot, error)
+	SaveAnomaly(anomaly models.Anomaly) (bool, error)
+	GetAnomalies(blackoutType string, from string, to string, limit int, filter models.Filter) ([]models.Anomaly, error)
+
+	// kinds
+	GetUnclassifiedBlackouts(limit int) ([]models.Blackout, error)
+	SetBlackoutKinds(kinds map[string]string) error
+	OverrideBlackoutKind(id string, kind string) error
+	ResetBlackoutKinds(id string) (int64, error)
+
+	// revisions
+	GetLatestRevisions(filter models.Filter) (map[string]models.Revision, error)
+	GetRevisions(blackoutID string, filter models.Filter) ([]models.Revision, error)
+	SaveRevisions(revisions []models.Revision) error
+	ReviseBlackout(revision models.Revision) error
+
+	// email subscriptions
+	SaveSubscription(sub models.Subscription) (int64, error)
+	ConfirmSubscription(token string, confirmedAt string) (models.Subscription, error)
+	DeleteSubscription(token string) error
+	GetSubscriptionsByBuildings(buildingIDs []int64, mode string) ([]models.Subscription, error)
+	GetDigestSubscriptions(since string, filter models.Filter) ([]models.Subscription, error)
+	SetSubscriptionDigestSent(id int64, sentAt string) error
+
+	// telegram subscriptions
+	SaveTelegramSubscription(chatID int64, buildingID int64, createdAt string) error
+	DeleteTelegramSubscriptions(chatID int64) (int64, error)
+	GetTelegramSubscriptions(chatID int64) ([]models.TelegramSubscription, error)
+	GetTelegramSubscriptionsByBuildings(buildingIDs []int64) ([]models.TelegramSubscription, error)
+	SetTelegramChatCity(chatID int64, city string) error
+	GetTelegramChatCity(chatID int64) (string, error)
+
+	// DataVersion changes whenever the stored data changes, caches compare it
+	DataVersion() (string, error)
+
+	// webhooks
+	SaveWebhook(webhook models.Webhook) (int64, error)
+	GetWebhooks(filter models.Filter) ([]models.Webhook, error)
+	GetWebhook(id int64, filter models.Filter) (models.Webhook, error)
+	DeleteWebhook(id int64, filter models.Filter) error
+	SaveWebhookDelivery(delivery models.WebhookDelivery) (int64, error)
+	UpdateWebhookDelivery(delivery models.WebhookDelivery) error
+	GetDueWebhookDeliveries(currentTime string, limit int) ([]models.WebhookDelivery, error)
+	GetWebhookDeliveries(webhookID int64, limit int) ([]models.WebhookDelivery, error)
+	GetWebhookDelivery(id int64) (models.WebhookDelivery, error)
+}
@@ -0,0 +1,887 @@
+// Package storagetest is the conformance suite of the storage backends. Every
+// backend runs the same cases against the same seed data, so the handlers see
+// the same answers whichever backend is configured.
+package storagetest
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"vlru-prsch/internal/lib/geo"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/spatial"
+	"vlru-prsch/internal/storage"
+)
+
+// Now is the moment the cases query the seed at
+const Now = "2024-03-10 12:00:00"
+
+// Seed fills a migrated database. The statements are plain SQL accepted by
+// SQLite and PostgreSQL alike.
+//
+// Vladivostok is the default city, its streets and blackouts have no city set.
+// b1 and b2 are active at Now, b3 is active in Khabarovsk, b4 is over and b5
+// has not started yet. Building 13 is fake.
+var Seed = []string{
+	`INSERT INTO cities (code, name, timezone, buildings_total, is_default) VALUES
+		('vladivostok', 'Владивосток', 'Asia/Vladivostok', 0, TRUE),
+		('khabarovsk', 'Хабаровск', 'Asia/Vladivostok', 0, FALSE)`,
+	`INSERT INTO streets (id, name) VALUES
+		(1, 'Карбышева ул.'),
+		(2, 'Светланская ул.')`,
+	`INSERT INTO streets (id, name, city_id)
+		SELECT 3, 'Ленина ул.', id FROM cities WHERE code = 'khabarovsk'`,
+	`INSERT INTO buildings (id, street_id, number, is_fake) VALUES
+		(10, 1, '54', FALSE),
+		(11, 1, '56', FALSE),
+		(12, 2, '1', FALSE),
+		(13, 2, '', TRUE),
+		(20, 3, '5', FALSE)`,
+	`INSERT INTO blackouts (id, start_date, end_date, description, type, initiator_name, source) VALUES
+		('b1', '2024-03-10 09:00:00', '2024-03-10 18:00:00', 'Плановые работы', 'hot_water', 'Водоканал', 'vl.ru'),
+		('b2', '2024-03-10 11:30:00', '2024-03-11 12:00:00', 'Авария на линии', 'electricity', 'Дальэнерго', 'vl.ru'),
+		('b4', '2024-02-01 08:00:00', '2024-02-01 10:00:00', 'Промывка', 'cold_water', 'Водоканал', 'vl.ru'),
+		('b5', '2024-03-12 09:00:00', '2024-03-12 17:00:00', 'Плановые работы', 'heat', 'Теплосеть', 'vl.ru')`,
+	`INSERT INTO blackouts (id, start_date, end_date, description, type, initiator_name, source, city_id)
+		SELECT 'b3', '2024-03-10 10:00:00', '2024-03-10 20:00:00', 'Ремонт', 'hot_water', 'Водоканал', 'vl.ru', id
+		FROM cities WHERE code = 'khabarovsk'`,
+	`INSERT INTO blackouts_buildings (blackout_id, building_id) VALUES
+		('b1', 10),
+		('b1', 11),
+		('b2', 12),
+		('b2', 13),
+		('b3', 20),
+		('b4', 10),
+		('b5', 11)`,
+}
+
+var (
+	vladivostok = models.Filter{City: "vladivostok"}
+	khabarovsk  = models.Filter{City: "khabarovsk"}
+)
+
+// Run runs the suite. open returns a migrated and seeded storage, it is
+// called once per case so the cases do not see each other's writes.
+func Run(t *testing.T, open func(t *testing.T) storage.Storage) {
+	cases := []struct {
+		name string
+		run  func(t *testing.T, s storage.Storage)
+	}{
+		{"Streets", testStreets},
+		{"Blackouts", testBlackouts},
+		{"Counts", testCounts},
+		{"Organizations", testOrganizations},
+		{"Complaints", testComplaints},
+		{"Addresses", testAddresses},
+		{"Kinds", testKinds},
+		{"Revisions", testRevisions},
+		{"Districts", testDistricts},
+		{"Locations", testLocations},
+		{"Spatial", testSpatial},
+		{"Hotspots", testHotspots},
+		{"Anomalies", testAnomalies},
+		{"ServiceTypes", testServiceTypes},
+		{"Subscriptions", testSubscriptions},
+		{"Telegram", testTelegram},
+		{"Webhooks", testWebhooks},
+		{"DataVersion", testDataVersion},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, open(t))
+		})
+	}
+}
+
+func testStreets(t *testing.T, s storage.Storage) {
+	streets, err := s.FindStreets("карб", models.Filter{})
+	check(t, err)
+	equal(t, "FindStreets", streets, []string{"Карбышева ул."})
+
+	streets, err = s.FindStreets("Лен", vladivostok)
+	check(t, err)
+	equal(t, "FindStreets in another city", len(streets), 0)
+
+	streets, err = s.FindStreets("Лен", khabarovsk)
+	check(t, err)
+	equal(t, "FindStreets in its city", streets, []string{"Ленина ул."})
+
+	count, err := s.GetStreetsCount()
+	check(t, err)
+	equal(t, "GetStreetsCount", count, 3)
+}
+
+func testBlackouts(t *testing.T, s storage.Storage) {
+	blackouts, err := s.GetBlackouts(Now)
+	check(t, err)
+	equal(t, "GetBlackouts", ids(blackouts), []string{"b1", "b2", "b3"})
+
+	blackouts, err = s.GetFilteredBlackouts(Now, vladivostok)
+	check(t, err)
+	equal(t, "GetFilteredBlackouts", ids(blackouts), []string{"b1", "b2"})
+
+	blackouts, err = s.GetFilteredBlackouts(Now, khabarovsk)
+	check(t, err)
+	equal(t, "GetFilteredBlackouts in Khabarovsk", ids(blackouts), []string{"b3"})
+
+	// a date covers the whole day
+	blackouts, err = s.GetBlackouts("2024-02-01")
+	check(t, err)
+	equal(t, "GetBlackouts of a day", ids(blackouts), []string{"b4"})
+
+	blackouts, err = s.GetUpcomingBlackouts(Now, models.Filter{})
+	check(t, err)
+	equal(t, "GetUpcomingBlackouts", ids(blackouts), []string{"b5"})
+
+	blackouts, err = s.GetAllBlackouts(models.Filter{})
+	check(t, err)
+	equal(t, "GetAllBlackouts", len(blackouts), 5)
+
+	blackouts, err = s.GetAllBlackouts(khabarovsk)
+	check(t, err)
+	equal(t, "GetAllBlackouts of a city", ids(blackouts), []string{"b3"})
+
+	blackout, err := s.GetBlackout("b2", models.Filter{})
+	check(t, err)
+	equal(t, "GetBlackout", blackout.Description, "Авария на линии")
+	equal(t, "GetBlackout end", blackout.EndDate, "2024-03-11 12:00:00")
+
+	_, err = s.GetBlackout("missing", models.Filter{})
+	is(t, "GetBlackout missing", err, storage.ErrBlackoutNotFound)
+
+	blackouts, err = s.GetBuildingBlackouts(10, "2024-01-01 00:00:00", "2024-12-31 23:59:59")
+	check(t, err)
+	equal(t, "GetBuildingBlackouts", len(blackouts), 2)
+
+	withBuildings, err := s.GetBlackoutsWithBuildings("2024-03-10 00:00:00", "2024-03-10 23:59:59", vladivostok)
+	check(t, err)
+	counts := map[string]int64{}
+	for _, b := range withBuildings {
+		counts[b.ID] = b.BuildingsCount
+	}
+	equal(t, "GetBlackoutsWithBuildings", counts, map[string]int64{"b1": 2, "b2": 1})
+
+	infos, err := s.GetBlackoutsWithBuildingsCount("2024-03-10", vladivostok)
+	check(t, err)
+	equal(t, "GetBlackoutsWithBuildingsCount", len(infos), 2)
+
+	history, err := s.GetOutageHistory()
+	check(t, err)
+	equal(t, "GetOutageHistory", len(history), 6)
+	equal(t, "GetOutageHistory oldest", history[0].BlackoutID, "b4")
+}
+
+func testCounts(t *testing.T, s storage.Storage) {
+	count, err := s.GetBuildingsCount(models.Filter{})
+	check(t, err)
+	equal(t, "GetBuildingsCount", count, int64(4))
+
+	count, err = s.GetBuildingsCount(khabarovsk)
+	check(t, err)
+	equal(t, "GetBuildingsCount in Khabarovsk", count, int64(1))
+
+	count, err = s.GetBuildingsCountByBlackoutType("hot_water", Now, models.Filter{})
+	check(t, err)
+	equal(t, "GetBuildingsCountByBlackoutType", count, int64(3))
+
+	count, err = s.GetBuildingsCountByBlackoutType("hot_water", Now, vladivostok)
+	check(t, err)
+	equal(t, "GetBuildingsCountByBlackoutType in Vladivostok", count, int64(2))
+
+	// fake buildings are not counted
+	count, err = s.GetBuildingsCountByBlackoutType("electricity", Now, models.Filter{})
+	check(t, err)
+	equal(t, "GetBuildingsCountByBlackoutType fake", count, int64(1))
+
+	last, err := s.GetLastBlackoutTimeByType("hot_water", Now, vladivostok)
+	check(t, err)
+	equal(t, "GetLastBlackoutTimeByType", last, "2024-03-10 09:00:00")
+
+	hourly, err := s.GetHourlyCounts("2024-03-10 00:00:00", "2024-03-10 23:59:59", models.Filter{})
+	check(t, err)
+	equal(t, "GetHourlyCounts", hourly, []models.HourlyCount{
+		{Hour: "2024-03-10 09:00:00", Type: "hot_water", Count: 1},
+		{Hour: "2024-03-10 10:00:00", Type: "hot_water", Count: 1},
+		{Hour: "2024-03-10 11:00:00", Type: "electricity", Count: 1},
+	})
+}
+
+func testOrganizations(t *testing.T, s storage.Storage) {
+	byOrg, err := s.GetBuildingsCountByOrganization("hot_water", Now, models.Filter{})
+	check(t, err)
+	equal(t, "GetBuildingsCountByOrganization", byOrg, map[string]int64{"Водоканал": 3})
+
+	orgs, err := s.GetOrganizations(Now, models.Filter{})
+	check(t, err)
+	slices.Sort(orgs)
+	equal(t, "GetOrganizations", orgs, []string{"Водоканал", "Дальэнерго"})
+
+	count, err := s.GetBuildingsCountByOrgName("Дальэнерго", Now, models.Filter{})
+	check(t, err)
+	equal(t, "GetBuildingsCountByOrgName", count, int64(1))
+
+	lastTime, address, err := s.GetLastAddressByOrgName("Дальэнерго", Now, models.Filter{})
+	check(t, err)
+	equal(t, "GetLastAddressByOrgName time", lastTime, "2024-03-10 11:30:00")
+	equal(t, "GetLastAddressByOrgName", address, "Светланская ул. 1")
+}
+
+func testComplaints(t *testing.T, s storage.Storage) {
+	for name, get := range map[string]func(string, models.Filter) ([]models.ComplaintData, error){
+		"GetComplaintsLastHour":  s.GetComplaintsLastHour,
+		"GetComplaintsLastDay":   s.GetComplaintsLastDay,
+		"GetComplaintsLastWeek":  s.GetComplaintsLastWeek,
+		"GetComplaintsLastMonth": s.GetComplaintsLastMonth,
+	} {
+		all, err := get(Now, models.Filter{})
+		check(t, err)
+		scoped, err := get(Now, khabarovsk)
+		check(t, err)
+		if len(all) == 0 || len(all) != len(scoped) {
+			t.Errorf("%s: got %d and %d buckets", name, len(all), len(scoped))
+		}
+	}
+
+	day, err := s.GetComplaintsLastDay(Now, vladivostok)
+	check(t, err)
+	equal(t, "GetComplaintsLastDay", complaints(day), 2)
+
+	day, err = s.GetComplaintsLastDay(Now, khabarovsk)
+	check(t, err)
+	equal(t, "GetComplaintsLastDay in Khabarovsk", complaints(day), 1)
+
+	// both backends label the buckets alike and put a blackout in the bucket of its start
+	windows := []struct {
+		name        string
+		get         func(string, models.Filter) ([]models.ComplaintData, error)
+		end         string
+		first, last string
+		bucket      string
+		want        map[string]int
+	}{
+		{"GetComplaintsLastHour", s.GetComplaintsLastHour, Now, "11:00", "11:59", "11:30", map[string]int{"electricity": 1}},
+		{"GetComplaintsLastWeek", s.GetComplaintsLastWeek, "2024-03-11 00:00:00", "04.03", "10.03", "10.03", map[string]int{"hot_water": 2, "electricity": 1}},
+		{"GetComplaintsLastMonth", s.GetComplaintsLastMonth, "2024-03-02 00:00:00", "01.02", "01.03", "01.02", map[string]int{"cold_water": 1}},
+	}
+
+	for _, w := range windows {
+		buckets, err := w.get(w.end, models.Filter{})
+		check(t, err)
+
+		equal(t, w.name+" first", buckets[0].Time, w.first)
+		equal(t, w.name+" last", buckets[len(buckets)-1].Time, w.last)
+
+		got := map[string]int{}
+		for _, bucket := range buckets {
+			for code, count := range bucket.Counts {
+				if count > 0 {
+					if bucket.Time != w.bucket {
+						t.Errorf("%s: %d of %s in %s, want them in %s", w.name, count, code, bucket.Time, w.bucket)
+					}
+					got[code] += count
+				}
+			}
+		}
+		equal(t, w.name, got, w.want)
+	}
+}
+
+func testAddresses(t *testing.T, s storage.Storage) {
+	addresses, err := s.GetBlackoutAddresses("b1")
+	check(t, err)
+	equal(t, "GetBlackoutAddresses", addresses, []models.Address{
+		{BuildingID: 10, Street: "Карбышева ул.", Number: "54"},
+		{BuildingID: 11, Street: "Карбышева ул.", Number: "56"},
+	})
+
+	address, err := s.FindBuilding("Карбышева ул.", "56", models.Filter{})
+	check(t, err)
+	equal(t, "FindBuilding", address.BuildingID, int64(11))
+
+	_, err = s.FindBuilding("Карбышева ул.", "1000", models.Filter{})
+	is(t, "FindBuilding missing", err, storage.ErrBuildingNotFound)
+
+	addresses, err = s.GetStreetBuildings("Карбышева ул.", models.Filter{})
+	check(t, err)
+	equal(t, "GetStreetBuildings", len(addresses), 2)
+}
+
+func testKinds(t *testing.T, s storage.Storage) {
+	unclassified, err := s.GetUnclassifiedBlackouts(10)
+	check(t, err)
+	equal(t, "GetUnclassifiedBlackouts", len(unclassified), 5)
+
+	check(t, s.SetBlackoutKinds(map[string]string{"b1": models.KindPlanned, "b2": models.KindEmergency}))
+	check(t, s.OverrideBlackoutKind("b2", models.KindPlanned))
+	// a kind set by hand is kept by the rules
+	check(t, s.SetBlackoutKinds(map[string]string{"b2": models.KindEmergency}))
+
+	blackout, err := s.GetBlackout("b2", models.Filter{})
+	check(t, err)
+	equal(t, "OverrideBlackoutKind", blackout.Kind, models.KindPlanned)
+
+	blackouts, err := s.GetFilteredBlackouts(Now, models.Filter{City: "vladivostok", Kind: models.KindPlanned})
+	check(t, err)
+	equal(t, "GetFilteredBlackouts by kind", ids(blackouts), []string{"b1", "b2"})
+
+	blackouts, err = s.GetFilteredBlackouts(Now, models.Filter{Kind: models.KindUnknown})
+	check(t, err)
+	equal(t, "GetFilteredBlackouts unknown kind", ids(blackouts), []string{"b3"})
+
+	blackouts, err = s.GetUpcomingBlackouts("2024-01-01 00:00:00", models.Filter{City: "vladivostok", Kind: models.KindPlanned})
+	check(t, err)
+	equal(t, "GetUpcomingBlackouts by kind", ids(blackouts), []string{"b1", "b2"})
+
+	orgs, err := s.GetOrganizations(Now, models.Filter{Kind: models.KindPlanned})
+	check(t, err)
+	slices.Sort(orgs)
+	equal(t, "GetOrganizations by kind", orgs, []string{"Водоканал", "Дальэнерго"})
+
+	orgs, err = s.GetOrganizations(Now, models.Filter{Kind: models.KindEmergency})
+	check(t, err)
+	equal(t, "GetOrganizations of another kind", len(orgs), 0)
+
+	count, err := s.GetBuildingsCountByOrgName("Водоканал", Now, models.Filter{Kind: models.KindUnknown})
+	check(t, err)
+	equal(t, "GetBuildingsCountByOrgName by kind", count, int64(1))
+
+	lastTime, _, err := s.GetLastAddressByOrgName("Водоканал", Now, models.Filter{Kind: models.KindPlanned})
+	check(t, err)
+	equal(t, "GetLastAddressByOrgName by kind", lastTime, "2024-03-10 09:00:00")
+
+	hourly, err := s.GetHourlyCounts("2024-03-10 00:00:00", "2024-03-10 23:59:59", models.Filter{Kind: models.KindPlanned})
+	check(t, err)
+	equal(t, "GetHourlyCounts by kind", hourly, []models.HourlyCount{
+		{Hour: "2024-03-10 09:00:00", Type: "hot_water", Count: 1},
+		{Hour: "2024-03-10 11:00:00", Type: "electricity", Count: 1},
+	})
+
+	// every blackout but the one set by hand
+	reset, err := s.ResetBlackoutKinds("")
+	check(t, err)
+	equal(t, "ResetBlackoutKinds", reset, int64(4))
+
+	err = s.OverrideBlackoutKind("missing", models.KindPlanned)
+	is(t, "OverrideBlackoutKind missing", err, storage.ErrBlackoutNotFound)
+}
+
+func testRevisions(t *testing.T, s storage.Storage) {
+	check(t, s.SaveRevisions([]models.Revision{
+		{BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", State: models.StateAnnounced, Actor: models.ActorSource, ChangedAt: "2024-03-09 10:00:00"},
+		{BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", State: models.StateActive, Actor: models.ActorSystem, ChangedAt: "2024-03-10 09:00:00"},
+		{BlackoutID: "b2", StartDate: "2024-03-10 11:30:00", State: models.StateActive, Actor: models.ActorSource, ChangedAt: "2024-03-10 11:30:00"},
+	}))
+
+	check(t, s.ReviseBlackout(models.Revision{
+		BlackoutID: "b1", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 21:00:00",
+		Description: "Продлено", State: models.StateExtended, Actor: "operator", ChangedAt: "2024-03-10 17:00:00",
+	}))
+
+	revisions, err := s.GetRevisions("b1", models.Filter{})
+	check(t, err)
+	states := []string{}
+	for _, r := range revisions {
+		states = append(states, r.State)
+	}
+	equal(t, "GetRevisions", states, []string{models.StateAnnounced, models.StateActive, models.StateExtended})
+
+	latest, err := s.GetLatestRevisions(models.Filter{})
+	check(t, err)
+	equal(t, "GetLatestRevisions", len(latest), 2)
+	equal(t, "GetLatestRevisions b1", latest["b1"].Actor, "operator")
+
+	latest, err = s.GetLatestRevisions(khabarovsk)
+	check(t, err)
+	equal(t, "GetLatestRevisions of another city", len(latest), 0)
+
+	blackout, err := s.GetBlackout("b1", models.Filter{})
+	check(t, err)
+	equal(t, "ReviseBlackout", blackout.EndDate, "2024-03-10 21:00:00")
+
+	err = s.ReviseBlackout(models.Revision{BlackoutID: "missing", StartDate: Now, State: models.StateActive, Actor: "operator", ChangedAt: Now})
+	is(t, "ReviseBlackout missing", err, storage.ErrBlackoutNotFound)
+}
+
+func testDistricts(t *testing.T, s storage.Storage) {
+	updated, missing, err := s.ImportDistricts("vladivostok", []models.DistrictMapping{
+		{District: "Ленинский", Street: "Светланская ул."},
+		{District: "Первореченский", Street: "Карбышева ул."},
+		{District: "Ленинский", Street: "Карбышева ул.", Number: "56"},
+		{District: "Ленинский", Street: "Нет такой ул."},
+	})
+	check(t, err)
+	equal(t, "ImportDistricts updated", updated, int64(3))
+	equal(t, "ImportDistricts missing", len(missing), 1)
+
+	districts, err := s.GetDistricts(vladivostok)
+	check(t, err)
+	equal(t, "GetDistricts", len(districts), 2)
+
+	updated, _, err = s.ImportDistricts("khabarovsk", []models.DistrictMapping{
+		{District: "Ленинский", Street: "Ленина ул."},
+	})
+	check(t, err)
+	equal(t, "ImportDistricts in khabarovsk", updated, int64(1))
+
+	districts, err = s.GetDistricts(models.Filter{City: "khabarovsk"})
+	check(t, err)
+	equal(t, "GetDistricts in khabarovsk", len(districts), 1)
+
+	_, _, err = s.ImportDistricts("moscow", nil)
+	is(t, "ImportDistricts of an unknown city", err, storage.ErrCityNotFound)
+
+	stats, err := s.GetDistrictStats(Now, vladivostok)
+	check(t, err)
+	equal(t, "GetDistrictStats", districtCounts(stats), map[string][2]int64{
+		"Ленинский":      {2, 2},
+		"Первореченский": {1, 1},
+	})
+
+	check(t, s.SetBlackoutKinds(map[string]string{"b1": models.KindPlanned, "b2": models.KindEmergency}))
+	stats, err = s.GetDistrictStats(Now, models.Filter{City: "vladivostok", Kind: models.KindEmergency})
+	check(t, err)
+	equal(t, "GetDistrictStats by kind", districtCounts(stats), map[string][2]int64{
+		"Ленинский":      {2, 1},
+		"Первореченский": {1, 0},
+	})
+
+	blackouts, err := s.GetFilteredBlackouts(Now, models.Filter{City: "vladivostok", District: "Первореченский"})
+	check(t, err)
+	equal(t, "GetFilteredBlackouts by district", ids(blackouts), []string{"b1"})
+
+	// a district name of another city is a district of its own
+	stats, err = s.GetDistrictStats(Now, models.Filter{City: "khabarovsk"})
+	check(t, err)
+	equal(t, "GetDistrictStats in khabarovsk", districtCounts(stats), map[string][2]int64{
+		"Ленинский": {1, 1},
+	})
+
+	blackouts, err = s.GetFilteredBlackouts(Now, models.Filter{City: "khabarovsk", District: "Ленинский"})
+	check(t, err)
+	equal(t, "GetFilteredBlackouts by district in khabarovsk", ids(blackouts), []string{"b3"})
+}
+
+// districtCounts maps district names to their total and affected buildings
+func districtCounts(stats []models.DistrictStats) map[string][2]int64 {
+	counts := map[string][2]int64{}
+	for _, d := range stats {
+		counts[d.Name] = [2]int64{d.TotalBuildings, d.AffectedBuildings}
+	}
+	return counts
+}
+
+func testLocations(t *testing.T, s storage.Storage) {
+	updated, missing, err := s.ImportBuildingLocations("vladivostok", []models.BuildingLocation{
+		{Street: "Карбышева ул.", Number: "54", Latitude: 43.16, Longitude: 131.93},
+		{Street: "Светланская ул.", Number: "1", Latitude: 43.11, Longitude: 131.88},
+		{Street: "Ленина ул.", Number: "5", Latitude: 48.48, Longitude: 135.07},
+		{Street: "Карбышева ул.", Number: "1000", Latitude: 43.17, Longitude: 131.94},
+	})
+	check(t, err)
+	equal(t, "ImportBuildingLocations updated", updated, int64(2))
+	equal(t, "ImportBuildingLocations missing", len(missing), 2)
+
+	updated, _, err = s.ImportBuildingLocations("khabarovsk", []models.BuildingLocation{
+		{Street: "Ленина ул.", Number: "5", Latitude: 48.48, Longitude: 135.07},
+	})
+	check(t, err)
+	equal(t, "ImportBuildingLocations in khabarovsk", updated, int64(1))
+
+	_, _, err = s.ImportBuildingLocations("moscow", nil)
+	is(t, "ImportBuildingLocations of an unknown city", err, storage.ErrCityNotFound)
+
+	located, err := s.GetBuildingLocations(models.Filter{})
+	check(t, err)
+	equal(t, "GetBuildingLocations", len(located), 3)
+
+	onMap, err := s.GetMapBlackouts(Now, nil, vladivostok)
+	check(t, err)
+	equal(t, "GetMapBlackouts", len(onMap), 2)
+
+	onMap, err = s.GetMapBlackouts(Now, &models.BBox{MinLon: 131.9, MinLat: 43.1, MaxLon: 132, MaxLat: 43.2}, models.Filter{})
+	check(t, err)
+	equal(t, "GetMapBlackouts in a box", len(onMap), 1)
+	equal(t, "GetMapBlackouts in a box id", onMap[0].BlackoutID, "b1")
+
+	// a date covers the whole day
+	onMap, err = s.GetMapBlackouts("2024-02-01", nil, models.Filter{})
+	check(t, err)
+	equal(t, "GetMapBlackouts of a day", len(onMap), 1)
+
+	onMap, err = s.GetBuildingsBlackouts([]int64{10}, "2024-02-01")
+	check(t, err)
+	equal(t, "GetBuildingsBlackouts of a day", len(onMap), 1)
+
+	onMap, err = s.GetBuildingsBlackouts([]int64{10, 12, 20}, Now)
+	check(t, err)
+	equal(t, "GetBuildingsBlackouts", len(onMap), 3)
+}
+
+// testSpatial covers backends searching located buildings themselves, the
+// answers must match the in-memory index the locator falls back to
+func testSpatial(t *testing.T, s storage.Storage) {
+	searcher, ok := s.(spatial.Searcher)
+	if !ok || !searcher.Spatial() {
+		t.Skip("the backend has no spatial search")
+	}
+
+	_, _, err := s.ImportBuildingLocations("vladivostok", []models.BuildingLocation{
+		{Street: "Карбышева ул.", Number: "54", Latitude: 43.16, Longitude: 131.93},
+		{Street: "Карбышева ул.", Number: "56", Latitude: 43.1601, Longitude: 131.93},
+		{Street: "Светланская ул.", Number: "1", Latitude: 43.11, Longitude: 131.88},
+	})
+	check(t, err)
+
+	// Khabarovsk has a building at the very same place
+	_, _, err = s.ImportBuildingLocations("khabarovsk", []models.BuildingLocation{
+		{Street: "Ленина ул.", Number: "5", Latitude: 43.16, Longitude: 131.93},
+	})
+	check(t, err)
+
+	center := geo.Point{Lat: 43.16, Lon: 131.93}
+
+	hits, err := searcher.NearbyBuildings(center, 50, vladivostok)
+	check(t, err)
+	equal(t, "NearbyBuildings", hitIDs(hits), []int64{10, 11})
+	near(t, "NearbyBuildings", hits, center)
+
+	hits, err = searcher.NearbyBuildings(center, 50, models.Filter{City: "khabarovsk"})
+	check(t, err)
+	equal(t, "NearbyBuildings in khabarovsk", hitIDs(hits), []int64{20})
+
+	hits, err = searcher.NearbyBuildings(geo.Point{Lat: 43.12, Lon: 131.88}, 1000, vladivostok)
+	check(t, err)
+	equal(t, "NearbyBuildings out of reach", hitIDs(hits), []int64{})
+
+	// the ring is left open, the way clients send it
+	square := geo.Polygon{{
+		{Lat: 43.159, Lon: 131.929}, {Lat: 43.161, Lon: 131.929},
+		{Lat: 43.161, Lon: 131.931}, {Lat: 43.159, Lon: 131.931},
+	}}
+	ref := geo.Point{Lat: 43.11, Lon: 131.88}
+
+	hits, err = searcher.BuildingsWithin(square, ref, models.Filter{})
+	check(t, err)
+	equal(t, "BuildingsWithin", hitIDs(hits), []int64{10, 20, 11})
+	near(t, "BuildingsWithin", hits, ref)
+
+	hole := append(square, []geo.Point{
+		{Lat: 43.15995, Lon: 131.92995}, {Lat: 43.16005, Lon: 131.92995},
+		{Lat: 43.16005, Lon: 131.93005}, {Lat: 43.15995, Lon: 131.93005},
+	})
+
+	hits, err = searcher.BuildingsWithin(hole, ref, vladivostok)
+	check(t, err)
+	equal(t, "BuildingsWithin a polygon with a hole", hitIDs(hits), []int64{11})
+}
+
+func hitIDs(hits []geo.Hit) []int64 {
+	result := []int64{}
+	for _, hit := range hits {
+		result = append(result, hit.ID)
+	}
+	return result
+}
+
+// near checks the distances against the great-circle distance of the
+// in-memory index, to a decimeter
+func near(t *testing.T, name string, hits []geo.Hit, ref geo.Point) {
+	t.Helper()
+	for _, hit := range hits {
+		if want := geo.Distance(ref, hit.Point); math.Abs(hit.Distance-want) > 0.1 {
+			t.Errorf("%s: got distance %.2f to building %d, want %.2f", name, hit.Distance, hit.ID, want)
+		}
+	}
+}
+
+func testHotspots(t *testing.T, s storage.Storage) {
+	timeline := []models.HotspotOutage{
+		{ID: "b4", StartOff: "2024-02-01 08:00:00", EndOff: "2024-02-01 10:00:00"},
+		{ID: "b1", StartOff: "2024-03-10 09:00:00", EndOff: "2024-03-10 18:00:00"},
+	}
+
+	check(t, s.ReplaceHotspots([]models.Hotspot{
+		{Scope: models.HotspotBuilding, BuildingID: 10, Street: "Карбышева ул.", Number: "54", Type: "hot_water", Outages: 2, WindowOutages: 2, FirstAt: timeline[0].StartOff, LastAt: timeline[1].StartOff, Timeline: timeline},
+		{Scope: models.HotspotStreet, Street: "Карбышева ул.", Type: "hot_water", Outages: 5, WindowOutages: 3, Periodic: true, PeriodDays: 14.5, FirstAt: timeline[0].StartOff, LastAt: timeline[1].StartOff, Timeline: timeline},
+		{Scope: models.HotspotBuilding, BuildingID: 10, Street: "Карбышева ул.", Number: "54", Type: "hot_water", Kind: models.KindPlanned, Outages: 2, WindowOutages: 2, FirstAt: timeline[0].StartOff, LastAt: timeline[1].StartOff, Timeline: timeline},
+	}, Now))
+
+	hotspots, err := s.GetHotspots("", "", 10, models.Filter{})
+	check(t, err)
+	equal(t, "GetHotspots", len(hotspots), 2)
+	equal(t, "GetHotspots order", hotspots[0].Outages, 5)
+	equal(t, "GetHotspots period", hotspots[0].PeriodDays, 14.5)
+	equal(t, "GetHotspots timeline", hotspots[0].Timeline, timeline)
+
+	hotspots, err = s.GetHotspots(models.HotspotBuilding, "hot_water", 10, models.Filter{})
+	check(t, err)
+	equal(t, "GetHotspots by scope", len(hotspots), 1)
+
+	hotspots, err = s.GetHotspots("", "", 10, models.Filter{Kind: models.KindPlanned})
+	check(t, err)
+	equal(t, "GetHotspots by kind", len(hotspots), 1)
+	equal(t, "GetHotspots kind", hotspots[0].Kind, models.KindPlanned)
+
+	check(t, s.ReplaceHotspots(nil, Now))
+	hotspots, err = s.GetHotspots("", "", 10, models.Filter{})
+	check(t, err)
+	equal(t, "ReplaceHotspots", len(hotspots), 0)
+}
+
+func testAnomalies(t *testing.T, s storage.Storage) {
+	anomaly := models.Anomaly{Type: "electricity", Hour: "2024-03-10 11:00:00", Count: 5, Baseline: 1, StdDev: 0.5, ZScore: 8, DetectedAt: Now}
+
+	created, err := s.SaveAnomaly(anomaly)
+	check(t, err)
+	equal(t, "SaveAnomaly", created, true)
+
+	anomaly.Count = 7
+	created, err = s.SaveAnomaly(anomaly)
+	check(t, err)
+	equal(t, "SaveAnomaly repeated", created, false)
+
+	// the same hour among emergencies only is another anomaly
+	anomaly.Kind = models.KindEmergency
+	created, err = s.SaveAnomaly(anomaly)
+	check(t, err)
+	equal(t, "SaveAnomaly of a kind", created, true)
+
+	anomalies, err := s.GetAnomalies("", "2024-03-10 00:00:00", "2024-03-10 23:59:59", 10, models.Filter{})
+	check(t, err)
+	equal(t, "GetAnomalies", len(anomalies), 1)
+	equal(t, "GetAnomalies count", anomalies[0].Count, 7)
+
+	anomalies, err = s.GetAnomalies("", "2024-03-10 00:00:00", "2024-03-10 23:59:59", 10, models.Filter{Kind: models.KindEmergency})
+	check(t, err)
+	equal(t, "GetAnomalies by kind", len(anomalies), 1)
+	equal(t, "GetAnomalies kind", anomalies[0].Kind, models.KindEmergency)
+
+	anomalies, err = s.GetAnomalies("heat", "2024-03-10 00:00:00", "2024-03-10 23:59:59", 10, models.Filter{})
+	check(t, err)
+	equal(t, "GetAnomalies by type", len(anomalies), 0)
+}
+
+func testServiceTypes(t *testing.T, s storage.Storage) {
+	serviceTypes, err := s.GetServiceTypes()
+	check(t, err)
+	equal(t, "GetServiceTypes", serviceTypes, models.DefaultServiceTypes())
+
+	check(t, s.SyncServiceTypes([]models.ServiceType{{Code: "gas", NameRu: "газ", NameEn: "gas", Icon: "gas", Position: 5}}))
+	serviceTypes, err = s.GetServiceTypes()
+	check(t, err)
+	equal(t, "SyncServiceTypes", serviceTypes.Codes(), []string{"hot_water", "cold_water", "electricity", "heat", "gas"})
+
+	// syncing again keeps the data of a city dropped from the config
+	check(t, s.SyncCities([]models.City{{Code: "vladivostok", Name: "Владивосток", Timezone: "Asia/Vladivostok", BuildingsTotal: 100}}, "vladivostok"))
+	count, err := s.GetBuildingsCount(vladivostok)
+	check(t, err)
+	equal(t, "SyncCities buildings total", count, int64(100))
+	count, err = s.GetBuildingsCount(khabarovsk)
+	check(t, err)
+	equal(t, "SyncCities kept city", count, int64(1))
+}
+
+func testSubscriptions(t *testing.T, s storage.Storage) {
+	sub := models.Subscription{Email: "a@example.com", BuildingID: 10, Mode: models.SubscriptionInstant, Token: "t1", CreatedAt: Now}
+
+	id, err := s.SaveSubscription(sub)
+	check(t, err)
+	if id == 0 {
+		t.Errorf("SaveSubscription: got id 0")
+	}
+
+	// a pending subscription takes the new token
+	sub.Token = "t2"
+	_, err = s.SaveSubscription(sub)
+	check(t, err)
+
+	_, err = s.ConfirmSubscription("t1", Now)
+	is(t, "ConfirmSubscription old token", err, storage.ErrSubscriptionNotFound)
+
+	confirmed, err := s.ConfirmSubscription("t2", Now)
+	check(t, err)
+	equal(t, "ConfirmSubscription", confirmed.Street+" "+confirmed.Number, "Карбышева ул. 54")
+
+	sub.Token = "t3"
+	_, err = s.SaveSubscription(sub)
+	is(t, "SaveSubscription confirmed", err, storage.ErrSubscriptionExists)
+
+	subs, err := s.GetSubscriptionsByBuildings([]int64{10, 11}, models.SubscriptionInstant)
+	check(t, err)
+	equal(t, "GetSubscriptionsByBuildings", len(subs), 1)
+
+	digest := models.Subscription{Email: "b@example.com", BuildingID: 12, Mode: models.SubscriptionDigest, Token: "d1", CreatedAt: Now}
+	digestID, err := s.SaveSubscription(digest)
+	check(t, err)
+	_, err = s.ConfirmSubscription("d1", Now)
+	check(t, err)
+
+	subs, err = s.GetDigestSubscriptions("2024-03-10 08:00:00", models.Filter{})
+	check(t, err)
+	equal(t, "GetDigestSubscriptions", len(subs), 1)
+
+	check(t, s.SetSubscriptionDigestSent(digestID, "2024-03-10 08:30:00"))
+	subs, err = s.GetDigestSubscriptions("2024-03-10 08:00:00", models.Filter{})
+	check(t, err)
+	equal(t, "SetSubscriptionDigestSent", len(subs), 0)
+
+	check(t, s.DeleteSubscription("t2"))
+	is(t, "DeleteSubscription missing", s.DeleteSubscription("t2"), storage.ErrSubscriptionNotFound)
+}
+
+func testTelegram(t *testing.T, s storage.Storage) {
+	check(t, s.SaveTelegramSubscription(100, 10, Now))
+	check(t, s.SaveTelegramSubscription(100, 12, Now))
+	is(t, "SaveTelegramSubscription repeated", s.SaveTelegramSubscription(100, 12, Now), storage.ErrSubscriptionExists)
+	check(t, s.SaveTelegramSubscription(200, 12, Now))
+
+	subs, err := s.GetTelegramSubscriptions(100)
+	check(t, err)
+	equal(t, "GetTelegramSubscriptions", len(subs), 2)
+	equal(t, "GetTelegramSubscriptions address", subs[0].Address.Street, "Карбышева ул.")
+
+	subs, err = s.GetTelegramSubscriptionsByBuildings([]int64{12})
+	check(t, err)
+	equal(t, "GetTelegramSubscriptionsByBuildings", len(subs), 2)
+
+	deleted, err := s.DeleteTelegramSubscriptions(100)
+	check(t, err)
+	equal(t, "DeleteTelegramSubscriptions", deleted, int64(2))
+
+	check(t, s.SetTelegramChatCity(100, "khabarovsk"))
+	check(t, s.SetTelegramChatCity(100, "vladivostok"))
+	is(t, "SetTelegramChatCity unknown", s.SetTelegramChatCity(200, "moscow"), storage.ErrCityNotFound)
+
+	city, err := s.GetTelegramChatCity(100)
+	check(t, err)
+	equal(t, "GetTelegramChatCity", city, "vladivostok")
+
+	city, err = s.GetTelegramChatCity(200)
+	check(t, err)
+	equal(t, "GetTelegramChatCity not picked", city, "")
+}
+
+func testWebhooks(t *testing.T, s storage.Storage) {
+	webhook := models.Webhook{URL: "https://example.com/hook", Secret: "s", Buildings: []int64{10}, Types: []string{"hot_water"}, CreatedAt: Now}
+
+	id, err := s.SaveWebhook(webhook)
+	check(t, err)
+
+	stored, err := s.GetWebhook(id, models.Filter{})
+	check(t, err)
+	equal(t, "GetWebhook buildings", stored.Buildings, []int64{10})
+	equal(t, "GetWebhook streets", stored.Streets, []string{})
+	equal(t, "GetWebhook secret", stored.Secret, "s")
+
+	webhooks, err := s.GetWebhooks(models.Filter{})
+	check(t, err)
+	equal(t, "GetWebhooks", len(webhooks), 1)
+
+	// the payload is signed, it must come back byte for byte
+	payload := `{"b":1,  "a":[2, 1]}`
+	deliveryID, err := s.SaveWebhookDelivery(models.WebhookDelivery{
+		WebhookID: id, EventType: "blackout.started", Payload: payload,
+		Status: models.DeliveryPending, NextAttemptAt: Now, CreatedAt: Now,
+	})
+	check(t, err)
+
+	due, err := s.GetDueWebhookDeliveries(Now, 10)
+	check(t, err)
+	equal(t, "GetDueWebhookDeliveries", len(due), 1)
+	equal(t, "GetDueWebhookDeliveries payload", due[0].Payload, payload)
+
+	delivery := due[0]
+	delivery.Status = models.DeliverySucceeded
+	delivery.Attempts = 1
+	delivery.LastStatusCode = 200
+	delivery.DeliveredAt = Now
+	check(t, s.UpdateWebhookDelivery(delivery))
+
+	delivery, err = s.GetWebhookDelivery(deliveryID)
+	check(t, err)
+	equal(t, "UpdateWebhookDelivery", delivery.Status, models.DeliverySucceeded)
+	equal(t, "UpdateWebhookDelivery delivered", delivery.DeliveredAt, Now)
+
+	deliveries, err := s.GetWebhookDeliveries(id, 10)
+	check(t, err)
+	equal(t, "GetWebhookDeliveries", len(deliveries), 1)
+
+	check(t, s.DeleteWebhook(id, models.Filter{}))
+	is(t, "DeleteWebhook missing", s.DeleteWebhook(id, models.Filter{}), storage.ErrWebhookNotFound)
+
+	_, err = s.GetWebhook(id, models.Filter{})
+	is(t, "GetWebhook missing", err, storage.ErrWebhookNotFound)
+
+	_, err = s.GetWebhookDelivery(deliveryID)
+	is(t, "GetWebhookDelivery missing", err, storage.ErrDeliveryNotFound)
+}
+
+func testDataVersion(t *testing.T, s storage.Storage) {
+	before, err := s.DataVersion()
+	check(t, err)
+
+	_, err = s.GetBlackouts(Now)
+	check(t, err)
+
+	again, err := s.DataVersion()
+	check(t, err)
+	equal(t, "DataVersion after a read", again, before)
+
+	// only the source tables are versioned
+	check(t, s.SyncServiceTypes([]models.ServiceType{{Code: "gas", NameRu: "газ", NameEn: "gas"}}))
+
+	again, err = s.DataVersion()
+	check(t, err)
+	equal(t, "DataVersion after a write to a service table", again, before)
+
+	check(t, s.OverrideBlackoutKind("b1", models.KindEmergency))
+
+	after, err := s.DataVersion()
+	check(t, err)
+	if after == before {
+		t.Errorf("DataVersion after a write: still %s", after)
+	}
+}
+
+func ids(blackouts []models.Blackout) []string {
+	result := []string{}
+	for _, b := range blackouts {
+		result = append(result, b.ID)
+	}
+	slices.Sort(result)
+	return result
+}
+
+func complaints(buckets []models.ComplaintData) int {
+	total := 0
+	for _, b := range buckets {
+		for _, count := range b.Counts {
+			total += count
+		}
+	}
+	return total
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func is(t *testing.T, name string, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: got error %v, want %v", name, err, target)
+	}
+}
+
+func equal[T any](t *testing.T, name string, got T, want T) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: got %v, want %v", name, got, want)
+	}
+}
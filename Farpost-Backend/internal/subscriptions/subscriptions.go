@@ -0,0 +1,215 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"text/template"
+	"time"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/mailer"
+	"vlru-prsch/internal/models"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+type Storage interface {
+	GetSubscriptionsByBuildings(buildingIDs []int64, mode string) ([]models.Subscription, error)
+	GetDigestSubscriptions(since string, filter models.Filter) ([]models.Subscription, error)
+	SetSubscriptionDigestSent(id int64, sentAt string) error
+	GetBuildingBlackouts(buildingID int64, from string, to string) ([]models.Blackout, error)
+}
+
+// Notifier emails residents about blackouts touching their building:
+// instant subscribers on every created or started blackout, digest
+// subscribers once a day at the configured time of the city of their building.
+type Notifier struct {
+	log       *slog.Logger
+	store     Storage
+	sender    mailer.Sender
+	templates map[string]*template.Template
+	baseURL   string
+	digestAt  time.Duration
+	cities    []models.City
+	now       func() time.Time
+}
+
+func New(log *slog.Logger, store Storage, sender mailer.Sender, types models.ServiceTypes, baseURL string, digestAt time.Duration, cities []models.City) *Notifier {
+	return &Notifier{
+		log:       log,
+		store:     store,
+		sender:    sender,
+		templates: parseTemplates(types),
+		baseURL:   baseURL,
+		digestAt:  digestAt,
+		cities:    cities,
+		now:       time.Now,
+	}
+}
+
+func (n *Notifier) Run(ctx context.Context, hub events.Subscriber) {
+	const op = "subscriptions.Notifier.Run"
+
+	log := n.log.With(slog.String("op", op))
+
+	go events.Consume(ctx, log, hub, events.Filter{}, n.Notify)
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		n.SendDigests()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendConfirmation sends the double opt-in email with the confirmation link
+func (n *Notifier) SendConfirmation(sub models.Subscription) error {
+	const op = "subscriptions.Notifier.SendConfirmation"
+
+	msg, err := n.render("confirm", sub.Email, map[string]any{
+		"Street":     sub.Street,
+		"Number":     sub.Number,
+		"Mode":       sub.Mode,
+		"ConfirmURL": n.link("/off/subscriptions/confirm", sub.Token),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := n.sender.Send(msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Notify emails instant subscribers of the buildings touched by a created or started blackout
+func (n *Notifier) Notify(e events.Event) {
+	const op = "subscriptions.Notifier.Notify"
+
+	if e.Blackout == nil || (e.Type != events.BlackoutCreated && e.Type != events.BlackoutStarted) {
+		return
+	}
+
+	log := n.log.With(
+		slog.String("op", op),
+		slog.Int64("event_id", e.ID),
+		slog.String("blackout_id", e.Blackout.ID),
+	)
+
+	buildingIDs := make([]int64, 0, len(e.Blackout.Addresses))
+	for _, address := range e.Blackout.Addresses {
+		buildingIDs = append(buildingIDs, address.BuildingID)
+	}
+
+	subs, err := n.store.GetSubscriptionsByBuildings(buildingIDs, models.SubscriptionInstant)
+	if err != nil {
+		log.Error("failed to get subscriptions", sl.Err(err))
+		return
+	}
+
+	for _, sub := range subs {
+		msg, err := n.render("blackout", sub.Email, map[string]any{
+			"Event":          e.Type,
+			"Street":         sub.Street,
+			"Number":         sub.Number,
+			"Blackout":       e.Blackout,
+			"UnsubscribeURL": n.link("/off/subscriptions/unsubscribe", sub.Token),
+		})
+		if err != nil {
+			log.Error("failed to render email", slog.Int64("subscription_id", sub.ID), sl.Err(err))
+			continue
+		}
+
+		if err := n.sender.Send(msg); err != nil {
+			log.Error("failed to send email", slog.Int64("subscription_id", sub.ID), sl.Err(err))
+			continue
+		}
+
+		log.Info("blackout email sent", slog.Int64("subscription_id", sub.ID))
+	}
+}
+
+// SendDigests sends today's digest to every digest subscriber that has not got it yet.
+// Today and the time of day are those of the city of the building, nothing is
+// sent there before the configured time.
+func (n *Notifier) SendDigests() {
+	cities := n.cities
+	if len(cities) == 0 {
+		cities = []models.City{{}}
+	}
+
+	for _, city := range cities {
+		n.sendDigests(city)
+	}
+}
+
+func (n *Notifier) sendDigests(city models.City) {
+	const op = "subscriptions.Notifier.SendDigests"
+
+	log := n.log.With(slog.String("op", op), slog.String("city", city.Code))
+
+	now := n.now()
+	if city.Location != nil {
+		now = now.In(city.Location)
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	digestTime := midnight.Add(n.digestAt)
+	if now.Before(digestTime) {
+		return
+	}
+
+	subs, err := n.store.GetDigestSubscriptions(digestTime.Format(timeLayout), models.Filter{City: city.Code})
+	if err != nil {
+		log.Error("failed to get digest subscriptions", sl.Err(err))
+		return
+	}
+
+	from := now.Format(timeLayout)
+	to := now.Add(24 * time.Hour).Format(timeLayout)
+
+	for _, sub := range subs {
+		blackouts, err := n.store.GetBuildingBlackouts(sub.BuildingID, from, to)
+		if err != nil {
+			log.Error("failed to get building blackouts", slog.Int64("subscription_id", sub.ID), sl.Err(err))
+			continue
+		}
+
+		if len(blackouts) > 0 {
+			msg, err := n.render("digest", sub.Email, map[string]any{
+				"Date":           now.Format("02.01.2006"),
+				"Street":         sub.Street,
+				"Number":         sub.Number,
+				"Blackouts":      blackouts,
+				"UnsubscribeURL": n.link("/off/subscriptions/unsubscribe", sub.Token),
+			})
+			if err != nil {
+				log.Error("failed to render digest", slog.Int64("subscription_id", sub.ID), sl.Err(err))
+				continue
+			}
+
+			if err := n.sender.Send(msg); err != nil {
+				log.Error("failed to send digest", slog.Int64("subscription_id", sub.ID), sl.Err(err))
+				continue
+			}
+
+			log.Info("digest sent", slog.Int64("subscription_id", sub.ID), slog.Int("blackouts", len(blackouts)))
+		}
+
+		if err := n.store.SetSubscriptionDigestSent(sub.ID, now.Format(timeLayout)); err != nil {
+			log.Error("failed to mark digest sent", slog.Int64("subscription_id", sub.ID), sl.Err(err))
+		}
+	}
+}
+
+func (n *Notifier) link(path, token string) string {
+	return n.baseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,275 @@
+package subscriptions
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/mailer"
+	"vlru-prsch/internal/models"
+)
+
+// sender records the messages and fails for the addresses in failing
+type sender struct {
+	sent    []mailer.Message
+	failing map[string]bool
+}
+
+func (s *sender) Send(msg mailer.Message) error {
+	if s.failing[msg.To] {
+		return errors.New("mailbox unavailable")
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *sender) recipients() []string {
+	var to []string
+	for _, msg := range s.sent {
+		to = append(to, msg.To)
+	}
+	s.sent = nil
+	return to
+}
+
+// store keeps the subscriptions in memory and answers like the SQLite storage:
+// only confirmed ones are returned, blackouts are looked up in blackouts by building
+// and the city of a building is looked up in cities
+type store struct {
+	subs      []models.Subscription
+	blackouts map[int64][]models.Blackout
+	cities    map[int64]string
+}
+
+func (s *store) GetSubscriptionsByBuildings(buildingIDs []int64, mode string) ([]models.Subscription, error) {
+	var subs []models.Subscription
+	for _, sub := range s.subs {
+		if sub.ConfirmedAt != "" && sub.Mode == mode && slices.Contains(buildingIDs, sub.BuildingID) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (s *store) GetDigestSubscriptions(since string, filter models.Filter) ([]models.Subscription, error) {
+	var subs []models.Subscription
+	for _, sub := range s.subs {
+		if filter.City != "" && s.cities[sub.BuildingID] != filter.City {
+			continue
+		}
+		if sub.ConfirmedAt != "" && sub.Mode == models.SubscriptionDigest && (sub.LastDigestAt == "" || sub.LastDigestAt < since) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (s *store) SetSubscriptionDigestSent(id int64, sentAt string) error {
+	s.subs[id-1].LastDigestAt = sentAt
+	return nil
+}
+
+func (s *store) GetBuildingBlackouts(buildingID int64, from string, to string) ([]models.Blackout, error) {
+	var blackouts []models.Blackout
+	for _, blackout := range s.blackouts[buildingID] {
+		if blackout.StartDate < to && (blackout.EndDate == "" || blackout.EndDate > from) {
+			blackouts = append(blackouts, blackout)
+		}
+	}
+	return blackouts, nil
+}
+
+func (s *store) subscribe(sub models.Subscription, confirmed bool) {
+	sub.ID = int64(len(s.subs) + 1)
+	sub.Token = sub.Email
+	if confirmed {
+		sub.ConfirmedAt = "2024-03-10 06:00:00"
+	}
+	s.subs = append(s.subs, sub)
+}
+
+func setup(t *testing.T) (*Notifier, *store, *sender, *time.Time) {
+	t.Helper()
+
+	s := &store{blackouts: map[int64][]models.Blackout{
+		11: {{ID: "b6", Type: "cold_water", StartDate: "2024-03-09 22:00:00", InitiatorName: "Водоканал"}},
+		12: {{ID: "b6", Type: "cold_water", StartDate: "2024-03-09 22:00:00", InitiatorName: "Водоканал"}},
+		20: {{ID: "b3", Type: "electricity", StartDate: "2024-03-10 09:00:00", EndDate: "2024-03-10 18:00:00", InitiatorName: "Дальэнерго"}},
+	}}
+	s.subscribe(models.Subscription{Email: "a@example.com", BuildingID: 10, Street: "Карбышева ул.", Number: "54", Mode: models.SubscriptionInstant}, true)
+	s.subscribe(models.Subscription{Email: "b@example.com", BuildingID: 12, Street: "Светланская ул.", Number: "1", Mode: models.SubscriptionInstant}, true)
+	s.subscribe(models.Subscription{Email: "c@example.com", BuildingID: 10, Street: "Карбышева ул.", Number: "54", Mode: models.SubscriptionInstant}, false)
+	s.subscribe(models.Subscription{Email: "d@example.com", BuildingID: 11, Street: "Карбышева ул.", Number: "56", Mode: models.SubscriptionDigest}, true)
+	s.subscribe(models.Subscription{Email: "e@example.com", BuildingID: 12, Street: "Светланская ул.", Number: "1", Mode: models.SubscriptionDigest}, true)
+
+	fake := &sender{failing: map[string]bool{}}
+	now := time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)
+
+	n := New(slog.New(slog.DiscardHandler), s, fake, models.DefaultServiceTypes(), "https://off.example.com", 8*time.Hour, nil)
+	n.now = func() time.Time { return now }
+
+	return n, s, fake, &now
+}
+
+func TestSendConfirmation(t *testing.T) {
+	n, _, fake, _ := setup(t)
+
+	err := n.SendConfirmation(models.Subscription{Email: "a@example.com", Street: "Карбышева ул.", Number: "54", Mode: models.SubscriptionDigest, Token: "a b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.sent) != 1 {
+		t.Fatalf("got %d emails, want 1", len(fake.sent))
+	}
+	msg := fake.sent[0]
+
+	if msg.To != "a@example.com" || msg.Subject != "Подтвердите подписку на отключения по адресу Карбышева ул. 54" {
+		t.Errorf("got email to %s with subject %q", msg.To, msg.Subject)
+	}
+	for _, want := range []string{"https://off.example.com/off/subscriptions/confirm?token=a+b", "ежедневную сводку"} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("%q is missing from the body:\n%s", want, msg.Body)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	blackout := &events.Blackout{
+		ID:   "b8",
+		Type: "hot_water",
+		Addresses: []models.Address{
+			{BuildingID: 10, Street: "Карбышева ул.", Number: "54"},
+			{BuildingID: 12, Street: "Светланская ул.", Number: "1"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		event   events.Event
+		failing []string
+		want    []string
+	}{
+		{"created", events.Event{Type: events.BlackoutCreated, Blackout: blackout}, nil, []string{"a@example.com", "b@example.com"}},
+		{"started", events.Event{Type: events.BlackoutStarted, Blackout: blackout}, nil, []string{"a@example.com", "b@example.com"}},
+		{"ended", events.Event{Type: events.BlackoutEnded, Blackout: blackout}, nil, nil},
+		{"counts", events.Event{Type: events.CountsChanged, Counts: map[string]int64{"hot_water": 1}}, nil, nil},
+		{"one mailbox fails", events.Event{Type: events.BlackoutStarted, Blackout: blackout}, []string{"a@example.com"}, []string{"b@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, _, fake, _ := setup(t)
+			for _, email := range tt.failing {
+				fake.failing[email] = true
+			}
+
+			n.Notify(tt.event)
+
+			for _, msg := range fake.sent {
+				if !strings.Contains(msg.Body, "https://off.example.com/off/subscriptions/unsubscribe?token=") {
+					t.Errorf("email to %s has no unsubscribe link:\n%s", msg.To, msg.Body)
+				}
+			}
+			if got := fake.recipients(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got emails to %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendDigests(t *testing.T) {
+	n, _, fake, now := setup(t)
+
+	steps := []struct {
+		name    string
+		at      time.Time
+		failing []string
+		want    []string
+	}{
+		{"before the digest time", time.Date(2024, 3, 10, 7, 59, 0, 0, time.UTC), nil, nil},
+		{"at the digest time, one mailbox fails", time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC), []string{"e@example.com"}, []string{"d@example.com"}},
+		{"the failed one is retried", time.Date(2024, 3, 10, 8, 1, 0, 0, time.UTC), nil, []string{"e@example.com"}},
+		{"once a day", time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), nil, nil},
+		{"the next day before the digest time", time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC), nil, nil},
+		{"the next day", time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC), nil, []string{"d@example.com", "e@example.com"}},
+	}
+
+	for _, step := range steps {
+		*now = step.at
+		fake.failing = map[string]bool{}
+		for _, email := range step.failing {
+			fake.failing[email] = true
+		}
+
+		n.SendDigests()
+
+		if got := fake.recipients(); !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s: got digests to %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestSendDigestsSkipsQuietDays(t *testing.T) {
+	n, s, fake, now := setup(t)
+	s.subscribe(models.Subscription{Email: "f@example.com", BuildingID: 20, Mode: models.SubscriptionDigest}, true)
+
+	// b3 of building 20 is over by then, open-ended b6 still touches buildings 11 and 12
+	*now = time.Date(2024, 3, 13, 8, 0, 0, 0, time.UTC)
+	n.SendDigests()
+
+	if got, want := fake.recipients(), []string{"d@example.com", "e@example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got digests to %v, want %v", got, want)
+	}
+
+	// the quiet one is marked as well, so it is not queried again today
+	subs, err := s.GetDigestSubscriptions("2024-03-13 08:00:00", models.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 0 {
+		t.Errorf("got %d digest subscriptions left for today, want 0", len(subs))
+	}
+}
+
+func TestSendDigestsAtTheTimeOfEveryCity(t *testing.T) {
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, s, fake, now := setup(t)
+	s.cities = map[int64]string{11: "vladivostok", 12: "moscow"}
+	n.cities = []models.City{
+		{Code: "vladivostok", Location: vladivostok},
+		{Code: "moscow", Location: moscow},
+	}
+
+	steps := []struct {
+		name string
+		at   time.Time
+		want []string
+	}{
+		{"08:00 in Vladivostok, 01:00 in Moscow", time.Date(2024, 3, 9, 22, 0, 0, 0, time.UTC), []string{"d@example.com"}},
+		{"08:00 in Moscow", time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), []string{"e@example.com"}},
+		{"both sent today", time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), nil},
+	}
+
+	for _, step := range steps {
+		*now = step.at
+
+		n.SendDigests()
+
+		if got := fake.recipients(); !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s: got digests to %v, want %v", step.name, got, step.want)
+		}
+	}
+}
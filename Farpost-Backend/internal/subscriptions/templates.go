@@ -0,0 +1,49 @@
+package subscriptions
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"text/template"
+	"vlru-prsch/internal/mailer"
+	"vlru-prsch/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// parseTemplates parses the mail templates, the services are named after types
+func parseTemplates(types models.ServiceTypes) map[string]*template.Template {
+	parse := func(name string) *template.Template {
+		return template.Must(template.New("").Funcs(template.FuncMap{
+			"service": types.Name,
+		}).ParseFS(templatesFS, name))
+	}
+
+	return map[string]*template.Template{
+		"confirm":  parse("templates/confirm.tmpl"),
+		"blackout": parse("templates/blackout.tmpl"),
+		"digest":   parse("templates/digest.tmpl"),
+	}
+}
+
+func (n *Notifier) render(name, to string, data any) (mailer.Message, error) {
+	tmpl, ok := n.templates[name]
+	if !ok {
+		return mailer.Message{}, fmt.Errorf("unknown template %q", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return mailer.Message{}, err
+	}
+
+	return mailer.Message{
+		To:      to,
+		Subject: subject.String(),
+		Body:    body.String(),
+	}, nil
+}
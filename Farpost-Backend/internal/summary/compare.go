@@ -0,0 +1,164 @@
+package summary
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"vlru-prsch/internal/models"
+)
+
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+	PeriodYear = "year"
+)
+
+var Periods = []string{PeriodDay, PeriodWeek, PeriodYear}
+
+// topOrganizations is how many organizations are listed per type
+const topOrganizations = 5
+
+type OrganizationGiver interface {
+	GetBuildingsCountByOrganization(blackoutType string, currentTime string, filter models.Filter) (map[string]int64, error)
+}
+
+// Change holds one type at currentTime against the same type at the baseline time
+type Change struct {
+	BaselineCount    int64
+	BaselineFraction float64
+	DeltaCount       int64
+	DeltaFraction    float64
+	// PercentDelta is nil when the baseline is zero
+	PercentDelta  *float64
+	Organizations []OrganizationChange
+}
+
+type OrganizationChange struct {
+	Name          string
+	Count         int64
+	BaselineCount int64
+	Delta         int64
+}
+
+// BaselineTime shifts currentTime back by the period. A date without time stays a date.
+// A year before February 29 is February 28, not March 1.
+func BaselineTime(currentTime string, period string) (string, error) {
+	const op = "summary.BaselineTime"
+
+	layout := "2006-01-02 15:04:05"
+	if len(currentTime) == 10 {
+		layout = "2006-01-02"
+	}
+
+	t, err := time.Parse(layout, currentTime)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch period {
+	case PeriodDay:
+		t = t.AddDate(0, 0, -1)
+	case PeriodWeek:
+		t = t.AddDate(0, 0, -7)
+	case PeriodYear:
+		lastYear := t.AddDate(-1, 0, 0)
+		if lastYear.Month() != t.Month() {
+			// AddDate normalizes February 29 of a non-leap year to March 1
+			lastYear = lastYear.AddDate(0, 0, -lastYear.Day())
+		}
+		t = lastYear
+	default:
+		return "", fmt.Errorf("%s: unknown period %q", op, period)
+	}
+
+	return t.Format(layout), nil
+}
+
+// Compare pairs each current summary with the baseline summary of the same type
+// and lists the organizations whose buildings changed the most. Types missing
+// from the baseline are compared against zero.
+func Compare(giver OrganizationGiver, current, baseline []TypeSummary,
+	currentTime, baselineTime string, filter models.Filter) (map[string]Change, error) {
+	const op = "summary.Compare"
+
+	baselineByType := make(map[string]TypeSummary, len(baseline))
+	for _, typeSummary := range baseline {
+		baselineByType[typeSummary.Type] = typeSummary
+	}
+
+	changes := make(map[string]Change, len(current))
+
+	for _, typeSummary := range current {
+		base := baselineByType[typeSummary.Type]
+
+		change := Change{
+			BaselineCount:    base.CountBuildings,
+			BaselineFraction: base.FractionBuildings,
+			DeltaCount:       typeSummary.CountBuildings - base.CountBuildings,
+			DeltaFraction:    math.Round((typeSummary.FractionBuildings-base.FractionBuildings)*100) / 100,
+		}
+		if base.CountBuildings > 0 {
+			percent := math.Round(float64(change.DeltaCount)/float64(base.CountBuildings)*100*100) / 100
+			change.PercentDelta = &percent
+		}
+
+		organizations, err := giver.GetBuildingsCountByOrganization(typeSummary.Type, currentTime, filter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		baselineOrganizations, err := giver.GetBuildingsCountByOrganization(typeSummary.Type, baselineTime, filter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		change.Organizations = contributors(organizations, baselineOrganizations)
+
+		changes[typeSummary.Type] = change
+	}
+
+	return changes, nil
+}
+
+// contributors returns the organizations with the largest absolute change,
+// unchanged ones are left out
+func contributors(current, baseline map[string]int64) []OrganizationChange {
+	organizations := []OrganizationChange{}
+
+	for name, count := range current {
+		if delta := count - baseline[name]; delta != 0 {
+			organizations = append(organizations, OrganizationChange{
+				Name: name, Count: count, BaselineCount: baseline[name], Delta: delta,
+			})
+		}
+	}
+	for name, count := range baseline {
+		if _, ok := current[name]; !ok {
+			organizations = append(organizations, OrganizationChange{
+				Name: name, BaselineCount: count, Delta: -count,
+			})
+		}
+	}
+
+	sort.Slice(organizations, func(i, j int) bool {
+		a, b := organizations[i], organizations[j]
+		if abs(a.Delta) != abs(b.Delta) {
+			return abs(a.Delta) > abs(b.Delta)
+		}
+		return a.Name < b.Name
+	})
+
+	if len(organizations) > topOrganizations {
+		organizations = organizations[:topOrganizations]
+	}
+
+	return organizations
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,40 @@
+package summary
+
+import (
+	"testing"
+)
+
+func TestBaselineTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		currentTime string
+		period      string
+		want        string
+		wantErr     bool
+	}{
+		{"day", "2024-03-10 12:00:00", PeriodDay, "2024-03-09 12:00:00", false},
+		{"day over a month", "2024-03-01 00:30:00", PeriodDay, "2024-02-29 00:30:00", false},
+		{"week", "2024-03-10 12:00:00", PeriodWeek, "2024-03-03 12:00:00", false},
+		{"year", "2024-03-10 12:00:00", PeriodYear, "2023-03-10 12:00:00", false},
+		{"year from February 29", "2024-02-29 12:00:00", PeriodYear, "2023-02-28 12:00:00", false},
+		{"year from a date of February 29", "2024-02-29", PeriodYear, "2023-02-28", false},
+		{"year from March 1 after a leap day", "2024-03-01", PeriodYear, "2023-03-01", false},
+		{"year back to a leap year", "2025-02-28", PeriodYear, "2024-02-28", false},
+		{"year from the last day of a month", "2024-12-31 23:59:59", PeriodYear, "2023-12-31 23:59:59", false},
+		{"a date stays a date", "2024-03-10", PeriodDay, "2024-03-09", false},
+		{"unknown period", "2024-03-10", "month", "", true},
+		{"invalid time", "2024-03-10 12", PeriodDay, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BaselineTime(tt.currentTime, tt.period)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package summary
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"vlru-prsch/internal/models"
+)
+
+type Giver interface {
+	GetBuildingsCount(filter models.Filter) (int64, error)
+	GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error)
+	GetLastBlackoutTimeByType(blackoutType string, currentTime string, filter models.Filter) (string, error)
+}
+
+// TypeSummary holds the share of buildings affected by one blackout type
+type TypeSummary struct {
+	Type              string
+	CountBuildings    int64
+	FractionBuildings float64
+	TimeLastBlackout  string
+}
+
+// Collect builds the per-type summary shown by /off/blackouts. A type whose
+// count cannot be read is skipped, a last blackout time that cannot be read
+// becomes "unknown" and is empty for a type without blackouts.
+// Shares are counted against the buildings matching the filter.
+func Collect(log *slog.Logger, giver Giver, types models.ServiceTypes, currentTime string, filter models.Filter) ([]TypeSummary, error) {
+	const op = "summary.Collect"
+
+	totalBuildings, err := giver.GetBuildingsCount(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var summaries []TypeSummary
+
+	for _, blackoutType := range types.Codes() {
+		affectedBuildings, err := giver.GetBuildingsCountByBlackoutType(blackoutType, currentTime, filter)
+		if err != nil {
+			log.Error("failed to get buildings count for type",
+				slog.String("type", blackoutType), slog.Any("error", err))
+			continue
+		}
+
+		lastBlackoutTime, err := giver.GetLastBlackoutTimeByType(blackoutType, currentTime, filter)
+		if err != nil {
+			log.Error("failed to get last blackout time",
+				slog.String("type", blackoutType), slog.Any("error", err))
+			lastBlackoutTime = "unknown"
+		}
+
+		summaries = append(summaries, TypeSummary{
+			Type:              blackoutType,
+			CountBuildings:    affectedBuildings,
+			FractionBuildings: Percentage(affectedBuildings, totalBuildings),
+			TimeLastBlackout:  lastBlackoutTime,
+		})
+	}
+
+	return summaries, nil
+}
+
+// Percentage returns part/total in percent rounded to two decimals
+func Percentage(part, total int64) float64 {
+	var fraction float64
+	if total > 0 {
+		fraction = float64(part) / float64(total)
+	}
+	return math.Round(fraction*100*100) / 100
+}
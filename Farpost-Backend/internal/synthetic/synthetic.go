@@ -0,0 +1,438 @@
+package synthetic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+type Options struct {
+	// Seed makes the city reproducible, the same seed and options give the same city
+	Seed int64
+	// Streets is the number of streets
+	Streets int
+	// Buildings is the number of real buildings spread over the streets
+	Buildings int
+	// Blackouts is the number of blackouts between From and To
+	Blackouts int
+	// FakeShare is the share of streets that get a fake building standing for the whole street
+	FakeShare float64
+	// From and To bound the start of the blackouts
+	From time.Time
+	To   time.Time
+}
+
+type Street struct {
+	ID   int64
+	Name string
+}
+
+type Building struct {
+	ID       int64
+	StreetID int64
+	Number   string
+	IsFake   bool
+}
+
+type Blackout struct {
+	ID        string
+	StartDate string
+	// EndDate is empty when the end is not known yet, it is stored as NULL
+	EndDate       string
+	Description   string
+	Type          string
+	InitiatorName string
+	Source        string
+	Buildings     []int64
+}
+
+// City is the content of the source tables
+type City struct {
+	Streets   []Street
+	Buildings []Building
+	Blackouts []Blackout
+}
+
+// profile describes how blackouts of one type happen
+type profile struct {
+	Type string
+	// Weight is the share of the type among all blackouts
+	Weight float64
+	// Season is the relative frequency by month, January first
+	Season [12]float64
+	// Planned is the share of planned works, the rest are emergencies
+	Planned float64
+	// PlannedHours and EmergencyHours bound the duration
+	PlannedHours   [2]float64
+	EmergencyHours [2]float64
+	// SummerDays bound the duration of the planned summer works, in days,
+	// zero when the type has none
+	SummerDays [2]float64
+	// PlannedBuildings and EmergencyBuildings bound the buildings on one street
+	PlannedBuildings   [2]int
+	EmergencyBuildings [2]int
+
+	Initiators []string
+	// PlannedWorks and Emergencies are the descriptions, they carry the
+	// words the kind classification looks for
+	PlannedWorks []string
+	Emergencies  []string
+}
+
+var profiles = []profile{
+	{
+		Type:               "hot_water",
+		Weight:             0.3,
+		Season:             [12]float64{0.6, 0.6, 0.7, 0.8, 1.2, 2.2, 2.4, 1.8, 1, 0.8, 0.7, 0.6},
+		Planned:            0.7,
+		PlannedHours:       [2]float64{4, 12},
+		EmergencyHours:     [2]float64{2, 18},
+		SummerDays:         [2]float64{3, 14},
+		PlannedBuildings:   [2]int{5, 40},
+		EmergencyBuildings: [2]int{1, 12},
+		Initiators:         []string{"Приморские тепловые сети", "Филиал «Приморская генерация» АО «ДГК»", "КГУП «Примтеплоэнерго»"},
+		PlannedWorks:       []string{"Гидравлические испытания (опрессовка) тепловых сетей", "Плановый ремонт теплотрассы", "Профилактические работы на сетях ГВС", "Плановое отключение горячей воды"},
+		Emergencies:        []string{"Аварийные работы на теплотрассе", "Порыв на сети горячего водоснабжения", "Утечка на трубопроводе ГВС", "Внеплановое отключение горячей воды"},
+	},
+	{
+		Type:               "cold_water",
+		Weight:             0.25,
+		Season:             [12]float64{1.4, 1.3, 1.1, 0.9, 0.9, 0.9, 1, 1.1, 1, 0.9, 1, 1.3},
+		Planned:            0.4,
+		PlannedHours:       [2]float64{3, 9},
+		EmergencyHours:     [2]float64{1, 14},
+		PlannedBuildings:   [2]int{3, 20},
+		EmergencyBuildings: [2]int{1, 10},
+		Initiators:         []string{"КГУП Приморский водоканал", "КГУП «Приморский водоканал»", "ООО «УК Первореченская»"},
+		PlannedWorks:       []string{"Плановые работы на водопроводе", "Ремонт водопроводного колодца", "Замена запорной арматуры, плановые работы", "Профилактическое обслуживание насосной станции"},
+		Emergencies:        []string{"Порыв водовода", "Аварийные работы на водопроводной сети", "Повреждение трубопровода", "Утечка на вводе в дом"},
+	},
+	{
+		Type:               "electricity",
+		Weight:             0.35,
+		Season:             [12]float64{1.3, 1.2, 1, 0.9, 0.9, 1, 1.1, 1.3, 1.2, 0.9, 1, 1.2},
+		Planned:            0.55,
+		PlannedHours:       [2]float64{2, 8},
+		EmergencyHours:     [2]float64{0.5, 8},
+		PlannedBuildings:   [2]int{2, 15},
+		EmergencyBuildings: [2]int{1, 25},
+		Initiators:         []string{"Дальэнерго", "АО «ДРСК»", "Приморские электрические сети"},
+		PlannedWorks:       []string{"Плановое обслуживание трансформаторной подстанции", "Ремонт кабельной линии", "Плановые работы на ВЛ-0,4 кВ", "Регламентные работы в РУ-10 кВ"},
+		Emergencies:        []string{"Повреждение кабельной линии", "Аварийное отключение ТП", "Неисправность оборудования подстанции", "Внеплановое отключение электроэнергии"},
+	},
+	{
+		Type:               "heat",
+		Weight:             0.1,
+		Season:             [12]float64{2, 1.8, 1.4, 0.8, 0.2, 0, 0, 0, 0.3, 1.2, 1.6, 2},
+		Planned:            0.2,
+		PlannedHours:       [2]float64{4, 10},
+		EmergencyHours:     [2]float64{3, 30},
+		PlannedBuildings:   [2]int{5, 30},
+		EmergencyBuildings: [2]int{2, 20},
+		Initiators:         []string{"Приморские тепловые сети", "КГУП «Примтеплоэнерго»", "ООО «УК Первореченская»"},
+		PlannedWorks:       []string{"Плановый ремонт тепловой камеры", "Испытания тепловых сетей", "Профилактические работы в ЦТП"},
+		Emergencies:        []string{"Авария на тепломагистрали", "Порыв на сети отопления", "Повреждение теплотрассы", "Неисправность в ЦТП"},
+	},
+}
+
+// vague descriptions match no kind rule, the way a part of the real ones does
+var vague = []string{"Отключение", "Работы на сетях", "Проведение работ"}
+
+// adjectives agree with "ул." only, genitives go with any kind of street
+var (
+	adjectives = []string{
+		"Светланская", "Алеутская", "Пограничная", "Некрасовская", "Русская", "Тигровая",
+		"Луговая", "Сахалинская", "Хабаровская", "Семеновская", "Фонтанная", "Нахимовская",
+		"Шилкинская", "Ульяновская", "Днепровская", "Камская", "Лесная", "Садовая",
+		"Черемуховая", "Кипарисовая", "Снеговая", "Зеленая", "Окатовая", "Тобольская",
+	}
+	genitives = []string{
+		"Карбышева", "Гоголя", "Невельского", "Фадеева", "Лазо", "Калинина", "Котельникова",
+		"Адмирала Фокина", "Адмирала Кузнецова", "Баляева", "Бестужева", "Борисенко", "Гамарника",
+		"Давыдова", "Жигура", "Кирова", "Крылова", "Махалина", "Острякова", "Пушкина",
+		"Суханова", "Толстого", "Уборевича", "Чапаева", "Шепеткова", "Шкотова", "Энгельса",
+		"Полетаева", "Овчинникова", "Станюковича",
+	}
+	kinds = []string{"пер.", "пр-т", "проезд"}
+)
+
+// Generate builds a city from the options, it does not touch the database
+func Generate(opts Options) (City, error) {
+	if err := opts.validate(); err != nil {
+		return City{}, err
+	}
+
+	r := rand.New(rand.NewPCG(uint64(opts.Seed), 0x5eed))
+
+	var city City
+
+	names := streetNames(r, opts.Streets)
+	sizes := spread(r, opts.Buildings, opts.Streets)
+
+	// real buildings of each street in the order of their numbers and the fake one, 0 if none
+	houses := make([][]int64, opts.Streets)
+	fake := make([]int64, opts.Streets)
+
+	for i, name := range names {
+		street := Street{ID: int64(i + 1), Name: name}
+		city.Streets = append(city.Streets, street)
+
+		for _, number := range numbers(r, sizes[i]) {
+			id := int64(len(city.Buildings) + 1)
+			city.Buildings = append(city.Buildings, Building{ID: id, StreetID: street.ID, Number: number})
+			houses[i] = append(houses[i], id)
+		}
+
+		if r.Float64() < opts.FakeShare {
+			id := int64(len(city.Buildings) + 1)
+			city.Buildings = append(city.Buildings, Building{ID: id, StreetID: street.ID, IsFake: true})
+			fake[i] = id
+		}
+	}
+
+	// some streets are hit much more often than others
+	trouble := make([]float64, opts.Streets)
+	total := 0.0
+	for i := range trouble {
+		total += math.Exp(r.NormFloat64() * 0.8)
+		trouble[i] = total
+	}
+
+	typeWeights := make([]float64, len(profiles))
+	total = 0
+	for i, p := range profiles {
+		total += p.Weight
+		typeWeights[i] = total
+	}
+
+	days := int(opts.To.Sub(opts.From).Hours() / 24)
+	ids := make(map[string]bool, opts.Blackouts)
+
+	for range opts.Blackouts {
+		p := profiles[pick(r, typeWeights)]
+		planned := r.Float64() < p.Planned
+
+		start := startTime(r, p, opts.From, days, planned)
+		end := start.Add(duration(r, p, start, planned))
+
+		blackout := Blackout{
+			ID:            blackoutID(r, ids),
+			StartDate:     start.Format(timeLayout),
+			EndDate:       end.Format(timeLayout),
+			Description:   description(r, p, planned),
+			Type:          p.Type,
+			InitiatorName: p.Initiators[r.IntN(len(p.Initiators))],
+			Source:        "vl.ru",
+		}
+
+		// emergencies of the last days of the data often have no end yet
+		if !planned && opts.To.Sub(start) < 3*24*time.Hour && r.Float64() < 0.5 {
+			blackout.EndDate = ""
+		}
+
+		bounds := p.EmergencyBuildings
+		if planned {
+			bounds = p.PlannedBuildings
+		}
+
+		street := pick(r, trouble)
+		blackout.Buildings = affected(r, houses[street], fake[street], bounds)
+
+		// works on a crossing reach the next street too
+		if r.Float64() < 0.15 && street+1 < opts.Streets {
+			blackout.Buildings = append(blackout.Buildings, affected(r, houses[street+1], fake[street+1], bounds)...)
+		}
+
+		city.Blackouts = append(city.Blackouts, blackout)
+	}
+
+	sort.SliceStable(city.Blackouts, func(i, j int) bool {
+		return city.Blackouts[i].StartDate < city.Blackouts[j].StartDate
+	})
+
+	return city, nil
+}
+
+func (opts Options) validate() error {
+	switch {
+	case opts.Streets < 1:
+		return errors.New("at least one street is required")
+	case opts.Buildings < opts.Streets:
+		return fmt.Errorf("%d buildings are too few for %d streets, every street needs one", opts.Buildings, opts.Streets)
+	case opts.Blackouts < 0:
+		return errors.New("the number of blackouts cannot be negative")
+	case opts.FakeShare < 0 || opts.FakeShare > 1:
+		return errors.New("the fake share must be between 0 and 1")
+	case opts.To.Sub(opts.From) < 24*time.Hour:
+		return errors.New("the period must be at least a day long")
+	}
+
+	return nil
+}
+
+// streetNames returns n distinct names, mostly streets, then lanes and avenues,
+// numbered streets once the plain names run out
+func streetNames(r *rand.Rand, n int) []string {
+	var streets, others []string
+	for _, name := range adjectives {
+		streets = append(streets, name+" ул.")
+	}
+	for _, name := range genitives {
+		streets = append(streets, name+" ул.")
+		for _, kind := range kinds {
+			others = append(others, name+" "+kind)
+		}
+	}
+	r.Shuffle(len(streets), func(i, j int) { streets[i], streets[j] = streets[j], streets[i] })
+	r.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+
+	names := append(streets, others...)
+	for i := 2; len(names) < n; i++ {
+		for _, name := range adjectives {
+			names = append(names, fmt.Sprintf("%d-я %s ул.", i, name))
+		}
+	}
+
+	return names[:n]
+}
+
+// spread splits total buildings over n streets, at least one each,
+// long streets are rarer than short ones
+func spread(r *rand.Rand, total int, n int) []int {
+	weights := make([]float64, n)
+	sum := 0.0
+	for i := range weights {
+		weights[i] = r.ExpFloat64()
+		sum += weights[i]
+	}
+
+	sizes := make([]int, n)
+	left := total
+	for i := range sizes {
+		sizes[i] = 1 + int(float64(total-n)*weights[i]/sum)
+		left -= sizes[i]
+	}
+	// what the rounding left goes to random streets
+	for ; left > 0; left-- {
+		sizes[r.IntN(n)]++
+	}
+
+	return sizes
+}
+
+// numbers returns n house numbers going up one side or both sides of a street,
+// with a letter now and then for a building in the yard
+func numbers(r *rand.Rand, n int) []string {
+	letters := []rune("абв")
+
+	numbers := make([]string, 0, n)
+	house, letter := 0, 0
+	step := 1 + r.IntN(2)
+
+	for range n {
+		if house > 0 && letter < len(letters) && r.Float64() < 0.1 {
+			numbers = append(numbers, strconv.Itoa(house)+string(letters[letter]))
+			letter++
+			continue
+		}
+
+		house += step
+		letter = 0
+		numbers = append(numbers, strconv.Itoa(house))
+	}
+
+	return numbers
+}
+
+// pick returns the index drawn from cumulative weights
+func pick(r *rand.Rand, cumulative []float64) int {
+	return sort.SearchFloat64s(cumulative, r.Float64()*cumulative[len(cumulative)-1])
+}
+
+// startTime draws a day by the season of the type. Planned works start on a
+// weekday morning, emergencies at any time.
+func startTime(r *rand.Rand, p profile, from time.Time, days int, planned bool) time.Time {
+	peak := 0.0
+	for _, v := range p.Season {
+		peak = max(peak, v)
+	}
+
+	var day time.Time
+	for {
+		day = from.AddDate(0, 0, r.IntN(days))
+		if planned && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		if r.Float64()*peak < p.Season[day.Month()-1] {
+			break
+		}
+	}
+
+	if !planned {
+		return day.Add(time.Duration(r.IntN(24*60)) * time.Minute)
+	}
+
+	return day.Add(time.Duration(8+r.IntN(4))*time.Hour + time.Duration(30*r.IntN(2))*time.Minute)
+}
+
+// duration is log-uniform between the bounds of the type, so short
+// blackouts are common and long ones happen
+func duration(r *rand.Rand, p profile, start time.Time, planned bool) time.Duration {
+	bounds := p.EmergencyHours
+	if planned {
+		bounds = p.PlannedHours
+		if p.SummerDays[1] > 0 && start.Month() >= time.June && start.Month() <= time.August {
+			bounds = [2]float64{p.SummerDays[0] * 24, p.SummerDays[1] * 24}
+		}
+	}
+
+	hours := bounds[0] * math.Pow(bounds[1]/bounds[0], r.Float64())
+	minutes := math.Round(hours*4) * 15
+
+	return time.Duration(max(minutes, 15)) * time.Minute
+}
+
+func description(r *rand.Rand, p profile, planned bool) string {
+	if r.Float64() < 0.1 {
+		return vague[r.IntN(len(vague))]
+	}
+	if planned {
+		return p.PlannedWorks[r.IntN(len(p.PlannedWorks))]
+	}
+	return p.Emergencies[r.IntN(len(p.Emergencies))]
+}
+
+// affected returns a run of neighbouring buildings of a street. The fake
+// building comes along when the whole street is off, and now and then it is
+// all the blackout names.
+func affected(r *rand.Rand, houses []int64, fake int64, bounds [2]int) []int64 {
+	if fake != 0 && r.Float64() < 0.03 {
+		return []int64{fake}
+	}
+
+	n := min(bounds[0]+r.IntN(bounds[1]-bounds[0]+1), len(houses))
+	first := r.IntN(len(houses) - n + 1)
+
+	buildings := append([]int64(nil), houses[first:first+n]...)
+	if fake != 0 && n == len(houses) {
+		buildings = append(buildings, fake)
+	}
+
+	return buildings
+}
+
+func blackoutID(r *rand.Rand, ids map[string]bool) string {
+	for {
+		id := fmt.Sprintf("%016x", r.Uint64())
+		if !ids[id] {
+			ids[id] = true
+			return id
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package synthetic
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage/sqlite"
+)
+
+func options() Options {
+	return Options{
+		Seed:      7,
+		Streets:   40,
+		Buildings: 600,
+		Blackouts: 2000,
+		FakeShare: 0.25,
+		From:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func generate(t *testing.T, opts Options) City {
+	t.Helper()
+
+	city, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return city
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	opts := options()
+
+	if !reflect.DeepEqual(generate(t, opts), generate(t, opts)) {
+		t.Error("the same seed gave two different cities")
+	}
+
+	other := opts
+	other.Seed++
+	if reflect.DeepEqual(generate(t, opts), generate(t, other)) {
+		t.Error("two seeds gave the same city")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	opts := options()
+	city := generate(t, opts)
+
+	if len(city.Streets) != opts.Streets {
+		t.Errorf("got %d streets, want %d", len(city.Streets), opts.Streets)
+	}
+	if len(city.Blackouts) != opts.Blackouts {
+		t.Errorf("got %d blackouts, want %d", len(city.Blackouts), opts.Blackouts)
+	}
+
+	names := map[string]bool{}
+	for _, street := range city.Streets {
+		if names[street.Name] {
+			t.Errorf("street %q repeats", street.Name)
+		}
+		names[street.Name] = true
+	}
+
+	type address struct {
+		street int64
+		number string
+	}
+	addresses := map[address]bool{}
+	buildings := map[int64]Building{}
+	real, fake := 0, 0
+	for _, building := range city.Buildings {
+		buildings[building.ID] = building
+		if building.IsFake {
+			fake++
+			continue
+		}
+		real++
+		a := address{building.StreetID, building.Number}
+		if addresses[a] {
+			t.Errorf("building %d %q repeats on street %d", building.ID, building.Number, building.StreetID)
+		}
+		addresses[a] = true
+	}
+	if real != opts.Buildings {
+		t.Errorf("got %d real buildings, want %d", real, opts.Buildings)
+	}
+	if fake == 0 {
+		t.Error("no fake buildings")
+	}
+
+	open := 0
+	for _, blackout := range city.Blackouts {
+		start, err := time.Parse(timeLayout, blackout.StartDate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if start.Before(opts.From) || !start.Before(opts.To) {
+			t.Errorf("blackout %s starts at %s, out of the period", blackout.ID, blackout.StartDate)
+		}
+		if blackout.Type == "heat" && start.Month() >= time.June && start.Month() <= time.August {
+			t.Errorf("heating blackout %s in summer", blackout.ID)
+		}
+
+		if blackout.EndDate == "" {
+			open++
+		} else if blackout.EndDate <= blackout.StartDate {
+			t.Errorf("blackout %s ends at %s before it starts", blackout.ID, blackout.EndDate)
+		}
+
+		if len(blackout.Buildings) == 0 {
+			t.Errorf("blackout %s has no buildings", blackout.ID)
+		}
+		for _, id := range blackout.Buildings {
+			if _, ok := buildings[id]; !ok {
+				t.Errorf("blackout %s has unknown building %d", blackout.ID, id)
+			}
+		}
+	}
+	if open == 0 {
+		t.Error("no open-ended blackouts")
+	}
+}
+
+func TestGenerateSummerHotWaterLasts(t *testing.T) {
+	city := generate(t, options())
+
+	var summer, winter []time.Duration
+	for _, blackout := range city.Blackouts {
+		if blackout.Type != "hot_water" || blackout.EndDate == "" {
+			continue
+		}
+		start, _ := time.Parse(timeLayout, blackout.StartDate)
+		end, _ := time.Parse(timeLayout, blackout.EndDate)
+
+		switch start.Month() {
+		case time.July:
+			summer = append(summer, end.Sub(start))
+		case time.January:
+			winter = append(winter, end.Sub(start))
+		}
+	}
+
+	if len(summer) <= len(winter) {
+		t.Errorf("got %d hot water blackouts in July and %d in January, want more in July", len(summer), len(winter))
+	}
+	if mean(summer) < 24*time.Hour || mean(winter) > 24*time.Hour {
+		t.Errorf("got mean hot water blackout of %s in July and %s in January", mean(summer), mean(winter))
+	}
+}
+
+func mean(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	return sum / time.Duration(len(durations))
+}
+
+func TestGenerateFakeShare(t *testing.T) {
+	tests := []struct {
+		name  string
+		share float64
+		want  int
+	}{
+		{"none", 0, 0},
+		{"every street", 1, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options()
+			opts.FakeShare = tt.share
+
+			fake := 0
+			for _, building := range generate(t, opts).Buildings {
+				if building.IsFake {
+					fake++
+				}
+			}
+			if fake != tt.want {
+				t.Errorf("got %d fake buildings, want %d", fake, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateManyStreets(t *testing.T) {
+	opts := options()
+	opts.Streets = 1000
+	opts.Buildings = 1000
+	opts.Blackouts = 10
+
+	names := map[string]bool{}
+	for _, street := range generate(t, opts).Streets {
+		names[street.Name] = true
+	}
+	if len(names) != opts.Streets {
+		t.Errorf("got %d distinct street names, want %d", len(names), opts.Streets)
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(opts *Options)
+	}{
+		{"no streets", func(opts *Options) { opts.Streets = 0 }},
+		{"fewer buildings than streets", func(opts *Options) { opts.Buildings = opts.Streets - 1 }},
+		{"negative blackouts", func(opts *Options) { opts.Blackouts = -1 }},
+		{"fake share over one", func(opts *Options) { opts.FakeShare = 1.5 }},
+		{"period shorter than a day", func(opts *Options) { opts.To = opts.From.Add(time.Hour) }},
+		{"period reversed", func(opts *Options) { opts.From, opts.To = opts.To, opts.From }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options()
+			tt.modify(&opts)
+
+			if _, err := Generate(opts); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	city := generate(t, options())
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	if err := Write(path, city); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := sqlite.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	streets, err := s.GetStreetsCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streets != len(city.Streets) {
+		t.Errorf("got %d streets, want %d", streets, len(city.Streets))
+	}
+
+	buildings, err := s.GetBuildingsCount(models.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buildings != int64(options().Buildings) {
+		t.Errorf("got %d buildings, want %d", buildings, options().Buildings)
+	}
+
+	// the history leaves out fake buildings and keeps NULL ends empty
+	want, open := 0, 0
+	fake := map[int64]bool{}
+	for _, building := range city.Buildings {
+		fake[building.ID] = building.IsFake
+	}
+	for _, blackout := range city.Blackouts {
+		for _, id := range blackout.Buildings {
+			if !fake[id] {
+				want++
+				if blackout.EndDate == "" {
+					open++
+				}
+			}
+		}
+	}
+
+	history, err := s.GetOutageHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != want {
+		t.Errorf("got %d outage records, want %d", len(history), want)
+	}
+	for _, record := range history {
+		if record.EndDate == "" {
+			open--
+		}
+	}
+	if open != 0 {
+		t.Errorf("open-ended records differ by %d", open)
+	}
+
+	if err := Write(path, city); err == nil {
+		t.Error("wrote over an existing database")
+	}
+}
@@ -0,0 +1,105 @@
+package synthetic
+
+import (
+	"database/sql"
+	"fmt"
+	"vlru-prsch/internal/storage/sqlite"
+)
+
+// Write creates the SQLite database at path with the source tables filled
+// from the city, then migrates it to the schema of the service.
+// The file must not exist yet.
+func Write(path string, city City) error {
+	const op = "synthetic.Write"
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer db.Close()
+
+	for _, stmt := range sqlite.SourceSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := insert(db, city); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	storage, err := sqlite.New(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return storage.Close()
+}
+
+func insert(db *sql.DB, city City) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	streets, err := tx.Prepare(`INSERT INTO streets (id, name) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer streets.Close()
+
+	for _, street := range city.Streets {
+		if _, err := streets.Exec(street.ID, street.Name); err != nil {
+			return err
+		}
+	}
+
+	buildings, err := tx.Prepare(`INSERT INTO buildings (id, street_id, number, is_fake) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer buildings.Close()
+
+	for _, building := range city.Buildings {
+		if _, err := buildings.Exec(building.ID, building.StreetID, building.Number, building.IsFake); err != nil {
+			return err
+		}
+	}
+
+	blackouts, err := tx.Prepare(`
+        INSERT INTO blackouts (id, start_date, end_date, description, type, initiator_name, source)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer blackouts.Close()
+
+	links, err := tx.Prepare(`INSERT INTO blackouts_buildings (blackout_id, building_id) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer links.Close()
+
+	for _, blackout := range city.Blackouts {
+		end := sql.NullString{String: blackout.EndDate, Valid: blackout.EndDate != ""}
+
+		_, err := blackouts.Exec(blackout.ID, blackout.StartDate, end, blackout.Description,
+			blackout.Type, blackout.InitiatorName, blackout.Source)
+		if err != nil {
+			return err
+		}
+
+		for _, building := range blackout.Buildings {
+			if _, err := links.Exec(blackout.ID, building); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,551 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+	"vlru-prsch/internal/summary"
+)
+
+const (
+	maxStreets   = 10
+	maxBuildings = 100
+	buildingsRow = 5
+
+	callbackStreet   = "street:"
+	callbackBuilding = "building:"
+	callbackCity     = "city:"
+
+	// sessionTTL is how long the buttons of a search keep working
+	sessionTTL = 30 * time.Minute
+)
+
+type Storage interface {
+	summary.Giver
+	FindStreets(substr string, filter models.Filter) ([]string, error)
+	GetStreetBuildings(street string, filter models.Filter) ([]models.Address, error)
+	SaveTelegramSubscription(chatID int64, buildingID int64, createdAt string) error
+	DeleteTelegramSubscriptions(chatID int64) (int64, error)
+	GetTelegramSubscriptions(chatID int64) ([]models.TelegramSubscription, error)
+	GetTelegramSubscriptionsByBuildings(buildingIDs []int64) ([]models.TelegramSubscription, error)
+	SetTelegramChatCity(chatID int64, city string) error
+	GetTelegramChatCity(chatID int64) (string, error)
+}
+
+type API interface {
+	GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error)
+	SendMessage(ctx context.Context, chatID int64, text string, markup *InlineKeyboardMarkup) error
+	AnswerCallbackQuery(ctx context.Context, id string) error
+}
+
+// Bot lets residents find their building, subscribe to it and ask what is off now.
+// Every chat searches in its city, the default one until it picks another with /city.
+// Search results are kept per chat in memory, so buttons refer to them by index,
+// until the chat subscribes or sessionTTL passes.
+type Bot struct {
+	log         *slog.Logger
+	api         API
+	store       Storage
+	types       models.ServiceTypes
+	pollTimeout time.Duration
+	cities      []models.City
+	now         func() time.Time
+
+	mu       sync.Mutex
+	sessions map[int64]*session
+}
+
+type session struct {
+	city      string
+	streets   []string
+	buildings map[int64]models.Address
+	updatedAt time.Time
+}
+
+func New(log *slog.Logger, api API, store Storage, types models.ServiceTypes, pollTimeout time.Duration, cities []models.City) *Bot {
+	return &Bot{
+		log:         log,
+		api:         api,
+		store:       store,
+		types:       types,
+		pollTimeout: pollTimeout,
+		cities:      cities,
+		now:         time.Now,
+		sessions:    make(map[int64]*session),
+	}
+}
+
+// Run long-polls the Bot API and forwards blackout events to subscribed chats until ctx is done.
+func (b *Bot) Run(ctx context.Context, hub events.Subscriber) {
+	const op = "telegram.Bot.Run"
+
+	log := b.log.With(slog.String("op", op))
+
+	go events.Consume(ctx, log, hub, events.Filter{}, func(e events.Event) { b.Notify(ctx, e) })
+
+	var offset int64
+	for ctx.Err() == nil {
+		b.sweepSessions()
+
+		updates, err := b.api.GetUpdates(ctx, offset, b.pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error("failed to get updates", sl.Err(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			b.Handle(ctx, update)
+		}
+	}
+}
+
+func (b *Bot) Handle(ctx context.Context, update Update) {
+	switch {
+	case update.CallbackQuery != nil:
+		b.handleCallback(ctx, update.CallbackQuery)
+	case update.Message != nil:
+		b.handleMessage(ctx, update.Message)
+	}
+}
+
+func (b *Bot) handleMessage(ctx context.Context, msg *Message) {
+	text := strings.TrimSpace(msg.Text)
+	chatID := msg.Chat.ID
+
+	switch {
+	case text == "/start" || text == "/help":
+		b.send(ctx, chatID, helpText(b.city(chatID)), nil)
+	case text == "/city":
+		b.sendCities(ctx, chatID)
+	case text == "/now" || isWhatsOffQuestion(text):
+		b.sendSummary(ctx, chatID)
+	case text == "/my":
+		b.sendSubscriptions(ctx, chatID)
+	case text == "/stop":
+		b.unsubscribe(ctx, chatID)
+	case strings.HasPrefix(text, "/"):
+		b.send(ctx, chatID, "Неизвестная команда.\n\n"+helpText(b.city(chatID)), nil)
+	default:
+		b.searchStreets(ctx, chatID, text)
+	}
+}
+
+func (b *Bot) handleCallback(ctx context.Context, query *CallbackQuery) {
+	if err := b.api.AnswerCallbackQuery(ctx, query.ID); err != nil {
+		b.log.Warn("failed to answer callback query", sl.Err(err))
+	}
+
+	if query.Message == nil {
+		return
+	}
+	chatID := query.Message.Chat.ID
+
+	switch {
+	case strings.HasPrefix(query.Data, callbackStreet):
+		idx, err := strconv.Atoi(strings.TrimPrefix(query.Data, callbackStreet))
+		if err != nil {
+			return
+		}
+		b.pickStreet(ctx, chatID, idx)
+	case strings.HasPrefix(query.Data, callbackBuilding):
+		id, err := strconv.ParseInt(strings.TrimPrefix(query.Data, callbackBuilding), 10, 64)
+		if err != nil {
+			return
+		}
+		b.subscribe(ctx, chatID, id)
+	case strings.HasPrefix(query.Data, callbackCity):
+		b.pickCity(ctx, chatID, strings.TrimPrefix(query.Data, callbackCity))
+	}
+}
+
+// city returns the city the chat picked, the default one when it has not
+func (b *Bot) city(chatID int64) models.City {
+	code, err := b.store.GetTelegramChatCity(chatID)
+	if err != nil {
+		b.log.Error("failed to get chat city", slog.Int64("chat_id", chatID), sl.Err(err))
+	}
+
+	var fallback models.City
+	for _, city := range b.cities {
+		if code != "" && city.Code == code {
+			return city
+		}
+		if city.IsDefault {
+			fallback = city
+		}
+	}
+
+	return fallback
+}
+
+func (b *Bot) sendCities(ctx context.Context, chatID int64) {
+	current := b.city(chatID)
+
+	markup := &InlineKeyboardMarkup{}
+	for _, city := range b.cities {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []InlineKeyboardButton{{
+			Text:         city.Name,
+			CallbackData: callbackCity + city.Code,
+		}})
+	}
+
+	b.send(ctx, chatID, "Ваш город: "+current.Name+". Выберите город:", markup)
+}
+
+func (b *Bot) pickCity(ctx context.Context, chatID int64, code string) {
+	var picked models.City
+	for _, city := range b.cities {
+		if city.Code == code {
+			picked = city
+		}
+	}
+
+	if picked.Code == "" {
+		b.send(ctx, chatID, "Такого города нет, выберите город еще раз: /city", nil)
+		return
+	}
+
+	if err := b.store.SetTelegramChatCity(chatID, picked.Code); err != nil {
+		b.log.Error("failed to set chat city", slog.Int64("chat_id", chatID), slog.String("city", picked.Code), sl.Err(err))
+		b.send(ctx, chatID, errorText, nil)
+		return
+	}
+
+	// the search of the old city has no use
+	b.mu.Lock()
+	delete(b.sessions, chatID)
+	b.mu.Unlock()
+
+	b.send(ctx, chatID, "Ваш город: "+picked.Name+". Напишите название своей улицы.", nil)
+}
+
+func (b *Bot) searchStreets(ctx context.Context, chatID int64, text string) {
+	if utf8.RuneCountInString(text) < 2 {
+		b.send(ctx, chatID, "Введите хотя бы 2 буквы названия улицы.", nil)
+		return
+	}
+
+	city := b.city(chatID)
+
+	streets, err := b.store.FindStreets(text, models.Filter{City: city.Code})
+	if err != nil {
+		b.log.Error("failed to find streets", slog.String("suggest", text), sl.Err(err))
+		b.send(ctx, chatID, errorText, nil)
+		return
+	}
+
+	if len(streets) == 0 {
+		b.send(ctx, chatID, "Улицы не найдены. Попробуйте написать название иначе.", nil)
+		return
+	}
+
+	reply := "Выберите улицу:"
+	if len(streets) > maxStreets {
+		streets = streets[:maxStreets]
+		reply = "Найдено много улиц, показаны первые " + strconv.Itoa(maxStreets) + ". Уточните запрос или выберите улицу:"
+	}
+
+	b.mu.Lock()
+	b.sessions[chatID] = &session{city: city.Code, streets: streets, updatedAt: b.now()}
+	b.mu.Unlock()
+
+	markup := &InlineKeyboardMarkup{}
+	for i, street := range streets {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []InlineKeyboardButton{{
+			Text:         street,
+			CallbackData: callbackStreet + strconv.Itoa(i),
+		}})
+	}
+
+	b.send(ctx, chatID, reply, markup)
+}
+
+func (b *Bot) pickStreet(ctx context.Context, chatID int64, idx int) {
+	sess, ok := b.session(chatID)
+
+	if !ok || idx < 0 || idx >= len(sess.streets) {
+		b.send(ctx, chatID, "Поиск устарел, напишите название улицы еще раз.", nil)
+		return
+	}
+
+	street := sess.streets[idx]
+
+	buildings, err := b.store.GetStreetBuildings(street, models.Filter{City: sess.city})
+	if err != nil {
+		b.log.Error("failed to get street buildings", slog.String("street", street), sl.Err(err))
+		b.send(ctx, chatID, errorText, nil)
+		return
+	}
+
+	if len(buildings) == 0 {
+		b.send(ctx, chatID, "На улице "+street+" нет домов в базе.", nil)
+		return
+	}
+
+	reply := "Выберите дом на улице " + street + ":"
+	if len(buildings) > maxBuildings {
+		buildings = buildings[:maxBuildings]
+		reply = "На улице " + street + " много домов, показаны первые " + strconv.Itoa(maxBuildings) + ":"
+	}
+
+	b.mu.Lock()
+	sess.buildings = make(map[int64]models.Address, len(buildings))
+	for _, building := range buildings {
+		sess.buildings[building.BuildingID] = building
+	}
+	sess.updatedAt = b.now()
+	b.mu.Unlock()
+
+	markup := &InlineKeyboardMarkup{}
+	var row []InlineKeyboardButton
+	for _, building := range buildings {
+		row = append(row, InlineKeyboardButton{
+			Text:         building.Number,
+			CallbackData: callbackBuilding + strconv.FormatInt(building.BuildingID, 10),
+		})
+		if len(row) == buildingsRow {
+			markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+	}
+
+	b.send(ctx, chatID, reply, markup)
+}
+
+func (b *Bot) subscribe(ctx context.Context, chatID int64, buildingID int64) {
+	var address models.Address
+	sess, ok := b.session(chatID)
+	if ok {
+		b.mu.Lock()
+		address, ok = sess.buildings[buildingID]
+		b.mu.Unlock()
+	}
+
+	if !ok {
+		b.send(ctx, chatID, "Поиск устарел, напишите название улицы еще раз.", nil)
+		return
+	}
+
+	err := b.store.SaveTelegramSubscription(chatID, buildingID, b.now().Format("2006-01-02 15:04:05"))
+	if errors.Is(err, storage.ErrSubscriptionExists) {
+		b.send(ctx, chatID, "Вы уже подписаны на адрес "+formatAddress(address)+".", nil)
+		return
+	}
+	if err != nil {
+		b.log.Error("failed to save subscription", slog.Int64("building_id", buildingID), sl.Err(err))
+		b.send(ctx, chatID, errorText, nil)
+		return
+	}
+
+	b.mu.Lock()
+	delete(b.sessions, chatID)
+	b.mu.Unlock()
+
+	b.log.Info("telegram subscription created", slog.Int64("chat_id", chatID), slog.Int64("building_id", buildingID))
+
+	b.send(ctx, chatID, "Готово! Буду сообщать об отключениях по адресу "+formatAddress(address)+".\n\n/my - мои подписки, /stop - отписаться от всех.", nil)
+}
+
+// session returns the search of the chat unless it has expired
+func (b *Bot) session(chatID int64) (*session, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sess, ok := b.sessions[chatID]
+	if !ok || b.now().Sub(sess.updatedAt) > sessionTTL {
+		return nil, false
+	}
+
+	return sess, true
+}
+
+// sweepSessions forgets the searches nobody has touched for sessionTTL
+func (b *Bot) sweepSessions() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	for chatID, sess := range b.sessions {
+		if now.Sub(sess.updatedAt) > sessionTTL {
+			delete(b.sessions, chatID)
+		}
+	}
+}
+
+func (b *Bot) unsubscribe(ctx context.Context, chatID int64) {
+	deleted, err := b.store.DeleteTelegramSubscriptions(chatID)
+	if err != nil {
+		b.log.Error("failed to delete subscriptions", slog.Int64("chat_id", chatID), sl.Err(err))
+		b.send(ctx, chatID, errorText, nil)
+		return
+	}
+
+	if deleted == 0 {
+		b.send(ctx, chatID, "У вас нет подписок.", nil)
+		return
+	}
+
+	b.send(ctx, chatID, "Вы отписались от всех адресов.", nil)
+}
+
+func (b *Bot) sendSubscriptions(ctx context.Context, chatID int64) {
+	subs, err := b.store.GetTelegramSubscriptions(chatID)
+	if err != nil {
+		b.log.Error("failed to get subscriptions", slog.Int64("chat_id", chatID), sl.Err(err))
+		b.send(ctx, chatID, errorText, nil)
+		return
+	}
+
+	if len(subs) == 0 {
+		b.send(ctx, chatID, "У вас нет подписок. Напишите название улицы, чтобы подписаться.", nil)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Ваши адреса:\n")
+	for _, sub := range subs {
+		sb.WriteString("• " + formatAddress(sub.Address) + "\n")
+	}
+
+	b.send(ctx, chatID, sb.String(), nil)
+}
+
+// sendSummary tells what is off in the city of the chat, at the wall clock time of the city
+func (b *Bot) sendSummary(ctx context.Context, chatID int64) {
+	city := b.city(chatID)
+
+	now := b.now()
+	if city.Location != nil {
+		now = now.In(city.Location)
+	}
+
+	summaries, err := summary.Collect(b.log, b.store, b.types, now.Format("2006-01-02 15:04:05"), models.Filter{City: city.Code})
+	if err != nil {
+		b.log.Error("failed to collect summary", sl.Err(err))
+		b.send(ctx, chatID, errorText, nil)
+		return
+	}
+
+	b.send(ctx, chatID, FormatSummary(now, summaries, b.types), nil)
+}
+
+// Notify tells subscribed chats about created and started blackouts touching their buildings
+func (b *Bot) Notify(ctx context.Context, e events.Event) {
+	if e.Blackout == nil || (e.Type != events.BlackoutCreated && e.Type != events.BlackoutStarted) {
+		return
+	}
+
+	buildingIDs := make([]int64, 0, len(e.Blackout.Addresses))
+	for _, address := range e.Blackout.Addresses {
+		buildingIDs = append(buildingIDs, address.BuildingID)
+	}
+
+	subs, err := b.store.GetTelegramSubscriptionsByBuildings(buildingIDs)
+	if err != nil {
+		b.log.Error("failed to get subscriptions", slog.String("blackout_id", e.Blackout.ID), sl.Err(err))
+		return
+	}
+
+	for _, sub := range subs {
+		b.send(ctx, sub.ChatID, FormatBlackout(e.Type, sub.Address, e.Blackout, b.types), nil)
+	}
+}
+
+func (b *Bot) send(ctx context.Context, chatID int64, text string, markup *InlineKeyboardMarkup) {
+	if err := b.api.SendMessage(ctx, chatID, text, markup); err != nil {
+		b.log.Error("failed to send message", slog.Int64("chat_id", chatID), sl.Err(err))
+	}
+}
+
+func isWhatsOffQuestion(text string) bool {
+	text = strings.ToLower(text)
+	return strings.Contains(text, "что отключ") || strings.Contains(text, "что сейчас")
+}
+
+func formatAddress(address models.Address) string {
+	return address.Street + " " + address.Number
+}
+
+// FormatSummary renders the /off/blackouts summary as a chat message
+func FormatSummary(now time.Time, summaries []summary.TypeSummary, types models.ServiceTypes) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Отключения на %s:\n", now.Format("02.01.2006 15:04"))
+	for _, s := range summaries {
+		fmt.Fprintf(&sb, "• %s: %d %s (%.2f%%)\n", types.Name(s.Type), s.CountBuildings, houses(s.CountBuildings), s.FractionBuildings)
+	}
+
+	return sb.String()
+}
+
+// houses returns the Russian plural form of "дом" for n
+func houses(n int64) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "дом"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "дома"
+	default:
+		return "домов"
+	}
+}
+
+// FormatBlackout renders a blackout notification for a subscribed address
+func FormatBlackout(eventType string, address models.Address, blackout *events.Blackout, types models.ServiceTypes) string {
+	var sb strings.Builder
+
+	if eventType == events.BlackoutCreated {
+		sb.WriteString("Запланировано отключение по адресу " + formatAddress(address) + "\n")
+	} else {
+		sb.WriteString("Началось отключение по адресу " + formatAddress(address) + "\n")
+	}
+
+	sb.WriteString("Услуга: " + types.Name(blackout.Type) + "\n")
+	sb.WriteString("Начало: " + blackout.StartDate + "\n")
+	if blackout.EndDate != "" {
+		sb.WriteString("Окончание: " + blackout.EndDate + "\n")
+	} else {
+		sb.WriteString("Окончание: не указано\n")
+	}
+	sb.WriteString("Организация: " + blackout.InitiatorName)
+	if blackout.Description != "" {
+		sb.WriteString("\n" + blackout.Description)
+	}
+
+	return sb.String()
+}
+
+func helpText(city models.City) string {
+	return `Здравствуйте! Я бот VL.RU/off, сообщаю об отключениях воды, света и отопления.
+
+Ваш город: ` + city.Name + `. Напишите название своей улицы, выберите дом - и я буду присылать уведомления об отключениях.
+
+/now - что отключено сейчас
+/my - мои подписки
+/city - выбрать другой город
+/stop - отписаться от всех адресов`
+}
+
+const errorText = "Что-то пошло не так, попробуйте позже."
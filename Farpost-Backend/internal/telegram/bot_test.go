@@ -0,0 +1,496 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+const (
+	token  = "123:secret"
+	chatID = 42
+)
+
+// store knows the buildings of three streets and keeps the subscriptions
+// and the cities picked by chats in memory
+type store struct {
+	mu         sync.Mutex
+	subs       []models.TelegramSubscription
+	chatCities map[int64]string
+}
+
+var buildings = []models.Address{
+	{BuildingID: 10, Street: "Карбышева ул.", Number: "54"},
+	{BuildingID: 11, Street: "Карбышева ул.", Number: "56"},
+	{BuildingID: 12, Street: "Светланская ул.", Number: "1"},
+	{BuildingID: 20, Street: "Ленина ул.", Number: "5"},
+}
+
+// streetCities holds the city of every street, the streets of Vladivostok are the default ones
+var streetCities = map[string]string{"Ленина ул.": "khabarovsk"}
+
+var cities = []models.City{
+	{Code: "vladivostok", Name: "Владивосток", IsDefault: true},
+	{Code: "khabarovsk", Name: "Хабаровск"},
+}
+
+func inCity(street string, filter models.Filter) bool {
+	city := streetCities[street]
+	if city == "" {
+		city = "vladivostok"
+	}
+	return city == filter.City
+}
+
+func (s *store) GetBuildingsCount(filter models.Filter) (int64, error) {
+	var count int64
+	for _, building := range buildings {
+		if inCity(building.Street, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *store) GetBuildingsCountByBlackoutType(blackoutType string, currentTime string, filter models.Filter) (int64, error) {
+	return map[string]int64{"hot_water": 2, "electricity": 1}[blackoutType], nil
+}
+
+func (s *store) GetLastBlackoutTimeByType(blackoutType string, currentTime string, filter models.Filter) (string, error) {
+	return "", nil
+}
+
+func (s *store) FindStreets(substr string, filter models.Filter) ([]string, error) {
+	var streets []string
+	for _, building := range buildings {
+		if inCity(building.Street, filter) && strings.Contains(strings.ToLower(building.Street), strings.ToLower(substr)) && !slices.Contains(streets, building.Street) {
+			streets = append(streets, building.Street)
+		}
+	}
+	return streets, nil
+}
+
+func (s *store) GetStreetBuildings(street string, filter models.Filter) ([]models.Address, error) {
+	var found []models.Address
+	for _, building := range buildings {
+		if building.Street == street && inCity(street, filter) {
+			found = append(found, building)
+		}
+	}
+	return found, nil
+}
+
+func (s *store) SetTelegramChatCity(chatID int64, city string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.chatCities == nil {
+		s.chatCities = map[int64]string{}
+	}
+	s.chatCities[chatID] = city
+	return nil
+}
+
+func (s *store) GetTelegramChatCity(chatID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.chatCities[chatID], nil
+}
+
+func (s *store) SaveTelegramSubscription(chatID int64, buildingID int64, createdAt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		if sub.ChatID == chatID && sub.Address.BuildingID == buildingID {
+			return storage.ErrSubscriptionExists
+		}
+	}
+	for _, building := range buildings {
+		if building.BuildingID == buildingID {
+			s.subs = append(s.subs, models.TelegramSubscription{ChatID: chatID, Address: building})
+		}
+	}
+	return nil
+}
+
+func (s *store) DeleteTelegramSubscriptions(chatID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.subs)
+	s.subs = slices.DeleteFunc(s.subs, func(sub models.TelegramSubscription) bool { return sub.ChatID == chatID })
+	return int64(before - len(s.subs)), nil
+}
+
+func (s *store) GetTelegramSubscriptions(chatID int64) ([]models.TelegramSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subs []models.TelegramSubscription
+	for _, sub := range s.subs {
+		if sub.ChatID == chatID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (s *store) GetTelegramSubscriptionsByBuildings(buildingIDs []int64) ([]models.TelegramSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subs []models.TelegramSubscription
+	for _, sub := range s.subs {
+		if slices.Contains(buildingIDs, sub.Address.BuildingID) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+type sentMessage struct {
+	text    string
+	buttons []string
+}
+
+// fakeAPI is a Bot API server handing out the queued updates one per
+// getUpdates call and recording the sent messages
+type fakeAPI struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	updates []Update
+	sent    chan sentMessage
+}
+
+func newFakeAPI(t *testing.T, updates ...Update) (*fakeAPI, *Client) {
+	t.Helper()
+
+	for i := range updates {
+		updates[i].UpdateID = int64(i + 1)
+	}
+
+	api := &fakeAPI{t: t, updates: updates, sent: make(chan sentMessage, 100)}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	return api, NewClient(server.URL, token, 0)
+}
+
+func (api *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+token+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		api.t.Error(err)
+	}
+
+	var result any = true
+	switch method {
+	case "getUpdates":
+		offset, _ := strconv.ParseInt(r.Form.Get("offset"), 10, 64)
+		result = api.next(offset)
+		if len(result.([]Update)) == 0 {
+			// a long poll with nothing new
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	case "sendMessage":
+		if r.Form.Get("chat_id") != strconv.Itoa(chatID) {
+			api.t.Errorf("message to chat %s", r.Form.Get("chat_id"))
+		}
+		msg := sentMessage{text: r.Form.Get("text")}
+		if markup := r.Form.Get("reply_markup"); markup != "" {
+			var keyboard InlineKeyboardMarkup
+			if err := json.Unmarshal([]byte(markup), &keyboard); err != nil {
+				api.t.Error(err)
+			}
+			for _, row := range keyboard.InlineKeyboard {
+				for _, button := range row {
+					msg.buttons = append(msg.buttons, button.Text+"="+button.CallbackData)
+				}
+			}
+		}
+		api.sent <- msg
+	case "answerCallbackQuery":
+	default:
+		api.t.Errorf("unexpected method %s", method)
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+func (api *fakeAPI) next(offset int64) []Update {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	for _, update := range api.updates {
+		if update.UpdateID >= offset {
+			return []Update{update}
+		}
+	}
+	return []Update{}
+}
+
+// reply waits for the next message sent by the bot
+func (api *fakeAPI) reply(t *testing.T) sentMessage {
+	t.Helper()
+
+	select {
+	case msg := <-api.sent:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reply")
+		return sentMessage{}
+	}
+}
+
+func text(text string) Update {
+	return Update{Message: &Message{Chat: Chat{ID: chatID}, Text: text}}
+}
+
+func button(data string) Update {
+	return Update{CallbackQuery: &CallbackQuery{ID: data, Message: &Message{Chat: Chat{ID: chatID}}, Data: data}}
+}
+
+// run runs the bot until the test ends
+func run(t *testing.T, bot *Bot, hub *events.Hub) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bot.Run(ctx, hub)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestBotSubscribes(t *testing.T) {
+	api, client := newFakeAPI(t,
+		text("карб"),
+		button(callbackStreet+"0"),
+		button(callbackBuilding+"10"),
+		button(callbackBuilding+"11"),
+		text("/my"),
+		text("/stop"),
+		text("/stop"),
+	)
+	s := &store{}
+	run(t, New(slog.New(slog.DiscardHandler), client, s, models.DefaultServiceTypes(), 0, cities), events.NewHub(1, 1, 0))
+
+	replies := []sentMessage{
+		{"Выберите улицу:", []string{"Карбышева ул.=street:0"}},
+		{"Выберите дом на улице Карбышева ул.:", []string{"54=building:10", "56=building:11"}},
+		{"Готово! Буду сообщать об отключениях по адресу Карбышева ул. 54.\n\n/my - мои подписки, /stop - отписаться от всех.", nil},
+		// the search is over once the chat has subscribed
+		{"Поиск устарел, напишите название улицы еще раз.", nil},
+		{"Ваши адреса:\n• Карбышева ул. 54\n", nil},
+		{"Вы отписались от всех адресов.", nil},
+		{"У вас нет подписок.", nil},
+	}
+	for i, want := range replies {
+		got := api.reply(t)
+		if got.text != want.text || strings.Join(got.buttons, ",") != strings.Join(want.buttons, ",") {
+			t.Errorf("reply %d: got %q %v, want %q %v", i+1, got.text, got.buttons, want.text, want.buttons)
+		}
+	}
+}
+
+func TestBotPicksCity(t *testing.T) {
+	api, client := newFakeAPI(t,
+		text("/city"),
+		button(callbackCity+"moscow"),
+		button(callbackCity+"khabarovsk"),
+		text("лен"),
+		button(callbackStreet+"0"),
+		text("карб"),
+	)
+	run(t, New(slog.New(slog.DiscardHandler), client, &store{}, models.DefaultServiceTypes(), 0, cities), events.NewHub(1, 1, 0))
+
+	replies := []sentMessage{
+		{"Ваш город: Владивосток. Выберите город:", []string{"Владивосток=city:vladivostok", "Хабаровск=city:khabarovsk"}},
+		{"Такого города нет, выберите город еще раз: /city", nil},
+		{"Ваш город: Хабаровск. Напишите название своей улицы.", nil},
+		{"Выберите улицу:", []string{"Ленина ул.=street:0"}},
+		{"Выберите дом на улице Ленина ул.:", []string{"5=building:20"}},
+		// the streets of Vladivostok are not searched any more
+		{"Улицы не найдены. Попробуйте написать название иначе.", nil},
+	}
+	for i, want := range replies {
+		got := api.reply(t)
+		if got.text != want.text || strings.Join(got.buttons, ",") != strings.Join(want.buttons, ",") {
+			t.Errorf("reply %d: got %q %v, want %q %v", i+1, got.text, got.buttons, want.text, want.buttons)
+		}
+	}
+}
+
+func TestBotNotifies(t *testing.T) {
+	api, client := newFakeAPI(t)
+	s := &store{}
+	if err := s.SaveTelegramSubscription(chatID, 11, "2024-03-10 12:00:00"); err != nil {
+		t.Fatal(err)
+	}
+
+	hub := events.NewHub(10, 10, 0)
+	run(t, New(slog.New(slog.DiscardHandler), client, s, models.DefaultServiceTypes(), 0, cities), hub)
+
+	blackout := func(id string, buildingID int64) *events.Blackout {
+		return &events.Blackout{
+			ID:            id,
+			Type:          "hot_water",
+			StartDate:     "2024-03-10 09:00:00",
+			InitiatorName: "Водоканал",
+			Addresses:     []models.Address{{BuildingID: buildingID}},
+		}
+	}
+
+	// the bot subscribes to the hub in the background, publish until it is listening
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		hub.Publish(events.Event{Type: events.BlackoutStarted, Blackout: blackout("b1", 11)})
+		select {
+		case got := <-api.sent:
+			want := "Началось отключение по адресу Карбышева ул. 56\nУслуга: горячая вода\nНачало: 2024-03-10 09:00:00\nОкончание: не указано\nОрганизация: Водоканал"
+			if got.text != want {
+				t.Errorf("got %q, want %q", got.text, want)
+			}
+		case <-time.After(10 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("no notification")
+			}
+			continue
+		}
+		break
+	}
+
+}
+
+func TestBotNotify(t *testing.T) {
+	api, client := newFakeAPI(t)
+	s := &store{}
+	if err := s.SaveTelegramSubscription(chatID, 11, "2024-03-10 12:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	bot := New(slog.New(slog.DiscardHandler), client, s, models.DefaultServiceTypes(), 0, cities)
+
+	blackout := func(buildingID int64) *events.Blackout {
+		return &events.Blackout{
+			Type:          "electricity",
+			StartDate:     "2024-03-10 09:00:00",
+			EndDate:       "2024-03-10 18:00:00",
+			Description:   "Замена кабеля",
+			InitiatorName: "Дальэнерго",
+			Addresses:     []models.Address{{BuildingID: 10}, {BuildingID: buildingID}},
+		}
+	}
+	planned := "Запланировано отключение по адресу Карбышева ул. 56\nУслуга: электричество\nНачало: 2024-03-10 09:00:00\nОкончание: 2024-03-10 18:00:00\nОрганизация: Дальэнерго\nЗамена кабеля"
+
+	tests := []struct {
+		name  string
+		event events.Event
+		want  []string
+	}{
+		{"created", events.Event{Type: events.BlackoutCreated, Blackout: blackout(11)}, []string{planned}},
+		{"other buildings", events.Event{Type: events.BlackoutStarted, Blackout: blackout(12)}, nil},
+		{"ended", events.Event{Type: events.BlackoutEnded, Blackout: blackout(11)}, nil},
+		{"changed", events.Event{Type: events.BlackoutChanged, Blackout: blackout(11)}, nil},
+		{"counts", events.Event{Type: events.CountsChanged, Counts: map[string]int64{"electricity": 2}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot.Notify(context.Background(), tt.event)
+
+			// the API is called synchronously, so whatever was sent is queued by now
+			var got []string
+			for len(api.sent) > 0 {
+				got = append(got, (<-api.sent).text)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotSessionExpires(t *testing.T) {
+	api, client := newFakeAPI(t)
+	bot := New(slog.New(slog.DiscardHandler), client, &store{}, models.DefaultServiceTypes(), 0, cities)
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	bot.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		update  Update
+		want    string
+		left    int
+	}{
+		{"search", 0, text("карб"), "Выберите улицу:", 1},
+		{"pick a street within the TTL", sessionTTL, button(callbackStreet + "0"), "Выберите дом на улице Карбышева ул.:", 1},
+		{"picking renews the TTL", sessionTTL, button(callbackBuilding + "10"), "Готово! Буду сообщать об отключениях по адресу Карбышева ул. 54.\n\n/my - мои подписки, /stop - отписаться от всех.", 0},
+		{"search again", 0, text("свет"), "Выберите улицу:", 1},
+		{"pick a street after the TTL", sessionTTL + time.Second, button(callbackStreet + "0"), "Поиск устарел, напишите название улицы еще раз.", 0},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		bot.Handle(context.Background(), tt.update)
+		bot.sweepSessions()
+
+		if got := api.reply(t); got.text != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got.text, tt.want)
+		}
+		if len(bot.sessions) != tt.left {
+			t.Errorf("%s: got %d sessions, want %d", tt.name, len(bot.sessions), tt.left)
+		}
+	}
+}
+
+func TestBotSummary(t *testing.T) {
+	api, client := newFakeAPI(t)
+	bot := New(slog.New(slog.DiscardHandler), client, &store{}, models.DefaultServiceTypes(), 0, cities)
+	bot.now = func() time.Time { return time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC) }
+
+	want := "Отключения на 10.03.2024 12:00:\n• горячая вода: 2 дома (66.67%)\n• холодная вода: 0 домов (0.00%)\n• электричество: 1 дом (33.33%)\n• отопление: 0 домов (0.00%)\n"
+
+	for _, question := range []string{"/now", "Что сейчас отключено?"} {
+		bot.Handle(context.Background(), text(question))
+
+		if got := api.reply(t); got.text != want {
+			t.Errorf("%s: got %q, want %q", question, got.text, want)
+		}
+	}
+}
+
+func TestHouses(t *testing.T) {
+	tests := map[int64]string{0: "домов", 1: "дом", 2: "дома", 5: "домов", 11: "домов", 12: "домов", 21: "дом", 22: "дома", 111: "домов"}
+
+	for n, want := range tests {
+		if got := houses(n); got != want {
+			t.Errorf("houses(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Client is a minimal Telegram Bot API client. The base URL is configurable,
+// so the bot can run against a local fake server.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+func NewClient(baseURL, token string, pollTimeout time.Duration) *Client {
+	return &Client{
+		baseURL: baseURL,
+		token:   token,
+		http:    &http.Client{Timeout: pollTimeout + 10*time.Second},
+	}
+}
+
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	params := url.Values{}
+	params.Set("offset", strconv.FormatInt(offset, 10))
+	params.Set("timeout", strconv.Itoa(int(timeout.Seconds())))
+	params.Set("allowed_updates", `["message","callback_query"]`)
+
+	var updates []Update
+	if err := c.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string, markup *InlineKeyboardMarkup) error {
+	params := url.Values{}
+	params.Set("chat_id", strconv.FormatInt(chatID, 10))
+	params.Set("text", text)
+
+	if markup != nil {
+		b, err := json.Marshal(markup)
+		if err != nil {
+			return err
+		}
+		params.Set("reply_markup", string(b))
+	}
+
+	return c.call(ctx, "sendMessage", params, nil)
+}
+
+func (c *Client) AnswerCallbackQuery(ctx context.Context, id string) error {
+	params := url.Values{}
+	params.Set("callback_query_id", id)
+
+	return c.call(ctx, "answerCallbackQuery", params, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, params url.Values, result any) error {
+	const op = "telegram.Client.call"
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBufferString(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", op, method, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// the token is part of the URL, so it must not leak into logs
+		return fmt.Errorf("%s: %s: request failed: %w", op, method, unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("%s: %s: failed to decode response: %w", op, method, err)
+	}
+
+	if !apiResp.OK {
+		return fmt.Errorf("%s: %s: %s", op, method, apiResp.Description)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(apiResp.Result, result); err != nil {
+			return fmt.Errorf("%s: %s: failed to decode result: %w", op, method, err)
+		}
+	}
+
+	return nil
+}
+
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"vlru-prsch/internal/lib/random"
+)
+
+const (
+	HeaderEvent     = "X-Off-Event"
+	HeaderDelivery  = "X-Off-Delivery"
+	HeaderTimestamp = "X-Off-Timestamp"
+	HeaderSignature = "X-Off-Signature"
+)
+
+// Sign returns the value of the signature header: "sha256=" followed by
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the webhook secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature produced by Sign. Receivers can use it as a reference.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func NewSecret() (string, error) {
+	return random.Token(32)
+}
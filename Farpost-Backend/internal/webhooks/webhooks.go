@@ -0,0 +1,276 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/lib/logger/sl"
+	"vlru-prsch/internal/models"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+type Storage interface {
+	GetWebhooks(filter models.Filter) ([]models.Webhook, error)
+	GetWebhook(id int64, filter models.Filter) (models.Webhook, error)
+	SaveWebhookDelivery(delivery models.WebhookDelivery) (int64, error)
+	UpdateWebhookDelivery(delivery models.WebhookDelivery) error
+	GetDueWebhookDeliveries(currentTime string, limit int) ([]models.WebhookDelivery, error)
+}
+
+type Options struct {
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+	BatchSize    int
+}
+
+// Dispatcher turns hub events into persistent deliveries and sends them
+// with retries. The queue lives in SQLite, so pending deliveries survive restarts.
+type Dispatcher struct {
+	log    *slog.Logger
+	store  Storage
+	hub    events.Subscriber
+	client *http.Client
+	opts   Options
+	now    func() time.Time
+}
+
+func New(log *slog.Logger, store Storage, hub events.Subscriber, opts Options) *Dispatcher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+
+	return &Dispatcher{
+		log:    log,
+		store:  store,
+		hub:    hub,
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Run enqueues events from the hub and delivers due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	go events.Consume(ctx, d.log.With(slog.String("op", "webhooks.Dispatcher.Run")), d.hub, events.Filter{}, d.Enqueue)
+
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.DeliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enqueue stores a pending delivery of the event for every matching webhook.
+func (d *Dispatcher) Enqueue(e events.Event) {
+	const op = "webhooks.Dispatcher.Enqueue"
+
+	log := d.log.With(slog.String("op", op), slog.Int64("event_id", e.ID))
+
+	webhooks, err := d.store.GetWebhooks(models.Filter{})
+	if err != nil {
+		log.Error("failed to get webhooks", sl.Err(err))
+		return
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Error("failed to marshal event", sl.Err(err))
+		return
+	}
+
+	currentTime := d.now().Format(timeLayout)
+
+	for _, webhook := range webhooks {
+		if !Match(webhook, e) {
+			continue
+		}
+
+		_, err := d.store.SaveWebhookDelivery(models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventType:     e.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: currentTime,
+			CreatedAt:     currentTime,
+		})
+		if err != nil {
+			log.Error("failed to save delivery", slog.Int64("webhook_id", webhook.ID), sl.Err(err))
+		}
+	}
+}
+
+// DeliverDue sends every delivery whose next attempt time has come.
+func (d *Dispatcher) DeliverDue(ctx context.Context) {
+	const op = "webhooks.Dispatcher.DeliverDue"
+
+	log := d.log.With(slog.String("op", op))
+
+	deliveries, err := d.store.GetDueWebhookDeliveries(d.now().Format(timeLayout), d.opts.BatchSize)
+	if err != nil {
+		log.Error("failed to get due deliveries", sl.Err(err))
+		return
+	}
+
+	webhooks := make(map[int64]models.Webhook)
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.store.GetWebhook(delivery.WebhookID, models.Filter{})
+			if err != nil {
+				log.Error("failed to get webhook", slog.Int64("webhook_id", delivery.WebhookID), sl.Err(err))
+				continue
+			}
+			webhooks[webhook.ID] = webhook
+		}
+
+		delivery = d.attempt(ctx, webhook, delivery)
+
+		if err := d.store.UpdateWebhookDelivery(delivery); err != nil {
+			log.Error("failed to update delivery", slog.Int64("delivery_id", delivery.ID), sl.Err(err))
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) models.WebhookDelivery {
+	log := d.log.With(
+		slog.Int64("webhook_id", webhook.ID),
+		slog.Int64("delivery_id", delivery.ID),
+	)
+
+	delivery.Attempts++
+
+	statusCode, err := d.send(ctx, webhook, delivery)
+	delivery.LastStatusCode = statusCode
+
+	now := d.now()
+
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = now.Format(timeLayout)
+		log.Info("webhook delivered", slog.Int("status_code", statusCode))
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= d.opts.MaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		log.Warn("webhook delivery failed permanently", slog.Int("attempts", delivery.Attempts), sl.Err(err))
+		return delivery
+	}
+
+	delivery.NextAttemptAt = now.Add(Backoff(d.opts.BackoffBase, d.opts.BackoffMax, delivery.Attempts)).Format(timeLayout)
+	log.Warn("webhook delivery failed, will retry",
+		slog.Int("attempts", delivery.Attempts),
+		slog.String("next_attempt_at", delivery.NextAttemptAt),
+		sl.Err(err))
+
+	return delivery
+}
+
+func (d *Dispatcher) send(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "vlru-off-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the delay before the next attempt: base doubled for every
+// failed attempt and capped by max.
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return min(delay, max)
+}
+
+// Match reports whether the event passes the webhook filters and happened in the city of the webhook.
+// City-wide count events go only to webhooks without address or organization filters.
+func Match(webhook models.Webhook, e events.Event) bool {
+	if e.Blackout == nil {
+		if len(webhook.Buildings) > 0 || len(webhook.Streets) > 0 || len(webhook.Organizations) > 0 {
+			return false
+		}
+		return events.Filter{City: webhook.City, Types: webhook.Types}.Match(e)
+	}
+
+	filter := events.Filter{City: webhook.City, Types: webhook.Types, Streets: webhook.Streets}
+	if !filter.Match(e) {
+		return false
+	}
+
+	if len(webhook.Organizations) > 0 && !slices.ContainsFunc(webhook.Organizations, func(org string) bool {
+		return strings.EqualFold(org, e.Blackout.InitiatorName)
+	}) {
+		return false
+	}
+
+	if len(webhook.Buildings) == 0 {
+		return true
+	}
+
+	for _, address := range e.Blackout.Addresses {
+		if slices.Contains(webhook.Buildings, address.BuildingID) {
+			return true
+		}
+	}
+
+	return false
+}
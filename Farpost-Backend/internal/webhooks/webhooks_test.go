@@ -0,0 +1,283 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"vlru-prsch/internal/events"
+	"vlru-prsch/internal/models"
+	"vlru-prsch/internal/storage"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	signature := Sign("secret", "1710000000", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"valid", "secret", "1710000000", body, signature, true},
+		{"other secret", "other", "1710000000", body, signature, false},
+		{"other timestamp", "secret", "1710000001", body, signature, false},
+		{"other body", "secret", "1710000000", []byte(`{"id":2}`), signature, false},
+		{"bare hex", "secret", "1710000000", body, signature[len("sha256="):], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.body, tt.signature); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{5, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(time.Minute, 10*time.Minute, tt.attempts); got != tt.want {
+			t.Errorf("Backoff after %d attempts = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// store keeps webhooks and deliveries in memory, the way the SQLite storage
+// keeps them in its tables
+type store struct {
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+}
+
+func (s *store) GetWebhooks(filter models.Filter) ([]models.Webhook, error) {
+	return s.webhooks, nil
+}
+
+func (s *store) GetWebhook(id int64, filter models.Filter) (models.Webhook, error) {
+	for _, webhook := range s.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return models.Webhook{}, storage.ErrWebhookNotFound
+}
+
+func (s *store) SaveWebhookDelivery(delivery models.WebhookDelivery) (int64, error) {
+	delivery.ID = int64(len(s.deliveries) + 1)
+	s.deliveries = append(s.deliveries, delivery)
+	return delivery.ID, nil
+}
+
+func (s *store) UpdateWebhookDelivery(delivery models.WebhookDelivery) error {
+	s.deliveries[delivery.ID-1] = delivery
+	return nil
+}
+
+func (s *store) GetDueWebhookDeliveries(currentTime string, limit int) ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status == models.DeliveryPending && delivery.NextAttemptAt <= currentTime && len(due) < limit {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+// receiver is a webhook endpoint answering with the queued status codes, 200 once they run out
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+// setup returns a dispatcher over a store with one webhook pointing at the
+// receiver and one pending delivery
+func setup(t *testing.T, statuses ...int) (*Dispatcher, *store, *receiver, *time.Time) {
+	t.Helper()
+
+	rc := &receiver{statuses: statuses}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	s := &store{webhooks: []models.Webhook{{ID: 1, URL: server.URL, Secret: "secret", CreatedAt: "2024-03-10 12:00:00"}}}
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	d := New(slog.New(slog.DiscardHandler), s, events.NewHub(1, 1, 0), Options{
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		BackoffBase: time.Minute,
+		BackoffMax:  time.Hour,
+	})
+	d.now = func() time.Time { return now }
+
+	d.Enqueue(events.Event{ID: 7, Type: events.CountsChanged, Time: "2024-03-10 12:00:00", Counts: map[string]int64{"hot_water": 2}})
+
+	if len(s.deliveries) != 1 {
+		t.Fatalf("got %d deliveries enqueued, want 1", len(s.deliveries))
+	}
+
+	return d, s, rc, &now
+}
+
+func TestDeliverDueSigns(t *testing.T) {
+	d, s, rc, now := setup(t)
+
+	d.DeliverDue(context.Background())
+
+	if rc.count() != 1 {
+		t.Fatalf("got %d requests, want 1", rc.count())
+	}
+
+	r, body := rc.requests[0], rc.bodies[0]
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		HeaderEvent:     events.CountsChanged,
+		HeaderDelivery:  "1",
+		HeaderTimestamp: timestamp,
+	}
+	for name, want := range headers {
+		if got := r.Header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+	if !Verify("secret", timestamp, body, r.Header.Get(HeaderSignature)) {
+		t.Errorf("signature %q does not verify", r.Header.Get(HeaderSignature))
+	}
+
+	got := s.deliveries[0]
+	if got.Status != models.DeliverySucceeded || got.Attempts != 1 || got.LastStatusCode != http.StatusOK || got.DeliveredAt != "2024-03-10 12:00:00" {
+		t.Errorf("got delivery %+v, want succeeded on the first attempt", got)
+	}
+}
+
+func TestDeliverDueRetries(t *testing.T) {
+	d, s, rc, now := setup(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	steps := []struct {
+		name         string
+		advance      time.Duration
+		wantRequests int
+		wantStatus   string
+		wantAttempts int
+		wantNext     string
+	}{
+		{"first attempt fails", 0, 1, models.DeliveryPending, 1, "2024-03-10 12:01:00"},
+		{"not due before the backoff", 30 * time.Second, 1, models.DeliveryPending, 1, "2024-03-10 12:01:00"},
+		{"second attempt fails, the backoff doubles", 30 * time.Second, 2, models.DeliveryPending, 2, "2024-03-10 12:03:00"},
+		{"third attempt succeeds", 2 * time.Minute, 3, models.DeliverySucceeded, 3, "2024-03-10 12:03:00"},
+	}
+
+	for _, step := range steps {
+		*now = now.Add(step.advance)
+		d.DeliverDue(context.Background())
+
+		got := s.deliveries[0]
+		if rc.count() != step.wantRequests || got.Status != step.wantStatus || got.Attempts != step.wantAttempts || got.NextAttemptAt != step.wantNext {
+			t.Fatalf("%s: got %d requests and delivery %+v", step.name, rc.count(), got)
+		}
+	}
+}
+
+func TestDeliverDueGivesUp(t *testing.T) {
+	d, s, rc, now := setup(t, 500, 500, 500, 500)
+
+	for range 5 {
+		d.DeliverDue(context.Background())
+		*now = now.Add(time.Hour)
+	}
+
+	if rc.count() != 3 {
+		t.Errorf("got %d requests, want 3", rc.count())
+	}
+
+	got := s.deliveries[0]
+	if got.Status != models.DeliveryFailed || got.Attempts != 3 || got.LastStatusCode != 500 || got.LastError == "" {
+		t.Errorf("got delivery %+v, want failed after 3 attempts", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	blackout := events.Event{Type: events.BlackoutStarted, City: "vladivostok", Blackout: &events.Blackout{
+		Type:          "hot_water",
+		InitiatorName: "Водоканал",
+		Addresses:     []models.Address{{BuildingID: 10, Street: "Карбышева ул.", Number: "54"}},
+	}}
+	counts := events.Event{Type: events.CountsChanged, City: "vladivostok", Counts: map[string]int64{"hot_water": 1}}
+
+	tests := []struct {
+		name    string
+		webhook models.Webhook
+		event   events.Event
+		want    bool
+	}{
+		{"no filters", models.Webhook{}, blackout, true},
+		{"type", models.Webhook{Types: []string{"hot_water"}}, blackout, true},
+		{"other type", models.Webhook{Types: []string{"electricity"}}, blackout, false},
+		{"street", models.Webhook{Streets: []string{"Карбышева ул."}}, blackout, true},
+		{"organization in other case", models.Webhook{Organizations: []string{"водоканал"}}, blackout, true},
+		{"other organization", models.Webhook{Organizations: []string{"Дальэнерго"}}, blackout, false},
+		{"building", models.Webhook{Buildings: []int64{10}}, blackout, true},
+		{"other building", models.Webhook{Buildings: []int64{11}}, blackout, false},
+		{"city", models.Webhook{City: "vladivostok"}, blackout, true},
+		{"other city", models.Webhook{City: "khabarovsk"}, blackout, false},
+		{"counts without filters", models.Webhook{}, counts, true},
+		{"counts of the city", models.Webhook{City: "vladivostok", Types: []string{"hot_water"}}, counts, true},
+		{"counts of other city", models.Webhook{City: "khabarovsk"}, counts, false},
+		{"counts of the type", models.Webhook{Types: []string{"hot_water"}}, counts, true},
+		{"counts with an address filter", models.Webhook{Streets: []string{"Карбышева ул."}}, counts, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.webhook, tt.event); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}